@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"sort"
 	"strings"
 	"text/template"
@@ -17,9 +18,12 @@ import (
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/component"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
+	"github.com/thanos-io/thanos/pkg/prober"
+	"github.com/thanos-io/thanos/pkg/replicate"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/ui"
 	"github.com/thanos-io/thanos/pkg/verifier"
@@ -44,6 +48,8 @@ var (
 		verifier.IndexIssueID:                verifier.IndexIssue,
 		verifier.OverlappedBlocksIssueID:     verifier.OverlappedBlocksIssue,
 		verifier.DuplicatedCompactionIssueID: verifier.DuplicatedCompactionIssue,
+		verifier.ChunkIssueID:                verifier.ChunkIssue,
+		verifier.DownsampleCoverageIssueID:   verifier.DownsampleCoverageIssue,
 	}
 	allIssues = func() (s []string) {
 		for id := range issuesMap {
@@ -64,6 +70,8 @@ func registerBucket(m map[string]setupFunc, app *kingpin.Application, name strin
 	registerBucketLs(m, cmd, name, objStoreConfig)
 	registerBucketInspect(m, cmd, name, objStoreConfig)
 	registerBucketWeb(m, cmd, name, objStoreConfig)
+	registerBucketReplicate(m, cmd, name, objStoreConfig)
+	registerBucketMark(m, cmd, name, objStoreConfig)
 }
 
 func registerBucketVerify(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
@@ -157,6 +165,10 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 	cmd := root.Command("ls", "List all blocks in the bucket")
 	output := cmd.Flag("output", "Optional format in which to print each block's information. Options are 'json', 'wide' or a custom template.").
 		Short('o').Default("").String()
+	newerThan := cmd.Flag("newer-than", "Only list blocks created within the given duration, e.g. 24h. The listing of the bucket starts after older blocks. 0 lists all blocks.").
+		Default("0s").Duration()
+	limit := cmd.Flag("limit", "Maximum number of blocks to list. Blocks are listed in order of their creation, oldest first, and listing stops after the limit. 0 lists all blocks.").
+		Default("0").Int()
 	m[name+" ls"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
 		confContentYaml, err := objStoreConfig.Content()
 		if err != nil {
@@ -235,7 +247,15 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 			}
 		}
 
-		if err := bkt.Iter(ctx, "", func(name string) error {
+		iterCtx := ctx
+		if *newerThan > 0 {
+			iterCtx = objstore.WithIterStartAfter(iterCtx, ulid.MustNew(ulid.Timestamp(time.Now().Add(-*newerThan)), nil).String())
+		}
+		if *limit > 0 {
+			// Block directories sort before all other top-level directories.
+			iterCtx = objstore.WithIterMaxKeys(iterCtx, *limit)
+		}
+		if err := bkt.Iter(iterCtx, "", func(name string) error {
 			id, ok := block.IsBlockDir(name)
 			if !ok {
 				return nil
@@ -319,9 +339,8 @@ func registerBucketWeb(m map[string]setupFunc, root *kingpin.CmdClause, name str
 	m[name+" web"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
 		ctx, cancel := context.WithCancel(context.Background())
 
-		router := route.New()
 		bucketUI := ui.NewBucketUI(logger, *label)
-		bucketUI.Register(router, extpromhttp.NewInstrumentationMiddleware(reg))
+		router := bucketUIRouter(bucketUI, reg)
 
 		if *interval < 5*time.Minute {
 			level.Warn(logger).Log("msg", "Refreshing more often than 5m could lead to large data transfers")
@@ -357,6 +376,99 @@ func registerBucketWeb(m map[string]setupFunc, root *kingpin.CmdClause, name str
 	}
 }
 
+func registerBucketReplicate(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("replicate", "Replicate blocks missing in the target bucket from the bucket. Blocks are replicated once their upload finished and their meta.json is replicated last.")
+	objStoreToConfig := regCommonObjStoreFlags(cmd, "-to", true, "The object storage to replicate blocks to.")
+	propagateDeletions := cmd.Flag("propagate-deletions", "Delete blocks from the target bucket which were deleted from the bucket, e.g. by compaction or retention.").
+		Default("false").Bool()
+	maxDeletions := cmd.Flag("propagate-deletions.max-per-run", "Maximum number of blocks deleted from the target bucket by each replication run, oldest first. Remaining blocks are deleted by the next runs. 0 means no limit.").
+		Default("100").Int()
+	minDeletionAge := modelDuration(cmd.Flag("propagate-deletions.min-block-age", "Minimum age of blocks, based on their ULID, to be deleted from the target bucket, so replicas of recent blocks are not deleted due to an inconsistent listing of the bucket.").
+		Default("24h"))
+	wait := cmd.Flag("wait", "Do not exit after replicating all blocks, but keep replicating new blocks every wait-interval. Replication metrics are served on http-address.").
+		Default("false").Bool()
+	waitInterval := cmd.Flag("wait-interval", "Wait interval between consecutive replication runs. Only works when --wait flag specified.").
+		Default("5m").Duration()
+	httpBindAddr := regHTTPAddrFlag(cmd)
+
+	m[name+" replicate"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		fromBkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		toConfContentYaml, err := objStoreToConfig.Content()
+		if err != nil {
+			runutil.CloseWithLogOnErr(logger, fromBkt, "bucket client")
+			return err
+		}
+
+		// nil Prometheus registerer: don't create conflicting metrics.
+		toBkt, err := client.NewBucket(logger, toConfContentYaml, nil, name)
+		if err != nil {
+			runutil.CloseWithLogOnErr(logger, fromBkt, "bucket client")
+			return err
+		}
+
+		r := replicate.New(logger, reg, fromBkt, toBkt, replicate.DeletionConfig{
+			Propagate:   *propagateDeletions,
+			MaxPerRun:   *maxDeletions,
+			MinBlockAge: time.Duration(*minDeletionAge),
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if !*wait {
+			g.Add(func() error {
+				defer runutil.CloseWithLogOnErr(logger, fromBkt, "bucket client")
+				defer runutil.CloseWithLogOnErr(logger, toBkt, "target bucket client")
+
+				return errors.Wrap(r.Run(ctx), "replicate")
+			}, func(error) {
+				cancel()
+			})
+			return nil
+		}
+
+		statusProber := prober.NewProber(component.Bucket, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+		// Initiate HTTP listener providing metrics endpoint and readiness/liveness probes.
+		if err := scheduleHTTPServer(g, logger, reg, statusProber, *httpBindAddr, nil, component.Bucket); err != nil {
+			cancel()
+			return errors.Wrap(err, "schedule HTTP server with probes")
+		}
+
+		g.Add(func() error {
+			defer runutil.CloseWithLogOnErr(logger, fromBkt, "bucket client")
+			defer runutil.CloseWithLogOnErr(logger, toBkt, "target bucket client")
+
+			statusProber.SetReady()
+			return runutil.Repeat(*waitInterval, ctx.Done(), func() error {
+				// Failed runs are retried in the next interval, so transient errors of either bucket do not stop
+				// the replication.
+				if err := r.Run(ctx); err != nil {
+					level.Error(logger).Log("msg", "replication failed; retrying in the next interval", "err", err)
+				}
+				return nil
+			})
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	}
+}
+
+// bucketUIRouter returns the router serving the bucket UI, shared by the bucket web command, the compactor and the
+// store gateway.
+func bucketUIRouter(bucketUI *ui.Bucket, reg *prometheus.Registry) *route.Router {
+	router := route.New()
+	bucketUI.Register(router, extpromhttp.NewInstrumentationMiddleware(reg))
+	return router
+}
+
 // refresh metadata from remote storage periodically and update UI.
 func refresh(ctx context.Context, logger log.Logger, bucketUI *ui.Bucket, duration time.Duration, timeout time.Duration, name string, reg *prometheus.Registry, objStoreConfig *extflag.PathOrContent) error {
 	confContentYaml, err := objStoreConfig.Content()
@@ -377,16 +489,10 @@ func refresh(ctx context.Context, logger log.Logger, bucketUI *ui.Bucket, durati
 
 			blocks, err := download(iterCtx, logger, bkt)
 			if err != nil {
-				bucketUI.Set("[]", err)
-				return err
-			}
-
-			data, err := json.Marshal(blocks)
-			if err != nil {
-				bucketUI.Set("[]", err)
+				bucketUI.SetBlocks(nil, err)
 				return err
 			}
-			bucketUI.Set(string(data), nil)
+			bucketUI.SetBlocks(blocks, nil)
 			return nil
 		})
 	})
@@ -539,3 +645,52 @@ func compare(s1, s2 string) bool {
 	}
 	return s1Time.Before(s2Time)
 }
+
+func registerBucketMark(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("mark", "Mark blocks for no compaction. Marked blocks are neither compacted nor downsampled, but still queried and deleted by retention.")
+	ids := cmd.Flag("id", "ID of the block to mark (repeated).").Required().Strings()
+	details := cmd.Flag("details", "Human readable details of the reason to mark the blocks, stored in their marker.").Required().String()
+	m[name+" mark"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
+		blockIDs := make([]ulid.ULID, 0, len(*ids))
+		for _, id := range *ids {
+			u, err := ulid.Parse(id)
+			if err != nil {
+				return errors.Errorf("invalid block ID %q", id)
+			}
+			blockIDs = append(blockIDs, u)
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		for _, id := range blockIDs {
+			ok, err := bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+			if err != nil {
+				return errors.Wrapf(err, "check block %s", id)
+			}
+			if !ok {
+				return errors.Errorf("block %s not found", id)
+			}
+			if err := block.MarkForNoCompact(ctx, logger, bkt, id, metadata.ManualNoCompactReason, *details); err != nil {
+				return errors.Wrapf(err, "mark block %s", id)
+			}
+		}
+		level.Info(logger).Log("msg", "marking done", "blocks", len(blockIDs))
+		return nil
+	}
+}
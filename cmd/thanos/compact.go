@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/thanos-io/thanos/pkg/extflag"
@@ -18,16 +24,21 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/compact"
 	"github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/ui"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -41,6 +52,55 @@ var (
 	}
 )
 
+const (
+	compactPhaseGC         = "gc"
+	compactPhaseCompact    = "compact"
+	compactPhaseDownsample = "downsample"
+	compactPhaseRetention  = "retention"
+	compactPhaseCleanup    = "cleanup"
+)
+
+var compactPhases = []string{compactPhaseGC, compactPhaseCompact, compactPhaseDownsample, compactPhaseRetention, compactPhaseCleanup}
+
+// defaultCompactPhases are the phases run unless given by flags. Cleanup of orphaned objects is optional.
+var defaultCompactPhases = []string{compactPhaseGC, compactPhaseCompact, compactPhaseDownsample, compactPhaseRetention}
+
+// compactSources are the sources of uploaded blocks which can be used to filter blocks to compact.
+var compactSources = []string{
+	string(metadata.SidecarSource),
+	string(metadata.ReceiveSource),
+	string(metadata.RulerSource),
+	string(metadata.BucketRepairSource),
+}
+
+// isCompactDownsamplePair returns true if the phases are the compact and downsample phases, in any order.
+func isCompactDownsamplePair(a, b string) bool {
+	return (a == compactPhaseCompact && b == compactPhaseDownsample) || (a == compactPhaseDownsample && b == compactPhaseCompact)
+}
+
+// runConcurrently runs both functions at the same time and returns the first error of them, once both are done.
+func runConcurrently(f1, f2 func() error) error {
+	errc := make(chan error, 1)
+	go func() { errc <- f2() }()
+	err := f1()
+	if err2 := <-errc; err == nil {
+		err = err2
+	}
+	return err
+}
+
+// validateCompactPhases checks that each of the requested phases is given at most once.
+func validateCompactPhases(phases []string) error {
+	seen := map[string]struct{}{}
+	for _, p := range phases {
+		if _, ok := seen[p]; ok {
+			return errors.Errorf("phase %q specified more than once", p)
+		}
+		seen[p] = struct{}{}
+	}
+	return nil
+}
+
 type compactionSet []time.Duration
 
 func (cs compactionSet) String() string {
@@ -85,7 +145,7 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
 
-	consistencyDelay := modelDuration(cmd.Flag("consistency-delay", fmt.Sprintf("Minimum age of fresh (non-compacted) blocks before they are being processed. Malformed blocks older than the maximum of consistency-delay and %s will be removed.", compact.MinimumAgeForRemoval)).
+	consistencyDelay := modelDuration(cmd.Flag("consistency-delay", fmt.Sprintf("Minimum age of fresh (non-compacted) blocks before they are being processed, so blocks of eventually consistent object stores are not considered while they may look partially uploaded. Malformed blocks and orphaned objects older than the maximum of consistency-delay and %s will be removed.", compact.MinimumAgeForRemoval)).
 		Default("30m"))
 
 	retentionRaw := modelDuration(cmd.Flag("retention.resolution-raw", "How long to retain raw samples in bucket. 0d - disables this retention").Default("0d"))
@@ -95,6 +155,18 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 	wait := cmd.Flag("wait", "Do not exit after all compactions have been processed and wait for new work.").
 		Short('w').Bool()
 
+	waitInterval := modelDuration(cmd.Flag("wait-interval", "Interval between the start of consecutive iterations of the compactor if --wait is given, greater than 0. An iteration taking longer starts the next one right after.").
+		Default("5m"))
+
+	startJitter := modelDuration(cmd.Flag("compact.start-jitter", "Maximum random delay before the first iteration, so compactors of multiple buckets started at the same time do not all hammer object storage at once. 0s disables the delay.").
+		Default("0s"))
+
+	uploadReports := cmd.Flag("compact.upload-reports", "Upload a JSON report of each iteration, with the groups compacted, blocks compacted, downsampled and deleted, bytes transferred, durations and errors of the phases, to the "+compactReportsDir+"/ directory of the bucket, as audit trail. The report of the last iteration is always served on /api/v1/compaction/report.").
+		Default("false").Bool()
+
+	reportRetention := modelDuration(cmd.Flag("compact.report-retention", "How long to retain uploaded iteration reports in the bucket. Older reports of all compactors of the bucket are deleted after each upload. 0d - disables this retention").
+		Default("30d"))
+
 	generateMissingIndexCacheFiles := cmd.Flag("index.generate-missing-cache-file", "If enabled, on startup compactor runs an on-off job that scans all the blocks to find all blocks with missing index cache file. It generates those if needed and upload.").
 		Hidden().Default("false").Bool()
 
@@ -102,26 +174,153 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 		"as querying long time ranges without non-downsampled data is not efficient and useful e.g it is not possible to render all samples for a human eye anyway").
 		Default("false").Bool()
 
+	dropCounterMinMax := regDropCounterMinMaxFlag(cmd)
+	noDownsampleReasons := regNoDownsampleReasonsFlag(cmd)
+
 	maxCompactionLevel := cmd.Flag("debug.max-compaction-level", fmt.Sprintf("Maximum compaction level, default is %d: %s", compactions.maxLevel(), compactions.String())).
 		Hidden().Default(strconv.Itoa(compactions.maxLevel())).Int()
 
 	blockSyncConcurrency := cmd.Flag("block-sync-concurrency", "Number of goroutines to use when syncing block metadata from object storage.").
 		Default("20").Int()
 
-	compactionConcurrency := cmd.Flag("compact.concurrency", "Number of goroutines to use when compacting groups.").
+	compactionConcurrency := cmd.Flag("compact.concurrency", "Number of goroutines to use when compacting groups. Blocks of as many other groups are downloaded and compacted blocks uploaded while groups are compacted.").
+		Default("1").Int()
+
+	downloadConcurrency := cmd.Flag("compact.download-concurrency", "Number of blocks downloaded at the same time by each of the goroutines compacting groups. Downloaded blocks are verified while the rest of the planned blocks are downloaded.").
 		Default("1").Int()
 
+	downloadRateLimit := cmd.Flag("compact.download-rate-limit", "Maximum rate of bytes per second downloaded from object storage, shared by all downloads of the compactor, e.g. of blocks to compact or downsample, so the compactor does not saturate the network link and starve query traffic against the same object storage. 0 means no limit.").
+		Default("0B").Bytes()
+
+	uploadRateLimit := cmd.Flag("compact.upload-rate-limit", "Maximum rate of bytes per second uploaded to object storage, shared by all uploads of the compactor, e.g. of compacted and downsampled blocks. 0 means no limit.").
+		Default("0B").Bytes()
+
+	bucketWebLabel := cmd.Flag("bucket-web-label", "Prometheus label to use as timeline title in the bucket web UI of blocks synced for compaction, served on http-address.").String()
+
+	groupDirQuota := cmd.Flag("compact.group-dir-quota", "Maximum disk space used by compaction of a single group in its work directory within data-dir, including downloaded and compacted blocks. Compaction of a group which would exceed it is skipped, so a single large group cannot fill up the disk for other groups compacted concurrently. 0 means no limit.").
+		Default("0B").Bytes()
+
+	maxBlockSize := cmd.Flag("compact.max-block-size", "Maximum estimated size of blocks created by compaction, e.g. to keep the index of highly compacted blocks within size limits. Compactions exceeding it compact fewer blocks, so blocks of a compaction range are compacted into multiple blocks. The size is estimated from the number of samples and series of the compacted blocks. 0 means no limit.").
+		Default("0B").Bytes()
+
+	maxBlockSeries := cmd.Flag("compact.max-block-series", "Maximum number of series of blocks created by compaction, counted as the sum of series of the compacted blocks. Compactions exceeding it compact fewer blocks. 0 means no limit.").
+		Default("0").Int64()
+
+	maxBlockSamples := cmd.Flag("compact.max-block-samples", "Maximum number of samples of blocks created by compaction. Compactions exceeding it compact fewer blocks. 0 means no limit.").
+		Default("0").Int64()
+
+	outputShards := cmd.Flag("compact.output-shards", "Number of shards of series compacted blocks are split into by series hash, so store gateways load smaller index files and query the shards of huge blocks concurrently. The shard is recorded in the meta of each block. Blocks of a shard are compacted with blocks of the same shard only. 0 and 1 disable splitting.").
+		Default("0").Uint64()
+
+	verifyCompactedBlocks := cmd.Flag("compact.verify-compacted-blocks", "Read all series, chunks and samples of compacted blocks before uploading them and halt if they are not ordered or do not match the stats of the block or the samples of the compacted blocks. Slows down compaction, as compacted blocks are read once more.").
+		Default("false").Bool()
+
+	diskSpaceFactor := cmd.Flag("compact.disk-space-safety-factor", "Before downloading the blocks of a compaction, their size estimated from the number of samples and series is multiplied by this factor and compared with the free disk space of data-dir. Compaction of a group which does not fit is skipped, instead of failing halfway through when the disk is full. The default accounts for the compacted block being as large as the downloaded blocks. 0 disables the check.").
+		Default("2").Float64()
+
+	enableVerticalCompaction := cmd.Flag("compact.enable-vertical-compaction", "Merge overlapping blocks of a compaction group into one block, deduplicating identical samples, instead of halting. Overlaps are expected e.g. after backfilling blocks or uploading blocks of the same external labels from multiple sources. NOTE: Only one of samples with the same timestamp is kept, even if their values differ.").
+		Default("false").Bool()
+
+	haltBackoff := modelDuration(cmd.Flag("compact.halt-backoff", "Time after which the compactor or a group halted on a critical error is unhalted automatically, doubled for each further consecutive halt up to --compact.halt-max-backoff, so transient issues, e.g. a block deleted while it was downloaded, heal without restarts. The backoff is reset by a successful iteration, or a successful compaction of the group. 0s disables automatic unhalts, so the compactor and groups stay halted until unhalted by a POST request to /api/v1/compaction/unhalt.").
+		Default("0s"))
+
+	haltMaxBackoff := modelDuration(cmd.Flag("compact.halt-max-backoff", "Maximum time after which the compactor or a group halted on a critical error is unhalted automatically, if --compact.halt-backoff is set.").
+		Default("6h"))
+
+	repairOutOfOrderChunks := cmd.Flag("compact.repair-out-of-order-chunks", "Repair blocks with out-of-order or duplicated chunks and chunks completely outside of their time range instead of halting the compaction of their group. Repaired blocks replace the broken blocks in the bucket, like repaired by thanos bucket verify --repair. Blocks with overlapping chunks of different data cannot be repaired and still halt the compaction of their group.").
+		Default("false").Bool()
+
+	var groupOrders []string
+	for _, o := range compact.GroupOrders {
+		groupOrders = append(groupOrders, string(o))
+	}
+	groupOrder := cmd.Flag("compact.group-order", fmt.Sprintf("Order in which compaction groups are compacted. %s compacts groups with the most blocks not compacted yet first, so the most delayed groups catch up first e.g. after an outage. %s compacts groups in order of their keys. %s compacts groups with the oldest data not compacted yet first. %s compacts groups with the most blocks first. %s compacts groups with the smallest estimated size first, so as many groups as possible catch up quickly. Possible values: %s.", compact.GroupOrderBacklog, compact.GroupOrderKey, compact.GroupOrderOldest, compact.GroupOrderBlocks, compact.GroupOrderSmallest, strings.Join(groupOrders, ", "))).
+		Default(string(compact.GroupOrderBacklog)).Enum(groupOrders...)
+
+	phases := cmd.Flag("compact.phase", fmt.Sprintf("Phase to run in each iteration of the compactor, in the given order. Repeat the flag to run multiple phases. Only the given phases are run. Possible values: %s. The %s phase deletes blocks already compacted into other blocks, without it they are only excluded from compaction. The %s phase removes auxiliary objects without a corresponding block, e.g. stale index cache files and debug meta files of deleted blocks, it is not run by default.", strings.Join(compactPhases, ", "), compactPhaseGC, compactPhaseCleanup)).
+		Default(defaultCompactPhases...).Enums(compactPhases...)
+
+	gcMaxDeletions := cmd.Flag("compact.gc-max-deletions", "Maximum number of outdated blocks deleted by each garbage collection of compacted blocks, to limit the impact of unexpected garbage collections, e.g. after a bug in a block producer. Remaining blocks are deleted by the next garbage collections and are not compacted until then. 0 means no limit.").
+		Default("0").Int()
+
+	partialDeleteDelays := cmd.Flag("compact.partial-delete-delays", "Number of consistency delays after which partial blocks, whose meta file cannot be decoded for longer than consistency-delay and which are therefore quarantined by marking them for no compaction, are deleted from the bucket. 0 disables deletion of quarantined blocks.").
+		Default("0").Int()
+
+	metaCache := cmd.Flag("compact.meta-cache", "Cache meta files of blocks in the meta-syncer directory of data-dir, so they are not downloaded from the bucket again after restarts. Cached metas of blocks deleted from the bucket are removed on each sync.").
+		Default("true").Bool()
+
+	var blockDiscoveries []string
+	for _, d := range compact.BlockDiscoveries {
+		blockDiscoveries = append(blockDiscoveries, string(d))
+	}
+	blockDiscovery := cmd.Flag("compact.block-discovery", fmt.Sprintf("Strategy to discover the blocks of the bucket with on each sync. %s lists the block directories of the bucket and downloads their meta files concurrently while listing. %s lists all objects of the bucket recursively first, which performs better with object storages listing directories slowly, e.g. with prefixed layouts, and downloads meta files of blocks listed with one afterwards. Possible values: %s.", compact.BlockDiscoveryConcurrent, compact.BlockDiscoveryRecursive, strings.Join(blockDiscoveries, ", "))).
+		Default(string(compact.BlockDiscoveryConcurrent)).Enum(blockDiscoveries...)
+
+	concurrentDownsampling := cmd.Flag("compact.concurrent-downsampling", fmt.Sprintf("Run the %s and %s phases concurrently if they are adjacent in the given phases, instead of one after the other. A group is not compacted while its blocks are downsampled and vice versa, unrelated groups are compacted and downsampled at the same time.", compactPhaseCompact, compactPhaseDownsample)).
+		Default("false").Bool()
+
+	cleanupDryRun := cmd.Flag("compact.cleanup-dry-run", "Only log orphaned objects found by the cleanup phase instead of removing them.").
+		Default("false").Bool()
+
+	sourceFilter := cmd.Flag("compact.source-filter", fmt.Sprintf("Only compact and downsample blocks produced by the given source, e.g. to run a dedicated compactor for blocks of Thanos Receive. Repeat the flag to allow multiple sources. Blocks created by the compactor are processed if the blocks they were created from were produced by the given sources, as recorded in their meta. Other blocks are left untouched. All blocks are processed if not given. Possible values: %s.", strings.Join(compactSources, ", "))).
+		Enums(compactSources...)
+
+	minTime := model.TimeOrDuration(cmd.Flag("min-time", "Start of time window of blocks to compact and downsample. Thanos Compact will only process blocks starting at or after this value, e.g. to shard multiple compactors over a single bucket by time. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("0000-01-01T00:00:00Z"))
+
+	maxTime := model.TimeOrDuration(cmd.Flag("max-time", "End of time window of blocks to compact and downsample. Thanos Compact will only process blocks starting before this value. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("9999-12-31T23:59:59Z"))
+
 	selectorRelabelConf := regSelectorRelabelFlags(cmd)
 
+	selectorLabels := cmd.Flag("selector.label", "External label blocks must have for this compactor to compact and downsample them, e.g. to shard multiple compactors over a single bucket by external labels (repeated). Blocks must match all given label names, with any of the values given for the same name. The label sets owned by this compactor are served on /status/shard. All blocks are selected if not given.").
+		PlaceHolder("<name>=\"<value>\"").Strings()
+
+	gcConf := regGCFlags(cmd)
+
 	m[component.Compact.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ bool) error {
+		if err := validateCompactPhases(*phases); err != nil {
+			return errors.Wrap(err, "invalid argument: --compact.phase")
+		}
+
+		if *waitInterval <= 0 {
+			return errors.Errorf("invalid argument: --wait-interval must be greater than 0, got %s", *waitInterval)
+		}
+
+		gcc, err := gcConf()
+		if err != nil {
+			return err
+		}
+
+		if minTime.PrometheusTimestamp() > maxTime.PrometheusTimestamp() {
+			return errors.Errorf("invalid argument: --min-time '%s' can't be greater than --max-time '%s'",
+				minTime, maxTime)
+		}
+
+		selector, err := parseFlagLabels(*selectorLabels)
+		if err != nil {
+			return errors.Wrap(err, "parse selector labels")
+		}
+
+		var sources []metadata.SourceType
+		for _, s := range *sourceFilter {
+			sources = append(sources, metadata.SourceType(s))
+		}
+
 		return runCompact(g, logger, reg,
 			*httpAddr,
 			*dataDir,
 			objStoreConfig,
 			time.Duration(*consistencyDelay),
 			*haltOnError,
+			time.Duration(*haltBackoff),
+			time.Duration(*haltMaxBackoff),
 			*acceptMalformedIndex,
 			*wait,
+			time.Duration(*waitInterval),
+			time.Duration(*startJitter),
+			*uploadReports,
+			time.Duration(*reportRetention),
 			*generateMissingIndexCacheFiles,
 			map[compact.ResolutionLevel]time.Duration{
 				compact.ResolutionLevelRaw: time.Duration(*retentionRaw),
@@ -130,10 +329,38 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 			},
 			component.Compact,
 			*disableDownsampling,
+			*dropCounterMinMax,
+			noCompactReasons(*noDownsampleReasons),
 			*maxCompactionLevel,
 			*blockSyncConcurrency,
 			*compactionConcurrency,
+			*downloadConcurrency,
+			int64(*groupDirQuota),
+			compact.CompactionLimits{
+				MaxBlockBytes: int64(*maxBlockSize),
+				MaxSeries:     *maxBlockSeries,
+				MaxSamples:    *maxBlockSamples,
+			},
+			*outputShards,
+			*verifyCompactedBlocks,
+			*diskSpaceFactor,
+			objstore.RateLimits{Download: int64(*downloadRateLimit), Upload: int64(*uploadRateLimit)},
+			compact.GroupOrder(*groupOrder),
+			*enableVerticalCompaction,
+			*bucketWebLabel,
+			*gcMaxDeletions,
+			*partialDeleteDelays,
+			*metaCache,
+			compact.BlockDiscovery(*blockDiscovery),
+			*repairOutOfOrderChunks,
+			*phases,
+			*concurrentDownsampling,
+			*cleanupDryRun,
+			sources,
+			&compact.TimeFilter{MinTime: *minTime, MaxTime: *maxTime},
 			selectorRelabelConf,
+			selector,
+			gcc,
 		)
 	}
 }
@@ -147,35 +374,71 @@ func runCompact(
 	objStoreConfig *extflag.PathOrContent,
 	consistencyDelay time.Duration,
 	haltOnError bool,
+	haltBackoff time.Duration,
+	haltMaxBackoff time.Duration,
 	acceptMalformedIndex bool,
 	wait bool,
+	waitInterval time.Duration,
+	startJitter time.Duration,
+	uploadReports bool,
+	reportRetention time.Duration,
 	generateMissingIndexCacheFiles bool,
 	retentionByResolution map[compact.ResolutionLevel]time.Duration,
 	component component.Component,
 	disableDownsampling bool,
+	dropCounterMinMax bool,
+	noDownsampleReasons []metadata.NoCompactReason,
 	maxCompactionLevel int,
 	blockSyncConcurrency int,
 	concurrency int,
+	downloadConcurrency int,
+	groupDirQuota int64,
+	limits compact.CompactionLimits,
+	outputShards uint64,
+	verifyCompactedBlocks bool,
+	diskSpaceFactor float64,
+	rateLimits objstore.RateLimits,
+	groupOrder compact.GroupOrder,
+	enableVerticalCompaction bool,
+	bucketWebLabel string,
+	gcMaxDeletions int,
+	partialDeleteDelays int,
+	metaCache bool,
+	blockDiscovery compact.BlockDiscovery,
+	repairOutOfOrderChunks bool,
+	phases []string,
+	concurrentDownsampling bool,
+	cleanupDryRun bool,
+	sources []metadata.SourceType,
+	timeFilter *compact.TimeFilter,
 	selectorRelabelConf *extflag.PathOrContent,
+	selector labels.Labels,
+	gcConf gcConfig,
 ) error {
-	halted := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "thanos_compactor_halted",
-		Help: "Set to 1 if the compactor halted due to an unexpected error",
-	})
 	retried := prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "thanos_compactor_retries_total",
 		Help: "Total number of retries after retriable compactor error",
 	})
-	halted.Set(0)
-
-	reg.MustRegister(halted)
+	orphanedObjectsRemoved := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_compactor_orphaned_objects_removed_total",
+		Help: "Total number of orphaned objects and block directories removed by the cleanup phase.",
+	})
 	reg.MustRegister(retried)
+	reg.MustRegister(orphanedObjectsRemoved)
 
 	downsampleMetrics := newDownsampleMetrics(reg)
 
+	gcTuner := newGCTuner(logger, reg, gcConf)
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/gc", gcTuner.handler())
+
+	bucketUI := ui.NewBucketUI(logger, bucketWebLabel)
+	mux.Handle("/", bucketUIRouter(bucketUI, reg))
+
 	statusProber := prober.NewProber(component, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	// Initiate HTTP listener providing metrics endpoint and readiness/liveness probes.
-	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, nil, component); err != nil {
+	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, mux, component); err != nil {
 		return errors.Wrap(err, "schedule HTTP server with probes")
 	}
 
@@ -188,6 +451,10 @@ func runCompact(
 	if err != nil {
 		return err
 	}
+	transferredBytes := objstore.TransferredBytes(bkt)
+	if rateLimits.Download > 0 || rateLimits.Upload > 0 {
+		bkt = objstore.BucketWithRateLimits(bkt, rateLimits, reg)
+	}
 
 	relabelContentYaml, err := selectorRelabelConf.Content()
 	if err != nil {
@@ -199,6 +466,12 @@ func runCompact(
 		return err
 	}
 
+	partitioned := len(selector) > 0 || len(relabelConfig) > 0 || len(sources) > 0 ||
+		timeFilter.MinTime.PrometheusTimestamp() > 0 || timeFilter.MaxTime.PrometheusTimestamp() < timestamp.FromTime(time.Now())
+	if err := validateRetention(logger, retentionByResolution, phases, disableDownsampling, partitioned); err != nil {
+		return err
+	}
+
 	// Ensure we close up everything properly.
 	defer func() {
 		if err != nil {
@@ -206,11 +479,35 @@ func runCompact(
 		}
 	}()
 
+	garbageCollectedBlocks := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_compact_garbage_collected_blocks_total",
+		Help: "Total number of deleted blocks by compactor.",
+	})
+	reg.MustRegister(garbageCollectedBlocks)
+
+	var metaCacheDir string
+	if metaCache {
+		metaCacheDir = path.Join(dataDir, "meta-syncer")
+	}
 	sy, err := compact.NewSyncer(logger, reg, bkt, consistencyDelay,
-		blockSyncConcurrency, acceptMalformedIndex, relabelConfig)
+		blockSyncConcurrency, relabelConfig, selector, sources, timeFilter, garbageCollectedBlocks, gcMaxDeletions, partialDeleteDelays, metaCacheDir, blockDiscovery)
 	if err != nil {
 		return errors.Wrap(err, "create syncer")
 	}
+	if err := sy.SetGroupHaltOptions(compact.GroupHaltOptions{
+		Path:       filepath.Join(dataDir, "halted-groups.json"),
+		Backoff:    haltBackoff,
+		MaxBackoff: haltMaxBackoff,
+	}); err != nil {
+		return errors.Wrap(err, "restore halted groups")
+	}
+	mux.Handle("/lineage", lineageHandler(sy))
+	mux.Handle("/status/shard", shardHandler(sy))
+	mux.Handle("/status/partial", partialHandler(sy))
+	haltStatus := newHaltStatus(logger, reg, sy.HaltedGroups, filepath.Join(dataDir, "halt.json"), haltBackoff, haltMaxBackoff)
+	mux.Handle("/status/halt", haltStatus)
+	mux.Handle("/api/v1/compaction/unhalt", unhaltHandler(haltStatus, sy.UnhaltGroups))
+	grouper := compact.NewDefaultGrouper(logger, bkt, acceptMalformedIndex, enableVerticalCompaction, reg, garbageCollectedBlocks, repairOutOfOrderChunks)
 
 	levels, err := compactions.levels(maxCompactionLevel)
 	if err != nil {
@@ -241,11 +538,34 @@ func runCompact(
 		return errors.Wrap(err, "clean working downsample directory")
 	}
 
-	compactor, err := compact.NewBucketCompactor(logger, sy, comp, compactDir, bkt, concurrency)
+	// Group locks are only needed if groups are compacted and downsampled at the same time.
+	var locks *compact.GroupLocks
+	if concurrentDownsampling {
+		locks = compact.NewGroupLocks()
+	}
+
+	compactor, err := compact.NewBucketCompactor(logger, sy, grouper, comp, compactDir, bkt, reg, compact.BucketCompactorOptions{
+		Concurrency:         concurrency,
+		DownloadConcurrency: downloadConcurrency,
+		GroupQuota:          groupDirQuota,
+		GroupOrder:          groupOrder,
+		Ranges:              levels,
+		Locks:               locks,
+		Limits:              limits,
+		Shards:              outputShards,
+		Verify:              verifyCompactedBlocks,
+		DiskSpaceFactor:     diskSpaceFactor,
+	})
 	if err != nil {
 		cancel()
 		return errors.Wrap(err, "create bucket compactor")
 	}
+	mux.Handle("/api/v1/blocks", blocksHandler(sy))
+	pause := newPauseStatus(reg)
+	mux.Handle("/api/v1/compaction/status", compactionStatusHandler(sy, compactor, haltStatus, pause))
+	mux.Handle("/api/v1/compaction/progress", progressHandler(compactor))
+	mux.Handle("/api/v1/compaction/pause", pauseHandler(pause))
+	mux.Handle("/api/v1/compaction/resume", resumeHandler(pause))
 
 	if retentionByResolution[compact.ResolutionLevelRaw].Seconds() != 0 {
 		level.Info(logger).Log("msg", "retention policy of raw samples is enabled", "duration", retentionByResolution[compact.ResolutionLevelRaw])
@@ -257,35 +577,104 @@ func runCompact(
 		level.Info(logger).Log("msg", "retention policy of 1 hour aggregated samples is enabled", "duration", retentionByResolution[compact.ResolutionLevel1h])
 	}
 
-	f := func() error {
-		if err := compactor.Compact(ctx); err != nil {
-			return errors.Wrap(err, "compaction failed")
-		}
-		level.Info(logger).Log("msg", "compaction iterations done")
+	runPhase := map[string]func() error{
+		compactPhaseGC: func() error {
+			if err := sy.SyncMetas(ctx); err != nil {
+				return errors.Wrap(err, "sync before garbage collection")
+			}
+			if err := sy.GarbageCollect(ctx); err != nil {
+				return errors.Wrap(err, "garbage collection failed")
+			}
+			return nil
+		},
+		compactPhaseCompact: func() error {
+			err := compactor.Compact(ctx)
+			bucketUI.SetBlocks(syncedMetas(sy), err)
+			bucketUI.SetBlockStates(blockStates(sy))
+			if err != nil {
+				return errors.Wrap(err, "compaction failed")
+			}
+			level.Info(logger).Log("msg", "compaction iterations done")
+			return nil
+		},
+		compactPhaseDownsample: func() error {
+			// TODO(bplotka): Remove "disableDownsampling" once https://github.com/thanos-io/thanos/issues/297 is fixed.
+			if disableDownsampling {
+				level.Warn(logger).Log("msg", "downsampling was explicitly disabled")
+				return nil
+			}
 
-		// TODO(bplotka): Remove "disableDownsampling" once https://github.com/thanos-io/thanos/issues/297 is fixed.
-		if !disableDownsampling {
 			// After all compactions are done, work down the downsampling backlog.
 			// We run two passes of this to ensure that the 1h downsampling is generated
 			// for 5m downsamplings created in the first run.
 			level.Info(logger).Log("msg", "start first pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, downsamplingDir); err != nil {
+			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, downsamplingDir, relabelConfig, selector, sources, timeFilter, locks, consistencyDelay, dropCounterMinMax, noDownsampleReasons); err != nil {
 				return errors.Wrap(err, "first pass of downsampling failed")
 			}
 
 			level.Info(logger).Log("msg", "start second pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, downsamplingDir); err != nil {
+			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, downsamplingDir, relabelConfig, selector, sources, timeFilter, locks, consistencyDelay, dropCounterMinMax, noDownsampleReasons); err != nil {
 				return errors.Wrap(err, "second pass of downsampling failed")
 			}
 			level.Info(logger).Log("msg", "downsampling iterations done")
-		} else {
-			level.Warn(logger).Log("msg", "downsampling was explicitly disabled")
-		}
+			return nil
+		},
+		compactPhaseRetention: func() error {
+			if err := compact.ApplyRetentionPolicyByResolution(ctx, logger, bkt, retentionByResolution); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("retention failed"))
+			}
+			return nil
+		},
+		compactPhaseCleanup: func() error {
+			orphaned, err := compact.CleanOrphanedObjects(ctx, logger, bkt, cleanupDryRun, consistencyDelay)
+			if !cleanupDryRun {
+				orphanedObjectsRemoved.Add(float64(len(orphaned)))
+			}
+			if err != nil {
+				return errors.Wrap(err, "cleanup of orphaned objects failed")
+			}
+			return nil
+		},
+	}
+
+	groupCounters := grouper.Counters()
+	counters := iterationCounters{
+		groupRunsStarted:       groupCounters.RunsStarted,
+		compactions:            groupCounters.Compactions,
+		compactionFailures:     groupCounters.Failures,
+		downsamples:            downsampleMetrics.downsamples,
+		downsampleFailures:     downsampleMetrics.downsampleFailures,
+		garbageCollectedBlocks: garbageCollectedBlocks,
+		orphanedObjectsRemoved: orphanedObjectsRemoved,
+	}
+	if transferredBytes != nil {
+		counters.transferredBytes = transferredBytes
+	}
+	reporter := newIterationReporter(logger, bkt, counters, uploadReports, reportRetention)
+	mux.Handle("/api/v1/compaction/report", reporter)
+	for name, run := range runPhase {
+		runPhase[name] = reporter.phase(name, run)
+	}
 
-		if err := compact.ApplyRetentionPolicyByResolution(ctx, logger, bkt, retentionByResolution); err != nil {
-			return errors.Wrap(err, fmt.Sprintf("retention failed"))
+	level.Info(logger).Log("msg", "compactor phases configured", "phases", strings.Join(phases, ","))
+
+	f := func() (err error) {
+		reporter.start()
+		defer func() { reporter.finish(ctx, err) }()
+
+		for i := 0; i < len(phases); i++ {
+			if concurrentDownsampling && i+1 < len(phases) && isCompactDownsamplePair(phases[i], phases[i+1]) {
+				if err := runConcurrently(runPhase[phases[i]], runPhase[phases[i+1]]); err != nil {
+					return err
+				}
+				i++
+				continue
+			}
+			if err := runPhase[phases[i]](); err != nil {
+				return err
+			}
 		}
 		return nil
 	}
@@ -300,14 +689,40 @@ func runCompact(
 			}
 		}
 
+		if d := jitter(startJitter); d > 0 {
+			level.Info(logger).Log("msg", "delaying first iteration", "delay", d)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(d):
+			}
+		}
+
 		if !wait {
 			return f()
 		}
 
 		// --wait=true is specified.
-		return runutil.Repeat(5*time.Minute, ctx.Done(), func() error {
+		halted, err := haltStatus.load()
+		if err != nil {
+			return err
+		}
+		if halted {
+			resp := haltStatus.response()
+			level.Error(logger).Log("msg", "compactor halted before restart; staying halted until unhalted", "err", resp.Error,
+				"reason", resp.Reason, "group", resp.Group, "blocks", fmt.Sprintf("%v", resp.Blocks))
+		}
+		return runutil.Repeat(waitInterval, ctx.Done(), func() error {
+			if !haltStatus.wait(ctx) {
+				return nil
+			}
+			if pause.isPaused() {
+				level.Info(logger).Log("msg", "compactor paused; skipping iteration")
+				return nil
+			}
 			err := f()
 			if err == nil {
+				haltStatus.succeeded()
 				return nil
 			}
 
@@ -315,9 +730,16 @@ func runCompact(
 			// for investigation. You should alert on this being halted.
 			if compact.IsHaltError(err) {
 				if haltOnError {
-					level.Error(logger).Log("msg", "critical error detected; halting", "err", err)
-					halted.Set(1)
-					select {}
+					details := haltStatus.set(err)
+					level.Error(logger).Log("msg", "critical error detected; halting", "err", err,
+						"reason", details.Reason, "group", details.Group, "blocks", fmt.Sprintf("%v", details.Blocks))
+					if at := haltStatus.retryAt(); !at.IsZero() {
+						level.Info(logger).Log("msg", "compactor is unhalted automatically after backoff", "retryAt", at)
+					}
+					if haltStatus.wait(ctx) {
+						level.Info(logger).Log("msg", "compactor unhalted; resuming iterations")
+					}
+					return nil
 				} else {
 					return errors.Wrap(err, "critical error detected")
 				}
@@ -328,7 +750,7 @@ func runCompact(
 			if compact.IsRetryError(err) {
 				level.Error(logger).Log("msg", "retriable error", "err", err)
 				retried.Inc()
-				// TODO(bplotka): use actual "retry()" here instead of waiting 5 minutes?
+				// TODO(bplotka): use actual "retry()" here instead of waiting for the next iteration?
 				return nil
 			}
 
@@ -463,3 +885,577 @@ func generateIndexCacheFile(
 	}
 	return nil
 }
+
+// haltStatus holds details of the critical error the compactor halted on, and of groups halted on critical errors of
+// their compaction. It serves them as JSON, so it is immediately visible which blocks need to be investigated.
+// The halt of the compactor is persisted to its halt record file, so the compactor stays halted across restarts until
+// it is unhalted, either by an unhalt request or, if a backoff is set, automatically once the backoff passed.
+type haltStatus struct {
+	logger log.Logger
+	// path is the file the halt record is persisted to, none if empty.
+	path string
+	// backoff is the time waited before the first automatic unhalt, doubled for each further consecutive halt up to
+	// maxBackoff. Automatic unhalts are disabled if 0.
+	backoff, maxBackoff time.Duration
+	now                 func() time.Time
+
+	mtx     sync.RWMutex
+	halted  bool
+	err     string
+	details compact.HaltDetails
+	since   time.Time
+	// halts is the number of consecutive halts without a successful iteration in between.
+	halts   int
+	unhaltc chan struct{}
+	groups  func() []compact.HaltedGroup
+
+	info    *prometheus.GaugeVec
+	unhalts *prometheus.CounterVec
+}
+
+// validateRetention fails if the retention phase would delete blocks before they are downsampled by this compactor and
+// warns about retentions deleting blocks before they are downsampled, if downsampled by other compactors only.
+func validateRetention(logger log.Logger, retentionByResolution map[compact.ResolutionLevel]time.Duration, phases []string, disableDownsampling bool, partitioned bool) error {
+	var retention, downsampling bool
+	for _, p := range phases {
+		retention = retention || p == compactPhaseRetention
+		downsampling = downsampling || (p == compactPhaseDownsample && !disableDownsampling)
+	}
+	if !retention {
+		return nil
+	}
+
+	warnings, err := compact.ValidateRetention(retentionByResolution, downsampling)
+	if err != nil {
+		return errors.Wrap(err, "invalid argument: --retention.resolution-*")
+	}
+	for _, w := range warnings {
+		level.Warn(logger).Log("msg", "retention may delete blocks before they are downsampled, unless another compactor downsamples them", "warning", w)
+	}
+	if !downsampling || !partitioned {
+		return nil
+	}
+	// Retention is applied to all blocks of the bucket, also to blocks not selected by this compactor.
+	warnings, _ = compact.ValidateRetention(retentionByResolution, false)
+	for _, w := range warnings {
+		level.Warn(logger).Log("msg", "retention is applied to all blocks of the bucket and may delete blocks not selected by this compactor before other compactors downsample them", "warning", w)
+	}
+	return nil
+}
+
+const (
+	haltTriggerAPI     = "api"
+	haltTriggerBackoff = "backoff"
+)
+
+// haltRecord is the halt of the compactor as persisted to the halt record file.
+type haltRecord struct {
+	Error string `json:"error"`
+	compact.HaltDetails
+	Since time.Time `json:"since"`
+	Halts int       `json:"halts"`
+}
+
+func newHaltStatus(logger log.Logger, reg prometheus.Registerer, groups func() []compact.HaltedGroup, path string, backoff, maxBackoff time.Duration) *haltStatus {
+	s := &haltStatus{
+		logger:     logger,
+		path:       path,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+		unhaltc:    make(chan struct{}),
+		groups:     groups,
+		info: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_compactor_halt_info",
+			Help: "Set to 1 with the group and the reason of the critical error the compactor halted on.",
+		}, []string{"group", "reason"}),
+		unhalts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_compactor_unhalts_total",
+			Help: "Total number of times the halted compactor was unhalted, by an unhalt request or automatically after the backoff.",
+		}, []string{"trigger"}),
+	}
+	if reg != nil {
+		reg.MustRegister(s.info, s.unhalts)
+		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "thanos_compactor_halted",
+			Help: "Set to 1 if the compactor halted due to an unexpected error",
+		}, func() float64 {
+			if s.isHalted() {
+				return 1
+			}
+			return 0
+		}))
+	}
+	return s
+}
+
+// load restores the halt persisted to the halt record file, if any, and returns whether the compactor is halted.
+func (s *haltStatus) load() (bool, error) {
+	if s.path == "" {
+		return false, nil
+	}
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "read halt record")
+	}
+	var rec haltRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return false, errors.Wrapf(err, "decode halt record %s", s.path)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.halted = true
+	s.err = rec.Error
+	s.details = rec.HaltDetails
+	s.since = rec.Since
+	s.halts = rec.Halts
+	s.info.WithLabelValues(rec.Group, string(rec.Reason)).Set(1)
+	return true, nil
+}
+
+// set records the halt error, persists it to the halt record file and returns its details.
+func (s *haltStatus) set(err error) compact.HaltDetails {
+	details, ok := compact.HaltErrorDetails(err)
+	if !ok {
+		details = compact.HaltDetails{Reason: compact.HaltReasonUnknown}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.halted = true
+	s.err = err.Error()
+	s.details = details
+	s.since = s.now()
+	s.halts++
+	s.info.WithLabelValues(details.Group, string(details.Reason)).Set(1)
+
+	if s.path != "" {
+		if err := writeHaltRecord(s.path, haltRecord{Error: s.err, HaltDetails: details, Since: s.since, Halts: s.halts}); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to persist halt record", "path", s.path, "err", err)
+		}
+	}
+	return details
+}
+
+func writeHaltRecord(path string, rec haltRecord) error {
+	b, err := json.MarshalIndent(rec, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "encode halt record")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return errors.Wrap(err, "create dir")
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write halt record")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "rename halt record")
+}
+
+func (s *haltStatus) isHalted() bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.halted
+}
+
+// retryAt returns the time the compactor is unhalted at automatically, zero if it is not halted or automatic unhalts
+// are disabled. The backoff doubles with each consecutive halt.
+func (s *haltStatus) retryAt() time.Time {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if !s.halted || s.backoff <= 0 {
+		return time.Time{}
+	}
+	return s.since.Add(compact.HaltBackoff(s.backoff, s.maxBackoff, s.halts))
+}
+
+// unhalt lifts the halt of the compactor and removes the halt record file. It returns false if it was not halted.
+// Unhalts triggered by requests reset the backoff, automatic ones do not.
+func (s *haltStatus) unhalt(trigger string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.halted {
+		return false
+	}
+	s.halted = false
+	s.err = ""
+	s.details = compact.HaltDetails{}
+	s.since = time.Time{}
+	if trigger != haltTriggerBackoff {
+		s.halts = 0
+	}
+	s.info.Reset()
+	s.unhalts.WithLabelValues(trigger).Inc()
+	close(s.unhaltc)
+	s.unhaltc = make(chan struct{})
+
+	if s.path != "" {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			level.Warn(s.logger).Log("msg", "failed to remove halt record", "path", s.path, "err", err)
+		}
+	}
+	return true
+}
+
+// succeeded resets the backoff after a successful iteration.
+func (s *haltStatus) succeeded() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.halts = 0
+}
+
+// wait blocks while the compactor is halted. It returns false if the context is done before the compactor was
+// unhalted.
+func (s *haltStatus) wait(ctx context.Context) bool {
+	for {
+		s.mtx.RLock()
+		halted, unhaltc := s.halted, s.unhaltc
+		s.mtx.RUnlock()
+		if !halted {
+			return true
+		}
+
+		var (
+			t      *time.Timer
+			retryc <-chan time.Time
+		)
+		if at := s.retryAt(); !at.IsZero() {
+			t = time.NewTimer(at.Sub(s.now()))
+			retryc = t.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-unhaltc:
+		case <-retryc:
+			if s.unhalt(haltTriggerBackoff) {
+				level.Info(s.logger).Log("msg", "backoff passed; unhalting compactor")
+			}
+		}
+		if t != nil {
+			t.Stop()
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// syncedMetas returns metas of blocks synced by the syncer, sorted by block ID.
+func syncedMetas(sy *compact.Syncer) []metadata.Meta {
+	metas := make([]metadata.Meta, 0)
+	for _, m := range sy.Metas() {
+		metas = append(metas, *m)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].ULID.Compare(metas[j].ULID) < 0
+	})
+	return metas
+}
+
+// blockStates returns the states of blocks of halted groups and of blocks marked for no compaction, highlighted by the
+// bucket UI.
+func blockStates(sy *compact.Syncer) map[string]ui.BlockState {
+	states := map[string]ui.BlockState{}
+	for _, id := range sy.NoCompactBlocks() {
+		states[id.String()] = ui.BlockState{State: "marked", Reason: "marked for no compaction"}
+	}
+
+	halted := map[string]compact.HaltedGroup{}
+	for _, h := range sy.HaltedGroups() {
+		halted[h.Group] = h
+	}
+	for id, m := range sy.Metas() {
+		h, ok := halted[compact.GroupKey(m.Thanos)]
+		if !ok {
+			continue
+		}
+		reason := fmt.Sprintf("group halted (%s)", h.Reason)
+		for _, b := range h.Blocks {
+			if b == id {
+				reason = fmt.Sprintf("block caused halt of group (%s): %s", h.Reason, h.Error)
+			}
+		}
+		states[id.String()] = ui.BlockState{State: "halted", Reason: reason}
+	}
+	return states
+}
+
+// lineageHandler serves the lineage of blocks synced by the syncer as JSON, or in the DOT language with format=dot
+// query parameter.
+func lineageHandler(sy *compact.Syncer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, err := sy.Lineage()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		switch f := r.URL.Query().Get("format"); f {
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			_ = l.WriteDOT(w)
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(l); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		default:
+			http.Error(w, fmt.Sprintf("unknown format %q", f), http.StatusBadRequest)
+		}
+	})
+}
+
+// shardHandler serves the external label sets of blocks owned and not owned by the syncer as JSON.
+func shardHandler(sy *compact.Syncer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sy.Shard()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// partialHandler serves the partial blocks found by the syncer as JSON.
+func partialHandler(sy *compact.Syncer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sy.PartialBlocks()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+type haltStatusResponse struct {
+	Halted      bool       `json:"halted"`
+	HaltedSince *time.Time `json:"halted_since,omitempty"`
+	// RetryAt is the time the compactor is unhalted at automatically.
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	compact.HaltDetails
+	HaltedGroups []compact.HaltedGroup `json:"halted_groups"`
+}
+
+func (s *haltStatus) response() haltStatusResponse {
+	retryAt := s.retryAt()
+	s.mtx.RLock()
+	resp := haltStatusResponse{Halted: s.halted, Error: s.err, HaltDetails: s.details}
+	if s.halted {
+		since := s.since
+		resp.HaltedSince = &since
+	}
+	s.mtx.RUnlock()
+	if !retryAt.IsZero() {
+		resp.RetryAt = &retryAt
+	}
+	if s.groups != nil {
+		resp.HaltedGroups = s.groups()
+	}
+	return resp
+}
+
+func (s *haltStatus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.response()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// unhaltHandler lifts the halt of the compactor and of all halted groups on POST requests, once the critical errors
+// they halted on were investigated. It serves the halt status as JSON.
+func unhaltHandler(s *haltStatus, unhaltGroups func() int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed, use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		unhalted := s.unhalt(haltTriggerAPI)
+		groups := 0
+		if unhaltGroups != nil {
+			groups = unhaltGroups()
+		}
+		level.Info(s.logger).Log("msg", "unhalt requested", "compactorUnhalted", unhalted, "groupsUnhalted", groups)
+		s.ServeHTTP(w, r)
+	})
+}
+
+// blocksHandler serves the metas of blocks synced by the syncer as JSON.
+func blocksHandler(sy *compact.Syncer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(syncedMetas(sy)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// progressHandler serves the estimated remaining work of all compaction groups as JSON.
+func progressHandler(compactor *compact.BucketCompactor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		progress, err := compactor.Progress()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(progress); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+type compactionStatusResponse struct {
+	CompactingGroups      []compact.CompactingGroup  `json:"compacting_groups"`
+	LastGarbageCollection *compact.GarbageCollection `json:"last_garbage_collection"`
+	haltStatusResponse
+	pauseStatusResponse
+}
+
+// compactionStatusHandler serves the groups compacted at the moment, the result of the last garbage collection, the
+// halt status and the pause status as JSON.
+func compactionStatusHandler(sy *compact.Syncer, compactor *compact.BucketCompactor, halt *haltStatus, pause *pauseStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		resp := compactionStatusResponse{
+			CompactingGroups:      compactor.CompactingGroups(),
+			LastGarbageCollection: sy.LastGarbageCollection(),
+			haltStatusResponse:    halt.response(),
+			pauseStatusResponse:   pause.response(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// jitter returns a random duration in [0, max).
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.New(rand.NewSource(time.Now().UnixNano())).Int63n(int64(max)))
+}
+
+// pauseStatus holds whether iterations of the compactor are paused, e.g. during maintenance of the bucket. Iterations
+// are skipped while paused, an iteration in progress is completed.
+type pauseStatus struct {
+	mtx    sync.RWMutex
+	paused bool
+	// until is the time the pause ends at, zero if it lasts until resumed.
+	until time.Time
+	now   func() time.Time
+}
+
+func newPauseStatus(reg prometheus.Registerer) *pauseStatus {
+	s := &pauseStatus{now: time.Now}
+	if reg != nil {
+		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "thanos_compactor_paused",
+			Help: "Set to 1 if iterations of the compactor are paused.",
+		}, func() float64 {
+			if s.isPaused() {
+				return 1
+			}
+			return 0
+		}))
+	}
+	return s
+}
+
+// pause pauses iterations for the given duration, or until resumed if d is 0.
+func (s *pauseStatus) pause(d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.paused = true
+	s.until = time.Time{}
+	if d > 0 {
+		s.until = s.now().Add(d)
+	}
+}
+
+func (s *pauseStatus) resume() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.paused = false
+	s.until = time.Time{}
+}
+
+func (s *pauseStatus) isPaused() bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.paused && (s.until.IsZero() || s.now().Before(s.until))
+}
+
+type pauseStatusResponse struct {
+	Paused      bool       `json:"paused"`
+	PausedUntil *time.Time `json:"paused_until,omitempty"`
+}
+
+func (s *pauseStatus) response() pauseStatusResponse {
+	if !s.isPaused() {
+		return pauseStatusResponse{}
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	resp := pauseStatusResponse{Paused: true}
+	if !s.until.IsZero() {
+		until := s.until
+		resp.PausedUntil = &until
+	}
+	return resp
+}
+
+// pauseHandler pauses iterations of the compactor on POST requests, for the duration given by the optional duration
+// parameter, e.g. 1h, or until resumed. It serves the pause status as JSON.
+func pauseHandler(s *pauseStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed, use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		var d time.Duration
+		if v := r.FormValue("duration"); v != "" {
+			md, err := prommodel.ParseDuration(v)
+			if err != nil || md <= 0 {
+				http.Error(w, fmt.Sprintf("invalid duration %q", v), http.StatusBadRequest)
+				return
+			}
+			d = time.Duration(md)
+		}
+		s.pause(d)
+		writePauseStatus(w, s)
+	})
+}
+
+// resumeHandler resumes iterations of the compactor on POST requests. It serves the pause status as JSON.
+func resumeHandler(s *pauseStatus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed, use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		s.resume()
+		writePauseStatus(w, s)
+	})
+}
+
+func writePauseStatus(w http.ResponseWriter, s *pauseStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.response()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
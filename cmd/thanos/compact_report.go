@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// compactReportsDir is the directory of the bucket reports of compactor iterations are uploaded to.
+const compactReportsDir = "reports"
+
+// phaseReport is the outcome of a phase of a compactor iteration.
+type phaseReport struct {
+	Phase           string  `json:"phase"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// iterationReport summarizes a compactor iteration. Counts and bytes are taken from the counters of the compactor, so
+// they include work of all phases of the iteration.
+type iterationReport struct {
+	ID              ulid.ULID     `json:"id"`
+	Start           time.Time     `json:"start"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	Phases          []phaseReport `json:"phases"`
+	// Groups is the number of groups whose compaction was run.
+	Groups                 int    `json:"groups"`
+	Compactions            int    `json:"compactions"`
+	CompactionFailures     int    `json:"compaction_failures"`
+	Downsamplings          int    `json:"downsamplings"`
+	DownsamplingFailures   int    `json:"downsampling_failures"`
+	DeletedBlocks          int    `json:"deleted_blocks"`
+	OrphanedObjectsRemoved int    `json:"orphaned_objects_removed"`
+	DownloadedBytes        int64  `json:"downloaded_bytes"`
+	UploadedBytes          int64  `json:"uploaded_bytes"`
+	Error                  string `json:"error,omitempty"`
+}
+
+// iterationCounters are the counters of the compactor reports of iterations are built from. Nil counters are not
+// reported.
+type iterationCounters struct {
+	// groupRunsStarted are compaction runs by group, the groups whose counter changed are counted as compacted.
+	groupRunsStarted       prometheus.Collector
+	compactions            prometheus.Collector
+	compactionFailures     prometheus.Collector
+	downsamples            prometheus.Collector
+	downsampleFailures     prometheus.Collector
+	garbageCollectedBlocks prometheus.Collector
+	orphanedObjectsRemoved prometheus.Collector
+	// transferredBytes are bytes transferred from and to the bucket with a direction label.
+	transferredBytes prometheus.Collector
+}
+
+// counterSnapshot are the values of iterationCounters by label set, e.g. {direction="upload"}.
+type counterSnapshot struct {
+	groupRunsStarted       map[string]float64
+	compactions            map[string]float64
+	compactionFailures     map[string]float64
+	downsamples            map[string]float64
+	downsampleFailures     map[string]float64
+	garbageCollectedBlocks map[string]float64
+	orphanedObjectsRemoved map[string]float64
+	transferredBytes       map[string]float64
+}
+
+func (c iterationCounters) snapshot() counterSnapshot {
+	return counterSnapshot{
+		groupRunsStarted:       counterValues(c.groupRunsStarted),
+		compactions:            counterValues(c.compactions),
+		compactionFailures:     counterValues(c.compactionFailures),
+		downsamples:            counterValues(c.downsamples),
+		downsampleFailures:     counterValues(c.downsampleFailures),
+		garbageCollectedBlocks: counterValues(c.garbageCollectedBlocks),
+		orphanedObjectsRemoved: counterValues(c.orphanedObjectsRemoved),
+		transferredBytes:       counterValues(c.transferredBytes),
+	}
+}
+
+// iterationReporter records reports of compactor iterations, serves the latest one as JSON and uploads them to the
+// bucket if enabled, deleting uploaded reports older than the retention.
+type iterationReporter struct {
+	logger    log.Logger
+	bkt       objstore.Bucket
+	counters  iterationCounters
+	upload    bool
+	retention time.Duration
+
+	mtx     sync.Mutex
+	current *iterationReport
+	before  counterSnapshot
+	last    *iterationReport
+}
+
+func newIterationReporter(logger log.Logger, bkt objstore.Bucket, counters iterationCounters, upload bool, retention time.Duration) *iterationReporter {
+	return &iterationReporter{logger: logger, bkt: bkt, counters: counters, upload: upload, retention: retention}
+}
+
+// start starts the report of a new iteration.
+func (r *iterationReporter) start() {
+	now := time.Now()
+	before := r.counters.snapshot()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.current = &iterationReport{
+		ID:     ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Start:  now,
+		Phases: []phaseReport{},
+	}
+	r.before = before
+}
+
+// phase returns the function running the given phase, recording its duration and error in the current report.
+func (r *iterationReporter) phase(name string, f func() error) func() error {
+	return func() error {
+		begin := time.Now()
+		err := f()
+
+		p := phaseReport{Phase: name, DurationSeconds: time.Since(begin).Seconds()}
+		if err != nil {
+			p.Error = err.Error()
+		}
+		r.mtx.Lock()
+		if r.current != nil {
+			r.current.Phases = append(r.current.Phases, p)
+		}
+		r.mtx.Unlock()
+		return err
+	}
+}
+
+// finish completes the current report with the error of the iteration and the work done, and uploads it if enabled.
+// Failed uploads are logged only, so they do not fail the iteration.
+func (r *iterationReporter) finish(ctx context.Context, err error) {
+	after := r.counters.snapshot()
+
+	r.mtx.Lock()
+	rep := r.current
+	if rep == nil {
+		r.mtx.Unlock()
+		return
+	}
+	r.current = nil
+	rep.DurationSeconds = time.Since(rep.Start).Seconds()
+	if err != nil {
+		rep.Error = err.Error()
+	}
+	before := r.before
+	rep.Groups = changedCounters(before.groupRunsStarted, after.groupRunsStarted)
+	rep.Compactions = int(counterDelta(before.compactions, after.compactions, ""))
+	rep.CompactionFailures = int(counterDelta(before.compactionFailures, after.compactionFailures, ""))
+	// Downsamplings are counted once downsampled successfully, failures are counted separately.
+	rep.Downsamplings = int(counterDelta(before.downsamples, after.downsamples, ""))
+	rep.DownsamplingFailures = int(counterDelta(before.downsampleFailures, after.downsampleFailures, ""))
+	rep.DeletedBlocks = int(counterDelta(before.garbageCollectedBlocks, after.garbageCollectedBlocks, ""))
+	rep.OrphanedObjectsRemoved = int(counterDelta(before.orphanedObjectsRemoved, after.orphanedObjectsRemoved, ""))
+	rep.DownloadedBytes = int64(counterDelta(before.transferredBytes, after.transferredBytes, `direction="download"`))
+	rep.UploadedBytes = int64(counterDelta(before.transferredBytes, after.transferredBytes, `direction="upload"`))
+	r.last = rep
+	r.mtx.Unlock()
+
+	level.Info(r.logger).Log("msg", "compactor iteration done", "duration", time.Duration(rep.DurationSeconds*float64(time.Second)),
+		"groups", rep.Groups, "compactions", rep.Compactions, "downsamplings", rep.Downsamplings, "deletedBlocks", rep.DeletedBlocks)
+
+	if !r.upload || ctx.Err() != nil {
+		return
+	}
+	if err := r.uploadReport(ctx, rep); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to upload iteration report", "err", err)
+	}
+	if err := r.deleteOldReports(ctx); err != nil {
+		level.Warn(r.logger).Log("msg", "failed to delete old iteration reports", "err", err)
+	}
+}
+
+func (r *iterationReporter) uploadReport(ctx context.Context, rep *iterationReport) error {
+	b, err := json.MarshalIndent(rep, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "encode report")
+	}
+	return r.bkt.Upload(ctx, path.Join(compactReportsDir, rep.ID.String()+".json"), bytes.NewReader(b))
+}
+
+// deleteOldReports deletes reports of iterations started before the retention, of all compactors of the bucket.
+func (r *iterationReporter) deleteOldReports(ctx context.Context) error {
+	if r.retention <= 0 {
+		return nil
+	}
+	minTime := ulid.Timestamp(time.Now().Add(-r.retention))
+	return r.bkt.Iter(ctx, compactReportsDir+"/", func(name string) error {
+		id, err := ulid.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+		if err != nil || id.Time() >= minTime {
+			return nil
+		}
+		return errors.Wrapf(r.bkt.Delete(ctx, name), "delete %s", name)
+	})
+}
+
+// ServeHTTP serves the report of the last iteration as JSON, null if no iteration finished yet.
+func (r *iterationReporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mtx.Lock()
+	rep := r.last
+	r.mtx.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rep); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// counterValues returns the values of the counters collected from the collector by label set, e.g. {a="1",b="2"}.
+func counterValues(c prometheus.Collector) map[string]float64 {
+	values := map[string]float64{}
+	if c == nil {
+		return values
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil || pb.GetCounter() == nil {
+			continue
+		}
+		lbls := make([]string, 0, len(pb.GetLabel()))
+		for _, l := range pb.GetLabel() {
+			lbls = append(lbls, l.GetName()+"="+`"`+l.GetValue()+`"`)
+		}
+		sort.Strings(lbls)
+		values["{"+strings.Join(lbls, ",")+"}"] = pb.GetCounter().GetValue()
+	}
+	return values
+}
+
+// counterDelta returns the sum of the changes of counters between two calls of counterValues, of label sets
+// containing the given label pair.
+func counterDelta(before, after map[string]float64, label string) float64 {
+	var res float64
+	for key, v := range after {
+		if strings.Contains(key, label) {
+			res += v - before[key]
+		}
+	}
+	return res
+}
+
+// changedCounters returns the number of label sets whose counter changed between two calls of counterValues.
+func changedCounters(before, after map[string]float64) int {
+	n := 0
+	for key, v := range after {
+		if v != before[key] {
+			n++
+		}
+	}
+	return n
+}
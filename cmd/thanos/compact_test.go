@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func Test_validateCompactPhases(t *testing.T) {
+	testutil.Ok(t, validateCompactPhases(compactPhases))
+	testutil.Ok(t, validateCompactPhases([]string{compactPhaseRetention, compactPhaseCompact}))
+	testutil.Ok(t, validateCompactPhases([]string{compactPhaseDownsample}))
+	testutil.NotOk(t, validateCompactPhases([]string{compactPhaseCompact, compactPhaseDownsample, compactPhaseCompact}))
+}
+
+func Test_runConcurrently(t *testing.T) {
+	// Both functions run at the same time, each waiting for the other one.
+	started1, started2 := make(chan struct{}), make(chan struct{})
+	testutil.Ok(t, runConcurrently(
+		func() error { close(started1); <-started2; return nil },
+		func() error { close(started2); <-started1; return nil },
+	))
+
+	err1, err2 := errors.New("1"), errors.New("2")
+	testutil.Equals(t, err1, runConcurrently(func() error { return err1 }, func() error { return err2 }))
+	testutil.Equals(t, err2, runConcurrently(func() error { return nil }, func() error { return err2 }))
+
+	testutil.Assert(t, isCompactDownsamplePair(compactPhaseDownsample, compactPhaseCompact), "downsample and compact are a pair")
+	testutil.Assert(t, !isCompactDownsamplePair(compactPhaseCompact, compactPhaseRetention), "compact and retention are not a pair")
+}
+
+func Test_pauseStatus(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newPauseStatus(nil)
+	s.now = func() time.Time { return now }
+	testutil.Assert(t, !s.isPaused(), "not paused initially")
+
+	for _, tcase := range []struct {
+		method, url string
+		code        int
+		paused      bool
+		until       *time.Time
+	}{
+		{method: http.MethodGet, url: "/api/v1/compaction/pause", code: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, url: "/api/v1/compaction/pause?duration=foo", code: http.StatusBadRequest},
+		{method: http.MethodPost, url: "/api/v1/compaction/pause", code: http.StatusOK, paused: true},
+		{method: http.MethodPost, url: "/api/v1/compaction/resume", code: http.StatusOK},
+		{method: http.MethodPost, url: "/api/v1/compaction/pause?duration=1h", code: http.StatusOK, paused: true, until: func() *time.Time { u := now.Add(time.Hour); return &u }()},
+	} {
+		h := pauseHandler(s)
+		if tcase.url == "/api/v1/compaction/resume" {
+			h = resumeHandler(s)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tcase.method, tcase.url, nil))
+		testutil.Equals(t, tcase.code, w.Code)
+		testutil.Equals(t, tcase.paused, s.isPaused())
+		testutil.Equals(t, pauseStatusResponse{Paused: tcase.paused, PausedUntil: tcase.until}, s.response())
+	}
+
+	// The pause ends after its duration.
+	now = now.Add(time.Hour)
+	testutil.Assert(t, !s.isPaused(), "pause ended")
+	testutil.Equals(t, pauseStatusResponse{}, s.response())
+
+	testutil.Equals(t, time.Duration(0), jitter(0))
+	testutil.Assert(t, jitter(time.Second) < time.Second, "jitter is below its maximum")
+}
+
+func Test_haltStatus(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "halt-status")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "halt.json")
+
+	now := time.Unix(1000, 0)
+	s := newHaltStatus(log.NewNopLogger(), nil, nil, path, time.Minute, 3*time.Minute)
+	s.now = func() time.Time { return now }
+	halted, err := s.load()
+	testutil.Ok(t, err)
+	testutil.Assert(t, !halted, "not halted initially")
+	testutil.Assert(t, s.wait(ctx), "wait returns if not halted")
+
+	// The backoff doubles with each consecutive halt up to the maximum.
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		s.set(errors.New("critical"))
+		testutil.Equals(t, now.Add(expected), s.retryAt())
+		testutil.Assert(t, s.unhalt(haltTriggerBackoff), "unhalted")
+	}
+	s.succeeded()
+
+	details := s.set(errors.New("critical"))
+	testutil.Equals(t, compact.HaltDetails{Reason: compact.HaltReasonUnknown}, details)
+	testutil.Equals(t, now.Add(time.Minute), s.retryAt())
+
+	// The halt is restored after restarts.
+	restored := newHaltStatus(log.NewNopLogger(), nil, nil, path, 0, 0)
+	halted, err = restored.load()
+	testutil.Ok(t, err)
+	testutil.Assert(t, halted, "halt not restored")
+	resp := restored.response()
+	testutil.Equals(t, "critical", resp.Error)
+	testutil.Equals(t, details, resp.HaltDetails)
+	testutil.Assert(t, now.Equal(*resp.HaltedSince), "halt time not restored")
+	testutil.Equals(t, 1, restored.halts)
+	// Automatic unhalts are disabled.
+	testutil.Equals(t, time.Time{}, restored.retryAt())
+
+	// Waiting is stopped by done contexts and unhalt requests.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	testutil.Assert(t, !restored.wait(cctx), "wait returns false on done contexts")
+
+	waited := make(chan bool)
+	go func() { waited <- restored.wait(ctx) }()
+
+	groups := 0
+	for _, tcase := range []struct {
+		method string
+		code   int
+		halted bool
+	}{
+		{method: http.MethodGet, code: http.StatusMethodNotAllowed, halted: true},
+		{method: http.MethodPost, code: http.StatusOK},
+		{method: http.MethodPost, code: http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		unhaltHandler(restored, func() int { groups++; return 0 }).ServeHTTP(w, httptest.NewRequest(tcase.method, "/api/v1/compaction/unhalt", nil))
+		testutil.Equals(t, tcase.code, w.Code)
+		testutil.Equals(t, tcase.halted, restored.isHalted())
+	}
+	testutil.Assert(t, <-waited, "wait returns true once unhalted")
+	testutil.Equals(t, 2, groups)
+	_, err = os.Stat(path)
+	testutil.Assert(t, os.IsNotExist(err), "halt record not removed")
+
+	// Once the backoff passed, the compactor is unhalted automatically.
+	now = now.Add(time.Hour)
+	testutil.Assert(t, s.wait(ctx), "wait returns true once the backoff passed")
+	testutil.Assert(t, !s.isHalted(), "still halted")
+}
+
+func Test_iterationReporter(t *testing.T) {
+	ctx := context.Background()
+	compactions := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "compactions_total"}, []string{"group"})
+	runs := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "runs_started_total"}, []string{"group"})
+	downsamples := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "downsamples_total"}, []string{"group"})
+	downsampleFailures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "downsample_failures_total"}, []string{"group"})
+	transferred := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "transferred_bytes_total"}, []string{"direction"})
+	compactions.WithLabelValues("a").Add(5)
+	runs.WithLabelValues("b")
+	transferred.WithLabelValues("download").Add(100)
+
+	bkt := inmem.NewBucket()
+	oldID := ulid.MustNew(ulid.Timestamp(time.Now().Add(-2*time.Hour)), rand.Reader)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(compactReportsDir, oldID.String()+".json"), bytes.NewReader([]byte("{}"))))
+
+	r := newIterationReporter(log.NewNopLogger(), bkt, iterationCounters{
+		groupRunsStarted:   runs,
+		compactions:        compactions,
+		downsamples:        downsamples,
+		downsampleFailures: downsampleFailures,
+		transferredBytes:   transferred,
+	}, true, time.Hour)
+	r.start()
+	testutil.Ok(t, r.phase("compact", func() error {
+		compactions.WithLabelValues("a").Inc()
+		compactions.WithLabelValues("b").Inc()
+		runs.WithLabelValues("a").Inc()
+		runs.WithLabelValues("b").Inc()
+		transferred.WithLabelValues("download").Add(1000)
+		transferred.WithLabelValues("upload").Add(500)
+		downsamples.WithLabelValues("a").Add(2)
+		downsampleFailures.WithLabelValues("b").Inc()
+		return nil
+	})())
+	testutil.NotOk(t, r.phase("retention", func() error { return errors.New("failed") })())
+	r.finish(ctx, errors.New("retention failed"))
+
+	testutil.Equals(t, 2, len(r.last.Phases))
+	testutil.Equals(t, "compact", r.last.Phases[0].Phase)
+	testutil.Equals(t, "failed", r.last.Phases[1].Error)
+	testutil.Equals(t, "retention failed", r.last.Error)
+	testutil.Equals(t, 2, r.last.Groups)
+	testutil.Equals(t, 2, r.last.Compactions)
+	testutil.Equals(t, 2, r.last.Downsamplings)
+	testutil.Equals(t, 1, r.last.DownsamplingFailures)
+	testutil.Equals(t, int64(1000), r.last.DownloadedBytes)
+	testutil.Equals(t, int64(500), r.last.UploadedBytes)
+
+	// The report is uploaded and reports older than the retention are deleted.
+	objs := bkt.Objects()
+	testutil.Equals(t, 1, len(objs))
+	_, ok := objs[path.Join(compactReportsDir, r.last.ID.String()+".json")]
+	testutil.Assert(t, ok, "report not uploaded")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/compaction/report", nil))
+	var served iterationReport
+	testutil.Ok(t, json.NewDecoder(w.Body).Decode(&served))
+	testutil.Equals(t, r.last.ID, served.ID)
+	testutil.Equals(t, r.last.Compactions, served.Compactions)
+}
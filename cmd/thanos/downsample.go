@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"os"
+	"path"
 	"path/filepath"
 	"time"
 
@@ -15,8 +16,10 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/compact"
@@ -26,6 +29,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/verifier"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -40,14 +44,56 @@ func registerDownsample(m map[string]setupFunc, app *kingpin.Application) {
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
 
+	consistencyDelay := modelDuration(cmd.Flag("consistency-delay", "Minimum age of fresh (non-compacted) blocks before they are being downsampled, so blocks of eventually consistent object stores are not considered while they may look partially uploaded.").
+		Default("30m"))
+
+	dropCounterMinMax := regDropCounterMinMaxFlag(cmd)
+	noDownsampleReasons := regNoDownsampleReasonsFlag(cmd)
+
 	m[comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ bool) error {
-		return runDownsample(g, logger, reg, *httpAddr, *dataDir, objStoreConfig, comp)
+		return runDownsample(g, logger, reg, *httpAddr, *dataDir, objStoreConfig, time.Duration(*consistencyDelay), *dropCounterMinMax, noCompactReasons(*noDownsampleReasons), comp)
+	}
+}
+
+func regDropCounterMinMaxFlag(cmd *kingpin.CmdClause) *bool {
+	return cmd.Flag("downsampling.drop-counter-min-max", "Downsample series which are counters by their name, i.e. ending with _total, _count, _sum or _bucket with le label, without min and max aggregates, saving space of downsampled blocks. Metric types are not stored, so gauges named like counters lose their min and max aggregates as well. Functions like min_over_time on such series are served from the counter aggregate.").
+		Default("false").Bool()
+}
+
+func regNoDownsampleReasonsFlag(cmd *kingpin.CmdClause) *[]string {
+	return cmd.Flag("downsampling.no-compact-reasons", "Reasons of no-compact-mark.json marks excluding blocks from downsampling (repeated). Blocks marked for other reasons are downsampled, but still not compacted. Marks of all reasons exclude blocks if not given. Quarantined partial blocks are always excluded.").
+		Enums(string(metadata.UnsupportedChunkEncodingNoCompactReason), string(metadata.PartialNoCompactReason), string(metadata.ManualNoCompactReason))
+}
+
+func noCompactReasons(reasons []string) []metadata.NoCompactReason {
+	res := make([]metadata.NoCompactReason, 0, len(reasons))
+	for _, r := range reasons {
+		res = append(res, metadata.NoCompactReason(r))
+	}
+	return res
+}
+
+// excludesFromDownsampling returns true if the no compact mark excludes its block from downsampling, i.e. it is not nil
+// and has one of the given reasons. All reasons exclude blocks if none are given.
+func excludesFromDownsampling(reasons []metadata.NoCompactReason, mark *metadata.NoCompactMark) bool {
+	if mark == nil {
+		return false
 	}
+	if len(reasons) == 0 {
+		return true
+	}
+	for _, r := range reasons {
+		if mark.Reason == r {
+			return true
+		}
+	}
+	return false
 }
 
 type DownsampleMetrics struct {
 	downsamples        *prometheus.CounterVec
 	downsampleFailures *prometheus.CounterVec
+	missingDownsamples *prometheus.GaugeVec
 }
 
 func newDownsampleMetrics(reg *prometheus.Registry) *DownsampleMetrics {
@@ -62,8 +108,14 @@ func newDownsampleMetrics(reg *prometheus.Registry) *DownsampleMetrics {
 		Help: "Total number of failed downsampling attempts.",
 	}, []string{"group"})
 
+	m.missingDownsamples = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_compact_downsample_missing_blocks",
+		Help: "Number of blocks past the downsampling threshold without a downsampled block of the resolution after the last downsampling pass, e.g. blocks marked for no compaction.",
+	}, []string{"resolution"})
+
 	reg.MustRegister(m.downsamples)
 	reg.MustRegister(m.downsampleFailures)
+	reg.MustRegister(m.missingDownsamples)
 
 	return m
 }
@@ -75,6 +127,9 @@ func runDownsample(
 	httpBindAddr string,
 	dataDir string,
 	objStoreConfig *extflag.PathOrContent,
+	consistencyDelay time.Duration,
+	dropCounterMinMax bool,
+	noDownsampleReasons []metadata.NoCompactReason,
 	comp component.Component,
 ) error {
 	confContentYaml, err := objStoreConfig.Content()
@@ -106,13 +161,13 @@ func runDownsample(
 
 			level.Info(logger).Log("msg", "start first pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, metrics, bkt, dataDir); err != nil {
+			if err := downsampleBucket(ctx, logger, metrics, bkt, dataDir, nil, nil, nil, nil, nil, consistencyDelay, dropCounterMinMax, noDownsampleReasons); err != nil {
 				return errors.Wrap(err, "downsampling failed")
 			}
 
 			level.Info(logger).Log("msg", "start second pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, metrics, bkt, dataDir); err != nil {
+			if err := downsampleBucket(ctx, logger, metrics, bkt, dataDir, nil, nil, nil, nil, nil, consistencyDelay, dropCounterMinMax, noDownsampleReasons); err != nil {
 				return errors.Wrap(err, "downsampling failed")
 			}
 
@@ -131,12 +186,26 @@ func runDownsample(
 	return nil
 }
 
+// downsampleSource is a source block of a downsampled block holding the given shard of series, empty for all series.
+type downsampleSource struct {
+	id    ulid.ULID
+	shard string
+}
+
 func downsampleBucket(
 	ctx context.Context,
 	logger log.Logger,
 	metrics *DownsampleMetrics,
 	bkt objstore.Bucket,
 	dir string,
+	relabelConfig []*relabel.Config,
+	selector labels.Labels,
+	sources []metadata.SourceType,
+	timeFilter *compact.TimeFilter,
+	locks *compact.GroupLocks,
+	consistencyDelay time.Duration,
+	dropCounterMinMax bool,
+	noDownsampleReasons []metadata.NoCompactReason,
 ) error {
 	if err := os.RemoveAll(dir); err != nil {
 		return errors.Wrap(err, "clean working directory")
@@ -152,6 +221,8 @@ func downsampleBucket(
 	}()
 
 	var metas []*metadata.Meta
+	// noDownsample are blocks excluded from downsampling by their no compact marks.
+	noDownsample := map[ulid.ULID]struct{}{}
 
 	err := bkt.Iter(ctx, "", func(name string) error {
 		id, ok := block.IsBlockDir(name)
@@ -159,12 +230,35 @@ func downsampleBucket(
 			return nil
 		}
 
+		// Marks are read before the meta, so quarantined partial blocks are skipped without reading their meta.
+		mark, err := block.ReadNoCompactMark(ctx, logger, bkt, id)
+		if err != nil {
+			return errors.Wrap(err, "read no compact mark")
+		}
+		if mark != nil && mark.Reason == metadata.PartialNoCompactReason {
+			level.Debug(logger).Log("msg", "skipping quarantined partial block", "block", id)
+			return nil
+		}
+
 		m, err := block.DownloadMeta(ctx, logger, bkt, id)
 		if err != nil {
+			if compact.IsBlockTooFresh(id, nil, consistencyDelay) {
+				level.Debug(logger).Log("msg", "block is too fresh for now", "block", id)
+				return nil
+			}
 			return errors.Wrap(err, "download metadata")
 		}
+		if compact.IsBlockTooFresh(id, &m, consistencyDelay) {
+			level.Debug(logger).Log("msg", "block is too fresh for now", "block", id)
+			return nil
+		}
 
+		// Marked blocks are still needed to find blocks which are downsampled already.
 		metas = append(metas, &m)
+		if excludesFromDownsampling(noDownsampleReasons, mark) {
+			level.Debug(logger).Log("msg", "block is marked for no downsampling", "block", id, "reason", mark.Reason)
+			noDownsample[id] = struct{}{}
+		}
 
 		return nil
 	})
@@ -172,10 +266,27 @@ func downsampleBucket(
 		return errors.Wrap(err, "retrieve bucket block metas")
 	}
 
+	if len(relabelConfig) > 0 || len(selector) > 0 || len(sources) > 0 || timeFilter != nil {
+		byID := make(map[ulid.ULID]*metadata.Meta, len(metas))
+		for _, m := range metas {
+			byID[m.ULID] = m
+		}
+		filtered := compact.FilterByTime(timeFilter, compact.FilterBySource(sources, compact.FilterByLabels(relabelConfig, selector, byID)))
+
+		all := metas
+		metas = metas[:0]
+		for _, m := range all {
+			if _, ok := filtered[m.ULID]; ok {
+				metas = append(metas, m)
+			}
+		}
+	}
+
 	// mapping from a hash over all source IDs to blocks. We don't need to downsample a block
 	// if a downsampled version with the same hash already exists.
-	sources5m := map[ulid.ULID]struct{}{}
-	sources1h := map[ulid.ULID]struct{}{}
+	// Sources are tracked by shard, as blocks of all shards of series of a compacted block share its sources.
+	sources5m := map[downsampleSource]struct{}{}
+	sources1h := map[downsampleSource]struct{}{}
 
 	for _, m := range metas {
 		switch m.Thanos.Downsample.Resolution {
@@ -183,23 +294,31 @@ func downsampleBucket(
 			continue
 		case downsample.ResLevel1:
 			for _, id := range m.Compaction.Sources {
-				sources5m[id] = struct{}{}
+				sources5m[downsampleSource{id: id, shard: m.Thanos.Shard.String()}] = struct{}{}
 			}
 		case downsample.ResLevel2:
 			for _, id := range m.Compaction.Sources {
-				sources1h[id] = struct{}{}
+				sources1h[downsampleSource{id: id, shard: m.Thanos.Shard.String()}] = struct{}{}
 			}
 		default:
 			return errors.Errorf("unexpected downsampling resolution %d", m.Thanos.Downsample.Resolution)
 		}
 	}
 
+	// Blocks downsampled in this pass are left out of the blocks reported as missing downsampled blocks.
+	downsampled := map[ulid.ULID]struct{}{}
 	for _, m := range metas {
+		if m.Thanos.Hints != nil && m.Thanos.Hints.DisableDownsampling {
+			continue
+		}
+		if _, ok := noDownsample[m.ULID]; ok {
+			continue
+		}
 		switch m.Thanos.Downsample.Resolution {
 		case downsample.ResLevel0:
 			missing := false
 			for _, id := range m.Compaction.Sources {
-				if _, ok := sources5m[id]; !ok {
+				if _, ok := sources5m[downsampleSource{id: id, shard: m.Thanos.Shard.String()}]; !ok {
 					missing = true
 					break
 				}
@@ -213,16 +332,27 @@ func downsampleBucket(
 			if m.MaxTime-m.MinTime < downsample.DownsampleRange0 {
 				continue
 			}
-			if err := processDownsampling(ctx, logger, bkt, m, dir, downsample.ResLevel1); err != nil {
+			ok, err := downsampleLocked(ctx, logger, bkt, locks, m, dir, downsample.ResLevel1, dropCounterMinMax)
+			if err != nil {
+				if compact.IsUnsupportedChunkEncodingError(err) {
+					if err := markUnsupportedChunkEncoding(ctx, logger, bkt, err); err != nil {
+						return errors.Wrap(err, "downsampling to 5 min")
+					}
+					continue
+				}
 				metrics.downsampleFailures.WithLabelValues(compact.GroupKey(m.Thanos)).Inc()
 				return errors.Wrap(err, "downsampling to 5 min")
 			}
+			if !ok {
+				continue
+			}
 			metrics.downsamples.WithLabelValues(compact.GroupKey(m.Thanos)).Inc()
+			downsampled[m.ULID] = struct{}{}
 
 		case downsample.ResLevel1:
 			missing := false
 			for _, id := range m.Compaction.Sources {
-				if _, ok := sources1h[id]; !ok {
+				if _, ok := sources1h[downsampleSource{id: id, shard: m.Thanos.Shard.String()}]; !ok {
 					missing = true
 					break
 				}
@@ -236,17 +366,65 @@ func downsampleBucket(
 			if m.MaxTime-m.MinTime < downsample.DownsampleRange1 {
 				continue
 			}
-			if err := processDownsampling(ctx, logger, bkt, m, dir, downsample.ResLevel2); err != nil {
-				metrics.downsampleFailures.WithLabelValues(compact.GroupKey(m.Thanos))
+			ok, err := downsampleLocked(ctx, logger, bkt, locks, m, dir, downsample.ResLevel2, dropCounterMinMax)
+			if err != nil {
+				if compact.IsUnsupportedChunkEncodingError(err) {
+					if err := markUnsupportedChunkEncoding(ctx, logger, bkt, err); err != nil {
+						return errors.Wrap(err, "downsampling to 60 min")
+					}
+					continue
+				}
+				metrics.downsampleFailures.WithLabelValues(compact.GroupKey(m.Thanos)).Inc()
 				return errors.Wrap(err, "downsampling to 60 min")
 			}
-			metrics.downsamples.WithLabelValues(compact.GroupKey(m.Thanos))
+			if !ok {
+				continue
+			}
+			metrics.downsamples.WithLabelValues(compact.GroupKey(m.Thanos)).Inc()
+			downsampled[m.ULID] = struct{}{}
+		}
+	}
+
+	missing := map[int64]float64{downsample.ResLevel1: 0, downsample.ResLevel2: 0}
+	for _, d := range verifier.MissingDownsamples(metas) {
+		if _, ok := downsampled[d.Meta.ULID]; !ok {
+			missing[d.Resolution]++
 		}
 	}
+	metrics.missingDownsamples.WithLabelValues("5m").Set(missing[downsample.ResLevel1])
+	metrics.missingDownsamples.WithLabelValues("1h").Set(missing[downsample.ResLevel2])
 	return nil
 }
 
-func processDownsampling(ctx context.Context, logger log.Logger, bkt objstore.Bucket, m *metadata.Meta, dir string, resolution int64) error {
+// markUnsupportedChunkEncoding marks the block with chunks of unsupported encodings for no compaction, so newer format
+// blocks do not fail downsampling of all other blocks.
+func markUnsupportedChunkEncoding(ctx context.Context, logger log.Logger, bkt objstore.Bucket, encErr error) error {
+	level.Warn(logger).Log("msg", "excluding block with unsupported chunk encodings from downsampling", "err", encErr)
+	_, err := compact.MarkUnsupportedChunkEncoding(ctx, logger, bkt, encErr)
+	return err
+}
+
+// downsampleLocked downsamples the block to the given resolution while holding the lock of its group, so it is not
+// compacted concurrently. It returns false if the block is skipped, because it was deleted meanwhile, e.g. as
+// compacted by a concurrent compaction.
+func downsampleLocked(ctx context.Context, logger log.Logger, bkt objstore.Bucket, locks *compact.GroupLocks, m *metadata.Meta, dir string, resolution int64, dropCounterMinMax bool) (bool, error) {
+	unlock := locks.Lock(compact.GroupKey(m.Thanos))
+	defer unlock()
+
+	if locks != nil {
+		ok, err := bkt.Exists(ctx, path.Join(m.ULID.String(), block.MetaFilename))
+		if err != nil {
+			return false, errors.Wrap(err, "check block existence")
+		}
+		if !ok {
+			level.Debug(logger).Log("msg", "skipping downsampling of deleted block", "block", m.ULID)
+			return false, nil
+		}
+	}
+	return true, processDownsampling(ctx, logger, bkt, m, dir, resolution, dropCounterMinMax)
+}
+
+func processDownsampling(ctx context.Context, logger log.Logger, bkt objstore.Bucket, m *metadata.Meta, dir string, resolution int64, dropCounterMinMax bool) error {
 	begin := time.Now()
 	bdir := filepath.Join(dir, m.ULID.String())
 
@@ -260,6 +438,10 @@ func processDownsampling(ctx context.Context, logger log.Logger, bkt objstore.Bu
 		return errors.Wrap(err, "input block index not valid")
 	}
 
+	if err := compact.CheckChunkEncodings(bdir, m); err != nil {
+		return err
+	}
+
 	begin = time.Now()
 
 	var pool chunkenc.Pool
@@ -275,7 +457,7 @@ func processDownsampling(ctx context.Context, logger log.Logger, bkt objstore.Bu
 	}
 	defer runutil.CloseWithLogOnErr(log.With(logger, "outcome", "potential left mmap file handlers left"), b, "tsdb reader")
 
-	id, err := downsample.Downsample(logger, m, b, dir, resolution)
+	id, err := downsample.Downsample(logger, m, b, dir, resolution, dropCounterMinMax)
 	if err != nil {
 		return errors.Wrapf(err, "downsample block %s to window %d", m.ULID, resolution)
 	}
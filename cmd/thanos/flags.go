@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/go-kit/kit/log"
 	"github.com/thanos-io/thanos/pkg/extflag"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
 
 	"github.com/prometheus/common/model"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -15,6 +18,7 @@ func regGRPCFlags(cmd *kingpin.CmdClause) (
 	grpcTLSSrvCert *string,
 	grpcTLSSrvKey *string,
 	grpcTLSSrvClientCA *string,
+	grpcTLSSrvOpts *thanostls.Options,
 ) {
 	grpcBindAddr = cmd.Flag("grpc-address", "Listen ip:port address for gRPC endpoints (StoreAPI). Make sure this address is routable from other components.").
 		Default("0.0.0.0:10901").String()
@@ -22,11 +26,56 @@ func regGRPCFlags(cmd *kingpin.CmdClause) (
 	grpcTLSSrvCert = cmd.Flag("grpc-server-tls-cert", "TLS Certificate for gRPC server, leave blank to disable TLS").Default("").String()
 	grpcTLSSrvKey = cmd.Flag("grpc-server-tls-key", "TLS Key for the gRPC server, leave blank to disable TLS").Default("").String()
 	grpcTLSSrvClientCA = cmd.Flag("grpc-server-tls-client-ca", "TLS CA to verify clients against. If no client CA is specified, there is no client verification on server side. (tls.NoClientCert)").Default("").String()
+	grpcTLSSrvOpts = &thanostls.Options{}
+	regTLSOptionsFlags(cmd, "grpc-server", grpcTLSSrvOpts)
 
 	return grpcBindAddr,
 		grpcTLSSrvCert,
 		grpcTLSSrvKey,
-		grpcTLSSrvClientCA
+		grpcTLSSrvClientCA,
+		grpcTLSSrvOpts
+}
+
+// httpClientTLSFlags are the flags of the TLS configuration of an HTTP client, used for https URLs.
+type httpClientTLSFlags struct {
+	cert       *string
+	key        *string
+	caCert     *string
+	serverName *string
+	opts       *thanostls.Options
+}
+
+// regHTTPClientTLSFlags registers the flags of the TLS configuration of the HTTP client requesting the given servers.
+func regHTTPClientTLSFlags(cmd *kingpin.CmdClause, prefix string, servers string) *httpClientTLSFlags {
+	f := &httpClientTLSFlags{opts: &thanostls.Options{}}
+	f.cert = cmd.Flag(prefix+"-tls-cert", fmt.Sprintf("TLS Certificates to use to identify this client to %s over https.", servers)).Default("").String()
+	f.key = cmd.Flag(prefix+"-tls-key", "TLS Key for the client's certificate").Default("").String()
+	f.caCert = cmd.Flag(prefix+"-tls-ca", fmt.Sprintf("TLS CA Certificates to use to verify %s over https. If empty, the system certificate pool is used.", servers)).Default("").String()
+	f.serverName = cmd.Flag(prefix+"-server-name", "Server name to verify the hostname on the returned certificates. See https://tools.ietf.org/html/rfc4366#section-3.1").Default("").String()
+	regTLSOptionsFlags(cmd, prefix, f.opts)
+	return f
+}
+
+// transport returns an HTTP transport using the TLS configuration of the flags for https URLs.
+func (f *httpClientTLSFlags) transport(logger log.Logger) (*http.Transport, error) {
+	tlsCfg, err := thanostls.NewClientConfig(logger, *f.cert, *f.key, *f.caCert, *f.serverName, *f.opts)
+	if err != nil {
+		return nil, err
+	}
+	return thanostls.NewTransport(tlsCfg), nil
+}
+
+// regTLSOptionsFlags registers the flags of the TLS versions and cipher suites of the given gRPC or HTTP server or
+// client.
+func regTLSOptionsFlags(cmd *kingpin.CmdClause, prefix string, opts *thanostls.Options) {
+	help := ""
+	if thanostls.FIPS {
+		help = " Built in FIPS mode: only TLS 1.2 with FIPS-approved cipher suites is allowed."
+	}
+	cmd.Flag(prefix+"-tls-min-version", "Minimum TLS version, one of 1.0, 1.1, 1.2 and 1.3."+help).
+		Default("1.2").EnumVar(&opts.MinVersion, "1.0", "1.1", "1.2", "1.3")
+	cmd.Flag(prefix+"-tls-cipher-suite", "Cipher suite allowed for TLS 1.2 and below, as named by Go's crypto/tls. Repeat to allow multiple suites. If none is given, Go's default suites are allowed."+help).
+		PlaceHolder("<suite>").EnumsVar(&opts.CipherSuites, thanostls.CipherSuiteNames()...)
 }
 
 func regHTTPAddrFlag(cmd *kingpin.CmdClause) *string {
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// maxGCPercent is the highest GOGC which can be set, both by flag and at runtime. Higher values let the heap grow
+// so much between collections that the process is likely to run out of memory.
+const maxGCPercent = 1000
+
+// minRuntimeGCPercent is the lowest GOGC which can be set at runtime. Lower values make the process spend most of its
+// CPU time on GC, disabling GC is only possible by flag.
+const minRuntimeGCPercent = 10
+
+// gcConfig configures memory ballast and GC of the process.
+type gcConfig struct {
+	// ballastBytes is the size of the memory ballast. 0 means no ballast.
+	ballastBytes uint64
+	// maxBallastBytes is the maximum size of the memory ballast, both by flag and at runtime.
+	maxBallastBytes uint64
+	// gcPercent overrides GOGC. 0 means the GOGC environment variable or Go default is kept.
+	gcPercent int
+	// runtimeChanges allows changing ballast and GOGC using POST /debug/gc.
+	runtimeChanges bool
+}
+
+func regGCFlags(cmd *kingpin.CmdClause) func() (gcConfig, error) {
+	ballast := cmd.Flag("debug.memory-ballast", "Size of memory ballast allocated on start-up. Ballast is never touched, so it does not use physical memory, but it increases the heap size GC targets, which reduces GC frequency for processes with small live heaps and high allocation rate. It can be changed at runtime using the /debug/gc endpoint if --debug.gc-runtime-changes is set.").
+		Default("0B").Bytes()
+	maxBallast := cmd.Flag("debug.max-memory-ballast", "Maximum size of memory ballast, given by --debug.memory-ballast or at runtime.").
+		Default("4GiB").Bytes()
+	gcPercent := cmd.Flag("debug.gogc", fmt.Sprintf("Overrides GOGC, the percentage of heap growth since last GC which triggers the next GC. Negative value disables GC. 0 keeps the GOGC environment variable or Go default. At most %d. It can be changed at runtime to a value between %d and %d using the /debug/gc endpoint if --debug.gc-runtime-changes is set.", maxGCPercent, minRuntimeGCPercent, maxGCPercent)).
+		Default("0").Int()
+	runtimeChanges := cmd.Flag("debug.gc-runtime-changes", "Allow changing memory ballast and GOGC at runtime with POST requests to the /debug/gc endpoint. The endpoint is not authenticated, so only enable it if the HTTP address is reachable by trusted clients only. Current values are always served on GET.").
+		Default("false").Bool()
+
+	return func() (gcConfig, error) {
+		conf := gcConfig{
+			ballastBytes:    uint64(*ballast),
+			maxBallastBytes: uint64(*maxBallast),
+			gcPercent:       *gcPercent,
+			runtimeChanges:  *runtimeChanges,
+		}
+		if conf.ballastBytes > conf.maxBallastBytes {
+			return conf, errors.Errorf("invalid argument: --debug.memory-ballast %s exceeds --debug.max-memory-ballast %s", *ballast, *maxBallast)
+		}
+		if conf.gcPercent > maxGCPercent {
+			return conf, errors.Errorf("invalid argument: --debug.gogc %d exceeds %d", conf.gcPercent, maxGCPercent)
+		}
+		return conf, nil
+	}
+}
+
+// gcTuner holds memory ballast and GOGC of the process, allowing to change them at runtime.
+type gcTuner struct {
+	logger          log.Logger
+	maxBallastBytes uint64
+	runtimeChanges  bool
+
+	mtx       sync.Mutex
+	ballast   []byte
+	gcPercent int
+
+	ballastBytes prometheus.Gauge
+	gcPercentage prometheus.Gauge
+}
+
+// newGCTuner applies the given config to the process.
+func newGCTuner(logger log.Logger, reg prometheus.Registerer, conf gcConfig) *gcTuner {
+	t := &gcTuner{
+		logger:          logger,
+		maxBallastBytes: conf.maxBallastBytes,
+		runtimeChanges:  conf.runtimeChanges,
+		ballastBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_memory_ballast_bytes",
+			Help: "Size of the allocated memory ballast.",
+		}),
+		gcPercentage: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_gc_percent",
+			Help: "Current GOGC value. Negative value means GC is disabled.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(t.ballastBytes, t.gcPercentage)
+	}
+
+	// SetGCPercent is the only way to read the current value, so set and restore it.
+	t.gcPercent = debug.SetGCPercent(100)
+	debug.SetGCPercent(t.gcPercent)
+	if conf.gcPercent != 0 {
+		t.setGCPercent(conf.gcPercent)
+	}
+	t.gcPercentage.Set(float64(t.gcPercent))
+
+	t.setBallast(conf.ballastBytes)
+	return t
+}
+
+func (t *gcTuner) setGCPercent(percent int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	debug.SetGCPercent(percent)
+	t.gcPercent = percent
+	t.gcPercentage.Set(float64(percent))
+	level.Info(t.logger).Log("msg", "set GOGC", "value", percent)
+}
+
+func (t *gcTuner) setBallast(size uint64) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if uint64(len(t.ballast)) == size {
+		return
+	}
+	// Drop the old ballast before allocating the new one. It is released by the next GC.
+	t.ballast = nil
+	if size > 0 {
+		t.ballast = make([]byte, size)
+	}
+	t.ballastBytes.Set(float64(size))
+	level.Info(t.logger).Log("msg", "set memory ballast", "size", units.Base2Bytes(size))
+}
+
+func (t *gcTuner) String() string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return fmt.Sprintf("gogc=%d memory-ballast=%d\n", t.gcPercent, len(t.ballast))
+}
+
+// ServeHTTP returns current settings on GET. On POST, it applies the "gogc" and "memory-ballast" form
+// values if given, e.g. POST /debug/gc?gogc=50&memory-ballast=1GB, if runtime changes are enabled.
+func (t *gcTuner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !t.runtimeChanges {
+			http.Error(w, "runtime changes are disabled, enable them with --debug.gc-runtime-changes", http.StatusForbidden)
+			return
+		}
+		if err := t.apply(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	_, _ = fmt.Fprint(w, t.String())
+}
+
+func (t *gcTuner) apply(r *http.Request) error {
+	var (
+		gcPercent  int
+		ballast    units.Base2Bytes
+		hasGOGC    = r.FormValue("gogc") != ""
+		hasBallast = r.FormValue("memory-ballast") != ""
+		err        error
+	)
+	if hasGOGC {
+		if gcPercent, err = strconv.Atoi(r.FormValue("gogc")); err != nil {
+			return errors.Wrap(err, "parse gogc")
+		}
+		if gcPercent < minRuntimeGCPercent || gcPercent > maxGCPercent {
+			return errors.Errorf("gogc must be between %d and %d", minRuntimeGCPercent, maxGCPercent)
+		}
+	}
+	if hasBallast {
+		if ballast, err = units.ParseBase2Bytes(r.FormValue("memory-ballast")); err != nil {
+			return errors.Wrap(err, "parse memory-ballast")
+		}
+		if ballast < 0 {
+			return errors.New("memory-ballast must not be negative")
+		}
+		if uint64(ballast) > t.maxBallastBytes {
+			return errors.Errorf("memory-ballast must not exceed %s", units.Base2Bytes(t.maxBallastBytes))
+		}
+	}
+
+	if hasGOGC {
+		t.setGCPercent(gcPercent)
+	}
+	if hasBallast {
+		t.setBallast(uint64(ballast))
+	}
+	return nil
+}
+
+// handler returns HTTP handler serving the /debug/gc endpoint.
+func (t *gcTuner) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/gc", t)
+	return mux
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func Test_gcTuner(t *testing.T) {
+	prev := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(prev)
+
+	tuner := newGCTuner(log.NewNopLogger(), prometheus.NewRegistry(), gcConfig{ballastBytes: 1024, maxBallastBytes: 4096, gcPercent: 200, runtimeChanges: true})
+	testutil.Equals(t, 1024, len(tuner.ballast))
+	testutil.Equals(t, 200, debug.SetGCPercent(200))
+	testutil.Equals(t, 200.0, promtest.ToFloat64(tuner.gcPercentage))
+
+	srv := httptest.NewServer(tuner.handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/gc")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	testutil.Ok(t, err)
+	testutil.Ok(t, resp.Body.Close())
+	testutil.Equals(t, http.StatusOK, resp.StatusCode)
+	testutil.Equals(t, "gogc=200 memory-ballast=1024\n", string(b))
+
+	resp, err = http.Post(srv.URL+"/debug/gc?gogc=50&memory-ballast=2KiB", "", nil)
+	testutil.Ok(t, err)
+	b, err = ioutil.ReadAll(resp.Body)
+	testutil.Ok(t, err)
+	testutil.Ok(t, resp.Body.Close())
+	testutil.Equals(t, http.StatusOK, resp.StatusCode)
+	testutil.Equals(t, "gogc=50 memory-ballast=2048\n", string(b))
+	testutil.Equals(t, 50, debug.SetGCPercent(50))
+	testutil.Equals(t, 2048.0, promtest.ToFloat64(tuner.ballastBytes))
+
+	for _, query := range []string{"gogc=0", "gogc=-1", "gogc=5", "gogc=2000", "gogc=abc", "memory-ballast=-1KB", "memory-ballast=8KiB", "memory-ballast=abc"} {
+		resp, err = http.Post(srv.URL+"/debug/gc?"+query, "", nil)
+		testutil.Ok(t, err)
+		testutil.Ok(t, resp.Body.Close())
+		testutil.Equals(t, http.StatusBadRequest, resp.StatusCode)
+	}
+	// Invalid requests must not change anything.
+	testutil.Equals(t, "gogc=50 memory-ballast=2048\n", tuner.String())
+}
+
+func Test_gcTuner_RuntimeChangesDisabled(t *testing.T) {
+	prev := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(prev)
+
+	tuner := newGCTuner(log.NewNopLogger(), prometheus.NewRegistry(), gcConfig{ballastBytes: 1024, maxBallastBytes: 4096, gcPercent: 200})
+
+	srv := httptest.NewServer(tuner.handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/gc")
+	testutil.Ok(t, err)
+	testutil.Ok(t, resp.Body.Close())
+	testutil.Equals(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+"/debug/gc?gogc=50&memory-ballast=2KiB", "", nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, resp.Body.Close())
+	testutil.Equals(t, http.StatusForbidden, resp.StatusCode)
+	testutil.Equals(t, "gogc=200 memory-ballast=1024\n", tuner.String())
+}
+
+func Test_regGCFlags(t *testing.T) {
+	for _, tcase := range []struct {
+		args []string
+		ok   bool
+	}{
+		{args: nil, ok: true},
+		{args: []string{"--debug.gogc=1000", "--debug.memory-ballast=4GiB"}, ok: true},
+		{args: []string{"--debug.gogc=-1"}, ok: true},
+		{args: []string{"--debug.gogc=1001"}, ok: false},
+		{args: []string{"--debug.memory-ballast=5GiB"}, ok: false},
+		{args: []string{"--debug.memory-ballast=5GiB", "--debug.max-memory-ballast=8GiB"}, ok: true},
+	} {
+		app := kingpin.New("test", "")
+		conf := regGCFlags(app.Command("cmd", ""))
+		_, err := app.Parse(append([]string{"cmd"}, tcase.args...))
+		testutil.Ok(t, err)
+
+		_, err = conf()
+		testutil.Assert(t, tcase.ok == (err == nil), "args %v: unexpected error %v", tcase.args, err)
+	}
+}
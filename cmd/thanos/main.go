@@ -2,11 +2,8 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"math"
 	"net"
 	"net/http"
@@ -31,10 +28,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/prober"
+	"github.com/thanos-io/thanos/pkg/requestid"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/tracing"
 	"github.com/thanos-io/thanos/pkg/tracing/client"
 	"go.uber.org/automaxprocs/maxprocs"
@@ -82,6 +82,7 @@ func main() {
 	registerDownsample(cmds, app)
 	registerReceive(cmds, app)
 	registerChecks(cmds, app, "check")
+	registerTools(cmds, app, "tools")
 
 	cmd, err := app.Parse(os.Args[1:])
 	if err != nil {
@@ -196,6 +197,17 @@ func main() {
 		})
 	}
 
+	// Serve the resolved configuration on /api/v1/status/config of every command.
+	{
+		c, err := newResolvedConfig(app, cmd)
+		if err == nil {
+			configStatus, err = configStatusHandler(c)
+		}
+		if err != nil {
+			level.Warn(logger).Log("msg", "resolving configuration failed, it will not be served", "err", err)
+		}
+	}
+
 	if err := cmds[cmd](&g, logger, metrics, tracer, *logLevel == "debug"); err != nil {
 		level.Error(logger).Log("err", errors.Wrapf(err, "%s command failed", cmd))
 		os.Exit(1)
@@ -242,51 +254,13 @@ func registerMetrics(mux *http.ServeMux, g prometheus.Gatherer) {
 	mux.Handle("/metrics", promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
 }
 
-func defaultGRPCServerOpts(logger log.Logger, cert, key, clientCA string) ([]grpc.ServerOption, error) {
+func defaultGRPCServerOpts(logger log.Logger, cert, key, clientCA string, tlsOpts thanostls.Options) ([]grpc.ServerOption, error) {
 	opts := []grpc.ServerOption{}
 
-	if key == "" && cert == "" {
-		if clientCA != "" {
-			return nil, errors.New("when a client CA is used a server key and certificate must also be provided")
-		}
-
-		level.Info(logger).Log("msg", "disabled TLS, key and cert must be set to enable")
-		return opts, nil
-	}
-
-	if key == "" || cert == "" {
-		return nil, errors.New("both server key and certificate must be provided")
-	}
-
-	tlsCfg := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
-
-	tlsCert, err := tls.LoadX509KeyPair(cert, key)
-	if err != nil {
-		return nil, errors.Wrap(err, "server credentials")
-	}
-
-	level.Info(logger).Log("msg", "enabled gRPC server side TLS")
-
-	tlsCfg.Certificates = []tls.Certificate{tlsCert}
-
-	if clientCA != "" {
-		caPEM, err := ioutil.ReadFile(clientCA)
-		if err != nil {
-			return nil, errors.Wrap(err, "reading client CA")
-		}
-
-		certPool := x509.NewCertPool()
-		if !certPool.AppendCertsFromPEM(caPEM) {
-			return nil, errors.Wrap(err, "building client CA")
-		}
-		tlsCfg.ClientCAs = certPool
-		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
-
-		level.Info(logger).Log("msg", "gRPC server TLS client verification enabled")
+	tlsCfg, err := thanostls.NewServerConfig(logger, cert, key, clientCA, tlsOpts)
+	if err != nil || tlsCfg == nil {
+		return opts, err
 	}
-
 	return append(opts, grpc.Creds(credentials.NewTLS(tlsCfg))), nil
 }
 
@@ -313,11 +287,13 @@ func newStoreGRPCServer(logger log.Logger, reg prometheus.Registerer, tracer ope
 		grpc_middleware.WithUnaryServerChain(
 			met.UnaryServerInterceptor(),
 			tracing.UnaryServerInterceptor(tracer),
+			requestid.UnaryServerInterceptor(),
 			grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
 		),
 		grpc_middleware.WithStreamServerChain(
 			met.StreamServerInterceptor(),
 			tracing.StreamServerInterceptor(tracer),
+			requestid.StreamServerInterceptor(),
 			grpc_recovery.StreamServerInterceptor(grpc_recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
 		),
 	)
@@ -329,6 +305,20 @@ func newStoreGRPCServer(logger log.Logger, reg prometheus.Registerer, tracer ope
 	return s
 }
 
+// newActiveQueryTracker returns a tracker of queries in flight logging them to a memory mapped file in the given
+// directory. Unlike promql.NewActiveQueryTracker, which panics if the log cannot be created, it returns an error.
+func newActiveQueryTracker(logger log.Logger, dir string, maxQueries int) (tracker *promql.ActiveQueryTracker, err error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, errors.Wrap(err, "create active query log dir")
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tracker, err = nil, errors.Errorf("create active query log in %s: %v", dir, p)
+		}
+	}()
+	return promql.NewActiveQueryTracker(dir, maxQueries, logger), nil
+}
+
 // scheduleHTTPServer starts a run.Group that servers HTTP endpoint with default endpoints providing Prometheus metrics,
 // profiling and liveness/readiness probes.
 func scheduleHTTPServer(g *run.Group, logger log.Logger, reg *prometheus.Registry, readinessProber *prober.Prober, httpBindAddr string, handler http.Handler, comp component.Component) error {
@@ -336,6 +326,9 @@ func scheduleHTTPServer(g *run.Group, logger log.Logger, reg *prometheus.Registr
 	registerMetrics(mux, reg)
 	registerProfile(mux)
 	readinessProber.RegisterInMux(mux)
+	if configStatus != nil {
+		mux.Handle("/api/v1/status/config", configStatus)
+	}
 	if handler != nil {
 		mux.Handle("/", handler)
 	}
@@ -112,9 +112,63 @@ func TestCleanupDownsampleCacheFolder(t *testing.T) {
 
 	metrics := newDownsampleMetrics(prometheus.NewRegistry())
 	testutil.Equals(t, 0.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(meta.Thanos))))
-	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, dir))
+	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, dir, nil, nil, nil, nil, compact.NewGroupLocks(), 0, false, nil))
 	testutil.Equals(t, 1.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(meta.Thanos))))
 
 	_, err = os.Stat(dir)
 	testutil.Assert(t, os.IsNotExist(err), "index cache dir shouldn't not exist at the end of execution")
 }
+
+func TestDownsampleBucket_NoCompactReasons(t *testing.T) {
+	logger := log.NewNopLogger()
+	dir, err := ioutil.TempDir("", "test-downsample-no-compact-reasons")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bkt := inmem.NewBucket()
+	id, err := testutil.CreateBlock(
+		ctx,
+		dir,
+		[]labels.Labels{{{Name: "a", Value: "1"}}},
+		1, 0, downsample.DownsampleRange0+1,
+		labels.Labels{{Name: "e1", Value: "1"}},
+		downsample.ResLevel0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, logger, bkt, path.Join(dir, id.String())))
+	testutil.Ok(t, block.MarkForNoCompact(ctx, logger, bkt, id, metadata.ManualNoCompactReason, "test"))
+
+	meta, err := block.DownloadMeta(ctx, logger, bkt, id)
+	testutil.Ok(t, err)
+	metrics := newDownsampleMetrics(prometheus.NewRegistry())
+	workDir := filepath.Join(dir, "downsample")
+
+	// Marks of all reasons exclude blocks by default.
+	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, workDir, nil, nil, nil, nil, nil, 0, false, nil))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(meta.Thanos))))
+
+	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, workDir, nil, nil, nil, nil, nil, 0, false, []metadata.NoCompactReason{metadata.UnsupportedChunkEncodingNoCompactReason}))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(meta.Thanos))))
+}
+
+func TestNewActiveQueryTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-active-query-tracker")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	tracker, err := newActiveQueryTracker(log.NewNopLogger(), filepath.Join(dir, "queries"), 2)
+	testutil.Ok(t, err)
+	testutil.Assert(t, tracker != nil, "expected tracker")
+
+	// A file is in the way of the directory.
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0666))
+	_, err = newActiveQueryTracker(log.NewNopLogger(), filepath.Join(dir, "file"), 2)
+	testutil.NotOk(t, err)
+
+	// The directory exists, but the log cannot be created in it.
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "queries.active", "queries.active"), 0777))
+	_, err = newActiveQueryTracker(log.NewNopLogger(), filepath.Join(dir, "queries.active"), 2)
+	testutil.NotOk(t, err)
+}
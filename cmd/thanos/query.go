@@ -2,14 +2,13 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
-	"io/ioutil"
 	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -33,8 +32,12 @@ import (
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/query"
 	v1 "github.com/thanos-io/thanos/pkg/query/api"
+	"github.com/thanos-io/thanos/pkg/receive"
+	"github.com/thanos-io/thanos/pkg/requestid"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/tracing"
 	"github.com/thanos-io/thanos/pkg/ui"
 	"google.golang.org/grpc"
@@ -48,13 +51,15 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(comp.String(), "query node exposing PromQL enabled Query API with data retrieved from multiple store nodes")
 
 	httpBindAddr := regHTTPAddrFlag(cmd)
-	grpcBindAddr, srvCert, srvKey, srvClientCA := regGRPCFlags(cmd)
+	grpcBindAddr, srvCert, srvKey, srvClientCA, srvTLSOpts := regGRPCFlags(cmd)
 
 	secure := cmd.Flag("grpc-client-tls-secure", "Use TLS when talking to the gRPC server").Default("false").Bool()
 	cert := cmd.Flag("grpc-client-tls-cert", "TLS Certificates to use to identify this client to the server").Default("").String()
 	key := cmd.Flag("grpc-client-tls-key", "TLS Key for the client's certificate").Default("").String()
 	caCert := cmd.Flag("grpc-client-tls-ca", "TLS CA Certificates to use to verify gRPC servers").Default("").String()
 	serverName := cmd.Flag("grpc-client-server-name", "Server name to verify the hostname on the returned gRPC certificates. See https://tools.ietf.org/html/rfc4366#section-3.1").Default("").String()
+	clientTLSOpts := &thanostls.Options{}
+	regTLSOptionsFlags(cmd, "grpc-client", clientTLSOpts)
 
 	webRoutePrefix := cmd.Flag("web.route-prefix", "Prefix for API and UI endpoints. This allows thanos UI to be served on a sub-path. This option is analogous to --web.route-prefix of Promethus.").Default("").String()
 	webExternalPrefix := cmd.Flag("web.external-prefix", "Static prefix for all HTML links and redirect URLs in the UI query web interface. Actual endpoints are still served on / or the web.route-prefix. This allows thanos UI to be served behind a reverse proxy that strips a URL sub-path.").Default("").String()
@@ -66,17 +71,34 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 	maxConcurrentQueries := cmd.Flag("query.max-concurrent", "Maximum number of queries processed concurrently by query node.").
 		Default("20").Int()
 
+	activeQueryLogDir := cmd.Flag("query.active-query-log-dir", "Directory of the log of queries in flight, in the format of the Prometheus active query log. The log is memory mapped, so queries in flight during a crash, e.g. an OOM kill, are logged on the next start. Empty disables the log.").
+		Default("").String()
+
 	replicaLabels := cmd.Flag("query.replica-label", "Labels to treat as a replica indicator along which data is deduplicated. Still you will be able to query without deduplication using 'dedup=false' parameter.").
 		Strings()
 
 	instantDefaultMaxSourceResolution := modelDuration(cmd.Flag("query.instant.default.max_source_resolution", "default value for max_source_resolution for instant queries. If not set, defaults to 0s only taking raw resolution into account. 1h can be a good value if you use instant queries over time ranges that incorporate times outside of your raw-retention.").Default("0s").Hidden())
 
+	mirrorURL := cmd.Flag("query.mirror-url", "URL of a secondary query API (prefix of /api/v1, e.g. another Thanos Querier) to mirror a percentage of instant and range queries to. Results of mirrored queries are compared with the primary results and divergences are exported as metrics. Primary responses are not affected.").URL()
+
+	mirrorPercentage := cmd.Flag("query.mirror-percentage", "Percentage of successful instant and range queries mirrored to --query.mirror-url.").
+		Default("0").Float64()
+
 	selectorLabels := cmd.Flag("selector-label", "Query selector labels that will be exposed in info endpoint (repeated).").
 		PlaceHolder("<name>=\"<value>\"").Strings()
 
 	stores := cmd.Flag("store", "Addresses of statically configured store API servers (repeatable). The scheme may be prefixed with 'dns+' or 'dnssrv+' to detect store API servers through respective DNS lookups.").
 		PlaceHolder("<store>").Strings()
 
+	storeViewFlags := cmd.Flag("store.view", "Named view of store API servers (repeatable). Query API requests with the view name in the --query.store-view-header header query only stores of the view. The store address has the same format as --store flag. Stores of views are not queried by requests without the header or by the StoreAPI of the querier, unless also given by --store or --store.sd-files.").
+		PlaceHolder("<name>=<store>").Strings()
+
+	storeViewHeader := cmd.Flag("query.store-view-header", "Name of HTTP request header selecting the view of store API servers used by the query API request. See --store.view.").
+		Default("X-Thanos-Store-View").String()
+
+	ruleAPIFlags := cmd.Flag("store.rules-api", "Rules API of a ruler by the address of its StoreAPI as given by the store set (repeatable). Series requests selecting a recording rule of rulers covering the requested time range skip stores serving only external labels of these rulers, e.g. store gateways of the bucket the rulers upload to.").
+		PlaceHolder("<store>=<url>").Strings()
+
 	fileSDFiles := cmd.Flag("store.sd-files", "Path to files that contain addresses of store API servers. The path can be a glob pattern (repeatable).").
 		PlaceHolder("<path>").Strings()
 
@@ -95,10 +117,28 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 	enableAutodownsampling := cmd.Flag("query.auto-downsampling", "Enable automatic adjustment (step / 5) to what source of data should be used in store gateways if no max_source_resolution param is specified.").
 		Default("false").Bool()
 
+	downsampleRawData := cmd.Flag("query.downsample-raw-data", "Downsample raw data on the fly if max_source_resolution allows downsampled data, but stores (e.g sidecar, ruler or receive) only have raw data for the queried range. This gives uniform resolution across the whole queried range.").
+		Default("false").Bool()
+
+	coalesceSelects := cmd.Flag("query.coalesce-selects", "Execute identical series requests of concurrent queries, e.g. of repeated panels of a dashboard, only once and share their result.").
+		Default("false").Bool()
+
+	coalesceSelectsMaxSize := cmd.Flag("query.coalesce-selects.max-size", "Maximum size of results of series requests shared by coalesced requests. Requests sharing larger results are executed on their own. 0 shares results of any size.").
+		Default("64MB").Bytes()
+
+	preferFastestReplica := cmd.Flag("store.prefer-fastest-replica", "Query only the store with the lowest latency of series requests out of stores of the same type with identical external labels and time range, e.g. replicas of store gateways of the same bucket, instead of all of them. Such stores must serve the same data.").
+		Default("false").Bool()
+
 	enablePartialResponse := cmd.Flag("query.partial-response", "Enable partial response for queries if no partial_response param is specified. --no-query.partial-response for disabling.").
 		Default("true").Bool()
 
-	defaultEvaluationInterval := modelDuration(cmd.Flag("query.default-evaluation-interval", "Set default evaluation interval for sub queries.").Default("1m"))
+	defaultEvaluationInterval := modelDuration(cmd.Flag("query.default-evaluation-interval", "Set default evaluation interval for sub queries, used as step of subqueries without step, e.g. [1h:].").Default("1m"))
+
+	maxSubqueryPoints := cmd.Flag("query.max-subquery-points", "Maximum number of points selected by a single subquery per evaluation, e.g. 2592000 for [30d:1s]. Queries with subqueries selecting more points are rejected before evaluation. 0 disables the limit.").
+		Default("0").Int()
+
+	tenantHeader := cmd.Flag("query.tenant-header", "HTTP header of query API requests determining their tenant, which is sent to StoreAPI servers as gRPC metadata with the lower-cased header as the key, e.g. for per tenant limits of receivers, see --receive.tenant-header. Empty disables propagation of tenants.").
+		Default(receive.DefaultTenantHeader).String()
 
 	storeResponseTimeout := modelDuration(cmd.Flag("store.response-timeout", "If a Store doesn't send any data in this specified duration then a Store will be ignored and partial data will be returned if it's enabled. 0 disables timeout.").Default("0ms"))
 
@@ -117,6 +157,16 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 			lookupStores[s] = struct{}{}
 		}
 
+		storeViews, err := parseStoreViews(*storeViewFlags)
+		if err != nil {
+			return errors.Wrap(err, "parse store views")
+		}
+
+		ruleAPIs, err := parseRuleAPIs(*ruleAPIFlags)
+		if err != nil {
+			return errors.Wrap(err, "parse rules APIs")
+		}
+
 		var fileSD *file.Discovery
 		if len(*fileSDFiles) > 0 {
 			conf := &file.SDConfig{
@@ -137,34 +187,48 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 			*srvCert,
 			*srvKey,
 			*srvClientCA,
+			*srvTLSOpts,
 			*secure,
 			*cert,
 			*key,
 			*caCert,
 			*serverName,
+			*clientTLSOpts,
 			*httpBindAddr,
 			*webRoutePrefix,
 			*webExternalPrefix,
 			*webPrefixHeaderName,
 			*maxConcurrentQueries,
+			*activeQueryLogDir,
 			time.Duration(*queryTimeout),
 			time.Duration(*storeResponseTimeout),
 			*replicaLabels,
 			selectorLset,
 			*stores,
+			storeViews,
+			*storeViewHeader,
+			ruleAPIs,
 			*enableAutodownsampling,
+			*downsampleRawData,
+			*coalesceSelects,
+			int(*coalesceSelectsMaxSize),
+			*preferFastestReplica,
 			*enablePartialResponse,
 			fileSD,
 			time.Duration(*dnsSDInterval),
 			*dnsSDResolver,
 			time.Duration(*unhealthyStoreTimeout),
 			time.Duration(*instantDefaultMaxSourceResolution),
+			*mirrorURL,
+			*mirrorPercentage,
+			*maxSubqueryPoints,
+			*tenantHeader,
 			component.Query,
 		)
 	}
 }
 
-func storeClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, secure bool, cert, key, caCert string, serverName string) ([]grpc.DialOption, error) {
+func storeClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, secure bool, cert, key, caCert string, serverName string, tlsOpts thanostls.Options) ([]grpc.DialOption, error) {
 	grpcMets := grpc_prometheus.NewClientMetrics()
 	grpcMets.EnableClientHandlingTimeHistogram(
 		grpc_prometheus.WithHistogramBuckets([]float64{
@@ -181,12 +245,14 @@ func storeClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer ope
 			grpc_middleware.ChainUnaryClient(
 				grpcMets.UnaryClientInterceptor(),
 				tracing.UnaryClientInterceptor(tracer),
+				requestid.UnaryClientInterceptor(),
 			),
 		),
 		grpc.WithStreamInterceptor(
 			grpc_middleware.ChainStreamClient(
 				grpcMets.StreamClientInterceptor(),
 				tracing.StreamClientInterceptor(tracer),
+				requestid.StreamClientInterceptor(),
 			),
 		),
 	}
@@ -201,45 +267,10 @@ func storeClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer ope
 
 	level.Info(logger).Log("msg", "Enabling client to server TLS")
 
-	var certPool *x509.CertPool
-
-	if caCert != "" {
-		caPEM, err := ioutil.ReadFile(caCert)
-		if err != nil {
-			return nil, errors.Wrap(err, "reading client CA")
-		}
-
-		certPool = x509.NewCertPool()
-		if !certPool.AppendCertsFromPEM(caPEM) {
-			return nil, errors.Wrap(err, "building client CA")
-		}
-		level.Info(logger).Log("msg", "TLS Client using provided certificate pool")
-	} else {
-		var err error
-		certPool, err = x509.SystemCertPool()
-		if err != nil {
-			return nil, errors.Wrap(err, "reading system certificate pool")
-		}
-		level.Info(logger).Log("msg", "TLS Client using system certificate pool")
-	}
-
-	tlsCfg := &tls.Config{
-		RootCAs: certPool,
-	}
-
-	if serverName != "" {
-		tlsCfg.ServerName = serverName
-	}
-
-	if cert != "" {
-		cert, err := tls.LoadX509KeyPair(cert, key)
-		if err != nil {
-			return nil, errors.Wrap(err, "client credentials")
-		}
-		tlsCfg.Certificates = []tls.Certificate{cert}
-		level.Info(logger).Log("msg", "TLS Client authentication enabled")
+	tlsCfg, err := thanostls.NewClientConfig(logger, cert, key, caCert, serverName, tlsOpts)
+	if err != nil {
+		return nil, err
 	}
-
 	creds := credentials.NewTLS(tlsCfg)
 
 	return append(dialOpts, grpc.WithTransportCredentials(creds)), nil
@@ -256,28 +287,42 @@ func runQuery(
 	srvCert string,
 	srvKey string,
 	srvClientCA string,
+	srvTLSOpts thanostls.Options,
 	secure bool,
 	cert string,
 	key string,
 	caCert string,
 	serverName string,
+	clientTLSOpts thanostls.Options,
 	httpBindAddr string,
 	webRoutePrefix string,
 	webExternalPrefix string,
 	webPrefixHeaderName string,
 	maxConcurrentQueries int,
+	activeQueryLogDir string,
 	queryTimeout time.Duration,
 	storeResponseTimeout time.Duration,
 	replicaLabels []string,
 	selectorLset labels.Labels,
 	storeAddrs []string,
+	storeViews map[string][]string,
+	storeViewHeader string,
+	ruleAPIs map[string]string,
 	enableAutodownsampling bool,
+	downsampleRawData bool,
+	coalesceSelects bool,
+	coalesceSelectsMaxSize int,
+	preferFastestReplica bool,
 	enablePartialResponse bool,
 	fileSD *file.Discovery,
 	dnsSDInterval time.Duration,
 	dnsSDResolver string,
 	unhealthyStoreTimeout time.Duration,
 	instantDefaultMaxSourceResolution time.Duration,
+	mirrorURL *url.URL,
+	mirrorPercentage float64,
+	maxSubqueryPoints int,
+	tenantHeader string,
 	comp component.Component,
 ) error {
 	// TODO(bplotka in PR #513 review): Move arguments into struct.
@@ -287,7 +332,7 @@ func runQuery(
 	})
 	reg.MustRegister(duplicatedStores)
 
-	dialOpts, err := storeClientGRPCOpts(logger, reg, tracer, secure, cert, key, caCert, serverName)
+	dialOpts, err := storeClientGRPCOpts(logger, reg, tracer, secure, cert, key, caCert, serverName, clientTLSOpts)
 	if err != nil {
 		return errors.Wrap(err, "building gRPC client")
 	}
@@ -299,6 +344,27 @@ func runQuery(
 		dns.ResolverType(dnsSDResolver),
 	)
 
+	// rankStores picks stores used by queries out of the given ones.
+	rankStores := func(s func() []store.Client) func() []store.Client { return s }
+	if preferFastestReplica {
+		rankStores = query.PreferFastestReplicas
+	}
+
+	// selectStore wraps stores used by queries.
+	selectStore := func(s storepb.StoreServer) storepb.StoreServer { return s }
+	if coalesceSelects {
+		selectStore = query.NewSelectCoalescer(reg, coalesceSelectsMaxSize).Wrap
+	}
+
+	var activeQueries *promql.ActiveQueryTracker
+	if activeQueryLogDir != "" {
+		var err error
+		activeQueries, err = newActiveQueryTracker(logger, activeQueryLogDir, maxConcurrentQueries)
+		if err != nil {
+			return err
+		}
+	}
+
 	var (
 		stores = query.NewStoreSet(
 			logger,
@@ -316,19 +382,60 @@ func runQuery(
 			dialOpts,
 			unhealthyStoreTimeout,
 		)
-		proxy            = store.NewProxyStore(logger, stores.Get, component.Query, selectorLset, storeResponseTimeout)
-		queryableCreator = query.NewQueryableCreator(logger, proxy)
+		proxy            = store.NewProxyStore(logger, rankStores(defaultStoresOf(stores, dnsProvider, fileSDCache, storeAddrs, storeViews)), component.Query, selectorLset, storeResponseTimeout)
+		queryableCreator = query.NewQueryableCreator(logger, selectStore(proxy), downsampleRawData)
 		engine           = promql.NewEngine(
 			promql.EngineOpts{
 				Logger:        logger,
 				Reg:           reg,
 				MaxConcurrent: maxConcurrentQueries,
 				// TODO(bwplotka): Expose this as a flag: https://github.com/thanos-io/thanos/issues/703.
-				MaxSamples: math.MaxInt32,
-				Timeout:    queryTimeout,
+				MaxSamples:         math.MaxInt32,
+				Timeout:            queryTimeout,
+				ActiveQueryTracker: activeQueries,
 			},
 		)
 	)
+	var ruleRouter *query.RuleRouter
+	if len(ruleAPIs) > 0 {
+		ruleRouter = query.NewRuleRouter(logger, reg, ruleAPIs)
+		proxy.SetSeriesRouter(ruleRouter)
+	}
+	storeViewQueryableCreators := make(map[string]query.QueryableCreator, len(storeViews))
+	for name, addrs := range storeViews {
+		addrs := addrs
+		viewProxy := store.NewProxyStore(
+			log.With(logger, "store_view", name),
+			rankStores(query.StoresOf(stores.Get, func() []string { return dnsProvider.AddressesOf(addrs) })),
+			component.Query,
+			selectorLset,
+			storeResponseTimeout,
+		)
+		if ruleRouter != nil {
+			viewProxy.SetSeriesRouter(ruleRouter)
+		}
+		storeViewQueryableCreators[name] = query.NewQueryableCreator(logger, selectStore(viewProxy), downsampleRawData)
+	}
+	// Periodically update the recording rules of rulers.
+	if ruleRouter != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return runutil.Repeat(30*time.Second, ctx.Done(), func() error {
+				ruleRouter.Update(ctx)
+				return nil
+			})
+		}, func(error) {
+			cancel()
+		})
+	}
+	// resolveAddrs returns addresses of stores from static flags, file SD and store views.
+	resolveAddrs := func() []string {
+		addrs := append(fileSDCache.Addresses(), storeAddrs...)
+		for _, viewAddrs := range storeViews {
+			addrs = append(addrs, viewAddrs...)
+		}
+		return addrs
+	}
 	// Periodically update the store set with the addresses we see in our cluster.
 	{
 		ctx, cancel := context.WithCancel(context.Background())
@@ -367,7 +474,7 @@ func runQuery(
 					}
 					fileSDCache.Update(update)
 					stores.Update(ctxUpdate)
-					dnsProvider.Resolve(ctxUpdate, append(fileSDCache.Addresses(), storeAddrs...))
+					dnsProvider.Resolve(ctxUpdate, resolveAddrs())
 				case <-ctxUpdate.Done():
 					return nil
 				}
@@ -382,7 +489,7 @@ func runQuery(
 		ctx, cancel := context.WithCancel(context.Background())
 		g.Add(func() error {
 			return runutil.Repeat(dnsSDInterval, ctx.Done(), func() error {
-				dnsProvider.Resolve(ctx, append(fileSDCache.Addresses(), storeAddrs...))
+				dnsProvider.Resolve(ctx, resolveAddrs())
 				return nil
 			})
 		}, func(error) {
@@ -411,7 +518,15 @@ func runQuery(
 		ins := extpromhttp.NewInstrumentationMiddleware(reg)
 		ui.NewQueryUI(logger, reg, stores, flagsMap).Register(router.WithPrefix(webRoutePrefix), ins)
 
-		api := v1.NewAPI(logger, reg, engine, queryableCreator, enableAutodownsampling, enablePartialResponse, replicaLabels, instantDefaultMaxSourceResolution)
+		var mirror *v1.Mirror
+		if mirrorURL != nil {
+			mirror, err = v1.NewMirror(log.With(logger, "component", "mirror"), reg, mirrorURL, mirrorPercentage, queryTimeout)
+			if err != nil {
+				return errors.Wrap(err, "create query mirror")
+			}
+		}
+
+		api := v1.NewAPI(logger, reg, engine, queryableCreator, enableAutodownsampling, enablePartialResponse, replicaLabels, instantDefaultMaxSourceResolution, storeViewHeader, storeViewQueryableCreators, mirror, maxSubqueryPoints, tenantHeader)
 
 		api.Register(router.WithPrefix(path.Join(webRoutePrefix, "/api/v1")), tracer, logger, ins)
 
@@ -428,7 +543,7 @@ func runQuery(
 		}
 		logger := log.With(logger, "component", component.Query.String())
 
-		opts, err := defaultGRPCServerOpts(logger, srvCert, srvKey, srvClientCA)
+		opts, err := defaultGRPCServerOpts(logger, srvCert, srvKey, srvClientCA, srvTLSOpts)
 		if err != nil {
 			return errors.Wrap(err, "build gRPC server")
 		}
@@ -447,6 +562,46 @@ func runQuery(
 	return nil
 }
 
+// parseStoreViews parses store view flags in <name>=<store> format into store addresses of each view.
+func parseStoreViews(flags []string) (map[string][]string, error) {
+	views := map[string][]string{}
+	for _, f := range flags {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("unrecognized store view %q, expected <name>=<store>", f)
+		}
+		views[parts[0]] = append(views[parts[0]], parts[1])
+	}
+	return views, nil
+}
+
+// parseRuleAPIs parses <store>=<url> flags into URLs of rules APIs by store address.
+func parseRuleAPIs(flags []string) (map[string]string, error) {
+	apis := map[string]string{}
+	for _, f := range flags {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("unrecognized rules API %q, expected <store>=<url>", f)
+		}
+		if _, ok := apis[parts[0]]; ok {
+			return nil, errors.Errorf("rules API of store %s is duplicated", parts[0])
+		}
+		apis[parts[0]] = parts[1]
+	}
+	return apis, nil
+}
+
+// defaultStoresOf returns stores queried by requests without a store view. If there are store views, stores which
+// are only part of views are excluded.
+func defaultStoresOf(stores *query.StoreSet, dnsProvider *dns.Provider, fileSDCache *cache.Cache, storeAddrs []string, storeViews map[string][]string) func() []store.Client {
+	if len(storeViews) == 0 {
+		return stores.Get
+	}
+	return query.StoresOf(stores.Get, func() []string {
+		return dnsProvider.AddressesOf(append(fileSDCache.Addresses(), storeAddrs...))
+	})
+}
+
 func removeDuplicateStoreSpecs(logger log.Logger, duplicatedStores prometheus.Counter, specs []query.StoreSpec) []query.StoreSpec {
 	set := make(map[string]query.StoreSpec)
 	for _, spec := range specs {
@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/thanos-io/thanos/pkg/extflag"
@@ -17,16 +23,20 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/storage/tsdb"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/exemplars"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/receive"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/shipper"
 	"github.com/thanos-io/thanos/pkg/store"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
 	"google.golang.org/grpc"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
@@ -35,12 +45,19 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 	comp := component.Receive
 	cmd := app.Command(comp.String(), "Accept Prometheus remote write API requests and write to local tsdb (EXPERIMENTAL, this may change drastically without notice)")
 
-	grpcBindAddr, cert, key, clientCA := regGRPCFlags(cmd)
+	grpcBindAddr, cert, key, clientCA, tlsOpts := regGRPCFlags(cmd)
 	httpBindAddr := regHTTPAddrFlag(cmd)
 
 	remoteWriteAddress := cmd.Flag("remote-write.address", "Address to listen on for remote write requests.").
 		Default("0.0.0.0:19291").String()
 
+	rwServerCert := cmd.Flag("remote-write.server-tls-cert", "TLS Certificate for HTTP server, leave blank to disable TLS").Default("").String()
+	rwServerKey := cmd.Flag("remote-write.server-tls-key", "TLS Key for the HTTP server, leave blank to disable TLS").Default("").String()
+	rwServerClientCA := cmd.Flag("remote-write.server-tls-client-ca", "TLS CA to verify clients against. If no client CA is specified, there is no client verification on server side. (tls.NoClientCert)").Default("").String()
+	rwServerTLSOpts := &thanostls.Options{}
+	regTLSOptionsFlags(cmd, "remote-write.server", rwServerTLSOpts)
+	rwClientTLS := regHTTPClientTLSFlags(cmd, "remote-write.client", "other nodes of the hashring forwarded to")
+
 	dataDir := cmd.Flag("tsdb.path", "Data directory of TSDB.").
 		Default("./data").String()
 
@@ -66,12 +83,68 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 
 	tsdbBlockDuration := modelDuration(cmd.Flag("tsdb.block-duration", "Duration for local TSDB blocks").Default("2h").Hidden())
 
+	walSyncPolicy := cmd.Flag("tsdb.wal.fsync-policy", "When to fsync the WAL, which defines how many acknowledged samples can be lost on node crash. "+
+		"'segment': fsync on WAL segment completion only, up to a segment (128MiB) can be lost. "+
+		"'request': fsync before acknowledging each write request (concurrent requests are batched into a single fsync), nothing can be lost. "+
+		"'interval': fsync every --tsdb.wal.fsync-interval, samples written within the interval can be lost. "+
+		"Samples are not lost on crash of the process only, regardless of the policy.").
+		Default(string(receive.WALSyncSegment)).Enum(string(receive.WALSyncSegment), string(receive.WALSyncRequest), string(receive.WALSyncInterval))
+
+	walSyncInterval := modelDuration(cmd.Flag("tsdb.wal.fsync-interval", "Interval of WAL fsyncs with 'interval' fsync policy.").Default("1s"))
+
+	maxExemplars := cmd.Flag("tsdb.max-exemplars", "Maximum number of exemplars of remote write requests kept in memory and served by the Exemplars gRPC API. Once exceeded, oldest exemplars are dropped. 0 disables storage of exemplars.").
+		Default("0").Int()
+
+	readMaxConcurrencyPerTenant := cmd.Flag("receive.read.max-concurrency-per-tenant", "Maximum number of concurrent series requests of the StoreAPI per tenant, given by gRPC metadata with the lower-cased --receive.tenant-header as the key. Requests without tenant are limited together. Requests above the limit are rejected, so heavy queries do not stall ingestion. 0 means no limit.").
+		Default("0").Int()
+
+	tenantHints := extflag.RegisterPathOrContent(cmd, "receive.tenant-hints", "YAML list of hints of tenants for the compactor, written into meta.json of uploaded blocks of the hashring of the node. Each item has a hashring, empty for the default hashring, a tenant of the hashring and optional retention_raw, retention_5m, retention_1h and disable_downsampling fields, taking precedence over the retention and downsampling of the compactor. Blocks hold the series of all tenants of the hashring, so hints are only attached if given for a single tenant of the hashrings of the node.", false)
+
+	readSkipColdTenants := cmd.Flag("receive.read.skip-cold-tenants", "Respond to series requests of tenants, given by gRPC metadata with the lower-cased --receive.tenant-header as the key, without local writes within --tsdb.retention immediately with no data, without querying the TSDB.").
+		Default("false").Bool()
+
+	forwardBufferDir := cmd.Flag("receive.forward-buffer.dir", "Directory of the on-disk buffer of requests forwarded to other nodes of the hashring. Requests to nodes which are unavailable, failing with network errors or 5xx statuses, are buffered and retried, so the nodes catch up once available again. Buffered requests are synced to disk and deduplicated, and succeed the write request. Empty disables the buffer.").
+		Default("").String()
+
+	forwardBufferMaxSize := cmd.Flag("receive.forward-buffer.max-size", "Maximum size of the forward buffer. Requests to unavailable nodes not fitting into the buffer fail the write request.").
+		Default("256MB").Bytes()
+
+	forwardBufferRetryInterval := modelDuration(cmd.Flag("receive.forward-buffer.retry-interval", "Interval of retries of buffered forward requests, also used as the timeout of each retry.").
+		Default("5s"))
+
+	forwardBufferMaxAge := modelDuration(cmd.Flag("receive.forward-buffer.max-age", "Maximum age of buffered forward requests. Older requests are dropped instead of replayed, as the TSDB of the node rejects samples older than half of its block duration. 0 replays requests of any age.").
+		Default("1h"))
+
+	storeMetadata := cmd.Flag("receive.store-metadata", "Store metric metadata (type, HELP and unit) sent with remote write requests, e.g. by Prometheus in agent mode, per tenant given by --receive.tenant-header, persisted in --tsdb.path. It is served on /api/v1/metadata of the remote write address. Metadata not received within --tsdb.retention is dropped.").
+		Default("false").Bool()
+
+	metadataMaxTenants := cmd.Flag("receive.metadata.max-tenants", "Maximum number of tenants metadata is stored for with --receive.store-metadata. Metadata of further tenants is dropped until metadata of other tenants expires. 0 is unlimited.").
+		Default("1000").Int()
+
+	metadataMaxMetricsPerTenant := cmd.Flag("receive.metadata.max-metrics-per-tenant", "Maximum number of metrics metadata is stored for per tenant with --receive.store-metadata. Metadata of further metrics is dropped until metadata of other metrics of the tenant expires. 0 is unlimited.").
+		Default("10000").Int()
+
+	enableFlushAPI := cmd.Flag("receive.enable-flush-api", "Serve POST requests to /api/v1/flush of the remote write address, flushing the head of the TSDB into a block and uploading it, as used by 'thanos tools receive-rebalance'. Any client able to send remote write requests can trigger flushes, so only enable it if the remote write address is not exposed to untrusted clients.").
+		Default("false").Bool()
+
+	limitsConfig := extflag.RegisterPathOrContent(cmd, "receive.limits-config", "YAML limits of tenants, reloaded on SIGHUP and /-/reload. It has read_max_concurrency_per_tenant, metadata_max_tenants and metadata_max_metrics_per_tenant fields, defaulting to the flags of the same limits, and a tenants map overriding read_max_concurrency of single tenants.", false)
+
+	relabelConfig := extflag.RegisterPathOrContent(cmd, "receive.relabel-config", "YAML relabel configs applied to series of remote write requests received from clients, before they are forwarded or written, reloaded on SIGHUP and /-/reload. Series with empty label sets after relabeling are dropped. It follows native Prometheus relabel-config syntax. See format details: https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config", false)
+
+	reloadTokenFile := cmd.Flag("receive.reload-token-file", "File of the bearer token authorizing POST requests to /-/reload of the HTTP address, which reload the hashring configuration file, limits and relabel configs like SIGHUP does. The endpoint is disabled if not set.").
+		PlaceHolder("<path>").String()
+
 	m[comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ bool) error {
 		lset, err := parseFlagLabels(*labelStrs)
 		if err != nil {
 			return errors.Wrap(err, "parse labels")
 		}
 
+		hints, err := parseTenantHints(tenantHints)
+		if err != nil {
+			return err
+		}
+
 		var cw *receive.ConfigWatcher
 		if *hashringsFile != "" {
 			cw, err = receive.NewConfigWatcher(log.With(logger, "component", "config-watcher"), reg, *hashringsFile, *refreshInterval)
@@ -80,6 +153,58 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			}
 		}
 
+		var reloadToken string
+		if *reloadTokenFile != "" {
+			b, err := ioutil.ReadFile(*reloadTokenFile)
+			if err != nil {
+				return errors.Wrap(err, "read reload token file")
+			}
+			reloadToken = strings.TrimSpace(string(b))
+			if reloadToken == "" {
+				return errors.Errorf("reload token file %s is empty", *reloadTokenFile)
+			}
+		}
+
+		defaultLimits := receive.LimitsConfig{
+			ReadMaxConcurrencyPerTenant: *readMaxConcurrencyPerTenant,
+			MetadataMaxTenants:          *metadataMaxTenants,
+			MetadataMaxMetricsPerTenant: *metadataMaxMetricsPerTenant,
+		}
+		// loadConfigs reads and validates the limits and relabel configs on start-up and on reloads.
+		loadConfigs := func() (receive.LimitsConfig, []*relabel.Config, error) {
+			limits := defaultLimits
+			content, err := limitsConfig.Content()
+			if err != nil {
+				return receive.LimitsConfig{}, nil, errors.Wrap(err, "get content of limits configuration")
+			}
+			if len(content) > 0 {
+				if limits, err = receive.ParseLimitsConfig(content, defaultLimits); err != nil {
+					return receive.LimitsConfig{}, nil, err
+				}
+			}
+			content, err = relabelConfig.Content()
+			if err != nil {
+				return receive.LimitsConfig{}, nil, errors.Wrap(err, "get content of relabel configuration")
+			}
+			relabelConfigs, err := parseRelabelConfig(content)
+			if err != nil {
+				return receive.LimitsConfig{}, nil, err
+			}
+			return limits, relabelConfigs, nil
+		}
+		if _, _, err := loadConfigs(); err != nil {
+			return err
+		}
+
+		rwServerTLSConfig, err := thanostls.NewServerConfig(log.With(logger, "server", "remote-write"), *rwServerCert, *rwServerKey, *rwServerClientCA, *rwServerTLSOpts)
+		if err != nil {
+			return errors.Wrap(err, "setup remote write server TLS")
+		}
+		rwClientTLSConfig, err := thanostls.NewClientConfig(log.With(logger, "client", "remote-write"), *rwClientTLS.cert, *rwClientTLS.key, *rwClientTLS.caCert, *rwClientTLS.serverName, *rwClientTLS.opts)
+		if err != nil {
+			return errors.Wrap(err, "setup remote write client TLS")
+		}
+
 		// Local is empty, so try to generate a local endpoint
 		// based on the hostname and the listening port.
 		if *local == "" {
@@ -89,7 +214,11 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			}
 			parts := strings.Split(*remoteWriteAddress, ":")
 			port := parts[len(parts)-1]
-			*local = fmt.Sprintf("http://%s:%s/api/v1/receive", hostname, port)
+			scheme := "http"
+			if rwServerTLSConfig != nil {
+				scheme = "https"
+			}
+			*local = fmt.Sprintf("%s://%s:%s/api/v1/receive", scheme, hostname, port)
 		}
 
 		return runReceive(
@@ -101,8 +230,11 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			*cert,
 			*key,
 			*clientCA,
+			*tlsOpts,
 			*httpBindAddr,
 			*remoteWriteAddress,
+			rwServerTLSConfig,
+			rwClientTLSConfig,
 			*dataDir,
 			objStoreConfig,
 			lset,
@@ -113,11 +245,36 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			*replicaHeader,
 			*replicationFactor,
 			*tsdbBlockDuration,
+			receive.WALSyncPolicy(*walSyncPolicy),
+			time.Duration(*walSyncInterval),
+			*maxExemplars,
+			loadConfigs,
+			*readSkipColdTenants,
+			*forwardBufferDir,
+			int64(*forwardBufferMaxSize),
+			time.Duration(*forwardBufferRetryInterval),
+			time.Duration(*forwardBufferMaxAge),
+			*storeMetadata,
+			*enableFlushAPI,
+			hints,
 			comp,
+			reloadToken,
 		)
 	}
 }
 
+// parseTenantHints returns hints of tenants by hashring, nil if none are configured.
+func parseTenantHints(tenantHints *extflag.PathOrContent) (receive.TenantHints, error) {
+	content, err := tenantHints.Content()
+	if err != nil {
+		return nil, errors.Wrap(err, "get content of tenant hints")
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+	return receive.ParseTenantHints(content)
+}
+
 func runReceive(
 	g *run.Group,
 	logger log.Logger,
@@ -127,8 +284,11 @@ func runReceive(
 	cert string,
 	key string,
 	clientCA string,
+	tlsOpts thanostls.Options,
 	httpBindAddr string,
 	remoteWriteAddress string,
+	rwServerTLSConfig *tls.Config,
+	rwClientTLSConfig *tls.Config,
 	dataDir string,
 	objStoreConfig *extflag.PathOrContent,
 	lset labels.Labels,
@@ -139,11 +299,29 @@ func runReceive(
 	replicaHeader string,
 	replicationFactor uint64,
 	tsdbBlockDuration model.Duration,
+	walSyncPolicy receive.WALSyncPolicy,
+	walSyncInterval time.Duration,
+	maxExemplars int,
+	loadConfigs func() (receive.LimitsConfig, []*relabel.Config, error),
+	readSkipColdTenants bool,
+	forwardBufferDir string,
+	forwardBufferMaxSize int64,
+	forwardBufferRetryInterval time.Duration,
+	forwardBufferMaxAge time.Duration,
+	storeMetadata bool,
+	enableFlushAPI bool,
+	tenantHints receive.TenantHints,
 	comp component.Component,
+	reloadToken string,
 ) error {
 	logger = log.With(logger, "component", "receive")
 	level.Warn(logger).Log("msg", "setting up receive; the Thanos receive component is EXPERIMENTAL, it may break significantly without notice")
 
+	limits, relabelConfigs, err := loadConfigs()
+	if err != nil {
+		return err
+	}
+
 	tsdbCfg := &tsdb.Options{
 		RetentionDuration: retention,
 		NoLockfile:        true,
@@ -152,17 +330,161 @@ func runReceive(
 		WALCompression:    true,
 	}
 
+	var writerSyncer *receive.WALSyncer
+	if walSyncPolicy != receive.WALSyncSegment {
+		syncer := receive.NewWALSyncer(reg, dataDir)
+		if walSyncPolicy == receive.WALSyncRequest {
+			writerSyncer = syncer
+		} else {
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(func() error {
+				return runutil.Repeat(walSyncInterval, ctx.Done(), func() error {
+					if err := syncer.Sync(); err != nil {
+						level.Warn(logger).Log("msg", "failed to fsync WAL", "err", err)
+					}
+					return nil
+				})
+			}, func(error) {
+				cancel()
+			})
+		}
+		level.Info(logger).Log("msg", "WAL fsync enabled", "policy", walSyncPolicy, "interval", walSyncInterval)
+	}
+
+	// Exemplars are kept in memory only, independently of TSDB, so they survive TSDB flushes.
+	var exemplarStorage *exemplars.Storage
+	if maxExemplars > 0 {
+		exemplarStorage = exemplars.NewStorage(reg, maxExemplars)
+	}
+
+	var tenantActivity *receive.TenantActivity
+	if readSkipColdTenants {
+		if err := os.MkdirAll(dataDir, 0777); err != nil {
+			return errors.Wrap(err, "create data dir")
+		}
+		var err error
+		tenantActivity, err = receive.NewTenantActivity(dataDir, time.Duration(retention))
+		if err != nil {
+			return errors.Wrap(err, "load tenant activity")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			defer func() {
+				if err := tenantActivity.Persist(); err != nil {
+					level.Warn(logger).Log("msg", "failed to persist tenant activity", "err", err)
+				}
+			}()
+			return runutil.Repeat(time.Minute, ctx.Done(), func() error {
+				if err := tenantActivity.Persist(); err != nil {
+					level.Warn(logger).Log("msg", "failed to persist tenant activity", "err", err)
+				}
+				return nil
+			})
+		}, func(error) {
+			cancel()
+		})
+	}
+	var metadataStore *receive.MetadataStore
+	if storeMetadata {
+		if err := os.MkdirAll(dataDir, 0777); err != nil {
+			return errors.Wrap(err, "create data dir")
+		}
+		var err error
+		metadataStore, err = receive.NewMetadataStore(reg, dataDir, time.Duration(retention), limits.MetadataLimits())
+		if err != nil {
+			return errors.Wrap(err, "load metadata")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			defer func() {
+				if err := metadataStore.Persist(); err != nil {
+					level.Warn(logger).Log("msg", "failed to persist metadata", "err", err)
+				}
+			}()
+			return runutil.Repeat(time.Minute, ctx.Done(), func() error {
+				if err := metadataStore.Persist(); err != nil {
+					level.Warn(logger).Log("msg", "failed to persist metadata", "err", err)
+				}
+				return nil
+			})
+		}, func(error) {
+			cancel()
+		})
+	}
+	readLimiter := receive.NewReadLimiter(reg, receive.ReadLimitsOptions{
+		TenantHeader: tenantHeader,
+		Activity:     tenantActivity,
+	})
+	readLimiter.SetLimits(limits)
+
+	var forwardBuffer *receive.ForwardBuffer
+	if forwardBufferDir != "" {
+		var err error
+		forwardBuffer, err = receive.NewForwardBuffer(log.With(logger, "component", "forward-buffer"), reg, forwardBufferDir, forwardBufferMaxSize, forwardBufferMaxAge)
+		if err != nil {
+			return errors.Wrap(err, "create forward buffer")
+		}
+	}
+
+	// flushC receives requests to flush TSDB and upload its blocks, answered once done.
+	flushC := make(chan chan error)
+
+	var flusher func(ctx context.Context) error
+	if enableFlushAPI {
+		flusher = func(ctx context.Context) error {
+			errC := make(chan error, 1)
+			select {
+			case flushC <- errC:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case err := <-errC:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
 	localStorage := &tsdb.ReadyStorage{}
 	webHandler := receive.NewHandler(log.With(logger, "component", "receive-handler"), &receive.Options{
 		ListenAddress:     remoteWriteAddress,
+		TLSConfig:         rwServerTLSConfig,
+		ForwardTLSConfig:  rwClientTLSConfig,
 		Registry:          reg,
 		Endpoint:          endpoint,
 		TenantHeader:      tenantHeader,
 		ReplicaHeader:     replicaHeader,
 		ReplicationFactor: replicationFactor,
 		Tracer:            tracer,
+		TenantActivity:    tenantActivity,
+		ForwardBuffer:     forwardBuffer,
+		Metadata:          metadataStore,
+		Flusher:           flusher,
+		RelabelConfigs:    relabelConfigs,
 	})
 
+	// reload reloads the hashring configuration, limits and relabel configs. They are all validated before any of them
+	// is applied, so invalid configurations are not applied at all.
+	reload := func(ctx context.Context) error {
+		limits, relabelConfigs, err := loadConfigs()
+		if err != nil {
+			return err
+		}
+		if cw != nil {
+			if err := cw.Reload(ctx); err != nil {
+				return errors.Wrap(err, "reload hashring configuration")
+			}
+		}
+		readLimiter.SetLimits(limits)
+		if metadataStore != nil {
+			metadataStore.SetLimits(limits.MetadataLimits())
+		}
+		webHandler.SetRelabelConfigs(relabelConfigs)
+		return nil
+	}
+
 	statusProber := prober.NewProber(comp, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	confContentYaml, err := objStoreConfig.Content()
 	if err != nil {
@@ -179,7 +501,7 @@ func runReceive(
 
 	// dbReady signals when TSDB is ready and the Store gRPC server can start.
 	dbReady := make(chan struct{}, 1)
-	// updateDB signals when TSDB needs to be flushed and updated.
+	// updateDB signals when the hashring is loaded and TSDB can be started.
 	updateDB := make(chan struct{}, 1)
 	// uploadC signals when new blocks should be uploaded.
 	uploadC := make(chan struct{}, 1)
@@ -221,6 +543,24 @@ func runReceive(
 				}
 			}()
 
+			// flush flushes the head into a block, uploads it and reopens the storage.
+			flush := func() error {
+				if err := db.Flush(); err != nil {
+					return errors.Wrap(err, "flushing storage")
+				}
+				if upload {
+					uploadC <- struct{}{}
+					<-uploadDone
+				}
+				level.Info(logger).Log("msg", "tsdb started")
+				localStorage.Set(db.Get(), startTimeMargin)
+				webHandler.SetWriter(receive.NewWriter(log.With(logger, "component", "receive-writer"), localStorage, writerSyncer, exemplarStorage))
+				statusProber.SetReady()
+				level.Info(logger).Log("msg", "server is ready to receive web requests.")
+				dbReady <- struct{}{}
+				return nil
+			}
+
 			for {
 				select {
 				case <-cancel:
@@ -229,19 +569,19 @@ func runReceive(
 					if !ok {
 						return nil
 					}
-					if err := db.Flush(); err != nil {
-						return errors.Wrap(err, "flushing storage")
+					if err := flush(); err != nil {
+						return err
 					}
-					if upload {
-						uploadC <- struct{}{}
-						<-uploadDone
+				case errC := <-flushC:
+					webHandler.SetWriter(nil)
+					msg := "flushing storage on request; server is not ready to receive web requests."
+					statusProber.SetNotReady(errors.New(msg))
+					level.Info(logger).Log("msg", msg)
+					err := flush()
+					errC <- err
+					if err != nil {
+						return err
 					}
-					level.Info(logger).Log("msg", "tsdb started")
-					localStorage.Set(db.Get(), startTimeMargin)
-					webHandler.SetWriter(receive.NewWriter(log.With(logger, "component", "receive-writer"), localStorage))
-					statusProber.SetReady()
-					level.Info(logger).Log("msg", "server is ready to receive web requests.")
-					dbReady <- struct{}{}
 				}
 			}
 		}, func(err error) {
@@ -250,6 +590,24 @@ func runReceive(
 		)
 	}
 
+	// Hints of the tenant of the hashrings of the node follow changes of the hashring configuration. Blocks uploaded on
+	// start-up, before the watcher applies the configuration, get the hints of the configuration file at start-up.
+	var (
+		endpointHints *receive.EndpointHints
+		onConfig      func([]receive.HashringConfig)
+	)
+	if tenantHints != nil {
+		endpointHints = receive.NewEndpointHints(logger, tenantHints, endpoint)
+		onConfig = endpointHints.Update
+		if cw == nil {
+			endpointHints.Update([]receive.HashringConfig{{Endpoints: []string{endpoint}}})
+		} else if cfgs, err := cw.Load(); err != nil {
+			level.Warn(logger).Log("msg", "failed to load hashring configuration for tenant hints", "err", err)
+		} else {
+			endpointHints.Update(cfgs)
+		}
+	}
+
 	level.Debug(logger).Log("msg", "setting up hashring")
 	{
 		// Note: the hashring configuration watcher
@@ -260,7 +618,7 @@ func runReceive(
 		if cw != nil {
 			ctx, cancel := context.WithCancel(context.Background())
 			g.Add(func() error {
-				receive.HashringFromConfig(ctx, updates, cw)
+				receive.HashringFromConfig(ctx, updates, cw, onConfig)
 				return nil
 			}, func(error) {
 				cancel()
@@ -280,17 +638,22 @@ func runReceive(
 		cancel := make(chan struct{})
 		g.Add(func() error {
 			defer close(updateDB)
+			loaded := false
 			for {
 				select {
 				case h, ok := <-updates:
 					if !ok {
 						return nil
 					}
-					webHandler.SetWriter(nil)
+					// Later hashrings are swapped atomically, without restarting the writer and TSDB. Series of
+					// tenants moved to other nodes are uploaded with the next block of the local TSDB.
 					webHandler.Hashring(h)
-					msg := "hashring has changed; server is not ready to receive web requests."
-					statusProber.SetNotReady(errors.New(msg))
-					level.Info(logger).Log("msg", msg)
+					if loaded {
+						level.Info(logger).Log("msg", "hashring has changed")
+						continue
+					}
+					loaded = true
+					level.Info(logger).Log("msg", "hashring is loaded; starting tsdb")
 					updateDB <- struct{}{}
 				case <-cancel:
 					return nil
@@ -302,9 +665,39 @@ func runReceive(
 		)
 	}
 
+	{
+		// Reload configurations on SIGHUP. Invalid configurations are not applied.
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			c := make(chan os.Signal, 1)
+			signal.Notify(c, syscall.SIGHUP)
+			defer signal.Stop(c)
+			for {
+				select {
+				case <-c:
+					if err := reload(ctx); err != nil {
+						level.Error(logger).Log("msg", "reloading configuration failed", "err", err)
+						continue
+					}
+					level.Info(logger).Log("msg", "reloaded configuration")
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}, func(error) {
+			cancel()
+		})
+	}
+
 	level.Debug(logger).Log("msg", "setting up http server")
 	// Initiate HTTP listener providing metrics endpoint and readiness/liveness probes.
-	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, nil, comp); err != nil {
+	var httpHandler http.Handler
+	if reloadToken != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/-/reload", reloadHandler(logger, reload, reloadToken))
+		httpHandler = mux
+	}
+	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, httpHandler, comp); err != nil {
 		return errors.Wrap(err, "schedule HTTP server with probes")
 	}
 
@@ -317,7 +710,7 @@ func runReceive(
 		startGRPC := make(chan struct{})
 		g.Add(func() error {
 			defer close(startGRPC)
-			opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA)
+			opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA, tlsOpts)
 			if err != nil {
 				return errors.Wrap(err, "setup gRPC server")
 			}
@@ -331,7 +724,10 @@ func runReceive(
 					return errors.Wrap(err, "listen API address")
 				}
 				tsdbStore := store.NewTSDBStore(log.With(logger, "component", "thanos-tsdb-store"), nil, localStorage.Get(), component.Receive, lset)
-				s = newStoreGRPCServer(logger, &receive.UnRegisterer{Registerer: reg}, tracer, tsdbStore, opts)
+				s = newStoreGRPCServer(logger, &receive.UnRegisterer{Registerer: reg}, tracer, readLimiter.Wrap(tsdbStore), opts)
+				if exemplarStorage != nil {
+					exemplarspb.RegisterExemplarsServer(s, exemplars.NewServer(exemplarStorage, lset))
+				}
 				startGRPC <- struct{}{}
 			}
 			return nil
@@ -364,6 +760,14 @@ func runReceive(
 			},
 		)
 	}
+	if forwardBuffer != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return webHandler.RunForwardBuffer(ctx, forwardBufferRetryInterval)
+		}, func(error) {
+			cancel()
+		})
+	}
 
 	if upload {
 		// The background shipper continuously scans the data directory and uploads
@@ -373,7 +777,10 @@ func runReceive(
 			return err
 		}
 
-		s := shipper.New(logger, reg, dataDir, bkt, func() labels.Labels { return lset }, metadata.ReceiveSource)
+		s := shipper.New(logger, reg, dataDir, bkt, func() labels.Labels { return lset }, metadata.ReceiveSource, false)
+		if endpointHints != nil {
+			s.SetHints(endpointHints.Get)
+		}
 
 		// Before starting, ensure any old blocks are uploaded.
 		if uploaded, err := s.Sync(context.Background()); err != nil {
@@ -432,3 +839,26 @@ func runReceive(
 	level.Info(logger).Log("msg", "starting receiver")
 	return nil
 }
+
+// reloadHandler reloads the configurations on POST requests authorized by the bearer token. Invalid configurations are
+// not applied and answered with 400.
+func reloadHandler(logger log.Logger, reload func(context.Context) error, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := reload(r.Context()); err != nil {
+			level.Error(logger).Log("msg", "reloading configuration failed", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level.Info(logger).Log("msg", "reloaded configuration")
+	})
+}
@@ -51,6 +51,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/shipper"
 	"github.com/thanos-io/thanos/pkg/store"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/tracing"
 	"github.com/thanos-io/thanos/pkg/ui"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -62,7 +63,7 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(comp.String(), "ruler evaluating Prometheus rules against given Query nodes, exposing Store API and storing old blocks in bucket")
 
 	httpBindAddr := regHTTPAddrFlag(cmd)
-	grpcBindAddr, cert, key, clientCA := regGRPCFlags(cmd)
+	grpcBindAddr, cert, key, clientCA, tlsOpts := regGRPCFlags(cmd)
 
 	labelStrs := cmd.Flag("label", "Labels to be applied to all generated metrics (repeated). Similar to external labels for Prometheus, used to identify ruler and its blocks as unique source.").
 		PlaceHolder("<name>=\"<value>\"").Strings()
@@ -75,6 +76,10 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 		Default("1m"))
 	evalInterval := modelDuration(cmd.Flag("eval-interval", "The default evaluation interval to use.").
 		Default("30s"))
+	shards := cmd.Flag("rule.shards", "Number of rulers rule groups are sharded across. Each ruler evaluates only groups of its shard, determined by the hash of the group name. 0 or 1 disables sharding.").
+		Default("0").Uint64()
+	shardIndex := cmd.Flag("rule.shard-index", "Shard of this ruler, from 0 to --rule.shards minus one. If -1, the shard is given by the ordinal of hostname of the ruler, e.g. 2 for thanos-rule-2, as assigned to pods of a StatefulSet.").
+		Default("-1").Int()
 	tsdbBlockDuration := modelDuration(cmd.Flag("tsdb.block-duration", "Block duration for TSDB block.").
 		Default("2h"))
 	tsdbRetention := modelDuration(cmd.Flag("tsdb.retention", "Block retention time on local disk.").
@@ -110,6 +115,9 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 	dnsSDResolver := cmd.Flag("query.sd-dns-resolver", "Resolver to use. Possible options: [golang, miekgdns]").
 		Default("golang").Hidden().String()
 
+	querySecure := cmd.Flag("query.http-client-tls-secure", "Use https when talking to query API servers.").Default("false").Bool()
+	queryClientTLS := regHTTPClientTLSFlags(cmd, "query.http-client", "query API servers")
+
 	m[comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ bool) error {
 		lset, err := parseFlagLabels(*labelStrs)
 		if err != nil {
@@ -119,6 +127,18 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 		if err != nil {
 			return errors.Wrap(err, "parse alert query url")
 		}
+		shard, err := parseRuleShard(*shards, *shardIndex)
+		if err != nil {
+			return errors.Wrap(err, "parse rule shard")
+		}
+		queryScheme := "http"
+		if *querySecure {
+			queryScheme = "https"
+		}
+		queryTransport, err := queryClientTLS.transport(logger)
+		if err != nil {
+			return errors.Wrap(err, "setup query HTTP client")
+		}
 
 		tsdbOpts := &tsdb.Options{
 			MinBlockDuration:  *tsdbBlockDuration,
@@ -160,6 +180,7 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 			*cert,
 			*key,
 			*clientCA,
+			*tlsOpts,
 			*httpBindAddr,
 			*webRoutePrefix,
 			*webExternalPrefix,
@@ -168,11 +189,14 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 			time.Duration(*evalInterval),
 			*dataDir,
 			*ruleFiles,
+			shard,
 			objStoreConfig,
 			tsdbOpts,
 			alertQueryURL,
 			*alertExcludeLabels,
 			*queries,
+			queryScheme,
+			promclient.NewClient(queryTransport),
 			fileSD,
 			time.Duration(*dnsSDInterval),
 			*dnsSDResolver,
@@ -181,6 +205,31 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 	}
 }
 
+// parseRuleShard returns the shard of rule groups evaluated by this ruler, nil if sharding is disabled.
+func parseRuleShard(total uint64, index int) (*thanosrule.Shard, error) {
+	if total <= 1 {
+		return nil, nil
+	}
+	if index < 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Wrap(err, "get hostname")
+		}
+		i := strings.LastIndex(hostname, "-")
+		if i < 0 {
+			return nil, errors.Errorf("no ordinal in hostname %q, specify --rule.shard-index", hostname)
+		}
+		index, err = strconv.Atoi(hostname[i+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse ordinal of hostname %q", hostname)
+		}
+	}
+	if uint64(index) >= total {
+		return nil, errors.Errorf("shard index %d out of range of %d shards", index, total)
+	}
+	return &thanosrule.Shard{Index: uint64(index), Total: total}, nil
+}
+
 // runRule runs a rule evaluation component that continuously evaluates alerting and recording
 // rules. It sends alert notifications and writes TSDB data for results like a regular Prometheus server.
 func runRule(
@@ -195,6 +244,7 @@ func runRule(
 	cert string,
 	key string,
 	clientCA string,
+	tlsOpts thanostls.Options,
 	httpBindAddr string,
 	webRoutePrefix string,
 	webExternalPrefix string,
@@ -203,11 +253,14 @@ func runRule(
 	evalInterval time.Duration,
 	dataDir string,
 	ruleFiles []string,
+	shard *thanosrule.Shard,
 	objStoreConfig *extflag.PathOrContent,
 	tsdbOpts *tsdb.Options,
 	alertQueryURL *url.URL,
 	alertExcludeLabels []string,
 	queryAddrs []string,
+	queryScheme string,
+	queryClient *promclient.Client,
 	fileSD *file.Discovery,
 	dnsSDInterval time.Duration,
 	dnsSDResolver string,
@@ -283,9 +336,11 @@ func runRule(
 
 	// Run rule evaluation and alert notifications.
 	var (
-		alertmgrs = newAlertmanagerSet(logger, alertmgrURLs, dns.ResolverType(dnsSDResolver))
-		alertQ    = alert.NewQueue(logger, reg, 10000, 100, labelsTSDBToProm(lset), alertExcludeLabels)
-		ruleMgrs  = thanosrule.Managers{}
+		alertmgrs    = newAlertmanagerSet(logger, alertmgrURLs, dns.ResolverType(dnsSDResolver))
+		alertQ       = alert.NewQueue(logger, reg, 10000, 100, labelsTSDBToProm(lset), alertExcludeLabels)
+		ruleMgrs     = thanosrule.Managers{}
+		queryOpts    = &thanosrule.GroupQueryOptions{}
+		evalWarnings = thanosrule.NewEvalWarnings(reg)
 	)
 	{
 		notify := func(ctx context.Context, expr string, alerts ...*rules.Alert) {
@@ -330,7 +385,7 @@ func runRule(
 			opts := opts
 			opts.Registerer = extprom.WrapRegistererWith(prometheus.Labels{"strategy": strings.ToLower(s.String())}, reg)
 			opts.Context = ctx
-			opts.QueryFunc = queryFunc(logger, dnsProvider, duplicatedQuery, ruleEvalWarnings, s)
+			opts.QueryFunc = queryFunc(logger, queryScheme, queryClient, dnsProvider, duplicatedQuery, ruleEvalWarnings, queryOpts, evalWarnings, s)
 
 			ruleMgrs[s] = rules.NewManager(&opts)
 			g.Add(func() error {
@@ -441,7 +496,7 @@ func runRule(
 
 				level.Info(logger).Log("msg", "reload rule files", "numFiles", len(files))
 
-				if err := ruleMgrs.Update(dataDir, evalInterval, files); err != nil {
+				if err := ruleMgrs.Update(dataDir, evalInterval, files, queryOpts, shard); err != nil {
 					configSuccess.Set(0)
 					level.Error(logger).Log("msg", "reloading rules failed", "err", err)
 					continue
@@ -449,6 +504,7 @@ func runRule(
 
 				configSuccess.Set(1)
 				configSuccessTime.Set(float64(time.Now().UnixNano()) / 1e9)
+				evalWarnings.Reset()
 
 				rulesLoaded.Reset()
 				for s, mgr := range ruleMgrs {
@@ -506,7 +562,7 @@ func runRule(
 
 		store := store.NewTSDBStore(logger, reg, db, component.Rule, lset)
 
-		opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA)
+		opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA, tlsOpts)
 		if err != nil {
 			return errors.Wrap(err, "setup gRPC options")
 		}
@@ -542,9 +598,9 @@ func runRule(
 
 		ins := extpromhttp.NewInstrumentationMiddleware(reg)
 
-		ui.NewRuleUI(logger, reg, ruleMgrs, alertQueryURL.String(), flagsMap).Register(router.WithPrefix(webRoutePrefix), ins)
+		ui.NewRuleUI(logger, reg, ruleMgrs, evalWarnings, alertQueryURL.String(), flagsMap).Register(router.WithPrefix(webRoutePrefix), ins)
 
-		api := v1.NewAPI(logger, reg, ruleMgrs)
+		api := v1.NewAPI(logger, reg, ruleMgrs, evalWarnings)
 		api.Register(router.WithPrefix(path.Join(webRoutePrefix, "/api/v1")), tracer, logger, ins)
 
 		// Initiate HTTP listener providing metrics endpoint and readiness/liveness probes.
@@ -579,7 +635,7 @@ func runRule(
 			}
 		}()
 
-		s := shipper.New(logger, nil, dataDir, bkt, func() labels.Labels { return lset }, metadata.RulerSource)
+		s := shipper.New(logger, nil, dataDir, bkt, func() labels.Labels { return lset }, metadata.RulerSource, false)
 
 		ctx, cancel := context.WithCancel(context.Background())
 
@@ -597,6 +653,9 @@ func runRule(
 		})
 	}
 
+	if shard != nil {
+		level.Info(logger).Log("msg", "evaluating rule groups of shard", "shard", shard.Index, "shards", shard.Total)
+	}
 	level.Info(logger).Log("msg", "starting rule node")
 	return nil
 }
@@ -735,9 +794,13 @@ func removeDuplicateQueryAddrs(logger log.Logger, duplicatedQueriers prometheus.
 // back or the context get canceled.
 func queryFunc(
 	logger log.Logger,
+	scheme string,
+	client *promclient.Client,
 	dnsProvider *dns.Provider,
 	duplicatedQuery prometheus.Counter,
 	ruleEvalWarnings *prometheus.CounterVec,
+	queryOpts *thanosrule.GroupQueryOptions,
+	evalWarnings *thanosrule.EvalWarnings,
 	partialResponseStrategy storepb.PartialResponseStrategy,
 ) rules.QueryFunc {
 	var spanID string
@@ -759,14 +822,25 @@ func queryFunc(
 
 		removeDuplicateQueryAddrs(logger, duplicatedQuery, addrs)
 
+		// Apply query options of the rule group. Jitter delays the query only, so evaluation time stays the same.
+		o := queryOpts.Get(partialResponseStrategy, q)
+		if o.Jitter > 0 {
+			select {
+			case <-time.After(time.Duration(rand.Int63n(int64(o.Jitter)))):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		t = t.Add(-o.Offset)
+
 		for _, i := range rand.Perm(len(addrs)) {
-			u, err := url.Parse(fmt.Sprintf("http://%s", addrs[i]))
+			u, err := url.Parse(fmt.Sprintf("%s://%s", scheme, addrs[i]))
 			if err != nil {
 				return nil, errors.Wrapf(err, "url parse %s", addrs[i])
 			}
 
 			span, ctx := tracing.StartSpan(ctx, spanID)
-			v, warns, err := promclient.PromqlQueryInstant(ctx, logger, u, q, t, promclient.QueryOptions{
+			v, warns, err := client.PromqlQueryInstant(ctx, logger, u, q, t, promclient.QueryOptions{
 				Deduplicate:             true,
 				PartialResponseStrategy: partialResponseStrategy,
 			})
@@ -777,9 +851,11 @@ func queryFunc(
 			} else {
 				if len(warns) > 0 {
 					ruleEvalWarnings.WithLabelValues(strings.ToLower(partialResponseStrategy.String())).Inc()
-					// TODO(bwplotka): Propagate those to UI, probably requires changing rule manager code ):
 					level.Warn(logger).Log("warnings", strings.Join(warns, ", "), "query", q)
 				}
+				// Rule manager resets rule health after each evaluation, so warnings are kept aside and reported
+				// as rule health by UI and API.
+				evalWarnings.Set(partialResponseStrategy, q, warns)
 				return v, nil
 			}
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"math"
 	"net"
+	"net/http"
 	"net/url"
 	"sync"
 	"time"
@@ -20,15 +21,19 @@ import (
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/component"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	thanosmodel "github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/promclient"
+	"github.com/thanos-io/thanos/pkg/promproxy"
 	"github.com/thanos-io/thanos/pkg/reloader"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/shipper"
 	"github.com/thanos-io/thanos/pkg/store"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
+	"github.com/thanos-io/thanos/pkg/tracing"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -36,14 +41,22 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(component.Sidecar.String(), "sidecar for Prometheus server")
 
 	httpBindAddr := regHTTPAddrFlag(cmd)
-	grpcBindAddr, cert, key, clientCA := regGRPCFlags(cmd)
+	grpcBindAddr, cert, key, clientCA, tlsOpts := regGRPCFlags(cmd)
 
 	promURL := cmd.Flag("prometheus.url", "URL at which to reach Prometheus's API. For better performance use local network.").
 		Default("http://localhost:9090").URL()
 
+	promClientTLS := regHTTPClientTLSFlags(cmd, "prometheus.http-client", "Prometheus")
+
 	promReadyTimeout := cmd.Flag("prometheus.ready_timeout", "Maximum time to wait for the Prometheus instance to start up").
 		Default("10m").Duration()
 
+	queryProxy := cmd.Flag("prometheus.query-proxy", "If true, sidecar proxies /api/v1/query and /api/v1/query_range requests on its HTTP port to Prometheus and adds external labels to series of results, the same way as for StoreAPI.").
+		Default("false").Bool()
+
+	labelStrs := cmd.Flag("label", "External label to add to external labels of Prometheus (repeated), e.g. if the Prometheus configuration cannot be modified. They are announced to queriers and written into meta.json of uploaded blocks. A label overrides the external label of Prometheus with the same name.").
+		PlaceHolder("key=\"value\"").Strings()
+
 	dataDir := cmd.Flag("tsdb.path", "Data directory of TSDB.").
 		Default("./data").String()
 
@@ -59,10 +72,22 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 
 	uploadCompacted := cmd.Flag("shipper.upload-compacted", "[Experimental] If true sidecar will try to upload compacted blocks as well. Useful for migration purposes. Works only if compaction is disabled on Prometheus.").Default("false").Hidden().Bool()
 
+	uploadBackfilled := cmd.Flag("shipper.upload-backfilled", "If true sidecar will upload compacted blocks which do not overlap with any block in the bucket, which are blocks backfilled into the Prometheus data directory e.g. by promtool. Their index is verified before upload.").Default("false").Bool()
+
 	minTime := thanosmodel.TimeOrDuration(cmd.Flag("min-time", "Start of time range limit to serve. Thanos sidecar will serve only metrics, which happened later than this value. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
 		Default("0000-01-01T00:00:00Z"))
 
 	m[component.Sidecar.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ bool) error {
+		lset, err := parseFlagLabels(*labelStrs)
+		if err != nil {
+			return errors.Wrap(err, "parse labels")
+		}
+
+		promTransport, err := promClientTLS.transport(logger)
+		if err != nil {
+			return errors.Wrap(err, "setup Prometheus HTTP client")
+		}
+
 		rl := reloader.New(
 			log.With(logger, "component", "reloader"),
 			reloader.ReloadURLFromBase(*promURL),
@@ -70,6 +95,7 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 			*reloaderCfgOutputFile,
 			*reloaderRuleDirs,
 		)
+		rl.WithTransport(promTransport)
 
 		return runSidecar(
 			g,
@@ -80,13 +106,18 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 			*cert,
 			*key,
 			*clientCA,
+			*tlsOpts,
 			*httpBindAddr,
 			*promURL,
+			promTransport,
 			*promReadyTimeout,
+			*queryProxy,
+			lset,
 			*dataDir,
 			objStoreConfig,
 			rl,
 			*uploadCompacted,
+			*uploadBackfilled,
 			component.Sidecar,
 			*minTime,
 		)
@@ -102,18 +133,24 @@ func runSidecar(
 	cert string,
 	key string,
 	clientCA string,
+	tlsOpts thanostls.Options,
 	httpBindAddr string,
 	promURL *url.URL,
+	promTransport http.RoundTripper,
 	promReadyTimeout time.Duration,
+	queryProxy bool,
+	extraLabels labels.Labels,
 	dataDir string,
 	objStoreConfig *extflag.PathOrContent,
 	reloader *reloader.Reloader,
 	uploadCompacted bool,
+	uploadBackfilled bool,
 	comp component.Component,
 	limitMinTime thanosmodel.TimeOrDurationValue,
 ) error {
 	var m = &promMetadata{
 		promURL: promURL,
+		client:  promclient.NewClient(promTransport),
 
 		// Start out with the full time range. The shipper will constrain it later.
 		// TODO(fabxc): minimum timestamp is never adjusted if shipping is disabled.
@@ -121,6 +158,7 @@ func runSidecar(
 		maxt: math.MaxInt64,
 
 		limitMinTime: limitMinTime,
+		extraLabels:  extraLabels,
 	}
 
 	confContentYaml, err := objStoreConfig.Content()
@@ -136,7 +174,14 @@ func runSidecar(
 
 	statusProber := prober.NewProber(comp, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	// Initiate HTTP listener providing metrics endpoint and readiness/liveness probes.
-	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, nil, comp); err != nil {
+	var handler http.Handler
+	if queryProxy {
+		ins := extpromhttp.NewInstrumentationMiddleware(reg)
+		proxy := promproxy.New(log.With(logger, "component", "query-proxy"), promURL, m.Labels)
+		proxy.WithTransport(promTransport)
+		handler = ins.NewHandler("query_proxy", proxy)
+	}
+	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, handler, comp); err != nil {
 		return errors.Wrap(err, "schedule HTTP server with probes")
 	}
 
@@ -229,12 +274,12 @@ func runSidecar(
 		logger := log.With(logger, "component", component.Sidecar.String())
 
 		promStore, err := store.NewPrometheusStore(
-			logger, nil, promURL, component.Sidecar, m.Labels, m.Timestamps)
+			logger, &http.Client{Transport: tracing.HTTPTripperware(logger, promTransport)}, promURL, component.Sidecar, m.Labels, m.Timestamps)
 		if err != nil {
 			return errors.Wrap(err, "create Prometheus store")
 		}
 
-		opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA)
+		opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA, tlsOpts)
 		if err != nil {
 			return errors.Wrap(err, "setup gRPC server")
 		}
@@ -287,7 +332,7 @@ func runSidecar(
 			if uploadCompacted {
 				s = shipper.NewWithCompacted(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
 			} else {
-				s = shipper.New(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
+				s = shipper.New(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource, uploadBackfilled)
 			}
 
 			return runutil.Repeat(30*time.Second, ctx.Done(), func() error {
@@ -319,7 +364,7 @@ func validatePrometheus(ctx context.Context, logger log.Logger, m *promMetadata)
 	)
 
 	if err := runutil.Retry(2*time.Second, ctx.Done(), func() error {
-		if flags, flagErr = promclient.ConfiguredFlags(ctx, logger, m.promURL); flagErr != nil && flagErr != promclient.ErrFlagEndpointNotFound {
+		if flags, flagErr = m.client.ConfiguredFlags(ctx, logger, m.promURL); flagErr != nil && flagErr != promclient.ErrFlagEndpointNotFound {
 			level.Warn(logger).Log("msg", "failed to get Prometheus flags. Is Prometheus running? Retrying", "err", flagErr)
 			return errors.Wrapf(flagErr, "fetch Prometheus flags")
 		}
@@ -349,6 +394,7 @@ func validatePrometheus(ctx context.Context, logger log.Logger, m *promMetadata)
 
 type promMetadata struct {
 	promURL *url.URL
+	client  *promclient.Client
 
 	mtx    sync.Mutex
 	mint   int64
@@ -356,10 +402,12 @@ type promMetadata struct {
 	labels labels.Labels
 
 	limitMinTime thanosmodel.TimeOrDurationValue
+	// extraLabels are added to external labels of Prometheus, overriding labels with the same name.
+	extraLabels labels.Labels
 }
 
 func (s *promMetadata) UpdateLabels(ctx context.Context, logger log.Logger) error {
-	elset, err := promclient.ExternalLabels(ctx, logger, s.promURL)
+	elset, err := s.client.ExternalLabels(ctx, logger, s.promURL)
 	if err != nil {
 		return err
 	}
@@ -367,10 +415,22 @@ func (s *promMetadata) UpdateLabels(ctx context.Context, logger log.Logger) erro
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	s.labels = elset
+	s.labels = mergeLabels(elset, s.extraLabels)
 	return nil
 }
 
+// mergeLabels returns sorted labels of both label sets. Labels of b override labels of a with the same name.
+func mergeLabels(a, b labels.Labels) labels.Labels {
+	if len(b) == 0 {
+		return a
+	}
+	m := a.Map()
+	for _, l := range b {
+		m[l.Name] = l.Value
+	}
+	return labels.FromMap(m)
+}
+
 func (s *promMetadata) UpdateTimestamps(mint int64, maxt int64) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func Test_mergeLabels(t *testing.T) {
+	prom := labels.FromStrings("cluster", "eu1", "replica", "a")
+
+	testutil.Equals(t, prom, mergeLabels(prom, nil))
+	testutil.Equals(t, labels.FromStrings("cluster", "eu1", "region", "eu", "replica", "a"), mergeLabels(prom, labels.Labels{{Name: "region", Value: "eu"}}))
+	// Labels of flags override labels of Prometheus.
+	testutil.Equals(t, labels.FromStrings("cluster", "eu2", "replica", "a"), mergeLabels(prom, labels.Labels{{Name: "cluster", Value: "eu2"}}))
+	// Without external labels of Prometheus.
+	testutil.Equals(t, labels.FromStrings("cluster", "eu1"), mergeLabels(nil, labels.Labels{{Name: "cluster", Value: "eu1"}}))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+)
+
+const redactedValue = "<secret>"
+
+// urlUserInfo matches the user info of URLs, e.g. basic auth credentials of Alertmanager URLs.
+var urlUserInfo = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]+@`)
+
+// configStatus is set up by main for the running command. It is served by the HTTP server of every command.
+var configStatus http.Handler
+
+// ignoredFlags are built-in flags of kingpin, which do not configure the command.
+var ignoredFlags = map[string]struct{}{
+	"help":                   {},
+	"help-long":              {},
+	"help-man":               {},
+	"version":                {},
+	"completion-bash":        {},
+	"completion-script-bash": {},
+	"completion-script-zsh":  {},
+}
+
+// resolvedConfig is the configuration of the running command.
+type resolvedConfig struct {
+	Command string            `yaml:"command"`
+	Flags   map[string]string `yaml:"flags"`
+	// Configs are contents of flags given by path or content, e.g. objstore.config, parsed as YAML with secrets
+	// redacted, by the name of the content flag.
+	Configs map[string]interface{} `yaml:"configs,omitempty"`
+}
+
+// newResolvedConfig returns the configuration of the given command of the parsed application. Contents of flags given
+// by path or content are read, so the configuration is resolved at start-up, as used by the command.
+func newResolvedConfig(app *kingpin.Application, cmd string) (*resolvedConfig, error) {
+	model := app.Model()
+	flags := append([]*kingpin.FlagModel{}, model.Flags...)
+
+	// Flags of parent commands apply to sub-commands as well.
+	cmds := model.Commands
+	for _, name := range strings.Fields(cmd) {
+		var found *kingpin.CmdModel
+		for _, c := range cmds {
+			if c.Name == name {
+				found = c
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.Errorf("command %q not found", cmd)
+		}
+		flags = append(flags, found.Flags...)
+		cmds = found.Commands
+	}
+
+	c := &resolvedConfig{Command: cmd, Flags: map[string]string{}, Configs: map[string]interface{}{}}
+	byName := map[string]*kingpin.FlagModel{}
+	for _, f := range flags {
+		if _, ok := ignoredFlags[f.Name]; ok {
+			continue
+		}
+		byName[f.Name] = f
+		c.Flags[f.Name] = redactFlag(f.Name, f.Value.String())
+	}
+
+	// Flags given by path or content are pairs of <name>-file and <name> flags, see extflag.PathOrContent.
+	for name, f := range byName {
+		fileFlag, ok := byName[name+"-file"]
+		if !ok {
+			continue
+		}
+		content := []byte(f.Value.String())
+		if p := fileFlag.Value.String(); p != "" {
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return nil, errors.Wrapf(err, "read %s", p)
+			}
+			content = b
+		}
+		if len(content) == 0 {
+			continue
+		}
+		if c.Flags[name] != "" {
+			// The content is redacted in configs already, it may carry secrets.
+			c.Flags[name] = redactedValue
+		}
+
+		var v interface{}
+		if err := yaml.Unmarshal(content, &v); err != nil {
+			return nil, errors.Wrapf(err, "parse content of %s", name)
+		}
+		c.Configs[name] = redactSecrets(v)
+	}
+	return c, nil
+}
+
+// redactFlag returns the value of the flag with the value of secret flags and user info of URLs redacted. Flags of
+// files, e.g. of TLS keys, give paths only and are not redacted.
+func redactFlag(name, value string) string {
+	if value != "" && isSecretKey(name) && !strings.HasSuffix(name, "-file") {
+		return redactedValue
+	}
+	return redactURLUserInfo(value)
+}
+
+// redactURLUserInfo replaces user info of URLs in the given string.
+func redactURLUserInfo(s string) string {
+	return urlUserInfo.ReplaceAllString(s, "${1}"+redactedValue+"@")
+}
+
+// isSecretKey returns true if the YAML key likely holds a secret, e.g. secret_key of S3 or password of Swift.
+func isSecretKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range []string{"password", "secret", "token", "credentials"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return k == "service_account" || strings.HasSuffix(k, "account_key")
+}
+
+// redactSecrets replaces values of secret keys and user info of URLs of the parsed YAML.
+func redactSecrets(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return redactURLUserInfo(t)
+	case map[interface{}]interface{}:
+		for k, e := range t {
+			if ks, ok := k.(string); ok && isSecretKey(ks) && e != nil && e != "" {
+				t[k] = redactedValue
+				continue
+			}
+			t[k] = redactSecrets(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = redactSecrets(e)
+		}
+	}
+	return v
+}
+
+// configStatusHandler serves the configuration as YAML, in the format of /api/v1/status/config of Prometheus.
+func configStatusHandler(c *resolvedConfig) (http.Handler, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal config")
+	}
+	resp, err := json.Marshal(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]string{"yaml": string(b)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal config response")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resp)
+	}), nil
+}
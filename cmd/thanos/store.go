@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"net"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/thanos-io/thanos/pkg/extflag"
@@ -21,6 +23,8 @@ import (
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
+	"github.com/thanos-io/thanos/pkg/ui"
 	"gopkg.in/alecthomas/kingpin.v2"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -30,7 +34,7 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(component.Store.String(), "store node giving access to blocks in a bucket provider. Now supported GCS, S3, Azure, Swift and Tencent COS.")
 
 	httpBindAddr := regHTTPAddrFlag(cmd)
-	grpcBindAddr, cert, key, clientCA := regGRPCFlags(cmd)
+	grpcBindAddr, cert, key, clientCA, tlsOpts := regGRPCFlags(cmd)
 
 	dataDir := cmd.Flag("data-dir", "Data directory in which to cache remote blocks.").
 		Default("./data").String()
@@ -38,6 +42,9 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	indexCacheSize := cmd.Flag("index-cache-size", "Maximum size of items held in the index cache.").
 		Default("250MB").Bytes()
 
+	indexCacheCompressPostings := cmd.Flag("index-cache.compress-postings", "If true, postings held in the index cache are compressed with snappy. This allows roughly 3x more postings to be cached at the cost of CPU spent on compression.").
+		Default("false").Bool()
+
 	chunkPoolSize := cmd.Flag("chunk-pool-size", "Maximum size of concurrently allocatable bytes for chunks.").
 		Default("2GB").Bytes()
 
@@ -47,6 +54,15 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 
 	maxConcurrent := cmd.Flag("store.grpc.series-max-concurrency", "Maximum number of concurrent Series calls.").Default("20").Int()
 
+	maxConcurrentFetches := cmd.Flag("store.max-concurrent-chunk-fetches", "Maximum number of concurrent range reads of chunks from the object storage across all Series calls. Waiting reads are started in order of deadline of their Series calls and dropped once their call is canceled. 0 means no limit.").
+		Default("0").Int()
+
+	getRangeMaxGapSize := cmd.Flag("store.get-range.max-gap-size", "Maximum gap between two byte ranges of the same object storage file which are still merged into a single GetRange request. Bigger gaps mean fewer requests, but more bytes fetched and thrown away.").
+		Default("512KB").Bytes()
+
+	getRangeMaxSize := cmd.Flag("store.get-range.max-size", "Maximum size of a single GetRange request created by merging byte ranges. Single ranges bigger than that are still fetched in one request. 0 means no limit.").
+		Default("0").Bytes()
+
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
 
 	syncInterval := cmd.Flag("sync-block-duration", "Repeat interval for syncing the blocks between local and remote view.").
@@ -64,14 +80,41 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	advertiseCompatibilityLabel := cmd.Flag("debug.advertise-compatibility-label", "If true, Store Gateway in addition to other labels, will advertise special \"@thanos_compatibility_store_type=store\" label set. This makes store Gateway compatible with Querier before 0.8.0").
 		Hidden().Default("true").Bool()
 
+	tenantLabel := cmd.Flag("store.tenant-label", "External label of blocks identifying their tenant. If set, fetched bytes, touched series and series requests are exported by thanos_bucket_store_tenant_* metrics by the value of the label, e.g. for chargeback of store gateway and object storage costs.").
+		Default("").String()
+
+	activeQueryLogDir := cmd.Flag("store.active-query-log-dir", "Directory of the log of Series calls in flight, in the format of the Prometheus active query log. The log is memory mapped, so calls in flight during a crash, e.g. an OOM kill, are logged on the next start. Empty disables the log.").
+		Default("").String()
+
 	selectorRelabelConf := regSelectorRelabelFlags(cmd)
 
+	gcConf := regGCFlags(cmd)
+
+	warmUpFile := cmd.Flag("store.warmup-file", "File of queries replayed against the store after its initial sync, so its index cache is populated before it is ready to serve queries, e.g. a log of slow queries or the active query log of a querier or store gateway. Either one PromQL query or logged Series call per line, or a JSON array of objects with a query field.").
+		PlaceHolder("<path>").String()
+
+	warmUpSelectors := cmd.Flag("store.warmup-selector", "PromQL series selector to replay against the store after its initial sync, in addition to the queries of --store.warmup-file. Can be repeated.").
+		PlaceHolder("<selector>").Strings()
+
+	warmUpLookback := modelDuration(cmd.Flag("store.warmup-lookback", "Time range before now selected by replayed PromQL queries. Logged Series calls select their recorded time range.").
+		Default("1d"))
+
+	warmUpTimeout := modelDuration(cmd.Flag("store.warmup-timeout", "Maximum duration of replaying queries. The store is ready to serve queries once all queries are replayed or the timeout is reached.").
+		Default("5m"))
+
+	bucketWebLabel := cmd.Flag("bucket-web-label", "Prometheus label to use as timeline title in the bucket web UI of blocks loaded by the store, served on http-address.").String()
+
 	m[component.Store.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, debugLogging bool) error {
 		if minTime.PrometheusTimestamp() > maxTime.PrometheusTimestamp() {
 			return errors.Errorf("invalid argument: --min-time '%s' can't be greater than --max-time '%s'",
 				minTime, maxTime)
 		}
 
+		gcc, err := gcConf()
+		if err != nil {
+			return err
+		}
+
 		return runStore(g,
 			logger,
 			reg,
@@ -82,8 +125,10 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 			*cert,
 			*key,
 			*clientCA,
+			*tlsOpts,
 			*httpBindAddr,
 			uint64(*indexCacheSize),
+			*indexCacheCompressPostings,
 			uint64(*chunkPoolSize),
 			uint64(*maxSampleCount),
 			int(*maxConcurrent),
@@ -91,12 +136,23 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 			debugLogging,
 			*syncInterval,
 			*blockSyncConcurrency,
+			uint64(*getRangeMaxGapSize),
+			uint64(*getRangeMaxSize),
 			&store.FilterConfig{
 				MinTime: *minTime,
 				MaxTime: *maxTime,
 			},
 			selectorRelabelConf,
 			*advertiseCompatibilityLabel,
+			*tenantLabel,
+			*maxConcurrentFetches,
+			*activeQueryLogDir,
+			*warmUpFile,
+			*warmUpSelectors,
+			time.Duration(*warmUpLookback),
+			time.Duration(*warmUpTimeout),
+			gcc,
+			*bucketWebLabel,
 		)
 	}
 }
@@ -113,8 +169,10 @@ func runStore(
 	cert string,
 	key string,
 	clientCA string,
+	tlsOpts thanostls.Options,
 	httpBindAddr string,
 	indexCacheSizeBytes uint64,
+	indexCacheCompressPostings bool,
 	chunkPoolSizeBytes uint64,
 	maxSampleCount uint64,
 	maxConcurrent int,
@@ -122,13 +180,32 @@ func runStore(
 	verbose bool,
 	syncInterval time.Duration,
 	blockSyncConcurrency int,
+	getRangeMaxGapSize uint64,
+	getRangeMaxSize uint64,
 	filterConf *store.FilterConfig,
 	selectorRelabelConf *extflag.PathOrContent,
 	advertiseCompatibilityLabel bool,
+	tenantLabel string,
+	maxConcurrentFetches int,
+	activeQueryLogDir string,
+	warmUpFile string,
+	warmUpSelectors []string,
+	warmUpLookback time.Duration,
+	warmUpTimeout time.Duration,
+	gcConf gcConfig,
+	bucketWebLabel string,
 ) error {
+	gcTuner := newGCTuner(logger, reg, gcConf)
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/gc", gcTuner.handler())
+
+	bucketUI := ui.NewBucketUI(logger, bucketWebLabel)
+	mux.Handle("/", bucketUIRouter(bucketUI, reg))
+
 	// Initiate HTTP listener providing metrics endpoint and readiness/liveness probes.
 	statusProber := prober.NewProber(component, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
-	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, nil, component); err != nil {
+	if err := scheduleHTTPServer(g, logger, reg, statusProber, httpBindAddr, mux, component); err != nil {
 		return errors.Wrap(err, "schedule HTTP server")
 	}
 
@@ -159,17 +236,45 @@ func runStore(
 		}
 	}()
 
+	warmUpQueries := warmUpSelectors
+	if warmUpFile != "" {
+		f, err := os.Open(warmUpFile)
+		if err != nil {
+			return errors.Wrap(err, "open warm-up file")
+		}
+		queries, err := store.ReadWarmUpQueries(f)
+		runutil.CloseWithLogOnErr(logger, f, "warm-up file")
+		if err != nil {
+			return errors.Wrapf(err, "read warm-up file %s", warmUpFile)
+		}
+		warmUpQueries = append(warmUpQueries, queries...)
+	}
+	// Parse the queries before starting, so invalid ones are reported right away.
+	if _, err := store.WarmUpRequests(warmUpQueries, time.Now(), warmUpLookback); err != nil {
+		return errors.Wrap(err, "warm-up queries")
+	}
+
 	// TODO(bwplotka): Add as a flag?
 	maxItemSizeBytes := indexCacheSizeBytes / 2
 
 	indexCache, err := storecache.NewIndexCache(logger, reg, storecache.Opts{
 		MaxSizeBytes:     indexCacheSizeBytes,
 		MaxItemSizeBytes: maxItemSizeBytes,
+		CompressPostings: indexCacheCompressPostings,
 	})
 	if err != nil {
 		return errors.Wrap(err, "create index cache")
 	}
 
+	var activeQueries store.ActiveQueryTracker
+	if activeQueryLogDir != "" {
+		tracker, err := newActiveQueryTracker(logger, activeQueryLogDir, maxConcurrent)
+		if err != nil {
+			return err
+		}
+		activeQueries = tracker
+	}
+
 	bs, err := store.NewBucketStore(
 		logger,
 		reg,
@@ -181,9 +286,14 @@ func runStore(
 		maxConcurrent,
 		verbose,
 		blockSyncConcurrency,
+		getRangeMaxGapSize,
+		getRangeMaxSize,
 		filterConf,
 		relabelConfig,
 		advertiseCompatibilityLabel,
+		tenantLabel,
+		maxConcurrentFetches,
+		activeQueries,
 	)
 	if err != nil {
 		return errors.Wrap(err, "create object storage store")
@@ -202,13 +312,30 @@ func runStore(
 				close(bucketStoreReady)
 				return errors.Wrap(err, "bucket store initial sync")
 			}
+			if len(warmUpQueries) > 0 {
+				level.Info(logger).Log("msg", "warming up bucket store", "queries", len(warmUpQueries))
+				// Requests are created now, so replayed PromQL queries select data up to the end of the initial sync.
+				reqs, err := store.WarmUpRequests(warmUpQueries, time.Now(), warmUpLookback)
+				if err != nil {
+					close(bucketStoreReady)
+					return errors.Wrap(err, "warm-up queries")
+				}
+				wctx, wcancel := context.WithTimeout(ctx, warmUpTimeout)
+				if err := store.WarmUp(wctx, logger, bs, reqs, maxConcurrent); err != nil {
+					level.Warn(logger).Log("msg", "warm-up did not finish", "err", err)
+				}
+				wcancel()
+			}
 			level.Info(logger).Log("msg", "bucket store ready", "init_duration", time.Since(begin).String())
 			close(bucketStoreReady)
+			bucketUI.SetBlocks(bs.Metas(), nil)
 
 			err := runutil.Repeat(syncInterval, ctx.Done(), func() error {
-				if err := bs.SyncBlocks(ctx); err != nil {
+				err := bs.SyncBlocks(ctx)
+				if err != nil {
 					level.Warn(logger).Log("msg", "syncing blocks failed", "err", err)
 				}
+				bucketUI.SetBlocks(bs.Metas(), err)
 				return nil
 			})
 
@@ -224,7 +351,7 @@ func runStore(
 		return errors.Wrap(err, "listen API address")
 	}
 
-	opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA)
+	opts, err := defaultGRPCServerOpts(logger, cert, key, clientCA, tlsOpts)
 	if err != nil {
 		return errors.Wrap(err, "grpc server options")
 	}
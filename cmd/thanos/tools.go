@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/thanos-io/thanos/pkg/migrate"
+	"github.com/thanos-io/thanos/pkg/objstore/client"
+	"github.com/thanos-io/thanos/pkg/receive"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+)
+
+const generatedRulesHeader = "# Generated by `thanos tools generate-rules`. DO NOT EDIT.\n"
+
+func registerTools(m map[string]setupFunc, app *kingpin.Application, name string) {
+	cmd := app.Command(name, "Tools utility commands")
+	registerToolsGenerateRules(m, cmd, name)
+	registerToolsPromMigrate(m, cmd, name)
+	registerToolsReceiveRebalance(m, cmd, name)
+}
+
+func registerToolsReceiveRebalance(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("receive-rebalance", "Move a tenant from one receive node to another by applying an updated hashring configuration. The source node flushes and uploads its head before and after the change is applied, so no samples are missing or written twice.")
+	tenant := cmd.Flag("tenant", "Tenant to move.").Required().String()
+	source := cmd.Flag("source", "Endpoint of the node the tenant is moved from, as given in the hashring configuration.").
+		Required().String()
+	target := cmd.Flag("target", "Endpoint of the node the tenant is moved to, as given in the hashring configuration.").
+		Required().String()
+	hashringsFile := cmd.Flag("receive.hashrings-file", "Path of the hashring configuration file watched by the receive nodes. It is replaced by the updated configuration.").
+		Required().ExistingFile()
+	newHashringsFile := cmd.Flag("receive.new-hashrings-file", "Path of the updated hashring configuration file moving the tenant.").
+		Required().ExistingFile()
+	timeout := modelDuration(cmd.Flag("timeout", "Maximum time to wait for the nodes to apply the updated hashring configuration.").Default("10m"))
+
+	m[name+" receive-rebalance"] = func(g *run.Group, logger log.Logger, _ *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
+		b, err := ioutil.ReadFile(*newHashringsFile)
+		if err != nil {
+			return errors.Wrap(err, "read updated hashrings file")
+		}
+		var hashrings []receive.HashringConfig
+		if err := json.Unmarshal(b, &hashrings); err != nil {
+			return errors.Wrap(err, "parse updated hashrings file")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout))
+		g.Add(func() error {
+			return receive.Rebalance(ctx, logger, http.DefaultClient, 5*time.Second, receive.RebalanceOptions{
+				Tenant:        *tenant,
+				Source:        *source,
+				Target:        *target,
+				HashringsFile: *hashringsFile,
+				Hashrings:     hashrings,
+			})
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	}
+}
+
+func registerToolsPromMigrate(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("prom-migrate", "Upload blocks of a Prometheus TSDB snapshot to the bucket, e.g. to onboard existing data of Prometheus. Blocks are validated and checked for overlaps with blocks in the bucket before anything is uploaded.")
+	snapshotDir := cmd.Flag("snapshot-dir", "Directory of the Prometheus TSDB snapshot, as created by the snapshot admin API of Prometheus. Meta files of blocks are rewritten in place.").
+		Required().ExistingDir()
+	labelStrs := cmd.Flag("label", "External labels of the Prometheus the snapshot was taken from (repeated). Must be the same as the external labels its sidecar uploads blocks with.").
+		PlaceHolder("<name>=\"<value>\"").Required().Strings()
+	dryRun := cmd.Flag("dry-run", "Only validate blocks of the snapshot, do not upload them.").Default("false").Bool()
+	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
+
+	m[name+" prom-migrate"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
+		lset, err := parseFlagLabels(*labelStrs)
+		if err != nil {
+			return errors.Wrap(err, "parse labels")
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+			ids, err := migrate.Snapshot(ctx, logger, bkt, *snapshotDir, lset, *dryRun)
+			if err != nil {
+				return errors.Wrap(err, "migrate snapshot")
+			}
+			level.Info(logger).Log("msg", "migration of snapshot done", "blocks", len(ids), "dryRun", *dryRun)
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	}
+}
+
+func registerToolsGenerateRules(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("generate-rules", "Generate Prometheus alerting and recording rules for monitoring Thanos components.")
+	output := cmd.Flag("output", "Path of the file to write the rules to. Rules are written to stdout if empty.").String()
+
+	var sel rulesSelectors
+	cmd.Flag("selector.compact", "Label selector matching metrics of Thanos Compact.").
+		Default(defaultRulesSelectors.compact).StringVar(&sel.compact)
+	cmd.Flag("selector.store", "Label selector matching metrics of Thanos Store.").
+		Default(defaultRulesSelectors.store).StringVar(&sel.store)
+	cmd.Flag("selector.receive", "Label selector matching metrics of Thanos Receive.").
+		Default(defaultRulesSelectors.receive).StringVar(&sel.receive)
+
+	m[name+" generate-rules"] = func(g *run.Group, logger log.Logger, _ *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		b, err := generateRules(sel)
+		if err != nil {
+			return err
+		}
+		if *output == "" {
+			_, err := os.Stdout.Write(b)
+			return err
+		}
+		if err := ioutil.WriteFile(*output, b, 0644); err != nil {
+			return errors.Wrapf(err, "write rules to %s", *output)
+		}
+		level.Info(logger).Log("msg", "rules written", "file", *output)
+		return nil
+	}
+}
+
+// rulesSelectors are label selectors used to match metrics of each Thanos component in generated rules.
+type rulesSelectors struct {
+	compact string
+	store   string
+	receive string
+}
+
+var defaultRulesSelectors = rulesSelectors{
+	compact: `job=~".*thanos-compact.*"`,
+	store:   `job=~".*thanos-store.*"`,
+	receive: `job=~".*thanos-receive.*"`,
+}
+
+// generateRules returns the YAML encoded rule groups for Thanos self-monitoring.
+func generateRules(sel rulesSelectors) ([]byte, error) {
+	b, err := yaml.Marshal(thanosRuleGroups(sel))
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal rules")
+	}
+	return append([]byte(generatedRulesHeader), b...), nil
+}
+
+// thanosRuleGroups returns rule groups for Thanos self-monitoring. Metric names used here must match
+// the metrics exposed by the components, which is verified by tests.
+func thanosRuleGroups(sel rulesSelectors) rulefmt.RuleGroups {
+	return rulefmt.RuleGroups{Groups: []rulefmt.RuleGroup{
+		{
+			Name: "thanos-compact.rules",
+			Rules: []rulefmt.Rule{
+				{
+					Alert: "ThanosCompactHalted",
+					Expr:  fmt.Sprintf(`thanos_compactor_halted{%s} == 1`, sel.compact),
+					For:   model.Duration(5 * time.Minute),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Compact has halted due to an unexpected error.",
+						"description": "Thanos Compact {{ $labels.job }} has halted and stopped compacting and downsampling blocks. Check its logs.",
+					},
+				},
+				{
+					Alert: "ThanosCompactSyncFailing",
+					Expr:  fmt.Sprintf(`rate(thanos_compact_sync_meta_failures_total{%s}[5m]) > 0`, sel.compact),
+					For:   model.Duration(15 * time.Minute),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Compact is failing to sync block metadata from the bucket.",
+						"description": "Thanos Compact {{ $labels.job }} is failing to sync block metadata for more than 15 minutes.",
+					},
+				},
+				{
+					Alert: "ThanosCompactGarbageCollectionFailing",
+					Expr:  fmt.Sprintf(`rate(thanos_compact_garbage_collection_failures_total{%s}[5m]) > 0`, sel.compact),
+					For:   model.Duration(15 * time.Minute),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Compact is failing to garbage collect blocks.",
+						"description": "Thanos Compact {{ $labels.job }} is failing to delete blocks replaced by compaction for more than 15 minutes.",
+					},
+				},
+				{
+					Alert: "ThanosCompactGroupCompactionsFailing",
+					Expr:  fmt.Sprintf(`rate(thanos_compact_group_compactions_failures_total{%s}[5m]) > 0`, sel.compact),
+					For:   model.Duration(15 * time.Minute),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Compact is failing to compact blocks.",
+						"description": "Thanos Compact {{ $labels.job }} is failing to compact group {{ $labels.group }} for more than 15 minutes.",
+					},
+				},
+			},
+		},
+		{
+			Name: "thanos-store.rules",
+			Rules: []rulefmt.Rule{
+				{
+					Alert: "ThanosStoreBlockLoadsFailing",
+					Expr:  fmt.Sprintf(`rate(thanos_bucket_store_block_load_failures_total{%s}[5m]) > 0`, sel.store),
+					For:   model.Duration(15 * time.Minute),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Store is failing to load blocks from the bucket.",
+						"description": "Thanos Store {{ $labels.instance }} of {{ $labels.job }} is failing to load blocks for more than 15 minutes.",
+					},
+				},
+				{
+					Alert: "ThanosStoreBlocksDrift",
+					Expr: fmt.Sprintf(
+						`max by (job) (thanos_bucket_store_blocks_loaded{%s}) - min by (job) (thanos_bucket_store_blocks_loaded{%s}) > 0`,
+						sel.store, sel.store,
+					),
+					For: model.Duration(time.Hour),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Store replicas have loaded different sets of blocks.",
+						"description": "Thanos Store replicas of {{ $labels.job }} differ by {{ $value }} loaded blocks for more than 1 hour.",
+					},
+				},
+			},
+		},
+		{
+			Name: "thanos-receive.rules",
+			Rules: []rulefmt.Rule{
+				{
+					Record: "job:thanos_receive_forward_requests_failures:ratio_rate5m",
+					Expr: fmt.Sprintf(
+						`sum by (job) (rate(thanos_receive_forward_requests_total{%s}[5m])) / sum by (job) (rate(thanos_receive_forward_requests_total{%s}[5m]))`,
+						selector(`result="error"`, sel.receive), sel.receive,
+					),
+				},
+				{
+					Alert: "ThanosReceiveReplicationFailing",
+					Expr:  `job:thanos_receive_forward_requests_failures:ratio_rate5m > 0.05`,
+					For:   model.Duration(15 * time.Minute),
+					Labels: map[string]string{
+						"severity": "warning",
+					},
+					Annotations: map[string]string{
+						"summary":     "Thanos Receive is failing to forward and replicate write requests.",
+						"description": "Thanos Receive {{ $labels.job }} is failing {{ $value | humanizePercentage }} of forward requests to other receive nodes.",
+					},
+				},
+			},
+		},
+	}}
+}
+
+// selector joins the given label matchers, skipping empty ones, so they can be used within curly braces.
+func selector(matchers ...string) string {
+	var ms []string
+	for _, m := range matchers {
+		if m != "" {
+			ms = append(ms, m)
+		}
+	}
+	return strings.Join(ms, ", ")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+const shippedRulesFile = "../../examples/alerts/rules.yaml"
+
+func Test_generateRules(t *testing.T) {
+	b, err := generateRules(defaultRulesSelectors)
+	testutil.Ok(t, err)
+
+	f, err := ioutil.TempFile("", "test-generate-rules")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.Remove(f.Name())) }()
+
+	_, err = f.Write(b)
+	testutil.Ok(t, err)
+	testutil.Ok(t, f.Close())
+
+	files := []string{f.Name()}
+	testutil.Ok(t, checkRulesFiles(log.NewNopLogger(), &files))
+
+	shipped, err := ioutil.ReadFile(shippedRulesFile)
+	testutil.Ok(t, err)
+	testutil.Assert(t, string(b) == string(shipped), "%s is out of date, regenerate it using `thanos tools generate-rules --output examples/alerts/rules.yaml`", shippedRulesFile)
+}
+
+func Test_generateRules_MetricsExist(t *testing.T) {
+	// Gather source of all metric names defined in the code.
+	var src strings.Builder
+	for _, dir := range []string{".", "../../pkg"} {
+		testutil.Ok(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			src.Write(b)
+			return nil
+		}))
+	}
+
+	for _, g := range thanosRuleGroups(defaultRulesSelectors).Groups {
+		for _, r := range g.Rules {
+			expr, err := promql.ParseExpr(r.Expr)
+			testutil.Ok(t, err)
+
+			promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+				vs, ok := node.(*promql.VectorSelector)
+				if !ok || !strings.HasPrefix(vs.Name, "thanos_") {
+					return nil
+				}
+				testutil.Assert(t, strings.Contains(src.String(), `"`+vs.Name+`"`), "metric %s used by rule %s%s is not defined in the code", vs.Name, r.Alert, r.Record)
+				return nil
+			})
+		}
+	}
+}
+
+func Test_selector(t *testing.T) {
+	testutil.Equals(t, "", selector())
+	testutil.Equals(t, `a="1"`, selector(`a="1"`, ""))
+	testutil.Equals(t, `a="1", b=~"2"`, selector(`a="1"`, `b=~"2"`))
+}
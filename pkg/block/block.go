@@ -3,6 +3,7 @@
 package block
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log/level"
 
@@ -86,6 +88,14 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir st
 	if meta.Thanos.Labels == nil || len(meta.Thanos.Labels) == 0 {
 		return errors.Errorf("empty external labels are not allowed for Thanos block.")
 	}
+	if err := metadata.ValidateLabels(meta.Thanos.Labels); err != nil {
+		return errors.Wrap(err, "invalid external labels")
+	}
+	ctx = objstore.WithBlockResolution(ctx, meta.Thanos.Downsample.Resolution)
+
+	if err := MarkUpload(ctx, bkt, id); err != nil {
+		return err
+	}
 
 	if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, MetaFilename), path.Join(DebugMetas, fmt.Sprintf("%s.json", id))); err != nil {
 		return errors.Wrap(err, "upload meta file to debug dir")
@@ -111,9 +121,56 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir st
 		return cleanUp(logger, bkt, id, errors.Wrap(err, "upload meta file"))
 	}
 
+	DeleteUploadMark(ctx, logger, bkt, id)
 	return nil
 }
 
+// MarkUpload uploads a mark of the block's upload start. It has to be uploaded before any other object of the block.
+func MarkUpload(ctx context.Context, bkt objstore.Bucket, id ulid.ULID) error {
+	markFile := path.Join(id.String(), metadata.UploadMarkFilename)
+	b, err := json.Marshal(metadata.UploadMark{
+		ID:         id,
+		Version:    metadata.UploadMarkVersion1,
+		UploadTime: time.Now().Unix(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode upload mark")
+	}
+	if err := bkt.Upload(ctx, markFile, bytes.NewReader(b)); err != nil {
+		return errors.Wrapf(err, "upload %s", markFile)
+	}
+	return nil
+}
+
+// DeleteUploadMark deletes the mark of the block's upload start once its meta.json is uploaded. A mark left in a
+// complete block is harmless, hence failures are only logged.
+func DeleteUploadMark(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID) {
+	markFile := path.Join(id.String(), metadata.UploadMarkFilename)
+	if err := bkt.Delete(ctx, markFile); err != nil {
+		level.Warn(logger).Log("msg", "failed to delete upload mark", "block", id, "err", err)
+	}
+}
+
+// ReadUploadMark returns the mark of the block's upload start, or nil if the block is not marked, e.g. since its
+// upload finished or it was uploaded by an older version.
+func ReadUploadMark(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, id ulid.ULID) (*metadata.UploadMark, error) {
+	markFile := path.Join(id.String(), metadata.UploadMarkFilename)
+	rc, err := bkt.Get(ctx, markFile)
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get %s", markFile)
+	}
+	defer runutil.CloseWithLogOnErr(logger, rc, "upload mark reader")
+
+	var m metadata.UploadMark
+	if err := json.NewDecoder(rc).Decode(&m); err != nil {
+		return nil, errors.Wrapf(err, "decode %s", markFile)
+	}
+	return &m, nil
+}
+
 func cleanUp(logger log.Logger, bkt objstore.Bucket, id ulid.ULID, err error) error {
 	// Cleanup the dir with an uncancelable context.
 	cleanErr := Delete(context.Background(), logger, bkt, id)
@@ -178,12 +235,87 @@ func DownloadMeta(ctx context.Context, logger log.Logger, bkt objstore.Bucket, i
 	}
 
 	if err = json.Unmarshal(obj, &m); err != nil {
-		return metadata.Meta{}, errors.Wrapf(err, "unmarshal meta.json for block %s", id.String())
+		return metadata.Meta{}, errors.Wrapf(CorruptedMetaError{err: err}, "unmarshal meta.json for block %s", id.String())
 	}
 
 	return m, nil
 }
 
+// CorruptedMetaError is returned by DownloadMeta if the meta file was downloaded, but cannot be decoded, e.g. as its
+// upload was interrupted. Other errors of DownloadMeta, e.g. of the bucket, may succeed on retries.
+type CorruptedMetaError struct {
+	err error
+}
+
+func (e CorruptedMetaError) Error() string {
+	return e.err.Error()
+}
+
+// IsCorruptedMetaError returns true if the base error is a CorruptedMetaError.
+func IsCorruptedMetaError(err error) bool {
+	_, ok := errors.Cause(err).(CorruptedMetaError)
+	return ok
+}
+
+// MarkForNoCompact uploads a mark excluding the block from compaction and downsampling, unless the block is marked
+// already.
+func MarkForNoCompact(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, reason metadata.NoCompactReason, details string) error {
+	markFile := path.Join(id.String(), metadata.NoCompactMarkFilename)
+	ok, err := bkt.Exists(ctx, markFile)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s", markFile)
+	}
+	if ok {
+		return nil
+	}
+
+	b, err := json.Marshal(metadata.NoCompactMark{
+		ID:            id,
+		Version:       metadata.NoCompactMarkVersion1,
+		Reason:        reason,
+		Details:       details,
+		NoCompactTime: time.Now().Unix(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode no compact mark")
+	}
+	if err := bkt.Upload(ctx, markFile, bytes.NewReader(b)); err != nil {
+		return errors.Wrapf(err, "upload %s", markFile)
+	}
+	level.Info(logger).Log("msg", "marked block for no compaction", "block", id, "reason", reason, "details", details)
+	return nil
+}
+
+// IsMarkedForNoCompact returns true if the block is excluded from compaction and downsampling.
+func IsMarkedForNoCompact(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (bool, error) {
+	markFile := path.Join(id.String(), metadata.NoCompactMarkFilename)
+	ok, err := bkt.Exists(ctx, markFile)
+	if err != nil {
+		return false, errors.Wrapf(err, "stat %s", markFile)
+	}
+	return ok, nil
+}
+
+// ReadNoCompactMark returns the mark excluding the block from compaction and downsampling, or nil if the block is not
+// marked.
+func ReadNoCompactMark(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, id ulid.ULID) (*metadata.NoCompactMark, error) {
+	markFile := path.Join(id.String(), metadata.NoCompactMarkFilename)
+	rc, err := bkt.Get(ctx, markFile)
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get %s", markFile)
+	}
+	defer runutil.CloseWithLogOnErr(logger, rc, "no compact mark reader")
+
+	var m metadata.NoCompactMark
+	if err := json.NewDecoder(rc).Decode(&m); err != nil {
+		return nil, errors.Wrapf(err, "decode %s", markFile)
+	}
+	return &m, nil
+}
+
 func IsBlockDir(path string) (id ulid.ULID, ok bool) {
 	id, err := ulid.Parse(filepath.Base(path))
 	return id, err == nil
@@ -2,6 +2,7 @@ package block
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/testutil"
 
@@ -173,6 +175,22 @@ func TestUpload(t *testing.T) {
 		testutil.Equals(t, "empty external labels are not allowed for Thanos block.", err.Error())
 		testutil.Equals(t, 4, len(bkt.Objects()))
 	}
+	{
+		// Upload with invalid external labels should be blocked.
+		b3, err := testutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+			{{Name: "a", Value: "1"}},
+		}, 100, 0, 1000, labels.Labels{{Name: "ext1", Value: "val1"}}, 124)
+		testutil.Ok(t, err)
+		meta, err := metadata.Read(path.Join(tmpDir, b3.String()))
+		testutil.Ok(t, err)
+		meta.Thanos.Labels["1ext"] = "val1"
+		testutil.Ok(t, metadata.Write(log.NewNopLogger(), path.Join(tmpDir, b3.String()), meta))
+
+		err = Upload(ctx, log.NewNopLogger(), bkt, path.Join(tmpDir, b3.String()))
+		testutil.NotOk(t, err)
+		testutil.Equals(t, `invalid external labels: invalid external label name "1ext"`, err.Error())
+		testutil.Equals(t, 4, len(bkt.Objects()))
+	}
 }
 
 func cpy(src, dst string) error {
@@ -246,3 +264,30 @@ func TestDelete(t *testing.T) {
 		testutil.Equals(t, 2, len(bkt.Objects()))
 	}
 }
+
+func TestMarkForNoCompact(t *testing.T) {
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+	id := ulid.MustNew(1, nil)
+
+	ok, err := IsMarkedForNoCompact(ctx, bkt, id)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "block should not be marked")
+
+	testutil.Ok(t, MarkForNoCompact(ctx, log.NewNopLogger(), bkt, id, metadata.UnsupportedChunkEncodingNoCompactReason, "details"))
+	ok, err = IsMarkedForNoCompact(ctx, bkt, id)
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "block should be marked")
+
+	var m metadata.NoCompactMark
+	testutil.Ok(t, json.Unmarshal(bkt.Objects()[path.Join(id.String(), metadata.NoCompactMarkFilename)], &m))
+	testutil.Equals(t, id, m.ID)
+	testutil.Equals(t, metadata.NoCompactMarkVersion1, m.Version)
+	testutil.Equals(t, metadata.UnsupportedChunkEncodingNoCompactReason, m.Reason)
+	testutil.Equals(t, "details", m.Details)
+
+	// Marking again keeps the existing mark.
+	testutil.Ok(t, MarkForNoCompact(ctx, log.NewNopLogger(), bkt, id, metadata.UnsupportedChunkEncodingNoCompactReason, "other"))
+	testutil.Ok(t, json.Unmarshal(bkt.Objects()[path.Join(id.String(), metadata.NoCompactMarkFilename)], &m))
+	testutil.Equals(t, "details", m.Details)
+}
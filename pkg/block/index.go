@@ -21,6 +21,7 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/prometheus/prometheus/tsdb/labels"
@@ -324,6 +325,14 @@ func (i Stats) CriticalErr() error {
 	return nil
 }
 
+// CriticalIssuesRepairable returns true if the critical block issues indicated by stats might be resolved by Repair, i.e.
+// all chunks outside the block time range are complete outsiders or introduced by
+// https://github.com/prometheus/tsdb/issues/347. Out-of-order chunks are repaired only if they are exact duplicates or
+// do not overlap, which is known only once the block is repaired.
+func (i Stats) CriticalIssuesRepairable() bool {
+	return i.OutsideChunks == i.CompleteOutsideChunks+i.Issue347OutsideChunks
+}
+
 // AnyErr returns error if stats indicates any block issue.
 func (i Stats) AnyErr() error {
 	var errMsg []string
@@ -446,6 +455,66 @@ func GatherIndexIssueStats(logger log.Logger, fn string, minTime int64, maxTime
 	return stats, nil
 }
 
+// UnsupportedChunkEncodings returns encodings of chunks of the block in the given directory which are not among the
+// supported ones, sorted. Chunks are not decoded, only their encoding is read.
+func UnsupportedChunkEncodings(dir string, supported ...chunkenc.Encoding) (encs []chunkenc.Encoding, err error) {
+	ir, err := index.NewFileReader(filepath.Join(dir, IndexFilename))
+	if err != nil {
+		return nil, errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, ir, "chunk encodings index reader")
+
+	pool := &encodingPool{encodings: map[chunkenc.Encoding]struct{}{}}
+	cr, err := chunks.NewDirReader(filepath.Join(dir, ChunksDirname), pool)
+	if err != nil {
+		return nil, errors.Wrap(err, "open chunks dir")
+	}
+	defer runutil.CloseWithErrCapture(&err, cr, "chunk encodings chunk reader")
+
+	p, err := ir.Postings(index.AllPostingsKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "get all postings")
+	}
+	var (
+		lset labels.Labels
+		chks []chunks.Meta
+	)
+	for p.Next() {
+		if err := ir.Series(p.At(), &lset, &chks); err != nil {
+			return nil, errors.Wrap(err, "read series")
+		}
+		for _, c := range chks {
+			if _, err := cr.Chunk(c.Ref); err != nil {
+				return nil, errors.Wrapf(err, "read chunk %d of series %v", c.Ref, lset)
+			}
+		}
+	}
+	if p.Err() != nil {
+		return nil, errors.Wrap(p.Err(), "walk postings")
+	}
+
+	for _, e := range supported {
+		delete(pool.encodings, e)
+	}
+	for e := range pool.encodings {
+		encs = append(encs, e)
+	}
+	sort.Slice(encs, func(i, j int) bool { return encs[i] < encs[j] })
+	return encs, nil
+}
+
+// encodingPool records encodings of read chunks instead of decoding them.
+type encodingPool struct {
+	encodings map[chunkenc.Encoding]struct{}
+}
+
+func (p *encodingPool) Get(e chunkenc.Encoding, _ []byte) (chunkenc.Chunk, error) {
+	p.encodings[e] = struct{}{}
+	return nil, nil
+}
+
+func (p *encodingPool) Put(chunkenc.Chunk) error { return nil }
+
 type ignoreFnType func(mint, maxt int64, prev *chunks.Meta, curr *chunks.Meta) (bool, error)
 
 // Repair open the block with given id in dir and creates a new one with fixed data.
@@ -509,7 +578,8 @@ func Repair(logger log.Logger, dir string, id ulid.ULID, source metadata.SourceT
 	resmeta := *meta
 	resmeta.ULID = resid
 	resmeta.Stats = tsdb.BlockStats{} // Reset stats.
-	resmeta.Thanos.Source = source    // Update source.
+	resmeta.Thanos.Origin = meta.Thanos.OriginSource()
+	resmeta.Thanos.Source = source // Update source.
 
 	if err := rewrite(logger, indexr, chunkr, indexw, chunkw, &resmeta, ignoreChkFns); err != nil {
 		return resid, errors.Wrap(err, "rewrite block")
@@ -2,12 +2,14 @@ package block
 
 import (
 	"context"
+	"encoding/binary"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
@@ -47,3 +49,43 @@ func TestWriteReadIndexCache(t *testing.T) {
 	testutil.Equals(t, []string{"1"}, vals)
 	testutil.Equals(t, 6, len(postings))
 }
+
+// setChunkEncoding overwrites the encoding of the first chunk of the block.
+func setChunkEncoding(t *testing.T, dir string, e chunkenc.Encoding) {
+	fn := filepath.Join(dir, ChunksDirname, "000001")
+	b, err := ioutil.ReadFile(fn)
+	testutil.Ok(t, err)
+
+	// Chunks follow the 8 bytes header of the segment, starting with their length.
+	_, n := binary.Uvarint(b[8:])
+	b[8+n] = byte(e)
+	testutil.Ok(t, ioutil.WriteFile(fn, b, os.ModePerm))
+}
+
+func TestUnsupportedChunkEncodings(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-chunk-encodings")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	b, err := testutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		{{Name: "a", Value: "1"}},
+		{{Name: "a", Value: "2"}},
+	}, 100, 0, 1000, nil, 0)
+	testutil.Ok(t, err)
+	dir := filepath.Join(tmpDir, b.String())
+
+	encs, err := UnsupportedChunkEncodings(dir, chunkenc.EncXOR)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(encs))
+
+	encs, err = UnsupportedChunkEncodings(dir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []chunkenc.Encoding{chunkenc.EncXOR}, encs)
+
+	setChunkEncoding(t, dir, chunkenc.Encoding(0x7f))
+	encs, err = UnsupportedChunkEncodings(dir, chunkenc.EncXOR)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []chunkenc.Encoding{chunkenc.Encoding(0x7f)}, encs)
+}
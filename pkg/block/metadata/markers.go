@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"github.com/oklog/ulid"
+)
+
+const (
+	// NoCompactMarkFilename is the known JSON filename of the marker of blocks excluded from compaction and
+	// downsampling.
+	NoCompactMarkFilename = "no-compact-mark.json"
+
+	// NoCompactMarkVersion1 is the version of no compact marks supported by Thanos.
+	NoCompactMarkVersion1 = 1
+
+	// UploadMarkFilename is the known JSON filename of the marker of blocks being uploaded.
+	UploadMarkFilename = "upload-mark.json"
+
+	// UploadMarkVersion1 is the version of upload marks supported by Thanos.
+	UploadMarkVersion1 = 1
+)
+
+// NoCompactReason is the class of the reason a block is excluded from compaction.
+type NoCompactReason string
+
+const (
+	// UnsupportedChunkEncodingNoCompactReason means the block has chunks of encodings the compactor cannot read,
+	// e.g. written by a newer version of the block producer.
+	UnsupportedChunkEncodingNoCompactReason NoCompactReason = "unsupported-chunk-encoding"
+	// PartialNoCompactReason means the meta file of the block cannot be read, e.g. due to a corrupted upload.
+	PartialNoCompactReason NoCompactReason = "partial"
+	// ManualNoCompactReason means the block was marked by an operator, e.g. using thanos bucket mark.
+	ManualNoCompactReason NoCompactReason = "manual"
+)
+
+// NoCompactMark marks a block excluded from compaction and downsampling. The block is still queried and deleted by
+// retention.
+type NoCompactMark struct {
+	// ID of the block.
+	ID      ulid.ULID `json:"id"`
+	Version int       `json:"version"`
+
+	Reason NoCompactReason `json:"reason"`
+	// Details are a human readable description of the reason.
+	Details string `json:"details,omitempty"`
+	// NoCompactTime is the unix timestamp in seconds when the block was marked.
+	NoCompactTime int64 `json:"noCompactTime"`
+}
+
+// UploadMark marks a block whose upload started, but did not finish yet. It is uploaded before all other objects of
+// the block and removed once its meta.json is uploaded, so the age of partial blocks is known regardless of their ULID,
+// e.g. of old blocks uploaded late.
+type UploadMark struct {
+	// ID of the block.
+	ID      ulid.ULID `json:"id"`
+	Version int       `json:"version"`
+
+	// UploadTime is the unix timestamp in seconds when the upload of the block started.
+	UploadTime int64 `json:"uploadTime"`
+}
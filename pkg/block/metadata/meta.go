@@ -7,12 +7,18 @@ package metadata
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
 	"github.com/thanos-io/thanos/pkg/runutil"
@@ -28,6 +34,7 @@ const (
 	CompactorRepairSource SourceType = "compactor.repair"
 	RulerSource           SourceType = "ruler"
 	BucketRepairSource    SourceType = "bucket.repair"
+	PromMigrateSource     SourceType = "prom-migrate"
 	TestSource            SourceType = "test"
 )
 
@@ -56,15 +63,136 @@ type Thanos struct {
 
 	// Source is a real upload source of the block.
 	Source SourceType `json:"source"`
+
+	// Origin is the upload source of the blocks a block created by the compactor was compacted, downsampled or
+	// repaired from, if all of them have the same one. Empty for blocks uploaded by their source.
+	Origin SourceType `json:"origin,omitempty"`
+
+	// Hints are preferences for processing of the block by the compactor, given at ingestion time.
+	Hints *ThanosHints `json:"hints,omitempty"`
+
+	// Shard identifies the series of the block, if the block holds only a shard of the series of its external labels.
+	Shard *ThanosShard `json:"shard,omitempty"`
+}
+
+// OriginSource returns the upload source of the data of the block: the origin of blocks created by the compactor and the
+// source of other blocks. It is empty if the origin is not known, e.g. for blocks created by the compactor from blocks
+// of different sources or before origins were recorded.
+func (m *Thanos) OriginSource() SourceType {
+	if m.Origin != "" {
+		return m.Origin
+	}
+	if m.Source == CompactorSource || m.Source == CompactorRepairSource {
+		return ""
+	}
+	return m.Source
+}
+
+// ThanosShard identifies a shard of series, split by the compactor by series hash: the block holds the series whose
+// labels hash modulo Count is Index.
+type ThanosShard struct {
+	Index uint64 `json:"index"`
+	Count uint64 `json:"count"`
+}
+
+// String returns the shard in the format <index>_of_<count>, e.g. 1_of_4, and an empty string for no shard.
+func (s *ThanosShard) String() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d_of_%d", s.Index, s.Count)
 }
 
 type ThanosDownsample struct {
 	Resolution int64 `json:"resolution"`
 }
 
+// ThanosHints are preferences of the tenant owning the data of the block on how long to retain and whether to
+// downsample it. They take precedence over the retention and downsampling configured for the compactor.
+// Compacted and downsampled blocks keep hints of their source blocks.
+type ThanosHints struct {
+	// Tenant the hints are given by.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Retention of raw, 5m and 1h resolution blocks in milliseconds. Zero means the retention of the compactor.
+	RetentionRaw int64 `json:"retentionRaw,omitempty"`
+	Retention5m  int64 `json:"retention5m,omitempty"`
+	Retention1h  int64 `json:"retention1h,omitempty"`
+
+	// DisableDownsampling disables downsampling of the block.
+	DisableDownsampling bool `json:"disableDownsampling,omitempty"`
+}
+
+// Retention returns the retention of blocks of the given resolution, zero if the hints do not give it.
+func (h *ThanosHints) Retention(resolution int64) time.Duration {
+	if h == nil {
+		return 0
+	}
+	var ms int64
+	switch resolution {
+	case 0:
+		ms = h.RetentionRaw
+	case 5 * 60 * 1000:
+		ms = h.Retention5m
+	case 60 * 60 * 1000:
+		ms = h.Retention1h
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// ValidateLabels checks that external labels have valid Prometheus label names and non-empty UTF-8 values.
+// Invalid labels break grouping of blocks by their external labels, e.g. in the compactor.
+func ValidateLabels(lset map[string]string) error {
+	names := make([]string, 0, len(lset))
+	for n := range lset {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		if !model.LabelName(n).IsValid() {
+			return errors.Errorf("invalid external label name %q", n)
+		}
+		v := lset[n]
+		if v == "" {
+			return errors.Errorf("empty value of external label %q", n)
+		}
+		if !utf8.ValidString(v) {
+			return errors.Errorf("invalid UTF-8 value %q of external label %q", v, n)
+		}
+	}
+	return nil
+}
+
+// NormalizeLabels returns external labels without labels with empty values, which are equivalent to
+// not set labels in Prometheus.
+func NormalizeLabels(lset map[string]string) map[string]string {
+	if lset == nil {
+		return nil
+	}
+	res := make(map[string]string, len(lset))
+	for n, v := range lset {
+		if v == "" {
+			continue
+		}
+		res[n] = v
+	}
+	return res
+}
+
 // InjectThanos sets Thanos meta to the block meta JSON and saves it to the disk.
+// External labels with empty values are removed and the remaining ones are validated.
 // NOTE: It should be used after writing any block by any Thanos component, otherwise we will miss crucial metadata.
 func InjectThanos(logger log.Logger, bdir string, meta Thanos, downsampledMeta *tsdb.BlockMeta) (*Meta, error) {
+	lset := NormalizeLabels(meta.Labels)
+	if len(lset) != len(meta.Labels) {
+		level.Warn(logger).Log("msg", "removed external labels with empty values", "dir", bdir)
+	}
+	if err := ValidateLabels(lset); err != nil {
+		return nil, errors.Wrap(err, "validate external labels")
+	}
+	meta.Labels = lset
+
 	newMeta, err := Read(bdir)
 	if err != nil {
 		return nil, errors.Wrap(err, "read new meta")
@@ -0,0 +1,74 @@
+// Package writer writes TSDB blocks with Thanos metadata from appended samples, e.g. for backfilling or ETL tools
+// producing blocks to upload into the object storage with block.Upload.
+package writer
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+// chunkRange is the chunk range of the in-memory head buffering appended samples. The head rejects samples older than
+// half of it before the most recent appended sample, i.e. about 57 days.
+const chunkRange = 10000000000
+
+// Writer buffers appended samples in memory and writes them into a new block once flushed.
+type Writer struct {
+	logger log.Logger
+	dir    string
+	meta   metadata.Thanos
+	head   *tsdb.Head
+}
+
+// New returns a writer of a block into dir with the given Thanos metadata, e.g. the external labels and source of the
+// block. The writer must be closed once done.
+func New(logger log.Logger, dir string, meta metadata.Thanos) (*Writer, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	h, err := tsdb.NewHead(nil, logger, nil, chunkRange)
+	if err != nil {
+		return nil, errors.Wrap(err, "create head block")
+	}
+	return &Writer{logger: logger, dir: dir, meta: meta, head: h}, nil
+}
+
+// Appender returns an appender of samples of series. Appenders may be used concurrently, appended samples are
+// written into the block once committed. Samples of a series must be appended in order of their timestamps.
+func (w *Writer) Appender() tsdb.Appender {
+	return w.head.Appender()
+}
+
+// Flush writes the committed samples within [mint, maxt) into a new block in dir, with the Thanos metadata of the
+// writer, and returns its ID. The block is written like Prometheus writes blocks, including its tombstones file,
+// which is not uploaded by block.Upload. An empty ID is returned if there are no samples to write.
+func (w *Writer) Flush(ctx context.Context, mint, maxt int64) (id ulid.ULID, err error) {
+	if mint >= maxt {
+		return id, errors.Errorf("invalid time range [%d, %d)", mint, maxt)
+	}
+	c, err := tsdb.NewLeveledCompactor(ctx, nil, w.logger, []int64{maxt - mint}, nil)
+	if err != nil {
+		return id, errors.Wrap(err, "create compactor")
+	}
+	id, err = c.Write(w.dir, w.head, mint, maxt, nil)
+	if err != nil {
+		return id, errors.Wrap(err, "write block")
+	}
+	if id == (ulid.ULID{}) {
+		return id, nil
+	}
+	if _, err := metadata.InjectThanos(w.logger, filepath.Join(w.dir, id.String()), w.meta, nil); err != nil {
+		return id, errors.Wrap(err, "finalize block")
+	}
+	return id, nil
+}
+
+// Close releases the samples buffered by the writer.
+func (w *Writer) Close() error {
+	return w.head.Close()
+}
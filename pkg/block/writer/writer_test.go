@@ -0,0 +1,67 @@
+package writer_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/block/writer"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-block-writer")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	meta := metadata.Thanos{
+		Labels: map[string]string{"cluster": "eu"},
+		Source: metadata.SourceType("backfill"),
+	}
+	w, err := writer.New(log.NewNopLogger(), dir, meta)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, w.Close()) }()
+
+	// No samples, no block.
+	id, err := w.Flush(context.Background(), 0, 1000)
+	testutil.Ok(t, err)
+	testutil.Equals(t, ulid.ULID{}, id)
+
+	app := w.Appender()
+	for ts := int64(0); ts < 2000; ts += 100 {
+		_, err := app.Add(labels.FromStrings("__name__", "up", "job", "a"), ts, 1)
+		testutil.Ok(t, err)
+		_, err = app.Add(labels.FromStrings("__name__", "up", "job", "b"), ts, 0)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	_, err = w.Flush(context.Background(), 1000, 1000)
+	testutil.NotOk(t, err)
+
+	// Samples outside of the time range are not written.
+	id, err = w.Flush(context.Background(), 0, 1000)
+	testutil.Ok(t, err)
+
+	bdir := filepath.Join(dir, id.String())
+	m, err := metadata.Read(bdir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, meta, m.Thanos)
+	testutil.Equals(t, int64(0), m.MinTime)
+	testutil.Equals(t, int64(1000), m.MaxTime)
+	testutil.Equals(t, uint64(2), m.Stats.NumSeries)
+	testutil.Equals(t, uint64(20), m.Stats.NumSamples)
+
+	testutil.Ok(t, block.VerifyIndex(log.NewNopLogger(), filepath.Join(bdir, block.IndexFilename), m.MinTime, m.MaxTime))
+	b, err := tsdb.OpenBlock(nil, bdir, nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, b.Close())
+}
@@ -0,0 +1,106 @@
+package compact
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// CleanOrphanedObjects removes auxiliary objects left in the bucket without a corresponding block: block directories
+// without meta.json, e.g. stale index cache files of blocks which were not deleted completely, and debug meta files of
+// blocks no longer in the bucket. Only objects of blocks whose upload started longer ago than the maximum of
+// MinimumAgeForRemoval and the consistency delay are removed, so blocks being uploaded are not affected, even if the
+// bucket lists them without their meta file for a while. The upload start is read from the upload mark of the block,
+// blocks without one, e.g. uploaded by older versions, are as old as their ULID. With dryRun nothing is removed. It returns the removed objects, block directories as a whole.
+func CleanOrphanedObjects(ctx context.Context, logger log.Logger, bkt objstore.Bucket, dryRun bool, consistencyDelay time.Duration) ([]string, error) {
+	level.Info(logger).Log("msg", "start cleanup of orphaned objects", "dryRun", dryRun)
+
+	var (
+		blocks   = map[ulid.ULID]struct{}{}
+		orphaned []string
+		minAge   = MinimumAgeForRemoval
+	)
+	if consistencyDelay > minAge {
+		minAge = consistencyDelay
+	}
+	oldEnoughForRemoval := func(id ulid.ULID) (bool, error) {
+		started := ulid.Time(id.Time())
+		m, err := block.ReadUploadMark(ctx, logger, bkt, id)
+		if err != nil {
+			return false, errors.Wrapf(err, "read upload mark of %s", id)
+		}
+		if m != nil {
+			started = time.Unix(m.UploadTime, 0)
+		}
+		return time.Since(started) > minAge, nil
+	}
+	if err := bkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if !ok {
+			return nil
+		}
+		ok, err := bkt.Exists(ctx, path.Join(name, block.MetaFilename))
+		if err != nil {
+			return errors.Wrapf(err, "check meta file of %s", id)
+		}
+		if ok {
+			blocks[id] = struct{}{}
+			return nil
+		}
+		old, err := oldEnoughForRemoval(id)
+		if err != nil {
+			return err
+		}
+		if !old {
+			blocks[id] = struct{}{}
+			return nil
+		}
+
+		level.Info(logger).Log("msg", "found block directory without meta file", "block", id, "dryRun", dryRun)
+		if !dryRun {
+			if err := block.Delete(ctx, logger, bkt, id); err != nil {
+				return errors.Wrapf(err, "delete block directory %s", id)
+			}
+		}
+		orphaned = append(orphaned, name)
+		return nil
+	}); err != nil {
+		return orphaned, errors.Wrap(err, "clean block directories")
+	}
+
+	if err := bkt.Iter(ctx, block.DebugMetas, func(name string) error {
+		id, err := ulid.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+		if err != nil {
+			return nil
+		}
+		if _, ok := blocks[id]; ok {
+			return nil
+		}
+		// The block may have been uploaded since its directory was checked.
+		if old, err := oldEnoughForRemoval(id); err != nil || !old {
+			return err
+		}
+
+		level.Info(logger).Log("msg", "found debug meta file of deleted block", "block", id, "dryRun", dryRun)
+		if !dryRun {
+			if err := bkt.Delete(ctx, name); err != nil {
+				return errors.Wrapf(err, "delete %s", name)
+			}
+		}
+		orphaned = append(orphaned, name)
+		return nil
+	}); err != nil {
+		return orphaned, errors.Wrap(err, "clean debug meta files")
+	}
+
+	level.Info(logger).Log("msg", "cleanup of orphaned objects done", "orphaned", len(orphaned), "dryRun", dryRun)
+	return orphaned, nil
+}
@@ -0,0 +1,87 @@
+package compact_test
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestCleanOrphanedObjects(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		old    = ulid.MustNew(ulid.Now()-uint64(2*time.Hour/time.Millisecond), nil)
+		stale  = ulid.MustNew(ulid.Now()-uint64(3*time.Hour/time.Millisecond), nil)
+		fresh  = ulid.MustNew(ulid.Now(), nil)
+		gone   = ulid.MustNew(ulid.Now()-uint64(4*time.Hour/time.Millisecond), nil)
+		upload = ulid.MustNew(ulid.Now()-uint64(time.Minute/time.Millisecond), nil)
+		// Old blocks uploaded late, by the upload mark.
+		late     = ulid.MustNew(ulid.Now()-uint64(6*time.Hour/time.Millisecond), nil)
+		lateGone = ulid.MustNew(ulid.Now()-uint64(7*time.Hour/time.Millisecond), nil)
+	)
+	bkt := inmem.NewBucket()
+	for _, name := range []string{
+		// Complete block with its debug meta.
+		path.Join(old.String(), block.MetaFilename),
+		path.Join(old.String(), block.IndexFilename),
+		path.Join(block.DebugMetas, old.String()+".json"),
+		// Stale index cache of a block not deleted completely.
+		path.Join(stale.String(), block.IndexCacheFilename),
+		// Block being uploaded.
+		path.Join(fresh.String(), block.IndexFilename),
+		path.Join(block.DebugMetas, fresh.String()+".json"),
+		// Debug metas of a deleted block and a block about to be uploaded.
+		path.Join(block.DebugMetas, gone.String()+".json"),
+		path.Join(block.DebugMetas, upload.String()+".json"),
+		path.Join(block.DebugMetas, "other.json"),
+	} {
+		testutil.Ok(t, bkt.Upload(ctx, name, strings.NewReader("{}")))
+	}
+	testutil.Ok(t, block.MarkUpload(ctx, bkt, late))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(late.String(), block.IndexFilename), strings.NewReader("{}")))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(lateGone.String(), metadata.UploadMarkFilename), strings.NewReader(
+		fmt.Sprintf(`{"id":%q,"version":1,"uploadTime":%d}`, lateGone, time.Now().Add(-2*time.Hour).Unix()))))
+
+	objects := func() (res []string) {
+		for name := range bkt.Objects() {
+			res = append(res, name)
+		}
+		sort.Strings(res)
+		return res
+	}
+	before := objects()
+
+	// Nothing is removed within the consistency delay.
+	orphaned, err := compact.CleanOrphanedObjects(ctx, log.NewNopLogger(), bkt, false, 5*time.Hour)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(orphaned))
+	testutil.Equals(t, before, objects())
+
+	exp := []string{lateGone.String() + "/", stale.String() + "/", path.Join(block.DebugMetas, gone.String()+".json")}
+
+	orphaned, err = compact.CleanOrphanedObjects(ctx, log.NewNopLogger(), bkt, true, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, exp, orphaned)
+	testutil.Equals(t, before, objects())
+
+	orphaned, err = compact.CleanOrphanedObjects(ctx, log.NewNopLogger(), bkt, false, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, exp, orphaned)
+	testutil.Equals(t, 9, len(objects()))
+	for _, name := range objects() {
+		testutil.Assert(t, !strings.HasPrefix(name, stale.String()), "stale block directory not removed")
+		testutil.Assert(t, !strings.Contains(name, gone.String()), "debug meta of deleted block not removed")
+	}
+}
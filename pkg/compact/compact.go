@@ -2,12 +2,16 @@ package compact
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,11 +23,13 @@ import (
 	promlables "github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	terrors "github.com/prometheus/prometheus/tsdb/errors"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 )
 
@@ -39,6 +45,30 @@ const (
 
 var blockTooFreshSentinelError = errors.New("Block too fresh")
 
+// BlockDiscovery is the strategy the syncer discovers the blocks of the bucket with.
+type BlockDiscovery string
+
+const (
+	// BlockDiscoveryConcurrent lists the block directories of the bucket and downloads their meta files concurrently
+	// while listing.
+	BlockDiscoveryConcurrent BlockDiscovery = "concurrent"
+	// BlockDiscoveryRecursive lists all objects of the bucket recursively first, and downloads meta files of listed
+	// blocks afterwards, skipping blocks listed without meta file. Object storages listing directories slowly, e.g.
+	// with prefixed layouts, perform better with it, although it also lists chunks and indexes of all blocks. Buckets
+	// which cannot list recursively are listed by directory.
+	BlockDiscoveryRecursive BlockDiscovery = "recursive"
+)
+
+// BlockDiscoveries are all supported block discovery strategies.
+var BlockDiscoveries = []BlockDiscovery{BlockDiscoveryConcurrent, BlockDiscoveryRecursive}
+
+// discoveredBlock is a block listed in the bucket.
+type discoveredBlock struct {
+	id ulid.ULID
+	// noMeta is true if the block was listed recursively without meta file.
+	noMeta bool
+}
+
 // Syncer syncronizes block metas from a bucket into a local directory.
 // It sorts them into compaction groups based on equal label sets.
 type Syncer struct {
@@ -49,27 +79,56 @@ type Syncer struct {
 	mtx                  sync.Mutex
 	blocks               map[ulid.ULID]*metadata.Meta
 	blocksMtx            sync.Mutex
+	noCompact            map[ulid.ULID]struct{}
+	halted               map[string]HaltedGroup
 	blockSyncConcurrency int
 	metrics              *syncerMetrics
-	acceptMalformedIndex bool
 	relabelConfig        []*relabel.Config
+	selector             labels.Labels
+	sources              []metadata.SourceType
+	timeFilter           *TimeFilter
+	shard                Shard
+
+	gcMaxDeletions int
+	// pendingGarbage are outdated blocks not deleted by the last garbage collection. They are excluded from compaction.
+	pendingGarbage map[ulid.ULID]struct{}
+	// lastGC is the result of the last garbage collection, guarded by its own mutex so it can be read while a garbage
+	// collection runs.
+	lastGCMtx sync.Mutex
+	lastGC    *GarbageCollection
+
+	// partial are blocks with meta files which cannot be read, see PartialBlocks.
+	partial map[ulid.ULID]*PartialBlock
+	// partialDeleteDelays is the number of consistency delays after which quarantined partial blocks are deleted.
+	partialDeleteDelays int
+	// metaCacheDir is the directory meta files downloaded from the bucket are cached in, empty if disabled.
+	metaCacheDir string
+	discovery    BlockDiscovery
+
+	// haltOpts configure halts of groups, see SetGroupHaltOptions.
+	haltOpts GroupHaltOptions
+	// groupHalts are the numbers of consecutive halts of groups, kept once halts are lifted by the backoff.
+	groupHalts map[string]int
+	now        func() time.Time
 }
 
 type syncerMetrics struct {
 	syncMetas                 prometheus.Counter
 	syncMetaFailures          prometheus.Counter
 	syncMetaDuration          prometheus.Histogram
+	metaCacheHits             prometheus.Counter
 	garbageCollectedBlocks    prometheus.Counter
 	garbageCollections        prometheus.Counter
 	garbageCollectionFailures prometheus.Counter
 	garbageCollectionDuration prometheus.Histogram
-	compactions               *prometheus.CounterVec
-	compactionRunsStarted     *prometheus.CounterVec
-	compactionRunsCompleted   *prometheus.CounterVec
-	compactionFailures        *prometheus.CounterVec
+	garbageDeletionFailures   prometheus.Counter
+	pendingGarbageBlocks      prometheus.Gauge
+	haltedGroups              *prometheus.GaugeVec
+	shardLabelSets            *prometheus.GaugeVec
+	partialBlocks             *prometheus.GaugeVec
 }
 
-func newSyncerMetrics(reg prometheus.Registerer) *syncerMetrics {
+func newSyncerMetrics(reg prometheus.Registerer, garbageCollectedBlocks prometheus.Counter) *syncerMetrics {
 	var m syncerMetrics
 
 	m.syncMetas = prometheus.NewCounter(prometheus.CounterOpts{
@@ -87,11 +146,12 @@ func newSyncerMetrics(reg prometheus.Registerer) *syncerMetrics {
 			0.25, 0.6, 1, 2, 3.5, 5, 7.5, 10, 15, 30, 60, 100, 200, 500,
 		},
 	})
-
-	m.garbageCollectedBlocks = prometheus.NewCounter(prometheus.CounterOpts{
-		Name: "thanos_compact_garbage_collected_blocks_total",
-		Help: "Total number of deleted blocks by compactor.",
+	m.metaCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_compact_sync_meta_cache_hits_total",
+		Help: "Total number of meta files read from the meta cache directory instead of the bucket.",
 	})
+
+	m.garbageCollectedBlocks = garbageCollectedBlocks
 	m.garbageCollections = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "thanos_compact_garbage_collection_total",
 		Help: "Total number of garbage collection operations.",
@@ -108,36 +168,41 @@ func newSyncerMetrics(reg prometheus.Registerer) *syncerMetrics {
 		},
 	})
 
-	m.compactions = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "thanos_compact_group_compactions_total",
-		Help: "Total number of group compaction attempts that resulted in a new block.",
-	}, []string{"group"})
-	m.compactionRunsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "thanos_compact_group_compaction_runs_started_total",
-		Help: "Total number of group compaction attempts.",
-	}, []string{"group"})
-	m.compactionRunsCompleted = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "thanos_compact_group_compaction_runs_completed_total",
-		Help: "Total number of group completed compaction runs. This also includes compactor group runs that resulted with no compaction.",
-	}, []string{"group"})
-	m.compactionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "thanos_compact_group_compactions_failures_total",
-		Help: "Total number of failed group compactions.",
-	}, []string{"group"})
+	m.garbageDeletionFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_compact_garbage_collection_block_failures_total",
+		Help: "Total number of failed deletions of outdated blocks by garbage collection. Failed deletions are retried by the next garbage collection.",
+	})
+	m.pendingGarbageBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_compact_garbage_collection_pending_blocks",
+		Help: "Number of outdated blocks not deleted by the last garbage collection, due to failures or the limit of deletions.",
+	})
+	m.haltedGroups = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_compact_group_halted",
+		Help: "Set to 1 for groups, which are not compacted anymore due to a critical error, with the reason of the error.",
+	}, []string{"group", "reason"})
+	m.shardLabelSets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_compact_shard_label_sets",
+		Help: "Number of external label sets of blocks in the bucket by whether they are owned by this compactor, as selected by its selector and relabel config.",
+	}, []string{"owned"})
+	m.partialBlocks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_compact_partial_blocks",
+		Help: "Number of blocks with meta files which cannot be read, by whether they are quarantined by marking them for no compaction.",
+	}, []string{"quarantined"})
 
 	if reg != nil {
 		reg.MustRegister(
 			m.syncMetas,
 			m.syncMetaFailures,
 			m.syncMetaDuration,
-			m.garbageCollectedBlocks,
+			m.metaCacheHits,
 			m.garbageCollections,
 			m.garbageCollectionFailures,
 			m.garbageCollectionDuration,
-			m.compactions,
-			m.compactionRunsStarted,
-			m.compactionRunsCompleted,
-			m.compactionFailures,
+			m.garbageDeletionFailures,
+			m.pendingGarbageBlocks,
+			m.haltedGroups,
+			m.shardLabelSets,
+			m.partialBlocks,
 		)
 	}
 	return &m
@@ -145,26 +210,56 @@ func newSyncerMetrics(reg prometheus.Registerer) *syncerMetrics {
 
 // NewSyncer returns a new Syncer for the given Bucket and directory.
 // Blocks must be at least as old as the sync delay for being considered.
-func NewSyncer(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, consistencyDelay time.Duration, blockSyncConcurrency int, acceptMalformedIndex bool, relabelConfig []*relabel.Config) (*Syncer, error) {
+// If a selector is given, only blocks with matching external labels are considered, see Shard.
+// If sources are given, only blocks produced by them are considered, see FilterBySource.
+// If timeFilter is given, only blocks starting within its time window are considered, see FilterByTime.
+// Deleted blocks are counted by the given garbageCollectedBlocks counter, which is shared with groups of the Grouper.
+// If gcMaxDeletions is positive, each garbage collection deletes at most gcMaxDeletions blocks.
+// If partialDeleteDelays is positive, quarantined partial blocks are deleted partialDeleteDelays consistency delays
+// after they were quarantined, see PartialBlocks.
+// If metaCacheDir is not empty, meta files are cached in it, so they are not downloaded again after restarts. Metas of
+// blocks deleted from the bucket are removed from it on each sync.
+// Blocks are discovered with the given strategy, BlockDiscoveryConcurrent if empty.
+func NewSyncer(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, consistencyDelay time.Duration, blockSyncConcurrency int, relabelConfig []*relabel.Config, selector labels.Labels, sources []metadata.SourceType, timeFilter *TimeFilter, garbageCollectedBlocks prometheus.Counter, gcMaxDeletions int, partialDeleteDelays int, metaCacheDir string, discovery BlockDiscovery) (*Syncer, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
+	switch discovery {
+	case "":
+		discovery = BlockDiscoveryConcurrent
+	case BlockDiscoveryConcurrent, BlockDiscoveryRecursive:
+	default:
+		return nil, errors.Errorf("unknown block discovery %q", discovery)
+	}
 	return &Syncer{
 		logger:               logger,
 		reg:                  reg,
 		consistencyDelay:     consistencyDelay,
 		blocks:               map[ulid.ULID]*metadata.Meta{},
+		noCompact:            map[ulid.ULID]struct{}{},
+		halted:               map[string]HaltedGroup{},
+		groupHalts:           map[string]int{},
+		now:                  time.Now,
+		pendingGarbage:       map[ulid.ULID]struct{}{},
+		gcMaxDeletions:       gcMaxDeletions,
+		partial:              map[ulid.ULID]*PartialBlock{},
+		partialDeleteDelays:  partialDeleteDelays,
+		metaCacheDir:         metaCacheDir,
+		discovery:            discovery,
 		bkt:                  bkt,
-		metrics:              newSyncerMetrics(reg),
+		metrics:              newSyncerMetrics(reg, garbageCollectedBlocks),
 		blockSyncConcurrency: blockSyncConcurrency,
-		acceptMalformedIndex: acceptMalformedIndex,
 		relabelConfig:        relabelConfig,
+		selector:             selector,
+		sources:              sources,
+		timeFilter:           timeFilter,
 	}, nil
 }
 
 // SyncMetas synchronizes all meta files from blocks in the bucket into
 // the memory.  It removes any partial blocks older than the max of
-// consistencyDelay and MinimumAgeForRemoval from the bucket.
+// consistencyDelay and MinimumAgeForRemoval from the bucket. Blocks with meta files
+// which cannot be read are quarantined, see PartialBlocks.
 func (c *Syncer) SyncMetas(ctx context.Context) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
@@ -175,6 +270,14 @@ func (c *Syncer) SyncMetas(ctx context.Context) error {
 	if err != nil {
 		c.metrics.syncMetaFailures.Inc()
 	}
+	var quarantined int
+	for _, p := range c.partial {
+		if p.QuarantineTime != 0 {
+			quarantined++
+		}
+	}
+	c.metrics.partialBlocks.WithLabelValues("true").Set(float64(quarantined))
+	c.metrics.partialBlocks.WithLabelValues("false").Set(float64(len(c.partial) - quarantined))
 	c.metrics.syncMetas.Inc()
 	c.metrics.syncMetaDuration.Observe(time.Since(begin).Seconds())
 	return err
@@ -200,8 +303,10 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
-	metaIDsChan := make(chan ulid.ULID)
+	blocksChan := make(chan discoveredBlock)
 	errChan := make(chan error, c.blockSyncConcurrency)
+	// notOwned are external label sets of blocks dropped by the selector or relabeling.
+	notOwned := map[uint64]map[string]string{}
 
 	workCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -210,7 +315,8 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 		go func() {
 			defer wg.Done()
 
-			for id := range metaIDsChan {
+			for b := range blocksChan {
+				id := b.id
 				// Check if we already have this block cached locally.
 				c.blocksMtx.Lock()
 				_, seen := c.blocks[id]
@@ -219,18 +325,38 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 					continue
 				}
 
-				meta, err := c.downloadMeta(workCtx, id)
-				if err == blockTooFreshSentinelError {
-					continue
-				}
-
-				if err != nil {
-					if removedOrIgnored := c.removeIfMetaMalformed(workCtx, id); removedOrIgnored {
+				meta := c.cachedMeta(id)
+				if meta == nil {
+					var err error
+					if b.noMeta {
+						err = c.missingMeta(id)
+					} else {
+						meta, err = c.downloadMeta(workCtx, id)
+					}
+					if err == blockTooFreshSentinelError {
 						continue
 					}
-					errChan <- err
-					return
+
+					if err != nil {
+						if removedOrIgnored := c.removeIfMetaMalformed(workCtx, id); removedOrIgnored {
+							continue
+						}
+						quarantined, qerr := c.quarantineIfPartial(workCtx, id, err)
+						if qerr != nil {
+							errChan <- qerr
+							return
+						}
+						if quarantined {
+							continue
+						}
+						errChan <- err
+						return
+					}
+					c.cacheMeta(meta)
 				}
+				c.blocksMtx.Lock()
+				delete(c.partial, id)
+				c.blocksMtx.Unlock()
 
 				// Check for block labels by relabeling.
 				// If output is empty, the block will be dropped.
@@ -238,11 +364,35 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 				processedLabels := relabel.Process(lset, c.relabelConfig...)
 				if processedLabels == nil {
 					level.Debug(c.logger).Log("msg", "dropping block(drop in relabeling)", "block", id)
+					c.blocksMtx.Lock()
+					notOwned[lset.Hash()] = meta.Thanos.Labels
+					c.blocksMtx.Unlock()
 					continue
 				}
+				if !selects(c.selector, meta.Thanos.Labels) {
+					level.Debug(c.logger).Log("msg", "dropping block(not matched by selector)", "block", id)
+					c.blocksMtx.Lock()
+					notOwned[lset.Hash()] = meta.Thanos.Labels
+					c.blocksMtx.Unlock()
+					continue
+				}
+
+				// Marks are only checked once per block, blocks marked by this compactor are excluded by markNoCompact and
+				// blocks marked later by others once they are planned.
+				noCompact, err := block.IsMarkedForNoCompact(workCtx, c.bkt, id)
+				if err != nil {
+					errChan <- err
+					return
+				}
+				if noCompact {
+					level.Debug(c.logger).Log("msg", "block is marked for no compaction", "block", id)
+				}
 
 				c.blocksMtx.Lock()
 				c.blocks[id] = meta
+				if noCompact {
+					c.noCompact[id] = struct{}{}
+				}
 				c.blocksMtx.Unlock()
 			}
 		}()
@@ -251,22 +401,27 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 	// Read back all block metas so we can detect deleted blocks.
 	remote := map[ulid.ULID]struct{}{}
 
-	err := c.bkt.Iter(ctx, "", func(name string) error {
-		id, ok := block.IsBlockDir(name)
-		if !ok {
-			return nil
-		}
+	var err error
+	if c.discovery == BlockDiscoveryRecursive {
+		err = c.discoverRecursive(ctx, remote, blocksChan)
+	} else {
+		err = c.bkt.Iter(ctx, "", func(name string) error {
+			id, ok := block.IsBlockDir(name)
+			if !ok {
+				return nil
+			}
 
-		remote[id] = struct{}{}
+			remote[id] = struct{}{}
 
-		select {
-		case <-ctx.Done():
-		case metaIDsChan <- id:
-		}
+			select {
+			case <-ctx.Done():
+			case blocksChan <- discoveredBlock{id: id}:
+			}
 
-		return nil
-	})
-	close(metaIDsChan)
+			return nil
+		})
+	}
+	close(blocksChan)
 	if err != nil {
 		return retry(errors.Wrap(err, "retrieve bucket block metas"))
 	}
@@ -282,35 +437,207 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 	for id := range c.blocks {
 		if _, ok := remote[id]; !ok {
 			delete(c.blocks, id)
+			delete(c.noCompact, id)
+		}
+	}
+	for id := range c.partial {
+		if _, ok := remote[id]; !ok {
+			delete(c.partial, id)
+		}
+	}
+	c.cleanMetaCache(remote)
+	c.setShard(notOwned)
+
+	filtered := FilterBySource(c.sources, c.blocks)
+	for id := range c.blocks {
+		if _, ok := filtered[id]; !ok {
+			level.Debug(c.logger).Log("msg", "dropping block(not produced by filtered sources)", "block", id, "source", c.blocks[id].Thanos.Source)
+			delete(c.blocks, id)
 		}
 	}
 
+	filtered = FilterByTime(c.timeFilter, c.blocks)
+	for id := range c.blocks {
+		if _, ok := filtered[id]; !ok {
+			level.Debug(c.logger).Log("msg", "dropping block(outside of filtered time window)", "block", id, "minTime", c.blocks[id].MinTime)
+			delete(c.blocks, id)
+		}
+	}
+
+	return nil
+}
+
+// discoverRecursive lists all objects of the bucket recursively, adds the IDs of listed blocks to remote and sends the
+// blocks to the channel once listed.
+func (c *Syncer) discoverRecursive(ctx context.Context, remote map[ulid.ULID]struct{}, blocksChan chan<- discoveredBlock) error {
+	// mayHaveMeta is whether a meta file of the block was listed, or the block directory itself in case the bucket
+	// cannot list recursively.
+	mayHaveMeta := map[ulid.ULID]bool{}
+	if err := c.bkt.Iter(objstore.WithRecursiveIter(ctx), "", func(name string) error {
+		parts := strings.SplitN(name, objstore.DirDelim, 2)
+		if len(parts) != 2 {
+			return nil
+		}
+		id, err := ulid.Parse(parts[0])
+		if err != nil {
+			return nil
+		}
+		mayHaveMeta[id] = mayHaveMeta[id] || parts[1] == "" || parts[1] == block.MetaFilename
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for id, ok := range mayHaveMeta {
+		remote[id] = struct{}{}
+		select {
+		case <-ctx.Done():
+			return nil
+		case blocksChan <- discoveredBlock{id: id, noMeta: !ok}:
+		}
+	}
 	return nil
 }
 
+// Shard is the subset of external label sets of blocks in the bucket owned by a syncer, as selected by its selector and
+// relabel config. Multiple compactors can split a single bucket safely if each label set is owned by exactly one of them.
+type Shard struct {
+	// Selector are the values of external labels blocks must have by label name, any of the values of each name.
+	Selector map[string][]string `json:"selector"`
+	Owned    []map[string]string `json:"owned"`
+	NotOwned []map[string]string `json:"not_owned"`
+}
+
+// selects returns true if the external labels have, for every label name of the selector, one of its values.
+func selects(selector labels.Labels, lset map[string]string) bool {
+	matched := make(map[string]bool, len(selector))
+	for _, l := range selector {
+		if !matched[l.Name] {
+			matched[l.Name] = lset[l.Name] == l.Value
+		}
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Syncer) setShard(notOwned map[uint64]map[string]string) {
+	shard := Shard{Selector: map[string][]string{}}
+	for _, l := range c.selector {
+		shard.Selector[l.Name] = append(shard.Selector[l.Name], l.Value)
+	}
+	owned := map[uint64]map[string]string{}
+	for _, m := range c.blocks {
+		owned[labels.FromMap(m.Thanos.Labels).Hash()] = m.Thanos.Labels
+	}
+	sorted := func(lsets map[uint64]map[string]string) []map[string]string {
+		res := make([]map[string]string, 0, len(lsets))
+		for _, lset := range lsets {
+			res = append(res, lset)
+		}
+		sort.Slice(res, func(i, j int) bool {
+			return labels.Compare(labels.FromMap(res[i]), labels.FromMap(res[j])) < 0
+		})
+		return res
+	}
+	shard.Owned, shard.NotOwned = sorted(owned), sorted(notOwned)
+
+	c.shard = shard
+	c.metrics.shardLabelSets.WithLabelValues("true").Set(float64(len(shard.Owned)))
+	c.metrics.shardLabelSets.WithLabelValues("false").Set(float64(len(shard.NotOwned)))
+}
+
+// Shard returns the external label sets of blocks in the bucket owned and not owned by the syncer, as of the last sync.
+func (c *Syncer) Shard() Shard {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.shard
+}
+
+// TimeFilter is a time window of blocks to process. Relative times are evaluated against the current time on each use.
+type TimeFilter struct {
+	MinTime, MaxTime model.TimeOrDurationValue
+}
+
+// FilterByTime returns blocks whose min time is within the time window of the filter, from its min time inclusive to
+// its max time exclusive. No filtering is done if no filter is given.
+//
+// Blocks are selected by their min time only, so every block belongs to exactly one of multiple non-overlapping time
+// windows, and blocks compacted or downsampled from blocks of a window belong to the same window.
+func FilterByTime(f *TimeFilter, metas map[ulid.ULID]*metadata.Meta) map[ulid.ULID]*metadata.Meta {
+	if f == nil {
+		return metas
+	}
+	minTime, maxTime := f.MinTime.PrometheusTimestamp(), f.MaxTime.PrometheusTimestamp()
+
+	res := make(map[ulid.ULID]*metadata.Meta, len(metas))
+	for id, m := range metas {
+		if m.MinTime >= minTime && m.MinTime < maxTime {
+			res[id] = m
+		}
+	}
+	return res
+}
+
+// FilterByLabels returns blocks whose external labels are kept by the relabel config and matched by the selector, like
+// the blocks synced by a syncer with the same relabel config and selector. No filtering is done if neither is given.
+func FilterByLabels(relabelConfig []*relabel.Config, selector labels.Labels, metas map[ulid.ULID]*metadata.Meta) map[ulid.ULID]*metadata.Meta {
+	if len(relabelConfig) == 0 && len(selector) == 0 {
+		return metas
+	}
+
+	res := make(map[ulid.ULID]*metadata.Meta, len(metas))
+	for id, m := range metas {
+		if relabel.Process(promlables.FromMap(m.Thanos.Labels), relabelConfig...) == nil {
+			continue
+		}
+		if !selects(selector, m.Thanos.Labels) {
+			continue
+		}
+		res[id] = m
+	}
+	return res
+}
+
+// FilterBySource returns blocks produced by the given sources. No filtering is done if no sources are given.
+//
+// Blocks created by the compactor are attributed to the source of the blocks they were created from, as recorded in
+// their meta, see metadata.Thanos.OriginSource, so the blocks returned for disjoint sources are disjoint, even once the
+// blocks they were created from are deleted. Blocks created by the compactor without known origin are not returned.
+func FilterBySource(sources []metadata.SourceType, metas map[ulid.ULID]*metadata.Meta) map[ulid.ULID]*metadata.Meta {
+	if len(sources) == 0 {
+		return metas
+	}
+
+	res := make(map[ulid.ULID]*metadata.Meta, len(metas))
+	for id, m := range metas {
+		origin := m.Thanos.OriginSource()
+		for _, s := range sources {
+			if origin == s {
+				res[id] = m
+				break
+			}
+		}
+	}
+	return res
+}
+
 func (c *Syncer) downloadMeta(ctx context.Context, id ulid.ULID) (*metadata.Meta, error) {
 	level.Debug(c.logger).Log("msg", "download meta", "block", id)
 
 	meta, err := block.DownloadMeta(ctx, c.logger, c.bkt, id)
 	if err != nil {
-		if ulid.Now()-id.Time() < uint64(c.consistencyDelay/time.Millisecond) {
+		if IsBlockTooFresh(id, nil, c.consistencyDelay) {
 			level.Debug(c.logger).Log("msg", "block is too fresh for now", "block", id)
 			return nil, blockTooFreshSentinelError
 		}
 		return nil, errors.Wrapf(err, "downloading meta.json for %s", id)
 	}
 
-	// ULIDs contain a millisecond timestamp. We do not consider blocks that have been created too recently to
-	// avoid races when a block is only partially uploaded. This relates to all blocks, excluding:
-	// - repair created blocks
-	// - compactor created blocks
-	// NOTE: It is not safe to miss "old" block (even that it is newly created) in sync step. Compactor needs to aware of ALL old blocks.
-	// TODO(bplotka): https://github.com/thanos-io/thanos/issues/377.
-	if ulid.Now()-id.Time() < uint64(c.consistencyDelay/time.Millisecond) &&
-		meta.Thanos.Source != metadata.BucketRepairSource &&
-		meta.Thanos.Source != metadata.CompactorSource &&
-		meta.Thanos.Source != metadata.CompactorRepairSource {
-
+	if IsBlockTooFresh(id, &meta, c.consistencyDelay) {
 		level.Debug(c.logger).Log("msg", "block is too fresh for now", "block", id)
 		return nil, blockTooFreshSentinelError
 	}
@@ -318,6 +645,37 @@ func (c *Syncer) downloadMeta(ctx context.Context, id ulid.ULID) (*metadata.Meta
 	return &meta, nil
 }
 
+// missingMeta returns the error of downloading the meta file of a block listed without one.
+func (c *Syncer) missingMeta(id ulid.ULID) error {
+	if IsBlockTooFresh(id, nil, c.consistencyDelay) {
+		level.Debug(c.logger).Log("msg", "block is too fresh for now", "block", id)
+		return blockTooFreshSentinelError
+	}
+	return errors.Errorf("no meta.json listed for %s", id)
+}
+
+// IsBlockTooFresh returns true if the block was created less than the consistency delay ago, so it must not be
+// considered yet. Blocks of eventually consistent object stores may look partially uploaded until then, e.g. listed
+// without their meta file or with only some of their chunks. Meta is nil if the meta file could not be read.
+//
+// ULIDs contain a millisecond timestamp. We do not consider blocks that have been created too recently to
+// avoid races when a block is only partially uploaded. This relates to all blocks, excluding:
+// - repair created blocks
+// - compactor created blocks
+// NOTE: It is not safe to miss "old" block (even that it is newly created) in sync step. Compactor needs to aware of ALL old blocks.
+// TODO(bplotka): https://github.com/thanos-io/thanos/issues/377.
+func IsBlockTooFresh(id ulid.ULID, meta *metadata.Meta, consistencyDelay time.Duration) bool {
+	if ulid.Now()-id.Time() >= uint64(consistencyDelay/time.Millisecond) {
+		return false
+	}
+	if meta == nil {
+		return true
+	}
+	return meta.Thanos.Source != metadata.BucketRepairSource &&
+		meta.Thanos.Source != metadata.CompactorSource &&
+		meta.Thanos.Source != metadata.CompactorRepairSource
+}
+
 // removeIfMalformed removes a block from the bucket if that block does not have a meta file.  It ignores blocks that
 // are younger than MinimumAgeForRemoval.
 func (c *Syncer) removeIfMetaMalformed(ctx context.Context, id ulid.ULID) (removedOrIgnored bool) {
@@ -345,150 +703,701 @@ func (c *Syncer) removeIfMetaMalformed(ctx context.Context, id ulid.ULID) (remov
 	return true
 }
 
-// GroupKey returns a unique identifier for the group the block belongs to. It considers
-// the downsampling resolution and the block's labels.
-func GroupKey(meta metadata.Thanos) string {
-	return groupKey(meta.Downsample.Resolution, labels.FromMap(meta.Labels))
-}
-
-func groupKey(res int64, lbls labels.Labels) string {
-	return fmt.Sprintf("%d@%v", res, lbls.Hash())
+// PartialBlock is a block in the bucket with a meta file which cannot be read, e.g. due to a corrupted or interrupted
+// upload. Blocks without meta file are not partial blocks, they are removed once older than MinimumAgeForRemoval.
+type PartialBlock struct {
+	ID    ulid.ULID `json:"id"`
+	Error string    `json:"error"`
+	// FirstSeen is when the syncer failed to read the meta file first.
+	FirstSeen time.Time `json:"first_seen"`
+	// QuarantineTime is the unix timestamp in seconds when the block was marked for no compaction for being
+	// partial, 0 if it is not quarantined yet.
+	QuarantineTime int64 `json:"quarantine_time,omitempty"`
 }
 
-// Groups returns the compaction groups for all blocks currently known to the syncer.
-// It creates all groups from the scratch on every call.
-func (c *Syncer) Groups() (res []*Group, err error) {
+// PartialBlocks returns all partial blocks found by the last sync, sorted by ID.
+//
+// Meta files which cannot be read fail the sync, as they may be read again later. Once a block is partial for longer
+// than the consistency delay, it is quarantined by marking it for no compaction and the sync ignores it from then on.
+// Quarantined blocks are deleted after partialDeleteDelays consistency delays, if enabled.
+func (c *Syncer) PartialBlocks() []PartialBlock {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	groups := map[string]*Group{}
-	for _, m := range c.blocks {
-		g, ok := groups[GroupKey(m.Thanos)]
-		if !ok {
-			g, err = newGroup(
-				log.With(c.logger, "compactionGroup", GroupKey(m.Thanos)),
-				c.bkt,
-				labels.FromMap(m.Thanos.Labels),
-				m.Thanos.Downsample.Resolution,
-				c.acceptMalformedIndex,
-				c.metrics.compactions.WithLabelValues(GroupKey(m.Thanos)),
-				c.metrics.compactionRunsStarted.WithLabelValues(GroupKey(m.Thanos)),
-				c.metrics.compactionRunsCompleted.WithLabelValues(GroupKey(m.Thanos)),
-				c.metrics.compactionFailures.WithLabelValues(GroupKey(m.Thanos)),
-				c.metrics.garbageCollectedBlocks,
-			)
-			if err != nil {
-				return nil, errors.Wrap(err, "create compaction group")
-			}
-			groups[GroupKey(m.Thanos)] = g
-			res = append(res, g)
-		}
-		if err := g.Add(m); err != nil {
-			return nil, errors.Wrap(err, "add compaction group")
-		}
+	res := make([]PartialBlock, 0, len(c.partial))
+	for _, p := range c.partial {
+		res = append(res, *p)
 	}
 	sort.Slice(res, func(i, j int) bool {
-		return res[i].Key() < res[j].Key()
+		return res[i].ID.Compare(res[j].ID) < 0
 	})
-	return res, nil
+	return res
 }
 
-// GarbageCollect deletes blocks from the bucket if their data is available as part of a
-// block with a higher compaction level.
-func (c *Syncer) GarbageCollect(ctx context.Context) error {
-	c.mtx.Lock()
-	defer c.mtx.Unlock()
+// quarantineIfPartial records the block with a meta file which cannot be decoded due to the given error as partial. It
+// returns true if the block is quarantined, i.e. it is ignored by the sync, or deleted. Other errors, e.g. of the bucket,
+// are not considered partial blocks, they fail the sync, which is retried.
+func (c *Syncer) quarantineIfPartial(ctx context.Context, id ulid.ULID, metaErr error) (bool, error) {
+	if !block.IsCorruptedMetaError(metaErr) {
+		return false, nil
+	}
+	metaExists, err := c.bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+	if err != nil || !metaExists {
+		return false, nil
+	}
 
-	begin := time.Now()
+	c.blocksMtx.Lock()
+	p, ok := c.partial[id]
+	if !ok {
+		p = &PartialBlock{ID: id, FirstSeen: time.Now()}
+		c.partial[id] = p
+	}
+	p.Error = metaErr.Error()
+	c.blocksMtx.Unlock()
 
-	// Run a separate round of garbage collections for each valid resolution.
-	for _, res := range []int64{
-		downsample.ResLevel0, downsample.ResLevel1, downsample.ResLevel2,
-	} {
-		err := c.garbageCollect(ctx, res)
+	if p.QuarantineTime == 0 {
+		// The block may have been quarantined before a restart.
+		mark, err := block.ReadNoCompactMark(ctx, c.logger, c.bkt, id)
 		if err != nil {
-			c.metrics.garbageCollectionFailures.Inc()
+			return false, err
 		}
-		c.metrics.garbageCollections.Inc()
-		c.metrics.garbageCollectionDuration.Observe(time.Since(begin).Seconds())
-
-		if err != nil {
-			return errors.Wrapf(err, "garbage collect resolution %d", res)
+		if mark != nil && mark.Reason == metadata.PartialNoCompactReason {
+			p.QuarantineTime = mark.NoCompactTime
 		}
 	}
-	return nil
+	if p.QuarantineTime == 0 {
+		if time.Since(p.FirstSeen) < c.consistencyDelay {
+			return false, nil
+		}
+		if err := block.MarkForNoCompact(ctx, c.logger, c.bkt, id, metadata.PartialNoCompactReason, p.Error); err != nil {
+			return false, errors.Wrapf(err, "quarantine partial block %s", id)
+		}
+		p.QuarantineTime = time.Now().Unix()
+		level.Warn(c.logger).Log("msg", "quarantined partial block", "block", id, "err", metaErr)
+	}
+
+	if c.partialDeleteDelays <= 0 || time.Since(time.Unix(p.QuarantineTime, 0)) < time.Duration(c.partialDeleteDelays)*c.consistencyDelay {
+		return true, nil
+	}
+	if err := block.Delete(ctx, c.logger, c.bkt, id); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to delete quarantined partial block", "block", id, "err", err)
+		return true, nil
+	}
+	level.Info(c.logger).Log("msg", "deleted quarantined partial block", "block", id)
+
+	c.blocksMtx.Lock()
+	delete(c.partial, id)
+	c.blocksMtx.Unlock()
+	return true, nil
 }
 
-func (c *Syncer) GarbageBlocks(resolution int64) (ids []ulid.ULID, err error) {
-	// Map each block to its highest priority parent. Initial blocks have themselves
-	// in their source section, i.e. are their own parent.
-	parents := map[ulid.ULID]ulid.ULID{}
+// GroupKey returns a unique identifier for the group the block belongs to. It considers
+// the downsampling resolution and the block's labels, e.g. `0@{cluster="eu",replica="a"}`. Blocks holding a shard of
+// series are grouped by shard, e.g. `0@{cluster="eu",replica="a"}@shard_1_of_4`.
+func GroupKey(meta metadata.Thanos) string {
+	return groupKey(meta.Downsample.Resolution, labels.FromMap(meta.Labels), meta.Shard)
+}
 
-	for id, meta := range c.blocks {
+func groupKey(res int64, lbls labels.Labels, shard *metadata.ThanosShard) string {
+	if shard != nil {
+		return fmt.Sprintf("%d@%s@shard_%s", res, lbls.String(), shard)
+	}
+	return fmt.Sprintf("%d@%s", res, lbls.String())
+}
 
-		// Skip any block that has a different resolution.
-		if meta.Thanos.Downsample.Resolution != resolution {
-			continue
+// legacyGroupKey returns the group key in the format used before labels were part of it, with the hash of the
+// labels instead, e.g. `0@17241709254077376921`. Unlike the group key, it is safe to use as a directory name.
+func legacyGroupKey(res int64, lbls labels.Labels, shard *metadata.ThanosShard) string {
+	if shard != nil {
+		return fmt.Sprintf("%d@%v@shard_%s", res, lbls.Hash(), shard)
+	}
+	return fmt.Sprintf("%d@%v", res, lbls.Hash())
+}
+
+// ParseGroupKey parses the downsampling resolution and the hash of the labels from the group key. Both the current
+// format and the legacy format with the hash of the labels are accepted, so keys of both formats, e.g. from
+// existing metrics, can be matched with each other. The shard of the key, if any, is ignored.
+func ParseGroupKey(key string) (res int64, labelsHash uint64, err error) {
+	parts := strings.SplitN(key, "@", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("invalid group key %q, expected <resolution>@<labels>", key)
+	}
+	res, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "parse resolution of group key %q", key)
+	}
+	// Label values may contain @, so the shard is cut after the closing brace of the labels.
+	if i := strings.LastIndex(parts[1], "}"); strings.HasPrefix(parts[1], "{") && i >= 0 {
+		parts[1] = parts[1][:i+1]
+	} else if i := strings.Index(parts[1], "@"); i >= 0 {
+		parts[1] = parts[1][:i]
+	}
+	if !strings.HasPrefix(parts[1], "{") {
+		labelsHash, err = strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "parse labels hash of group key %q", key)
 		}
+		return res, labelsHash, nil
+	}
+	lset, err := parseGroupKeyLabels(parts[1])
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "parse labels of group key %q", key)
+	}
+	return res, lset.Hash(), nil
+}
 
-		// For each source block we contain, check whether we are the highest priority parent block.
-		for _, sid := range meta.Compaction.Sources {
-			pid, ok := parents[sid]
-			// No parents for the source block so far.
-			if !ok {
-				parents[sid] = id
-				continue
+// parseGroupKeyLabels parses labels formatted by labels.Labels.String. Unlike PromQL, it accepts any label names
+// which are not quoted, as external labels of blocks are not validated, e.g. names with dots or slashes.
+func parseGroupKeyLabels(s string) (labels.Labels, error) {
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, errors.Errorf("labels %q not enclosed in braces", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var lset labels.Labels
+	for s != "" {
+		if len(lset) > 0 {
+			if !strings.HasPrefix(s, ",") {
+				return nil, errors.Errorf("expected \",\" before %q", s)
 			}
-			pmeta, ok := c.blocks[pid]
-			if !ok {
-				return nil, errors.Errorf("previous parent block %s not found", pid)
+			s = strings.TrimPrefix(s[1:], " ")
+		}
+		i := strings.Index(s, "=\"")
+		if i <= 0 {
+			return nil, errors.Errorf("expected label name before %q", s)
+		}
+		name := s[:i]
+		s = s[i+1:]
+
+		// Find the closing quote of the value, skipping escaped characters.
+		end := -1
+		for j := 1; j < len(s); j++ {
+			if s[j] == '\\' {
+				j++
+				continue
 			}
-			// The current block is the higher priority parent for the source if its
-			// compaction level is higher than that of the previously set parent.
-			// If compaction levels are equal, the more recent ULID wins.
-			//
-			// The ULID recency alone is not sufficient since races, e.g. induced
-			// by downtime of garbage collection, may re-compact blocks that are
-			// were already compacted into higher-level blocks multiple times.
-			level, plevel := meta.Compaction.Level, pmeta.Compaction.Level
-
-			if level > plevel || (level == plevel && id.Compare(pid) > 0) {
-				parents[sid] = id
+			if s[j] == '"' {
+				end = j
+				break
 			}
 		}
+		if end < 0 {
+			return nil, errors.Errorf("unterminated value of label %q", name)
+		}
+		value, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "unquote value of label %q", name)
+		}
+		s = s[end+1:]
+		lset = append(lset, labels.Label{Name: name, Value: value})
 	}
+	sort.Sort(lset)
+	return lset, nil
+}
 
-	// A block can safely be deleted if they are not the highest priority parent for
-	// any source block.
-	topParents := map[ulid.ULID]struct{}{}
-	for _, pid := range parents {
-		topParents[pid] = struct{}{}
+// Metas returns metas of all blocks currently known to the syncer.
+func (c *Syncer) Metas() map[ulid.ULID]*metadata.Meta {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	res := make(map[ulid.ULID]*metadata.Meta, len(c.blocks))
+	for id, m := range c.blocks {
+		res[id] = m
 	}
+	return res
+}
 
-	for id, meta := range c.blocks {
-		// Skip any block that has a different resolution.
-		if meta.Thanos.Downsample.Resolution != resolution {
+// metasToCompact returns metas of all blocks currently known to the syncer, except blocks marked for no compaction
+// and outdated blocks pending garbage collection.
+func (c *Syncer) metasToCompact() map[ulid.ULID]*metadata.Meta {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	res := make(map[ulid.ULID]*metadata.Meta, len(c.blocks))
+	for id, m := range c.blocks {
+		if _, ok := c.noCompact[id]; ok {
 			continue
 		}
-		if _, ok := topParents[id]; ok {
+		if _, ok := c.pendingGarbage[id]; ok {
 			continue
 		}
-
-		ids = append(ids, id)
+		res[id] = m
 	}
-	return ids, nil
+	return res
 }
 
-func (c *Syncer) garbageCollect(ctx context.Context, resolution int64) error {
-	garbageIds, err := c.GarbageBlocks(resolution)
-	if err != nil {
-		return err
-	}
-
+// markNoCompact excludes the block from compaction, once it was marked for no compaction in the bucket.
+func (c *Syncer) markNoCompact(id ulid.ULID) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.noCompact[id] = struct{}{}
+}
+
+// NoCompactBlocks returns IDs of all blocks known to the syncer which are marked for no compaction, sorted by ID.
+func (c *Syncer) NoCompactBlocks() []ulid.ULID {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	res := make([]ulid.ULID, 0, len(c.noCompact))
+	for id := range c.noCompact {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Compare(res[j]) < 0
+	})
+	return res
+}
+
+// HaltedGroup is a group, which is not compacted anymore due to a critical error.
+type HaltedGroup struct {
+	HaltDetails
+	Error string    `json:"error"`
+	Since time.Time `json:"since"`
+	// Halts is the number of consecutive halts of the group without a successful compaction in between.
+	Halts int `json:"halts"`
+}
+
+// GroupHaltOptions configure halts of groups of a Syncer.
+type GroupHaltOptions struct {
+	// Path is the file halts of groups are persisted to, so halted groups stay halted across restarts. Halts are not
+	// persisted if empty.
+	Path string
+	// Backoff is the time after which the halt of a group is lifted automatically, doubled for each further
+	// consecutive halt of the group up to MaxBackoff. Halts are not lifted automatically if 0.
+	Backoff, MaxBackoff time.Duration
+}
+
+// groupHaltsRecord are halts of groups as persisted to the file of GroupHaltOptions.
+type groupHaltsRecord struct {
+	Groups []HaltedGroup  `json:"groups"`
+	Halts  map[string]int `json:"halts,omitempty"`
+}
+
+// HaltBackoff returns the time after which a halt is lifted automatically after the given number of consecutive halts,
+// doubled for each halt after the first one up to maxBackoff, if set.
+func HaltBackoff(backoff, maxBackoff time.Duration, halts int) time.Duration {
+	d := backoff
+	for i := 1; i < halts && (maxBackoff <= 0 || d < maxBackoff); i++ {
+		d *= 2
+	}
+	if maxBackoff > 0 && d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// SetGroupHaltOptions configures halts of groups and restores the halts persisted to the file of the options, if any.
+// It must be called before the first compaction.
+func (c *Syncer) SetGroupHaltOptions(opts GroupHaltOptions) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.haltOpts = opts
+	if opts.Path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(opts.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "read group halts")
+	}
+	var rec groupHaltsRecord
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return errors.Wrapf(err, "decode group halts %s", opts.Path)
+	}
+	for _, h := range rec.Groups {
+		level.Warn(c.logger).Log("msg", "group halted before restart; staying halted until unhalted", "group", h.Group,
+			"reason", h.Reason, "blocks", fmt.Sprintf("%v", h.Blocks), "err", h.Error)
+		c.halted[h.Group] = h
+		c.metrics.haltedGroups.WithLabelValues(h.Group, string(h.Reason)).Set(1)
+	}
+	for key, n := range rec.Halts {
+		c.groupHalts[key] = n
+	}
+	return nil
+}
+
+// persistGroupHalts writes the halts of groups to the file of the halt options, if set. It must be called with the
+// mutex held.
+func (c *Syncer) persistGroupHalts() {
+	if c.haltOpts.Path == "" {
+		return
+	}
+	rec := groupHaltsRecord{Groups: make([]HaltedGroup, 0, len(c.halted)), Halts: c.groupHalts}
+	for _, h := range c.halted {
+		rec.Groups = append(rec.Groups, h)
+	}
+	sort.Slice(rec.Groups, func(i, j int) bool {
+		return rec.Groups[i].Group < rec.Groups[j].Group
+	})
+	if err := writeGroupHalts(c.haltOpts.Path, rec); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to persist group halts", "path", c.haltOpts.Path, "err", err)
+	}
+}
+
+func writeGroupHalts(path string, rec groupHaltsRecord) error {
+	b, err := json.MarshalIndent(rec, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "encode group halts")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return errors.Wrap(err, "create dir")
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write group halts")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "rename group halts")
+}
+
+// RetryAt returns the time the halt of the group is lifted automatically with the given options, zero if disabled.
+func (h HaltedGroup) RetryAt(opts GroupHaltOptions) time.Time {
+	if opts.Backoff <= 0 {
+		return time.Time{}
+	}
+	return h.Since.Add(HaltBackoff(opts.Backoff, opts.MaxBackoff, h.Halts))
+}
+
+// HaltedGroups returns all groups halted due to critical errors, sorted by group key.
+func (c *Syncer) HaltedGroups() []HaltedGroup {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	res := make([]HaltedGroup, 0, len(c.halted))
+	for _, h := range c.halted {
+		res = append(res, h)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Group < res[j].Group
+	})
+	return res
+}
+
+// haltGroup excludes the group from compaction due to the critical error.
+func (c *Syncer) haltGroup(key string, err error) {
+	details, ok := HaltErrorDetails(err)
+	if !ok {
+		details = HaltDetails{Reason: HaltReasonUnknown}
+	}
+	details.Group = key
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if h, ok := c.halted[key]; ok {
+		c.metrics.haltedGroups.DeleteLabelValues(key, string(h.Reason))
+	}
+	c.groupHalts[key]++
+	h := HaltedGroup{HaltDetails: details, Error: err.Error(), Since: c.now(), Halts: c.groupHalts[key]}
+	c.halted[key] = h
+	c.metrics.haltedGroups.WithLabelValues(key, string(details.Reason)).Set(1)
+	c.persistGroupHalts()
+	if at := h.RetryAt(c.haltOpts); !at.IsZero() {
+		level.Info(c.logger).Log("msg", "halt of the group is lifted automatically after backoff", "group", key, "retryAt", at)
+	}
+}
+
+// groupCompacted resets the backoff of halts of the group after a successful compaction.
+func (c *Syncer) groupCompacted(key string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.groupHalts[key]; !ok {
+		return
+	}
+	delete(c.groupHalts, key)
+	c.persistGroupHalts()
+}
+
+// UnhaltGroups lifts the halts of all groups, e.g. once their critical errors were investigated, and returns the
+// number of groups unhalted. Groups still having critical errors are halted again by their next compaction. The
+// backoff of the groups is reset.
+func (c *Syncer) UnhaltGroups() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	n := len(c.halted)
+	for key, h := range c.halted {
+		level.Info(c.logger).Log("msg", "lifting the halt of the group", "group", key, "reason", h.Reason)
+		c.metrics.haltedGroups.DeleteLabelValues(key, string(h.Reason))
+		delete(c.halted, key)
+		delete(c.groupHalts, key)
+	}
+	c.persistGroupHalts()
+	return n
+}
+
+// isHalted returns true if the group is halted. The halt is lifted once none of the blocks causing it are in
+// the group anymore, e.g. after they were removed or repaired, or once the backoff passed, if set.
+func (c *Syncer) isHalted(g *Group) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	h, ok := c.halted[g.Key()]
+	if !ok {
+		return false
+	}
+	if at := h.RetryAt(c.haltOpts); !at.IsZero() && !c.now().Before(at) {
+		level.Info(c.logger).Log("msg", "backoff passed; lifting the halt of the group", "group", h.Group, "halts", h.Halts)
+		delete(c.halted, h.Group)
+		c.metrics.haltedGroups.DeleteLabelValues(h.Group, string(h.Reason))
+		c.persistGroupHalts()
+		return false
+	}
+	if len(h.Blocks) == 0 {
+		return true
+	}
+	ids := map[ulid.ULID]struct{}{}
+	for _, id := range g.IDs() {
+		ids[id] = struct{}{}
+	}
+	for _, id := range h.Blocks {
+		if _, ok := ids[id]; ok {
+			return true
+		}
+	}
+	level.Info(c.logger).Log("msg", "blocks causing the halt of the group are gone, lifting the halt", "group", h.Group)
+	delete(c.halted, h.Group)
+	delete(c.groupHalts, h.Group)
+	c.metrics.haltedGroups.DeleteLabelValues(h.Group, string(h.Reason))
+	c.persistGroupHalts()
+	return false
+}
+
+// GarbageCollect deletes blocks from the bucket if their data is available as part of a
+// block with a higher compaction level.
+// Blocks failed to be deleted do not abort the garbage collection, they are logged and deleted by the next garbage
+// collection. Until then they are excluded from compaction, like blocks left for the next garbage collection once the
+// limit of deletions is reached.
+func (c *Syncer) GarbageCollect(ctx context.Context) (err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	begin := time.Now()
+	c.pendingGarbage = map[ulid.ULID]struct{}{}
+
+	// Run a separate round of garbage collections for each valid resolution.
+	var deletions, failures int
+	defer func() {
+		c.metrics.pendingGarbageBlocks.Set(float64(len(c.pendingGarbage)))
+
+		gc := &GarbageCollection{
+			Time:            begin,
+			DurationSeconds: time.Since(begin).Seconds(),
+			Deleted:         deletions - failures,
+			Failed:          failures,
+			Pending:         len(c.pendingGarbage),
+		}
+		if err != nil {
+			gc.Error = err.Error()
+		}
+		c.lastGCMtx.Lock()
+		c.lastGC = gc
+		c.lastGCMtx.Unlock()
+	}()
+
+	for _, res := range []int64{
+		downsample.ResLevel0, downsample.ResLevel1, downsample.ResLevel2,
+	} {
+		failed, err := c.garbageCollect(ctx, res, &deletions)
+		failures += failed
+		if err != nil || failed > 0 {
+			c.metrics.garbageCollectionFailures.Inc()
+		}
+		c.metrics.garbageCollections.Inc()
+		c.metrics.garbageCollectionDuration.Observe(time.Since(begin).Seconds())
+
+		if err != nil {
+			return errors.Wrapf(err, "garbage collect resolution %d", res)
+		}
+		if failed > 0 {
+			level.Warn(c.logger).Log("msg", "failed to delete outdated blocks, retrying with the next garbage collection", "resolution", res, "failed", failed)
+		}
+	}
+	if c.gcMaxDeletions > 0 && deletions >= c.gcMaxDeletions && len(c.pendingGarbage) > 0 {
+		level.Info(c.logger).Log("msg", "limit of deletions of garbage collection reached, deleting remaining blocks with the next garbage collection",
+			"limit", c.gcMaxDeletions, "pending", len(c.pendingGarbage))
+	}
+	return nil
+}
+
+// excludeGarbage excludes all outdated blocks from compaction without deleting them, for compactions run without
+// garbage collection.
+func (c *Syncer) excludeGarbage() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.pendingGarbage = map[ulid.ULID]struct{}{}
+	for _, res := range []int64{
+		downsample.ResLevel0, downsample.ResLevel1, downsample.ResLevel2,
+	} {
+		ids, err := c.GarbageBlocks(res)
+		if err != nil {
+			return errors.Wrapf(err, "find outdated blocks of resolution %d", res)
+		}
+		for _, id := range ids {
+			c.pendingGarbage[id] = struct{}{}
+		}
+	}
+	c.metrics.pendingGarbageBlocks.Set(float64(len(c.pendingGarbage)))
+	return nil
+}
+
+// GarbageCollection is the result of a garbage collection.
+type GarbageCollection struct {
+	Time            time.Time `json:"time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	// Deleted and Failed are the numbers of outdated blocks deleted and failed to be deleted.
+	Deleted int `json:"deleted"`
+	Failed  int `json:"failed"`
+	// Pending is the number of outdated blocks left for the next garbage collection.
+	Pending int    `json:"pending"`
+	Error   string `json:"error,omitempty"`
+}
+
+// LastGarbageCollection returns the result of the last garbage collection, nil if there was none yet.
+func (c *Syncer) LastGarbageCollection() *GarbageCollection {
+	c.lastGCMtx.Lock()
+	defer c.lastGCMtx.Unlock()
+
+	return c.lastGC
+}
+
+// sourceShard is a source block of a block holding the given shard of series, the shard being empty for blocks holding
+// all series.
+type sourceShard struct {
+	id    ulid.ULID
+	shard string
+}
+
+func (c *Syncer) GarbageBlocks(resolution int64) (ids []ulid.ULID, err error) {
+	// Map each block to its highest priority parent. Initial blocks have themselves
+	// in their source section, i.e. are their own parent.
+	// Blocks of a shard of series are only parents of the source for their shard.
+	parents := map[sourceShard]ulid.ULID{}
+	// Sharded parents are the highest priority blocks of any shard for each source. They supersede unsharded blocks
+	// of lower priority, which were split into shards on compaction, once all shards of the source are present.
+	shardedParents := map[ulid.ULID]ulid.ULID{}
+	// shardIndexes are the indexes of shards present for each source by shard count. Until all shards of a
+	// compaction are uploaded, e.g. after failed uploads or crashes, its source blocks hold series of missing shards.
+	shardIndexes := map[ulid.ULID]map[uint64]map[uint64]struct{}{}
+	allShards := func(sid ulid.ULID) bool {
+		for count, indexes := range shardIndexes[sid] {
+			if uint64(len(indexes)) == count {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The block is the higher priority parent for the source if its compaction level is higher than that of the
+	// previously set parent. If compaction levels are equal, the more recent ULID wins.
+	//
+	// The ULID recency alone is not sufficient since races, e.g. induced
+	// by downtime of garbage collection, may re-compact blocks that are
+	// were already compacted into higher-level blocks multiple times.
+	higher := func(id, pid ulid.ULID) (bool, error) {
+		pmeta, ok := c.blocks[pid]
+		if !ok {
+			return false, errors.Errorf("previous parent block %s not found", pid)
+		}
+		level, plevel := c.blocks[id].Compaction.Level, pmeta.Compaction.Level
+		return level > plevel || (level == plevel && id.Compare(pid) > 0), nil
+	}
+
+	for id, meta := range c.blocks {
+
+		// Skip any block that has a different resolution.
+		if meta.Thanos.Downsample.Resolution != resolution {
+			continue
+		}
+
+		// For each source block we contain, check whether we are the highest priority parent block.
+		for _, sid := range meta.Compaction.Sources {
+			key := sourceShard{id: sid, shard: meta.Thanos.Shard.String()}
+			if pid, ok := parents[key]; !ok {
+				// No parents for the source block so far.
+				parents[key] = id
+			} else if ok, err := higher(id, pid); err != nil {
+				return nil, err
+			} else if ok {
+				parents[key] = id
+			}
+
+			if meta.Thanos.Shard == nil {
+				continue
+			}
+			if _, ok := shardIndexes[sid]; !ok {
+				shardIndexes[sid] = map[uint64]map[uint64]struct{}{}
+			}
+			s := meta.Thanos.Shard
+			if _, ok := shardIndexes[sid][s.Count]; !ok {
+				shardIndexes[sid][s.Count] = map[uint64]struct{}{}
+			}
+			shardIndexes[sid][s.Count][s.Index] = struct{}{}
+			if pid, ok := shardedParents[sid]; !ok {
+				shardedParents[sid] = id
+			} else if ok, err := higher(id, pid); err != nil {
+				return nil, err
+			} else if ok {
+				shardedParents[sid] = id
+			}
+		}
+	}
+
+	// A block can safely be deleted if they are not the highest priority parent for
+	// any source block.
+	topParents := map[ulid.ULID]struct{}{}
+	for key, pid := range parents {
+		if key.shard == "" {
+			if sid, ok := shardedParents[key.id]; ok && allShards(key.id) {
+				if ok, err := higher(sid, pid); err != nil {
+					return nil, err
+				} else if ok {
+					continue
+				}
+			}
+		}
+		topParents[pid] = struct{}{}
+	}
+
+	for id, meta := range c.blocks {
+		// Skip any block that has a different resolution.
+		if meta.Thanos.Downsample.Resolution != resolution {
+			continue
+		}
+		if _, ok := topParents[id]; ok {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// garbageCollect deletes outdated blocks of the resolution, oldest first, and returns the number of blocks failed to be
+// deleted. Deletions are counted by deletions, and no more blocks than the limit of deletions are deleted.
+func (c *Syncer) garbageCollect(ctx context.Context, resolution int64, deletions *int) (failed int, err error) {
+	garbageIds, err := c.GarbageBlocks(resolution)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(garbageIds, func(i, j int) bool {
+		return garbageIds[i].Compare(garbageIds[j]) < 0
+	})
+
 	for _, id := range garbageIds {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return failed, ctx.Err()
+		}
+		if c.gcMaxDeletions > 0 && *deletions >= c.gcMaxDeletions {
+			c.pendingGarbage[id] = struct{}{}
+			continue
 		}
+		*deletions++
 
 		// Spawn a new context so we always delete a block in full on shutdown.
 		delCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -498,7 +1407,11 @@ func (c *Syncer) garbageCollect(ctx context.Context, resolution int64) error {
 		err := block.Delete(delCtx, c.logger, c.bkt, id)
 		cancel()
 		if err != nil {
-			return retry(errors.Wrapf(err, "delete block %s from bucket", id))
+			level.Warn(c.logger).Log("msg", "failed to delete outdated block", "block", id, "err", err)
+			c.metrics.garbageDeletionFailures.Inc()
+			c.pendingGarbage[id] = struct{}{}
+			failed++
+			continue
 		}
 
 		// Immediately update our in-memory state so no further call to SyncMetas is needed
@@ -506,38 +1419,197 @@ func (c *Syncer) garbageCollect(ctx context.Context, resolution int64) error {
 		delete(c.blocks, id)
 		c.metrics.garbageCollectedBlocks.Inc()
 	}
-	return nil
+	return failed, nil
+}
+
+// Grouper is responsible for grouping all known blocks into compaction groups. Groups are compacted independently
+// of each other, so blocks of a group must not depend on blocks of any other group.
+type Grouper interface {
+	// Groups returns the compaction groups for all blocks currently known to the syncer.
+	// It creates all groups from the scratch on every call.
+	Groups(blocks map[ulid.ULID]*metadata.Meta) (res []*Group, err error)
 }
 
-// Group captures a set of blocks that have the same origin labels and downsampling resolution.
+// DefaultGrouper is the Thanos built-in grouper. It groups blocks by their external labels and resolution.
+type DefaultGrouper struct {
+	logger                   log.Logger
+	bkt                      objstore.Bucket
+	acceptMalformedIndex     bool
+	enableVerticalCompaction bool
+	compactions              *prometheus.CounterVec
+	verticalCompactions      *prometheus.CounterVec
+	compactionRunsStarted    *prometheus.CounterVec
+	compactionRunsCompleted  *prometheus.CounterVec
+	compactionFailures       *prometheus.CounterVec
+	garbageCollectedBlocks   prometheus.Counter
+	repairOutOfOrderChunks   bool
+}
+
+// NewDefaultGrouper makes a new DefaultGrouper. With enableVerticalCompaction overlapping blocks of a group are merged
+// into one block instead of halting the compaction. With repairOutOfOrderChunks blocks with out-of-order or duplicated
+// chunks are repaired instead of halting the compaction, see RepairOutOfOrderChunks.
+func NewDefaultGrouper(logger log.Logger, bkt objstore.Bucket, acceptMalformedIndex bool, enableVerticalCompaction bool, reg prometheus.Registerer, garbageCollectedBlocks prometheus.Counter, repairOutOfOrderChunks bool) *DefaultGrouper {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	g := &DefaultGrouper{
+		logger:                   logger,
+		bkt:                      bkt,
+		acceptMalformedIndex:     acceptMalformedIndex,
+		enableVerticalCompaction: enableVerticalCompaction,
+		compactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_compact_group_compactions_total",
+			Help: "Total number of group compaction attempts that resulted in a new block.",
+		}, []string{"group"}),
+		verticalCompactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_compact_group_vertical_compactions_total",
+			Help: "Total number of group compaction attempts that resulted in a new block based on overlapping blocks.",
+		}, []string{"group"}),
+		compactionRunsStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_compact_group_compaction_runs_started_total",
+			Help: "Total number of group compaction attempts.",
+		}, []string{"group"}),
+		compactionRunsCompleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_compact_group_compaction_runs_completed_total",
+			Help: "Total number of group completed compaction runs. This also includes compactor group runs that resulted with no compaction.",
+		}, []string{"group"}),
+		compactionFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_compact_group_compactions_failures_total",
+			Help: "Total number of failed group compactions.",
+		}, []string{"group"}),
+		garbageCollectedBlocks: garbageCollectedBlocks,
+		repairOutOfOrderChunks: repairOutOfOrderChunks,
+	}
+	if reg != nil {
+		reg.MustRegister(
+			g.compactions,
+			g.verticalCompactions,
+			g.compactionRunsStarted,
+			g.compactionRunsCompleted,
+			g.compactionFailures,
+		)
+	}
+	return g
+}
+
+// Groups returns the compaction groups for the given blocks, sorted by group key.
+func (g *DefaultGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) (res []*Group, err error) {
+	groups := map[string]*Group{}
+	for _, m := range blocks {
+		groupKey := GroupKey(m.Thanos)
+		group, ok := groups[groupKey]
+		if !ok {
+			group, err = NewGroup(
+				log.With(g.logger, "compactionGroup", groupKey),
+				g.bkt,
+				labels.FromMap(m.Thanos.Labels),
+				m.Thanos.Downsample.Resolution,
+				m.Thanos.Shard,
+				g.acceptMalformedIndex,
+				g.enableVerticalCompaction,
+				g.compactions.WithLabelValues(groupKey),
+				g.verticalCompactions.WithLabelValues(groupKey),
+				g.compactionRunsStarted.WithLabelValues(groupKey),
+				g.compactionRunsCompleted.WithLabelValues(groupKey),
+				g.compactionFailures.WithLabelValues(groupKey),
+				g.garbageCollectedBlocks,
+				g.repairOutOfOrderChunks,
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "create compaction group")
+			}
+			groups[groupKey] = group
+			res = append(res, group)
+		}
+		if err := group.Add(m); err != nil {
+			return nil, errors.Wrap(err, "add compaction group")
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key() < res[j].Key()
+	})
+	return res, nil
+}
+
+// GroupCounters are the counters of compactions of groups created by a DefaultGrouper, by group key.
+type GroupCounters struct {
+	RunsStarted *prometheus.CounterVec
+	Compactions *prometheus.CounterVec
+	Failures    *prometheus.CounterVec
+}
+
+// Counters returns the counters of compactions of groups created by the grouper.
+func (g *DefaultGrouper) Counters() GroupCounters {
+	return GroupCounters{RunsStarted: g.compactionRunsStarted, Compactions: g.compactions, Failures: g.compactionFailures}
+}
+
+// Group captures a set of blocks that have the same origin labels, downsampling resolution and shard.
 // Those blocks generally contain the same series and can thus efficiently be compacted.
 type Group struct {
 	logger                      log.Logger
 	bkt                         objstore.Bucket
 	labels                      labels.Labels
 	resolution                  int64
+	shard                       *metadata.ThanosShard
 	mtx                         sync.Mutex
 	blocks                      map[ulid.ULID]*metadata.Meta
 	acceptMalformedIndex        bool
+	enableVerticalCompaction    bool
+	repairOutOfOrderChunks      bool
 	compactions                 prometheus.Counter
+	verticalCompactions         prometheus.Counter
 	compactionRunsStarted       prometheus.Counter
 	compactionRunsCompleted     prometheus.Counter
 	compactionFailures          prometheus.Counter
 	groupGarbageCollectedBlocks prometheus.Counter
+	// slots limit the compactions of downloaded blocks running at the same time with other groups, if set.
+	slots compactionSlots
+}
+
+// compactionSlots limits the number of TSDB compactions of downloaded blocks running at the same time. Downloads of
+// blocks and uploads of compacted blocks of other groups are not limited, so they run while groups are compacted.
+// Nil means no limit.
+type compactionSlots chan struct{}
+
+func newCompactionSlots(n int) compactionSlots {
+	return make(compactionSlots, n)
+}
+
+func (s compactionSlots) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s compactionSlots) release() {
+	if s == nil {
+		return
+	}
+	<-s
 }
 
-// newGroup returns a new compaction group.
-func newGroup(
+// NewGroup returns a new compaction group. The shard is nil for groups of blocks holding all series of their labels.
+func NewGroup(
 	logger log.Logger,
 	bkt objstore.Bucket,
 	lset labels.Labels,
 	resolution int64,
+	shard *metadata.ThanosShard,
 	acceptMalformedIndex bool,
+	enableVerticalCompaction bool,
 	compactions prometheus.Counter,
+	verticalCompactions prometheus.Counter,
 	compactionRunsStarted prometheus.Counter,
 	compactionRunsCompleted prometheus.Counter,
 	compactionFailures prometheus.Counter,
 	groupGarbageCollectedBlocks prometheus.Counter,
+	repairOutOfOrderChunks bool,
 ) (*Group, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -547,20 +1619,24 @@ func newGroup(
 		bkt:                         bkt,
 		labels:                      lset,
 		resolution:                  resolution,
+		shard:                       shard,
 		blocks:                      map[ulid.ULID]*metadata.Meta{},
 		acceptMalformedIndex:        acceptMalformedIndex,
+		enableVerticalCompaction:    enableVerticalCompaction,
 		compactions:                 compactions,
+		verticalCompactions:         verticalCompactions,
 		compactionRunsStarted:       compactionRunsStarted,
 		compactionRunsCompleted:     compactionRunsCompleted,
 		compactionFailures:          compactionFailures,
 		groupGarbageCollectedBlocks: groupGarbageCollectedBlocks,
+		repairOutOfOrderChunks:      repairOutOfOrderChunks,
 	}
 	return g, nil
 }
 
 // Key returns an identifier for the group.
 func (cg *Group) Key() string {
-	return groupKey(cg.resolution, cg.labels)
+	return groupKey(cg.resolution, cg.labels, cg.shard)
 }
 
 // Add the block with the given meta to the group.
@@ -574,6 +1650,9 @@ func (cg *Group) Add(meta *metadata.Meta) error {
 	if cg.resolution != meta.Thanos.Downsample.Resolution {
 		return errors.New("block and group resolution do not match")
 	}
+	if cg.shard.String() != meta.Thanos.Shard.String() {
+		return errors.New("block and group shard do not match")
+	}
 	cg.blocks[meta.ULID] = meta
 	return nil
 }
@@ -602,83 +1681,559 @@ func (cg *Group) Resolution() int64 {
 	return cg.resolution
 }
 
+// Shard returns the common shard of blocks in the group, nil if they hold all series of their labels.
+func (cg *Group) Shard() *metadata.ThanosShard {
+	return cg.shard
+}
+
+// Backlog returns the number of blocks of the group which were not compacted yet.
+func (cg *Group) Backlog() (n int) {
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+
+	for _, m := range cg.blocks {
+		if m.Compaction.Level <= 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// oldestBacklog returns the minimum time of the oldest block of the group which was not compacted yet, false if all
+// blocks are compacted.
+func (cg *Group) oldestBacklog() (minTime int64, ok bool) {
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+
+	for _, m := range cg.blocks {
+		if m.Compaction.Level <= 1 && (!ok || m.MinTime < minTime) {
+			minTime, ok = m.MinTime, true
+		}
+	}
+	return minTime, ok
+}
+
+// estimatedBytes returns the estimated size of all blocks of the group in bytes.
+func (cg *Group) estimatedBytes() (n int64) {
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+
+	for _, m := range cg.blocks {
+		n += estimatedBlockBytes(m)
+	}
+	return n
+}
+
 // Compact plans and runs a single compaction against the group. The compacted result
 // is uploaded into the bucket the blocks were retrieved from.
-func (cg *Group) Compact(ctx context.Context, dir string, comp tsdb.Compactor) (bool, ulid.ULID, error) {
+// The group works in its own subdirectory of dir, which is removed once done. If quota is positive, the compaction
+// fails with QuotaExceededError once the subdirectory would need more than quota bytes.
+// Up to downloadConcurrency blocks of the plan are downloaded at the same time. Compacted blocks are kept within the
+// given limits, see CompactionLimits.
+// If shards is greater than 1, the compacted block of a group without shard is split into blocks of shards of its series
+// by series hash, which are uploaded instead. Each shard is a group of its own then, so compacted blocks of groups with
+// a shard are not split further. The returned ID is the ID of the first shard then.
+// If verify is true, all series, chunks and samples of the compacted block are read and checked against the stats of the
+// block and its source blocks before it is uploaded. Compaction halts if the compacted block is invalid.
+// If diskSpaceFactor is positive, the compaction fails with InsufficientDiskSpaceError before downloading the blocks of
+// the plan, if the free disk space is less than their estimated size times diskSpaceFactor.
+// The subdirectory is kept if the compaction is interrupted or fails with a RetryError, so the next compaction of the
+// group resumes with the blocks downloaded and the block compacted already.
+func (cg *Group) Compact(ctx context.Context, dir string, comp tsdb.Compactor, quota int64, downloadConcurrency int, limits CompactionLimits, shards uint64, verify bool, diskSpaceFactor float64) (shouldRerun bool, compID ulid.ULID, err error) {
 	cg.compactionRunsStarted.Inc()
 
-	subDir := filepath.Join(dir, cg.Key())
+	subDir := filepath.Join(dir, legacyGroupKey(cg.resolution, cg.labels, cg.shard))
 
 	defer func() {
+		if err != nil && (ctx.Err() != nil || IsRetryError(err)) {
+			return
+		}
 		if err := os.RemoveAll(subDir); err != nil {
 			level.Error(cg.logger).Log("msg", "failed to remove compaction group work directory", "path", subDir, "err", err)
 		}
 	}()
 
-	if err := os.RemoveAll(subDir); err != nil {
-		return false, ulid.ULID{}, errors.Wrap(err, "clean compaction group dir")
-	}
 	if err := os.MkdirAll(subDir, 0777); err != nil {
 		return false, ulid.ULID{}, errors.Wrap(err, "create compaction group dir")
 	}
 
-	shouldRerun, compID, err := cg.compact(ctx, subDir, comp)
-	if err != nil {
-		cg.compactionFailures.Inc()
-		return false, ulid.ULID{}, err
-	}
-	cg.compactionRunsCompleted.Inc()
-	return shouldRerun, compID, nil
+	shouldRerun, compID, err = cg.compact(ctx, subDir, comp, quota, downloadConcurrency, limits, shards, verify, diskSpaceFactor)
+	if err != nil {
+		cg.compactionFailures.Inc()
+		return false, ulid.ULID{}, err
+	}
+	cg.compactionRunsCompleted.Inc()
+	return shouldRerun, compID, nil
+}
+
+// Issue347Error is a type wrapper for errors that should invoke repair process for broken block.
+type Issue347Error struct {
+	err error
+
+	id ulid.ULID
+}
+
+func issue347Error(err error, brokenBlock ulid.ULID) Issue347Error {
+	return Issue347Error{err: err, id: brokenBlock}
+}
+
+func (e Issue347Error) Error() string {
+	return e.err.Error()
+}
+
+// IsIssue347Error returns true if the base error is a Issue347Error.
+func IsIssue347Error(err error) bool {
+	_, ok := errors.Cause(err).(Issue347Error)
+	return ok
+}
+
+// OutOfOrderChunksError is a type wrapper for errors of blocks with out-of-order or duplicated chunks, which are
+// repaired automatically if enabled. If the repair fails, the group is halted with the wrapped halt error.
+type OutOfOrderChunksError struct {
+	err error
+
+	id   ulid.ULID
+	halt HaltError
+}
+
+func (e OutOfOrderChunksError) Error() string {
+	return e.err.Error()
+}
+
+// IsOutOfOrderChunksError returns true if the base error is a OutOfOrderChunksError.
+func IsOutOfOrderChunksError(err error) bool {
+	_, ok := errors.Cause(err).(OutOfOrderChunksError)
+	return ok
+}
+
+// UnsupportedChunkEncodingError is a type wrapper for errors of blocks with chunks of encodings the compactor cannot
+// read, e.g. written by a newer version of the block producer. Such blocks are marked for no compaction instead of
+// halting the compactor.
+type UnsupportedChunkEncodingError struct {
+	err error
+
+	id ulid.ULID
+}
+
+func (e UnsupportedChunkEncodingError) Error() string {
+	return e.err.Error()
+}
+
+// IsUnsupportedChunkEncodingError returns true if the base error is a UnsupportedChunkEncodingError.
+func IsUnsupportedChunkEncodingError(err error) bool {
+	_, ok := errors.Cause(err).(UnsupportedChunkEncodingError)
+	return ok
+}
+
+// NoCompactMarkedError is a type wrapper for errors of planned blocks marked for no compaction after they were synced.
+// Such blocks are excluded from compaction and the group is planned again.
+type NoCompactMarkedError struct {
+	err error
+
+	id ulid.ULID
+}
+
+func (e NoCompactMarkedError) Error() string {
+	return e.err.Error()
+}
+
+// IsNoCompactMarkedError returns true if the base error is a NoCompactMarkedError.
+func IsNoCompactMarkedError(err error) bool {
+	_, ok := errors.Cause(err).(NoCompactMarkedError)
+	return ok
+}
+
+// SupportedChunkEncodings returns encodings of chunks the compactor reads in blocks of the given resolution.
+func SupportedChunkEncodings(resolution int64) []chunkenc.Encoding {
+	if resolution == downsample.ResLevel0 {
+		return []chunkenc.Encoding{chunkenc.EncXOR}
+	}
+	return []chunkenc.Encoding{chunkenc.EncXOR, downsample.ChunkEncAggr}
+}
+
+// CheckChunkEncodings returns UnsupportedChunkEncodingError if the block in the given directory has chunks of
+// encodings not supported for its resolution.
+func CheckChunkEncodings(dir string, meta *metadata.Meta) error {
+	encs, err := block.UnsupportedChunkEncodings(dir, SupportedChunkEncodings(meta.Thanos.Downsample.Resolution)...)
+	if err != nil {
+		return errors.Wrapf(err, "gather chunk encodings of block %s", meta.ULID)
+	}
+	if len(encs) == 0 {
+		return nil
+	}
+	s := make([]string, 0, len(encs))
+	for _, e := range encs {
+		s = append(s, strconv.Itoa(int(e)))
+	}
+	return UnsupportedChunkEncodingError{
+		err: errors.Errorf("block %s of resolution %d has chunks of unsupported encodings %s", meta.ULID, meta.Thanos.Downsample.Resolution, strings.Join(s, ", ")),
+		id:  meta.ULID,
+	}
+}
+
+// MarkUnsupportedChunkEncoding marks the block of the UnsupportedChunkEncodingError for no compaction and returns its
+// ID.
+func MarkUnsupportedChunkEncoding(ctx context.Context, logger log.Logger, bkt objstore.Bucket, encErr error) (ulid.ULID, error) {
+	e, ok := errors.Cause(encErr).(UnsupportedChunkEncodingError)
+	if !ok {
+		return ulid.ULID{}, errors.Errorf("Given error is not an unsupported chunk encoding error: %v", encErr)
+	}
+	if err := block.MarkForNoCompact(ctx, logger, bkt, e.id, metadata.UnsupportedChunkEncodingNoCompactReason, e.Error()); err != nil {
+		return ulid.ULID{}, retry(errors.Wrapf(err, "mark block %s for no compaction", e.id))
+	}
+	return e.id, nil
+}
+
+// HaltReason is the class of a critical error halting the compactor.
+type HaltReason string
+
+const (
+	// HaltReasonOverlap means blocks of a group or sources of planned blocks overlap.
+	HaltReasonOverlap HaltReason = "overlap"
+	// HaltReasonMixedGroups means a block of another group was planned for compaction.
+	HaltReasonMixedGroups HaltReason = "mixed_groups"
+	// HaltReasonUnhealthyIndex means a block to compact has a critical index issue.
+	HaltReasonUnhealthyIndex HaltReason = "unhealthy_index"
+	// HaltReasonCompaction means TSDB compaction of the planned blocks failed.
+	HaltReasonCompaction HaltReason = "compaction"
+	// HaltReasonInvalidResult means the compacted block has an invalid index.
+	HaltReasonInvalidResult HaltReason = "invalid_result"
+	// HaltReasonUnknown is the reason of halt errors without details.
+	HaltReasonUnknown HaltReason = "unknown"
+)
+
+// HaltDetails describe what caused a halt error, so the blocks in question can be investigated.
+type HaltDetails struct {
+	Reason HaltReason `json:"reason"`
+	// Group is the key of the group the compaction halted for.
+	Group string `json:"group,omitempty"`
+	// Blocks are IDs of blocks causing the halt error, e.g. overlapping blocks or blocks planned for compaction.
+	Blocks []ulid.ULID `json:"blocks,omitempty"`
+}
+
+// HaltError is a type wrapper for errors that should halt any further progress on compactions. Halt errors of group
+// compactions halt only the compaction of the group, see Syncer.HaltedGroups.
+type HaltError struct {
+	err     error
+	details HaltDetails
+}
+
+func halt(err error) HaltError {
+	return HaltError{err: err, details: HaltDetails{Reason: HaltReasonUnknown}}
+}
+
+// halt returns a halt error with details of the group.
+func (cg *Group) halt(reason HaltReason, blocks []ulid.ULID, err error) HaltError {
+	return HaltError{err: err, details: HaltDetails{Reason: reason, Group: cg.Key(), Blocks: blocks}}
+}
+
+func (e HaltError) Error() string {
+	return e.err.Error()
+}
+
+// Details returns details of what caused the halt error.
+func (e HaltError) Details() HaltDetails {
+	return e.details
+}
+
+// HaltErrorDetails returns details of the halt error if the base error is a HaltError.
+// If a multierror is passed, details of the first halt error are returned.
+func HaltErrorDetails(err error) (HaltDetails, bool) {
+	if multiErr, ok := err.(terrors.MultiError); ok {
+		for _, err := range multiErr {
+			if herr, ok := errors.Cause(err).(HaltError); ok {
+				return herr.details, true
+			}
+		}
+		return HaltDetails{}, false
+	}
+
+	herr, ok := errors.Cause(err).(HaltError)
+	return herr.details, ok
+}
+
+// planIDs returns IDs of blocks in the planned block directories.
+func planIDs(plan []string) []ulid.ULID {
+	ids := make([]ulid.ULID, 0, len(plan))
+	for _, pdir := range plan {
+		if id, ok := block.IsBlockDir(pdir); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// blockDownloads are downloads of the planned blocks by a pool of workers.
+type blockDownloads struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// done holds the result of the download of each planned block, once it finished.
+	done []chan error
+}
+
+// diskReservation tracks bytes of the quota of a directory reserved by blocks downloaded into it, so concurrent downloads
+// do not exceed the quota together. Blocks being downloaded reserve their estimated size, downloaded blocks their size.
+type diskReservation struct {
+	quota int64
+
+	mtx      sync.Mutex
+	reserved int64
+}
+
+// newDiskReservation returns a reservation of the quota of the directory, with its current size reserved already.
+// Non positive quota means no limit.
+func newDiskReservation(dir string, quota int64) (*diskReservation, error) {
+	r := &diskReservation{quota: quota}
+	if quota <= 0 {
+		return r, nil
+	}
+	size, err := dirSize(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "get size of compaction group dir")
+	}
+	r.reserved = size
+	return r, nil
+}
+
+// reserve reserves the given bytes. It returns QuotaExceededError if they do not fit into the quota.
+func (r *diskReservation) reserve(n int64) error {
+	if r.quota <= 0 {
+		return nil
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.reserved+n > r.quota {
+		return quotaExceeded(errors.Errorf("compaction group dir needs %d bytes, which exceeds quota of %d bytes", r.reserved+n, r.quota))
+	}
+	r.reserved += n
+	return nil
+}
+
+// adjust replaces reserved bytes by the given actual bytes, e.g. the estimated size of a block by the size downloaded.
+func (r *diskReservation) adjust(reserved, actual int64) {
+	if r.quota <= 0 {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.reserved += actual - reserved
+}
+
+// downloadBlocks starts downloading the blocks of the plan in its order, with up to concurrency blocks downloaded at
+// the same time. Each download reserves the estimated size of its block first and fails with QuotaExceededError if it
+// does not fit, so the quota is not exceeded by concurrent downloads. The downloads must be stopped once they are not
+// needed anymore.
+func downloadBlocks(ctx context.Context, logger log.Logger, bkt objstore.Bucket, metas []*metadata.Meta, plan []string, concurrency int, reservation *diskReservation) *blockDownloads {
+	ctx, cancel := context.WithCancel(ctx)
+	d := &blockDownloads{cancel: cancel, done: make([]chan error, len(plan))}
+	for i := range d.done {
+		d.done[i] = make(chan error, 1)
+	}
+
+	next := make(chan int)
+	go func() {
+		defer close(next)
+		for i := range plan {
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	for w := 0; w < concurrency; w++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for i := range next {
+				if isDownloaded(plan[i]) {
+					level.Debug(logger).Log("msg", "block downloaded already", "block", metas[i].ULID)
+					d.done[i] <- nil
+					continue
+				}
+				d.done[i] <- downloadReserved(ctx, logger, bkt, metas[i], plan[i], reservation)
+			}
+		}()
+	}
+	return d
+}
+
+// downloadReserved downloads the block into the directory after reserving its estimated size.
+func downloadReserved(ctx context.Context, logger log.Logger, bkt objstore.Bucket, meta *metadata.Meta, bdir string, reservation *diskReservation) error {
+	estimated := estimatedBlockBytes(meta)
+	if err := reservation.reserve(estimated); err != nil {
+		return err
+	}
+	if err := block.Download(ctx, logger, bkt, meta.ULID, bdir); err != nil {
+		return err
+	}
+	if reservation.quota > 0 {
+		size, err := dirSize(bdir)
+		if err != nil {
+			return errors.Wrap(err, "get size of downloaded block")
+		}
+		reservation.adjust(estimated, size)
+	}
+	return markDownloaded(bdir)
+}
+
+// wait waits for the download of the i-th planned block.
+func (d *blockDownloads) wait(ctx context.Context, i int) error {
+	select {
+	case err := <-d.done[i]:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stop cancels the remaining downloads and waits for the workers to exit.
+func (d *blockDownloads) stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// IsHaltError returns true if the base error is a HaltError.
+// If a multierror is passed, any halt error will return true.
+func IsHaltError(err error) bool {
+	if multiErr, ok := err.(terrors.MultiError); ok {
+		for _, err := range multiErr {
+			if _, ok := errors.Cause(err).(HaltError); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	_, ok := errors.Cause(err).(HaltError)
+	return ok
 }
 
-// Issue347Error is a type wrapper for errors that should invoke repair process for broken block.
-type Issue347Error struct {
+// QuotaExceededError is a type wrapper for errors of group compactions which need more disk space than the quota of
+// their work directory. Only the compaction of the group is skipped, other groups are compacted.
+type QuotaExceededError struct {
 	err error
-
-	id ulid.ULID
 }
 
-func issue347Error(err error, brokenBlock ulid.ULID) Issue347Error {
-	return Issue347Error{err: err, id: brokenBlock}
+func quotaExceeded(err error) QuotaExceededError {
+	return QuotaExceededError{err: err}
 }
 
-func (e Issue347Error) Error() string {
+func (e QuotaExceededError) Error() string {
 	return e.err.Error()
 }
 
-// IsIssue347Error returns true if the base error is a Issue347Error.
-func IsIssue347Error(err error) bool {
-	_, ok := errors.Cause(err).(Issue347Error)
+// IsQuotaExceededError returns true if the base error is a QuotaExceededError.
+func IsQuotaExceededError(err error) bool {
+	_, ok := errors.Cause(err).(QuotaExceededError)
 	return ok
 }
 
-// HaltError is a type wrapper for errors that should halt any further progress on compactions.
-type HaltError struct {
+// checkDirQuota returns QuotaExceededError if the directory with additional bytes would exceed the quota.
+// Non positive quota means no limit.
+func checkDirQuota(dir string, additional, quota int64) error {
+	if quota <= 0 {
+		return nil
+	}
+	size, err := dirSize(dir)
+	if err != nil {
+		return errors.Wrap(err, "get size of compaction group dir")
+	}
+	if size+additional > quota {
+		return quotaExceeded(errors.Errorf("compaction group dir needs %d bytes, which exceeds quota of %d bytes", size+additional, quota))
+	}
+	return nil
+}
+
+// checkPlanQuota returns QuotaExceededError if the directory would exceed the quota once the blocks of the plan are
+// downloaded and compacted, based on the sizes of the blocks estimated from their metas. Blocks downloaded already,
+// e.g. by an interrupted compaction, count with their size in the directory. The compacted block is estimated to be
+// as large as the blocks of the plan. Non positive quota means no limit.
+func checkPlanQuota(dir string, metas []*metadata.Meta, plan []string, quota int64) error {
+	if quota <= 0 {
+		return nil
+	}
+	var missing, total int64
+	for i, m := range metas {
+		size := estimatedBlockBytes(m)
+		total += size
+		if !isDownloaded(plan[i]) {
+			missing += size
+		}
+	}
+	return checkDirQuota(dir, missing+total, quota)
+}
+
+// InsufficientDiskSpaceError is a type wrapper for errors of group compactions which need more disk space than is free
+// on the filesystem of their work directory. Only the compaction of the group is skipped, other groups are compacted.
+type InsufficientDiskSpaceError struct {
 	err error
 }
 
-func halt(err error) HaltError {
-	return HaltError{err: err}
+func insufficientDiskSpace(err error) InsufficientDiskSpaceError {
+	return InsufficientDiskSpaceError{err: err}
 }
 
-func (e HaltError) Error() string {
+func (e InsufficientDiskSpaceError) Error() string {
 	return e.err.Error()
 }
 
-// IsHaltError returns true if the base error is a HaltError.
-// If a multierror is passed, any halt error will return true.
-func IsHaltError(err error) bool {
-	if multiErr, ok := err.(terrors.MultiError); ok {
-		for _, err := range multiErr {
-			if _, ok := errors.Cause(err).(HaltError); ok {
-				return true
-			}
-		}
-		return false
+// IsInsufficientDiskSpaceError returns true if the base error is an InsufficientDiskSpaceError.
+func IsInsufficientDiskSpaceError(err error) bool {
+	_, ok := errors.Cause(err).(InsufficientDiskSpaceError)
+	return ok
+}
+
+// checkFreeDiskSpace returns InsufficientDiskSpaceError if the filesystem of the directory has less free space than
+// the estimated size of the blocks times safetyFactor, minus the bytes already in the directory, e.g. blocks downloaded
+// by an interrupted compaction. Non positive safetyFactor disables the check, as do filesystems whose free space cannot
+// be determined.
+func checkFreeDiskSpace(dir string, metas []*metadata.Meta, safetyFactor float64) error {
+	if safetyFactor <= 0 {
+		return nil
+	}
+	free, ok, err := freeDiskSpace(dir)
+	if err != nil {
+		return errors.Wrap(err, "get free disk space of compaction group dir")
+	}
+	if !ok {
+		return nil
 	}
 
-	_, ok := errors.Cause(err).(HaltError)
-	return ok
+	var blocksSize int64
+	for _, m := range metas {
+		blocksSize += estimatedBlockBytes(m)
+	}
+	size, err := dirSize(dir)
+	if err != nil {
+		return errors.Wrap(err, "get size of compaction group dir")
+	}
+	required := int64(float64(blocksSize)*safetyFactor) - size
+	if required > free {
+		return insufficientDiskSpace(errors.Errorf("compaction needs an estimated %d bytes of disk space, but only %d bytes are free", required, free))
+	}
+	return nil
+}
+
+// dirSize returns the total size of regular files in the directory.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
 }
 
 // RetryError is a type wrapper for errors that should trigger warning log and retry whole compaction loop, but aborting
@@ -714,7 +2269,9 @@ func IsRetryError(err error) bool {
 	return ok
 }
 
-func (cg *Group) areBlocksOverlapping(include *metadata.Meta, excludeDirs ...string) error {
+// areBlocksOverlapping returns error and IDs of overlapping blocks if any blocks of the group, including the given
+// block and excluding blocks of given directories, overlap.
+func (cg *Group) areBlocksOverlapping(include *metadata.Meta, excludeDirs ...string) ([]ulid.ULID, error) {
 	var (
 		metas   []tsdb.BlockMeta
 		exclude = map[ulid.ULID]struct{}{}
@@ -723,7 +2280,7 @@ func (cg *Group) areBlocksOverlapping(include *metadata.Meta, excludeDirs ...str
 	for _, e := range excludeDirs {
 		id, err := ulid.Parse(filepath.Base(e))
 		if err != nil {
-			return errors.Wrapf(err, "overlaps find dir %s", e)
+			return nil, errors.Wrapf(err, "overlaps find dir %s", e)
 		}
 		exclude[id] = struct{}{}
 	}
@@ -743,9 +2300,56 @@ func (cg *Group) areBlocksOverlapping(include *metadata.Meta, excludeDirs ...str
 		return metas[i].MinTime < metas[j].MinTime
 	})
 	if overlaps := tsdb.OverlappingBlocks(metas); len(overlaps) > 0 {
-		return errors.Errorf("overlaps found while gathering blocks. %s", overlaps)
+		seen := map[ulid.ULID]struct{}{}
+		var ids []ulid.ULID
+		for _, o := range overlaps {
+			for _, m := range o {
+				if _, ok := seen[m.ULID]; ok {
+					continue
+				}
+				seen[m.ULID] = struct{}{}
+				ids = append(ids, m.ULID)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i].Compare(ids[j]) < 0
+		})
+		return ids, errors.Errorf("overlaps found while gathering blocks. %s", overlaps)
 	}
-	return nil
+	return nil, nil
+}
+
+// isOverlappingOthers returns error and IDs of overlapping blocks if the given block overlaps any block of the group,
+// excluding blocks of given directories. Unlike areBlocksOverlapping it ignores overlaps between the other blocks, which
+// are compacted vertically later.
+func (cg *Group) isOverlappingOthers(m *metadata.Meta, excludeDirs ...string) ([]ulid.ULID, error) {
+	exclude := map[ulid.ULID]struct{}{}
+	for _, e := range excludeDirs {
+		id, err := ulid.Parse(filepath.Base(e))
+		if err != nil {
+			return nil, errors.Wrapf(err, "overlaps find dir %s", e)
+		}
+		exclude[id] = struct{}{}
+	}
+
+	var ids []ulid.ULID
+	for _, o := range cg.blocks {
+		if _, ok := exclude[o.ULID]; ok {
+			continue
+		}
+		// Block time ranges are half-open.
+		if o.MinTime < m.MaxTime && m.MinTime < o.MaxTime {
+			ids = append(ids, o.ULID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Compare(ids[j]) < 0
+	})
+	ids = append(ids, m.ULID)
+	return ids, errors.Errorf("block %s overlaps with blocks %v", m.ULID, ids[:len(ids)-1])
 }
 
 // RepairIssue347 repairs the https://github.com/prometheus/tsdb/issues/347 issue when having issue347Error.
@@ -757,7 +2361,35 @@ func RepairIssue347(ctx context.Context, logger log.Logger, bkt objstore.Bucket,
 
 	level.Info(logger).Log("msg", "Repairing block broken by https://github.com/prometheus/tsdb/issues/347", "id", ie.id, "err", issue347Err)
 
-	tmpdir, err := ioutil.TempDir("", fmt.Sprintf("repair-issue-347-id-%s-", ie.id))
+	return repairBlock(ctx, logger, bkt, ie.id, "repair-issue-347", func(dir string) (ulid.ULID, error) {
+		return block.Repair(logger, dir, ie.id, metadata.CompactorRepairSource, block.IgnoreIssue347OutsideChunk)
+	})
+}
+
+// RepairOutOfOrderChunks repairs the block of the OutOfOrderChunksError by rewriting it with its chunks sorted,
+// dropping exact duplicates of chunks and chunks outside of the block time range. Blocks with overlapping chunks of
+// different data cannot be repaired.
+func RepairOutOfOrderChunks(ctx context.Context, logger log.Logger, bkt objstore.Bucket, outOfOrderErr error) error {
+	oe, ok := errors.Cause(outOfOrderErr).(OutOfOrderChunksError)
+	if !ok {
+		return errors.Errorf("Given error is not an out-of-order chunks error: %v", outOfOrderErr)
+	}
+
+	level.Info(logger).Log("msg", "repairing block with out-of-order chunks", "id", oe.id, "err", outOfOrderErr)
+
+	return repairBlock(ctx, logger, bkt, oe.id, "repair-out-of-order", func(dir string) (ulid.ULID, error) {
+		return block.Repair(logger, dir, oe.id, metadata.CompactorRepairSource,
+			block.IgnoreCompleteOutsideChunk,
+			block.IgnoreDuplicateOutsideChunk,
+			block.IgnoreIssue347OutsideChunk,
+		)
+	})
+}
+
+// repairBlock downloads the block to a temporary directory, repairs it with the given function returning the ID of the
+// repaired block, and replaces the block in the bucket with the repaired one once it is verified.
+func repairBlock(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, tmpPrefix string, repair func(dir string) (ulid.ULID, error)) error {
+	tmpdir, err := ioutil.TempDir("", fmt.Sprintf("%s-id-%s-", tmpPrefix, id))
 	if err != nil {
 		return err
 	}
@@ -768,9 +2400,9 @@ func RepairIssue347(ctx context.Context, logger log.Logger, bkt objstore.Bucket,
 		}
 	}()
 
-	bdir := filepath.Join(tmpdir, ie.id.String())
-	if err := block.Download(ctx, logger, bkt, ie.id, bdir); err != nil {
-		return retry(errors.Wrapf(err, "download block %s", ie.id))
+	bdir := filepath.Join(tmpdir, id.String())
+	if err := block.Download(ctx, logger, bkt, id, bdir); err != nil {
+		return retry(errors.Wrapf(err, "download block %s", id))
 	}
 
 	meta, err := metadata.Read(bdir)
@@ -778,9 +2410,9 @@ func RepairIssue347(ctx context.Context, logger log.Logger, bkt objstore.Bucket,
 		return errors.Wrapf(err, "read meta from %s", bdir)
 	}
 
-	resid, err := block.Repair(logger, tmpdir, ie.id, metadata.CompactorRepairSource, block.IgnoreIssue347OutsideChunk)
+	resid, err := repair(tmpdir)
 	if err != nil {
-		return errors.Wrapf(err, "repair failed for block %s", ie.id)
+		return errors.Wrapf(err, "repair failed for block %s", id)
 	}
 
 	// Verify repaired id before uploading it.
@@ -793,27 +2425,43 @@ func RepairIssue347(ctx context.Context, logger log.Logger, bkt objstore.Bucket,
 		return retry(errors.Wrapf(err, "upload of %s failed", resid))
 	}
 
-	level.Info(logger).Log("msg", "deleting broken block", "id", ie.id)
+	level.Info(logger).Log("msg", "deleting broken block", "id", id)
 
 	// Spawn a new context so we always delete a block in full on shutdown.
 	delCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
 	// TODO(bplotka): Issue with this will introduce overlap that will halt compactor. Automate that (fix duplicate overlaps caused by this).
-	if err := block.Delete(delCtx, logger, bkt, ie.id); err != nil {
-		return errors.Wrapf(err, "deleting old block %s failed. You need to delete this block manually", ie.id)
+	if err := block.Delete(delCtx, logger, bkt, id); err != nil {
+		return errors.Wrapf(err, "deleting old block %s failed. You need to delete this block manually", id)
 	}
 
 	return nil
 }
 
-func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (shouldRerun bool, compID ulid.ULID, err error) {
+func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor, quota int64, downloadConcurrency int, limits CompactionLimits, shards uint64, verify bool, diskSpaceFactor float64) (shouldRerun bool, compID ulid.ULID, err error) {
 	cg.mtx.Lock()
 	defer cg.mtx.Unlock()
 
-	// Check for overlapped blocks.
-	if err := cg.areBlocksOverlapping(nil); err != nil {
-		return false, ulid.ULID{}, halt(errors.Wrap(err, "pre compaction overlap check"))
+	// Check for overlapped blocks. With vertical compaction the TSDB compactor plans overlapping blocks first and
+	// merges them, deduplicating identical samples.
+	overlapping := false
+	if ids, err := cg.areBlocksOverlapping(nil); err != nil {
+		if !cg.enableVerticalCompaction {
+			return false, ulid.ULID{}, cg.halt(HaltReasonOverlap, ids, errors.Wrap(err, "pre compaction overlap check"))
+		}
+		level.Info(cg.logger).Log("msg", "found overlapping blocks, compacting them vertically", "blocks", fmt.Sprintf("%v", ids))
+		overlapping = true
+	}
+
+	// Upload the block compacted before the compaction was interrupted, if its blocks are still to be compacted.
+	if resumed, compID, err := cg.resumeCompaction(ctx, dir); resumed || err != nil {
+		return resumed, compID, err
+	}
+
+	// Blocks left from previous compactions, which are not part of the group anymore, must not be planned.
+	if err := cg.removeStaleBlockDirs(dir); err != nil {
+		return false, ulid.ULID{}, err
 	}
 
 	// Planning a compaction works purely based on the meta.json files in our future group's dir.
@@ -829,9 +2477,9 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 	}
 
 	// Plan against the written meta.json files.
-	plan, err := comp.Plan(dir)
+	plan, err := cg.planWithinLimits(dir, comp, limits)
 	if err != nil {
-		return false, ulid.ULID{}, errors.Wrap(err, "plan compaction")
+		return false, ulid.ULID{}, err
 	}
 	if len(plan) == 0 {
 		// Nothing to do.
@@ -842,22 +2490,39 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 	// This is one potential source of how we could end up with duplicated chunks.
 	uniqueSources := map[ulid.ULID]struct{}{}
 
+	// The compacted block keeps hints of the most recent block of the plan, as hints of a tenant may change over time.
+	var (
+		hints        *metadata.ThanosHints
+		hintsMaxTime int64
+	)
+	// The compacted block keeps the origin of the blocks of the plan, if all of them have the same one.
+	var origin metadata.SourceType
+
 	// Once we have a plan we need to download the actual data.
 	begin := time.Now()
 
-	for _, pdir := range plan {
+	metas := make([]*metadata.Meta, 0, len(plan))
+	for i, pdir := range plan {
 		meta, err := metadata.Read(pdir)
 		if err != nil {
 			return false, ulid.ULID{}, errors.Wrapf(err, "read meta from %s", pdir)
 		}
+		if meta.Thanos.Hints != nil && (hints == nil || meta.MaxTime > hintsMaxTime) {
+			hints, hintsMaxTime = meta.Thanos.Hints, meta.MaxTime
+		}
+		if i == 0 {
+			origin = meta.Thanos.OriginSource()
+		} else if origin != meta.Thanos.OriginSource() {
+			origin = ""
+		}
 
 		if cg.Key() != GroupKey(meta.Thanos) {
-			return false, ulid.ULID{}, halt(errors.Wrapf(err, "compact planned compaction for mixed groups. group: %s, planned block's group: %s", cg.Key(), GroupKey(meta.Thanos)))
+			return false, ulid.ULID{}, cg.halt(HaltReasonMixedGroups, []ulid.ULID{meta.ULID}, errors.Wrapf(err, "compact planned compaction for mixed groups. group: %s, planned block's group: %s", cg.Key(), GroupKey(meta.Thanos)))
 		}
 
 		for _, s := range meta.Compaction.Sources {
 			if _, ok := uniqueSources[s]; ok {
-				return false, ulid.ULID{}, halt(errors.Errorf("overlapping sources detected for plan %v", plan))
+				return false, ulid.ULID{}, cg.halt(HaltReasonOverlap, planIDs(plan), errors.Errorf("overlapping sources detected for plan %v", plan))
 			}
 			uniqueSources[s] = struct{}{}
 		}
@@ -871,7 +2536,42 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 			return false, ulid.ULID{}, errors.Errorf("mismatch between meta %s and dir %s", meta.ULID, id)
 		}
 
-		if err := block.Download(ctx, cg.logger, cg.bkt, id, pdir); err != nil {
+		// Blocks may be marked for no compaction, e.g. by thanos bucket mark, after they were synced.
+		marked, err := block.IsMarkedForNoCompact(ctx, cg.bkt, id)
+		if err != nil {
+			return false, ulid.ULID{}, retry(errors.Wrapf(err, "check no compact mark of block %s", id))
+		}
+		if marked {
+			return false, ulid.ULID{}, NoCompactMarkedError{err: errors.Errorf("planned block %s is marked for no compaction", id), id: id}
+		}
+		metas = append(metas, meta)
+	}
+
+	// Skip the group before downloading anything, instead of running out of disk space halfway through.
+	if err := checkFreeDiskSpace(dir, metas, diskSpaceFactor); err != nil {
+		return false, ulid.ULID{}, errors.Wrapf(err, "compact blocks %v", plan)
+	}
+
+	if err := checkPlanQuota(dir, metas, plan, quota); err != nil {
+		return false, ulid.ULID{}, errors.Wrapf(err, "compact blocks %v", plan)
+	}
+
+	// Blocks are downloaded concurrently and each of them is verified as soon as it is downloaded, while the rest
+	// of the plan is still being downloaded.
+	reservation, err := newDiskReservation(dir, quota)
+	if err != nil {
+		return false, ulid.ULID{}, err
+	}
+	downloaded := downloadBlocks(ctx, cg.logger, cg.bkt, metas, plan, downloadConcurrency, reservation)
+	defer downloaded.stop()
+
+	for i, pdir := range plan {
+		meta, id := metas[i], metas[i].ULID
+
+		if err := downloaded.wait(ctx, i); err != nil {
+			if IsQuotaExceededError(err) {
+				return false, ulid.ULID{}, errors.Wrapf(err, "download block %s", id)
+			}
 			return false, ulid.ULID{}, retry(errors.Wrapf(err, "download block %s", id))
 		}
 
@@ -882,7 +2582,11 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 		}
 
 		if err := stats.CriticalErr(); err != nil {
-			return false, ulid.ULID{}, halt(errors.Wrapf(err, "block with not healthy index found %s; Compaction level %v; Labels: %v", pdir, meta.Compaction.Level, meta.Thanos.Labels))
+			herr := cg.halt(HaltReasonUnhealthyIndex, []ulid.ULID{meta.ULID}, errors.Wrapf(err, "block with not healthy index found %s; Compaction level %v; Labels: %v", pdir, meta.Compaction.Level, meta.Thanos.Labels))
+			if cg.repairOutOfOrderChunks && stats.CriticalIssuesRepairable() && meta.Thanos.Downsample.Resolution == downsample.ResLevel0 {
+				return false, ulid.ULID{}, OutOfOrderChunksError{err: errors.Wrapf(err, "invalid, but possibly reparable block %s", pdir), id: meta.ULID, halt: herr}
+			}
+			return false, ulid.ULID{}, herr
 		}
 
 		if err := stats.Issue347OutsideChunksErr(); err != nil {
@@ -893,15 +2597,36 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 			return false, ulid.ULID{}, errors.Wrapf(err,
 				"block id %s, try running with --debug.accept-malformed-index", id)
 		}
+
+		if err := CheckChunkEncodings(pdir, meta); err != nil {
+			return false, ulid.ULID{}, err
+		}
 	}
+	downloaded.stop()
 	level.Debug(cg.logger).Log("msg", "downloaded and verified blocks",
 		"blocks", fmt.Sprintf("%v", plan), "duration", time.Since(begin))
 
+	// The compacted block is roughly as large as the input blocks, so make sure there is space for them twice.
+	if quota > 0 {
+		inputSize, err := dirSize(dir)
+		if err != nil {
+			return false, ulid.ULID{}, errors.Wrap(err, "get size of input blocks")
+		}
+		if err := checkDirQuota(dir, inputSize, quota); err != nil {
+			return false, ulid.ULID{}, errors.Wrapf(err, "compact blocks %v", plan)
+		}
+	}
+
+	// Only the compaction itself waits for a slot, so blocks of the next groups are downloaded while groups compact.
+	if err := cg.slots.acquire(ctx); err != nil {
+		return false, ulid.ULID{}, err
+	}
 	begin = time.Now()
 
 	compID, err = comp.Compact(dir, plan, nil)
+	cg.slots.release()
 	if err != nil {
-		return false, ulid.ULID{}, halt(errors.Wrapf(err, "compact blocks %v", plan))
+		return false, ulid.ULID{}, cg.halt(HaltReasonCompaction, planIDs(plan), errors.Wrapf(err, "compact blocks %v", plan))
 	}
 	if compID == (ulid.ULID{}) {
 		// Prometheus compactor found that the compacted block would have no samples.
@@ -922,6 +2647,9 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 		return true, ulid.ULID{}, nil
 	}
 	cg.compactions.Inc()
+	if overlapping {
+		cg.verticalCompactions.Inc()
+	}
 	level.Debug(cg.logger).Log("msg", "compacted blocks",
 		"blocks", fmt.Sprintf("%v", plan), "duration", time.Since(begin))
 
@@ -933,6 +2661,9 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 		Labels:     cg.labels.Map(),
 		Downsample: metadata.ThanosDownsample{Resolution: cg.resolution},
 		Source:     metadata.CompactorSource,
+		Origin:     origin,
+		Hints:      hints,
+		Shard:      cg.shard,
 	}, nil)
 	if err != nil {
 		return false, ulid.ULID{}, errors.Wrapf(err, "failed to finalize the block %s", bdir)
@@ -944,36 +2675,151 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 
 	// Ensure the output block is valid.
 	if err := block.VerifyIndex(cg.logger, index, newMeta.MinTime, newMeta.MaxTime); !cg.acceptMalformedIndex && err != nil {
-		return false, ulid.ULID{}, halt(errors.Wrapf(err, "invalid result block %s", bdir))
+		return false, ulid.ULID{}, cg.halt(HaltReasonInvalidResult, planIDs(plan), errors.Wrapf(err, "invalid result block %s", bdir))
+	}
+	if verify {
+		begin = time.Now()
+		var sourceSamples uint64
+		for _, meta := range metas {
+			if meta.Stats.NumSamples == 0 {
+				// Stats are unknown, e.g. for blocks written by old versions.
+				sourceSamples = 0
+				break
+			}
+			sourceSamples += meta.Stats.NumSamples
+		}
+		if err := verifyCompactedBlock(cg.logger, bdir, newMeta, sourceSamples, overlapping); err != nil {
+			return false, ulid.ULID{}, cg.halt(HaltReasonInvalidResult, planIDs(plan), errors.Wrapf(err, "verify result block %s", bdir))
+		}
+		level.Debug(cg.logger).Log("msg", "verified compacted block", "result_block", compID, "duration", time.Since(begin))
 	}
 
-	// Ensure the output block is not overlapping with anything else.
-	if err := cg.areBlocksOverlapping(newMeta, plan...); err != nil {
-		return false, ulid.ULID{}, halt(errors.Wrapf(err, "resulted compacted block %s overlaps with something", bdir))
+	// Ensure the output block is not overlapping with anything else. With vertical compaction other blocks of the group
+	// may still overlap each other, so only the output block is checked against them.
+	overlapCheck := cg.areBlocksOverlapping
+	if cg.enableVerticalCompaction {
+		overlapCheck = cg.isOverlappingOthers
+	}
+	if ids, err := overlapCheck(newMeta, plan...); err != nil {
+		return false, ulid.ULID{}, cg.halt(HaltReasonOverlap, ids, errors.Wrapf(err, "resulted compacted block %s overlaps with something", bdir))
 	}
 
 	if err := block.WriteIndexCache(cg.logger, index, indexCache); err != nil {
 		return false, ulid.ULID{}, errors.Wrap(err, "write index cache")
 	}
 
-	begin = time.Now()
+	c := checkpoint{Plan: planIDs(plan), Block: compID}
+	if shards > 1 && cg.shard == nil {
+		// The shards are roughly as large as the compacted block.
+		if quota > 0 {
+			size, err := dirSize(bdir)
+			if err != nil {
+				return false, ulid.ULID{}, errors.Wrap(err, "get size of compacted block")
+			}
+			if err := checkDirQuota(dir, size, quota); err != nil {
+				return false, ulid.ULID{}, errors.Wrapf(err, "split block %s", compID)
+			}
+		}
+		begin = time.Now()
+		metas, err := splitBlock(cg.logger, dir, newMeta, shards)
+		if err != nil {
+			return false, ulid.ULID{}, errors.Wrapf(err, "split block %s into %d shards", compID, shards)
+		}
+		if err := os.RemoveAll(bdir); err != nil {
+			return false, ulid.ULID{}, errors.Wrap(err, "remove split block")
+		}
+		c.Shards = shardIDs(metas)
+		level.Debug(cg.logger).Log("msg", "split compacted block into shards", "result_block", compID,
+			"shards", fmt.Sprintf("%v", c.Shards), "duration", time.Since(begin))
+	}
+
+	if err := writeCheckpoint(dir, c); err != nil {
+		return false, ulid.ULID{}, err
+	}
+	if err := cg.uploadCompacted(ctx, dir, c.blocks(), plan); err != nil {
+		return false, ulid.ULID{}, err
+	}
+	return true, c.blocks()[0], nil
+}
 
-	if err := block.Upload(ctx, cg.logger, cg.bkt, bdir); err != nil {
-		return false, ulid.ULID{}, retry(errors.Wrapf(err, "upload of %s failed", compID))
+// uploadCompacted uploads the compacted blocks in dir and deletes the blocks of the plan they were compacted from.
+func (cg *Group) uploadCompacted(ctx context.Context, dir string, ids []ulid.ULID, plan []string) error {
+	for _, id := range ids {
+		begin := time.Now()
+		if err := block.Upload(ctx, cg.logger, cg.bkt, filepath.Join(dir, id.String())); err != nil {
+			return retry(errors.Wrapf(err, "upload of %s failed", id))
+		}
+		level.Debug(cg.logger).Log("msg", "uploaded block", "result_block", id, "duration", time.Since(begin))
 	}
-	level.Debug(cg.logger).Log("msg", "uploaded block", "result_block", compID, "duration", time.Since(begin))
 
 	// Delete the blocks we just compacted from the group and bucket so they do not get included
 	// into the next planning cycle.
 	// Eventually the block we just uploaded should get synced into the group again (including sync-delay).
 	for _, b := range plan {
 		if err := cg.deleteBlock(b); err != nil {
-			return false, ulid.ULID{}, retry(errors.Wrapf(err, "delete old block from bucket"))
+			return retry(errors.Wrapf(err, "delete old block from bucket"))
 		}
 		cg.groupGarbageCollectedBlocks.Inc()
 	}
+	return removeCheckpoint(dir)
+}
+
+// resumeCompaction uploads the compacted block of the checkpoint in the group dir, if all blocks it was compacted from
+// are still in the group. Otherwise the checkpoint is outdated, e.g. the block was uploaded already and the blocks of
+// its plan were garbage collected, and it is removed together with the compacted block.
+func (cg *Group) resumeCompaction(ctx context.Context, dir string) (bool, ulid.ULID, error) {
+	c, err := readCheckpoint(dir)
+	if err != nil || c == nil {
+		return false, ulid.ULID{}, err
+	}
+
+	_, uploaded := cg.blocks[c.Block]
+	resumable := !uploaded && len(c.Plan) > 0
+	plan := make([]string, 0, len(c.Plan))
+	for _, id := range c.Plan {
+		if _, ok := cg.blocks[id]; !ok {
+			resumable = false
+		}
+		plan = append(plan, filepath.Join(dir, id.String()))
+	}
+
+	if !resumable {
+		level.Info(cg.logger).Log("msg", "removing outdated compaction checkpoint", "result_block", c.Block)
+		for _, id := range c.blocks() {
+			if err := os.RemoveAll(filepath.Join(dir, id.String())); err != nil {
+				return false, ulid.ULID{}, errors.Wrapf(err, "remove compacted block %s", id)
+			}
+		}
+		return false, ulid.ULID{}, removeCheckpoint(dir)
+	}
+
+	level.Info(cg.logger).Log("msg", "resuming interrupted compaction, uploading compacted block", "result_block", c.Block, "shards", fmt.Sprintf("%v", c.Shards), "blocks", fmt.Sprintf("%v", c.Plan))
+	if err := cg.uploadCompacted(ctx, dir, c.blocks(), plan); err != nil {
+		return false, ulid.ULID{}, err
+	}
+	cg.compactions.Inc()
+	return true, c.blocks()[0], nil
+}
 
-	return true, compID, nil
+// removeStaleBlockDirs removes directories of blocks, which are not part of the group anymore, from the group dir.
+func (cg *Group) removeStaleBlockDirs(dir string) error {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrap(err, "read compaction group dir")
+	}
+	for _, fi := range fis {
+		id, ok := block.IsBlockDir(fi.Name())
+		if !ok || !fi.IsDir() {
+			continue
+		}
+		if _, ok := cg.blocks[id]; ok {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, fi.Name())); err != nil {
+			return errors.Wrapf(err, "remove stale block dir %s", id)
+		}
+	}
+	return nil
 }
 
 func (cg *Group) deleteBlock(b string) error {
@@ -1000,37 +2846,238 @@ func (cg *Group) deleteBlock(b string) error {
 type BucketCompactor struct {
 	logger      log.Logger
 	sy          *Syncer
+	grouper     Grouper
 	comp        tsdb.Compactor
 	compactDir  string
 	bkt         objstore.Bucket
 	concurrency int
+	// downloadConcurrency is the number of blocks downloaded at the same time by each group compaction.
+	downloadConcurrency int
+	groupQuota          int64
+	groupOrder          GroupOrder
+	// ranges are the compaction ranges of the TSDB compactor, used to estimate the progress of compaction.
+	ranges   []int64
+	progress *progressMetrics
+	// locks are locked for each group while it is compacted, if given.
+	locks  *GroupLocks
+	limits CompactionLimits
+	// shards is the number of shards of series compacted blocks are split into, if greater than 1.
+	shards uint64
+	// verify enables verification of compacted blocks before they are uploaded.
+	verify bool
+	// diskSpaceFactor is the safety factor of the estimated disk space needed by each group compaction, if positive.
+	diskSpaceFactor float64
+	// garbageCollect enables garbage collection before each compaction pass. Otherwise outdated blocks are only
+	// excluded from compaction and left for a separate garbage collection.
+	garbageCollect bool
+	skipped        *prometheus.CounterVec
+	// outOfOrderRepairs and outOfOrderRepairFailures count repairs of blocks with out-of-order chunks.
+	outOfOrderRepairs        prometheus.Counter
+	outOfOrderRepairFailures prometheus.Counter
+
+	// slots limit the group compactions of downloaded blocks to concurrency.
+	slots compactionSlots
+
+	compactingMtx sync.Mutex
+	// compacting are the groups compacted at the moment by group key, see CompactingGroups.
+	compacting map[string]CompactingGroup
+}
+
+// CompactingGroup is a group compacted at the moment.
+type CompactingGroup struct {
+	Group string `json:"group"`
+	// Blocks is the number of blocks of the group.
+	Blocks int       `json:"blocks"`
+	Since  time.Time `json:"since"`
+}
+
+// GroupOrder is the order in which the bucket compactor compacts groups.
+type GroupOrder string
+
+const (
+	// GroupOrderBacklog compacts groups with the most blocks not compacted yet first, so the most delayed
+	// groups catch up first, e.g. after an outage of the compactor. Groups with the same backlog are
+	// compacted in order of their keys.
+	GroupOrderBacklog GroupOrder = "backlog"
+	// GroupOrderKey compacts groups in order of their keys.
+	GroupOrderKey GroupOrder = "key"
+	// GroupOrderOldest compacts groups with the oldest data not compacted yet first, so the oldest gaps of compacted
+	// data are closed first. Groups without blocks to compact follow in order of their keys.
+	GroupOrderOldest GroupOrder = "oldest"
+	// GroupOrderBlocks compacts groups with the most blocks first, regardless of their compaction level.
+	GroupOrderBlocks GroupOrder = "blocks"
+	// GroupOrderSmallest compacts groups with the smallest estimated size of their blocks first, so as many groups
+	// as possible catch up quickly.
+	GroupOrderSmallest GroupOrder = "smallest"
+)
+
+// GroupOrders are all supported group orders.
+var GroupOrders = []GroupOrder{GroupOrderBacklog, GroupOrderKey, GroupOrderOldest, GroupOrderBlocks, GroupOrderSmallest}
+
+// sortGroups sorts the groups in the given order. Groups of the same rank are sorted by their keys.
+func sortGroups(groups []*Group, order GroupOrder) error {
+	var rank func(g *Group) int64
+	switch order {
+	case GroupOrderKey:
+		rank = func(*Group) int64 { return 0 }
+	case GroupOrderBacklog:
+		rank = func(g *Group) int64 { return -int64(g.Backlog()) }
+	case GroupOrderOldest:
+		rank = func(g *Group) int64 {
+			if t, ok := g.oldestBacklog(); ok {
+				return t
+			}
+			return math.MaxInt64
+		}
+	case GroupOrderBlocks:
+		rank = func(g *Group) int64 { return -int64(len(g.IDs())) }
+	case GroupOrderSmallest:
+		rank = func(g *Group) int64 { return g.estimatedBytes() }
+	default:
+		return errors.Errorf("unknown group order %q", order)
+	}
+
+	ranks := make(map[*Group]int64, len(groups))
+	for _, g := range groups {
+		ranks[g] = rank(g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if ranks[groups[i]] != ranks[groups[j]] {
+			return ranks[groups[i]] < ranks[groups[j]]
+		}
+		return groups[i].Key() < groups[j].Key()
+	})
+	return nil
+}
+
+// BucketCompactorOptions configure a BucketCompactor.
+type BucketCompactorOptions struct {
+	// Concurrency is the number of groups compacted at the same time, while blocks of as many other groups are
+	// downloaded. It must be positive.
+	Concurrency int
+	// DownloadConcurrency is the number of blocks downloaded at the same time by each group compaction. It must be
+	// positive.
+	DownloadConcurrency int
+	// GroupQuota is the disk space in bytes each group compaction can use at most, if positive.
+	GroupQuota int64
+	// GroupOrder is the order groups are handed to the compaction workers in.
+	GroupOrder GroupOrder
+	// Ranges are the compaction ranges of the TSDB compactor, used to estimate the remaining work of each group, see
+	// Progress.
+	Ranges []int64
+	// Locks, if given, are locked for each group while it is compacted, so other work on blocks of the group, e.g.
+	// downsampling, can run concurrently with the compaction.
+	Locks *GroupLocks
+	// Limits are the limits compacted blocks are kept within.
+	Limits CompactionLimits
+	// Shards is the number of shards of series compacted blocks are split into if greater than 1, see Group.Compact.
+	Shards uint64
+	// Verify enables verification of compacted blocks before they are uploaded.
+	Verify bool
+	// DiskSpaceFactor, if positive, is the safety factor of the estimated disk space needed by each group
+	// compaction. Compaction of groups needing more disk space than is free is skipped, see Group.Compact.
+	DiskSpaceFactor float64
+	// GarbageCollect enables garbage collection before each compaction pass. Otherwise outdated blocks are only
+	// excluded from compaction and left for a separate garbage collection.
+	GarbageCollect bool
 }
 
-// NewBucketCompactor creates a new bucket compactor.
+// NewBucketCompactor creates a new bucket compactor compacting blocks of the bucket in the given directory.
 func NewBucketCompactor(
 	logger log.Logger,
 	sy *Syncer,
+	grouper Grouper,
 	comp tsdb.Compactor,
 	compactDir string,
 	bkt objstore.Bucket,
-	concurrency int,
+	reg prometheus.Registerer,
+	opts BucketCompactorOptions,
 ) (*BucketCompactor, error) {
-	if concurrency <= 0 {
-		return nil, errors.Errorf("invalid concurrency level (%d), concurrency level must be > 0", concurrency)
+	if opts.Concurrency <= 0 {
+		return nil, errors.Errorf("invalid concurrency level (%d), concurrency level must be > 0", opts.Concurrency)
+	}
+	if opts.DownloadConcurrency <= 0 {
+		return nil, errors.Errorf("invalid download concurrency level (%d), download concurrency level must be > 0", opts.DownloadConcurrency)
+	}
+	if err := sortGroups(nil, opts.GroupOrder); err != nil {
+		return nil, err
+	}
+	skipped := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_compact_group_compactions_skipped_total",
+		Help: "Total number of group compactions skipped for lack of disk space, by reason.",
+	}, []string{"group", "reason"})
+	outOfOrderRepairs := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_compact_out_of_order_repairs_total",
+		Help: "Total number of blocks with out-of-order chunks repaired instead of halting the compaction of their group.",
+	})
+	outOfOrderRepairFailures := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_compact_out_of_order_repair_failures_total",
+		Help: "Total number of failed repairs of blocks with out-of-order chunks.",
+	})
+	if reg != nil {
+		reg.MustRegister(skipped, outOfOrderRepairs, outOfOrderRepairFailures)
 	}
 	return &BucketCompactor{
-		logger:      logger,
-		sy:          sy,
-		comp:        comp,
-		compactDir:  compactDir,
-		bkt:         bkt,
-		concurrency: concurrency,
+		logger:                   logger,
+		sy:                       sy,
+		grouper:                  grouper,
+		comp:                     comp,
+		compactDir:               compactDir,
+		bkt:                      bkt,
+		concurrency:              opts.Concurrency,
+		downloadConcurrency:      opts.DownloadConcurrency,
+		groupQuota:               opts.GroupQuota,
+		groupOrder:               opts.GroupOrder,
+		ranges:                   opts.Ranges,
+		progress:                 newProgressMetrics(reg),
+		locks:                    opts.Locks,
+		limits:                   opts.Limits,
+		shards:                   opts.Shards,
+		verify:                   opts.Verify,
+		diskSpaceFactor:          opts.DiskSpaceFactor,
+		garbageCollect:           opts.GarbageCollect,
+		skipped:                  skipped,
+		outOfOrderRepairs:        outOfOrderRepairs,
+		outOfOrderRepairFailures: outOfOrderRepairFailures,
+		compacting:               map[string]CompactingGroup{},
+		slots:                    newCompactionSlots(opts.Concurrency),
 	}, nil
 }
 
+// CompactingGroups returns the groups compacted at the moment, sorted by group key.
+func (c *BucketCompactor) CompactingGroups() []CompactingGroup {
+	c.compactingMtx.Lock()
+	defer c.compactingMtx.Unlock()
+
+	res := make([]CompactingGroup, 0, len(c.compacting))
+	for _, g := range c.compacting {
+		res = append(res, g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Group < res[j].Group
+	})
+	return res
+}
+
+func (c *BucketCompactor) setCompacting(g *Group, compacting bool) {
+	c.compactingMtx.Lock()
+	defer c.compactingMtx.Unlock()
+
+	if !compacting {
+		delete(c.compacting, g.Key())
+		return
+	}
+	c.compacting[g.Key()] = CompactingGroup{Group: g.Key(), Blocks: len(g.IDs()), Since: time.Now()}
+}
+
 // Compact runs compaction over bucket.
-func (c *BucketCompactor) Compact(ctx context.Context) error {
+// Work of group compactions interrupted or failed with a RetryError is kept in the work directory and validated before
+// the next compaction, which resumes it.
+func (c *BucketCompactor) Compact(ctx context.Context) (rerr error) {
 	defer func() {
+		if rerr != nil && (ctx.Err() != nil || IsRetryError(rerr)) {
+			return
+		}
 		if err := os.RemoveAll(c.compactDir); err != nil {
 			level.Error(c.logger).Log("msg", "failed to remove compaction work directory", "path", c.compactDir, "err", err)
 		}
@@ -1042,7 +3089,7 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 			wg                     sync.WaitGroup
 			workCtx, workCtxCancel = context.WithCancel(ctx)
 			groupChan              = make(chan *Group)
-			errChan                = make(chan error, c.concurrency)
+			errChan                = make(chan error, 2*c.concurrency)
 			finishedAllGroups      = true
 			mtx                    sync.Mutex
 		)
@@ -1050,13 +3097,21 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 
 		// Set up workers who will compact the groups when the groups are ready.
 		// They will compact available groups until they encounter an error, after which they will stop.
-		for i := 0; i < c.concurrency; i++ {
+		// Up to concurrency groups are compacted at the same time, while blocks of as many other groups are downloaded
+		// and compacted blocks are uploaded, so downloads, compactions and uploads of groups run as a pipeline.
+		for i := 0; i < 2*c.concurrency; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 				for g := range groupChan {
-					shouldRerunGroup, _, err := g.Compact(workCtx, c.compactDir, c.comp)
+					g.slots = c.slots
+					unlock := c.locks.Lock(g.Key())
+					c.setCompacting(g, true)
+					shouldRerunGroup, _, err := g.Compact(workCtx, c.compactDir, c.comp, c.groupQuota, c.downloadConcurrency, c.limits, c.shards, c.verify, c.diskSpaceFactor)
+					c.setCompacting(g, false)
+					unlock()
 					if err == nil {
+						c.sy.groupCompacted(g.Key())
 						if shouldRerunGroup {
 							mtx.Lock()
 							finishedAllGroups = false
@@ -1065,6 +3120,20 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 						continue
 					}
 
+					if IsQuotaExceededError(err) {
+						// The group work directory is already removed, so the other groups can continue.
+						level.Warn(c.logger).Log("msg", "skipping compaction of group exceeding disk quota", "group", g.Key(), "err", err)
+						c.skipped.WithLabelValues(g.Key(), "disk_quota").Inc()
+						continue
+					}
+
+					if IsInsufficientDiskSpaceError(err) {
+						// Nothing was downloaded and the group work directory is already removed.
+						level.Warn(c.logger).Log("msg", "skipping compaction of group for lack of free disk space", "group", g.Key(), "err", err)
+						c.skipped.WithLabelValues(g.Key(), "disk_space").Inc()
+						continue
+					}
+
 					if IsIssue347Error(err) {
 						if err := RepairIssue347(workCtx, c.logger, c.bkt, err); err == nil {
 							mtx.Lock()
@@ -1073,14 +3142,59 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 							continue
 						}
 					}
+
+					if IsOutOfOrderChunksError(err) {
+						rerr := RepairOutOfOrderChunks(workCtx, c.logger, c.bkt, err)
+						if rerr == nil {
+							c.outOfOrderRepairs.Inc()
+							mtx.Lock()
+							finishedAllGroups = false
+							mtx.Unlock()
+							continue
+						}
+						level.Warn(c.logger).Log("msg", "failed to repair block with out-of-order chunks", "group", g.Key(), "err", rerr)
+						c.outOfOrderRepairFailures.Inc()
+						err = errors.Cause(err).(OutOfOrderChunksError).halt
+					}
+
+					if IsHaltError(err) {
+						// Only the group is halted for investigation, other groups are compacted.
+						details, _ := HaltErrorDetails(err)
+						level.Error(c.logger).Log("msg", "critical error detected; halting compaction of group", "group", g.Key(),
+							"reason", details.Reason, "blocks", fmt.Sprintf("%v", details.Blocks), "err", err)
+						c.sy.haltGroup(g.Key(), err)
+						continue
+					}
+
+					if e, ok := errors.Cause(err).(NoCompactMarkedError); ok {
+						level.Info(c.logger).Log("msg", "excluding block marked for no compaction", "group", g.Key(), "block", e.id)
+						c.sy.markNoCompact(e.id)
+						mtx.Lock()
+						finishedAllGroups = false
+						mtx.Unlock()
+						continue
+					}
+
+					if IsUnsupportedChunkEncodingError(err) {
+						// Newer format blocks must not halt the compactor, the rest of the group is compacted without them.
+						level.Warn(c.logger).Log("msg", "excluding block with unsupported chunk encodings from compaction", "group", g.Key(), "err", err)
+						if id, err := MarkUnsupportedChunkEncoding(workCtx, c.logger, c.bkt, err); err == nil {
+							c.sy.markNoCompact(id)
+							mtx.Lock()
+							finishedAllGroups = false
+							mtx.Unlock()
+							continue
+						}
+					}
 					errChan <- errors.Wrap(err, fmt.Sprintf("compaction failed for group %s", g.Key()))
 					return
 				}
 			}()
 		}
 
-		// Clean up the compaction temporary directory at the beginning of every compaction loop.
-		if err := os.RemoveAll(c.compactDir); err != nil {
+		// Clean up the compaction temporary directory at the beginning of every compaction loop, keeping the work of
+		// interrupted compactions to resume.
+		if err := cleanWorkDir(c.logger, c.compactDir); err != nil {
 			return errors.Wrap(err, "clean up the compaction temporary directory")
 		}
 
@@ -1090,24 +3204,36 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 			return errors.Wrap(err, "sync")
 		}
 
-		level.Info(c.logger).Log("msg", "start of GC")
+		if c.garbageCollect {
+			level.Info(c.logger).Log("msg", "start of GC")
 
-		// Blocks that were compacted are garbage collected after each Compaction.
-		// However if compactor crashes we need to resolve those on startup.
-		if err := c.sy.GarbageCollect(ctx); err != nil {
-			return errors.Wrap(err, "garbage")
+			// Blocks that were compacted are garbage collected after each Compaction.
+			// However if compactor crashes we need to resolve those on startup.
+			if err := c.sy.GarbageCollect(ctx); err != nil {
+				return errors.Wrap(err, "garbage")
+			}
+		} else if err := c.sy.excludeGarbage(); err != nil {
+			return errors.Wrap(err, "exclude outdated blocks")
 		}
 
 		level.Info(c.logger).Log("msg", "start of compaction")
 
-		groups, err := c.sy.Groups()
+		groups, err := c.grouper.Groups(c.sy.metasToCompact())
 		if err != nil {
 			return errors.Wrap(err, "build compaction groups")
 		}
+		if err := sortGroups(groups, c.groupOrder); err != nil {
+			return errors.Wrap(err, "sort compaction groups")
+		}
+		c.progress.set(estimateProgress(groups, c.sy.Metas(), c.ranges))
 
 		// Send all groups found during this pass to the compaction workers.
 	groupLoop:
 		for _, g := range groups {
+			if c.sy.isHalted(g) {
+				level.Debug(c.logger).Log("msg", "skipping compaction of halted group", "group", g.Key())
+				continue
+			}
 			select {
 			case err = <-errChan:
 				break groupLoop
@@ -3,6 +3,7 @@ package compact
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -21,11 +22,14 @@ import (
 	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/objstore/objtesting"
 	"github.com/thanos-io/thanos/pkg/testutil"
 	"gopkg.in/yaml.v2"
@@ -37,7 +41,7 @@ func TestSyncer_SyncMetas_e2e(t *testing.T) {
 		defer cancel()
 
 		relabelConfig := make([]*relabel.Config, 0)
-		sy, err := NewSyncer(nil, nil, bkt, 0, 1, false, relabelConfig)
+		sy, err := NewSyncer(nil, nil, bkt, 0, 1, relabelConfig, nil, nil, nil, prometheus.NewCounter(prometheus.CounterOpts{}), 0, 0, "", "")
 		testutil.Ok(t, err)
 
 		// Generate 15 blocks. Initially the first 10 are synced into memory and only the last
@@ -67,13 +71,13 @@ func TestSyncer_SyncMetas_e2e(t *testing.T) {
 			testutil.Ok(t, bkt.Upload(ctx, path.Join(m.ULID.String(), metadata.MetaFilename), &buf))
 		}
 
-		groups, err := sy.Groups()
+		groups, err := NewDefaultGrouper(nil, bkt, false, false, nil, nil, false).Groups(sy.Metas())
 		testutil.Ok(t, err)
 		testutil.Equals(t, ids[:10], groups[0].IDs())
 
 		testutil.Ok(t, sy.SyncMetas(ctx))
 
-		groups, err = sy.Groups()
+		groups, err = NewDefaultGrouper(nil, bkt, false, false, nil, nil, false).Groups(sy.Metas())
 		testutil.Ok(t, err)
 		testutil.Equals(t, ids[5:], groups[0].IDs())
 	})
@@ -140,7 +144,7 @@ func TestSyncer_GarbageCollect_e2e(t *testing.T) {
 		}
 
 		// Do one initial synchronization with the bucket.
-		sy, err := NewSyncer(nil, nil, bkt, 0, 1, false, relabelConfig)
+		sy, err := NewSyncer(nil, nil, bkt, 0, 1, relabelConfig, nil, nil, nil, prometheus.NewCounter(prometheus.CounterOpts{}), 0, 0, "", "")
 		testutil.Ok(t, err)
 		testutil.Ok(t, sy.SyncMetas(ctx))
 
@@ -163,12 +167,12 @@ func TestSyncer_GarbageCollect_e2e(t *testing.T) {
 		testutil.Ok(t, sy.SyncMetas(ctx))
 
 		// Only the level 3 block, the last source block in both resolutions should be left.
-		groups, err := sy.Groups()
+		groups, err := NewDefaultGrouper(nil, bkt, false, false, nil, nil, false).Groups(sy.Metas())
 		testutil.Ok(t, err)
 
-		testutil.Equals(t, "0@17241709254077376921", groups[0].Key())
+		testutil.Equals(t, "0@{}", groups[0].Key())
 		testutil.Equals(t, []ulid.ULID{metas[9].ULID, m3.ULID}, groups[0].IDs())
-		testutil.Equals(t, "1000@17241709254077376921", groups[1].Key())
+		testutil.Equals(t, "1000@{}", groups[1].Key())
 		testutil.Equals(t, []ulid.ULID{m4.ULID}, groups[1].IDs())
 	})
 }
@@ -208,13 +212,23 @@ func TestGroup_Compact_e2e(t *testing.T) {
 
 		reg := prometheus.NewRegistry()
 
-		sy, err := NewSyncer(logger, reg, bkt, 0*time.Second, 5, false, nil)
+		garbageCollectedBlocks := prometheus.NewCounter(prometheus.CounterOpts{})
+		sy, err := NewSyncer(logger, reg, bkt, 0*time.Second, 5, nil, nil, nil, nil, garbageCollectedBlocks, 0, 0, "", "")
 		testutil.Ok(t, err)
+		grouper := NewDefaultGrouper(logger, bkt, false, false, reg, garbageCollectedBlocks, false)
 
 		comp, err := tsdb.NewLeveledCompactor(ctx, reg, logger, []int64{1000, 3000}, nil)
 		testutil.Ok(t, err)
 
-		bComp, err := NewBucketCompactor(logger, sy, comp, dir, bkt, 2)
+		bComp, err := NewBucketCompactor(logger, sy, grouper, comp, dir, bkt, nil, BucketCompactorOptions{
+			Concurrency:         2,
+			DownloadConcurrency: 2,
+			GroupOrder:          GroupOrderBacklog,
+			Ranges:              []int64{1000, 3000},
+			Locks:               NewGroupLocks(),
+			Verify:              true,
+			GarbageCollect:      true,
+		})
 		testutil.Ok(t, err)
 
 		// Compaction on empty should not fail.
@@ -223,10 +237,10 @@ func TestGroup_Compact_e2e(t *testing.T) {
 		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.syncMetaFailures))
 		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.garbageCollectedBlocks))
 		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.garbageCollectionFailures))
-		testutil.Equals(t, 0, MetricCount(sy.metrics.compactions))
-		testutil.Equals(t, 0, MetricCount(sy.metrics.compactionRunsStarted))
-		testutil.Equals(t, 0, MetricCount(sy.metrics.compactionRunsCompleted))
-		testutil.Equals(t, 0, MetricCount(sy.metrics.compactionFailures))
+		testutil.Equals(t, 0, MetricCount(grouper.compactions))
+		testutil.Equals(t, 0, MetricCount(grouper.compactionRunsStarted))
+		testutil.Equals(t, 0, MetricCount(grouper.compactionRunsCompleted))
+		testutil.Equals(t, 0, MetricCount(grouper.compactionFailures))
 
 		_, err = os.Stat(dir)
 		testutil.Assert(t, os.IsNotExist(err), "dir %s should be remove after compaction.", dir)
@@ -313,28 +327,28 @@ func TestGroup_Compact_e2e(t *testing.T) {
 		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.syncMetaFailures))
 		testutil.Equals(t, 5.0, promtest.ToFloat64(sy.metrics.garbageCollectedBlocks))
 		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.garbageCollectionFailures))
-		testutil.Equals(t, 4, MetricCount(sy.metrics.compactions))
-		testutil.Equals(t, 1.0, promtest.ToFloat64(sy.metrics.compactions.WithLabelValues(GroupKey(metas[0].Thanos))))
-		testutil.Equals(t, 1.0, promtest.ToFloat64(sy.metrics.compactions.WithLabelValues(GroupKey(metas[7].Thanos))))
-		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.compactions.WithLabelValues(GroupKey(metas[4].Thanos))))
-		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.compactions.WithLabelValues(GroupKey(metas[5].Thanos))))
-		testutil.Equals(t, 4, MetricCount(sy.metrics.compactionRunsStarted))
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsStarted.WithLabelValues(GroupKey(metas[0].Thanos))))
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsStarted.WithLabelValues(GroupKey(metas[7].Thanos))))
+		testutil.Equals(t, 4, MetricCount(grouper.compactions))
+		testutil.Equals(t, 1.0, promtest.ToFloat64(grouper.compactions.WithLabelValues(GroupKey(metas[0].Thanos))))
+		testutil.Equals(t, 1.0, promtest.ToFloat64(grouper.compactions.WithLabelValues(GroupKey(metas[7].Thanos))))
+		testutil.Equals(t, 0.0, promtest.ToFloat64(grouper.compactions.WithLabelValues(GroupKey(metas[4].Thanos))))
+		testutil.Equals(t, 0.0, promtest.ToFloat64(grouper.compactions.WithLabelValues(GroupKey(metas[5].Thanos))))
+		testutil.Equals(t, 4, MetricCount(grouper.compactionRunsStarted))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsStarted.WithLabelValues(GroupKey(metas[0].Thanos))))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsStarted.WithLabelValues(GroupKey(metas[7].Thanos))))
 		// TODO(bwplotka): Looks like we do some unnecessary loops. Not a major problem but investigate.
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsStarted.WithLabelValues(GroupKey(metas[4].Thanos))))
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsStarted.WithLabelValues(GroupKey(metas[5].Thanos))))
-		testutil.Equals(t, 4, MetricCount(sy.metrics.compactionRunsCompleted))
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsCompleted.WithLabelValues(GroupKey(metas[0].Thanos))))
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsCompleted.WithLabelValues(GroupKey(metas[7].Thanos))))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsStarted.WithLabelValues(GroupKey(metas[4].Thanos))))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsStarted.WithLabelValues(GroupKey(metas[5].Thanos))))
+		testutil.Equals(t, 4, MetricCount(grouper.compactionRunsCompleted))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsCompleted.WithLabelValues(GroupKey(metas[0].Thanos))))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsCompleted.WithLabelValues(GroupKey(metas[7].Thanos))))
 		// TODO(bwplotka): Looks like we do some unnecessary loops. Not a major problem but investigate.
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsCompleted.WithLabelValues(GroupKey(metas[4].Thanos))))
-		testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.compactionRunsCompleted.WithLabelValues(GroupKey(metas[5].Thanos))))
-		testutil.Equals(t, 4, MetricCount(sy.metrics.compactionFailures))
-		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.compactionFailures.WithLabelValues(GroupKey(metas[0].Thanos))))
-		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.compactionFailures.WithLabelValues(GroupKey(metas[7].Thanos))))
-		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.compactionFailures.WithLabelValues(GroupKey(metas[4].Thanos))))
-		testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.compactionFailures.WithLabelValues(GroupKey(metas[5].Thanos))))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsCompleted.WithLabelValues(GroupKey(metas[4].Thanos))))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(grouper.compactionRunsCompleted.WithLabelValues(GroupKey(metas[5].Thanos))))
+		testutil.Equals(t, 4, MetricCount(grouper.compactionFailures))
+		testutil.Equals(t, 0.0, promtest.ToFloat64(grouper.compactionFailures.WithLabelValues(GroupKey(metas[0].Thanos))))
+		testutil.Equals(t, 0.0, promtest.ToFloat64(grouper.compactionFailures.WithLabelValues(GroupKey(metas[7].Thanos))))
+		testutil.Equals(t, 0.0, promtest.ToFloat64(grouper.compactionFailures.WithLabelValues(GroupKey(metas[4].Thanos))))
+		testutil.Equals(t, 0.0, promtest.ToFloat64(grouper.compactionFailures.WithLabelValues(GroupKey(metas[5].Thanos))))
 
 		_, err = os.Stat(dir)
 		testutil.Assert(t, os.IsNotExist(err), "dir %s should be remove after compaction.", dir)
@@ -375,7 +389,7 @@ func TestGroup_Compact_e2e(t *testing.T) {
 		// We expect two compacted blocks only outside of what we expected in `nonCompactedExpected`.
 		testutil.Equals(t, 2, len(others))
 		{
-			meta, ok := others[groupKey(124, extLabels)]
+			meta, ok := others[groupKey(124, extLabels, nil)]
 			testutil.Assert(t, ok, "meta not found")
 
 			testutil.Equals(t, int64(0), meta.MinTime)
@@ -388,9 +402,10 @@ func TestGroup_Compact_e2e(t *testing.T) {
 			// Check thanos meta.
 			testutil.Assert(t, extLabels.Equals(labels.FromMap(meta.Thanos.Labels)), "ext labels does not match")
 			testutil.Equals(t, int64(124), meta.Thanos.Downsample.Resolution)
+			testutil.Equals(t, metadata.TestSource, meta.Thanos.Origin)
 		}
 		{
-			meta, ok := others[groupKey(124, extLabels2)]
+			meta, ok := others[groupKey(124, extLabels2, nil)]
 			testutil.Assert(t, ok, "meta not found")
 
 			testutil.Equals(t, int64(0), meta.MinTime)
@@ -403,10 +418,266 @@ func TestGroup_Compact_e2e(t *testing.T) {
 			// Check thanos meta.
 			testutil.Assert(t, extLabels2.Equals(labels.FromMap(meta.Thanos.Labels)), "ext labels does not match")
 			testutil.Equals(t, int64(124), meta.Thanos.Downsample.Resolution)
+			testutil.Equals(t, metadata.TestSource, meta.Thanos.Origin)
 		}
 	})
 }
 
+func TestGroup_Compact_Vertical_e2e(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enable-vertical-compaction=%v", enabled), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+			defer cancel()
+
+			dir, err := ioutil.TempDir("", "test-compact-vertical")
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+			bkt := inmem.NewBucket()
+			extLset := labels.Labels{{Name: "e1", Value: "1"}}
+
+			// Overlapping blocks, e.g. from a restarted Prometheus. Series a=2 is in both blocks with identical timestamps.
+			metas := createAndUpload(t, bkt, []blockgenSpec{
+				{
+					numSamples: 100, mint: 0, maxt: 1000, extLset: extLset, res: 0,
+					series: []labels.Labels{{{Name: "a", Value: "1"}}, {{Name: "a", Value: "2"}}},
+				},
+				{
+					numSamples: 100, mint: 0, maxt: 1000, extLset: extLset, res: 0,
+					series: []labels.Labels{{{Name: "a", Value: "2"}}, {{Name: "a", Value: "3"}}},
+				},
+			})
+
+			grouper := NewDefaultGrouper(nil, bkt, false, enabled, nil, prometheus.NewCounter(prometheus.CounterOpts{}), false)
+			groups, err := grouper.Groups(map[ulid.ULID]*metadata.Meta{metas[0].ULID: metas[0], metas[1].ULID: metas[1]})
+			testutil.Ok(t, err)
+			testutil.Equals(t, 1, len(groups))
+
+			comp, err := tsdb.NewLeveledCompactor(ctx, nil, nil, []int64{1000, 3000}, nil)
+			testutil.Ok(t, err)
+
+			_, id, err := groups[0].Compact(ctx, dir, comp, 0, 1, CompactionLimits{}, 0, true, 0)
+			if !enabled {
+				testutil.Assert(t, IsHaltError(err), "expected halt error, got %v", err)
+				d, _ := HaltErrorDetails(err)
+				testutil.Equals(t, HaltReasonOverlap, d.Reason)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, 1.0, promtest.ToFloat64(grouper.verticalCompactions.WithLabelValues(groups[0].Key())))
+
+			meta, err := block.DownloadMeta(ctx, log.NewNopLogger(), bkt, id)
+			testutil.Ok(t, err)
+			testutil.Equals(t, int64(0), meta.MinTime)
+			testutil.Equals(t, int64(1000), meta.MaxTime)
+			testutil.Equals(t, uint64(3), meta.Stats.NumSeries)
+			// Identical samples of series a=2 are deduplicated.
+			testutil.Equals(t, uint64(3*100), meta.Stats.NumSamples)
+			testutil.Equals(t, extLset.Map(), meta.Thanos.Labels)
+
+			for _, m := range metas {
+				ok, err := bkt.Exists(ctx, path.Join(m.ULID.String(), block.MetaFilename))
+				testutil.Ok(t, err)
+				testutil.Assert(t, !ok, "block %s should be deleted after compaction", m.ULID)
+			}
+		})
+	}
+}
+
+func TestGroup_Compact_RepairOutOfOrderChunks_e2e(t *testing.T) {
+	for _, repair := range []bool{false, true} {
+		t.Run(fmt.Sprintf("repair=%v", repair), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+			defer cancel()
+
+			dir, err := ioutil.TempDir("", "test-compact-out-of-order")
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+			bkt := inmem.NewBucket()
+			extLset := labels.Labels{{Name: "e1", Value: "1"}}
+
+			metas := createAndUpload(t, bkt, []blockgenSpec{
+				{
+					numSamples: 100, mint: 0, maxt: 500, extLset: extLset, res: 0,
+					series: []labels.Labels{{{Name: "a", Value: "1"}}},
+				},
+				// The most recent block is not planned for compaction.
+				{
+					numSamples: 100, mint: 1000, maxt: 1500, extLset: extLset, res: 0,
+					series: []labels.Labels{{{Name: "a", Value: "1"}}},
+				},
+			})
+			prepareDir := filepath.Join(dir, "prepare")
+			brokenID, err := createOutOfOrderBlock(prepareDir, 500, 1000, extLset)
+			testutil.Ok(t, err)
+			testutil.Ok(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(prepareDir, brokenID.String())))
+			broken, err := metadata.Read(filepath.Join(prepareDir, brokenID.String()))
+			testutil.Ok(t, err)
+
+			grouper := NewDefaultGrouper(nil, bkt, false, false, nil, prometheus.NewCounter(prometheus.CounterOpts{}), repair)
+			groups, err := grouper.Groups(map[ulid.ULID]*metadata.Meta{metas[0].ULID: metas[0], metas[1].ULID: metas[1], brokenID: broken})
+			testutil.Ok(t, err)
+			testutil.Equals(t, 1, len(groups))
+
+			comp, err := tsdb.NewLeveledCompactor(ctx, nil, nil, []int64{500, 1000}, nil)
+			testutil.Ok(t, err)
+
+			_, _, err = groups[0].Compact(ctx, filepath.Join(dir, "compact"), comp, 0, 1, CompactionLimits{}, 0, false, 0)
+			if !repair {
+				testutil.Assert(t, IsHaltError(err), "expected halt error, got %v", err)
+				d, _ := HaltErrorDetails(err)
+				testutil.Equals(t, HaltReasonUnhealthyIndex, d.Reason)
+				return
+			}
+			testutil.Assert(t, IsOutOfOrderChunksError(err), "expected out-of-order chunks error, got %v", err)
+			testutil.Ok(t, RepairOutOfOrderChunks(ctx, log.NewNopLogger(), bkt, err))
+
+			// The broken block is replaced by its repaired version.
+			var ids []ulid.ULID
+			testutil.Ok(t, bkt.Iter(ctx, "", func(name string) error {
+				if id, ok := block.IsBlockDir(name); ok {
+					ids = append(ids, id)
+				}
+				return nil
+			}))
+			testutil.Equals(t, 3, len(ids))
+			for _, id := range ids {
+				if id == metas[0].ULID || id == metas[1].ULID {
+					continue
+				}
+				testutil.Assert(t, id != brokenID, "broken block should be deleted")
+
+				repairedDir := filepath.Join(dir, "repaired", id.String())
+				testutil.Ok(t, block.Download(ctx, log.NewNopLogger(), bkt, id, repairedDir))
+				meta, err := metadata.Read(repairedDir)
+				testutil.Ok(t, err)
+				testutil.Equals(t, metadata.CompactorRepairSource, meta.Thanos.Source)
+				testutil.Ok(t, block.VerifyIndex(log.NewNopLogger(), filepath.Join(repairedDir, block.IndexFilename), meta.MinTime, meta.MaxTime))
+			}
+		})
+	}
+}
+
+func TestBucketCompactor_UnsupportedChunkEncoding_e2e(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "test-compact-encoding")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	extLset := labels.Labels{{Name: "e1", Value: "1"}}
+	series := []labels.Labels{{{Name: "a", Value: "1"}}}
+
+	var ids []ulid.ULID
+	for _, r := range [][2]int64{{0, 1000}, {1000, 2000}, {2000, 3000}, {3000, 4000}} {
+		id, err := testutil.CreateBlock(ctx, dir, series, 100, r[0], r[1], extLset, 0)
+		testutil.Ok(t, err)
+		ids = append(ids, id)
+	}
+	// The second block is written by a newer producer with a chunk encoding unknown to this compactor.
+	chunksFile := filepath.Join(dir, ids[1].String(), block.ChunksDirname, "000001")
+	b, err := ioutil.ReadFile(chunksFile)
+	testutil.Ok(t, err)
+	_, n := binary.Uvarint(b[8:])
+	b[8+n] = 0x7f
+	testutil.Ok(t, ioutil.WriteFile(chunksFile, b, os.ModePerm))
+
+	for _, id := range ids {
+		testutil.Ok(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(dir, id.String())))
+	}
+
+	garbageCollectedBlocks := prometheus.NewCounter(prometheus.CounterOpts{})
+	sy, err := NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, garbageCollectedBlocks, 0, 0, "", "")
+	testutil.Ok(t, err)
+	comp, err := tsdb.NewLeveledCompactor(ctx, nil, nil, []int64{1000, 3000}, nil)
+	testutil.Ok(t, err)
+	bComp, err := NewBucketCompactor(log.NewNopLogger(), sy, NewDefaultGrouper(nil, bkt, false, false, nil, garbageCollectedBlocks, false), comp, filepath.Join(dir, "compact"), bkt, nil, BucketCompactorOptions{
+		Concurrency:         1,
+		DownloadConcurrency: 1,
+		GroupOrder:          GroupOrderBacklog,
+		Ranges:              []int64{1000, 3000},
+		GarbageCollect:      true,
+	})
+	testutil.Ok(t, err)
+
+	// Compaction does not halt, the block is marked and excluded instead.
+	testutil.Ok(t, bComp.Compact(ctx))
+
+	marked, err := block.IsMarkedForNoCompact(ctx, bkt, ids[1])
+	testutil.Ok(t, err)
+	testutil.Assert(t, marked, "block with unsupported chunk encoding should be marked")
+	_, err = block.DownloadMeta(ctx, log.NewNopLogger(), bkt, ids[1])
+	testutil.Ok(t, err)
+
+	_, ok := sy.Metas()[ids[1]]
+	testutil.Assert(t, ok, "marked block should be still synced")
+	_, ok = sy.metasToCompact()[ids[1]]
+	testutil.Assert(t, !ok, "marked block should be excluded from compaction")
+
+	// A new syncer picks up the mark from the bucket.
+	sy2, err := NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, garbageCollectedBlocks, 0, 0, "", "")
+	testutil.Ok(t, err)
+	testutil.Ok(t, sy2.SyncMetas(ctx))
+	_, ok = sy2.metasToCompact()[ids[1]]
+	testutil.Assert(t, !ok, "marked block should be excluded from compaction")
+}
+
+func TestBucketCompactor_NoCompactMarkedAfterSync_e2e(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "test-compact-marked")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	extLset := labels.Labels{{Name: "e1", Value: "1"}}
+	series := []labels.Labels{{{Name: "a", Value: "1"}}}
+
+	var ids []ulid.ULID
+	for _, r := range [][2]int64{{0, 1000}, {1000, 2000}, {2000, 3000}, {3000, 4000}} {
+		id, err := testutil.CreateBlock(ctx, dir, series, 100, r[0], r[1], extLset, 0)
+		testutil.Ok(t, err)
+		testutil.Ok(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(dir, id.String())))
+		ids = append(ids, id)
+	}
+
+	garbageCollectedBlocks := prometheus.NewCounter(prometheus.CounterOpts{})
+	sy, err := NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, garbageCollectedBlocks, 0, 0, "", "")
+	testutil.Ok(t, err)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+
+	// The block is marked after it was synced, e.g. by thanos bucket mark.
+	testutil.Ok(t, block.MarkForNoCompact(ctx, log.NewNopLogger(), bkt, ids[1], metadata.ManualNoCompactReason, "huge chunks"))
+
+	comp, err := tsdb.NewLeveledCompactor(ctx, nil, nil, []int64{1000, 3000}, nil)
+	testutil.Ok(t, err)
+	bComp, err := NewBucketCompactor(log.NewNopLogger(), sy, NewDefaultGrouper(nil, bkt, false, false, nil, garbageCollectedBlocks, false), comp, filepath.Join(dir, "compact"), bkt, nil, BucketCompactorOptions{
+		Concurrency:         1,
+		DownloadConcurrency: 1,
+		GroupOrder:          GroupOrderBacklog,
+		Ranges:              []int64{1000, 3000},
+		GarbageCollect:      true,
+	})
+	testutil.Ok(t, err)
+	testutil.Ok(t, bComp.Compact(ctx))
+
+	_, ok := sy.metasToCompact()[ids[1]]
+	testutil.Assert(t, !ok, "marked block should be excluded from compaction")
+	for _, m := range sy.Metas() {
+		for _, s := range m.Compaction.Sources {
+			testutil.Assert(t, s != ids[1] || m.ULID == ids[1], "marked block compacted into %s", m.ULID)
+		}
+	}
+	mark, err := block.ReadNoCompactMark(ctx, nil, bkt, ids[1])
+	testutil.Ok(t, err)
+	testutil.Equals(t, metadata.ManualNoCompactReason, mark.Reason)
+	testutil.Equals(t, "huge chunks", mark.Details)
+}
+
 type blockgenSpec struct {
 	mint, maxt int64
 	series     []labels.Labels
@@ -495,6 +766,89 @@ func createEmptyBlock(dir string, mint int64, maxt int64, extLset labels.Labels,
 	return uid, nil
 }
 
+// createOutOfOrderBlock produces a block of a single series with two chunks in the wrong order and a duplicate of one
+// of them.
+func createOutOfOrderBlock(dir string, mint int64, maxt int64, extLset labels.Labels) (ulid.ULID, error) {
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	uid := ulid.MustNew(ulid.Now(), entropy)
+	bdir := filepath.Join(dir, uid.String())
+
+	newChunk := func(mint, maxt int64) (chunks.Meta, error) {
+		c := chunkenc.NewXORChunk()
+		app, err := c.Appender()
+		if err != nil {
+			return chunks.Meta{}, err
+		}
+		for t := mint; t <= maxt; t++ {
+			app.Append(t, float64(t))
+		}
+		return chunks.Meta{MinTime: mint, MaxTime: maxt, Chunk: c}, nil
+	}
+	first, err := newChunk(mint, mint+49)
+	if err != nil {
+		return ulid.ULID{}, err
+	}
+	second, err := newChunk(mint+50, mint+99)
+	if err != nil {
+		return ulid.ULID{}, err
+	}
+	chks := []chunks.Meta{second, first, first}
+
+	chunkw, err := chunks.NewWriter(filepath.Join(bdir, block.ChunksDirname))
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "new chunk writer")
+	}
+	if err := chunkw.WriteChunks(chks...); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "write chunks")
+	}
+	if err := chunkw.Close(); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "close chunk writer")
+	}
+
+	w, err := index.NewWriter(filepath.Join(bdir, block.IndexFilename))
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "new index")
+	}
+	if err := w.AddSymbols(map[string]struct{}{"a": {}, "1": {}}); err != nil {
+		return ulid.ULID{}, err
+	}
+	if err := w.AddSeries(0, labels.FromStrings("a", "1"), chks...); err != nil {
+		return ulid.ULID{}, err
+	}
+	if err := w.WriteLabelIndex([]string{"a"}, []string{"1"}); err != nil {
+		return ulid.ULID{}, err
+	}
+	if err := w.WritePostings("a", "1", index.NewListPostings([]uint64{0})); err != nil {
+		return ulid.ULID{}, err
+	}
+	n, v := index.AllPostingsKey()
+	if err := w.WritePostings(n, v, index.NewListPostings([]uint64{0})); err != nil {
+		return ulid.ULID{}, err
+	}
+	if err := w.Close(); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "close index")
+	}
+
+	m := &metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			Version: 1,
+			ULID:    uid,
+			MinTime: mint,
+			MaxTime: maxt,
+			Stats:   tsdb.BlockStats{NumSeries: 1, NumChunks: uint64(len(chks)), NumSamples: 150},
+			Compaction: tsdb.BlockMetaCompaction{
+				Level:   1,
+				Sources: []ulid.ULID{uid},
+			},
+		},
+		Thanos: metadata.Thanos{
+			Labels: extLset.Map(),
+			Source: metadata.TestSource,
+		},
+	}
+	return uid, metadata.Write(log.NewNopLogger(), bdir, m)
+}
+
 func TestSyncer_SyncMetasFilter_e2e(t *testing.T) {
 	var err error
 
@@ -514,7 +868,7 @@ func TestSyncer_SyncMetasFilter_e2e(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 		defer cancel()
 
-		sy, err := NewSyncer(nil, nil, bkt, 0, 1, false, relabelConfig)
+		sy, err := NewSyncer(nil, nil, bkt, 0, 1, relabelConfig, nil, nil, nil, prometheus.NewCounter(prometheus.CounterOpts{}), 0, 0, "", "")
 		testutil.Ok(t, err)
 
 		var ids []ulid.ULID
@@ -542,7 +896,7 @@ func TestSyncer_SyncMetasFilter_e2e(t *testing.T) {
 
 		testutil.Ok(t, sy.SyncMetas(ctx))
 
-		groups, err := sy.Groups()
+		groups, err := NewDefaultGrouper(nil, bkt, false, false, nil, nil, false).Groups(sy.Metas())
 		testutil.Ok(t, err)
 		var evenIds []ulid.ULID
 		for i := 0; i < 10; i++ {
@@ -566,7 +920,7 @@ func TestSyncer_SyncMetasFilter_e2e(t *testing.T) {
 
 		testutil.Ok(t, sy.SyncMetas(ctx))
 
-		groups, err = sy.Groups()
+		groups, err = NewDefaultGrouper(nil, bkt, false, false, nil, nil, false).Groups(sy.Metas())
 		testutil.Ok(t, err)
 		evenIds = make([]ulid.ULID, 0)
 		for i := 4; i < 16; i++ {
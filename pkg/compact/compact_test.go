@@ -3,16 +3,30 @@ package compact
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 
+	"github.com/go-kit/kit/log"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/tsdb"
 	terrors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
@@ -42,6 +56,54 @@ func TestHaltMultiError(t *testing.T) {
 	testutil.Assert(t, IsHaltError(errs), "if any halt errors are present this should return true")
 }
 
+func TestHaltErrorDetails(t *testing.T) {
+	_, ok := HaltErrorDetails(errors.New("test"))
+	testutil.Assert(t, !ok, "details of non halt error")
+
+	d, ok := HaltErrorDetails(errors.Wrap(halt(errors.New("test")), "something"))
+	testutil.Assert(t, ok, "no details of halt error")
+	testutil.Equals(t, HaltDetails{Reason: HaltReasonUnknown}, d)
+
+	cg := &Group{labels: labels.FromStrings("a", "1"), resolution: 0}
+	ids := []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(2, nil)}
+	errs := terrors.MultiError{errors.New("not a halt error")}
+	errs.Add(errors.Wrap(cg.halt(HaltReasonOverlap, ids, errors.New("overlap")), "compaction"))
+
+	d, ok = HaltErrorDetails(errs)
+	testutil.Assert(t, ok, "no details of halt error in multi error")
+	testutil.Equals(t, HaltDetails{Reason: HaltReasonOverlap, Group: `0@{a="1"}`, Blocks: ids}, d)
+}
+
+func TestGroup_IsOverlappingOthers(t *testing.T) {
+	meta := func(id uint64, mint, maxt int64) *metadata.Meta {
+		return &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(id, nil), MinTime: mint, MaxTime: maxt}}
+	}
+	var (
+		in1 = meta(1, 0, 100)
+		in2 = meta(2, 50, 150)
+		// Other blocks overlap with each other, but not with the compacted block.
+		o1 = meta(3, 200, 300)
+		o2 = meta(4, 250, 350)
+	)
+	cg := &Group{blocks: map[ulid.ULID]*metadata.Meta{}}
+	for _, m := range []*metadata.Meta{in1, in2, o1, o2} {
+		cg.blocks[m.ULID] = m
+	}
+	plan := []string{filepath.Join("dir", in1.ULID.String()), filepath.Join("dir", in2.ULID.String())}
+
+	out := meta(5, 0, 150)
+	_, err := cg.areBlocksOverlapping(out, plan...)
+	testutil.NotOk(t, err)
+	ids, err := cg.isOverlappingOthers(out, plan...)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(ids))
+
+	out = meta(5, 0, 201)
+	ids, err = cg.isOverlappingOthers(out, plan...)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, []ulid.ULID{o1.ULID, out.ULID}, ids)
+}
+
 func TestRetryMultiError(t *testing.T) {
 	retryErr := retry(errors.New("retry error"))
 	nonRetryErr := errors.New("not a retry error")
@@ -73,13 +135,94 @@ func TestRetryError(t *testing.T) {
 	testutil.Assert(t, IsHaltError(err), "not a halt error. Retry should not hide halt error")
 }
 
+func TestCheckDirQuota(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-dir-quota")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "block", "chunks"), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "block", "index"), make([]byte, 100), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "block", "chunks", "000001"), make([]byte, 50), os.ModePerm))
+
+	size, err := dirSize(dir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(150), size)
+
+	testutil.Ok(t, checkDirQuota(dir, 0, 0))
+	testutil.Ok(t, checkDirQuota(dir, 1000, 0))
+	testutil.Ok(t, checkDirQuota(dir, 0, 150))
+	testutil.Ok(t, checkDirQuota(dir, 50, 200))
+
+	err = checkDirQuota(dir, 51, 200)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, IsQuotaExceededError(errors.Wrap(err, "something")), "not a quota exceeded error")
+	testutil.Assert(t, !IsQuotaExceededError(errors.New("test")), "quota exceeded error")
+}
+
+func TestCheckPlanQuota(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-plan-quota")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	// The first block is downloaded already, the second one is not.
+	downloaded, missing := filepath.Join(dir, "downloaded"), filepath.Join(dir, "missing")
+	testutil.Ok(t, os.MkdirAll(downloaded, os.ModePerm))
+	testutil.Ok(t, os.MkdirAll(missing, os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(downloaded, "index"), make([]byte, 100), os.ModePerm))
+	testutil.Ok(t, markDownloaded(downloaded))
+
+	size, err := dirSize(dir)
+	testutil.Ok(t, err)
+
+	m1, m2 := &metadata.Meta{}, &metadata.Meta{}
+	m1.Stats.NumSamples = 50
+	m2.Stats.NumSamples = 100
+	metas, plan := []*metadata.Meta{m1, m2}, []string{downloaded, missing}
+
+	// The missing block is downloaded and both blocks are compacted.
+	required := size + estimatedBlockBytes(m2) + estimatedBlockBytes(m1) + estimatedBlockBytes(m2)
+	testutil.Ok(t, checkPlanQuota(dir, metas, plan, 0))
+	testutil.Ok(t, checkPlanQuota(dir, metas, plan, required))
+
+	err = checkPlanQuota(dir, metas, plan, required-1)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, IsQuotaExceededError(err), "not a quota exceeded error")
+}
+
+func TestCheckFreeDiskSpace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-free-disk-space")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	free, ok, err := freeDiskSpace(dir)
+	testutil.Ok(t, err)
+	if !ok {
+		t.Skip("free disk space is not determined on this platform")
+	}
+
+	small := &metadata.Meta{}
+	small.Stats.NumSamples = 100
+	// Estimated to need twice the free disk space.
+	large := &metadata.Meta{}
+	large.Stats.NumSamples = uint64(free)
+
+	testutil.Ok(t, checkFreeDiskSpace(dir, []*metadata.Meta{small}, 2))
+	testutil.Ok(t, checkFreeDiskSpace(dir, []*metadata.Meta{small, large}, 0))
+	testutil.Ok(t, checkFreeDiskSpace(dir, []*metadata.Meta{large}, 0.25))
+
+	err = checkFreeDiskSpace(dir, []*metadata.Meta{small, large}, 1)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, IsInsufficientDiskSpaceError(errors.Wrap(err, "something")), "not an insufficient disk space error")
+	testutil.Assert(t, !IsInsufficientDiskSpaceError(errors.New("test")), "insufficient disk space error")
+}
+
 func TestSyncer_SyncMetas_HandlesMalformedBlocks(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	bkt := inmem.NewBucket()
 	relabelConfig := make([]*relabel.Config, 0)
-	sy, err := NewSyncer(nil, nil, bkt, 10*time.Second, 1, false, relabelConfig)
+	sy, err := NewSyncer(nil, nil, bkt, 10*time.Second, 1, relabelConfig, nil, nil, nil, nil, 0, 0, "", "")
 	testutil.Ok(t, err)
 
 	// Generate 1 block which is older than MinimumAgeForRemoval which has chunk data but no meta.  Compactor should delete it.
@@ -108,41 +251,782 @@ func TestSyncer_SyncMetas_HandlesMalformedBlocks(t *testing.T) {
 	testutil.Equals(t, true, exists)
 }
 
+func TestSyncer_SyncMetas_QuarantinesPartialBlocks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bkt := inmem.NewBucket()
+	id, err := ulid.New(uint64(time.Now().Add(-2*time.Hour).Unix()*1000), nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewReader([]byte("{"))))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), "chunks", "000001"), bytes.NewReader([]byte{0, 1, 2, 3})))
+
+	// Partial blocks fail the sync until they are partial for longer than the consistency delay.
+	sy, err := NewSyncer(nil, nil, bkt, time.Hour, 1, nil, nil, nil, nil, nil, 0, 0, "", "")
+	testutil.Ok(t, err)
+	testutil.NotOk(t, sy.SyncMetas(ctx))
+	partial := sy.PartialBlocks()
+	testutil.Equals(t, 1, len(partial))
+	testutil.Equals(t, id, partial[0].ID)
+	testutil.Equals(t, int64(0), partial[0].QuarantineTime)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(sy.metrics.partialBlocks.WithLabelValues("false")))
+
+	sy.partial[id].FirstSeen = time.Now().Add(-time.Hour)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	partial = sy.PartialBlocks()
+	testutil.Equals(t, 1, len(partial))
+	testutil.Assert(t, partial[0].QuarantineTime != 0, "block not quarantined")
+	testutil.Equals(t, 1.0, promtest.ToFloat64(sy.metrics.partialBlocks.WithLabelValues("true")))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.partialBlocks.WithLabelValues("false")))
+
+	mark, err := block.ReadNoCompactMark(ctx, nil, bkt, id)
+	testutil.Ok(t, err)
+	testutil.Equals(t, metadata.PartialNoCompactReason, mark.Reason)
+
+	// Quarantined blocks are ignored after restarts and deleted once quarantined for long enough.
+	sy, err = NewSyncer(nil, nil, bkt, time.Hour, 1, nil, nil, nil, nil, nil, 0, 1, "", "")
+	testutil.Ok(t, err)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 1, len(sy.PartialBlocks()))
+
+	sy.partial[id].QuarantineTime = time.Now().Add(-time.Hour).Unix()
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, []PartialBlock{}, sy.PartialBlocks())
+	exists, err := bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+	testutil.Ok(t, err)
+	testutil.Equals(t, false, exists)
+}
+
+type failingGetBucket struct {
+	objstore.Bucket
+}
+
+func (b failingGetBucket) Get(context.Context, string) (io.ReadCloser, error) {
+	return nil, errors.New("get failed")
+}
+
+func TestSyncer_SyncMetas_DoesNotQuarantineOnBucketErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bkt := inmem.NewBucket()
+	id, err := ulid.New(uint64(time.Now().Add(-2*time.Hour).Unix()*1000), nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewReader([]byte("{}"))))
+
+	sy, err := NewSyncer(nil, nil, failingGetBucket{Bucket: bkt}, 0, 1, nil, nil, nil, nil, nil, 0, 0, "", "")
+	testutil.Ok(t, err)
+	testutil.NotOk(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, []PartialBlock{}, sy.PartialBlocks())
+
+	exists, err := bkt.Exists(ctx, path.Join(id.String(), metadata.NoCompactMarkFilename))
+	testutil.Ok(t, err)
+	testutil.Assert(t, !exists, "block quarantined on bucket error")
+}
+
+func TestSyncer_SyncMetas_MetaCache(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "meta-cache")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	var ids []ulid.ULID
+	for i := 0; i < 2; i++ {
+		m := metadata.Meta{}
+		m.ULID = ulid.MustNew(uint64(i), nil)
+		m.Version = metadata.MetaVersion1
+		m.Thanos.Labels = map[string]string{"cluster": "a"}
+		b, err := json.Marshal(m)
+		testutil.Ok(t, err)
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(m.ULID.String(), block.MetaFilename), bytes.NewReader(b)))
+		ids = append(ids, m.ULID)
+	}
+
+	sy, err := NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, nil, 0, 0, dir, "")
+	testutil.Ok(t, err)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 2, len(sy.Metas()))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.metaCacheHits))
+
+	// Metas are read from the cache after restarts.
+	sy, err = NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, nil, 0, 0, dir, "")
+	testutil.Ok(t, err)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 2, len(sy.Metas()))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.metaCacheHits))
+
+	// Cached metas of deleted blocks are removed.
+	testutil.Ok(t, bkt.Delete(ctx, path.Join(ids[0].String(), block.MetaFilename)))
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 1, len(sy.Metas()))
+	fis, err := ioutil.ReadDir(dir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(fis))
+	testutil.Equals(t, ids[1].String(), fis[0].Name())
+}
+
+func TestSyncer_SyncMetas_BlockDiscovery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bkt := inmem.NewBucket()
+	var ids []ulid.ULID
+	for i := 0; i < 3; i++ {
+		m := metadata.Meta{}
+		m.ULID = ulid.MustNew(uint64(i), nil)
+		m.Version = metadata.MetaVersion1
+		b, err := json.Marshal(m)
+		testutil.Ok(t, err)
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(m.ULID.String(), block.MetaFilename), bytes.NewReader(b)))
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(m.ULID.String(), "chunks", "000001"), bytes.NewReader([]byte{0, 1, 2, 3})))
+		ids = append(ids, m.ULID)
+	}
+	// Blocks without meta file are ignored while they are too fresh.
+	fresh, err := ulid.New(ulid.Now(), nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(fresh.String(), "chunks", "000001"), bytes.NewReader([]byte{0, 1, 2, 3})))
+
+	for _, d := range BlockDiscoveries {
+		t.Run(string(d), func(t *testing.T) {
+			sy, err := NewSyncer(nil, nil, bkt, time.Hour, 2, nil, nil, nil, nil, nil, 0, 0, "", d)
+			testutil.Ok(t, err)
+			testutil.Ok(t, sy.SyncMetas(ctx))
+
+			var got []ulid.ULID
+			for id := range sy.Metas() {
+				got = append(got, id)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Compare(got[j]) < 0 })
+			testutil.Equals(t, ids, got)
+		})
+	}
+
+	_, err = NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, nil, 0, 0, "", BlockDiscovery("random"))
+	testutil.NotOk(t, err)
+}
+
+func TestIsBlockTooFresh(t *testing.T) {
+	var (
+		fresh = ulid.MustNew(ulid.Now()-uint64(time.Minute/time.Millisecond), nil)
+		old   = ulid.MustNew(ulid.Now()-uint64(time.Hour/time.Millisecond), nil)
+	)
+	meta := func(source metadata.SourceType) *metadata.Meta {
+		m := &metadata.Meta{}
+		m.Thanos.Source = source
+		return m
+	}
+	testutil.Assert(t, IsBlockTooFresh(fresh, nil, 30*time.Minute), "fresh block without meta not too fresh")
+	testutil.Assert(t, IsBlockTooFresh(fresh, meta(metadata.SidecarSource), 30*time.Minute), "fresh sidecar block not too fresh")
+	testutil.Assert(t, !IsBlockTooFresh(fresh, meta(metadata.CompactorSource), 30*time.Minute), "fresh compacted block too fresh")
+	testutil.Assert(t, !IsBlockTooFresh(fresh, meta(metadata.BucketRepairSource), 30*time.Minute), "fresh repaired block too fresh")
+	testutil.Assert(t, !IsBlockTooFresh(old, nil, 30*time.Minute), "old block too fresh")
+	testutil.Assert(t, !IsBlockTooFresh(fresh, nil, 0), "block too fresh without consistency delay")
+}
+
+func TestSyncer_Shard(t *testing.T) {
+	ctx := context.Background()
+
+	bkt := inmem.NewBucket()
+	for i, cluster := range []string{"a", "b", "c", "a"} {
+		m := metadata.Meta{}
+		m.ULID = ulid.MustNew(uint64(i), nil)
+		m.Version = metadata.MetaVersion1
+		m.Thanos.Labels = map[string]string{"cluster": cluster}
+		b, err := json.Marshal(m)
+		testutil.Ok(t, err)
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(m.ULID.String(), block.MetaFilename), bytes.NewReader(b)))
+	}
+
+	selector := labels.Labels{{Name: "cluster", Value: "a"}, {Name: "cluster", Value: "b"}}
+	sy, err := NewSyncer(nil, nil, bkt, 0, 1, nil, selector, nil, nil, nil, 0, 0, "", "")
+	testutil.Ok(t, err)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+
+	testutil.Equals(t, 3, len(sy.Metas()))
+	testutil.Equals(t, Shard{
+		Selector: map[string][]string{"cluster": {"a", "b"}},
+		Owned:    []map[string]string{{"cluster": "a"}, {"cluster": "b"}},
+		NotOwned: []map[string]string{{"cluster": "c"}},
+	}, sy.Shard())
+	testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.shardLabelSets.WithLabelValues("true")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(sy.metrics.shardLabelSets.WithLabelValues("false")))
+
+	// All label names of the selector must match.
+	selector = labels.Labels{{Name: "cluster", Value: "a"}, {Name: "replica", Value: "0"}}
+	testutil.Assert(t, selects(selector, map[string]string{"cluster": "a", "replica": "0", "other": "x"}), "selector does not match")
+	testutil.Assert(t, !selects(selector, map[string]string{"cluster": "a"}), "selector matches without replica")
+	testutil.Assert(t, selects(nil, map[string]string{"cluster": "a"}), "empty selector does not match")
+}
+
 func TestGroupKey(t *testing.T) {
 	for _, tcase := range []struct {
-		input    metadata.Thanos
-		expected string
+		input          metadata.Thanos
+		expected       string
+		expectedLegacy string
 	}{
 		{
-			input:    metadata.Thanos{},
-			expected: "0@17241709254077376921",
+			input:          metadata.Thanos{},
+			expected:       "0@{}",
+			expectedLegacy: "0@17241709254077376921",
 		},
 		{
 			input: metadata.Thanos{
 				Labels:     map[string]string{},
 				Downsample: metadata.ThanosDownsample{Resolution: 0},
 			},
-			expected: "0@17241709254077376921",
+			expected:       "0@{}",
+			expectedLegacy: "0@17241709254077376921",
 		},
 		{
 			input: metadata.Thanos{
 				Labels:     map[string]string{"foo": "bar", "foo1": "bar2"},
-				Downsample: metadata.ThanosDownsample{Resolution: 0},
+				Downsample: metadata.ThanosDownsample{Resolution: 300000},
 			},
-			expected: "0@2124638872457683483",
+			expected:       `300000@{foo="bar",foo1="bar2"}`,
+			expectedLegacy: "300000@2124638872457683483",
 		},
 		{
 			input: metadata.Thanos{
 				Labels:     map[string]string{`foo/some..thing/some.thing/../`: `a_b_c/bar-something-a\metric/a\x`},
 				Downsample: metadata.ThanosDownsample{Resolution: 0},
 			},
-			expected: "0@16590761456214576373",
+			expected:       `0@{foo/some..thing/some.thing/../="a_b_c/bar-something-a\\metric/a\\x"}`,
+			expectedLegacy: "0@16590761456214576373",
+		},
+		{
+			input: metadata.Thanos{
+				Labels:     map[string]string{`foo_some_thing`: `a_b_c/bar-something-a\metric/a\x"`},
+				Downsample: metadata.ThanosDownsample{Resolution: 0},
+			},
+			expected:       `0@{foo_some_thing="a_b_c/bar-something-a\\metric/a\\x\""}`,
+			expectedLegacy: "0@16410163428541175599",
+		},
+		{
+			input: metadata.Thanos{
+				Labels:     map[string]string{"foo": "bar@baz"},
+				Downsample: metadata.ThanosDownsample{Resolution: 300000},
+				Shard:      &metadata.ThanosShard{Index: 1, Count: 4},
+			},
+			expected:       `300000@{foo="bar@baz"}@shard_1_of_4`,
+			expectedLegacy: "300000@9118922433344317207@shard_1_of_4",
 		},
 	} {
 		if ok := t.Run("", func(t *testing.T) {
 			testutil.Equals(t, tcase.expected, GroupKey(tcase.input))
+			testutil.Equals(t, tcase.expectedLegacy, legacyGroupKey(tcase.input.Downsample.Resolution, labels.FromMap(tcase.input.Labels), tcase.input.Shard))
+
+			// Both formats are parsed to the same resolution and labels hash.
+			res, hash, err := ParseGroupKey(tcase.expected)
+			testutil.Ok(t, err)
+			legacyRes, legacyHash, err := ParseGroupKey(tcase.expectedLegacy)
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.input.Downsample.Resolution, res)
+			testutil.Equals(t, res, legacyRes)
+			testutil.Equals(t, hash, legacyHash)
 		}); !ok {
 			return
 		}
 	}
+
+	for _, key := range []string{"", "0", "a@{}", "0@abc", `0@{foo="bar"`} {
+		_, _, err := ParseGroupKey(key)
+		testutil.NotOk(t, err)
+	}
+}
+
+func TestDefaultGrouper(t *testing.T) {
+	newMeta := func(i uint64, resolution int64, lset labels.Labels) *metadata.Meta {
+		m := &metadata.Meta{}
+		m.ULID = ulid.MustNew(i, nil)
+		m.Thanos.Labels = lset.Map()
+		m.Thanos.Downsample.Resolution = resolution
+		return m
+	}
+	a, b := labels.FromStrings("tenant", "a"), labels.FromStrings("tenant", "b")
+
+	blocks := map[ulid.ULID]*metadata.Meta{}
+	for _, m := range []*metadata.Meta{
+		newMeta(1, 0, b),
+		newMeta(2, 0, a),
+		newMeta(3, 300000, a),
+		newMeta(4, 0, a),
+	} {
+		blocks[m.ULID] = m
+	}
+
+	groups, err := NewDefaultGrouper(nil, nil, false, false, nil, nil, false).Groups(blocks)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, len(groups))
+	testutil.Equals(t, `0@{tenant="a"}`, groups[0].Key())
+	testutil.Equals(t, []ulid.ULID{ulid.MustNew(2, nil), ulid.MustNew(4, nil)}, groups[0].IDs())
+	testutil.Equals(t, `0@{tenant="b"}`, groups[1].Key())
+	testutil.Equals(t, []ulid.ULID{ulid.MustNew(1, nil)}, groups[1].IDs())
+	testutil.Equals(t, `300000@{tenant="a"}`, groups[2].Key())
+	testutil.Equals(t, []ulid.ULID{ulid.MustNew(3, nil)}, groups[2].IDs())
+}
+
+func TestSortGroups(t *testing.T) {
+	newTestGroup := func(lset labels.Labels, minTime int64, levels ...int) *Group {
+		g, err := NewGroup(nil, nil, lset, 0, nil, false, false, nil, nil, nil, nil, nil, nil, false)
+		testutil.Ok(t, err)
+		for _, l := range levels {
+			m := &metadata.Meta{}
+			m.ULID = ulid.MustNew(uint64(len(g.blocks)), nil)
+			m.MinTime = minTime + 10*int64(len(g.blocks))
+			m.Stats.NumSamples = 1000 * uint64(l)
+			m.Compaction.Level = l
+			m.Thanos.Labels = lset.Map()
+			testutil.Ok(t, g.Add(m))
+		}
+		return g
+	}
+	var (
+		a = newTestGroup(labels.FromStrings("tenant", "a"), 20, 1, 1, 2)
+		b = newTestGroup(labels.FromStrings("tenant", "b"), 30, 1, 1, 1)
+		c = newTestGroup(labels.FromStrings("tenant", "c"), 0, 3, 1)
+		d = newTestGroup(labels.FromStrings("tenant", "d"), 0, 4)
+	)
+	testutil.Equals(t, 3, b.Backlog())
+	testutil.Equals(t, 0, d.Backlog())
+
+	keys := func(groups []*Group) (res []string) {
+		for _, g := range groups {
+			res = append(res, g.Key())
+		}
+		return res
+	}
+
+	groups := []*Group{d, c, b, a}
+	exp := keys(groups)
+	sort.Strings(exp)
+	testutil.Ok(t, sortGroups(groups, GroupOrderKey))
+	testutil.Equals(t, exp, keys(groups))
+
+	groups = []*Group{d, c, b, a}
+	testutil.Ok(t, sortGroups(groups, GroupOrderBacklog))
+	testutil.Equals(t, keys([]*Group{b, a, c, d}), keys(groups))
+
+	groups = []*Group{d, c, b, a}
+	testutil.Ok(t, sortGroups(groups, GroupOrderOldest))
+	testutil.Equals(t, keys([]*Group{c, a, b, d}), keys(groups))
+
+	groups = []*Group{d, c, b, a}
+	testutil.Ok(t, sortGroups(groups, GroupOrderBlocks))
+	testutil.Equals(t, keys([]*Group{a, b, c, d}), keys(groups))
+
+	groups = []*Group{d, c, b, a}
+	testutil.Ok(t, sortGroups(groups, GroupOrderSmallest))
+	testutil.Equals(t, keys([]*Group{b, a, c, d}), keys(groups))
+
+	testutil.NotOk(t, sortGroups(groups, GroupOrder("random")))
+}
+
+func TestFilterBySource(t *testing.T) {
+	meta := func(i uint64, source, origin metadata.SourceType, lset map[string]string) *metadata.Meta {
+		m := &metadata.Meta{}
+		m.ULID = ulid.MustNew(i, nil)
+		m.Thanos.Source = source
+		m.Thanos.Origin = origin
+		m.Thanos.Labels = lset
+		return m
+	}
+	metas := map[ulid.ULID]*metadata.Meta{}
+	for _, m := range []*metadata.Meta{
+		meta(1, metadata.SidecarSource, "", map[string]string{"cluster": "a"}),
+		meta(2, metadata.CompactorSource, metadata.SidecarSource, map[string]string{"cluster": "a"}),
+		meta(3, metadata.ReceiveSource, "", map[string]string{"cluster": "b"}),
+		meta(4, metadata.CompactorSource, metadata.ReceiveSource, map[string]string{"cluster": "b"}),
+		meta(5, metadata.CompactorRepairSource, metadata.ReceiveSource, map[string]string{"cluster": "b"}),
+		// Compacted block of a sidecar with the same labels as blocks of receive.
+		meta(6, metadata.CompactorSource, metadata.SidecarSource, map[string]string{"cluster": "b"}),
+		meta(7, metadata.RulerSource, "", map[string]string{"cluster": "b"}),
+		// Compacted block without origin, e.g. created before origins were recorded.
+		meta(8, metadata.CompactorSource, "", map[string]string{"cluster": "b"}),
+		// Compacted block whose source blocks were garbage collected.
+		meta(9, metadata.CompactorSource, metadata.ReceiveSource, map[string]string{"cluster": "c"}),
+	} {
+		metas[m.ULID] = m
+	}
+
+	testutil.Equals(t, metas, FilterBySource(nil, metas))
+
+	ids := func(metas map[ulid.ULID]*metadata.Meta) (res []uint64) {
+		for id := range metas {
+			res = append(res, id.Time())
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+		return res
+	}
+	testutil.Equals(t, []uint64{3, 4, 5, 9}, ids(FilterBySource([]metadata.SourceType{metadata.ReceiveSource}, metas)))
+	testutil.Equals(t, []uint64{1, 2, 6}, ids(FilterBySource([]metadata.SourceType{metadata.SidecarSource}, metas)))
+	testutil.Equals(t, []uint64{1, 2, 3, 4, 5, 6, 9}, ids(FilterBySource([]metadata.SourceType{metadata.SidecarSource, metadata.ReceiveSource}, metas)))
+	testutil.Equals(t, []uint64(nil), ids(FilterBySource([]metadata.SourceType{metadata.BucketRepairSource}, metas)))
+}
+
+func TestFilterByLabels(t *testing.T) {
+	metas := map[ulid.ULID]*metadata.Meta{}
+	for i, cluster := range []string{"a", "b", "c"} {
+		m := &metadata.Meta{}
+		m.ULID = ulid.MustNew(uint64(i), nil)
+		m.Thanos.Labels = map[string]string{"cluster": cluster}
+		metas[m.ULID] = m
+	}
+
+	testutil.Equals(t, metas, FilterByLabels(nil, nil, metas))
+
+	ids := func(metas map[ulid.ULID]*metadata.Meta) (res []uint64) {
+		for id := range metas {
+			res = append(res, id.Time())
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+		return res
+	}
+	dropC := []*relabel.Config{{
+		Action:       relabel.Drop,
+		SourceLabels: model.LabelNames{"cluster"},
+		Regex:        relabel.MustNewRegexp("c"),
+	}}
+	testutil.Equals(t, []uint64{0, 1}, ids(FilterByLabels(dropC, nil, metas)))
+	testutil.Equals(t, []uint64{1, 2}, ids(FilterByLabels(nil, labels.FromStrings("cluster", "b", "cluster", "c"), metas)))
+	testutil.Equals(t, []uint64{1}, ids(FilterByLabels(dropC, labels.FromStrings("cluster", "b", "cluster", "c"), metas)))
+}
+
+func TestFilterByTime(t *testing.T) {
+	metas := map[ulid.ULID]*metadata.Meta{}
+	for i, mint := range []int64{-10, 0, 10, 20, 30} {
+		m := &metadata.Meta{}
+		m.ULID = ulid.MustNew(uint64(i), nil)
+		m.MinTime = mint
+		m.MaxTime = mint + 10
+		metas[m.ULID] = m
+	}
+
+	testutil.Equals(t, metas, FilterByTime(nil, metas))
+
+	filter := func(mint, maxt string) *TimeFilter {
+		f := &TimeFilter{}
+		testutil.Ok(t, f.MinTime.Set(mint))
+		testutil.Ok(t, f.MaxTime.Set(maxt))
+		return f
+	}
+	ids := func(metas map[ulid.ULID]*metadata.Meta) (res []uint64) {
+		for id := range metas {
+			res = append(res, id.Time())
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+		return res
+	}
+	// Blocks are selected by min time, so adjacent windows share no block.
+	testutil.Equals(t, []uint64{0, 1}, ids(FilterByTime(filter("1969-12-31T23:59:59Z", "1970-01-01T00:00:00.010Z"), metas)))
+	testutil.Equals(t, []uint64{2, 3}, ids(FilterByTime(filter("1970-01-01T00:00:00.010Z", "1970-01-01T00:00:00.030Z"), metas)))
+	testutil.Equals(t, []uint64{4}, ids(FilterByTime(filter("1970-01-01T00:00:00.030Z", "9999-12-31T23:59:59Z"), metas)))
+}
+
+func TestDownloadBlocks(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test-download-blocks")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	var (
+		metas []*metadata.Meta
+		plan  []string
+	)
+	for i := 0; i < 5; i++ {
+		id := ulid.MustNew(uint64(i), nil)
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), "index"), bytes.NewReader([]byte(id.String()))))
+		metas = append(metas, &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: id}})
+		plan = append(plan, filepath.Join(dir, id.String()))
+	}
+
+	d := downloadBlocks(ctx, log.NewNopLogger(), bkt, metas, plan, 2, &diskReservation{})
+	defer d.stop()
+	for i, pdir := range plan {
+		testutil.Ok(t, d.wait(ctx, i))
+
+		b, err := ioutil.ReadFile(filepath.Join(pdir, "index"))
+		testutil.Ok(t, err)
+		testutil.Equals(t, metas[i].ULID.String(), string(b))
+	}
+
+	// Blocks downloaded completely are not downloaded again.
+	testutil.Ok(t, bkt.Delete(ctx, path.Join(metas[0].ULID.String(), "index")))
+	d = downloadBlocks(ctx, log.NewNopLogger(), bkt, metas, plan, 2, &diskReservation{})
+	for i := range plan {
+		testutil.Ok(t, d.wait(ctx, i))
+	}
+	d.stop()
+
+	// Remaining downloads are canceled once stopped.
+	for _, pdir := range plan {
+		testutil.Ok(t, os.RemoveAll(pdir))
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	d = downloadBlocks(cctx, log.NewNopLogger(), bkt, metas, plan, 2, &diskReservation{})
+	testutil.NotOk(t, d.wait(cctx, len(plan)-1))
+	d.stop()
+}
+
+func TestDownloadBlocks_ReservesQuota(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test-download-blocks-quota")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	var (
+		metas []*metadata.Meta
+		plan  []string
+	)
+	for i := 0; i < 3; i++ {
+		id := ulid.MustNew(uint64(i), nil)
+		// Blocks are larger than estimated.
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), "index"), bytes.NewReader(make([]byte, 2*estimatedBytesPerSeries))))
+		m := &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: id}}
+		m.Stats.NumSeries = 1
+		metas = append(metas, m)
+		plan = append(plan, filepath.Join(dir, id.String()))
+	}
+
+	// Downloaded blocks count with their size, so the last block does not fit into the quota anymore.
+	reservation, err := newDiskReservation(dir, 4*estimatedBytesPerSeries+estimatedBytesPerSeries/2)
+	testutil.Ok(t, err)
+	d := downloadBlocks(ctx, log.NewNopLogger(), bkt, metas, plan, 1, reservation)
+	defer d.stop()
+
+	testutil.Ok(t, d.wait(ctx, 0))
+	testutil.Ok(t, d.wait(ctx, 1))
+	err = d.wait(ctx, 2)
+	testutil.Assert(t, IsQuotaExceededError(err), "expected quota exceeded error, got %v", err)
+	_, err = os.Stat(plan[2])
+	testutil.Assert(t, os.IsNotExist(err), "block exceeding quota downloaded")
+
+	// Blocks being downloaded reserve their estimated size.
+	r, err := newDiskReservation(dir, 2*estimatedBytesPerSeries)
+	testutil.Ok(t, err)
+	testutil.Assert(t, IsQuotaExceededError(r.reserve(estimatedBytesPerSeries)), "dir size is reserved")
+	testutil.Ok(t, os.RemoveAll(plan[0]))
+	testutil.Ok(t, os.RemoveAll(plan[1]))
+	r, err = newDiskReservation(dir, 2*estimatedBytesPerSeries)
+	testutil.Ok(t, err)
+	testutil.Ok(t, r.reserve(estimatedBytesPerSeries))
+	testutil.Ok(t, r.reserve(estimatedBytesPerSeries))
+	testutil.Assert(t, IsQuotaExceededError(r.reserve(1)), "quota exceeded by concurrent reservations")
+	r.adjust(estimatedBytesPerSeries, 0)
+	testutil.Ok(t, r.reserve(1))
+}
+
+func TestSyncer_HaltedGroups(t *testing.T) {
+	sy, err := NewSyncer(nil, nil, nil, 0, 1, nil, nil, nil, nil, nil, 0, 0, "", "")
+	testutil.Ok(t, err)
+	now := time.Unix(1000, 0)
+	sy.now = func() time.Time { return now }
+
+	newTestGroup := func(lset labels.Labels, ids ...ulid.ULID) *Group {
+		g, err := NewGroup(nil, nil, lset, 0, nil, false, false, nil, nil, nil, nil, nil, nil, false)
+		testutil.Ok(t, err)
+		for _, id := range ids {
+			m := &metadata.Meta{}
+			m.ULID = id
+			m.Thanos.Labels = lset.Map()
+			testutil.Ok(t, g.Add(m))
+		}
+		return g
+	}
+	var (
+		id1, id2, id3 = ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil)
+		a             = newTestGroup(labels.FromStrings("tenant", "a"), id1, id2)
+		b             = newTestGroup(labels.FromStrings("tenant", "b"), id3)
+	)
+	testutil.Equals(t, []HaltedGroup{}, sy.HaltedGroups())
+
+	sy.haltGroup(a.Key(), errors.Wrap(a.halt(HaltReasonUnhealthyIndex, []ulid.ULID{id2}, errors.New("unhealthy index")), "compact"))
+	sy.haltGroup(b.Key(), errors.New("unknown"))
+	testutil.Assert(t, sy.isHalted(a), "group a not halted")
+	testutil.Assert(t, sy.isHalted(b), "group b not halted")
+	testutil.Equals(t, []HaltedGroup{
+		{HaltDetails: HaltDetails{Reason: HaltReasonUnhealthyIndex, Group: a.Key(), Blocks: []ulid.ULID{id2}}, Error: "compact: unhealthy index", Since: now, Halts: 1},
+		{HaltDetails: HaltDetails{Reason: HaltReasonUnknown, Group: b.Key()}, Error: "unknown", Since: now, Halts: 1},
+	}, sy.HaltedGroups())
+
+	// The halt is lifted once the blocks causing it are gone.
+	testutil.Assert(t, !sy.isHalted(newTestGroup(labels.FromStrings("tenant", "a"), id1)), "group a still halted")
+	testutil.Assert(t, sy.isHalted(b), "group b not halted")
+	testutil.Equals(t, 1, len(sy.HaltedGroups()))
+
+	testutil.Equals(t, 1, sy.UnhaltGroups())
+	testutil.Assert(t, !sy.isHalted(b), "group b still halted")
+	testutil.Equals(t, []HaltedGroup{}, sy.HaltedGroups())
+}
+
+func TestSyncer_HaltedGroups_PersistedWithBackoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "halted-groups")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	opts := GroupHaltOptions{Path: filepath.Join(dir, "halted-groups.json"), Backoff: time.Minute, MaxBackoff: 3 * time.Minute}
+	now := time.Unix(1000, 0)
+	newSyncer := func() *Syncer {
+		sy, err := NewSyncer(nil, nil, nil, 0, 1, nil, nil, nil, nil, nil, 0, 0, "", "")
+		testutil.Ok(t, err)
+		sy.now = func() time.Time { return now }
+		testutil.Ok(t, sy.SetGroupHaltOptions(opts))
+		return sy
+	}
+	g, err := NewGroup(nil, nil, labels.FromStrings("tenant", "a"), 0, nil, false, false, nil, nil, nil, nil, nil, nil, false)
+	testutil.Ok(t, err)
+
+	sy := newSyncer()
+	sy.haltGroup(g.Key(), errors.New("unknown"))
+	testutil.Equals(t, now.Add(time.Minute), sy.HaltedGroups()[0].RetryAt(opts))
+
+	// Halted groups stay halted across restarts until the backoff passed.
+	sy = newSyncer()
+	testutil.Assert(t, sy.isHalted(g), "group not halted after restart")
+	now = now.Add(time.Minute)
+	testutil.Assert(t, !sy.isHalted(g), "group halted after backoff")
+
+	// The backoff doubles with consecutive halts up to the maximum and is kept across restarts.
+	sy.haltGroup(g.Key(), errors.New("unknown"))
+	testutil.Equals(t, now.Add(2*time.Minute), sy.HaltedGroups()[0].RetryAt(opts))
+	now = now.Add(2 * time.Minute)
+	testutil.Assert(t, !sy.isHalted(g), "group halted after backoff")
+	sy = newSyncer()
+	sy.haltGroup(g.Key(), errors.New("unknown"))
+	testutil.Equals(t, 3, sy.HaltedGroups()[0].Halts)
+	testutil.Equals(t, now.Add(3*time.Minute), sy.HaltedGroups()[0].RetryAt(opts))
+
+	// Unhalt requests and successful compactions reset the backoff.
+	testutil.Equals(t, 1, sy.UnhaltGroups())
+	sy.haltGroup(g.Key(), errors.New("unknown"))
+	testutil.Equals(t, 1, sy.HaltedGroups()[0].Halts)
+	now = now.Add(time.Minute)
+	testutil.Assert(t, !sy.isHalted(g), "group halted after backoff")
+	sy.groupCompacted(g.Key())
+	sy = newSyncer()
+	testutil.Equals(t, []HaltedGroup{}, sy.HaltedGroups())
+	sy.haltGroup(g.Key(), errors.New("unknown"))
+	testutil.Equals(t, 1, sy.HaltedGroups()[0].Halts)
+}
+
+type failingDeleteBucket struct {
+	objstore.Bucket
+	failing map[string]struct{}
+}
+
+func (b *failingDeleteBucket) Delete(ctx context.Context, name string) error {
+	if _, ok := b.failing[path.Dir(name)]; ok {
+		return errors.New("delete failed")
+	}
+	return b.Bucket.Delete(ctx, name)
+}
+
+func TestSyncer_GarbageCollect_FailuresAndLimit(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		ids    []ulid.ULID
+		blocks = map[ulid.ULID]*metadata.Meta{}
+		parent = ulid.MustNew(10, nil)
+		bkt    = &failingDeleteBucket{Bucket: inmem.NewBucket(), failing: map[string]struct{}{}}
+	)
+	for i := 0; i < 3; i++ {
+		id := ulid.MustNew(uint64(i), nil)
+		ids = append(ids, id)
+		m := &metadata.Meta{}
+		m.ULID = id
+		m.Compaction.Level = 1
+		m.Compaction.Sources = []ulid.ULID{id}
+		blocks[id] = m
+	}
+	m := &metadata.Meta{}
+	m.ULID = parent
+	m.Compaction.Level = 2
+	m.Compaction.Sources = ids
+	blocks[parent] = m
+	for id := range blocks {
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewReader([]byte("{}"))))
+	}
+
+	sy, err := NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, prometheus.NewCounter(prometheus.CounterOpts{}), 2, 0, "", "")
+	testutil.Ok(t, err)
+	sy.blocks = blocks
+	testutil.Assert(t, sy.LastGarbageCollection() == nil, "garbage collection before first one")
+
+	// The failed deletion of the second block does not abort the garbage collection, the third block is left for the
+	// next garbage collection due to the limit of deletions.
+	bkt.failing[ids[1].String()] = struct{}{}
+	testutil.Ok(t, sy.GarbageCollect(ctx))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.pendingGarbageBlocks))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(sy.metrics.garbageDeletionFailures))
+	testutil.Equals(t, 3, len(sy.Metas()))
+	testutil.Equals(t, map[ulid.ULID]*metadata.Meta{parent: blocks[parent]}, sy.metasToCompact())
+	gc := sy.LastGarbageCollection()
+	testutil.Equals(t, 1, gc.Deleted)
+	testutil.Equals(t, 1, gc.Failed)
+	testutil.Equals(t, 2, gc.Pending)
+	testutil.Equals(t, "", gc.Error)
+
+	delete(bkt.failing, ids[1].String())
+	testutil.Ok(t, sy.GarbageCollect(ctx))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(sy.metrics.pendingGarbageBlocks))
+	testutil.Equals(t, map[ulid.ULID]*metadata.Meta{parent: blocks[parent]}, sy.Metas())
+	testutil.Equals(t, 2, sy.LastGarbageCollection().Deleted)
+
+	for _, id := range ids {
+		ok, err := bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+		testutil.Ok(t, err)
+		testutil.Assert(t, !ok, "block %s not deleted", id)
+	}
+}
+
+func TestSyncer_ExcludeGarbage(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		ids    []ulid.ULID
+		blocks = map[ulid.ULID]*metadata.Meta{}
+		parent = ulid.MustNew(10, nil)
+		bkt    = inmem.NewBucket()
+	)
+	for i := 0; i < 2; i++ {
+		id := ulid.MustNew(uint64(i), nil)
+		ids = append(ids, id)
+		m := &metadata.Meta{}
+		m.ULID = id
+		m.Compaction.Level = 1
+		m.Compaction.Sources = []ulid.ULID{id}
+		blocks[id] = m
+	}
+	m := &metadata.Meta{}
+	m.ULID = parent
+	m.Compaction.Level = 2
+	m.Compaction.Sources = ids
+	blocks[parent] = m
+	for id := range blocks {
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewReader([]byte("{}"))))
+	}
+
+	sy, err := NewSyncer(nil, nil, bkt, 0, 1, nil, nil, nil, nil, prometheus.NewCounter(prometheus.CounterOpts{}), 0, 0, "", "")
+	testutil.Ok(t, err)
+	sy.blocks = blocks
+
+	// Outdated blocks are excluded from compaction, but not deleted.
+	testutil.Ok(t, sy.excludeGarbage())
+	testutil.Equals(t, 2.0, promtest.ToFloat64(sy.metrics.pendingGarbageBlocks))
+	testutil.Equals(t, map[ulid.ULID]*metadata.Meta{parent: blocks[parent]}, sy.metasToCompact())
+	testutil.Equals(t, 3, len(sy.Metas()))
+	testutil.Assert(t, sy.LastGarbageCollection() == nil, "no garbage collection expected")
+	for _, id := range ids {
+		ok, err := bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+		testutil.Ok(t, err)
+		testutil.Assert(t, ok, "block %s deleted", id)
+	}
 }
@@ -0,0 +1,12 @@
+package compact
+
+import "syscall"
+
+// freeDiskSpace returns the number of bytes available to unprivileged users on the filesystem of dir.
+func freeDiskSpace(dir string) (bytes int64, ok bool, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true, nil
+}
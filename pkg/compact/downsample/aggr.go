@@ -72,7 +72,7 @@ func (c AggrChunk) Get(t AggrType) (chunkenc.Chunk, error) {
 
 	for i := AggrType(0); i <= t; i++ {
 		l, n := binary.Uvarint(b)
-		if n < 1 || len(b[n:]) < int(l)+1 {
+		if n < 1 {
 			return nil, errors.New("invalid size")
 		}
 		b = b[n:]
@@ -83,6 +83,9 @@ func (c AggrChunk) Get(t AggrType) (chunkenc.Chunk, error) {
 			}
 			continue
 		}
+		if len(b) < int(l)+1 {
+			return nil, errors.New("invalid size")
+		}
 		x = b[:int(l)+1]
 		b = b[int(l)+1:]
 	}
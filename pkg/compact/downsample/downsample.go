@@ -5,11 +5,13 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	promlabels "github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/value"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
@@ -35,12 +37,14 @@ const (
 )
 
 // Downsample downsamples the given block. It writes a new block into dir and returns its ID.
+// If dropCounterMinMax is true, chunks of counter series, as told by IsCounterSeries, have no min and max aggregates.
 func Downsample(
 	logger log.Logger,
 	origMeta *metadata.Meta,
 	b tsdb.BlockReader,
 	dir string,
 	resolution int64,
+	dropCounterMinMax bool,
 ) (id ulid.ULID, err error) {
 	if origMeta.Thanos.Downsample.Resolution >= resolution {
 		return id, errors.New("target resolution not lower than existing one")
@@ -123,14 +127,16 @@ func Downsample(
 			chks[i].Chunk = chk
 		}
 
+		counter := dropCounterMinMax && IsCounterSeries(lset)
+
 		// Raw and already downsampled data need different processing.
 		if origMeta.Thanos.Downsample.Resolution == 0 {
 			for _, c := range chks {
-				if err := expandChunkIterator(c.Chunk.Iterator(reuseIt), &all); err != nil {
+				if err := expandRawChunkIterator(c.Chunk.Iterator(reuseIt), &all); err != nil {
 					return id, errors.Wrapf(err, "expand chunk %d, series %d", c.Ref, postings.At())
 				}
 			}
-			if err := streamedBlockWriter.WriteSeries(lset, downsampleRaw(all, resolution)); err != nil {
+			if err := streamedBlockWriter.WriteSeries(lset, downsampleRaw(all, resolution, counter)); err != nil {
 				return id, errors.Wrapf(err, "downsample raw data, series: %d", postings.At())
 			}
 		} else {
@@ -145,6 +151,7 @@ func Downsample(
 				chks[len(chks)-1].MaxTime,
 				origMeta.Thanos.Downsample.Resolution,
 				resolution,
+				counter,
 			)
 			if err != nil {
 				return id, errors.Wrapf(err, "downsample aggregate block, series: %d", postings.At())
@@ -162,6 +169,27 @@ func Downsample(
 	return
 }
 
+// IsCounterSeries returns true if the series is a counter by the naming conventions of Prometheus, i.e. its name ends
+// with _total, it is the _count or _sum series of a histogram or summary, or it is a _bucket series of a histogram.
+// The TSDB does not store metric types, so this is the only way to tell counters apart from gauges, and gauges named
+// like counters are misclassified. Min and max aggregates of counters can be dropped on downsampling, as they carry no
+// information on top of the counter aggregate, which is what rate and increase use, and functions like min_over_time
+// treat counters with gauge semantics, not counting counter resets.
+func IsCounterSeries(lset labels.Labels) bool {
+	return IsCounterMetric(lset.Get(promlabels.MetricName), lset.Get(promlabels.BucketLabel))
+}
+
+// IsCounterMetric is like IsCounterSeries for the given metric name and value of the le label.
+func IsCounterMetric(name, le string) bool {
+	switch {
+	case strings.HasSuffix(name, "_total"), strings.HasSuffix(name, "_count"), strings.HasSuffix(name, "_sum"):
+		return true
+	case strings.HasSuffix(name, "_bucket"):
+		return le != ""
+	}
+	return false
+}
+
 // currentWindow returns the end timestamp of the window that t falls into.
 func currentWindow(t, r int64) int64 {
 	// The next timestamp is the next number after s.t that's aligned with window.
@@ -199,14 +227,14 @@ func targetChunkCount(mint, maxt, inRes, outRes int64, count int) (x int) {
 
 // aggregator collects cumulative stats for a stream of values.
 type aggregator struct {
-	total   int     // Total samples processed.
+	total   int     // Total samples processed for the counter state.
 	count   int     // Samples in current window.
 	sum     float64 // Value sum of current window.
 	min     float64 // Min of current window.
 	max     float64 // Max of current window.
 	counter float64 // Total counter state since beginning.
 	resets  int     // Number of counter resets since beginning.
-	last    float64 // Last added value.
+	last    float64 // Last added value that is not NaN.
 }
 
 // reset the stats to start a new aggregation window.
@@ -218,6 +246,21 @@ func (a *aggregator) reset() {
 }
 
 func (a *aggregator) add(v float64) {
+	a.sum += v
+	a.count++
+
+	if v < a.min {
+		a.min = v
+	}
+	if v > a.max {
+		a.max = v
+	}
+
+	// A NaN value carries no information about the counter state. Taking it into account
+	// would turn the counter into NaN for the rest of the series, so we keep the last real value.
+	if math.IsNaN(v) {
+		return
+	}
 	if a.total > 0 {
 		if v < a.last {
 			// Counter reset, correct the value.
@@ -232,17 +275,7 @@ func (a *aggregator) add(v float64) {
 		a.counter = v
 	}
 	a.last = v
-
-	a.sum += v
-	a.count++
 	a.total++
-
-	if v < a.min {
-		a.min = v
-	}
-	if v > a.max {
-		a.max = v
-	}
 }
 
 // aggrChunkBuilder builds chunks for multiple different aggregates.
@@ -254,15 +287,18 @@ type aggrChunkBuilder struct {
 	apps   [5]chunkenc.Appender
 }
 
-func newAggrChunkBuilder() *aggrChunkBuilder {
+// newAggrChunkBuilder returns a builder of all aggregates. Builders of counter series skip min and max aggregates.
+func newAggrChunkBuilder(counter bool) *aggrChunkBuilder {
 	b := &aggrChunkBuilder{
 		mint: math.MaxInt64,
 		maxt: math.MinInt64,
 	}
 	b.chunks[AggrCount] = chunkenc.NewXORChunk()
 	b.chunks[AggrSum] = chunkenc.NewXORChunk()
-	b.chunks[AggrMin] = chunkenc.NewXORChunk()
-	b.chunks[AggrMax] = chunkenc.NewXORChunk()
+	if !counter {
+		b.chunks[AggrMin] = chunkenc.NewXORChunk()
+		b.chunks[AggrMax] = chunkenc.NewXORChunk()
+	}
 	b.chunks[AggrCounter] = chunkenc.NewXORChunk()
 
 	for i, c := range b.chunks {
@@ -281,8 +317,10 @@ func (b *aggrChunkBuilder) add(t int64, aggr *aggregator) {
 		b.maxt = t
 	}
 	b.apps[AggrSum].Append(t, aggr.sum)
-	b.apps[AggrMin].Append(t, aggr.min)
-	b.apps[AggrMax].Append(t, aggr.max)
+	if b.apps[AggrMin] != nil {
+		b.apps[AggrMin].Append(t, aggr.min)
+		b.apps[AggrMax].Append(t, aggr.max)
+	}
 	b.apps[AggrCount].Append(t, float64(aggr.count))
 	b.apps[AggrCounter].Append(t, aggr.counter)
 
@@ -302,7 +340,7 @@ func (b *aggrChunkBuilder) encode() chunks.Meta {
 }
 
 // downsampleRaw create a series of aggregation chunks for the given sample data.
-func downsampleRaw(data []sample, resolution int64) []chunks.Meta {
+func downsampleRaw(data []sample, resolution int64, counter bool) []chunks.Meta {
 	if len(data) == 0 {
 		return nil
 	}
@@ -327,14 +365,18 @@ func downsampleRaw(data []sample, resolution int64) []chunks.Meta {
 		for ; j < len(data) && data[j].t <= curW; j++ {
 		}
 
-		ab := newAggrChunkBuilder()
+		ab := newAggrChunkBuilder(counter)
 		batch := data[:j]
 		data = data[j:]
 
-		lastT := downsampleBatch(batch, resolution, ab.add)
+		var last float64
+		lastT := downsampleBatch(batch, resolution, func(t int64, a *aggregator) {
+			ab.add(t, a)
+			last = a.last
+		})
 
 		// InjectThanosMeta the chunk's counter aggregate with the last true sample.
-		ab.finalizeChunk(lastT, batch[len(batch)-1].v)
+		ab.finalizeChunk(lastT, last)
 
 		chks = append(chks, ab.encode())
 	}
@@ -342,6 +384,26 @@ func downsampleRaw(data []sample, resolution int64) []chunks.Meta {
 	return chks
 }
 
+// DownsampleRawChunks creates a series of aggregation chunks for the given raw chunks of a single series.
+// Chunks must be sorted by time. It allows to aggregate raw data, for which no downsampled block exists yet, on the fly.
+// If counter is true, the chunks have no min and max aggregates.
+func DownsampleRawChunks(chks []chunkenc.Chunk, resolution int64, counter bool) ([]chunks.Meta, error) {
+	var (
+		all     []sample
+		reuseIt chunkenc.Iterator
+	)
+	for _, c := range chks {
+		if c.Encoding() != chunkenc.EncXOR {
+			return nil, errors.Errorf("unexpected chunk encoding %d, only raw chunks can be downsampled", c.Encoding())
+		}
+		reuseIt = c.Iterator(reuseIt)
+		if err := expandRawChunkIterator(reuseIt, &all); err != nil {
+			return nil, errors.Wrap(err, "expand chunk")
+		}
+	}
+	return downsampleRaw(all, resolution, counter), nil
+}
+
 // downsampleBatch aggregates the data over the given resolution and calls add each time
 // the end of a resolution was reached.
 func downsampleBatch(data []sample, resolution int64, add func(int64, *aggregator)) int64 {
@@ -378,7 +440,8 @@ func downsampleBatch(data []sample, resolution int64, add func(int64, *aggregato
 }
 
 // downsampleAggr downsamples a sequence of aggregation chunks to the given resolution.
-func downsampleAggr(chks []*AggrChunk, buf *[]sample, mint, maxt, inRes, outRes int64) ([]chunks.Meta, error) {
+// Min and max aggregates of counter series are dropped.
+func downsampleAggr(chks []*AggrChunk, buf *[]sample, mint, maxt, inRes, outRes int64, counter bool) ([]chunks.Meta, error) {
 	// We downsample aggregates only along chunk boundaries. This is required for counters
 	// to be downsampled correctly since a chunks' last counter value is the true last value
 	// of the original series. We need to preserve it even across multiple aggregation iterations.
@@ -400,7 +463,7 @@ func downsampleAggr(chks []*AggrChunk, buf *[]sample, mint, maxt, inRes, outRes
 		part := chks[:j]
 		chks = chks[j:]
 
-		chk, err := downsampleAggrBatch(part, buf, outRes)
+		chk, err := downsampleAggrBatch(part, buf, outRes, counter)
 		if err != nil {
 			return nil, err
 		}
@@ -429,7 +492,29 @@ func expandChunkIterator(it chunkenc.Iterator, buf *[]sample) error {
 	return it.Err()
 }
 
-func downsampleAggrBatch(chks []*AggrChunk, buf *[]sample, resolution int64) (chk chunks.Meta, err error) {
+// expandRawChunkIterator reads all samples from the raw chunk iterator and appends them to buf.
+// Chunks of a series may overlap, so all samples that do not move forward in time with
+// regard to the samples already in buf are skipped. Otherwise a sample going back in time
+// with a lower value is taken for a counter reset, which shows up as a spike in the rate
+// over the downsampled counter.
+// Staleness markers are skipped as well. They only mark the end of a series in the raw data
+// and the aggregates of a window are not affected by them. A counter restarting after a
+// gap is still detected as a reset by comparing with the last sample before the gap.
+func expandRawChunkIterator(it chunkenc.Iterator, buf *[]sample) error {
+	for it.Next() {
+		t, v := it.At()
+		if value.IsStaleNaN(v) {
+			continue
+		}
+		if len(*buf) > 0 && t <= (*buf)[len(*buf)-1].t {
+			continue
+		}
+		*buf = append(*buf, sample{t, v})
+	}
+	return it.Err()
+}
+
+func downsampleAggrBatch(chks []*AggrChunk, buf *[]sample, resolution int64, counter bool) (chk chunks.Meta, err error) {
 	ab := &aggrChunkBuilder{}
 	mint, maxt := int64(math.MaxInt64), int64(math.MinInt64)
 	var reuseIt chunkenc.Iterator
@@ -476,15 +561,17 @@ func downsampleAggrBatch(chks []*AggrChunk, buf *[]sample, resolution int64) (ch
 	}); err != nil {
 		return chk, err
 	}
-	if err := do(AggrMin, func(a *aggregator) float64 {
-		return a.min
-	}); err != nil {
-		return chk, err
-	}
-	if err := do(AggrMax, func(a *aggregator) float64 {
-		return a.max
-	}); err != nil {
-		return chk, err
+	if !counter {
+		if err := do(AggrMin, func(a *aggregator) float64 {
+			return a.min
+		}); err != nil {
+			return chk, err
+		}
+		if err := do(AggrMax, func(a *aggregator) float64 {
+			return a.max
+		}); err != nil {
+			return chk, err
+		}
 	}
 
 	// Handle counters by reading them properly.
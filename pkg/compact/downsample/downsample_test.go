@@ -40,6 +40,58 @@ func TestExpandChunkIterator(t *testing.T) {
 	testutil.Equals(t, []sample{{100, 1}, {200, 2}, {200, 3}, {201, 4}, {300, 6}, {500, 5}}, res)
 }
 
+func TestExpandRawChunkIterator(t *testing.T) {
+	// Validate that expanding overlapping raw chunks filters samples that do not move forward
+	// in time across chunk boundaries and staleness markers.
+	var res []sample
+	testutil.Ok(t,
+		expandRawChunkIterator(
+			newSampleIterator([]sample{
+				{100, 1}, {200, 2}, {300, math.Float64frombits(value.StaleNaN)}, {400, 4},
+			}), &res,
+		),
+	)
+	testutil.Ok(t,
+		expandRawChunkIterator(
+			newSampleIterator([]sample{
+				{300, 3}, {400, 5}, {500, 6},
+			}), &res,
+		),
+	)
+
+	testutil.Equals(t, []sample{{100, 1}, {200, 2}, {400, 4}, {500, 6}}, res)
+}
+
+func TestDownsampleRawChunks(t *testing.T) {
+	encode := func(in []sample) chunkenc.Chunk {
+		chk := chunkenc.NewXORChunk()
+		app, _ := chk.Appender()
+		for _, s := range in {
+			app.Append(s.t, s.v)
+		}
+		return chk
+	}
+	chks, err := DownsampleRawChunks([]chunkenc.Chunk{
+		encode([]sample{{20, 1}, {40, 2}, {60, 3}, {80, 4}}),
+		// Overlapping chunk with a lower value in the past must not be taken for a counter reset.
+		// A NaN value must not break the counter state and a counter reset after a staleness
+		// marker is detected.
+		encode([]sample{
+			{40, 1}, {100, 5}, {120, math.NaN()}, {140, 6},
+			{150, math.Float64frombits(value.StaleNaN)}, {180, 2},
+		}),
+	}, 100, false)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(chks))
+
+	c, err := chks[0].Chunk.(*AggrChunk).Get(AggrCounter)
+	testutil.Ok(t, err)
+
+	var res []sample
+	testutil.Ok(t, expandChunkIterator(c.Iterator(nil), &res))
+	testutil.Equals(t, []sample{{99, 4}, {180, 8}, {180, 2}}, res)
+}
+
 func TestDownsampleRaw(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -59,8 +111,56 @@ func TestDownsampleRaw(t *testing.T) {
 				AggrCounter: {{99, 4}, {199, 13}, {250, 14}, {250, 1}},
 			},
 		},
+		{
+			// Histogram buckets are counters and have no min and max aggregates.
+			lset: labels.FromStrings("__name__", "a_bucket", "le", "0.5"),
+			inRaw: []sample{
+				{20, 1}, {40, 2}, {60, 3}, {80, 1}, {100, 2}, {101, staleMarker}, {120, 5}, {180, 10}, {250, 1},
+			},
+			output: map[AggrType][]sample{
+				AggrCount:   {{99, 4}, {199, 3}, {250, 1}},
+				AggrSum:     {{99, 7}, {199, 17}, {250, 1}},
+				AggrCounter: {{99, 4}, {199, 13}, {250, 14}, {250, 1}},
+			},
+		},
+	}
+	testDownsample(t, input, &metadata.Meta{BlockMeta: tsdb.BlockMeta{MinTime: 0, MaxTime: 250}, Thanos: metadata.Thanos{Source: metadata.SidecarSource}}, 100, true)
+
+	// Counters keep their min and max aggregates, unless dropped on demand.
+	input = []*downsampleTestSet{
+		{
+			lset: labels.FromStrings("__name__", "a_bucket", "le", "0.5"),
+			inRaw: []sample{
+				{20, 1}, {40, 2}, {60, 3}, {80, 1}, {100, 2}, {101, staleMarker}, {120, 5}, {180, 10}, {250, 1},
+			},
+			output: map[AggrType][]sample{
+				AggrCount:   {{99, 4}, {199, 3}, {250, 1}},
+				AggrSum:     {{99, 7}, {199, 17}, {250, 1}},
+				AggrMin:     {{99, 1}, {199, 2}, {250, 1}},
+				AggrMax:     {{99, 3}, {199, 10}, {250, 1}},
+				AggrCounter: {{99, 4}, {199, 13}, {250, 14}, {250, 1}},
+			},
+		},
+	}
+	testDownsample(t, input, &metadata.Meta{BlockMeta: tsdb.BlockMeta{MinTime: 0, MaxTime: 250}, Thanos: metadata.Thanos{Source: metadata.SidecarSource}}, 100, false)
+}
+
+func TestIsCounterSeries(t *testing.T) {
+	for _, tcase := range []struct {
+		lset labels.Labels
+		exp  bool
+	}{
+		{lset: labels.FromStrings("__name__", "http_requests_total"), exp: true},
+		{lset: labels.FromStrings("__name__", "rpc_duration_seconds_count"), exp: true},
+		{lset: labels.FromStrings("__name__", "rpc_duration_seconds_sum"), exp: true},
+		{lset: labels.FromStrings("__name__", "rpc_duration_seconds_bucket", "le", "+Inf"), exp: true},
+		{lset: labels.FromStrings("__name__", "rpc_duration_seconds_bucket"), exp: false},
+		{lset: labels.FromStrings("__name__", "rpc_duration_seconds", "quantile", "0.9"), exp: false},
+		{lset: labels.FromStrings("__name__", "up"), exp: false},
+		{lset: labels.FromStrings("job", "a_total"), exp: false},
+	} {
+		testutil.Equals(t, tcase.exp, IsCounterSeries(tcase.lset))
 	}
-	testDownsample(t, input, &metadata.Meta{BlockMeta: tsdb.BlockMeta{MinTime: 0, MaxTime: 250}}, 100)
 }
 
 func TestDownsampleAggr(t *testing.T) {
@@ -96,16 +196,34 @@ func TestDownsampleAggr(t *testing.T) {
 				AggrCounter: {{499, 210}, {999, 320}, {1299, 430}, {1299, 110}},
 			},
 		},
+		{
+			// Min and max aggregates of counters downsampled before they were dropped are not carried over.
+			lset: labels.FromStrings("__name__", "a_total"),
+			inAggr: map[AggrType][]sample{
+				AggrCount: {{199, 5}, {299, 1}, {399, 10}},
+				AggrSum:   {{199, 5}, {299, 1}, {399, 10}},
+				AggrMin:   {{199, 5}, {299, 1}, {399, 10}},
+				AggrMax:   {{199, 5}, {299, 1}, {399, 10}},
+				AggrCounter: {
+					{99, 100}, {299, 150}, {399, 210}, {399, 10},
+				},
+			},
+			output: map[AggrType][]sample{
+				AggrCount:   {{399, 16}},
+				AggrSum:     {{399, 16}},
+				AggrCounter: {{399, 210}, {399, 10}},
+			},
+		},
 	}
 	var meta metadata.Meta
 	meta.Thanos.Downsample.Resolution = 10
 	meta.BlockMeta = tsdb.BlockMeta{MinTime: 99, MaxTime: 1300}
 
-	testDownsample(t, input, &meta, 500)
+	testDownsample(t, input, &meta, 500, true)
 }
 
 func encodeTestAggrSeries(v map[AggrType][]sample) chunks.Meta {
-	b := newAggrChunkBuilder()
+	b := newAggrChunkBuilder(false)
 
 	for at, d := range v {
 		for _, s := range d {
@@ -125,7 +243,7 @@ type downsampleTestSet struct {
 
 // testDownsample inserts the input into a block and invokes the downsampler with the given resolution.
 // The chunk ranges within the input block are aligned at 500 time units.
-func testDownsample(t *testing.T, data []*downsampleTestSet, meta *metadata.Meta, resolution int64) {
+func testDownsample(t *testing.T, data []*downsampleTestSet, meta *metadata.Meta, resolution int64, dropCounterMinMax bool) {
 	t.Helper()
 
 	dir, err := ioutil.TempDir("", "downsample-raw")
@@ -160,11 +278,13 @@ func testDownsample(t *testing.T, data []*downsampleTestSet, meta *metadata.Meta
 		mb.addSeries(ser)
 	}
 
-	id, err := Downsample(log.NewNopLogger(), meta, mb, dir, resolution)
+	id, err := Downsample(log.NewNopLogger(), meta, mb, dir, resolution, dropCounterMinMax)
 	testutil.Ok(t, err)
 
-	_, err = metadata.Read(filepath.Join(dir, id.String()))
+	newMeta, err := metadata.Read(filepath.Join(dir, id.String()))
 	testutil.Ok(t, err)
+	testutil.Equals(t, metadata.CompactorSource, newMeta.Thanos.Source)
+	testutil.Equals(t, meta.Thanos.OriginSource(), newMeta.Thanos.Origin)
 
 	exp := map[uint64]map[AggrType][]sample{}
 	got := map[uint64]map[AggrType][]sample{}
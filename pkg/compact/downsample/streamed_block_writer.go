@@ -269,6 +269,7 @@ func (w *streamedBlockWriter) writeMemPostings() error {
 // writeMetaFile writes meta file.
 func (w *streamedBlockWriter) writeMetaFile() error {
 	w.meta.Version = metadata.MetaVersion1
+	w.meta.Thanos.Origin = w.meta.Thanos.OriginSource()
 	w.meta.Thanos.Source = metadata.CompactorSource
 	w.meta.Stats.NumChunks = w.totalChunks
 	w.meta.Stats.NumSamples = w.totalSamples
@@ -0,0 +1,115 @@
+package compact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+// CompactionLimits limit the size of blocks created by compaction. Zero values mean no limit.
+//
+// Blocks do not record their size, so the size of a compacted block is estimated from the stats of the compacted
+// blocks: samples are summed, series are summed as well, which overestimates series present in multiple blocks, and
+// bytes are estimated from both.
+type CompactionLimits struct {
+	MaxBlockBytes int64
+	MaxSeries     int64
+	MaxSamples    int64
+}
+
+func (l CompactionLimits) enabled() bool {
+	return l.MaxBlockBytes > 0 || l.MaxSeries > 0 || l.MaxSamples > 0
+}
+
+// exceeded returns a description of the limit exceeded by a block of the given stats, or "" if none is exceeded.
+func (l CompactionLimits) exceeded(bytes, series, samples int64) string {
+	switch {
+	case l.MaxBlockBytes > 0 && bytes > l.MaxBlockBytes:
+		return fmt.Sprintf("estimated size of %d bytes exceeds limit of %d bytes", bytes, l.MaxBlockBytes)
+	case l.MaxSeries > 0 && series > l.MaxSeries:
+		return fmt.Sprintf("%d series exceed limit of %d series", series, l.MaxSeries)
+	case l.MaxSamples > 0 && samples > l.MaxSamples:
+		return fmt.Sprintf("%d samples exceed limit of %d samples", samples, l.MaxSamples)
+	}
+	return ""
+}
+
+// planWithinLimits plans the next compaction of the blocks written into dir with the TSDB compactor, keeping the
+// compacted block within the limits. A plan exceeding the limits is cut to its longest prefix within the limits. If not
+// even the first two blocks of a plan fit, the first block is removed from dir and planning is repeated without it,
+// so the following blocks are compacted into a separate block instead of one exceeding the limits. Plans of overlapping
+// blocks are not limited, as cutting them would leave blocks overlapping.
+func (cg *Group) planWithinLimits(dir string, comp tsdb.Compactor, limits CompactionLimits) ([]string, error) {
+	for {
+		plan, err := comp.Plan(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "plan compaction")
+		}
+		if len(plan) == 0 || !limits.enabled() {
+			return plan, nil
+		}
+
+		metas := make([]*metadata.Meta, 0, len(plan))
+		for _, pdir := range plan {
+			meta, err := metadata.Read(pdir)
+			if err != nil {
+				return nil, errors.Wrapf(err, "read meta from %s", pdir)
+			}
+			metas = append(metas, meta)
+		}
+		sort.Sort(planByMinTime{plan: plan, metas: metas})
+		for i := 1; i < len(metas); i++ {
+			if metas[i].MinTime < metas[i-1].MaxTime {
+				return plan, nil
+			}
+		}
+
+		var (
+			n                      int
+			bytes, series, samples int64
+			exceeded               string
+		)
+		for ; n < len(metas); n++ {
+			m := metas[n]
+			bytes += estimatedBlockBytes(m)
+			series += int64(m.Stats.NumSeries)
+			samples += int64(m.Stats.NumSamples)
+			if exceeded = limits.exceeded(bytes, series, samples); exceeded != "" {
+				break
+			}
+		}
+		if n == len(plan) {
+			return plan, nil
+		}
+		if n >= 2 {
+			level.Info(cg.logger).Log("msg", "cutting compaction plan to keep compacted block within limits", "group", cg.Key(),
+				"plan", fmt.Sprintf("%v", plan[:n]), "excluded", fmt.Sprintf("%v", plan[n:]), "reason", exceeded)
+			return plan[:n], nil
+		}
+
+		level.Info(cg.logger).Log("msg", "excluding block from compaction to keep compacted block within limits", "group", cg.Key(),
+			"block", metas[0].ULID, "reason", exceeded)
+		if err := os.RemoveAll(plan[0]); err != nil {
+			return nil, errors.Wrapf(err, "remove planning dir of block %s", filepath.Base(plan[0]))
+		}
+	}
+}
+
+// planByMinTime sorts plan dirs together with their metas by min time.
+type planByMinTime struct {
+	plan  []string
+	metas []*metadata.Meta
+}
+
+func (p planByMinTime) Len() int           { return len(p.plan) }
+func (p planByMinTime) Less(i, j int) bool { return p.metas[i].MinTime < p.metas[j].MinTime }
+func (p planByMinTime) Swap(i, j int) {
+	p.plan[i], p.plan[j] = p.plan[j], p.plan[i]
+	p.metas[i], p.metas[j] = p.metas[j], p.metas[i]
+}
@@ -0,0 +1,89 @@
+package compact
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestGroup_PlanWithinLimits(t *testing.T) {
+	const h = int64(time.Hour / time.Millisecond)
+
+	comp, err := tsdb.NewLeveledCompactor(context.Background(), nil, log.NewNopLogger(), []int64{2 * h, 8 * h}, nil)
+	testutil.Ok(t, err)
+	g, err := NewGroup(log.NewNopLogger(), nil, nil, 0, nil, false, false,
+		prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}),
+		prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}),
+		prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}), false,
+	)
+	testutil.Ok(t, err)
+
+	// planDir writes metas of blocks with the given min times and samples into a new planning dir.
+	planDir := func(mints []int64, samples []uint64) string {
+		dir, err := ioutil.TempDir("", "test-plan-within-limits")
+		testutil.Ok(t, err)
+		for i, mint := range mints {
+			m := &metadata.Meta{}
+			m.ULID = ulid.MustNew(uint64(i), nil)
+			m.Version = metadata.MetaVersion1
+			m.MinTime, m.MaxTime = mint, mint+2*h
+			m.Stats.NumSamples = samples[i]
+			m.Compaction.Level = 1
+			m.Compaction.Sources = []ulid.ULID{m.ULID}
+			bdir := filepath.Join(dir, m.ULID.String())
+			testutil.Ok(t, os.MkdirAll(bdir, 0777))
+			testutil.Ok(t, metadata.Write(log.NewNopLogger(), bdir, m))
+		}
+		return dir
+	}
+	ids := func(plan []string) (res []uint64) {
+		for _, pdir := range plan {
+			res = append(res, ulid.MustParse(filepath.Base(pdir)).Time())
+		}
+		return res
+	}
+
+	var (
+		mints   = []int64{0, 2 * h, 4 * h, 6 * h, 8 * h, 10 * h, 12 * h, 14 * h, 16 * h}
+		samples = []uint64{100, 100, 100, 100, 100, 100, 100, 100, 100}
+	)
+	for _, tcase := range []struct {
+		name    string
+		mints   []int64
+		samples []uint64
+		limits  CompactionLimits
+		exp     []uint64
+	}{
+		{name: "no limits", mints: mints, samples: samples, exp: []uint64{0, 1, 2, 3}},
+		{name: "within limits", mints: mints, samples: samples, limits: CompactionLimits{MaxSamples: 400}, exp: []uint64{0, 1, 2, 3}},
+		{name: "plan is cut", mints: mints, samples: samples, limits: CompactionLimits{MaxSamples: 250}, exp: []uint64{0, 1}},
+		{name: "byte limit", mints: mints, samples: samples, limits: CompactionLimits{MaxBlockBytes: 3 * 100 * estimatedBytesPerSample}, exp: []uint64{0, 1, 2}},
+		{
+			name: "full block is excluded", mints: mints, samples: []uint64{400, 100, 100, 100, 100, 100, 100, 100, 100},
+			limits: CompactionLimits{MaxSamples: 350}, exp: []uint64{1, 2, 3},
+		},
+		{
+			name: "overlapping blocks are not limited", mints: []int64{0, h, 8 * h}, samples: []uint64{400, 400, 100},
+			limits: CompactionLimits{MaxSamples: 350}, exp: []uint64{0, 1},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			dir := planDir(tcase.mints, tcase.samples)
+			defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+			plan, err := g.planWithinLimits(dir, comp, tcase.limits)
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.exp, ids(plan))
+		})
+	}
+}
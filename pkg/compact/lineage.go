@@ -0,0 +1,180 @@
+package compact
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+)
+
+// Types of edges of the lineage.
+const (
+	LineageCompacted   = "compacted"
+	LineageDownsampled = "downsampled"
+)
+
+// LineageBlock is a block of the lineage.
+type LineageBlock struct {
+	ID         ulid.ULID           `json:"id"`
+	Level      int                 `json:"level,omitempty"`
+	Resolution int64               `json:"resolution"`
+	MinTime    int64               `json:"minTime,omitempty"`
+	MaxTime    int64               `json:"maxTime,omitempty"`
+	Labels     map[string]string   `json:"labels,omitempty"`
+	Source     metadata.SourceType `json:"source,omitempty"`
+	// Sources are the blocks of compaction level 1 the block was compacted from.
+	Sources []ulid.ULID `json:"sources,omitempty"`
+	// Missing blocks are parents of known blocks which are not in the bucket anymore, e.g. deleted by garbage collection.
+	// Only their ID is known.
+	Missing bool `json:"missing,omitempty"`
+	// Garbage blocks are deleted by the next garbage collection, as their data is part of blocks of higher level.
+	Garbage bool `json:"garbage,omitempty"`
+}
+
+// LineageEdge connects a block to a block created from it.
+type LineageEdge struct {
+	From ulid.ULID `json:"from"`
+	To   ulid.ULID `json:"to"`
+	Type string    `json:"type"`
+}
+
+// Lineage is the graph of blocks created by compaction and downsampling from other blocks.
+type Lineage struct {
+	Blocks []LineageBlock `json:"blocks"`
+	Edges  []LineageEdge  `json:"edges"`
+}
+
+// NewLineage returns the lineage of the given blocks. Compacted blocks are connected to their parents, downsampled
+// blocks to the block of the previous resolution they were downsampled from, i.e. the block with the same sources.
+func NewLineage(metas map[ulid.ULID]*metadata.Meta, garbage map[ulid.ULID]struct{}) *Lineage {
+	l := &Lineage{Blocks: []LineageBlock{}, Edges: []LineageEdge{}}
+
+	// Downsampled blocks keep compaction metadata of the block they were downsampled from.
+	bySources := map[string]ulid.ULID{}
+	for id, m := range metas {
+		bySources[sourcesKey(m, m.Thanos.Downsample.Resolution)] = id
+	}
+
+	missing := map[ulid.ULID]struct{}{}
+	for id, m := range metas {
+		_, isGarbage := garbage[id]
+		l.Blocks = append(l.Blocks, LineageBlock{
+			ID:         id,
+			Level:      m.Compaction.Level,
+			Resolution: m.Thanos.Downsample.Resolution,
+			MinTime:    m.MinTime,
+			MaxTime:    m.MaxTime,
+			Labels:     m.Thanos.Labels,
+			Source:     m.Thanos.Source,
+			Sources:    m.Compaction.Sources,
+			Garbage:    isGarbage,
+		})
+
+		if res, ok := previousResolution(m.Thanos.Downsample.Resolution); ok {
+			if from, ok := bySources[sourcesKey(m, res)]; ok {
+				l.Edges = append(l.Edges, LineageEdge{From: from, To: id, Type: LineageDownsampled})
+			}
+			continue
+		}
+		for _, p := range m.Compaction.Parents {
+			l.Edges = append(l.Edges, LineageEdge{From: p.ULID, To: id, Type: LineageCompacted})
+			if _, ok := metas[p.ULID]; !ok {
+				missing[p.ULID] = struct{}{}
+			}
+		}
+	}
+	for id := range missing {
+		l.Blocks = append(l.Blocks, LineageBlock{ID: id, Missing: true})
+	}
+
+	sort.Slice(l.Blocks, func(i, j int) bool {
+		return l.Blocks[i].ID.Compare(l.Blocks[j].ID) < 0
+	})
+	sort.Slice(l.Edges, func(i, j int) bool {
+		if l.Edges[i].To != l.Edges[j].To {
+			return l.Edges[i].To.Compare(l.Edges[j].To) < 0
+		}
+		return l.Edges[i].From.Compare(l.Edges[j].From) < 0
+	})
+	return l
+}
+
+// sourcesKey identifies blocks of the given resolution with the same data as the block, i.e. blocks with the same
+// labels compacted from the same sources.
+func sourcesKey(m *metadata.Meta, res int64) string {
+	ids := make([]string, 0, len(m.Compaction.Sources))
+	for _, s := range m.Compaction.Sources {
+		ids = append(ids, s.String())
+	}
+	sort.Strings(ids)
+	return groupKey(res, labels.FromMap(m.Thanos.Labels), m.Thanos.Shard) + "/" + strings.Join(ids, ",")
+}
+
+// previousResolution returns the resolution blocks of the given resolution are downsampled from.
+func previousResolution(res int64) (int64, bool) {
+	switch res {
+	case downsample.ResLevel1:
+		return downsample.ResLevel0, true
+	case downsample.ResLevel2:
+		return downsample.ResLevel1, true
+	}
+	return 0, false
+}
+
+// WriteDOT writes the lineage in the DOT language of Graphviz. Missing blocks are dashed, garbage blocks are grey.
+func (l *Lineage) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range l.Blocks {
+		if n.Missing {
+			fmt.Fprintf(&b, "  %q [label=%q, style=dashed];\n", n.ID.String(), n.ID.String()+"\nmissing")
+			continue
+		}
+		label := fmt.Sprintf("%s\nlevel %d, resolution %s\n%s - %s",
+			n.ID, n.Level, time.Duration(n.Resolution)*time.Millisecond,
+			time.Unix(0, n.MinTime*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+			time.Unix(0, n.MaxTime*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+		)
+		attrs := ""
+		if n.Garbage {
+			attrs = ", style=filled, fillcolor=grey"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q%s];\n", n.ID.String(), label, attrs)
+	}
+	for _, e := range l.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.From.String(), e.To.String(), e.Type)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err, "write lineage")
+}
+
+// Lineage returns the lineage of the synced blocks. Garbage blocks are the ones to be deleted by the next garbage
+// collection.
+func (c *Syncer) Lineage() (*Lineage, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	garbage := map[ulid.ULID]struct{}{}
+	for _, res := range []int64{
+		downsample.ResLevel0, downsample.ResLevel1, downsample.ResLevel2,
+	} {
+		ids, err := c.GarbageBlocks(res)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get garbage blocks of resolution %d", res)
+		}
+		for _, id := range ids {
+			garbage[id] = struct{}{}
+		}
+	}
+	return NewLineage(c.blocks, garbage), nil
+}
@@ -0,0 +1,63 @@
+package compact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestSyncer_Lineage(t *testing.T) {
+	var (
+		lset = map[string]string{"a": "1"}
+		src1 = ulid.MustNew(1, nil)
+		src2 = ulid.MustNew(2, nil)
+		src3 = ulid.MustNew(3, nil)
+		comp = ulid.MustNew(4, nil)
+		down = ulid.MustNew(5, nil)
+	)
+	meta := func(id ulid.ULID, level int, res int64, sources []ulid.ULID, parents ...ulid.ULID) *metadata.Meta {
+		m := &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: 0, MaxTime: 7200000, Compaction: tsdb.BlockMetaCompaction{Level: level, Sources: sources}},
+			Thanos:    metadata.Thanos{Labels: lset, Downsample: metadata.ThanosDownsample{Resolution: res}},
+		}
+		for _, p := range parents {
+			m.Compaction.Parents = append(m.Compaction.Parents, tsdb.BlockDesc{ULID: p})
+		}
+		return m
+	}
+	// Source block 1 was deleted already, source block 2 is deleted by the next garbage collection.
+	sy := &Syncer{blocks: map[ulid.ULID]*metadata.Meta{
+		src2: meta(src2, 1, downsample.ResLevel0, []ulid.ULID{src2}),
+		src3: meta(src3, 1, downsample.ResLevel0, []ulid.ULID{src3}),
+		comp: meta(comp, 2, downsample.ResLevel0, []ulid.ULID{src1, src2}, src1, src2),
+		down: meta(down, 2, downsample.ResLevel1, []ulid.ULID{src1, src2}, src1, src2),
+	}}
+
+	l, err := sy.Lineage()
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, 5, len(l.Blocks))
+	testutil.Equals(t, LineageBlock{ID: src1, Missing: true}, l.Blocks[0])
+	testutil.Assert(t, l.Blocks[1].Garbage, "source block 2 is not garbage")
+	testutil.Assert(t, !l.Blocks[2].Garbage, "source block 3 is garbage")
+	testutil.Equals(t, []ulid.ULID{src1, src2}, l.Blocks[3].Sources)
+	testutil.Equals(t, []LineageEdge{
+		{From: src1, To: comp, Type: LineageCompacted},
+		{From: src2, To: comp, Type: LineageCompacted},
+		{From: comp, To: down, Type: LineageDownsampled},
+	}, l.Edges)
+
+	var b bytes.Buffer
+	testutil.Ok(t, l.WriteDOT(&b))
+	dot := b.String()
+	testutil.Assert(t, strings.HasPrefix(dot, "digraph lineage {\n"), dot)
+	testutil.Assert(t, strings.Contains(dot, `"`+src1.String()+`" [label="`+src1.String()+`\nmissing", style=dashed];`), dot)
+	testutil.Assert(t, strings.Contains(dot, `"`+comp.String()+`" -> "`+down.String()+`" [label="downsampled"];`), dot)
+	testutil.Assert(t, strings.Contains(dot, `\nlevel 2, resolution 5m0s\n1970-01-01T00:00:00Z - 1970-01-01T02:00:00Z"`), dot)
+}
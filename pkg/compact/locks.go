@@ -0,0 +1,33 @@
+package compact
+
+import "sync"
+
+// GroupLocks are locks of compaction groups by group key. They coordinate work on blocks of the same group running
+// concurrently, e.g. compaction and downsampling of the group, while work on different groups is not blocked.
+type GroupLocks struct {
+	mtx   sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewGroupLocks returns new group locks.
+func NewGroupLocks() *GroupLocks {
+	return &GroupLocks{locks: map[string]*sync.Mutex{}}
+}
+
+// Lock locks the group of the given key, waiting until it is unlocked, and returns the function unlocking it.
+// Nil group locks do not lock at all.
+func (l *GroupLocks) Lock(key string) (unlock func()) {
+	if l == nil {
+		return func() {}
+	}
+	l.mtx.Lock()
+	m, ok := l.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[key] = m
+	}
+	l.mtx.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
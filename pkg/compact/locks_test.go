@@ -0,0 +1,34 @@
+package compact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestGroupLocks(t *testing.T) {
+	l := NewGroupLocks()
+
+	unlock := l.Lock("a")
+	// Other groups are not blocked.
+	l.Lock("b")()
+
+	locked := make(chan struct{})
+	go func() {
+		l.Lock("a")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("group locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+
+	var nilLocks *GroupLocks
+	nilLocks.Lock("a")()
+	nilLocks.Lock("a")()
+	testutil.Equals(t, 2, len(l.locks))
+}
@@ -0,0 +1,74 @@
+package compact
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+// cachedMeta returns the meta of the block from the meta cache directory, nil if it is not cached or the cache is
+// disabled. Meta files of blocks are never changed once uploaded, so cached metas stay valid until the block is
+// deleted.
+func (c *Syncer) cachedMeta(id ulid.ULID) *metadata.Meta {
+	if c.metaCacheDir == "" {
+		return nil
+	}
+	dir := filepath.Join(c.metaCacheDir, id.String())
+	meta, err := metadata.Read(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Warn(c.logger).Log("msg", "removing unreadable cached meta", "block", id, "err", err)
+			if err := os.RemoveAll(dir); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to remove cached meta", "block", id, "err", err)
+			}
+		}
+		return nil
+	}
+	c.metrics.metaCacheHits.Inc()
+	return meta
+}
+
+// cacheMeta writes the meta of the block to the meta cache directory, if enabled. Failures are logged only, as the
+// meta is downloaded again on the next sync.
+func (c *Syncer) cacheMeta(meta *metadata.Meta) {
+	if c.metaCacheDir == "" {
+		return
+	}
+	dir := filepath.Join(c.metaCacheDir, meta.ULID.String())
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to create meta cache directory", "block", meta.ULID, "err", err)
+		return
+	}
+	if err := metadata.Write(c.logger, dir, meta); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to cache meta", "block", meta.ULID, "err", err)
+	}
+}
+
+// cleanMetaCache removes cached metas of blocks which are not in the bucket anymore.
+func (c *Syncer) cleanMetaCache(remote map[ulid.ULID]struct{}) {
+	if c.metaCacheDir == "" {
+		return
+	}
+	fis, err := ioutil.ReadDir(c.metaCacheDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Warn(c.logger).Log("msg", "failed to read meta cache directory", "err", err)
+		}
+		return
+	}
+	for _, fi := range fis {
+		id, err := ulid.Parse(fi.Name())
+		if err == nil {
+			if _, ok := remote[id]; ok {
+				continue
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(c.metaCacheDir, fi.Name())); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to remove cached meta", "name", fi.Name(), "err", err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package compact
+
+import (
+	"sort"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+)
+
+const (
+	// estimatedBytesPerSample and estimatedBytesPerSeries are rough averages of chunk bytes per sample and index bytes
+	// per series of TSDB blocks, used to estimate sizes of blocks, which are not part of their metas.
+	estimatedBytesPerSample = 2
+	estimatedBytesPerSeries = 256
+)
+
+// estimatedBlockBytes returns the estimated size of the block in bytes.
+func estimatedBlockBytes(m *metadata.Meta) int64 {
+	return int64(m.Stats.NumSamples)*estimatedBytesPerSample + int64(m.Stats.NumSeries)*estimatedBytesPerSeries
+}
+
+// GroupProgress is the estimated remaining work of a compaction group, as planned based on the blocks of the group
+// known to the syncer. Blocks produced later, e.g. uploaded by sidecars meanwhile, are not considered.
+type GroupProgress struct {
+	Group string `json:"group"`
+	// Compactions is the number of compactions left.
+	Compactions int `json:"compactions"`
+	// Levels is the number of compaction ranges with compactions left. Compactions of overlapping blocks do not count.
+	Levels int `json:"levels"`
+	// Bytes is the estimated number of bytes of blocks to be compacted, summed over all compactions left.
+	Bytes int64 `json:"bytes"`
+	// Downsamplings is the number of downsamplings of the group's blocks left, once compacted.
+	Downsamplings int `json:"downsamplings"`
+}
+
+type progressMetrics struct {
+	compactions   *prometheus.GaugeVec
+	levels        *prometheus.GaugeVec
+	bytes         *prometheus.GaugeVec
+	downsamplings *prometheus.GaugeVec
+}
+
+func newProgressMetrics(reg prometheus.Registerer) *progressMetrics {
+	m := &progressMetrics{
+		compactions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_compact_todo_compactions",
+			Help: "Estimated number of compactions left for the group.",
+		}, []string{"group"}),
+		levels: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_compact_todo_compaction_levels",
+			Help: "Estimated number of compaction ranges with compactions left for the group.",
+		}, []string{"group"}),
+		bytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_compact_todo_compaction_bytes",
+			Help: "Estimated number of bytes of blocks to be compacted for the group, summed over all compactions left.",
+		}, []string{"group"}),
+		downsamplings: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_compact_todo_downsamplings",
+			Help: "Estimated number of downsamplings of blocks of the group left, once compacted.",
+		}, []string{"group"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.compactions, m.levels, m.bytes, m.downsamplings)
+	}
+	return m
+}
+
+func (m *progressMetrics) set(progress []GroupProgress) {
+	m.compactions.Reset()
+	m.levels.Reset()
+	m.bytes.Reset()
+	m.downsamplings.Reset()
+	for _, p := range progress {
+		m.compactions.WithLabelValues(p.Group).Set(float64(p.Compactions))
+		m.levels.WithLabelValues(p.Group).Set(float64(p.Levels))
+		m.bytes.WithLabelValues(p.Group).Set(float64(p.Bytes))
+		m.downsamplings.WithLabelValues(p.Group).Set(float64(p.Downsamplings))
+	}
+}
+
+// Progress returns the estimated remaining work of all compaction groups, sorted by group key.
+func (c *BucketCompactor) Progress() ([]GroupProgress, error) {
+	groups, err := c.grouper.Groups(c.sy.metasToCompact())
+	if err != nil {
+		return nil, err
+	}
+	return estimateProgress(groups, c.sy.Metas(), c.ranges), nil
+}
+
+// estimateProgress estimates the remaining work of the groups. Compactions are simulated like planned by the TSDB
+// compactor with the given ranges. Downsamplings are estimated like the downsampler would downsample the compacted
+// blocks, considering blocks of all given metas.
+func estimateProgress(groups []*Group, metas map[ulid.ULID]*metadata.Meta, ranges []int64) []GroupProgress {
+	sources5m, sources1h := map[sourceShard]struct{}{}, map[sourceShard]struct{}{}
+	for _, m := range metas {
+		switch m.Thanos.Downsample.Resolution {
+		case downsample.ResLevel1:
+			for _, id := range m.Compaction.Sources {
+				sources5m[sourceShard{id: id, shard: m.Thanos.Shard.String()}] = struct{}{}
+			}
+		case downsample.ResLevel2:
+			for _, id := range m.Compaction.Sources {
+				sources1h[sourceShard{id: id, shard: m.Thanos.Shard.String()}] = struct{}{}
+			}
+		}
+	}
+	covered := func(g *Group, b progressBlock, sources map[sourceShard]struct{}) bool {
+		for _, id := range b.sources {
+			if _, ok := sources[sourceShard{id: id, shard: g.Shard().String()}]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	res := make([]GroupProgress, 0, len(groups))
+	for _, g := range groups {
+		g.mtx.Lock()
+		blocks := make([]progressBlock, 0, len(g.blocks))
+		for _, m := range g.blocks {
+			blocks = append(blocks, progressBlock{
+				mint:    m.MinTime,
+				maxt:    m.MaxTime,
+				bytes:   estimatedBlockBytes(m),
+				sources: m.Compaction.Sources,
+			})
+		}
+		g.mtx.Unlock()
+
+		p := GroupProgress{Group: g.Key()}
+		levels := map[int64]struct{}{}
+		for {
+			plan, iv := planProgress(blocks, ranges)
+			if len(plan) == 0 {
+				break
+			}
+			blocks = compactProgress(blocks, plan)
+			p.Compactions++
+			p.Bytes += blocks[len(blocks)-1].bytes
+			if iv > 0 {
+				levels[iv] = struct{}{}
+			}
+		}
+		p.Levels = len(levels)
+
+		for _, b := range blocks {
+			switch g.Resolution() {
+			case downsample.ResLevel0:
+				if b.maxt-b.mint >= downsample.DownsampleRange0 && !covered(g, b, sources5m) {
+					p.Downsamplings++
+					// The downsampled block is downsampled further, once long enough.
+					if b.maxt-b.mint >= downsample.DownsampleRange1 && !covered(g, b, sources1h) {
+						p.Downsamplings++
+					}
+				}
+			case downsample.ResLevel1:
+				if b.maxt-b.mint >= downsample.DownsampleRange1 && !covered(g, b, sources1h) {
+					p.Downsamplings++
+				}
+			}
+		}
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Group < res[j].Group
+	})
+	return res
+}
+
+// progressBlock is a block of a group as simulated by compaction progress estimation.
+type progressBlock struct {
+	mint, maxt int64
+	bytes      int64
+	sources    []ulid.ULID
+}
+
+// planProgress returns indexes of the blocks, sorted by min time, the TSDB compactor would compact next, and the range
+// they are compacted for, which is 0 for overlapping blocks.
+func planProgress(blocks []progressBlock, ranges []int64) ([]int, int64) {
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].mint < blocks[j].mint
+	})
+	if len(blocks) < 2 {
+		return nil, 0
+	}
+
+	// Overlapping blocks are compacted first.
+	var overlapping []int
+	globalMaxt := blocks[0].maxt
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].mint < globalMaxt {
+			if len(overlapping) == 0 {
+				overlapping = append(overlapping, i-1)
+			}
+			overlapping = append(overlapping, i)
+		} else if len(overlapping) > 0 {
+			break
+		}
+		if blocks[i].maxt > globalMaxt {
+			globalMaxt = blocks[i].maxt
+		}
+	}
+	if len(overlapping) > 0 {
+		return overlapping, 0
+	}
+	if len(ranges) < 2 {
+		return nil, 0
+	}
+
+	// The most recent block is never compacted.
+	ds := blocks[:len(blocks)-1]
+	highTime := ds[len(ds)-1].mint
+	for _, iv := range ranges[1:] {
+		for i := 0; i < len(ds); {
+			t0 := iv * (ds[i].mint / iv)
+			if ds[i].mint < 0 {
+				t0 = iv * ((ds[i].mint - iv + 1) / iv)
+			}
+			if ds[i].maxt > t0+iv {
+				i++
+				continue
+			}
+			var part []int
+			for ; i < len(ds) && ds[i].maxt <= t0+iv; i++ {
+				part = append(part, i)
+			}
+			mint, maxt := ds[part[0]].mint, ds[part[len(part)-1]].maxt
+			if (maxt-mint == iv || maxt <= highTime) && len(part) > 1 {
+				return part, iv
+			}
+		}
+	}
+	return nil, 0
+}
+
+// compactProgress replaces the planned blocks by their compacted block, which is appended last.
+func compactProgress(blocks []progressBlock, plan []int) []progressBlock {
+	planned := make(map[int]struct{}, len(plan))
+	compacted := progressBlock{mint: blocks[plan[0]].mint, maxt: blocks[plan[0]].maxt}
+	for _, i := range plan {
+		planned[i] = struct{}{}
+		b := blocks[i]
+		if b.mint < compacted.mint {
+			compacted.mint = b.mint
+		}
+		if b.maxt > compacted.maxt {
+			compacted.maxt = b.maxt
+		}
+		compacted.bytes += b.bytes
+		compacted.sources = append(compacted.sources, b.sources...)
+	}
+
+	res := make([]progressBlock, 0, len(blocks)-len(plan)+1)
+	for i, b := range blocks {
+		if _, ok := planned[i]; !ok {
+			res = append(res, b)
+		}
+	}
+	return append(res, compacted)
+}
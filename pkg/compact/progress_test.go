@@ -0,0 +1,60 @@
+package compact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestEstimateProgress(t *testing.T) {
+	h := int64(time.Hour / time.Millisecond)
+
+	var (
+		metas  = map[ulid.ULID]*metadata.Meta{}
+		groups []*Group
+		nextID uint64
+	)
+	newMeta := func(lset labels.Labels, res, mint, maxt int64) *metadata.Meta {
+		nextID++
+		m := &metadata.Meta{}
+		m.ULID = ulid.MustNew(nextID, nil)
+		m.MinTime, m.MaxTime = mint, maxt
+		m.Stats.NumSamples, m.Stats.NumSeries = 100, 10
+		m.Compaction.Sources = []ulid.ULID{m.ULID}
+		m.Thanos.Labels = lset.Map()
+		m.Thanos.Downsample.Resolution = res
+		metas[m.ULID] = m
+		return m
+	}
+	newTestGroup := func(lset labels.Labels, res int64, ranges ...[2]int64) {
+		g, err := NewGroup(nil, nil, lset, res, nil, false, false, nil, nil, nil, nil, nil, nil, false)
+		testutil.Ok(t, err)
+		for _, r := range ranges {
+			testutil.Ok(t, g.Add(newMeta(lset, res, r[0], r[1])))
+		}
+		groups = append(groups, g)
+	}
+
+	// The first four 2h blocks are compacted into a 8h block, the most recent block is left.
+	newTestGroup(labels.FromStrings("tenant", "a"), downsample.ResLevel0, [2]int64{0, 2 * h}, [2]int64{2 * h, 4 * h}, [2]int64{4 * h, 6 * h}, [2]int64{6 * h, 8 * h}, [2]int64{8 * h, 10 * h})
+	// Both ranges are long enough to be downsampled, the second one is downsampled to 5m already.
+	newTestGroup(labels.FromStrings("tenant", "b"), downsample.ResLevel0, [2]int64{0, 48 * h}, [2]int64{48 * h, 48*h + 11*24*h})
+	b5m := newMeta(labels.FromStrings("tenant", "b"), downsample.ResLevel1, 48*h, 48*h+11*24*h)
+	b5m.Compaction.Sources = groups[1].blocks[groups[1].IDs()[1]].Compaction.Sources
+	// A 5m block long enough to be downsampled to 1h.
+	newTestGroup(labels.FromStrings("tenant", "c"), downsample.ResLevel1, [2]int64{0, 11 * 24 * h})
+	// Overlapping blocks are compacted first.
+	newTestGroup(labels.FromStrings("tenant", "d"), downsample.ResLevel0, [2]int64{0, 10 * h}, [2]int64{5 * h, 12 * h})
+
+	testutil.Equals(t, []GroupProgress{
+		{Group: groups[0].Key(), Compactions: 1, Levels: 1, Bytes: 4 * (100*estimatedBytesPerSample + 10*estimatedBytesPerSeries)},
+		{Group: groups[1].Key(), Downsamplings: 1},
+		{Group: groups[3].Key(), Compactions: 1, Bytes: 2 * (100*estimatedBytesPerSample + 10*estimatedBytesPerSeries)},
+		{Group: groups[2].Key(), Downsamplings: 1},
+	}, estimateProgress(groups, metas, []int64{2 * h, 8 * h}))
+}
@@ -0,0 +1,206 @@
+package compact
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+const (
+	// downloadedMarkFilename is the file written into the work directory of a block once it was downloaded completely.
+	downloadedMarkFilename = "downloaded"
+	// checkpointFilename is the file of a group work directory describing the compacted block waiting for upload.
+	checkpointFilename = "checkpoint.json"
+)
+
+// checkpoint describes a compaction of a group finished locally, whose compacted block was not uploaded yet. It allows
+// a restarted compactor to upload the block instead of compacting the blocks of the plan again.
+type checkpoint struct {
+	// Plan are IDs of the compacted blocks.
+	Plan []ulid.ULID `json:"plan"`
+	// Block is the ID of the compacted block.
+	Block ulid.ULID `json:"block"`
+	// Shards are IDs of the blocks the compacted block was split into, if it was split into shards of series. They
+	// are uploaded instead of the compacted block.
+	Shards []ulid.ULID `json:"shards,omitempty"`
+}
+
+// blocks returns IDs of the blocks to upload.
+func (c checkpoint) blocks() []ulid.ULID {
+	if len(c.Shards) > 0 {
+		return c.Shards
+	}
+	return []ulid.ULID{c.Block}
+}
+
+func writeCheckpoint(dir string, c checkpoint) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "encode checkpoint")
+	}
+	// Make the checkpoint appear atomically, so partial checkpoints are never resumed.
+	tmp := filepath.Join(dir, checkpointFilename+".tmp")
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write checkpoint")
+	}
+	return errors.Wrap(os.Rename(tmp, filepath.Join(dir, checkpointFilename)), "rename checkpoint")
+}
+
+// readCheckpoint returns the checkpoint of the group work directory, or nil if there is none.
+func readCheckpoint(dir string) (*checkpoint, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, checkpointFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read checkpoint")
+	}
+	c := &checkpoint{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, errors.Wrap(err, "decode checkpoint")
+	}
+	return c, nil
+}
+
+func removeCheckpoint(dir string) error {
+	if err := os.Remove(filepath.Join(dir, checkpointFilename)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove checkpoint")
+	}
+	return nil
+}
+
+// markDownloaded marks the block directory as downloaded completely.
+func markDownloaded(bdir string) error {
+	return errors.Wrap(ioutil.WriteFile(filepath.Join(bdir, downloadedMarkFilename), nil, 0666), "write downloaded mark")
+}
+
+// isDownloaded returns true if the block directory was downloaded completely.
+func isDownloaded(bdir string) bool {
+	_, err := os.Stat(filepath.Join(bdir, downloadedMarkFilename))
+	return err == nil
+}
+
+// checkBlockDir returns an error if the directory does not hold a complete block with the given ID.
+func checkBlockDir(bdir string, id ulid.ULID) error {
+	meta, err := metadata.Read(bdir)
+	if err != nil {
+		return errors.Wrap(err, "read meta")
+	}
+	if meta.ULID.Compare(id) != 0 {
+		return errors.Errorf("mismatch between meta %s and dir %s", meta.ULID, id)
+	}
+	if _, err := os.Stat(filepath.Join(bdir, block.IndexFilename)); err != nil {
+		return errors.Wrap(err, "stat index")
+	}
+	fi, err := os.Stat(filepath.Join(bdir, block.ChunksDirname))
+	if err != nil {
+		return errors.Wrap(err, "stat chunks dir")
+	}
+	if !fi.IsDir() {
+		return errors.Errorf("%s is not a directory", block.ChunksDirname)
+	}
+	return nil
+}
+
+// cleanWorkDir validates leftovers of group compactions interrupted before, e.g. by a crash of the compactor, in the
+// compaction work directory. Completely downloaded blocks and compacted blocks waiting for upload are kept, so the
+// compactions resume with them. Everything else, e.g. partially downloaded blocks or partially written compacted
+// blocks, is removed.
+func cleanWorkDir(logger log.Logger, dir string) error {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "read compaction work dir")
+	}
+	for _, fi := range fis {
+		gdir := filepath.Join(dir, fi.Name())
+		if !fi.IsDir() {
+			if err := os.RemoveAll(gdir); err != nil {
+				return errors.Wrapf(err, "remove %s", gdir)
+			}
+			continue
+		}
+		if err := cleanGroupWorkDir(logger, gdir); err != nil {
+			return errors.Wrapf(err, "clean compaction group dir %s", gdir)
+		}
+	}
+	return nil
+}
+
+func cleanGroupWorkDir(logger log.Logger, dir string) error {
+	c, err := readCheckpoint(dir)
+	if err != nil {
+		level.Warn(logger).Log("msg", "removing unreadable compaction checkpoint", "dir", dir, "err", err)
+		c = nil
+		if err := removeCheckpoint(dir); err != nil {
+			return err
+		}
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrap(err, "read compaction group dir")
+	}
+	compactedIDs := map[ulid.ULID]struct{}{}
+	if c != nil {
+		for _, id := range c.blocks() {
+			compactedIDs[id] = struct{}{}
+		}
+	}
+	var (
+		kept, removed int
+		compacted     []string
+	)
+	for _, fi := range fis {
+		if !fi.IsDir() && fi.Name() == checkpointFilename {
+			continue
+		}
+		p := filepath.Join(dir, fi.Name())
+
+		id, ok := block.IsBlockDir(p)
+		if ok && fi.IsDir() {
+			if _, ok := compactedIDs[id]; ok {
+				if checkBlockDir(p, id) == nil {
+					compacted = append(compacted, p)
+					kept++
+					continue
+				}
+			} else if isDownloaded(p) && checkBlockDir(p, id) == nil {
+				kept++
+				continue
+			}
+		}
+		removed++
+		if err := os.RemoveAll(p); err != nil {
+			return errors.Wrapf(err, "remove %s", p)
+		}
+	}
+	// The compaction is only resumable with all its compacted blocks.
+	resumable := c != nil && len(compacted) == len(compactedIDs)
+	if c != nil && !resumable {
+		for _, p := range compacted {
+			if err := os.RemoveAll(p); err != nil {
+				return errors.Wrapf(err, "remove %s", p)
+			}
+			kept--
+			removed++
+		}
+		if err := removeCheckpoint(dir); err != nil {
+			return err
+		}
+	}
+	if removed > 0 || kept > 0 {
+		level.Info(logger).Log("msg", "validated leftovers of interrupted compaction", "dir", dir, "kept", kept, "removed", removed, "resumable_compacted_block", resumable)
+	}
+	return nil
+}
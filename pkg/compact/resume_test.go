@@ -0,0 +1,142 @@
+package compact
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func writeTestBlockDir(t *testing.T, dir string, id ulid.ULID, downloaded, index bool) {
+	bdir := filepath.Join(dir, id.String())
+	testutil.Ok(t, os.MkdirAll(filepath.Join(bdir, block.ChunksDirname), 0777))
+	testutil.Ok(t, metadata.Write(log.NewNopLogger(), bdir, &metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{ULID: id, Version: metadata.MetaVersion1},
+	}))
+	if index {
+		testutil.Ok(t, ioutil.WriteFile(filepath.Join(bdir, block.IndexFilename), []byte("index"), 0666))
+	}
+	if downloaded {
+		testutil.Ok(t, markDownloaded(bdir))
+	}
+}
+
+func TestCleanWorkDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-clean-work-dir")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	var (
+		downloaded = ulid.MustNew(1, nil)
+		partial    = ulid.MustNew(2, nil)
+		noIndex    = ulid.MustNew(3, nil)
+		compacted  = ulid.MustNew(4, nil)
+		missing    = ulid.MustNew(5, nil)
+	)
+
+	group := filepath.Join(dir, "0@1")
+	testutil.Ok(t, os.MkdirAll(filepath.Join(group, compacted.String()+".tmp"), 0777))
+	writeTestBlockDir(t, group, downloaded, true, true)
+	writeTestBlockDir(t, group, partial, false, true)
+	writeTestBlockDir(t, group, noIndex, true, false)
+	writeTestBlockDir(t, group, compacted, false, true)
+	testutil.Ok(t, writeCheckpoint(group, checkpoint{Plan: []ulid.ULID{downloaded}, Block: compacted}))
+
+	// The checkpoint of a compacted block, which is gone, is outdated.
+	otherGroup := filepath.Join(dir, "0@2")
+	testutil.Ok(t, os.MkdirAll(otherGroup, 0777))
+	testutil.Ok(t, writeCheckpoint(otherGroup, checkpoint{Plan: []ulid.ULID{downloaded}, Block: missing}))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "file"), nil, 0666))
+
+	testutil.Ok(t, cleanWorkDir(log.NewNopLogger(), dir))
+
+	names := func(dir string) (res []string) {
+		fis, err := ioutil.ReadDir(dir)
+		testutil.Ok(t, err)
+		for _, fi := range fis {
+			res = append(res, fi.Name())
+		}
+		return res
+	}
+	testutil.Equals(t, []string{"0@1", "0@2"}, names(dir))
+	testutil.Equals(t, []string{downloaded.String(), compacted.String(), checkpointFilename}, names(group))
+	testutil.Equals(t, []string(nil), names(otherGroup))
+
+	// A missing work dir is nothing to clean.
+	testutil.Ok(t, cleanWorkDir(log.NewNopLogger(), filepath.Join(dir, "missing")))
+}
+
+func TestGroup_ResumeCompaction(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test-resume-compaction")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	extLset := labels.Labels{{Name: "a", Value: "1"}}
+	bkt := inmem.NewBucket()
+	g, err := NewGroup(nil, bkt, extLset, 0, nil, false, false,
+		prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}),
+		prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}),
+		prometheus.NewCounter(prometheus.CounterOpts{}), prometheus.NewCounter(prometheus.CounterOpts{}), false,
+	)
+	testutil.Ok(t, err)
+
+	// No checkpoint, nothing to resume.
+	resumed, _, err := g.resumeCompaction(ctx, dir)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !resumed, "resumed without checkpoint")
+
+	var plan []ulid.ULID
+	for i := 0; i < 2; i++ {
+		id := ulid.MustNew(uint64(i), nil)
+		plan = append(plan, id)
+		writeTestBlockDir(t, dir, id, true, true)
+		testutil.Ok(t, g.Add(&metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: id},
+			Thanos:    metadata.Thanos{Labels: extLset.Map()},
+		}))
+	}
+	compID, err := testutil.CreateBlock(ctx, dir, []labels.Labels{{{Name: "foo", Value: "bar"}}}, 10, 0, 1000, extLset, 0)
+	testutil.Ok(t, err)
+
+	// The compacted block is outdated once blocks of its plan are not in the group anymore.
+	testutil.Ok(t, writeCheckpoint(dir, checkpoint{Plan: append(plan, ulid.MustNew(10, nil)), Block: compID}))
+	resumed, _, err = g.resumeCompaction(ctx, dir)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !resumed, "resumed outdated checkpoint")
+	_, err = os.Stat(filepath.Join(dir, compID.String()))
+	testutil.Assert(t, os.IsNotExist(err), "outdated compacted block not removed")
+	_, err = os.Stat(filepath.Join(dir, checkpointFilename))
+	testutil.Assert(t, os.IsNotExist(err), "outdated checkpoint not removed")
+
+	compID, err = testutil.CreateBlock(ctx, dir, []labels.Labels{{{Name: "foo", Value: "bar"}}}, 10, 0, 1000, extLset, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, writeCheckpoint(dir, checkpoint{Plan: plan, Block: compID}))
+
+	resumed, id, err := g.resumeCompaction(ctx, dir)
+	testutil.Ok(t, err)
+	testutil.Assert(t, resumed, "compaction not resumed")
+	testutil.Equals(t, compID, id)
+
+	ok, err := bkt.Exists(ctx, filepath.Join(compID.String(), block.MetaFilename))
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "compacted block not uploaded")
+	for _, id := range plan {
+		_, err = os.Stat(filepath.Join(dir, id.String()))
+		testutil.Assert(t, os.IsNotExist(err), "compacted block %s not removed", id)
+	}
+	_, err = os.Stat(filepath.Join(dir, checkpointFilename))
+	testutil.Assert(t, os.IsNotExist(err), "checkpoint not removed")
+}
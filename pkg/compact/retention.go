@@ -2,17 +2,20 @@ package compact
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/objstore"
 )
 
 // ApplyRetentionPolicyByResolution removes blocks depending on the specified retentionByResolution based on blocks MaxTime.
 // A value of 0 disables the retention for its resolution.
+// Retention given by hints in meta.json of a block takes precedence.
 func ApplyRetentionPolicyByResolution(ctx context.Context, logger log.Logger, bkt objstore.Bucket, retentionByResolution map[ResolutionLevel]time.Duration) error {
 	level.Info(logger).Log("msg", "start optional retention")
 	if err := bkt.Iter(ctx, "", func(name string) error {
@@ -26,6 +29,9 @@ func ApplyRetentionPolicyByResolution(ctx context.Context, logger log.Logger, bk
 		}
 
 		retentionDuration := retentionByResolution[ResolutionLevel(m.Thanos.Downsample.Resolution)]
+		if d := m.Thanos.Hints.Retention(m.Thanos.Downsample.Resolution); d > 0 {
+			retentionDuration = d
+		}
 		if retentionDuration.Seconds() == 0 {
 			return nil
 		}
@@ -46,3 +52,57 @@ func ApplyRetentionPolicyByResolution(ctx context.Context, logger log.Logger, bk
 	level.Info(logger).Log("msg", "optional retention apply done")
 	return nil
 }
+
+// downsampleSteps are the resolutions blocks are downsampled from, to the resolution they are downsampled to once they
+// span the given range.
+var downsampleSteps = []struct {
+	from, to ResolutionLevel
+	rangeMs  int64
+}{
+	{from: ResolutionLevelRaw, to: ResolutionLevel5m, rangeMs: downsample.DownsampleRange0},
+	{from: ResolutionLevel5m, to: ResolutionLevel1h, rangeMs: downsample.DownsampleRange1},
+}
+
+func resolutionName(r ResolutionLevel) string {
+	switch r {
+	case ResolutionLevelRaw:
+		return "raw"
+	case ResolutionLevel5m:
+		return "5m"
+	case ResolutionLevel1h:
+		return "1h"
+	}
+	return fmt.Sprintf("%dms", r)
+}
+
+// ValidateRetention checks whether the retention by resolution deletes blocks before they are downsampled, although
+// blocks of the resolution they are downsampled to are retained longer. Blocks are only downsampled once they span
+// the downsample range of their resolution, so a shorter retention than that range deletes them first.
+//
+// If downsampling is true, i.e. the compactor downsamples the blocks it applies retention to, such retentions are
+// returned as error, as they lose data. Otherwise they are returned as warnings, as other compactors may downsample
+// the blocks in time.
+func ValidateRetention(retentionByResolution map[ResolutionLevel]time.Duration, downsampling bool) (warnings []string, err error) {
+	// produced is whether blocks of the resolution are retained long enough to be downsampled from the previous one.
+	produced := true
+	for _, s := range downsampleSteps {
+		r, next := retentionByResolution[s.from], retentionByResolution[s.to]
+		downsampleRange := time.Duration(s.rangeMs) * time.Millisecond
+		ok := !produced || r <= 0 || (next > 0 && next <= r)
+		produced = produced && (r <= 0 || r >= downsampleRange)
+		if ok {
+			// Blocks do not exist, are retained forever or the downsampled blocks are not retained longer than them.
+			continue
+		}
+		if !downsampling {
+			warnings = append(warnings, fmt.Sprintf("blocks of resolution %s are deleted after %s without being downsampled to resolution %s by this compactor, although resolution %s is retained longer",
+				resolutionName(s.from), r, resolutionName(s.to), resolutionName(s.to)))
+			continue
+		}
+		if r < downsampleRange {
+			return warnings, errors.Errorf("retention of resolution %s (%s) is shorter than the range of %s blocks span before they are downsampled to resolution %s, although resolution %s is retained longer: blocks would be deleted before they are downsampled",
+				resolutionName(s.from), r, downsampleRange, resolutionName(s.to), resolutionName(s.to))
+		}
+	}
+	return warnings, nil
+}
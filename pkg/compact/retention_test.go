@@ -251,7 +251,37 @@ func TestApplyRetentionPolicyByResolution(t *testing.T) {
 	}
 }
 
+func TestApplyRetentionPolicyByResolution_Hints(t *testing.T) {
+	bkt := inmem.NewBucket()
+	day := 24 * time.Hour
+
+	// Retention given by hints takes precedence over the retention of the compactor, both ways.
+	uploadMockBlockWithHints(t, bkt, "01CPHBEX20729MJQZXE3W0BW40", time.Now().Add(-3*day), time.Now().Add(-2*day), 0,
+		&metadata.ThanosHints{Tenant: "a", RetentionRaw: int64(7 * day / time.Millisecond)})
+	uploadMockBlockWithHints(t, bkt, "01CPHBEX20729MJQZXE3W0BW41", time.Now().Add(-3*day), time.Now().Add(-2*day), 0,
+		&metadata.ThanosHints{Tenant: "b", RetentionRaw: int64(day / time.Millisecond)})
+	uploadMockBlockWithHints(t, bkt, "01CPHBEX20729MJQZXE3W0BW42", time.Now().Add(-3*day), time.Now().Add(-2*day), 0,
+		&metadata.ThanosHints{Tenant: "c", Retention1h: int64(7 * day / time.Millisecond)})
+	uploadMockBlockWithHints(t, bkt, "01CPHBEX20729MJQZXE3W0BW43", time.Now().Add(-3*day), time.Now().Add(-2*day), 0, nil)
+
+	testutil.Ok(t, compact.ApplyRetentionPolicyByResolution(context.TODO(), log.NewNopLogger(), bkt, map[compact.ResolutionLevel]time.Duration{
+		compact.ResolutionLevelRaw: 36 * time.Hour,
+	}))
+
+	got := []string{}
+	testutil.Ok(t, bkt.Iter(context.TODO(), "", func(name string) error {
+		got = append(got, name)
+		return nil
+	}))
+	testutil.Equals(t, []string{"01CPHBEX20729MJQZXE3W0BW40/"}, got)
+}
+
 func uploadMockBlock(t *testing.T, bkt objstore.Bucket, id string, minTime, maxTime time.Time, resolutionLevel int64) {
+	t.Helper()
+	uploadMockBlockWithHints(t, bkt, id, minTime, maxTime, resolutionLevel, nil)
+}
+
+func uploadMockBlockWithHints(t *testing.T, bkt objstore.Bucket, id string, minTime, maxTime time.Time, resolutionLevel int64, hints *metadata.ThanosHints) {
 	t.Helper()
 	meta1 := metadata.Meta{
 		BlockMeta: tsdb.BlockMeta{
@@ -264,6 +294,7 @@ func uploadMockBlock(t *testing.T, bkt objstore.Bucket, id string, minTime, maxT
 			Downsample: metadata.ThanosDownsample{
 				Resolution: resolutionLevel,
 			},
+			Hints: hints,
 		},
 	}
 
@@ -275,3 +306,31 @@ func uploadMockBlock(t *testing.T, bkt objstore.Bucket, id string, minTime, maxT
 	testutil.Ok(t, bkt.Upload(context.Background(), id+"/chunks/000002", strings.NewReader("@test-data@")))
 	testutil.Ok(t, bkt.Upload(context.Background(), id+"/chunks/000003", strings.NewReader("@test-data@")))
 }
+
+func TestValidateRetention(t *testing.T) {
+	const day = 24 * time.Hour
+	for _, tcase := range []struct {
+		retention    map[compact.ResolutionLevel]time.Duration
+		downsampling bool
+		warnings     int
+		err          bool
+	}{
+		{retention: map[compact.ResolutionLevel]time.Duration{}, downsampling: true},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: day, compact.ResolutionLevel5m: day}, downsampling: true},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: 30 * day, compact.ResolutionLevel5m: 90 * day}, downsampling: true},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: day}, downsampling: true, err: true},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: day, compact.ResolutionLevel5m: 7 * day}, downsampling: true, err: true},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: 30 * day, compact.ResolutionLevel5m: 7 * day}, downsampling: true, err: true},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: day}, downsampling: false, warnings: 1},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: day, compact.ResolutionLevel5m: 7 * day, compact.ResolutionLevel1h: 7 * day}, downsampling: false, warnings: 1},
+		{retention: map[compact.ResolutionLevel]time.Duration{compact.ResolutionLevelRaw: 30 * day, compact.ResolutionLevel5m: 60 * day}, downsampling: false, warnings: 2},
+	} {
+		warnings, err := compact.ValidateRetention(tcase.retention, tcase.downsampling)
+		if tcase.err {
+			testutil.NotOk(t, err)
+			continue
+		}
+		testutil.Ok(t, err)
+		testutil.Equals(t, tcase.warnings, len(warnings))
+	}
+}
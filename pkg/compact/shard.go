@@ -0,0 +1,136 @@
+package compact
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// splitBlock splits the block in dir into the given number of blocks, each holding the series of the block whose
+// labels hash modulo shards is the index of its shard, as recorded in the meta of each block. The blocks are written
+// into dir, the split block is kept. Metas of the written blocks are returned in order of their shard.
+func splitBlock(logger log.Logger, dir string, meta *metadata.Meta, shards uint64) (metas []*metadata.Meta, err error) {
+	b, err := tsdb.OpenBlock(logger, filepath.Join(dir, meta.ULID.String()), downsample.NewPool())
+	if err != nil {
+		return nil, errors.Wrapf(err, "open block %s", meta.ULID)
+	}
+	defer runutil.CloseWithErrCapture(&err, b, "split block")
+
+	indexr, err := b.Index()
+	if err != nil {
+		return nil, errors.Wrap(err, "open index reader")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "split block index reader")
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return nil, errors.Wrap(err, "open chunk reader")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkr, "split block chunk reader")
+
+	type shardWriter interface {
+		WriteSeries(lset labels.Labels, chunks []chunks.Meta) error
+		Close() error
+	}
+	var (
+		writers = make([]shardWriter, 0, shards)
+		bdirs   = make([]string, 0, shards)
+		entropy = rand.New(rand.NewSource(time.Now().UnixNano()))
+	)
+	// Remove the written blocks in case of errors.
+	defer func() {
+		if err != nil {
+			var merr tsdberrors.MultiError
+			merr.Add(err)
+			for _, w := range writers {
+				merr.Add(w.Close())
+			}
+			for _, bdir := range bdirs {
+				merr.Add(os.RemoveAll(bdir))
+			}
+			err = merr.Err()
+		}
+	}()
+
+	for i := uint64(0); i < shards; i++ {
+		m := *meta
+		m.ULID = ulid.MustNew(ulid.Now(), entropy)
+		m.Thanos.Shard = &metadata.ThanosShard{Index: i, Count: shards}
+
+		bdir := filepath.Join(dir, m.ULID.String())
+		if err := os.MkdirAll(bdir, 0777); err != nil {
+			return nil, errors.Wrap(err, "create shard block dir")
+		}
+		bdirs = append(bdirs, bdir)
+
+		w, err := downsample.NewStreamedBlockWriter(bdir, indexr, logger, m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "create writer of shard %s", m.Thanos.Shard)
+		}
+		writers = append(writers, w)
+	}
+
+	postings, err := indexr.Postings(index.AllPostingsKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "get all postings list")
+	}
+	var (
+		lset labels.Labels
+		chks []chunks.Meta
+	)
+	// Series are written in order of the split block, which keeps the series of each shard sorted.
+	for postings.Next() {
+		if err := indexr.Series(postings.At(), &lset, &chks); err != nil {
+			return nil, errors.Wrapf(err, "get series %d", postings.At())
+		}
+		for i, c := range chks {
+			chk, err := chunkr.Chunk(c.Ref)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get chunk %d, series %d", c.Ref, postings.At())
+			}
+			chks[i].Chunk = chk
+		}
+		if err := writers[lset.Hash()%shards].WriteSeries(lset, chks); err != nil {
+			return nil, errors.Wrapf(err, "write series %d", postings.At())
+		}
+	}
+	if postings.Err() != nil {
+		return nil, errors.Wrap(postings.Err(), "iterate series set")
+	}
+
+	for i, w := range writers {
+		if err := w.Close(); err != nil {
+			return nil, errors.Wrapf(err, "finalize shard %d", i)
+		}
+		m, err := metadata.Read(bdirs[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "read meta of shard %d", i)
+		}
+		metas = append(metas, m)
+	}
+	level.Debug(logger).Log("msg", "split block into shards", "block", meta.ULID, "shards", fmt.Sprintf("%v", shardIDs(metas)))
+	return metas, nil
+}
+
+func shardIDs(metas []*metadata.Meta) []ulid.ULID {
+	ids := make([]ulid.ULID, 0, len(metas))
+	for _, m := range metas {
+		ids = append(ids, m.ULID)
+	}
+	return ids
+}
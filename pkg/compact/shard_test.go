@@ -0,0 +1,137 @@
+package compact
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestSplitBlock(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test-split-block")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	var series []labels.Labels
+	for i := 0; i < 20; i++ {
+		series = append(series, labels.FromStrings("a", fmt.Sprintf("%d", i)))
+	}
+	extLset := labels.FromStrings("ext", "1")
+	id, err := testutil.CreateBlock(ctx, dir, series, 10, 0, 1000, extLset, 0)
+	testutil.Ok(t, err)
+	meta, err := metadata.Read(filepath.Join(dir, id.String()))
+	testutil.Ok(t, err)
+
+	metas, err := splitBlock(log.NewNopLogger(), dir, meta, 3)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, len(metas))
+
+	var (
+		got        []string
+		numSamples uint64
+	)
+	for i, m := range metas {
+		testutil.Equals(t, &metadata.ThanosShard{Index: uint64(i), Count: 3}, m.Thanos.Shard)
+		testutil.Equals(t, meta.Thanos.Labels, m.Thanos.Labels)
+		testutil.Equals(t, meta.Compaction.Sources, m.Compaction.Sources)
+		testutil.Equals(t, meta.MinTime, m.MinTime)
+		testutil.Equals(t, meta.MaxTime, m.MaxTime)
+		numSamples += m.Stats.NumSamples
+
+		bdir := filepath.Join(dir, m.ULID.String())
+		testutil.Ok(t, block.VerifyIndex(log.NewNopLogger(), filepath.Join(bdir, block.IndexFilename), m.MinTime, m.MaxTime))
+
+		b, err := tsdb.OpenBlock(nil, bdir, nil)
+		testutil.Ok(t, err)
+		ir, err := b.Index()
+		testutil.Ok(t, err)
+		p, err := ir.Postings(index.AllPostingsKey())
+		testutil.Ok(t, err)
+		for p.Next() {
+			var (
+				lset labels.Labels
+				chks []chunks.Meta
+			)
+			testutil.Ok(t, ir.Series(p.At(), &lset, &chks))
+			testutil.Equals(t, uint64(i), lset.Hash()%3)
+			got = append(got, lset.String())
+		}
+		testutil.Ok(t, p.Err())
+		testutil.Equals(t, uint64(len(got)), numSeries(metas[:i+1]))
+		testutil.Ok(t, ir.Close())
+		testutil.Ok(t, b.Close())
+	}
+
+	// Every series is in exactly one shard.
+	var exp []string
+	for _, s := range series {
+		exp = append(exp, s.String())
+	}
+	sort.Strings(exp)
+	sort.Strings(got)
+	testutil.Equals(t, exp, got)
+	testutil.Equals(t, meta.Stats.NumSamples, numSamples)
+}
+
+func numSeries(metas []*metadata.Meta) (n uint64) {
+	for _, m := range metas {
+		n += m.Stats.NumSeries
+	}
+	return n
+}
+
+func TestSyncer_GarbageBlocks_Shards(t *testing.T) {
+	var (
+		blocks  = map[ulid.ULID]*metadata.Meta{}
+		sources = []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(2, nil)}
+	)
+	add := func(id ulid.ULID, lvl int, shard *metadata.ThanosShard, sources ...ulid.ULID) {
+		m := &metadata.Meta{}
+		m.ULID = id
+		m.Compaction.Level = lvl
+		m.Compaction.Sources = sources
+		m.Thanos.Shard = shard
+		blocks[id] = m
+	}
+	add(sources[0], 1, nil, sources[0])
+	add(sources[1], 1, nil, sources[1])
+
+	sy, err := NewSyncer(nil, nil, nil, 0, 1, nil, nil, nil, nil, nil, 0, 0, "", "")
+	testutil.Ok(t, err)
+	sy.blocks = blocks
+
+	// Source blocks are kept until all shards of a compacted block are present, e.g. after failed uploads of shards.
+	add(ulid.MustNew(3, nil), 2, &metadata.ThanosShard{Index: 0, Count: 2}, sources...)
+	ids, err := sy.GarbageBlocks(0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(ids))
+
+	// Shards of a compacted block supersede its source blocks, but not each other.
+	add(ulid.MustNew(4, nil), 2, &metadata.ThanosShard{Index: 1, Count: 2}, sources...)
+	ids, err = sy.GarbageBlocks(0)
+	testutil.Ok(t, err)
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Compare(ids[j]) < 0 })
+	testutil.Equals(t, sources, ids)
+
+	// Within a shard, compacted blocks supersede their sources as without shards.
+	add(ulid.MustNew(5, nil), 3, &metadata.ThanosShard{Index: 1, Count: 2}, sources...)
+	ids, err = sy.GarbageBlocks(0)
+	testutil.Ok(t, err)
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Compare(ids[j]) < 0 })
+	testutil.Equals(t, []ulid.ULID{sources[0], sources[1], ulid.MustNew(4, nil)}, ids)
+}
@@ -0,0 +1,142 @@
+package compact
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// verifyCompactedBlock reads all series and chunks of the compacted block in bdir and returns an error if they cannot
+// be read, series or chunks are not ordered, samples are out of order or outside of the time range of their chunk or
+// the block, or the series, chunks and samples do not match the stats of the block meta. The block has to hold all
+// sourceSamples samples of the blocks it was compacted from, or at most as many, if it deduplicated samples of
+// overlapping blocks.
+func verifyCompactedBlock(logger log.Logger, bdir string, meta *metadata.Meta, sourceSamples uint64, deduplicated bool) error {
+	stats, err := readBlockStats(logger, bdir, meta)
+	if err != nil {
+		return err
+	}
+	if stats != meta.Stats {
+		return errors.Errorf("block has %d series, %d chunks and %d samples, but its meta %d series, %d chunks and %d samples",
+			stats.NumSeries, stats.NumChunks, stats.NumSamples, meta.Stats.NumSeries, meta.Stats.NumChunks, meta.Stats.NumSamples)
+	}
+	if sourceSamples == 0 {
+		// Stats of source blocks are unknown.
+		return nil
+	}
+	if deduplicated && stats.NumSamples > sourceSamples || !deduplicated && stats.NumSamples != sourceSamples {
+		return errors.Errorf("block has %d samples, but its source blocks %d samples", stats.NumSamples, sourceSamples)
+	}
+	return nil
+}
+
+// readBlockStats reads all series and chunks of the block in bdir, verifying their order and time ranges, and returns
+// the number of series, chunks and samples read. TSDB readers panic on some corrupted data, e.g. truncated chunk
+// files, which is returned as error.
+func readBlockStats(logger log.Logger, bdir string, meta *metadata.Meta) (stats tsdb.BlockStats, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("read block: %v", r)
+		}
+	}()
+
+	b, err := tsdb.OpenBlock(logger, bdir, downsample.NewPool())
+	if err != nil {
+		return stats, errors.Wrap(err, "open block")
+	}
+	defer runutil.CloseWithErrCapture(&err, b, "verified block")
+
+	indexr, err := b.Index()
+	if err != nil {
+		return stats, errors.Wrap(err, "open index reader")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "verified block index reader")
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return stats, errors.Wrap(err, "open chunk reader")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkr, "verified block chunk reader")
+
+	postings, err := indexr.Postings(index.AllPostingsKey())
+	if err != nil {
+		return stats, errors.Wrap(err, "get all postings list")
+	}
+	var (
+		lset, prev labels.Labels
+		chks       []chunks.Meta
+	)
+	for postings.Next() {
+		if err := indexr.Series(postings.At(), &lset, &chks); err != nil {
+			return stats, errors.Wrapf(err, "get series %d", postings.At())
+		}
+		if prev != nil && labels.Compare(prev, lset) >= 0 {
+			return stats, errors.Errorf("series %s is not ordered after series %s", lset, prev)
+		}
+		prev = append(prev[:0], lset...)
+		if len(chks) == 0 {
+			return stats, errors.Errorf("series %s has no chunks", lset)
+		}
+
+		for i, c := range chks {
+			if c.MinTime > c.MaxTime || c.MinTime < meta.MinTime || c.MaxTime > meta.MaxTime {
+				return stats, errors.Errorf("chunk [%d, %d] of series %s is outside of block range [%d, %d]", c.MinTime, c.MaxTime, lset, meta.MinTime, meta.MaxTime)
+			}
+			if i > 0 && c.MinTime <= chks[i-1].MaxTime {
+				return stats, errors.Errorf("chunk [%d, %d] of series %s overlaps its previous chunk [%d, %d]", c.MinTime, c.MaxTime, lset, chks[i-1].MinTime, chks[i-1].MaxTime)
+			}
+			chk, err := chunkr.Chunk(c.Ref)
+			if err != nil {
+				return stats, errors.Wrapf(err, "get chunk %d of series %s", c.Ref, lset)
+			}
+			n, err := verifyChunkSamples(chk, c.MinTime, c.MaxTime)
+			if err != nil {
+				return stats, errors.Wrapf(err, "chunk [%d, %d] of series %s", c.MinTime, c.MaxTime, lset)
+			}
+			stats.NumChunks++
+			stats.NumSamples += uint64(n)
+		}
+		stats.NumSeries++
+	}
+	if postings.Err() != nil {
+		return stats, errors.Wrap(postings.Err(), "iterate series set")
+	}
+	return stats, nil
+}
+
+// verifyChunkSamples returns the number of samples of the chunk and an error if they are out of order or outside of
+// [mint, maxt]. Samples of aggregated chunks of downsampled blocks are verified by their count aggregate.
+func verifyChunkSamples(chk chunkenc.Chunk, mint, maxt int64) (n int, err error) {
+	if aggr, ok := chk.(downsample.AggrChunk); ok {
+		if chk, err = aggr.Get(downsample.AggrCount); err != nil {
+			return 0, errors.Wrap(err, "get count aggregate")
+		}
+	}
+	it := chk.Iterator(nil)
+	prev := int64(0)
+	for it.Next() {
+		t, _ := it.At()
+		if t < mint || t > maxt {
+			return n, errors.Errorf("sample at %d is outside of the chunk", t)
+		}
+		if n > 0 && t <= prev {
+			return n, errors.Errorf("sample at %d is not after its previous sample at %d", t, prev)
+		}
+		prev = t
+		n++
+	}
+	if it.Err() != nil {
+		return n, errors.Wrap(it.Err(), "iterate samples")
+	}
+	if n != chk.NumSamples() {
+		return n, errors.Errorf("read %d samples, but chunk has %d", n, chk.NumSamples())
+	}
+	return n, nil
+}
@@ -0,0 +1,63 @@
+package compact
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestVerifyCompactedBlock(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test-verify-compacted-block")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	var series []labels.Labels
+	for i := 0; i < 10; i++ {
+		series = append(series, labels.FromStrings("a", fmt.Sprintf("%d", i)))
+	}
+	id, err := testutil.CreateBlock(ctx, dir, series, 100, 0, 1000, labels.FromStrings("ext", "1"), 0)
+	testutil.Ok(t, err)
+	bdir := filepath.Join(dir, id.String())
+	meta, err := metadata.Read(bdir)
+	testutil.Ok(t, err)
+	samples := meta.Stats.NumSamples
+	testutil.Equals(t, uint64(1000), samples)
+
+	logger := log.NewNopLogger()
+	testutil.Ok(t, verifyCompactedBlock(logger, bdir, meta, samples, false))
+	// Stats of source blocks are unknown.
+	testutil.Ok(t, verifyCompactedBlock(logger, bdir, meta, 0, false))
+	// Samples of overlapping blocks may be deduplicated.
+	testutil.Ok(t, verifyCompactedBlock(logger, bdir, meta, samples+10, true))
+
+	// Samples of source blocks are missing.
+	testutil.NotOk(t, verifyCompactedBlock(logger, bdir, meta, samples+10, false))
+	testutil.NotOk(t, verifyCompactedBlock(logger, bdir, meta, samples-10, true))
+
+	// The stats of the meta do not match the block.
+	wrong := *meta
+	wrong.Stats.NumChunks++
+	testutil.NotOk(t, verifyCompactedBlock(logger, bdir, &wrong, samples, false))
+
+	// The block holds samples outside of its time range.
+	wrong = *meta
+	wrong.MaxTime = meta.MaxTime / 2
+	testutil.NotOk(t, verifyCompactedBlock(logger, bdir, &wrong, samples, false))
+
+	// The chunks of the block are truncated.
+	chunksFile := filepath.Join(bdir, "chunks", "000001")
+	fi, err := os.Stat(chunksFile)
+	testutil.Ok(t, err)
+	testutil.Ok(t, os.Truncate(chunksFile, fi.Size()/2))
+	testutil.NotOk(t, verifyCompactedBlock(logger, bdir, meta, samples, false))
+}
@@ -127,6 +127,18 @@ func (p *Provider) Addresses() []string {
 	return result
 }
 
+// AddressesOf returns the latest addresses present in the Provider which were resolved from the given addresses.
+func (p *Provider) AddressesOf(addrs []string) []string {
+	p.Lock()
+	defer p.Unlock()
+
+	var result []string
+	for _, addr := range addrs {
+		result = append(result, p.resolved[addr]...)
+	}
+	return result
+}
+
 func contains(slice []string, str string) bool {
 	for _, s := range slice {
 		if str == s {
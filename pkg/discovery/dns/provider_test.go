@@ -55,6 +55,10 @@ func TestProvider(t *testing.T) {
 	sort.Strings(result)
 	testutil.Equals(t, ips, result)
 
+	result = prv.AddressesOf([]string{"any+c", "any+a", "any+x"})
+	sort.Strings(result)
+	testutil.Equals(t, []string{ips[0], ips[1], ips[4]}, result)
+
 	prv.resolver = &mockResolver{err: errors.New("failed to resolve urls")}
 	prv.Resolve(ctx, []string{"any+a", "any+b", "any+c"})
 	result = prv.Addresses()
@@ -0,0 +1,112 @@
+package exemplars
+
+import (
+	"sort"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	tsdb_labels "github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Exemplars API against exemplars of the storage. External labels are added to series
+// labels of returned exemplars the same way as StoreAPI adds them to series.
+type Server struct {
+	storage        *Storage
+	externalLabels tsdb_labels.Labels
+}
+
+// NewServer returns a new Exemplars API server.
+func NewServer(storage *Storage, externalLabels tsdb_labels.Labels) *Server {
+	return &Server{storage: storage, externalLabels: externalLabels}
+}
+
+// Exemplars returns exemplars of series selected by series selectors of the query.
+func (s *Server) Exemplars(r *exemplarspb.ExemplarsRequest, srv exemplarspb.Exemplars_ExemplarsServer) error {
+	matcherSets, err := selectorMatchers(r.Query)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var ms [][]*labels.Matcher
+	for _, set := range matcherSets {
+		if set, ok := s.withoutExternalLabels(set); ok {
+			ms = append(ms, set)
+		}
+	}
+	if len(ms) == 0 {
+		return nil
+	}
+
+	for _, d := range s.storage.Select(r.Start, r.End, ms...) {
+		resp := &exemplarspb.ExemplarsResponse{
+			Result: &exemplarspb.ExemplarsResponse_Data{Data: &exemplarspb.ExemplarData{
+				SeriesLabels: s.translateAndExtendLabels(d.SeriesLabels),
+				Exemplars:    d.Exemplars,
+			}},
+		}
+		if err := srv.Send(resp); err != nil {
+			return status.Error(codes.Aborted, err.Error())
+		}
+	}
+	return nil
+}
+
+// withoutExternalLabels removes matchers of external labels, which the storage does not have. It returns false if
+// the matchers do not match external labels.
+func (s *Server) withoutExternalLabels(ms []*labels.Matcher) ([]*labels.Matcher, bool) {
+	res := make([]*labels.Matcher, 0, len(ms))
+	for _, m := range ms {
+		v := s.externalLabels.Get(m.Name)
+		if v == "" {
+			res = append(res, m)
+			continue
+		}
+		if !m.Matches(v) {
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+// translateAndExtendLabels transforms series labels into a protobuf label set with external labels, overwriting
+// existing ones on collision.
+func (s *Server) translateAndExtendLabels(m labels.Labels) []storepb.Label {
+	lset := make([]storepb.Label, 0, len(m)+len(s.externalLabels))
+	for _, l := range m {
+		if s.externalLabels.Get(l.Name) != "" {
+			continue
+		}
+		lset = append(lset, storepb.Label{Name: l.Name, Value: l.Value})
+	}
+	for _, l := range s.externalLabels {
+		lset = append(lset, storepb.Label{Name: l.Name, Value: l.Value})
+	}
+	sort.Slice(lset, func(i, j int) bool {
+		return lset[i].Name < lset[j].Name
+	})
+	return lset
+}
+
+// selectorMatchers returns label matchers of each series selector of the PromQL query.
+func selectorMatchers(query string) ([][]*labels.Matcher, error) {
+	expr, err := promql.ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]*labels.Matcher
+	promql.Inspect(expr, func(n promql.Node, _ []promql.Node) error {
+		switch n := n.(type) {
+		case *promql.VectorSelector:
+			res = append(res, n.LabelMatchers)
+		case *promql.MatrixSelector:
+			res = append(res, n.LabelMatchers)
+		}
+		return nil
+	})
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package exemplars
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	tsdb_labels "github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type exemplarsServer struct {
+	grpc.ServerStream
+
+	data []exemplarspb.ExemplarData
+}
+
+func (s *exemplarsServer) Send(r *exemplarspb.ExemplarsResponse) error {
+	s.data = append(s.data, *r.GetData())
+	return nil
+}
+
+func (s *exemplarsServer) Context() context.Context {
+	return context.Background()
+}
+
+func TestServer_Exemplars(t *testing.T) {
+	s := NewStorage(nil, 10)
+	testutil.Ok(t, s.Add(labels.FromStrings("__name__", "requests_total", "job", "a", "region", "x"), exemplar("1", 1, 10)))
+	testutil.Ok(t, s.Add(labels.FromStrings("__name__", "requests_total", "job", "b"), exemplar("2", 2, 20)))
+	testutil.Ok(t, s.Add(labels.FromStrings("__name__", "errors_total", "job", "a"), exemplar("3", 3, 30)))
+
+	srv := NewServer(s, tsdb_labels.FromStrings("region", "eu"))
+
+	for _, tcase := range []struct {
+		query    string
+		start    int64
+		expected []exemplarspb.ExemplarData
+	}{
+		{
+			query: `sum(rate(requests_total{job="a"}[5m])) / sum(rate(errors_total[5m]))`,
+			expected: []exemplarspb.ExemplarData{
+				{
+					SeriesLabels: []storepb.Label{{Name: "__name__", Value: "errors_total"}, {Name: "job", Value: "a"}, {Name: "region", Value: "eu"}},
+					Exemplars:    []exemplarspb.Exemplar{exemplar("3", 3, 30)},
+				},
+				{
+					// External labels overwrite series labels.
+					SeriesLabels: []storepb.Label{{Name: "__name__", Value: "requests_total"}, {Name: "job", Value: "a"}, {Name: "region", Value: "eu"}},
+					Exemplars:    []exemplarspb.Exemplar{exemplar("1", 1, 10)},
+				},
+			},
+		},
+		{
+			query: `requests_total{region="eu"}`,
+			start: 15,
+			expected: []exemplarspb.ExemplarData{
+				{
+					SeriesLabels: []storepb.Label{{Name: "__name__", Value: "requests_total"}, {Name: "job", Value: "b"}, {Name: "region", Value: "eu"}},
+					Exemplars:    []exemplarspb.Exemplar{exemplar("2", 2, 20)},
+				},
+			},
+		},
+		{
+			query: `requests_total{region="us"}`,
+		},
+	} {
+		t.Run(tcase.query, func(t *testing.T) {
+			res := &exemplarsServer{}
+			testutil.Ok(t, srv.Exemplars(&exemplarspb.ExemplarsRequest{Query: tcase.query, Start: tcase.start, End: 100}, res))
+			testutil.Equals(t, tcase.expected, res.data)
+		})
+	}
+
+	err := srv.Exemplars(&exemplarspb.ExemplarsRequest{Query: `requests_total{`, End: 100}, &exemplarsServer{})
+	testutil.NotOk(t, err)
+	testutil.Equals(t, codes.InvalidArgument, status.Code(err))
+}
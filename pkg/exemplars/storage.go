@@ -0,0 +1,171 @@
+// Package exemplars implements storage of exemplars, e.g. trace IDs linked to sample values they were observed with,
+// and the Exemplars API serving them.
+package exemplars
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// ErrOutOfOrderExemplar is returned when an exemplar is older than the last exemplar of its series.
+var ErrOutOfOrderExemplar = errors.New("out of order exemplar")
+
+// Storage is an in-memory circular buffer of exemplars of all series, the same way as exemplar storage of Prometheus
+// TSDB. Once the buffer is full, the oldest exemplars are overwritten by new ones, so memory usage is bounded by
+// the buffer size.
+// Exemplars of a series have to be added in timestamp order. Duplicates of the last exemplar of a series are dropped.
+type Storage struct {
+	mtx    sync.RWMutex
+	buf    []entry
+	next   int
+	series map[string]*seriesRef
+
+	added      prometheus.Counter
+	outOfOrder prometheus.Counter
+	seriesNum  prometheus.GaugeFunc
+}
+
+type entry struct {
+	ref      *seriesRef
+	exemplar exemplarspb.Exemplar
+}
+
+type seriesRef struct {
+	key  string
+	lset labels.Labels
+	// last is index of the last exemplar of the series in the buffer.
+	last int
+}
+
+// NewStorage returns a storage keeping up to the given number of exemplars.
+func NewStorage(reg prometheus.Registerer, size int) *Storage {
+	s := &Storage{
+		buf:    make([]entry, size),
+		series: map[string]*seriesRef{},
+		added: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_exemplars_added_total",
+			Help: "Total number of exemplars added to the exemplar storage.",
+		}),
+		outOfOrder: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_exemplars_out_of_order_total",
+			Help: "Total number of out of order exemplars rejected by the exemplar storage.",
+		}),
+	}
+	s.seriesNum = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_exemplars_series",
+		Help: "Number of series with exemplars in the exemplar storage.",
+	}, func() float64 {
+		s.mtx.RLock()
+		defer s.mtx.RUnlock()
+		return float64(len(s.series))
+	})
+	if reg != nil {
+		reg.MustRegister(s.added, s.outOfOrder, s.seriesNum)
+	}
+	return s
+}
+
+// Add adds the exemplar of the series with the given labels.
+func (s *Storage) Add(lset labels.Labels, e exemplarspb.Exemplar) error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	key := lset.String()
+	ref, ok := s.series[key]
+	if ok {
+		last := s.buf[ref.last].exemplar
+		if e.Ts < last.Ts {
+			s.outOfOrder.Inc()
+			return ErrOutOfOrderExemplar
+		}
+		if e.Ts == last.Ts && e.Value == last.Value && storepb.CompareLabels(e.Labels, last.Labels) == 0 {
+			return nil
+		}
+	} else {
+		ref = &seriesRef{key: key, lset: lset}
+		s.series[key] = ref
+	}
+
+	// Series of the overwritten exemplar has no exemplars left if it was its last one.
+	if old := s.buf[s.next].ref; old != nil && old.last == s.next && old != ref {
+		delete(s.series, old.key)
+	}
+	s.buf[s.next] = entry{ref: ref, exemplar: e}
+	ref.last = s.next
+	s.next = (s.next + 1) % len(s.buf)
+	s.added.Inc()
+	return nil
+}
+
+// Select returns exemplars within the time range of series matching any of the matcher sets. Series are sorted by
+// labels and exemplars of each series by timestamp.
+func (s *Storage) Select(mint, maxt int64, matcherSets ...[]*labels.Matcher) []Data {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	var (
+		data    []Data
+		idx     = map[*seriesRef]int{}
+		skipped = map[*seriesRef]struct{}{}
+	)
+	// Oldest exemplars are at the next index to be overwritten.
+	for i := 0; i < len(s.buf); i++ {
+		e := s.buf[(s.next+i)%len(s.buf)]
+		if e.ref == nil || e.exemplar.Ts < mint || e.exemplar.Ts > maxt {
+			continue
+		}
+		if _, ok := skipped[e.ref]; ok {
+			continue
+		}
+		j, ok := idx[e.ref]
+		if !ok {
+			if !matchesAny(e.ref.lset, matcherSets) {
+				skipped[e.ref] = struct{}{}
+				continue
+			}
+			j = len(data)
+			idx[e.ref] = j
+			data = append(data, Data{SeriesLabels: e.ref.lset})
+		}
+		data[j].Exemplars = append(data[j].Exemplars, e.exemplar)
+	}
+
+	sort.Slice(data, func(i, j int) bool {
+		return labels.Compare(data[i].SeriesLabels, data[j].SeriesLabels) < 0
+	})
+	return data
+}
+
+// Data are exemplars of a single series.
+type Data struct {
+	SeriesLabels labels.Labels
+	Exemplars    []exemplarspb.Exemplar
+}
+
+func matchesAny(lset labels.Labels, matcherSets [][]*labels.Matcher) bool {
+	for _, ms := range matcherSets {
+		if matches(lset, ms) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(lset labels.Labels, ms []*labels.Matcher) bool {
+	for _, m := range ms {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package exemplars
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func exemplar(traceID string, v float64, ts int64) exemplarspb.Exemplar {
+	return exemplarspb.Exemplar{Labels: []storepb.Label{{Name: "trace_id", Value: traceID}}, Value: v, Ts: ts}
+}
+
+func mustNewMatcher(t testing.TB, mt labels.MatchType, name, value string) *labels.Matcher {
+	m, err := labels.NewMatcher(mt, name, value)
+	testutil.Ok(t, err)
+	return m
+}
+
+func TestStorage(t *testing.T) {
+	var (
+		a = labels.FromStrings("__name__", "up", "job", "a")
+		b = labels.FromStrings("__name__", "up", "job", "b")
+		c = labels.FromStrings("__name__", "down", "job", "c")
+
+		all = []*labels.Matcher{mustNewMatcher(t, labels.MatchRegexp, "job", ".+")}
+	)
+
+	s := NewStorage(nil, 4)
+	testutil.Equals(t, []Data(nil), s.Select(0, 100, all))
+
+	testutil.Ok(t, s.Add(b, exemplar("1", 1, 10)))
+	testutil.Ok(t, s.Add(a, exemplar("2", 2, 10)))
+	testutil.Ok(t, s.Add(a, exemplar("3", 3, 20)))
+	// Duplicate of the last exemplar is dropped.
+	testutil.Ok(t, s.Add(a, exemplar("3", 3, 20)))
+	testutil.Equals(t, ErrOutOfOrderExemplar, s.Add(a, exemplar("4", 4, 15)))
+
+	testutil.Equals(t, []Data{
+		{SeriesLabels: a, Exemplars: []exemplarspb.Exemplar{exemplar("2", 2, 10), exemplar("3", 3, 20)}},
+		{SeriesLabels: b, Exemplars: []exemplarspb.Exemplar{exemplar("1", 1, 10)}},
+	}, s.Select(0, 100, all))
+	testutil.Equals(t, []Data{
+		{SeriesLabels: a, Exemplars: []exemplarspb.Exemplar{exemplar("3", 3, 20)}},
+	}, s.Select(15, 100, all))
+	testutil.Equals(t, []Data{
+		{SeriesLabels: b, Exemplars: []exemplarspb.Exemplar{exemplar("1", 1, 10)}},
+	}, s.Select(0, 100, []*labels.Matcher{mustNewMatcher(t, labels.MatchEqual, "job", "b")}))
+
+	// Oldest exemplars are overwritten once the buffer is full, removing series without exemplars left.
+	testutil.Ok(t, s.Add(c, exemplar("5", 5, 30)))
+	testutil.Ok(t, s.Add(c, exemplar("6", 6, 40)))
+	testutil.Equals(t, 2, len(s.series))
+	testutil.Equals(t, []Data{
+		{SeriesLabels: c, Exemplars: []exemplarspb.Exemplar{exemplar("5", 5, 30), exemplar("6", 6, 40)}},
+		{SeriesLabels: a, Exemplars: []exemplarspb.Exemplar{exemplar("2", 2, 10), exemplar("3", 3, 20)}},
+	}, s.Select(0, 100, all))
+	testutil.Ok(t, s.Add(c, exemplar("7", 7, 50)))
+	testutil.Ok(t, s.Add(c, exemplar("8", 8, 60)))
+	testutil.Equals(t, 1, len(s.series))
+	testutil.Equals(t, []Data{
+		{SeriesLabels: c, Exemplars: []exemplarspb.Exemplar{exemplar("5", 5, 30), exemplar("6", 6, 40), exemplar("7", 7, 50), exemplar("8", 8, 60)}},
+	}, s.Select(0, 100, all))
+
+	// Series matching any of the matcher sets are returned.
+	testutil.Equals(t, []Data{
+		{SeriesLabels: c, Exemplars: []exemplarspb.Exemplar{exemplar("7", 7, 50), exemplar("8", 8, 60)}},
+	}, s.Select(45, 100,
+		[]*labels.Matcher{mustNewMatcher(t, labels.MatchEqual, "job", "a")},
+		[]*labels.Matcher{mustNewMatcher(t, labels.MatchEqual, "__name__", "down")},
+	))
+
+	// Zero size storage keeps nothing.
+	s = NewStorage(nil, 0)
+	testutil.Ok(t, s.Add(a, exemplar("1", 1, 10)))
+	testutil.Equals(t, []Data(nil), s.Select(0, 100, all))
+}
@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// Snapshot uploads all blocks of the Prometheus TSDB snapshot in dir to the bucket as blocks of the source with the
+// given external labels. Thanos metadata is injected into meta files of the snapshot in place.
+//
+// All blocks are verified and checked for overlaps with raw blocks of the same source in the bucket before anything is
+// uploaded, so a failed validation leaves the bucket untouched. Blocks already present in the bucket are skipped, so
+// an interrupted migration can be resumed by running it again. If dryRun is true, blocks are only validated.
+// Returns IDs of blocks uploaded, or to be uploaded in case of a dry run.
+func Snapshot(ctx context.Context, logger log.Logger, bkt objstore.Bucket, dir string, lset labels.Labels, dryRun bool) ([]ulid.ULID, error) {
+	if len(lset) == 0 {
+		return nil, errors.New("external labels are required to identify the source of migrated blocks")
+	}
+	if err := metadata.ValidateLabels(lset.Map()); err != nil {
+		return nil, errors.Wrap(err, "invalid external labels")
+	}
+
+	existing, err := bucketMetas(ctx, logger, bkt)
+	if err != nil {
+		return nil, err
+	}
+
+	metas, err := snapshotMetas(logger, dir, existing, lset)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]tsdb.BlockMeta, 0, len(existing)+len(metas))
+	for _, m := range existing {
+		// Downsampled blocks overlap with their raw blocks by design.
+		if m.Thanos.Downsample.Resolution != 0 || !labels.FromMap(m.Thanos.Labels).Equals(lset) {
+			continue
+		}
+		all = append(all, m.BlockMeta)
+	}
+	for _, m := range metas {
+		all = append(all, m.BlockMeta)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].MinTime < all[j].MinTime
+	})
+	if o := tsdb.OverlappingBlocks(all); len(o) > 0 {
+		return nil, errors.Errorf("blocks overlap with each other or with blocks in the bucket: %s", o.String())
+	}
+
+	var ids []ulid.ULID
+	for _, m := range metas {
+		bdir := filepath.Join(dir, m.ULID.String())
+		if err := block.VerifyIndex(logger, filepath.Join(bdir, block.IndexFilename), m.MinTime, m.MaxTime); err != nil {
+			return nil, errors.Wrapf(err, "verify block %s", m.ULID)
+		}
+		ids = append(ids, m.ULID)
+	}
+	if dryRun {
+		level.Info(logger).Log("msg", "dry run, blocks are valid and would be uploaded", "blocks", len(ids))
+		return ids, nil
+	}
+
+	for i, id := range ids {
+		bdir := filepath.Join(dir, id.String())
+		if _, err := metadata.InjectThanos(logger, bdir, metadata.Thanos{
+			Labels:     lset.Map(),
+			Downsample: metadata.ThanosDownsample{Resolution: 0},
+			Source:     metadata.PromMigrateSource,
+		}, nil); err != nil {
+			return ids[:i], errors.Wrapf(err, "inject Thanos metadata into block %s", id)
+		}
+		if err := block.Upload(ctx, logger, bkt, bdir); err != nil {
+			return ids[:i], errors.Wrapf(err, "upload block %s", id)
+		}
+		level.Info(logger).Log("msg", "uploaded block", "block", id, "progress", i+1, "blocks", len(ids))
+	}
+	return ids, nil
+}
+
+// bucketMetas returns metas of all blocks in the bucket.
+func bucketMetas(ctx context.Context, logger log.Logger, bkt objstore.Bucket) (map[ulid.ULID]metadata.Meta, error) {
+	metas := map[ulid.ULID]metadata.Meta{}
+	if err := bkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if !ok {
+			return nil
+		}
+		m, err := block.DownloadMeta(ctx, logger, bkt, id)
+		if bkt.IsObjNotFoundErr(errors.Cause(err)) {
+			// Partially uploaded or deleted block.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		metas[id] = m
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "get metas of blocks in the bucket")
+	}
+	return metas, nil
+}
+
+// snapshotMetas returns metas of non-empty blocks in the snapshot which are not in the bucket yet. Blocks in the bucket
+// already must have been migrated with the same external labels.
+func snapshotMetas(logger log.Logger, dir string, existing map[ulid.ULID]metadata.Meta, lset labels.Labels) ([]*metadata.Meta, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read snapshot dir")
+	}
+	var metas []*metadata.Meta
+	for _, fi := range fis {
+		id, ok := block.IsBlockDir(fi.Name())
+		if !ok || !fi.IsDir() {
+			continue
+		}
+		if m, ok := existing[id]; ok {
+			if !labels.FromMap(m.Thanos.Labels).Equals(lset) {
+				return nil, errors.Errorf("block %s is in the bucket already with different external labels %v", id, m.Thanos.Labels)
+			}
+			level.Info(logger).Log("msg", "block already in the bucket, skipping", "block", id)
+			continue
+		}
+		m, err := metadata.Read(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read meta of block %s", id)
+		}
+		if m.Stats.NumSamples == 0 {
+			level.Info(logger).Log("msg", "ignoring empty block", "block", id)
+			continue
+		}
+		metas = append(metas, m)
+	}
+	return metas, nil
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir, err := ioutil.TempDir("", "migrate-snapshot")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	snapshot := filepath.Join(dir, "snapshot")
+	testutil.Ok(t, os.MkdirAll(snapshot, os.ModePerm))
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+	id1, err := testutil.CreateBlock(ctx, snapshot, series, 100, 0, 1000, nil, 0)
+	testutil.Ok(t, err)
+	id2, err := testutil.CreateBlock(ctx, snapshot, series, 100, 1000, 2000, nil, 0)
+	testutil.Ok(t, err)
+
+	bkt := inmem.NewBucket()
+	lset := labels.FromStrings("cluster", "eu1", "replica", "A")
+
+	_, err = Snapshot(ctx, logger, bkt, snapshot, nil, false)
+	testutil.NotOk(t, err)
+
+	// Dry run only validates blocks.
+	ids, err := Snapshot(ctx, logger, bkt, snapshot, lset, true)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(ids))
+	testutil.Equals(t, 0, len(bkt.Objects()))
+
+	ids, err = Snapshot(ctx, logger, bkt, snapshot, lset, false)
+	testutil.Ok(t, err)
+	exp := []ulid.ULID{id1, id2}
+	sort.Slice(exp, func(i, j int) bool { return exp[i].Compare(exp[j]) < 0 })
+	testutil.Equals(t, exp, ids)
+	for _, id := range ids {
+		m, err := block.DownloadMeta(ctx, logger, bkt, id)
+		testutil.Ok(t, err)
+		testutil.Equals(t, lset.Map(), m.Thanos.Labels)
+		testutil.Equals(t, metadata.PromMigrateSource, m.Thanos.Source)
+	}
+
+	// Migrated blocks are skipped, so migration can be resumed.
+	ids, err = Snapshot(ctx, logger, bkt, snapshot, lset, false)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(ids))
+
+	// Blocks in the bucket must not be migrated again with other labels.
+	_, err = Snapshot(ctx, logger, bkt, snapshot, labels.FromStrings("cluster", "eu1", "replica", "B"), false)
+	testutil.NotOk(t, err)
+
+	// Nothing is uploaded if any block overlaps with blocks of the same source in the bucket.
+	overlapping := filepath.Join(dir, "overlapping")
+	testutil.Ok(t, os.MkdirAll(overlapping, os.ModePerm))
+	_, err = testutil.CreateBlock(ctx, overlapping, series, 100, 1500, 2500, nil, 0)
+	testutil.Ok(t, err)
+	objects := len(bkt.Objects())
+	_, err = Snapshot(ctx, logger, bkt, overlapping, lset, false)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, objects, len(bkt.Objects()))
+
+	// Blocks of other sources do not overlap.
+	ids, err = Snapshot(ctx, logger, bkt, overlapping, labels.FromStrings("cluster", "eu2"), true)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(ids))
+}
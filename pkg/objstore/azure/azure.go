@@ -28,6 +28,8 @@ type Config struct {
 	ContainerName      string `yaml:"container"`
 	Endpoint           string `yaml:"endpoint"`
 	MaxRetries         int    `yaml:"max_retries"`
+	// ListPageSize is the maximum number of entries listed per request, at most 5000. If 0, 5000 entries are listed.
+	ListPageSize int `yaml:"list_page_size"`
 }
 
 // Bucket implements the store.Bucket interface against Azure APIs.
@@ -58,6 +60,9 @@ func (conf *Config) validate() error {
 	if conf.MaxRetries < 0 {
 		return errors.New("the value of maxretries must be greater than or equal to 0 in the config file")
 	}
+	if conf.ListPageSize < 0 || conf.ListPageSize > 5000 {
+		return errors.New("the value of list_page_size must be between 0 and 5000 in the config file")
+	}
 	return nil
 }
 
@@ -112,31 +117,44 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 	}
 
 	marker := blob.Marker{}
+	pageSize := objstore.IterPageSize(ctx, b.config.ListPageSize)
+	if pageSize > 5000 {
+		pageSize = 5000
+	}
+	f = objstore.IterFunc(ctx, f)
 
+	options := blob.ListBlobsSegmentOptions{
+		Prefix:     prefix,
+		MaxResults: int32(pageSize),
+	}
 	for i := 1; ; i++ {
-		list, err := b.containerURL.ListBlobsHierarchySegment(ctx, marker, DirDelim, blob.ListBlobsSegmentOptions{
-			Prefix: prefix,
-		})
-
-		if err != nil {
-			return errors.Wrapf(err, "cannot list blobs in directory %s (iteration #%d)", dir, i)
-		}
-
-		marker = list.NextMarker
-
 		var listNames []string
-
-		for _, blob := range list.Segment.BlobItems {
-			listNames = append(listNames, blob.Name)
-		}
-
-		for _, blobPrefix := range list.Segment.BlobPrefixes {
-			listNames = append(listNames, blobPrefix.Name)
+		if objstore.IsRecursiveIter(ctx) {
+			list, err := b.containerURL.ListBlobsFlatSegment(ctx, marker, options)
+			if err != nil {
+				return errors.Wrapf(err, "cannot list blobs below directory %s (iteration #%d)", dir, i)
+			}
+			marker = list.NextMarker
+			for _, blob := range list.Segment.BlobItems {
+				listNames = append(listNames, blob.Name)
+			}
+		} else {
+			list, err := b.containerURL.ListBlobsHierarchySegment(ctx, marker, DirDelim, options)
+			if err != nil {
+				return errors.Wrapf(err, "cannot list blobs in directory %s (iteration #%d)", dir, i)
+			}
+			marker = list.NextMarker
+			for _, blob := range list.Segment.BlobItems {
+				listNames = append(listNames, blob.Name)
+			}
+			for _, blobPrefix := range list.Segment.BlobPrefixes {
+				listNames = append(listNames, blobPrefix.Name)
+			}
 		}
 
 		for _, name := range listNames {
 			if err := f(name); err != nil {
-				return err
+				return objstore.IterError(err)
 			}
 		}
 
@@ -0,0 +1,51 @@
+package objstore
+
+import (
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ChecksumMismatchError is returned by uploads whose object in the bucket does not match the checksum of the uploaded
+// data, e.g. as the data was corrupted on the network. Providers verifying checksums sent along with uploads do not
+// store such objects, objects verified after the upload are left in the bucket until overwritten by a retry.
+type ChecksumMismatchError struct {
+	Name      string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum of object %s does not match the uploaded data: expected %s, got %s", e.Algorithm, e.Name, e.Expected, e.Actual)
+}
+
+// IsChecksumMismatchErr returns true if the base error is a ChecksumMismatchError.
+func IsChecksumMismatchErr(err error) bool {
+	_, ok := errors.Cause(err).(ChecksumMismatchError)
+	return ok
+}
+
+// UploadHash hashes the data uploaded from r with h. If r is an io.ReadSeeker, e.g. an *os.File, it is read once and
+// rewound, so the checksum is known before the upload and can be sent along with it. r is returned unchanged and
+// precomputed is true then. Otherwise the returned reader hashes the data while it is uploaded, so the checksum is
+// known once the upload read all data.
+func UploadHash(r io.Reader, h hash.Hash) (_ io.Reader, precomputed bool, _ error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		return io.TeeReader(r, h), false, nil
+	}
+	off, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "get offset")
+	}
+	if _, err := io.Copy(h, rs); err != nil {
+		return nil, false, errors.Wrap(err, "hash upload")
+	}
+	if _, err := rs.Seek(off, io.SeekStart); err != nil {
+		return nil, false, errors.Wrap(err, "rewind")
+	}
+	return r, true, nil
+}
@@ -0,0 +1,74 @@
+package objstore_test
+
+import (
+	"context"
+	"crypto/md5"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestUploadHash(t *testing.T) {
+	expected := md5.Sum([]byte("data"))
+
+	// Seekable readers are hashed upfront and rewound to their offset.
+	rs := strings.NewReader("xdata")
+	_, err := rs.Seek(1, io.SeekStart)
+	testutil.Ok(t, err)
+	h := md5.New()
+	r, precomputed, err := objstore.UploadHash(rs, h)
+	testutil.Ok(t, err)
+	testutil.Assert(t, precomputed, "checksum of seekable reader not precomputed")
+	testutil.Equals(t, expected[:], h.Sum(nil))
+	b, err := ioutil.ReadAll(r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "data", string(b))
+
+	// Other readers are hashed while read.
+	h = md5.New()
+	r, precomputed, err = objstore.UploadHash(ioutil.NopCloser(strings.NewReader("data")), h)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !precomputed, "checksum of reader precomputed")
+	b, err = ioutil.ReadAll(r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "data", string(b))
+	testutil.Equals(t, expected[:], h.Sum(nil))
+}
+
+// corruptingBucket fails uploads with a checksum mismatch.
+type corruptingBucket struct {
+	objstore.Bucket
+}
+
+func (b corruptingBucket) Upload(_ context.Context, name string, _ io.Reader) error {
+	return errors.Wrap(objstore.ChecksumMismatchError{Name: name, Algorithm: "MD5", Expected: "a", Actual: "b"}, "upload")
+}
+
+func TestBucketWithMetrics_ChecksumMismatches(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	bkt := objstore.BucketWithMetrics("test", corruptingBucket{Bucket: inmem.NewBucket()}, reg)
+
+	err := bkt.Upload(ctx, "obj", strings.NewReader("data"))
+	testutil.NotOk(t, err)
+	testutil.Assert(t, objstore.IsChecksumMismatchErr(err), "not a checksum mismatch: %v", err)
+	testutil.Assert(t, !objstore.IsChecksumMismatchErr(errors.New("upload failed")), "other errors are checksum mismatches")
+
+	mfs, err := reg.Gather()
+	testutil.Ok(t, err)
+	values := map[string]float64{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			values[mf.GetName()] += m.GetCounter().GetValue()
+		}
+	}
+	testutil.Equals(t, float64(1), values["thanos_objstore_bucket_operation_checksum_mismatches_total"])
+	testutil.Equals(t, float64(1), values["thanos_objstore_bucket_operation_failures_total"])
+}
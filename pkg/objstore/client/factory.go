@@ -1,20 +1,18 @@
 package client
 
 import (
-	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
-	"github.com/thanos-io/thanos/pkg/objstore/azure"
-	"github.com/thanos-io/thanos/pkg/objstore/cos"
-	"github.com/thanos-io/thanos/pkg/objstore/gcs"
-	"github.com/thanos-io/thanos/pkg/objstore/s3"
-	"github.com/thanos-io/thanos/pkg/objstore/swift"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -28,9 +26,58 @@ const (
 	COS   ObjProvider = "COS"
 )
 
+// ProviderFactory creates a bucket of a provider from the YAML content of its config.
+type ProviderFactory func(logger log.Logger, config []byte, component string) (objstore.Bucket, error)
+
+var (
+	providersMtx sync.RWMutex
+	providers    = map[ObjProvider]ProviderFactory{}
+)
+
+// RegisterProvider makes the provider of the given type available to bucket configurations. Built-in providers
+// register themselves unless excluded by their `noobjstore_<provider>` build tag, e.g. `noobjstore_swift`, so
+// binaries can be built with only the providers they need. Embedders may register own providers the same way.
+// It panics if the type is registered twice.
+func RegisterProvider(typ ObjProvider, factory ProviderFactory) {
+	providersMtx.Lock()
+	defer providersMtx.Unlock()
+
+	typ = ObjProvider(strings.ToUpper(string(typ)))
+	if _, ok := providers[typ]; ok {
+		panic(fmt.Sprintf("objstore provider %s registered twice", typ))
+	}
+	providers[typ] = factory
+}
+
+// Providers returns the types of all registered providers, sorted.
+func Providers() []ObjProvider {
+	providersMtx.RLock()
+	defer providersMtx.RUnlock()
+
+	res := make([]ObjProvider, 0, len(providers))
+	for typ := range providers {
+		res = append(res, typ)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
 type BucketConfig struct {
 	Type   ObjProvider `yaml:"type"`
 	Config interface{} `yaml:"config"`
+	// Timeouts are opt-in timeouts of bucket operations by operation type, see objstore.Timeouts.
+	Timeouts objstore.Timeouts `yaml:"timeouts"`
+	// Prefix is the directory of the bucket all objects are stored in, so multiple buckets can share a single bucket
+	// of the object storage.
+	Prefix string `yaml:"prefix"`
+	// Routes route blocks of some resolutions to other buckets, see objstore.RoutingBucket.
+	Routes []RouteConfig `yaml:"routes"`
+}
+
+// RouteConfig configures the bucket blocks of the given resolutions are written to.
+type RouteConfig struct {
+	Resolutions []model.Duration `yaml:"resolutions"`
+	Bucket      BucketConfig     `yaml:"bucket"`
 }
 
 // NewBucket initializes and returns new object storage clients.
@@ -42,28 +89,56 @@ func NewBucket(logger log.Logger, confContentYaml []byte, reg prometheus.Registe
 		return nil, errors.Wrap(err, "parsing config YAML file")
 	}
 
+	bucket, err := newBucket(logger, bucketConf, component)
+	if err != nil {
+		return nil, err
+	}
+	if len(bucketConf.Routes) == 0 {
+		return objstore.BucketWithMetrics(bucket.Name(), bucket, reg), nil
+	}
+
+	routes := make([]objstore.ResolutionRoute, 0, len(bucketConf.Routes))
+	for i, r := range bucketConf.Routes {
+		if len(r.Bucket.Routes) > 0 {
+			return nil, errors.Errorf("route %d: nested routes are not supported", i)
+		}
+		if len(r.Resolutions) == 0 {
+			return nil, errors.Errorf("route %d: no resolutions", i)
+		}
+		rb, err := newBucket(logger, &r.Bucket, component)
+		if err != nil {
+			return nil, errors.Wrapf(err, "route %d", i)
+		}
+		route := objstore.ResolutionRoute{Bucket: rb}
+		for _, res := range r.Resolutions {
+			route.Resolutions = append(route.Resolutions, int64(time.Duration(res)/time.Millisecond))
+		}
+		routes = append(routes, route)
+	}
+	return objstore.BucketWithMetrics(bucket.Name(), objstore.NewRoutingBucket(bucket, routes), reg), nil
+}
+
+func newBucket(logger log.Logger, bucketConf *BucketConfig, component string) (objstore.Bucket, error) {
 	config, err := yaml.Marshal(bucketConf.Config)
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal content of bucket configuration")
 	}
 
-	var bucket objstore.Bucket
-	switch strings.ToUpper(string(bucketConf.Type)) {
-	case string(GCS):
-		bucket, err = gcs.NewBucket(context.Background(), logger, config, component)
-	case string(S3):
-		bucket, err = s3.NewBucket(logger, config, component)
-	case string(AZURE):
-		bucket, err = azure.NewBucket(logger, config, component)
-	case string(SWIFT):
-		bucket, err = swift.NewContainer(logger, config)
-	case string(COS):
-		bucket, err = cos.NewBucket(logger, config, component)
-	default:
+	providersMtx.RLock()
+	factory, ok := providers[ObjProvider(strings.ToUpper(string(bucketConf.Type)))]
+	providersMtx.RUnlock()
+	if !ok {
 		return nil, errors.Errorf("bucket with type %s is not supported", bucketConf.Type)
 	}
+	bucket, err := factory(logger, config, component)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("create %s client", bucketConf.Type))
 	}
-	return objstore.BucketWithMetrics(bucket.Name(), bucket, reg), nil
+	if bucketConf.Timeouts != (objstore.Timeouts{}) {
+		bucket = objstore.BucketWithTimeouts(bucket, bucketConf.Timeouts)
+	}
+	if bucketConf.Prefix != "" {
+		bucket = objstore.NewPrefixedBucket(bucket, bucketConf.Prefix)
+	}
+	return bucket, nil
 }
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestRegisterProvider(t *testing.T) {
+	var gotConfig string
+	RegisterProvider("test", func(_ log.Logger, config []byte, _ string) (objstore.Bucket, error) {
+		gotConfig = string(config)
+		return inmem.NewBucket(), nil
+	})
+	defer func() {
+		providersMtx.Lock()
+		delete(providers, "TEST")
+		providersMtx.Unlock()
+	}()
+
+	testutil.Equals(t, []ObjProvider{AZURE, COS, GCS, S3, SWIFT, "TEST"}, Providers())
+
+	bkt, err := NewBucket(log.NewNopLogger(), []byte("type: Test\nconfig:\n  a: b\n"), nil, "test")
+	testutil.Ok(t, err)
+	testutil.Equals(t, "inmem", bkt.Name())
+	testutil.Equals(t, "a: b\n", gotConfig)
+
+	_, err = NewBucket(log.NewNopLogger(), []byte("type: unknown\n"), nil, "test")
+	testutil.NotOk(t, err)
+
+	defer func() { testutil.Assert(t, recover() != nil, "registering a type twice did not panic") }()
+	RegisterProvider("TEST", nil)
+}
@@ -0,0 +1,16 @@
+//go:build !noobjstore_azure
+// +build !noobjstore_azure
+
+package client
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/azure"
+)
+
+func init() {
+	RegisterProvider(AZURE, func(logger log.Logger, config []byte, component string) (objstore.Bucket, error) {
+		return azure.NewBucket(logger, config, component)
+	})
+}
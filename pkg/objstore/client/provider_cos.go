@@ -0,0 +1,16 @@
+//go:build !noobjstore_cos
+// +build !noobjstore_cos
+
+package client
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/cos"
+)
+
+func init() {
+	RegisterProvider(COS, func(logger log.Logger, config []byte, component string) (objstore.Bucket, error) {
+		return cos.NewBucket(logger, config, component)
+	})
+}
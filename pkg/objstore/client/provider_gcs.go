@@ -0,0 +1,18 @@
+//go:build !noobjstore_gcs
+// +build !noobjstore_gcs
+
+package client
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/gcs"
+)
+
+func init() {
+	RegisterProvider(GCS, func(logger log.Logger, config []byte, component string) (objstore.Bucket, error) {
+		return gcs.NewBucket(context.Background(), logger, config, component)
+	})
+}
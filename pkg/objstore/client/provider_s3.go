@@ -0,0 +1,16 @@
+//go:build !noobjstore_s3
+// +build !noobjstore_s3
+
+package client
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/s3"
+)
+
+func init() {
+	RegisterProvider(S3, func(logger log.Logger, config []byte, component string) (objstore.Bucket, error) {
+		return s3.NewBucket(logger, config, component)
+	})
+}
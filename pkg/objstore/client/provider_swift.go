@@ -0,0 +1,16 @@
+//go:build !noobjstore_swift
+// +build !noobjstore_swift
+
+package client
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/swift"
+)
+
+func init() {
+	RegisterProvider(SWIFT, func(logger log.Logger, config []byte, _ string) (objstore.Bucket, error) {
+		return swift.NewContainer(logger, config)
+	})
+}
@@ -27,6 +27,8 @@ type Bucket struct {
 	logger log.Logger
 	client *cos.Client
 	name   string
+
+	listPageSize int
 }
 
 // Config encapsulates the necessary config values to instantiate an cos client.
@@ -36,6 +38,8 @@ type Config struct {
 	AppId     string `yaml:"app_id"`
 	SecretKey string `yaml:"secret_key"`
 	SecretId  string `yaml:"secret_id"`
+	// ListPageSize is the maximum number of entries listed per request, at most 1000. If 0, 1000 entries are listed.
+	ListPageSize int `yaml:"list_page_size"`
 }
 
 // Validate checks to see if mandatory cos config options are set.
@@ -47,6 +51,9 @@ func (conf *Config) validate() error {
 		conf.SecretKey == "" {
 		return errors.New("insufficient cos configuration information")
 	}
+	if conf.ListPageSize < 0 || conf.ListPageSize > 1000 {
+		return errors.New("list_page_size must be between 0 and 1000")
+	}
 	return nil
 }
 
@@ -81,6 +88,8 @@ func NewBucket(logger log.Logger, conf []byte, component string) (*Bucket, error
 		logger: logger,
 		client: client,
 		name:   config.Bucket,
+
+		listPageSize: config.ListPageSize,
 	}
 	return bkt, nil
 }
@@ -113,6 +122,11 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 		dir = strings.TrimSuffix(dir, dirDelim) + dirDelim
 	}
 
+	// Stop listing once f stops the iteration.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	f = objstore.IterFunc(ctx, f)
 	for object := range b.listObjects(ctx, dir) {
 		if object.err != nil {
 			return object.err
@@ -121,7 +135,7 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 			continue
 		}
 		if err := f(object.key); err != nil {
-			return err
+			return objstore.IterError(err)
 		}
 	}
 
@@ -200,13 +214,21 @@ func (b *Bucket) listObjects(ctx context.Context, objectPrefix string) <-chan ob
 
 	go func(objectsCh chan<- objectInfo) {
 		defer close(objectsCh)
-		var marker string
+		marker, _ := objstore.IterStartAfter(ctx)
+		pageSize := objstore.IterPageSize(ctx, b.listPageSize)
+		if pageSize <= 0 || pageSize > 1000 {
+			pageSize = 1000
+		}
+		delimiter := dirDelim
+		if objstore.IsRecursiveIter(ctx) {
+			delimiter = ""
+		}
 		for {
 			result, _, err := b.client.Bucket.Get(ctx, &cos.BucketGetOptions{
 				Prefix:    objectPrefix,
-				MaxKeys:   1000,
+				MaxKeys:   pageSize,
 				Marker:    marker,
-				Delimiter: dirDelim,
+				Delimiter: delimiter,
 			})
 			if err != nil {
 				select {
@@ -245,6 +267,10 @@ func (b *Bucket) listObjects(ctx context.Context, objectPrefix string) <-chan ob
 			}
 
 			marker = result.NextMarker
+			// The next marker is only returned for listings with delimiter.
+			if marker == "" && len(result.Contents) > 0 {
+				marker = result.Contents[len(result.Contents)-1].Key
+			}
 		}
 	}(objectsCh)
 	return objectsCh
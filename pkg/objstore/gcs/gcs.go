@@ -4,8 +4,11 @@ package gcs
 import (
 	"context"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"math/rand"
+	"net/http"
+	"os"
 	"runtime"
 	"strings"
 	"testing"
@@ -13,10 +16,12 @@ import (
 
 	"cloud.google.com/go/storage"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/version"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 	yaml "gopkg.in/yaml.v2"
@@ -25,10 +30,15 @@ import (
 // DirDelim is the delimiter used to model a directory structure in an object store bucket.
 const DirDelim = "/"
 
+// emulatorHostEnvVar is the environment variable pointing the GCS client to an emulator.
+const emulatorHostEnvVar = "STORAGE_EMULATOR_HOST"
+
 // Config stores the configuration for gcs bucket.
 type Config struct {
 	Bucket         string `yaml:"bucket"`
 	ServiceAccount string `yaml:"service_account"`
+	// ListPageSize is the maximum number of entries listed per request. If 0, the client default is used.
+	ListPageSize int `yaml:"list_page_size"`
 }
 
 // Bucket implements the store.Bucket and shipper.Bucket interfaces against GCS.
@@ -37,11 +47,17 @@ type Bucket struct {
 	bkt    *storage.BucketHandle
 	name   string
 
+	listPageSize int
+
 	closer io.Closer
 }
 
 // NewBucket returns a new Bucket against the given bucket handle.
 func NewBucket(ctx context.Context, logger log.Logger, conf []byte, component string) (*Bucket, error) {
+	return newBucket(ctx, logger, conf, component)
+}
+
+func newBucket(ctx context.Context, logger log.Logger, conf []byte, component string, extraOpts ...option.ClientOption) (*Bucket, error) {
 	var gc Config
 	if err := yaml.Unmarshal(conf, &gc); err != nil {
 		return nil, err
@@ -49,6 +65,9 @@ func NewBucket(ctx context.Context, logger log.Logger, conf []byte, component st
 	if gc.Bucket == "" {
 		return nil, errors.New("missing Google Cloud Storage bucket name for stored blocks")
 	}
+	if gc.ListPageSize < 0 {
+		return nil, errors.New("list_page_size must not be negative")
+	}
 
 	var opts []option.ClientOption
 
@@ -64,6 +83,7 @@ func NewBucket(ctx context.Context, logger log.Logger, conf []byte, component st
 	opts = append(opts,
 		option.WithUserAgent(fmt.Sprintf("thanos-%s/%s (%s)", component, version.Version, runtime.Version())),
 	)
+	opts = append(opts, extraOpts...)
 
 	gcsClient, err := storage.NewClient(ctx, opts...)
 	if err != nil {
@@ -74,6 +94,8 @@ func NewBucket(ctx context.Context, logger log.Logger, conf []byte, component st
 		bkt:    gcsClient.Bucket(gc.Bucket),
 		closer: gcsClient,
 		name:   gc.Bucket,
+
+		listPageSize: gc.ListPageSize,
 	}
 	return bkt, nil
 }
@@ -91,10 +113,17 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 	if dir != "" {
 		dir = strings.TrimSuffix(dir, DirDelim) + DirDelim
 	}
+	delimiter := DirDelim
+	if objstore.IsRecursiveIter(ctx) {
+		delimiter = ""
+	}
 	it := b.bkt.Objects(ctx, &storage.Query{
 		Prefix:    dir,
-		Delimiter: DirDelim,
+		Delimiter: delimiter,
 	})
+	// Pages are requested as entries are consumed, so nothing is listed after f stops the iteration.
+	it.PageInfo().MaxSize = objstore.IterPageSize(ctx, b.listPageSize)
+	f = objstore.IterFunc(ctx, f)
 	for {
 		select {
 		case <-ctx.Done():
@@ -109,7 +138,7 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 			return err
 		}
 		if err := f(attrs.Prefix + attrs.Name); err != nil {
-			return err
+			return objstore.IterError(err)
 		}
 	}
 }
@@ -141,13 +170,47 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 }
 
 // Upload writes the file specified in src to remote GCS location specified as target.
+// Uploads are checksummed with CRC32C. The checksum of data read from files is sent along with the upload, so GCS
+// rejects corrupted uploads. The checksum of other uploads is compared with the checksum of the uploaded object,
+// which is deleted if they do not match.
 func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
-	w := b.bkt.Object(name).NewWriter(ctx)
+	crc := crc32.New(crc32cTable)
+	r, precomputed, err := objstore.UploadHash(r, crc)
+	if err != nil {
+		return errors.Wrap(err, "checksum upload")
+	}
 
+	w := b.bkt.Object(name).NewWriter(ctx)
+	if precomputed {
+		w.CRC32C = crc.Sum32()
+		w.SendCRC32C = true
+	}
 	if _, err := io.Copy(w, r); err != nil {
 		return err
 	}
-	return w.Close()
+	if err := w.Close(); err != nil {
+		if precomputed && isCRC32CMismatchErr(err) {
+			return objstore.ChecksumMismatchError{Name: name, Algorithm: "CRC32C", Expected: fmt.Sprintf("%08x", crc.Sum32()), Actual: err.Error()}
+		}
+		return err
+	}
+
+	if actual := w.Attrs().CRC32C; actual != crc.Sum32() {
+		if err := b.bkt.Object(name).Delete(ctx); err != nil {
+			level.Warn(b.logger).Log("msg", "failed to delete object not matching the checksum of the upload", "name", name, "err", err)
+		}
+		return objstore.ChecksumMismatchError{Name: name, Algorithm: "CRC32C", Expected: fmt.Sprintf("%08x", crc.Sum32()), Actual: fmt.Sprintf("%08x", actual)}
+	}
+	return nil
+}
+
+// crc32cTable is the table of the Castagnoli polynomial used by GCS for CRC32C checksums.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// isCRC32CMismatchErr returns true if GCS rejected an upload as the sent CRC32C checksum did not match its data.
+func isCRC32CMismatchErr(err error) bool {
+	gerr, ok := errors.Cause(err).(*googleapi.Error)
+	return ok && gerr.Code == http.StatusBadRequest && strings.Contains(strings.ToLower(gerr.Message), "crc32c")
 }
 
 // Delete removes the object with the given name.
@@ -167,6 +230,32 @@ func (b *Bucket) Close() error {
 // NewTestBucket creates test bkt client that before returning creates temporary bucket.
 // In a close function it empties and deletes the bucket.
 func NewTestBucket(t testing.TB, project string) (objstore.Bucket, func(), error) {
+	return newTestBucket(t, project)
+}
+
+// NewTestBucketWithEmulator is like NewTestBucket, but against the GCS emulator listening on the given host, e.g.
+// fake-gcs-server (https://github.com/fsouza/fake-gcs-server) started with plain HTTP.
+func NewTestBucketWithEmulator(t testing.TB, host string) (objstore.Bucket, func(), error) {
+	// The client takes the host of the XML API used for reads from the environment only, when it is created.
+	prev, ok := os.LookupEnv(emulatorHostEnvVar)
+	if err := os.Setenv(emulatorHostEnvVar, host); err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if ok {
+			_ = os.Setenv(emulatorHostEnvVar, prev)
+			return
+		}
+		_ = os.Unsetenv(emulatorHostEnvVar)
+	}()
+
+	return newTestBucket(t, "thanos-e2e-test",
+		option.WithEndpoint(fmt.Sprintf("http://%s/storage/v1/", host)),
+		option.WithoutAuthentication(),
+	)
+}
+
+func newTestBucket(t testing.TB, project string, opts ...option.ClientOption) (objstore.Bucket, func(), error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	src := rand.NewSource(time.Now().UnixNano())
@@ -179,7 +268,7 @@ func NewTestBucket(t testing.TB, project string) (objstore.Bucket, func(), error
 		return nil, nil, err
 	}
 
-	b, err := NewBucket(ctx, log.NewNopLogger(), bc, "thanos-e2e-test")
+	b, err := newBucket(ctx, log.NewNopLogger(), bc, "thanos-e2e-test", opts...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -37,7 +37,7 @@ func (b *Bucket) Objects() map[string][]byte {
 
 // Iter calls f for each entry in the given directory. The argument to f is the full
 // object name including the prefix of the inspected directory.
-func (b *Bucket) Iter(_ context.Context, dir string, f func(string) error) error {
+func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) error {
 	unique := map[string]struct{}{}
 
 	var dirPartsCount int
@@ -55,6 +55,10 @@ func (b *Bucket) Iter(_ context.Context, dir string, f func(string) error) error
 			continue
 		}
 
+		if objstore.IsRecursiveIter(ctx) {
+			unique[filename] = struct{}{}
+			continue
+		}
 		parts := strings.SplitAfter(filename, objstore.DirDelim)
 		unique[strings.Join(parts[:dirPartsCount+1], "")] = struct{}{}
 	}
@@ -78,9 +82,10 @@ func (b *Bucket) Iter(_ context.Context, dir string, f func(string) error) error
 		return strings.Compare(keys[i], keys[j]) < 0
 	})
 
+	f = objstore.IterFunc(ctx, f)
 	for _, k := range keys {
 		if err := f(k); err != nil {
-			return err
+			return objstore.IterError(err)
 		}
 	}
 	return nil
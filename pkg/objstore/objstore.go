@@ -53,6 +53,134 @@ type BucketReader interface {
 	IsObjNotFoundErr(err error) bool
 }
 
+// ErrStopIter stops Iter without error if returned by the function called for each entry, e.g. once the caller found
+// the entries it needs.
+var ErrStopIter = errors.New("stop iteration")
+
+type ctxKey int
+
+const (
+	blockResolutionKey ctxKey = iota
+	iterMaxKeysKey
+	iterStartAfterKey
+	iterRecursiveKey
+)
+
+// WithBlockResolution returns a context telling the bucket that objects uploaded with it belong to a block of the given
+// downsampling resolution, e.g. so the bucket can choose the storage class of the objects.
+func WithBlockResolution(ctx context.Context, resolution int64) context.Context {
+	return context.WithValue(ctx, blockResolutionKey, resolution)
+}
+
+// BlockResolution returns the block resolution set by WithBlockResolution.
+func BlockResolution(ctx context.Context) (int64, bool) {
+	res, ok := ctx.Value(blockResolutionKey).(int64)
+	return res, ok
+}
+
+// WithIterMaxKeys returns a context telling Iter that the caller needs at most n entries, so the bucket lists no more
+// than needed. Iter stops after calling the function for n entries.
+func WithIterMaxKeys(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, iterMaxKeysKey, n)
+}
+
+// IterMaxKeys returns the number of entries set by WithIterMaxKeys.
+func IterMaxKeys(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(iterMaxKeysKey).(int)
+	return n, ok
+}
+
+// WithIterStartAfter returns a context telling Iter to skip entries whose names are not lexicographically greater than
+// the given full object name, e.g. blocks older than a given ULID, so the bucket can start listing after it.
+func WithIterStartAfter(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, iterStartAfterKey, name)
+}
+
+// IterStartAfter returns the name set by WithIterStartAfter.
+func IterStartAfter(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(iterStartAfterKey).(string)
+	return name, ok
+}
+
+// WithRecursiveIter returns a context telling Iter to list all objects below the given directory with their full names,
+// instead of the entries of the directory only, e.g. <ULID>/meta.json instead of <ULID>/. Buckets which cannot list
+// recursively list the entries of the directory, so callers must handle both.
+func WithRecursiveIter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, iterRecursiveKey, true)
+}
+
+// IsRecursiveIter returns true if WithRecursiveIter was set.
+func IsRecursiveIter(ctx context.Context) bool {
+	recursive, _ := ctx.Value(iterRecursiveKey).(bool)
+	return recursive
+}
+
+// IterPageSize returns the number of entries to request per listing page out of the configured page size, if any, and
+// the number of entries set by WithIterMaxKeys. Zero means the default page size of the object storage.
+func IterPageSize(ctx context.Context, pageSize int) int {
+	if n, ok := IterMaxKeys(ctx); ok && n > 0 && (pageSize <= 0 || n < pageSize) {
+		return n
+	}
+	return pageSize
+}
+
+// IterFunc returns f honouring the hints set by WithIterMaxKeys and WithIterStartAfter, for buckets which cannot pass
+// them to the object storage or only as a page size. Bucket implementations call it for each listed entry in Iter
+// and return errors of it through IterError.
+func IterFunc(ctx context.Context, f func(string) error) func(string) error {
+	startAfter, _ := IterStartAfter(ctx)
+	maxKeys, limited := IterMaxKeys(ctx)
+	n := 0
+	return func(name string) error {
+		if name <= startAfter {
+			return nil
+		}
+		if limited && n >= maxKeys {
+			return ErrStopIter
+		}
+		n++
+		if err := f(name); err != nil {
+			return err
+		}
+		if limited && n >= maxKeys {
+			return ErrStopIter
+		}
+		return nil
+	}
+}
+
+// IterError returns the error Iter returns for the given error of the function called for each entry, i.e. nil if it
+// stopped the iteration with ErrStopIter.
+func IterError(err error) error {
+	if errors.Cause(err) == ErrStopIter {
+		return nil
+	}
+	return err
+}
+
+// ObjectSizer is implemented by readers wrapping other readers, e.g. of bucket wrappers, which can tell the size of the
+// object read upfront.
+type ObjectSizer interface {
+	// ObjectSize returns the size of the object read, or an error if it is unknown.
+	ObjectSize() (int64, error)
+}
+
+// TryToGetSize returns the size of the object read by the reader, if it is known upfront: of files, and of readers
+// implementing ObjectSizer.
+func TryToGetSize(r io.Reader) (int64, error) {
+	switch f := r.(type) {
+	case *os.File:
+		fileInfo, err := f.Stat()
+		if err != nil {
+			return 0, errors.Wrap(err, "stat file")
+		}
+		return fileInfo.Size(), nil
+	case ObjectSizer:
+		return f.ObjectSize()
+	}
+	return 0, errors.Errorf("unsupported type of reader %T", r)
+}
+
 // UploadDir uploads all files in srcdir to the bucket with into a top-level directory
 // named dstdir. It is a caller responsibility to clean partial upload in case of failure.
 func UploadDir(ctx context.Context, logger log.Logger, bkt Bucket, srcdir, dstdir string) error {
@@ -207,13 +335,71 @@ func BucketWithMetrics(name string, b Bucket, r prometheus.Registerer) Bucket {
 			Name: "thanos_objstore_bucket_last_successful_upload_time",
 			Help: "Second timestamp of the last successful upload to the bucket.",
 		}, []string{"bucket"}),
+
+		opsClass: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_operation_class_total",
+			Help:        "Total number of operations against a bucket by pricing class of object storage providers. Class A are list and write operations, class B are read operations, free are delete operations.",
+			ConstLabels: prometheus.Labels{"bucket": name},
+		}, []string{"class"}),
+
+		transferredBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_transferred_bytes_total",
+			Help:        "Total number of bytes transferred from and to a bucket. It estimates network traffic billed by object storage providers.",
+			ConstLabels: prometheus.Labels{"bucket": name},
+		}, []string{"direction"}),
+
+		checksumMismatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_operation_checksum_mismatches_total",
+			Help:        "Total number of operations against a bucket that failed, as the checksum of the object in the bucket did not match the transferred data.",
+			ConstLabels: prometheus.Labels{"bucket": name},
+		}, []string{"operation"}),
+	}
+	bkt.checksumMismatches.WithLabelValues("upload")
+	for _, c := range []string{opClassA, opClassB, opClassFree} {
+		bkt.opsClass.WithLabelValues(c)
+	}
+	for _, d := range []string{directionDownload, directionUpload} {
+		bkt.transferredBytes.WithLabelValues(d)
 	}
 	if r != nil {
-		r.MustRegister(bkt.ops, bkt.opsFailures, bkt.opsDuration, bkt.lastSuccessfullUploadTime)
+		r.MustRegister(bkt.ops, bkt.opsFailures, bkt.opsDuration, bkt.lastSuccessfullUploadTime, bkt.opsClass, bkt.transferredBytes, bkt.checksumMismatches)
 	}
 	return bkt
 }
 
+// Pricing classes of operations, similar across object storage providers.
+const (
+	opClassA    = "A"
+	opClassB    = "B"
+	opClassFree = "free"
+)
+
+const (
+	directionDownload = "download"
+	directionUpload   = "upload"
+)
+
+// operationClass returns the pricing class of the given bucket operation.
+func operationClass(op string) string {
+	switch op {
+	case "iter", "upload":
+		return opClassA
+	case "delete":
+		return opClassFree
+	default:
+		return opClassB
+	}
+}
+
+// TransferredBytes returns the counter of bytes transferred from and to the bucket returned by BucketWithMetrics, with
+// the direction label set to download or upload. It returns nil for other buckets.
+func TransferredBytes(b Bucket) *prometheus.CounterVec {
+	if mb, ok := b.(*metricBucket); ok {
+		return mb.transferredBytes
+	}
+	return nil
+}
+
 type metricBucket struct {
 	bkt Bucket
 
@@ -221,6 +407,15 @@ type metricBucket struct {
 	opsFailures               *prometheus.CounterVec
 	opsDuration               *prometheus.HistogramVec
 	lastSuccessfullUploadTime *prometheus.GaugeVec
+
+	opsClass           *prometheus.CounterVec
+	transferredBytes   *prometheus.CounterVec
+	checksumMismatches *prometheus.CounterVec
+}
+
+func (b *metricBucket) inc(op string) {
+	b.ops.WithLabelValues(op).Inc()
+	b.opsClass.WithLabelValues(operationClass(op)).Inc()
 }
 
 func (b *metricBucket) Iter(ctx context.Context, dir string, f func(name string) error) error {
@@ -230,14 +425,14 @@ func (b *metricBucket) Iter(ctx context.Context, dir string, f func(name string)
 	if err != nil {
 		b.opsFailures.WithLabelValues(op).Inc()
 	}
-	b.ops.WithLabelValues(op).Inc()
+	b.inc(op)
 
 	return err
 }
 
 func (b *metricBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
 	const op = "get"
-	b.ops.WithLabelValues(op).Inc()
+	b.inc(op)
 
 	rc, err := b.bkt.Get(ctx, name)
 	if err != nil {
@@ -249,6 +444,7 @@ func (b *metricBucket) Get(ctx context.Context, name string) (io.ReadCloser, err
 		op,
 		b.opsDuration,
 		b.opsFailures,
+		b.transferredBytes.WithLabelValues(directionDownload),
 	)
 
 	return rc, nil
@@ -256,7 +452,7 @@ func (b *metricBucket) Get(ctx context.Context, name string) (io.ReadCloser, err
 
 func (b *metricBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
 	const op = "get_range"
-	b.ops.WithLabelValues(op).Inc()
+	b.inc(op)
 
 	rc, err := b.bkt.GetRange(ctx, name, off, length)
 	if err != nil {
@@ -268,6 +464,7 @@ func (b *metricBucket) GetRange(ctx context.Context, name string, off, length in
 		op,
 		b.opsDuration,
 		b.opsFailures,
+		b.transferredBytes.WithLabelValues(directionDownload),
 	)
 
 	return rc, nil
@@ -281,7 +478,7 @@ func (b *metricBucket) Exists(ctx context.Context, name string) (bool, error) {
 	if err != nil {
 		b.opsFailures.WithLabelValues(op).Inc()
 	}
-	b.ops.WithLabelValues(op).Inc()
+	b.inc(op)
 	b.opsDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
 
 	return ok, err
@@ -291,14 +488,30 @@ func (b *metricBucket) Upload(ctx context.Context, name string, r io.Reader) err
 	const op = "upload"
 	start := time.Now()
 
+	uploaded := b.transferredBytes.WithLabelValues(directionUpload)
+	// Some providers rely on *os.File to find out the size of the upload, so files are not wrapped. Their size is
+	// counted after the successful upload instead.
+	f, isFile := r.(*os.File)
+	if !isFile {
+		r = &countingReader{Reader: r, bytes: uploaded}
+	}
+
 	err := b.bkt.Upload(ctx, name, r)
 	if err != nil {
 		b.opsFailures.WithLabelValues(op).Inc()
+		if IsChecksumMismatchErr(err) {
+			b.checksumMismatches.WithLabelValues(op).Inc()
+		}
 	} else {
+		if isFile {
+			if info, serr := f.Stat(); serr == nil {
+				uploaded.Add(float64(info.Size()))
+			}
+		}
 		// TODO: Use SetToCurrentTime() once we update the Prometheus client_golang.
 		b.lastSuccessfullUploadTime.WithLabelValues(b.bkt.Name()).Set(float64(time.Now().UnixNano()) / 1e9)
 	}
-	b.ops.WithLabelValues(op).Inc()
+	b.inc(op)
 	b.opsDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
 
 	return err
@@ -312,7 +525,7 @@ func (b *metricBucket) Delete(ctx context.Context, name string) error {
 	if err != nil {
 		b.opsFailures.WithLabelValues(op).Inc()
 	}
-	b.ops.WithLabelValues(op).Inc()
+	b.inc(op)
 	b.opsDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
 
 	return err
@@ -338,9 +551,10 @@ type timingReadCloser struct {
 	op       string
 	duration *prometheus.HistogramVec
 	failed   *prometheus.CounterVec
+	read     prometheus.Counter
 }
 
-func newTimingReadCloser(rc io.ReadCloser, op string, dur *prometheus.HistogramVec, failed *prometheus.CounterVec) *timingReadCloser {
+func newTimingReadCloser(rc io.ReadCloser, op string, dur *prometheus.HistogramVec, failed *prometheus.CounterVec, read prometheus.Counter) *timingReadCloser {
 	// Initialize the metrics with 0.
 	dur.WithLabelValues(op)
 	failed.WithLabelValues(op)
@@ -351,6 +565,7 @@ func newTimingReadCloser(rc io.ReadCloser, op string, dur *prometheus.HistogramV
 		op:         op,
 		duration:   dur,
 		failed:     failed,
+		read:       read,
 	}
 }
 
@@ -366,9 +581,26 @@ func (rc *timingReadCloser) Close() error {
 
 func (rc *timingReadCloser) Read(b []byte) (n int, err error) {
 	n, err = rc.ReadCloser.Read(b)
+	rc.read.Add(float64(n))
 	if rc.ok && err != nil && err != io.EOF {
 		rc.failed.WithLabelValues(rc.op).Inc()
 		rc.ok = false
 	}
 	return n, err
 }
+
+// countingReader counts bytes read from the wrapped reader.
+type countingReader struct {
+	io.Reader
+	bytes prometheus.Counter
+}
+
+func (r *countingReader) Read(b []byte) (n int, err error) {
+	n, err = r.Reader.Read(b)
+	r.bytes.Add(float64(n))
+	return n, err
+}
+
+func (r *countingReader) ObjectSize() (int64, error) {
+	return TryToGetSize(r.Reader)
+}
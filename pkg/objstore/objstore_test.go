@@ -0,0 +1,198 @@
+package objstore_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucketWithMetrics_CostMetrics(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	bkt := objstore.BucketWithMetrics("test", inmem.NewBucket(), reg)
+
+	testutil.Ok(t, bkt.Upload(ctx, "obj", strings.NewReader("@test-data@")))
+
+	f, err := ioutil.TempFile("", "test-bucket-metrics")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.Remove(f.Name())) }()
+	_, err = f.WriteString("@file@")
+	testutil.Ok(t, err)
+	_, err = f.Seek(0, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Upload(ctx, "file", f))
+	testutil.Ok(t, f.Close())
+
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+
+	rc, err = bkt.GetRange(ctx, "obj", 1, 4)
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+
+	_, err = bkt.Exists(ctx, "obj")
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Iter(ctx, "", func(string) error { return nil }))
+	testutil.Ok(t, bkt.Delete(ctx, "obj"))
+
+	mfs, err := reg.Gather()
+	testutil.Ok(t, err)
+
+	values := map[string]float64{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "class" || l.GetName() == "direction" {
+					values[mf.GetName()+"/"+l.GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	testutil.Equals(t, map[string]float64{
+		"thanos_objstore_bucket_operation_class_total/A":          3,
+		"thanos_objstore_bucket_operation_class_total/B":          3,
+		"thanos_objstore_bucket_operation_class_total/free":       1,
+		"thanos_objstore_bucket_transferred_bytes_total/download": 15,
+		"thanos_objstore_bucket_transferred_bytes_total/upload":   17,
+	}, values)
+}
+
+// hangingBucket blocks get and iter operations until their context is done.
+type hangingBucket struct {
+	objstore.Bucket
+}
+
+func (b hangingBucket) Iter(ctx context.Context, _ string, _ func(string) error) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b hangingBucket) Get(ctx context.Context, _ string) (io.ReadCloser, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestBucketWithTimeouts(t *testing.T) {
+	ctx := context.Background()
+
+	bkt := objstore.BucketWithTimeouts(hangingBucket{Bucket: inmem.NewBucket()}, objstore.Timeouts{
+		Iter: model.Duration(10 * time.Millisecond),
+		Get:  model.Duration(10 * time.Millisecond),
+	})
+	err := bkt.Iter(ctx, "", func(string) error { return nil })
+	testutil.NotOk(t, err)
+	testutil.Equals(t, context.DeadlineExceeded, errors.Cause(err))
+	testutil.Assert(t, strings.Contains(err.Error(), "iter operation timed out after 10ms"), "unexpected error %s", err)
+
+	_, err = bkt.Get(ctx, "obj")
+	testutil.NotOk(t, err)
+	testutil.Equals(t, context.DeadlineExceeded, errors.Cause(err))
+
+	// Cancellation of the parent context is not reported as timeout.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	testutil.Equals(t, context.Canceled, bkt.Iter(cctx, "", func(string) error { return nil }))
+
+	// Reader returned by get is usable until closed, operations without timeout pass through.
+	bkt = objstore.BucketWithTimeouts(inmem.NewBucket(), objstore.Timeouts{Get: model.Duration(time.Minute)})
+	testutil.Ok(t, bkt.Upload(ctx, "obj", strings.NewReader("@test-data@")))
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+	testutil.Equals(t, "@test-data@", string(b))
+}
+
+func TestBlockResolution(t *testing.T) {
+	_, ok := objstore.BlockResolution(context.Background())
+	testutil.Assert(t, !ok, "resolution of context without resolution")
+
+	// Resolution is passed through timeouts of wrapped buckets.
+	ctx, cancel := context.WithTimeout(objstore.WithBlockResolution(context.Background(), 300000), time.Minute)
+	defer cancel()
+	res, ok := objstore.BlockResolution(ctx)
+	testutil.Assert(t, ok, "no resolution")
+	testutil.Equals(t, int64(300000), res)
+}
+
+func TestIterHints(t *testing.T) {
+	ctx := context.Background()
+	a, b := inmem.NewBucket(), inmem.NewBucket()
+	for _, name := range []string{"1/obj", "2/obj", "3/obj"} {
+		testutil.Ok(t, a.Upload(ctx, name, strings.NewReader(name)))
+		testutil.Ok(t, b.Upload(ctx, "tenant/"+name, strings.NewReader(name)))
+	}
+	iter := func(ctx context.Context, bkt objstore.BucketReader, stopAt string) (got []string) {
+		testutil.Ok(t, bkt.Iter(ctx, "", func(name string) error {
+			got = append(got, name)
+			if name == stopAt {
+				return objstore.ErrStopIter
+			}
+			return nil
+		}))
+		return got
+	}
+
+	testutil.Equals(t, []string{"1/", "2/", "3/"}, iter(ctx, a, ""))
+	testutil.Equals(t, []string{"1/", "2/"}, iter(ctx, a, "2/"))
+	testutil.Equals(t, []string{"1/", "2/"}, iter(objstore.WithIterMaxKeys(ctx, 2), a, ""))
+	testutil.Equals(t, []string{"2/", "3/"}, iter(objstore.WithIterStartAfter(ctx, "1/"), a, ""))
+	testutil.Equals(t, []string{"3/"}, iter(objstore.WithIterStartAfter(objstore.WithIterMaxKeys(ctx, 1), "2/"), a, ""))
+
+	// Names of prefixed buckets are relative to the prefix.
+	p := objstore.NewPrefixedBucket(b, "tenant")
+	testutil.Equals(t, []string{"2/", "3/"}, iter(objstore.WithIterStartAfter(ctx, "1/"), p, ""))
+	testutil.Equals(t, []string{"1/obj", "2/obj"}, iter(objstore.WithRecursiveIter(ctx), p, "2/obj"))
+	testutil.Equals(t, []string{"tenant/1/obj", "tenant/2/obj", "tenant/3/obj"}, iter(objstore.WithRecursiveIter(ctx), b, ""))
+
+	// Routing buckets stop listing all of their buckets.
+	r := objstore.NewRoutingBucket(a, []objstore.ResolutionRoute{{Resolutions: []int64{300000}, Bucket: p}})
+	testutil.Equals(t, []string{"1/"}, iter(ctx, r, "1/"))
+	testutil.Equals(t, []string{"1/", "2/"}, iter(objstore.WithIterMaxKeys(ctx, 2), r, ""))
+}
+
+func TestBucketWithRateLimits(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	bkt := objstore.BucketWithRateLimits(inmem.NewBucket(), objstore.RateLimits{Download: 10000, Upload: 10000}, reg)
+
+	data := strings.Repeat("a", 5000)
+	begin := time.Now()
+	testutil.Ok(t, bkt.Upload(ctx, "obj", strings.NewReader(data)))
+	testutil.Assert(t, time.Since(begin) >= 400*time.Millisecond, "upload was not limited")
+
+	begin = time.Now()
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+	testutil.Equals(t, data, string(b))
+	testutil.Assert(t, time.Since(begin) >= 400*time.Millisecond, "download was not limited")
+
+	// Waiting for the rate limit ends with the context.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	rc, err = bkt.GetRange(cancelCtx, "obj", 0, 5000)
+	testutil.Ok(t, err)
+	cancel()
+	_, err = ioutil.ReadAll(rc)
+	testutil.Equals(t, context.Canceled, err)
+	testutil.Ok(t, rc.Close())
+}
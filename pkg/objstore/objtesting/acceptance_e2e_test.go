@@ -72,6 +72,16 @@ func TestObjStore_AcceptanceTest_e2e(t *testing.T) {
 		sort.Strings(seen)
 		testutil.Equals(t, expected, seen)
 
+		// Can we iter over all objects recursively?
+		seen = []string{}
+		testutil.Ok(t, bkt.Iter(objstore.WithRecursiveIter(ctx), "", func(fn string) error {
+			seen = append(seen, fn)
+			return nil
+		}))
+		expected = []string{"id1/obj_1.some", "id1/obj_2.some", "id1/obj_3.some", "id2/obj_4.some", "obj_5.some"}
+		sort.Strings(seen)
+		testutil.Equals(t, expected, seen)
+
 		// Can we iter over items from id1/ dir?
 		seen = []string{}
 		testutil.Ok(t, bkt.Iter(ctx, "id1/", func(fn string) error {
@@ -88,6 +98,23 @@ func TestObjStore_AcceptanceTest_e2e(t *testing.T) {
 		}))
 		testutil.Equals(t, []string{"id1/obj_1.some", "id1/obj_2.some", "id1/obj_3.some"}, seen)
 
+		// Can we stop iterating early?
+		seen = []string{}
+		testutil.Ok(t, bkt.Iter(ctx, "id1/", func(fn string) error {
+			seen = append(seen, fn)
+			return objstore.ErrStopIter
+		}))
+		testutil.Equals(t, []string{"id1/obj_1.some"}, seen)
+
+		// Are iteration hints honoured?
+		seen = []string{}
+		hintCtx := objstore.WithIterMaxKeys(objstore.WithIterStartAfter(ctx, "id1/obj_1.some"), 1)
+		testutil.Ok(t, bkt.Iter(hintCtx, "id1/", func(fn string) error {
+			seen = append(seen, fn)
+			return nil
+		}))
+		testutil.Equals(t, []string{"id1/obj_2.some"}, seen)
+
 		// Can we iter over items from not existing dir?
 		testutil.Ok(t, bkt.Iter(ctx, "id0", func(fn string) error {
 			t.Error("Not expected to loop through not existing directory")
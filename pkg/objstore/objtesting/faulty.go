@@ -0,0 +1,186 @@
+package objtesting
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// Op is a bucket operation faults can be injected into.
+type Op string
+
+const (
+	OpIter     Op = "iter"
+	OpGet      Op = "get"
+	OpGetRange Op = "get_range"
+	OpExists   Op = "exists"
+	OpUpload   Op = "upload"
+	OpDelete   Op = "delete"
+)
+
+// ErrInjected is returned by operations of FaultyBucket which failed due to an injected fault.
+var ErrInjected = errors.New("objtesting: injected fault")
+
+// IsInjected returns true if the error was caused by an injected fault.
+func IsInjected(err error) bool {
+	return errors.Cause(err) == ErrInjected
+}
+
+// Fault describes faults injected into a single bucket operation.
+type Fault struct {
+	// ErrorRate is the probability in [0, 1] of the operation failing with ErrInjected.
+	ErrorRate float64
+	// Latency is added to every call of the operation. It respects context cancellation.
+	Latency time.Duration
+	// PartialReadRate is the probability in [0, 1] of a reader returned by Get or GetRange returning only the first
+	// half of the content followed by io.ErrUnexpectedEOF.
+	PartialReadRate float64
+}
+
+// FaultyBucket wraps a bucket and injects configured faults into its operations.
+// It is meant to test resilience of components against misbehaving object storage.
+type FaultyBucket struct {
+	objstore.Bucket
+
+	mtx    sync.Mutex
+	rnd    *rand.Rand
+	faults map[Op]Fault
+}
+
+// NewFaultyBucket returns a bucket that injects faults into operations of the given bucket.
+// The seed makes the injected faults reproducible. No faults are injected until SetFault is called.
+func NewFaultyBucket(bkt objstore.Bucket, seed int64) *FaultyBucket {
+	return &FaultyBucket{
+		Bucket: bkt,
+		rnd:    rand.New(rand.NewSource(seed)),
+		faults: map[Op]Fault{},
+	}
+}
+
+// SetFault sets faults injected into the given operation, replacing the previous ones.
+func (b *FaultyBucket) SetFault(op Op, f Fault) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.faults[op] = f
+}
+
+// hit returns true with the given probability.
+func (b *FaultyBucket) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.rnd.Float64() < rate
+}
+
+func (b *FaultyBucket) fault(op Op) Fault {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.faults[op]
+}
+
+// inject applies latency and error faults of the given operation.
+func (b *FaultyBucket) inject(ctx context.Context, op Op) (Fault, error) {
+	f := b.fault(op)
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-ctx.Done():
+			return f, ctx.Err()
+		}
+	}
+	if b.hit(f.ErrorRate) {
+		return f, errors.Wrapf(ErrInjected, "%s", op)
+	}
+	return f, nil
+}
+
+// partialReader returns the first half of the wrapped reader content only, followed by io.ErrUnexpectedEOF.
+func partialReader(rc io.ReadCloser) (io.ReadCloser, error) {
+	defer func() { _ = rc.Close() }()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(io.MultiReader(bytes.NewReader(b[:len(b)/2]), errReader{err: io.ErrUnexpectedEOF})), nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func (b *FaultyBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	if _, err := b.inject(ctx, OpIter); err != nil {
+		return err
+	}
+	return b.Bucket.Iter(ctx, dir, f)
+}
+
+func (b *FaultyBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	f, err := b.inject(ctx, OpGet)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := b.Bucket.Get(ctx, name)
+	if err != nil || !b.hit(f.PartialReadRate) {
+		return rc, err
+	}
+	return partialReader(rc)
+}
+
+func (b *FaultyBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	f, err := b.inject(ctx, OpGetRange)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := b.Bucket.GetRange(ctx, name, off, length)
+	if err != nil || !b.hit(f.PartialReadRate) {
+		return rc, err
+	}
+	return partialReader(rc)
+}
+
+func (b *FaultyBucket) Exists(ctx context.Context, name string) (bool, error) {
+	if _, err := b.inject(ctx, OpExists); err != nil {
+		return false, err
+	}
+	return b.Bucket.Exists(ctx, name)
+}
+
+func (b *FaultyBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if _, err := b.inject(ctx, OpUpload); err != nil {
+		return err
+	}
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func (b *FaultyBucket) Delete(ctx context.Context, name string) error {
+	if _, err := b.inject(ctx, OpDelete); err != nil {
+		return err
+	}
+	return b.Bucket.Delete(ctx, name)
+}
+
+// ForeachFaultyStore runs given test using all available objstore implementations, same as ForeachStore, with
+// the given faults injected into each of them.
+func ForeachFaultyStore(t *testing.T, seed int64, faults map[Op]Fault, testFn func(t testing.TB, bkt *FaultyBucket)) {
+	ForeachStore(t, func(t testing.TB, bkt objstore.Bucket) {
+		fb := NewFaultyBucket(bkt, seed)
+		for op, f := range faults {
+			fb.SetFault(op, f)
+		}
+		testFn(t, fb)
+	})
+}
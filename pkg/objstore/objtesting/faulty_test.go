@@ -0,0 +1,58 @@
+package objtesting
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestFaultyBucket(t *testing.T) {
+	ctx := context.Background()
+	bkt := NewFaultyBucket(inmem.NewBucket(), 0)
+
+	// No faults by default.
+	testutil.Ok(t, bkt.Upload(ctx, "obj", strings.NewReader("@test-data@")))
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "@test-data@", string(b))
+
+	bkt.SetFault(OpUpload, Fault{ErrorRate: 1})
+	err = bkt.Upload(ctx, "obj2", strings.NewReader("@test-data@"))
+	testutil.NotOk(t, err)
+	testutil.Assert(t, IsInjected(err), "expected injected error, got %v", err)
+	testutil.Assert(t, !bkt.IsObjNotFoundErr(err), "injected error must not be a not found error")
+
+	ok, err := bkt.Exists(ctx, "obj2")
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "failed upload must not store the object")
+
+	bkt.SetFault(OpGetRange, Fault{PartialReadRate: 1})
+	rc, err = bkt.GetRange(ctx, "obj", 1, 4)
+	testutil.Ok(t, err)
+	b, err = ioutil.ReadAll(rc)
+	testutil.Equals(t, io.ErrUnexpectedEOF, err)
+	testutil.Equals(t, "te", string(b))
+
+	bkt.SetFault(OpIter, Fault{Latency: time.Minute})
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	testutil.Equals(t, context.DeadlineExceeded, bkt.Iter(cctx, "", func(string) error { return nil }))
+
+	// Error rate is applied probabilistically.
+	bkt.SetFault(OpExists, Fault{ErrorRate: 0.5})
+	var failed int
+	for i := 0; i < 1000; i++ {
+		if _, err := bkt.Exists(ctx, "obj"); err != nil {
+			failed++
+		}
+	}
+	testutil.Assert(t, failed > 400 && failed < 600, "expected roughly half of calls to fail, got %d", failed)
+}
@@ -1,6 +1,7 @@
 package objtesting
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
 	"time"
@@ -20,6 +21,8 @@ import (
 // For each it creates a new bucket with a random name and a cleanup function
 // that deletes it after test was run.
 // Use THANOS_SKIP_<objstorename>_TESTS to skip explicitly certain tests.
+// Set THANOS_TEST_S3_MINIO to additionally run against Minio and THANOS_TEST_GCS_EMULATOR_HOST to additionally run
+// against GCS emulator, covering provider specific code paths without access to real buckets.
 func ForeachStore(t *testing.T, testFn func(t testing.TB, bkt objstore.Bucket)) {
 	// Mandatory Inmem.
 	if ok := t.Run("inmem", func(t *testing.T) {
@@ -69,6 +72,39 @@ func ForeachStore(t *testing.T, testFn func(t testing.TB, bkt objstore.Bucket))
 		t.Log("THANOS_SKIP_S3_AWS_TESTS envvar present. Skipping test against S3 AWS.")
 	}
 
+	// Optional S3 against Minio started from the binary given by THANOS_TEST_MINIO_PATH.
+	if _, ok := os.LookupEnv("THANOS_TEST_S3_MINIO"); ok {
+		dir, err := ioutil.TempDir("", "objtesting-minio")
+		testutil.Ok(t, err)
+
+		bkt, closeFn, err := NewMinioBucket(t, dir)
+		testutil.Ok(t, err)
+
+		ok := t.Run("minio", func(t *testing.T) {
+			// TODO(bwplotka): Add leaktest when we fix potential leak in minio library.
+			testFn(t, bkt)
+		})
+		closeFn()
+		testutil.Ok(t, os.RemoveAll(dir))
+		if !ok {
+			return
+		}
+	}
+
+	// Optional GCS against emulator, e.g. fake-gcs-server, listening on THANOS_TEST_GCS_EMULATOR_HOST.
+	if host, ok := os.LookupEnv("THANOS_TEST_GCS_EMULATOR_HOST"); ok {
+		bkt, closeFn, err := gcs.NewTestBucketWithEmulator(t, host)
+		testutil.Ok(t, err)
+
+		ok := t.Run("gcs emulator", func(t *testing.T) {
+			testFn(t, bkt)
+		})
+		closeFn()
+		if !ok {
+			return
+		}
+	}
+
 	// Optional Azure.
 	if _, ok := os.LookupEnv("THANOS_SKIP_AZURE_TESTS"); !ok {
 		bkt, closeFn, err := azure.NewTestBucket(t, "e2e-tests")
@@ -0,0 +1,78 @@
+package objtesting
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/s3"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// NewMinioBucket starts Minio binary (see testutil.MinioBinary) storing data in the given directory and returns
+// a bucket client for a freshly created bucket in it.
+// The returned function empties the bucket and stops Minio.
+func NewMinioBucket(t testing.TB, dir string) (objstore.Bucket, func(), error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "listen on local address")
+	}
+	addr := l.Addr().String()
+	// Minio binds the port itself.
+	if err := l.Close(); err != nil {
+		return nil, nil, errors.Wrap(err, "close listener")
+	}
+
+	cfg := s3.Config{
+		AccessKey: "abc",
+		SecretKey: "mightysecret",
+		Endpoint:  addr,
+		Insecure:  true,
+	}
+
+	dataDir := filepath.Join(dir, "minio")
+	if err := os.MkdirAll(dataDir, 0777); err != nil {
+		return nil, nil, errors.Wrap(err, "create minio dir")
+	}
+
+	cmd := exec.Command(testutil.MinioBinary(), "server", "--address", addr, dataDir)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("MINIO_ACCESS_KEY=%s", cfg.AccessKey),
+		fmt.Sprintf("MINIO_SECRET_KEY=%s", cfg.SecretKey),
+	)
+	if err := cmd.Start(); err != nil {
+		return nil, nil, errors.Wrap(err, "start minio")
+	}
+	stopMinio := func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+
+	var (
+		bkt       objstore.Bucket
+		closeFunc func()
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := runutil.Retry(500*time.Millisecond, ctx.Done(), func() (err error) {
+		bkt, closeFunc, err = s3.NewTestBucketFromConfig(t, "eu-west1", cfg, false)
+		return err
+	}); err != nil {
+		stopMinio()
+		return nil, nil, errors.Wrap(err, "minio not ready in time")
+	}
+
+	return bkt, func() {
+		closeFunc()
+		stopMinio()
+	}, nil
+}
@@ -0,0 +1,76 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// PrefixedBucket stores all objects of the bucket under the given prefix of the wrapped bucket, so multiple buckets
+// can share a single bucket of the object storage.
+type PrefixedBucket struct {
+	bkt    Bucket
+	prefix string
+}
+
+// NewPrefixedBucket returns a bucket with all objects stored under the given prefix of the given bucket.
+func NewPrefixedBucket(bkt Bucket, prefix string) *PrefixedBucket {
+	prefix = strings.Trim(prefix, DirDelim)
+	if prefix != "" {
+		prefix += DirDelim
+	}
+	return &PrefixedBucket{bkt: bkt, prefix: prefix}
+}
+
+// Iter calls f for each entry in the given directory, with names relative to the prefix.
+func (b *PrefixedBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	if name, ok := IterStartAfter(ctx); ok {
+		ctx = WithIterStartAfter(ctx, b.prefix+name)
+	}
+	return b.bkt.Iter(ctx, b.prefix+dir, func(name string) error {
+		return f(strings.TrimPrefix(name, b.prefix))
+	})
+}
+
+// Get returns a reader for the given object name.
+func (b *PrefixedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.bkt.Get(ctx, b.prefix+name)
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *PrefixedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.bkt.GetRange(ctx, b.prefix+name, off, length)
+}
+
+// Exists checks if the given object exists in the bucket.
+func (b *PrefixedBucket) Exists(ctx context.Context, name string) (bool, error) {
+	return b.bkt.Exists(ctx, b.prefix+name)
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found.
+func (b *PrefixedBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+// Upload the contents of the reader as an object into the bucket.
+func (b *PrefixedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	return b.bkt.Upload(ctx, b.prefix+name, r)
+}
+
+// Delete removes the object with the given name.
+func (b *PrefixedBucket) Delete(ctx context.Context, name string) error {
+	return b.bkt.Delete(ctx, b.prefix+name)
+}
+
+// Name returns the bucket name with the prefix.
+func (b *PrefixedBucket) Name() string {
+	if b.prefix == "" {
+		return b.bkt.Name()
+	}
+	return b.bkt.Name() + DirDelim + strings.TrimSuffix(b.prefix, DirDelim)
+}
+
+// Close closes the wrapped bucket.
+func (b *PrefixedBucket) Close() error {
+	return b.bkt.Close()
+}
@@ -0,0 +1,176 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RateLimits are maximum transfer rates of a bucket in bytes per second, shared by all concurrent operations. Zero
+// disables the limit.
+type RateLimits struct {
+	// Download limits bytes read from objects returned by get and get_range operations.
+	Download int64
+	// Upload limits bytes read from readers of upload operations.
+	Upload int64
+}
+
+// BucketWithRateLimits returns a bucket which transfers objects at most at the given rates, e.g. so bulk transfers do
+// not saturate the network link to the object storage and starve other traffic against it.
+func BucketWithRateLimits(b Bucket, limits RateLimits, reg prometheus.Registerer) Bucket {
+	throttled := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_objstore_bucket_throttled_seconds_total",
+		Help: "Total time operations waited for the transfer rate limits of the bucket.",
+	}, []string{"operation"})
+	if reg != nil {
+		reg.MustRegister(throttled)
+	}
+	return &rateLimitedBucket{
+		bkt:      b,
+		download: newRateLimiter(limits.Download, throttled.WithLabelValues("download")),
+		upload:   newRateLimiter(limits.Upload, throttled.WithLabelValues("upload")),
+	}
+}
+
+type rateLimitedBucket struct {
+	bkt              Bucket
+	download, upload *rateLimiter
+}
+
+func (b *rateLimitedBucket) Iter(ctx context.Context, dir string, f func(name string) error) error {
+	return b.bkt.Iter(ctx, dir, f)
+}
+
+func (b *rateLimitedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	rc, err := b.bkt.Get(ctx, name)
+	if err != nil || b.download == nil {
+		return rc, err
+	}
+	return &rateLimitedReadCloser{ReadCloser: rc, r: rateLimitedReader{ctx: ctx, r: rc, l: b.download}}, nil
+}
+
+func (b *rateLimitedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	rc, err := b.bkt.GetRange(ctx, name, off, length)
+	if err != nil || b.download == nil {
+		return rc, err
+	}
+	return &rateLimitedReadCloser{ReadCloser: rc, r: rateLimitedReader{ctx: ctx, r: rc, l: b.download}}, nil
+}
+
+func (b *rateLimitedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if b.upload == nil {
+		return b.bkt.Upload(ctx, name, r)
+	}
+	return b.bkt.Upload(ctx, name, &rateLimitedReader{ctx: ctx, r: r, l: b.upload})
+}
+
+func (b *rateLimitedBucket) Exists(ctx context.Context, name string) (bool, error) {
+	return b.bkt.Exists(ctx, name)
+}
+
+func (b *rateLimitedBucket) Delete(ctx context.Context, name string) error {
+	return b.bkt.Delete(ctx, name)
+}
+
+func (b *rateLimitedBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+func (b *rateLimitedBucket) Close() error {
+	return b.bkt.Close()
+}
+
+func (b *rateLimitedBucket) Name() string {
+	return b.bkt.Name()
+}
+
+// rateLimiter paces transfers of bytes to the rate. Each transfer waits until all bytes transferred before are paid
+// for at the rate, so concurrent transfers share the rate.
+type rateLimiter struct {
+	bytesPerSec int64
+	throttled   prometheus.Counter
+	now         func() time.Time
+
+	mtx sync.Mutex
+	// next is the time all bytes transferred so far are paid for at the rate.
+	next time.Time
+}
+
+// newRateLimiter returns a limiter of the given rate, nil if the rate is not positive.
+func newRateLimiter(bytesPerSec int64, throttled prometheus.Counter) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{bytesPerSec: bytesPerSec, throttled: throttled, now: time.Now}
+}
+
+// reserve records the transfer of n bytes and returns how long to wait until they are paid for.
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.bytesPerSec))
+	return l.next.Sub(now)
+}
+
+// wait blocks until the transfer of n bytes is paid for, or the context is done.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	d := l.reserve(n)
+	if d <= 0 {
+		return nil
+	}
+	l.throttled.Add(d.Seconds())
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// maxRead returns the maximum number of bytes read at once, so a single read does not wait for more than a second.
+func (l *rateLimiter) maxRead() int {
+	return int(l.bytesPerSec)
+}
+
+type rateLimitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *rateLimiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if max := r.l.maxRead(); len(p) > max {
+		p = p[:max]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.wait(r.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+func (r *rateLimitedReader) ObjectSize() (int64, error) {
+	return TryToGetSize(r.r)
+}
+
+type rateLimitedReadCloser struct {
+	io.ReadCloser
+	r rateLimitedReader
+}
+
+func (rc *rateLimitedReadCloser) Read(p []byte) (int, error) {
+	return rc.r.Read(p)
+}
@@ -0,0 +1,53 @@
+package objstore
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newRateLimiter(100, prometheus.NewCounter(prometheus.CounterOpts{}))
+	l.now = func() time.Time { return now }
+
+	testutil.Equals(t, 500*time.Millisecond, l.reserve(50))
+	// Concurrent transfers share the rate.
+	testutil.Equals(t, time.Second, l.reserve(50))
+	now = now.Add(250 * time.Millisecond)
+	testutil.Equals(t, time.Second, l.reserve(25))
+
+	// Idle time is not saved up for later transfers.
+	now = now.Add(time.Hour)
+	testutil.Equals(t, 100*time.Millisecond, l.reserve(10))
+	testutil.Equals(t, 100, l.maxRead())
+
+	testutil.Assert(t, newRateLimiter(0, nil) == nil, "limiter without rate")
+}
+
+func TestTryToGetSize(t *testing.T) {
+	f, err := ioutil.TempFile("", "test-try-to-get-size")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.Remove(f.Name())) }()
+	defer func() { testutil.Ok(t, f.Close()) }()
+	_, err = f.WriteString("@file@")
+	testutil.Ok(t, err)
+
+	size, err := TryToGetSize(f)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(6), size)
+
+	// Readers of bucket wrappers pass the size of files through.
+	r := &countingReader{Reader: &rateLimitedReader{r: f}, bytes: prometheus.NewCounter(prometheus.CounterOpts{})}
+	size, err = TryToGetSize(r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(6), size)
+
+	_, err = TryToGetSize(&rateLimitedReader{r: strings.NewReader("@data@")})
+	testutil.NotOk(t, err)
+}
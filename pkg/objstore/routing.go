@@ -0,0 +1,262 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+)
+
+// ResolutionRoute routes blocks of the given downsampling resolutions to the bucket.
+type ResolutionRoute struct {
+	Resolutions []int64
+	Bucket      Bucket
+}
+
+var errBlockDirFound = errors.New("block directory found")
+
+// maxLocatedBlocks is the maximum number of blocks whose buckets are cached by a RoutingBucket. Buckets of least
+// recently used blocks are evicted and located again once needed.
+const maxLocatedBlocks = 100000
+
+// RoutingBucket writes blocks to different buckets based on their downsampling resolution, e.g. raw blocks to a bucket
+// with a hot storage class and downsampled blocks to a bucket with a cold one, while reading through all of them as
+// if they were a single bucket.
+// Blocks are routed by the resolution passed by WithBlockResolution to the upload of their objects. Objects uploaded
+// without the resolution, e.g. updated meta.json files, are written to the bucket already holding their block.
+// Buckets holding the blocks are cached for up to maxLocatedBlocks blocks, blocks are assumed to never move between
+// buckets.
+type RoutingBucket struct {
+	def    Bucket
+	routes []ResolutionRoute
+	bkts   []Bucket
+
+	mtx     sync.Mutex
+	located *lru.LRU
+}
+
+// NewRoutingBucket returns a bucket writing blocks of resolutions of the given routes to their buckets and all
+// other objects to the default bucket.
+func NewRoutingBucket(def Bucket, routes []ResolutionRoute) *RoutingBucket {
+	return newRoutingBucket(def, routes, maxLocatedBlocks)
+}
+
+func newRoutingBucket(def Bucket, routes []ResolutionRoute, maxLocated int) *RoutingBucket {
+	// Only fails for non positive sizes.
+	located, _ := lru.NewLRU(maxLocated, nil)
+	b := &RoutingBucket{
+		def:     def,
+		routes:  routes,
+		bkts:    []Bucket{def},
+		located: located,
+	}
+	for _, r := range routes {
+		b.bkts = append(b.bkts, r.Bucket)
+	}
+	return b
+}
+
+// blockDir returns the top-level block directory of the object name, if any.
+func blockDir(name string) (string, bool) {
+	dir := strings.SplitN(name, DirDelim, 2)[0]
+	if _, err := ulid.Parse(dir); err != nil {
+		return "", false
+	}
+	return dir, true
+}
+
+func (b *RoutingBucket) cached(dir string) Bucket {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if bkt, ok := b.located.Get(dir); ok {
+		return bkt.(Bucket)
+	}
+	return nil
+}
+
+func (b *RoutingBucket) cache(dir string, bkt Bucket) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.located.Add(dir, bkt)
+}
+
+// candidates returns buckets which can hold the object, the bucket known to hold its block first.
+func (b *RoutingBucket) candidates(name string) []Bucket {
+	if dir, ok := blockDir(name); ok {
+		if bkt := b.cached(dir); bkt != nil {
+			return []Bucket{bkt}
+		}
+	}
+	return b.bkts
+}
+
+// locate returns the bucket holding the block directory of the object name, or nil if there is no such bucket.
+func (b *RoutingBucket) locate(ctx context.Context, name string) (Bucket, error) {
+	dir, ok := blockDir(name)
+	if !ok {
+		return nil, nil
+	}
+	if bkt := b.cached(dir); bkt != nil {
+		return bkt, nil
+	}
+	for _, bkt := range b.bkts {
+		err := bkt.Iter(ctx, dir, func(string) error { return errBlockDirFound })
+		if errors.Cause(err) == errBlockDirFound {
+			b.cache(dir, bkt)
+			return bkt, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "iter %s of bucket %s", dir, bkt.Name())
+		}
+	}
+	return nil, nil
+}
+
+// uploadBucket returns the bucket the object is uploaded to.
+func (b *RoutingBucket) uploadBucket(ctx context.Context, name string) (Bucket, error) {
+	if dir, ok := blockDir(name); ok {
+		if bkt := b.cached(dir); bkt != nil {
+			return bkt, nil
+		}
+	}
+	if res, ok := BlockResolution(ctx); ok {
+		for _, r := range b.routes {
+			for _, rr := range r.Resolutions {
+				if rr == res {
+					return r.Bucket, nil
+				}
+			}
+		}
+		return b.def, nil
+	}
+	bkt, err := b.locate(ctx, name)
+	if err != nil || bkt != nil {
+		return bkt, err
+	}
+	return b.def, nil
+}
+
+// Iter calls f for each entry in the given directory of any of the buckets.
+func (b *RoutingBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	var (
+		seen    = map[string]struct{}{}
+		stopped bool
+	)
+	// Limit entries of all buckets together, the buckets stop at the end of the iteration of each of them.
+	f = IterFunc(ctx, f)
+	for _, bkt := range b.candidates(dir) {
+		bkt := bkt
+		if err := bkt.Iter(ctx, dir, func(name string) error {
+			if _, ok := seen[name]; ok {
+				return nil
+			}
+			seen[name] = struct{}{}
+
+			if d, ok := blockDir(name); ok && dir == "" && b.cached(d) == nil {
+				b.cache(d, bkt)
+			}
+			err := f(name)
+			stopped = errors.Cause(err) == ErrStopIter
+			return err
+		}); err != nil {
+			return IterError(err)
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Get returns a reader for the given object name.
+func (b *RoutingBucket) Get(ctx context.Context, name string) (rc io.ReadCloser, err error) {
+	for _, bkt := range b.candidates(name) {
+		rc, err = bkt.Get(ctx, name)
+		if err == nil || !bkt.IsObjNotFoundErr(err) {
+			return rc, err
+		}
+	}
+	return nil, err
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *RoutingBucket) GetRange(ctx context.Context, name string, off, length int64) (rc io.ReadCloser, err error) {
+	for _, bkt := range b.candidates(name) {
+		rc, err = bkt.GetRange(ctx, name, off, length)
+		if err == nil || !bkt.IsObjNotFoundErr(err) {
+			return rc, err
+		}
+	}
+	return nil, err
+}
+
+// Exists checks if the given object exists in any of the buckets.
+func (b *RoutingBucket) Exists(ctx context.Context, name string) (bool, error) {
+	for _, bkt := range b.candidates(name) {
+		ok, err := bkt.Exists(ctx, name)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found.
+func (b *RoutingBucket) IsObjNotFoundErr(err error) bool {
+	for _, bkt := range b.bkts {
+		if bkt.IsObjNotFoundErr(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload the contents of the reader as an object into the bucket chosen by the resolution of its block.
+func (b *RoutingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	bkt, err := b.uploadBucket(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "choose bucket of %s", name)
+	}
+	if err := bkt.Upload(ctx, name, r); err != nil {
+		return err
+	}
+	if dir, ok := blockDir(name); ok {
+		b.cache(dir, bkt)
+	}
+	return nil
+}
+
+// Delete removes the object with the given name from the bucket holding it.
+func (b *RoutingBucket) Delete(ctx context.Context, name string) error {
+	// Not all providers fail deletions of missing objects, so the bucket holding the object has to be found first.
+	for _, bkt := range b.candidates(name) {
+		ok, err := bkt.Exists(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "check %s in bucket %s", name, bkt.Name())
+		}
+		if ok {
+			return bkt.Delete(ctx, name)
+		}
+	}
+	// Let the default bucket report the missing object.
+	return b.def.Delete(ctx, name)
+}
+
+// Name returns the name of the default bucket.
+func (b *RoutingBucket) Name() string {
+	return b.def.Name()
+}
+
+// Close closes all buckets.
+func (b *RoutingBucket) Close() error {
+	var merr tsdberrors.MultiError
+	for _, bkt := range b.bkts {
+		merr.Add(bkt.Close())
+	}
+	return merr.Err()
+}
@@ -0,0 +1,21 @@
+package objstore
+
+import (
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestRoutingBucket_LocatedBlocksLimit(t *testing.T) {
+	hot, cold := NewPrefixedBucket(nil, "hot"), NewPrefixedBucket(nil, "cold")
+	b := newRoutingBucket(hot, []ResolutionRoute{{Resolutions: []int64{300000}, Bucket: cold}}, 2)
+
+	b.cache("a", hot)
+	b.cache("b", cold)
+	testutil.Equals(t, hot, b.cached("a"))
+	// The least recently used block is evicted, so it is located again once needed.
+	b.cache("c", cold)
+	testutil.Assert(t, b.cached("b") == nil, "least recently used block not evicted")
+	testutil.Equals(t, hot, b.cached("a"))
+	testutil.Equals(t, cold, b.cached("c"))
+}
@@ -0,0 +1,122 @@
+package objstore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func names(b *inmem.Bucket) (res []string) {
+	for name := range b.Objects() {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestPrefixedBucket(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	testutil.Ok(t, inner.Upload(ctx, "other/obj", strings.NewReader("other")))
+
+	b := objstore.NewPrefixedBucket(inner, "/tenant/")
+	testutil.Equals(t, "inmem/tenant", b.Name())
+	testutil.Ok(t, b.Upload(ctx, "dir/obj", strings.NewReader("obj")))
+	testutil.Equals(t, []string{"other/obj", "tenant/dir/obj"}, names(inner))
+
+	var got []string
+	testutil.Ok(t, b.Iter(ctx, "", func(name string) error {
+		got = append(got, name)
+		return nil
+	}))
+	testutil.Equals(t, []string{"dir/"}, got)
+
+	got = nil
+	testutil.Ok(t, b.Iter(ctx, "dir/", func(name string) error {
+		got = append(got, name)
+		return nil
+	}))
+	testutil.Equals(t, []string{"dir/obj"}, got)
+
+	ok, err := b.Exists(ctx, "dir/obj")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "object does not exist")
+	ok, err = b.Exists(ctx, "other/obj")
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "object of other prefix exists")
+
+	testutil.Ok(t, b.Delete(ctx, "dir/obj"))
+	testutil.Equals(t, []string{"other/obj"}, names(inner))
+}
+
+func TestRoutingBucket(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		raw = ulid.MustNew(1, nil).String()
+		ds  = ulid.MustNew(2, nil).String()
+	)
+	hot, cold := inmem.NewBucket(), inmem.NewBucket()
+	b := objstore.NewRoutingBucket(hot, []objstore.ResolutionRoute{{Resolutions: []int64{300000, 3600000}, Bucket: cold}})
+
+	testutil.Ok(t, b.Upload(objstore.WithBlockResolution(ctx, 0), path.Join(raw, "index"), strings.NewReader("raw")))
+	testutil.Ok(t, b.Upload(objstore.WithBlockResolution(ctx, 300000), path.Join(ds, "index"), strings.NewReader("ds")))
+	// Objects uploaded without resolution are written to the bucket holding their block.
+	testutil.Ok(t, b.Upload(ctx, path.Join(ds, "meta.json"), strings.NewReader("{}")))
+	testutil.Ok(t, b.Upload(ctx, "debug/obj", strings.NewReader("obj")))
+
+	testutil.Equals(t, []string{raw + "/index", "debug/obj"}, names(hot))
+	testutil.Equals(t, []string{ds + "/index", ds + "/meta.json"}, names(cold))
+
+	// Blocks are read through all buckets, also by a new bucket without a cache of block locations.
+	for _, b := range []*objstore.RoutingBucket{b, objstore.NewRoutingBucket(hot, []objstore.ResolutionRoute{{Resolutions: []int64{300000}, Bucket: cold}})} {
+		var got []string
+		testutil.Ok(t, b.Iter(ctx, "", func(name string) error {
+			got = append(got, name)
+			return nil
+		}))
+		sort.Strings(got)
+		testutil.Equals(t, []string{raw + "/", ds + "/", "debug/"}, got)
+
+		rc, err := b.Get(ctx, path.Join(ds, "index"))
+		testutil.Ok(t, err)
+		c, err := ioutil.ReadAll(rc)
+		testutil.Ok(t, err)
+		testutil.Ok(t, rc.Close())
+		testutil.Equals(t, "ds", string(c))
+
+		rc, err = b.GetRange(ctx, path.Join(raw, "index"), 1, 2)
+		testutil.Ok(t, err)
+		c, err = ioutil.ReadAll(rc)
+		testutil.Ok(t, err)
+		testutil.Ok(t, rc.Close())
+		testutil.Equals(t, "aw", string(c))
+
+		ok, err := b.Exists(ctx, path.Join(ds, "meta.json"))
+		testutil.Ok(t, err)
+		testutil.Assert(t, ok, "object does not exist")
+
+		_, err = b.Get(ctx, path.Join(ds, "missing"))
+		testutil.NotOk(t, err)
+		testutil.Assert(t, b.IsObjNotFoundErr(err), "not found error expected")
+	}
+
+	// Meta updates of blocks found by a new bucket are written to the bucket holding the block.
+	b = objstore.NewRoutingBucket(hot, []objstore.ResolutionRoute{{Resolutions: []int64{300000}, Bucket: cold}})
+	testutil.Ok(t, b.Upload(ctx, path.Join(ds, "meta.json"), strings.NewReader("{}")))
+	testutil.Equals(t, []string{raw + "/index", "debug/obj"}, names(hot))
+
+	testutil.Ok(t, b.Delete(ctx, path.Join(ds, "index")))
+	testutil.Ok(t, b.Delete(ctx, path.Join(raw, "index")))
+	testutil.NotOk(t, b.Delete(ctx, path.Join(raw, "index")))
+	testutil.Equals(t, []string{"debug/obj"}, names(hot))
+	testutil.Equals(t, []string{ds + "/meta.json"}, names(cold))
+}
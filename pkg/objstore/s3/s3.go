@@ -3,7 +3,10 @@ package s3
 
 import (
 	"context"
+	"crypto/md5"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math/rand"
@@ -21,6 +24,7 @@ import (
 	minio "github.com/minio/minio-go/v6"
 	"github.com/minio/minio-go/v6/pkg/credentials"
 	"github.com/minio/minio-go/v6/pkg/encrypt"
+	"github.com/minio/minio-go/v6/pkg/s3signer"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/version"
@@ -32,8 +36,15 @@ import (
 // DirDelim is the delimiter used to model a directory structure in an object store bucket.
 const DirDelim = "/"
 
+// maxListPageSize is the maximum number of entries S3 lists per request.
+const maxListPageSize = 1000
+
+// amzRequestPayer is the header confirming that the requester pays for requests to requester pays buckets.
+const amzRequestPayer = "X-Amz-Request-Payer"
+
 var DefaultConfig = Config{
-	PutUserMetadata: map[string]string{},
+	PutUserMetadata:          map[string]string{},
+	StorageClassByResolution: map[string]string{},
 	HTTPConfig: HTTPConfig{
 		IdleConnTimeout:       model.Duration(90 * time.Second),
 		ResponseHeaderTimeout: model.Duration(2 * time.Minute),
@@ -58,6 +69,20 @@ type Config struct {
 	TraceConfig     TraceConfig       `yaml:"trace"`
 	// PartSize used for multipart upload. Only used if uploaded object size is known and larger than configured PartSize.
 	PartSize uint64 `yaml:"part_size"`
+	// RequesterPays confirms that the requester pays for requests and data transfer of requester pays buckets.
+	RequesterPays bool `yaml:"requester_pays"`
+	// StorageClass is the storage class of uploaded objects, e.g. STANDARD_IA. If empty, the default storage class
+	// of the bucket is used.
+	StorageClass string `yaml:"storage_class"`
+	// StorageClassByResolution overrides StorageClass for objects of blocks of the given downsampling resolution,
+	// e.g. "5m" or "1h". Raw blocks have "0s" resolution.
+	StorageClassByResolution map[string]string `yaml:"storage_class_by_resolution"`
+	// ListPageSize is the maximum number of entries listed per request, at most 1000. If 0, 1000 entries are listed.
+	ListPageSize int `yaml:"list_page_size"`
+	// ChecksumUploads sends the MD5 checksum of uploaded data along with uploads, so S3 rejects uploads whose data does
+	// not match it, and compares it with the ETag of the uploaded object if not encrypted with SSE. Only uploads of
+	// files smaller than the part size are verified, as larger files are uploaded in multiple parts.
+	ChecksumUploads bool `yaml:"checksum_uploads"`
 }
 
 type TraceConfig struct {
@@ -79,6 +104,11 @@ type Bucket struct {
 	sse             encrypt.ServerSide
 	putUserMetadata map[string]string
 	partSize        uint64
+	listPageSize    int
+	checksumUploads bool
+
+	storageClass             string
+	storageClassByResolution map[int64]string
 }
 
 // parseConfig unmarshals a buffer into a Config with default HTTPConfig values.
@@ -108,6 +138,10 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 	if err := validate(config); err != nil {
 		return nil, err
 	}
+	storageClassByResolution, err := parseStorageClassByResolution(config.StorageClassByResolution)
+	if err != nil {
+		return nil, err
+	}
 	if config.AccessKey != "" {
 		signature := credentials.SignatureV4
 		// TODO(bwplotka): Don't do flags, use actual v2, v4 params.
@@ -134,12 +168,14 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 		}
 	}
 
-	client, err := minio.NewWithCredentials(config.Endpoint, credentials.NewChainCredentials(chain), !config.Insecure, config.Region)
+	creds := credentials.NewChainCredentials(chain)
+	client, err := minio.NewWithCredentials(config.Endpoint, creds, !config.Insecure, config.Region)
 	if err != nil {
 		return nil, errors.Wrap(err, "initialize s3 client")
 	}
 	client.SetAppInfo(fmt.Sprintf("thanos-%s", component), fmt.Sprintf("%s (%s)", version.Version, runtime.Version()))
-	client.SetCustomTransport(&http.Transport{
+
+	var rt http.RoundTripper = &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
@@ -162,7 +198,11 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 		// Refer: https://golang.org/src/net/http/transport.go?h=roundTrip#L1843.
 		DisableCompression: true,
 		TLSClientConfig:    &tls.Config{InsecureSkipVerify: config.HTTPConfig.InsecureSkipVerify},
-	})
+	}
+	if config.RequesterPays {
+		rt = &requesterPaysTransport{next: rt, creds: creds}
+	}
+	client.SetCustomTransport(rt)
 
 	var sse encrypt.ServerSide
 	if config.SSEEncryption {
@@ -181,10 +221,28 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 		sse:             sse,
 		putUserMetadata: config.PutUserMetadata,
 		partSize:        config.PartSize,
+		listPageSize:    config.ListPageSize,
+		checksumUploads: config.ChecksumUploads,
+
+		storageClass:             config.StorageClass,
+		storageClassByResolution: storageClassByResolution,
 	}
 	return bkt, nil
 }
 
+// parseStorageClassByResolution parses resolutions of storage classes into milliseconds.
+func parseStorageClassByResolution(classes map[string]string) (map[int64]string, error) {
+	res := make(map[int64]string, len(classes))
+	for r, class := range classes {
+		d, err := model.ParseDuration(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse resolution %q of storage class %s", r, class)
+		}
+		res[int64(time.Duration(d)/time.Millisecond)] = class
+	}
+	return res, nil
+}
+
 // Name returns the bucket name for s3.
 func (b *Bucket) Name() string {
 	return b.name
@@ -203,6 +261,18 @@ func validate(conf Config) error {
 	if conf.AccessKey != "" && conf.SecretKey == "" {
 		return errors.New("no s3 secret_key specified while access_key is present in config file; either both should be present in config or envvars/IAM should be used.")
 	}
+
+	// Requests are signed again to include the requester pays header, which is supported for signature v4 of
+	// requests with signed payload only. Requests over insecure HTTP stream the payload with chunked signatures.
+	if conf.RequesterPays && (conf.SignatureV2 || conf.Insecure) {
+		return errors.New("requester_pays requires signature version 4 over HTTPS; either signature_version2 or insecure is set in config file")
+	}
+	if _, err := parseStorageClassByResolution(conf.StorageClassByResolution); err != nil {
+		return err
+	}
+	if conf.ListPageSize < 0 || conf.ListPageSize > maxListPageSize {
+		return errors.Errorf("list_page_size must be between 0 and %d", maxListPageSize)
+	}
 	return nil
 }
 
@@ -225,25 +295,52 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 		dir = strings.TrimSuffix(dir, DirDelim) + DirDelim
 	}
 
-	for object := range b.client.ListObjects(b.name, dir, false, ctx.Done()) {
-		// Catch the error when failed to list objects.
-		if object.Err != nil {
-			return object.Err
+	pageSize := objstore.IterPageSize(ctx, b.listPageSize)
+	if pageSize <= 0 || pageSize > maxListPageSize {
+		pageSize = maxListPageSize
+	}
+	// Objects are listed page by page within Iter, so nothing is listed after f stops the iteration.
+	core := minio.Core{Client: b.client}
+	marker, _ := objstore.IterStartAfter(ctx)
+	f = objstore.IterFunc(ctx, f)
+	delimiter := DirDelim
+	if objstore.IsRecursiveIter(ctx) {
+		delimiter = ""
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		// This sometimes happens with empty buckets.
-		if object.Key == "" {
-			continue
+		result, err := core.ListObjects(b.name, dir, marker, delimiter, pageSize)
+		if err != nil {
+			return err
 		}
-		// The s3 client can also return the directory itself in the ListObjects call above.
-		if object.Key == dir {
-			continue
+		for _, object := range result.Contents {
+			marker = object.Key
+			// This sometimes happens with empty buckets.
+			if object.Key == "" {
+				continue
+			}
+			// The s3 client can also return the directory itself in the ListObjects call above.
+			if object.Key == dir {
+				continue
+			}
+			if err := f(object.Key); err != nil {
+				return objstore.IterError(err)
+			}
 		}
-		if err := f(object.Key); err != nil {
-			return err
+		for _, prefix := range result.CommonPrefixes {
+			if err := f(prefix.Prefix); err != nil {
+				return objstore.IterError(err)
+			}
+		}
+		if result.NextMarker != "" {
+			marker = result.NextMarker
+		}
+		if !result.IsTruncated {
+			return nil
 		}
 	}
-
-	return nil
 }
 
 func (b *Bucket) getRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
@@ -294,17 +391,12 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 }
 
 func (b *Bucket) guessFileSize(name string, r io.Reader) int64 {
-	if f, ok := r.(*os.File); ok {
-		fileInfo, err := f.Stat()
-		if err == nil {
-			return fileInfo.Size()
-		}
-		level.Warn(b.logger).Log("msg", "could not stat file for multipart upload", "name", name, "err", err)
+	size, err := objstore.TryToGetSize(r)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "could not guess file size for multipart upload", "name", name, "err", err)
 		return -1
 	}
-
-	level.Warn(b.logger).Log("msg", "could not guess file size for multipart upload", "name", name)
-	return -1
+	return size
 }
 
 // Upload the contents of the reader as an object into the bucket.
@@ -317,6 +409,25 @@ func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	if size < int64(partSize) {
 		partSize = 0
 	}
+
+	storageClass := b.storageClass
+	if res, ok := objstore.BlockResolution(ctx); ok {
+		if class, ok := b.storageClassByResolution[res]; ok {
+			storageClass = class
+		}
+	}
+
+	if b.checksumUploads && size >= 0 && size < int64(b.partSize) {
+		md5sum := md5.New()
+		hr, precomputed, err := objstore.UploadHash(r, md5sum)
+		if err != nil {
+			return errors.Wrap(err, "checksum upload")
+		}
+		// The checksum is only known before the upload if r can be rewound, e.g. for files.
+		if precomputed {
+			return b.uploadWithMD5(ctx, name, readSeeker{hr.(io.ReadSeeker)}, size, storageClass, md5sum.Sum(nil))
+		}
+	}
 	if _, err := b.client.PutObjectWithContext(
 		ctx,
 		b.name,
@@ -327,11 +438,55 @@ func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 			PartSize:             partSize,
 			ServerSideEncryption: b.sse,
 			UserMetadata:         b.putUserMetadata,
+			StorageClass:         storageClass,
 		},
 	); err != nil {
 		return errors.Wrap(err, "upload s3 object")
 	}
+	return nil
+}
+
+// readSeeker hides Close of readers uploaded with the minio core client, which closes them, like the minio client
+// does for uploads in a single part.
+type readSeeker struct {
+	io.ReadSeeker
+}
+
+// uploadWithMD5 uploads the object in a single part with the MD5 checksum of its data as Content-MD5, so S3 rejects
+// the upload if the received data does not match it. ETags of objects encrypted with SSE are not their MD5 checksum,
+// others are compared with it too, for S3-compatible object storages not verifying Content-MD5.
+func (b *Bucket) uploadWithMD5(ctx context.Context, name string, r io.Reader, size int64, storageClass string, md5sum []byte) error {
+	metadata := make(map[string]string, len(b.putUserMetadata)+1)
+	for k, v := range b.putUserMetadata {
+		metadata[k] = v
+	}
+	if storageClass != "" {
+		metadata["X-Amz-Storage-Class"] = storageClass
+	}
+	core := minio.Core{Client: b.client}
+	info, err := core.PutObjectWithContext(ctx, b.name, name, r, size, base64.StdEncoding.EncodeToString(md5sum), "", metadata, b.sse)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "BadDigest" {
+			return objstore.ChecksumMismatchError{Name: name, Algorithm: "MD5", Expected: hex.EncodeToString(md5sum), Actual: "rejected with BadDigest"}
+		}
+		return errors.Wrap(err, "upload s3 object")
+	}
+	if b.sse != nil {
+		return nil
+	}
+	return checkETag(name, info.ETag, md5sum)
+}
 
+// checkETag returns a ChecksumMismatchError if the ETag of an object does not match the MD5 checksum of its uploaded
+// data. ETags of objects uploaded in multiple parts have the number of parts as suffix and are not checked.
+func checkETag(name, etag string, md5sum []byte) error {
+	etag = strings.Trim(etag, `"`)
+	if strings.Contains(etag, "-") {
+		return nil
+	}
+	if expected := hex.EncodeToString(md5sum); !strings.EqualFold(etag, expected) {
+		return objstore.ChecksumMismatchError{Name: name, Algorithm: "MD5", Expected: expected, Actual: etag}
+	}
 	return nil
 }
 
@@ -425,3 +580,52 @@ func NewTestBucketFromConfig(t testing.TB, location string, c Config, reuseBucke
 		}
 	}, nil
 }
+
+// requesterPaysTransport adds the header confirming that the requester pays to all requests. The minio client does
+// not allow custom headers for all operations, e.g. for listing objects, so the header is added to the already signed
+// request, which is then signed again to include the header.
+type requesterPaysTransport struct {
+	next  http.RoundTripper
+	creds *credentials.Credentials
+}
+
+func (t *requesterPaysTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip must not modify the given request.
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	r.Header.Set(amzRequestPayer, "requester")
+
+	// Anonymous requests are not signed.
+	region, ok := signingRegion(r.Header.Get("Authorization"))
+	if !ok {
+		return t.next.RoundTrip(r)
+	}
+	v, err := t.creds.Get()
+	if err != nil {
+		return nil, errors.Wrap(err, "get credentials")
+	}
+	return t.next.RoundTrip(s3signer.SignV4(*r, v.AccessKeyID, v.SecretAccessKey, v.SessionToken, region))
+}
+
+// signingRegion returns the region from the credential scope of the signature v4 authorization header, e.g.
+// "AWS4-HMAC-SHA256 Credential=<access key>/20191014/us-east-1/s3/aws4_request, SignedHeaders=..., Signature=...".
+func signingRegion(authorization string) (string, bool) {
+	const prefix = "AWS4-HMAC-SHA256 Credential="
+	if !strings.HasPrefix(authorization, prefix) {
+		return "", false
+	}
+	credential := strings.TrimPrefix(authorization, prefix)
+	if i := strings.Index(credential, ","); i >= 0 {
+		credential = credential[:i]
+	}
+	// The access key may contain slashes, so fields of the scope are counted from the end.
+	scope := strings.Split(credential, "/")
+	if len(scope) < 5 {
+		return "", false
+	}
+	return scope[len(scope)-3], true
+}
@@ -1,9 +1,24 @@
 package s3
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/minio-go/v6/pkg/s3signer"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -133,3 +148,180 @@ http_config:
 	testutil.Ok(t, err)
 	testutil.Assert(t, cfg2.PartSize == 1024*1024*100, "when part size should be set to 100MiB")
 }
+
+func TestParseConfig_StorageClass(t *testing.T) {
+	input := []byte(`bucket: "bucket-name"
+endpoint: "s3-endpoint"
+storage_class: STANDARD_IA
+storage_class_by_resolution:
+  "0s": STANDARD
+  "1h": GLACIER`)
+	cfg, err := parseConfig(input)
+	testutil.Ok(t, err)
+	testutil.Ok(t, validate(cfg))
+	testutil.Equals(t, "STANDARD_IA", cfg.StorageClass)
+
+	classes, err := parseStorageClassByResolution(cfg.StorageClassByResolution)
+	testutil.Ok(t, err)
+	testutil.Equals(t, map[int64]string{0: "STANDARD", 3600000: "GLACIER"}, classes)
+
+	cfg.StorageClassByResolution = map[string]string{"1x": "GLACIER"}
+	testutil.NotOk(t, validate(cfg))
+}
+
+func TestValidate_RequesterPays(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Endpoint = "s3-endpoint"
+	cfg.RequesterPays = true
+	testutil.Ok(t, validate(cfg))
+
+	cfg.Insecure = true
+	testutil.NotOk(t, validate(cfg))
+
+	cfg.Insecure = false
+	cfg.SignatureV2 = true
+	testutil.NotOk(t, validate(cfg))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRequesterPaysTransport(t *testing.T) {
+	var got *http.Request
+	rt := &requesterPaysTransport{
+		next: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			got = r
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}),
+		creds: credentials.NewStaticV4("access/key", "secret", ""),
+	}
+
+	req, err := http.NewRequest("GET", "https://bucket.s3.amazonaws.com/?prefix=a", nil)
+	testutil.Ok(t, err)
+	req = s3signer.SignV4(*req, "access/key", "secret", "", "eu-west-1")
+	signed := req.Header.Get("Authorization")
+
+	_, err = rt.RoundTrip(req)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "requester", got.Header.Get(amzRequestPayer))
+	testutil.Assert(t, strings.Contains(got.Header.Get("Authorization"), "x-amz-request-payer"), "header not signed: %s", got.Header.Get("Authorization"))
+	testutil.Assert(t, strings.Contains(got.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request"), "region not kept: %s", got.Header.Get("Authorization"))
+
+	// The original request is not modified.
+	testutil.Equals(t, "", req.Header.Get(amzRequestPayer))
+	testutil.Equals(t, signed, req.Header.Get("Authorization"))
+
+	// Anonymous requests are not signed.
+	req, err = http.NewRequest("GET", "https://bucket.s3.amazonaws.com/?prefix=a", nil)
+	testutil.Ok(t, err)
+	_, err = rt.RoundTrip(req)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "requester", got.Header.Get(amzRequestPayer))
+	testutil.Equals(t, "", got.Header.Get("Authorization"))
+}
+
+func TestSigningRegion(t *testing.T) {
+	region, ok := signingRegion("AWS4-HMAC-SHA256 Credential=access/key/20191014/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-date, Signature=abc")
+	testutil.Assert(t, ok, "region not found")
+	testutil.Equals(t, "us-east-1", region)
+
+	_, ok = signingRegion("AWS access:signature")
+	testutil.Assert(t, !ok, "region found in signature v2")
+}
+
+func TestParseConfig_ChecksumUploads(t *testing.T) {
+	cfg, err := parseConfig([]byte(`bucket: "bucket-name"
+endpoint: "s3-endpoint"`))
+	testutil.Ok(t, err)
+	testutil.Assert(t, !cfg.ChecksumUploads, "checksums of uploads should not be verified by default")
+
+	cfg, err = parseConfig([]byte(`bucket: "bucket-name"
+endpoint: "s3-endpoint"
+checksum_uploads: true`))
+	testutil.Ok(t, err)
+	testutil.Assert(t, cfg.ChecksumUploads, "checksums of uploads should be verified")
+}
+
+func TestCheckETag(t *testing.T) {
+	md5sum := md5.Sum([]byte("data"))
+	etag := hex.EncodeToString(md5sum[:])
+
+	testutil.Ok(t, checkETag("obj", `"`+etag+`"`, md5sum[:]))
+	testutil.Ok(t, checkETag("obj", strings.ToUpper(etag), md5sum[:]))
+	// ETags of multipart uploads are not checked.
+	testutil.Ok(t, checkETag("obj", `"0123-2"`, md5sum[:]))
+
+	err := checkETag("obj", `"0123"`, md5sum[:])
+	testutil.Assert(t, objstore.IsChecksumMismatchErr(err), "not a checksum mismatch: %v", err)
+}
+
+func TestBucket_UploadChecksum(t *testing.T) {
+	data := []byte("data")
+	md5sum := md5.Sum(data)
+
+	var (
+		mtx         sync.Mutex
+		methods     []string
+		contentMD5s []string
+		status      = http.StatusOK
+		etag        = hex.EncodeToString(md5sum[:])
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		methods = append(methods, r.Method)
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		contentMD5s = append(contentMD5s, r.Header.Get("Content-MD5"))
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>BadDigest</Code><Message>The Content-MD5 you specified did not match what we received.</Message></Error>`))
+			return
+		}
+		w.Header().Set("ETag", `"`+etag+`"`)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+	b, err := NewBucketWithConfig(log.NewNopLogger(), Config{
+		Bucket:          "bucket",
+		Endpoint:        u.Host,
+		Region:          "us-east-1",
+		AccessKey:       "key",
+		SecretKey:       "secret",
+		Insecure:        true,
+		PartSize:        DefaultConfig.PartSize,
+		ChecksumUploads: true,
+	}, "test")
+	testutil.Ok(t, err)
+
+	f, err := ioutil.TempFile("", "upload")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.Remove(f.Name())) }()
+	defer func() { testutil.Ok(t, f.Close()) }()
+	_, err = f.Write(data)
+	testutil.Ok(t, err)
+	upload := func() error {
+		_, err := f.Seek(0, 0)
+		testutil.Ok(t, err)
+		return b.Upload(context.Background(), "obj", f)
+	}
+
+	// The checksum is sent along with the upload, without further requests.
+	testutil.Ok(t, upload())
+	testutil.Equals(t, []string{http.MethodPut}, methods)
+	testutil.Equals(t, []string{base64.StdEncoding.EncodeToString(md5sum[:])}, contentMD5s)
+
+	// Uploads rejected by S3 and ETags not matching the checksum fail, the object is not deleted.
+	methods, status = nil, http.StatusBadRequest
+	err = upload()
+	testutil.Assert(t, objstore.IsChecksumMismatchErr(err), "not a checksum mismatch: %v", err)
+	methods, status, etag = nil, http.StatusOK, "0123"
+	err = upload()
+	testutil.Assert(t, objstore.IsChecksumMismatchErr(err), "not a checksum mismatch: %v", err)
+	testutil.Equals(t, []string{http.MethodPut}, methods)
+}
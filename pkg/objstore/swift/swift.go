@@ -40,12 +40,16 @@ type SwiftConfig struct {
 	ProjectDomainName string `yaml:"project_domain_name"`
 	RegionName        string `yaml:"region_name"`
 	ContainerName     string `yaml:"container_name"`
+	// ListPageSize is the maximum number of entries listed per request. If 0, the server default is used.
+	ListPageSize int `yaml:"list_page_size"`
 }
 
 type Container struct {
 	logger log.Logger
 	client *gophercloud.ServiceClient
 	name   string
+
+	listPageSize int
 }
 
 func NewContainer(logger log.Logger, conf []byte) (*Container, error) {
@@ -53,6 +57,9 @@ func NewContainer(logger log.Logger, conf []byte) (*Container, error) {
 	if err != nil {
 		return nil, err
 	}
+	if sc.ListPageSize < 0 {
+		return nil, errors.New("list_page_size must not be negative")
+	}
 
 	authOpts, err := authOptsFromConfig(sc)
 	if err != nil {
@@ -75,6 +82,8 @@ func NewContainer(logger log.Logger, conf []byte) (*Container, error) {
 		logger: logger,
 		client: client,
 		name:   sc.ContainerName,
+
+		listPageSize: sc.ListPageSize,
 	}, nil
 }
 
@@ -92,8 +101,25 @@ func (c *Container) Iter(ctx context.Context, dir string, f func(string) error)
 		dir = strings.TrimSuffix(dir, DirDelim) + DirDelim
 	}
 
-	options := &objects.ListOpts{Full: false, Prefix: dir, Delimiter: DirDelim}
-	return objects.List(c.client, c.name, options).EachPage(func(page pagination.Page) (bool, error) {
+	marker, _ := objstore.IterStartAfter(ctx)
+	pageSize := objstore.IterPageSize(ctx, c.listPageSize)
+	// Swift rejects listings of more than 10000 entries by default.
+	if pageSize > 10000 {
+		pageSize = 10000
+	}
+	delimiter := DirDelim
+	if objstore.IsRecursiveIter(ctx) {
+		delimiter = ""
+	}
+	options := &objects.ListOpts{
+		Full:      false,
+		Prefix:    dir,
+		Delimiter: delimiter,
+		Marker:    marker,
+		Limit:     pageSize,
+	}
+	f = objstore.IterFunc(ctx, f)
+	return objstore.IterError(objects.List(c.client, c.name, options).EachPage(func(page pagination.Page) (bool, error) {
 		objectNames, err := objects.ExtractNames(page)
 		if err != nil {
 			return false, err
@@ -105,7 +131,7 @@ func (c *Container) Iter(ctx context.Context, dir string, f func(string) error)
 		}
 
 		return true, nil
-	})
+	}))
 }
 
 // Get returns a reader for the given object name.
@@ -0,0 +1,110 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// Timeouts are maximum durations of bucket operations by operation type. Zero disables the timeout of the
+// operation type, so no timeouts apply unless configured. Timeouts of get and get_range operations include reading of the returned object, timeout of
+// iter operation includes time spent in the callback.
+type Timeouts struct {
+	Iter     model.Duration `yaml:"iter"`
+	Get      model.Duration `yaml:"get"`
+	GetRange model.Duration `yaml:"get_range"`
+	Exists   model.Duration `yaml:"exists"`
+	Upload   model.Duration `yaml:"upload"`
+	Delete   model.Duration `yaml:"delete"`
+}
+
+// BucketWithTimeouts returns a bucket which fails operations taking longer than the given timeouts.
+func BucketWithTimeouts(b Bucket, timeouts Timeouts) Bucket {
+	return &timeoutBucket{bkt: b, timeouts: timeouts}
+}
+
+type timeoutBucket struct {
+	bkt      Bucket
+	timeouts Timeouts
+}
+
+// withTimeout returns context of an operation with the given timeout. Returned function has to be called with
+// the error of the operation once it is done, which cancels the context and annotates errors caused by the timeout.
+func withTimeout(ctx context.Context, op string, timeout model.Duration) (context.Context, func(error) error) {
+	if timeout == 0 {
+		return ctx, func(err error) error { return err }
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout))
+	return opCtx, func(err error) error {
+		defer cancel()
+		if err != nil && opCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return errors.Wrapf(err, "%s operation timed out after %s", op, timeout)
+		}
+		return err
+	}
+}
+
+func (b *timeoutBucket) Iter(ctx context.Context, dir string, f func(name string) error) error {
+	ctx, done := withTimeout(ctx, "iter", b.timeouts.Iter)
+	return done(b.bkt.Iter(ctx, dir, f))
+}
+
+func (b *timeoutBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	ctx, done := withTimeout(ctx, "get", b.timeouts.Get)
+	rc, err := b.bkt.Get(ctx, name)
+	if err != nil {
+		return nil, done(err)
+	}
+	return &timeoutReadCloser{ReadCloser: rc, done: done}, nil
+}
+
+func (b *timeoutBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	ctx, done := withTimeout(ctx, "get_range", b.timeouts.GetRange)
+	rc, err := b.bkt.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, done(err)
+	}
+	return &timeoutReadCloser{ReadCloser: rc, done: done}, nil
+}
+
+func (b *timeoutBucket) Exists(ctx context.Context, name string) (bool, error) {
+	ctx, done := withTimeout(ctx, "exists", b.timeouts.Exists)
+	ok, err := b.bkt.Exists(ctx, name)
+	return ok, done(err)
+}
+
+func (b *timeoutBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	ctx, done := withTimeout(ctx, "upload", b.timeouts.Upload)
+	return done(b.bkt.Upload(ctx, name, r))
+}
+
+func (b *timeoutBucket) Delete(ctx context.Context, name string) error {
+	ctx, done := withTimeout(ctx, "delete", b.timeouts.Delete)
+	return done(b.bkt.Delete(ctx, name))
+}
+
+func (b *timeoutBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+func (b *timeoutBucket) Close() error {
+	return b.bkt.Close()
+}
+
+func (b *timeoutBucket) Name() string {
+	return b.bkt.Name()
+}
+
+// timeoutReadCloser keeps the context of the operation until the reader is closed.
+type timeoutReadCloser struct {
+	io.ReadCloser
+	done func(error) error
+}
+
+func (rc *timeoutReadCloser) Close() error {
+	return rc.done(rc.ReadCloser.Close())
+}
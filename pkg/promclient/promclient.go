@@ -37,6 +37,23 @@ import (
 
 var ErrFlagEndpointNotFound = errors.New("no flag endpoint found")
 
+// Client performs requests against Prometheus and Thanos Query HTTP APIs using the given transport, e.g. one
+// restricting TLS versions and cipher suites of https URLs.
+type Client struct {
+	transport http.RoundTripper
+}
+
+// NewClient returns a client using the given transport, or http.DefaultTransport if nil.
+func NewClient(transport http.RoundTripper) *Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Client{transport: transport}
+}
+
+// defaultClient is used by the package functions.
+var defaultClient = NewClient(nil)
+
 // IsWALDirAccesible returns no error if WAL dir can be found. This helps to tell
 // if we have access to Prometheus TSDB directory.
 func IsWALDirAccesible(dir string) error {
@@ -54,9 +71,14 @@ func IsWALDirAccesible(dir string) error {
 	return nil
 }
 
+// ExternalLabels calls Client.ExternalLabels using http.DefaultTransport.
+func ExternalLabels(ctx context.Context, logger log.Logger, base *url.URL) (labels.Labels, error) {
+	return defaultClient.ExternalLabels(ctx, logger, base)
+}
+
 // ExternalLabels returns external labels from /api/v1/status/config Prometheus endpoint.
 // Note that configuration can be hot reloadable on Prometheus, so this config might change in runtime.
-func ExternalLabels(ctx context.Context, logger log.Logger, base *url.URL) (labels.Labels, error) {
+func (c *Client) ExternalLabels(ctx context.Context, logger log.Logger, base *url.URL) (labels.Labels, error) {
 	u := *base
 	u.Path = path.Join(u.Path, "/api/v1/status/config")
 
@@ -64,7 +86,7 @@ func ExternalLabels(ctx context.Context, logger log.Logger, base *url.URL) (labe
 	if err != nil {
 		return nil, errors.Wrap(err, "create request")
 	}
-	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	resp, err := (&http.Client{Transport: c.transport}).Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, errors.Wrapf(err, "request flags against %s", u.String())
 	}
@@ -170,9 +192,14 @@ func (m *modelBool) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// ConfiguredFlags calls Client.ConfiguredFlags using http.DefaultTransport.
+func ConfiguredFlags(ctx context.Context, logger log.Logger, base *url.URL) (Flags, error) {
+	return defaultClient.ConfiguredFlags(ctx, logger, base)
+}
+
 // ConfiguredFlags returns configured flags from /api/v1/status/flags Prometheus endpoint.
 // Added to Prometheus from v2.2.
-func ConfiguredFlags(ctx context.Context, logger log.Logger, base *url.URL) (Flags, error) {
+func (c *Client) ConfiguredFlags(ctx context.Context, logger log.Logger, base *url.URL) (Flags, error) {
 	u := *base
 	u.Path = path.Join(u.Path, "/api/v1/status/flags")
 
@@ -181,7 +208,7 @@ func ConfiguredFlags(ctx context.Context, logger log.Logger, base *url.URL) (Fla
 		return Flags{}, errors.Wrap(err, "create request")
 	}
 
-	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	resp, err := (&http.Client{Transport: c.transport}).Do(req.WithContext(ctx))
 	if err != nil {
 		return Flags{}, errors.Wrapf(err, "request config against %s", u.String())
 	}
@@ -211,12 +238,17 @@ func ConfiguredFlags(ctx context.Context, logger log.Logger, base *url.URL) (Fla
 
 }
 
+// Snapshot calls Client.Snapshot using http.DefaultTransport.
+func Snapshot(ctx context.Context, logger log.Logger, base *url.URL, skipHead bool) (string, error) {
+	return defaultClient.Snapshot(ctx, logger, base, skipHead)
+}
+
 // Snapshot will request Prometheus to perform snapshot in directory returned by this function.
 // Returned directory is relative to Prometheus data-dir.
 // NOTE: `--web.enable-admin-api` flag has to be set on Prometheus.
 // Added to Prometheus from v2.1.
 // TODO(bwplotka): Add metrics.
-func Snapshot(ctx context.Context, logger log.Logger, base *url.URL, skipHead bool) (string, error) {
+func (c *Client) Snapshot(ctx context.Context, logger log.Logger, base *url.URL, skipHead bool) (string, error) {
 	u := *base
 	u.Path = path.Join(u.Path, "/api/v1/admin/tsdb/snapshot")
 
@@ -230,7 +262,7 @@ func Snapshot(ctx context.Context, logger log.Logger, base *url.URL, skipHead bo
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	resp, err := (&http.Client{Transport: c.transport}).Do(req.WithContext(ctx))
 	if err != nil {
 		return "", errors.Wrapf(err, "request snapshot against %s", u.String())
 	}
@@ -281,8 +313,13 @@ func (p *QueryOptions) AddTo(values url.Values) error {
 	return nil
 }
 
-// QueryInstant performs instant query and returns results in model.Vector type.
+// QueryInstant calls Client.QueryInstant using http.DefaultTransport.
 func QueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query string, t time.Time, opts QueryOptions) (model.Vector, []string, error) {
+	return defaultClient.QueryInstant(ctx, logger, base, query, t, opts)
+}
+
+// QueryInstant performs instant query and returns results in model.Vector type.
+func (c *Client) QueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query string, t time.Time, opts QueryOptions) (model.Vector, []string, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
@@ -311,7 +348,7 @@ func QueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query s
 	req = req.WithContext(ctx)
 
 	client := &http.Client{
-		Transport: tracing.HTTPTripperware(logger, http.DefaultTransport),
+		Transport: tracing.HTTPTripperware(logger, c.transport),
 	}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -369,9 +406,14 @@ func QueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query s
 	return vectorResult, m.Warnings, nil
 }
 
-// PromqlQueryInstant performs instant query and returns results in promql.Vector type that is compatible with promql package.
+// PromqlQueryInstant calls Client.PromqlQueryInstant using http.DefaultTransport.
 func PromqlQueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query string, t time.Time, opts QueryOptions) (promql.Vector, []string, error) {
-	vectorResult, warnings, err := QueryInstant(ctx, logger, base, query, t, opts)
+	return defaultClient.PromqlQueryInstant(ctx, logger, base, query, t, opts)
+}
+
+// PromqlQueryInstant performs instant query and returns results in promql.Vector type that is compatible with promql package.
+func (c *Client) PromqlQueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query string, t time.Time, opts QueryOptions) (promql.Vector, []string, error) {
+	vectorResult, warnings, err := c.QueryInstant(ctx, logger, base, query, t, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -426,8 +468,13 @@ func convertScalarJSONToVector(scalarJSONResult json.RawMessage) (model.Vector,
 		Timestamp: resultTime}}, nil
 }
 
-// MetricValues returns current value of  instant query and returns results in model.Vector type.
+// MetricValues calls Client.MetricValues using http.DefaultTransport.
 func MetricValues(ctx context.Context, logger log.Logger, base *url.URL, perMetricFn func(metric promlabels.Labels, val float64) error) error {
+	return defaultClient.MetricValues(ctx, logger, base, perMetricFn)
+}
+
+// MetricValues returns current value of  instant query and returns results in model.Vector type.
+func (c *Client) MetricValues(ctx context.Context, logger log.Logger, base *url.URL, perMetricFn func(metric promlabels.Labels, val float64) error) error {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
@@ -446,7 +493,7 @@ func MetricValues(ctx context.Context, logger log.Logger, base *url.URL, perMetr
 	req = req.WithContext(ctx)
 
 	client := &http.Client{
-		Transport: tracing.HTTPTripperware(logger, http.DefaultTransport),
+		Transport: tracing.HTTPTripperware(logger, c.transport),
 	}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -0,0 +1,154 @@
+// Package promproxy implements HTTP proxy of Prometheus query API, which adds external labels to query results,
+// the same way sidecar StoreAPI does.
+package promproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/labels"
+)
+
+// Paths of Prometheus query API which are proxied.
+var Paths = []string{"/api/v1/query", "/api/v1/query_range"}
+
+// Proxy forwards query API requests to Prometheus and adds external labels to series of results. External labels
+// replace labels with the same name returned by Prometheus.
+type Proxy struct {
+	logger         log.Logger
+	externalLabels func() labels.Labels
+	proxy          *httputil.ReverseProxy
+}
+
+// New returns a proxy of the query API of Prometheus at the given URL. External labels are evaluated per request, so
+// they reflect changes of Prometheus configuration.
+func New(logger log.Logger, promURL *url.URL, externalLabels func() labels.Labels) *Proxy {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	p := &Proxy{logger: logger, externalLabels: externalLabels}
+	p.proxy = &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = promURL.Scheme
+			r.URL.Host = promURL.Host
+			r.URL.Path = path.Join("/", promURL.Path, r.URL.Path)
+			r.Host = promURL.Host
+			// Response has to be rewritten, so ask for uncompressed one.
+			r.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: p.modifyResponse,
+	}
+	return p
+}
+
+// WithTransport sets the transport of requests to Prometheus, e.g. to restrict TLS versions and cipher suites of
+// https URLs.
+func (p *Proxy) WithTransport(transport http.RoundTripper) {
+	p.proxy.Transport = transport
+}
+
+// ServeHTTP proxies requests of query API paths to Prometheus. Other paths are not found.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, pth := range Paths {
+		if r.URL.Path == pth {
+			p.proxy.ServeHTTP(w, r)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read response")
+	}
+	if err := resp.Body.Close(); err != nil {
+		return errors.Wrap(err, "close response")
+	}
+
+	if extLset := p.externalLabels(); len(extLset) > 0 {
+		if b, err = addExternalLabels(b, extLset); err != nil {
+			level.Warn(p.logger).Log("msg", "failed to add external labels to query response", "err", err)
+			return err
+		}
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	resp.ContentLength = int64(len(b))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(b)))
+	return nil
+}
+
+// addExternalLabels adds external labels to metric of each series of vector and matrix results in the JSON encoded
+// query API response. Other fields are left untouched.
+func addExternalLabels(b []byte, extLset labels.Labels) ([]byte, error) {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, errors.Wrap(err, "unmarshal response")
+	}
+	if len(resp["data"]) == 0 {
+		return b, nil
+	}
+
+	var (
+		rawData    map[string]json.RawMessage
+		resultType string
+		result     []map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(resp["data"], &rawData); err != nil {
+		return nil, errors.Wrap(err, "unmarshal data")
+	}
+	if err := json.Unmarshal(rawData["resultType"], &resultType); err != nil {
+		return nil, errors.Wrap(err, "unmarshal result type")
+	}
+	if resultType != "vector" && resultType != "matrix" {
+		return b, nil
+	}
+	if err := json.Unmarshal(rawData["result"], &result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal result")
+	}
+
+	for _, s := range result {
+		var metric map[string]string
+		if len(s["metric"]) > 0 {
+			if err := json.Unmarshal(s["metric"], &metric); err != nil {
+				return nil, errors.Wrap(err, "unmarshal metric")
+			}
+		}
+		if metric == nil {
+			metric = map[string]string{}
+		}
+		for _, l := range extLset {
+			metric[l.Name] = l.Value
+		}
+		mb, err := json.Marshal(metric)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal metric")
+		}
+		s["metric"] = mb
+	}
+
+	var err error
+	if rawData["result"], err = json.Marshal(result); err != nil {
+		return nil, errors.Wrap(err, "marshal result")
+	}
+	if resp["data"], err = json.Marshal(rawData); err != nil {
+		return nil, errors.Wrap(err, "marshal data")
+	}
+	return json.Marshal(resp)
+}
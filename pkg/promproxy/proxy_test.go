@@ -0,0 +1,89 @@
+package promproxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestProxy(t *testing.T) {
+	responses := map[string]string{
+		"/prom/api/v1/query":       `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","replica":"x"},"value":[1,"1"]},{"metric":null,"value":[1,"2"]}]}}`,
+		"/prom/api/v1/query_range": `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up"},"values":[[1,"1"],[2,"1"]]}]},"warnings":["w"]}`,
+	}
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "bad" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+			return
+		}
+		if r.URL.Query().Get("query") == "1" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`))
+			return
+		}
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(resp))
+	}))
+	defer prom.Close()
+
+	u, err := url.Parse(prom.URL + "/prom")
+	testutil.Ok(t, err)
+	extLset := labels.FromStrings("cluster", "a", "replica", "b")
+	srv := httptest.NewServer(New(nil, u, func() labels.Labels { return extLset }))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{
+			path:   "/api/v1/query?query=up",
+			status: http.StatusOK,
+			body:   `{"data":{"result":[{"metric":{"__name__":"up","cluster":"a","replica":"b"},"value":[1,"1"]},{"metric":{"cluster":"a","replica":"b"},"value":[1,"2"]}],"resultType":"vector"},"status":"success"}`,
+		},
+		{
+			path:   "/api/v1/query_range?query=up",
+			status: http.StatusOK,
+			body:   `{"data":{"result":[{"metric":{"__name__":"up","cluster":"a","replica":"b"},"values":[[1,"1"],[2,"1"]]}],"resultType":"matrix"},"status":"success","warnings":["w"]}`,
+		},
+		{
+			path:   "/api/v1/query?query=1",
+			status: http.StatusOK,
+			body:   `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`,
+		},
+		{
+			path:   "/api/v1/query?query=bad",
+			status: http.StatusBadRequest,
+			body:   `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+		},
+		{
+			path:   "/api/v1/labels",
+			status: http.StatusNotFound,
+			body:   "404 page not found\n",
+		},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tc.path)
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, resp.Body.Close()) }()
+
+			b, err := ioutil.ReadAll(resp.Body)
+			testutil.Ok(t, err)
+			testutil.Equals(t, tc.status, resp.StatusCode)
+			testutil.Equals(t, tc.body, string(b))
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/gogo/protobuf/proto"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
+)
+
+const (
+	// ContentTypeProtobuf is the media type of query results encoded as prompb.QueryResult, the message used for
+	// results of Prometheus remote read. Clients request it with the Accept header.
+	ContentTypeProtobuf = "application/x-protobuf"
+	// WarningsHeader is the header carrying warnings of responses encoded as protobuf, one value per warning.
+	WarningsHeader = "Thanos-Warnings"
+
+	encodingZstd = "zstd"
+)
+
+// accepts returns true if the value of the Accept or Accept-Encoding header lists the token with non-zero quality.
+func accepts(header, token string) bool {
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), token) {
+			continue
+		}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if !strings.HasPrefix(p, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(p[2:], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// acceptsProtobuf returns true if the client accepts query results encoded as protobuf.
+func acceptsProtobuf(r *http.Request) bool {
+	return accepts(r.Header.Get("Accept"), ContentTypeProtobuf)
+}
+
+// respondProtobuf writes matrix and vector results of queries encoded as prompb.QueryResult. Each series is encoded and
+// written on its own, the same way respondStream does for JSON. Returns false without writing anything for other
+// results, which are left to be encoded as JSON.
+func respondProtobuf(w http.ResponseWriter, data interface{}, warnings []error) bool {
+	qd, ok := data.(*queryData)
+	if !ok {
+		return false
+	}
+	switch qd.Result.(type) {
+	case promql.Matrix, promql.Vector:
+	default:
+		return false
+	}
+
+	for _, warn := range warnings {
+		w.Header().Add(WarningsHeader, warn.Error())
+	}
+	w.Header().Set("Content-Type", ContentTypeProtobuf)
+	w.WriteHeader(http.StatusOK)
+
+	// Encoded QueryResult is the concatenation of its encoded time series, as they are its only, repeated field.
+	var buf []byte
+	write := func(ts *prompb.TimeSeries) error {
+		b, err := ts.Marshal()
+		if err != nil {
+			return err
+		}
+		buf = append(buf[:0], 1<<3|proto.WireBytes)
+		buf = append(buf, proto.EncodeVarint(uint64(len(b)))...)
+		buf = append(buf, b...)
+		_, err = w.Write(buf)
+		return err
+	}
+
+	switch v := qd.Result.(type) {
+	case promql.Matrix:
+		for i := range v {
+			ts := &prompb.TimeSeries{Labels: protoLabels(v[i].Metric), Samples: make([]prompb.Sample, 0, len(v[i].Points))}
+			for _, p := range v[i].Points {
+				ts.Samples = append(ts.Samples, prompb.Sample{Timestamp: p.T, Value: p.V})
+			}
+			if err := write(ts); err != nil {
+				return true
+			}
+			v[i] = promql.Series{}
+			if (i+1)%streamFlushSeries == 0 {
+				flush(w)
+			}
+		}
+	case promql.Vector:
+		for _, s := range v {
+			ts := &prompb.TimeSeries{Labels: protoLabels(s.Metric), Samples: []prompb.Sample{{Timestamp: s.T, Value: s.V}}}
+			if err := write(ts); err != nil {
+				return true
+			}
+		}
+	}
+	return true
+}
+
+func protoLabels(lset labels.Labels) []prompb.Label {
+	res := make([]prompb.Label, 0, len(lset))
+	for _, l := range lset {
+		res = append(res, prompb.Label{Name: l.Name, Value: l.Value})
+	}
+	return res
+}
+
+var zstdEncoders = sync.Pool{
+	New: func() interface{} {
+		// The encoder is used by a single response, concurrency would only add goroutines.
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return e
+	},
+}
+
+// compressHandler compresses responses of the handler with zstd if accepted by the client, otherwise with gzip if
+// accepted.
+func compressHandler(h http.Handler) http.Handler {
+	gz := gziphandler.GzipHandler(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !accepts(r.Header.Get("Accept-Encoding"), encodingZstd) {
+			gz.ServeHTTP(w, r)
+			return
+		}
+
+		e := zstdEncoders.Get().(*zstd.Encoder)
+		defer zstdEncoders.Put(e)
+		e.Reset(w)
+
+		zw := &zstdResponseWriter{ResponseWriter: w, e: e}
+		h.ServeHTTP(zw, r)
+		if zw.status != 0 && zw.status != http.StatusNoContent {
+			// The response is written already, an error can only be noticed by the client.
+			_ = e.Close()
+		}
+	})
+}
+
+type zstdResponseWriter struct {
+	http.ResponseWriter
+	e      *zstd.Encoder
+	status int
+}
+
+func (w *zstdResponseWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	if code != http.StatusNoContent {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", encodingZstd)
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *zstdResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.e.Write(b)
+}
+
+// Flush implements http.Flusher, so streamed responses are sent to the client as they are written.
+func (w *zstdResponseWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := w.e.Flush(); err != nil {
+		return
+	}
+	flush(w.ResponseWriter)
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestAccepts(t *testing.T) {
+	testutil.Assert(t, accepts("gzip, zstd", "zstd"), "zstd not accepted")
+	testutil.Assert(t, accepts("application/json;q=0.9, Application/X-Protobuf;q=0.5", ContentTypeProtobuf), "protobuf not accepted")
+	testutil.Assert(t, !accepts("gzip, zstd;q=0", "zstd"), "zstd accepted")
+	testutil.Assert(t, !accepts("zstd;q=0.000", "zstd"), "zstd accepted")
+	testutil.Assert(t, !accepts("application/json", ContentTypeProtobuf), "protobuf accepted")
+	testutil.Assert(t, !accepts("", "zstd"), "zstd accepted")
+}
+
+func TestCompressHandler(t *testing.T) {
+	// Responses smaller than the minimum size of gziphandler are not compressed with gzip.
+	body := bytes.Repeat([]byte(`{"status":"success"}`), 100)
+	s := httptest.NewServer(compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	})))
+	defer s.Close()
+
+	get := func(encoding string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", s.URL, nil)
+		testutil.Ok(t, err)
+		req.Header.Set("Accept-Encoding", encoding)
+		resp, err := (&http.Transport{DisableCompression: true}).RoundTrip(req)
+		testutil.Ok(t, err)
+		defer func() { testutil.Ok(t, resp.Body.Close()) }()
+		b, err := ioutil.ReadAll(resp.Body)
+		testutil.Ok(t, err)
+		return resp, b
+	}
+
+	resp, b := get("gzip, zstd")
+	testutil.Equals(t, "zstd", resp.Header.Get("Content-Encoding"))
+	d, err := zstd.NewReader(nil)
+	testutil.Ok(t, err)
+	defer d.Close()
+	got, err := d.DecodeAll(b, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, body, got)
+
+	// Encoders are reused by subsequent responses.
+	_, b = get("zstd")
+	got, err = d.DecodeAll(b, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, body, got)
+
+	resp, b = get("gzip")
+	testutil.Equals(t, "gzip", resp.Header.Get("Content-Encoding"))
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	testutil.Ok(t, err)
+	got, err = ioutil.ReadAll(gz)
+	testutil.Ok(t, err)
+	testutil.Equals(t, body, got)
+
+	resp, b = get("")
+	testutil.Equals(t, "", resp.Header.Get("Content-Encoding"))
+	testutil.Equals(t, body, b)
+}
+
+func TestRespondProtobuf(t *testing.T) {
+	warnings := []error{errors.New("warning")}
+
+	for _, tcase := range []struct {
+		name   string
+		result promql.Value
+		exp    *prompb.QueryResult
+	}{
+		{
+			name: "matrix",
+			result: promql.Matrix{
+				{Metric: labels.FromStrings("a", "1"), Points: []promql.Point{{T: 1, V: 1}, {T: 2, V: 2}}},
+				{Metric: labels.FromStrings("a", "2", "b", "3"), Points: []promql.Point{{T: 1, V: 3}}},
+			},
+			exp: &prompb.QueryResult{Timeseries: []*prompb.TimeSeries{
+				{Labels: []prompb.Label{{Name: "a", Value: "1"}}, Samples: []prompb.Sample{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}}},
+				{Labels: []prompb.Label{{Name: "a", Value: "2"}, {Name: "b", Value: "3"}}, Samples: []prompb.Sample{{Timestamp: 1, Value: 3}}},
+			}},
+		},
+		{
+			name:   "vector",
+			result: promql.Vector{{Metric: labels.FromStrings("a", "1"), Point: promql.Point{T: 1, V: 1}}},
+			exp: &prompb.QueryResult{Timeseries: []*prompb.TimeSeries{
+				{Labels: []prompb.Label{{Name: "a", Value: "1"}}, Samples: []prompb.Sample{{Timestamp: 1, Value: 1}}},
+			}},
+		},
+		{
+			name:   "empty matrix",
+			result: promql.Matrix{},
+			exp:    &prompb.QueryResult{},
+		},
+		{
+			name:   "scalar is encoded as JSON",
+			result: promql.Scalar{T: 1, V: 1},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			data := &queryData{ResultType: tcase.result.Type(), Result: tcase.result}
+			if !respondProtobuf(w, data, warnings) {
+				testutil.Assert(t, tcase.exp == nil, "result not encoded as protobuf")
+				Respond(w, data, warnings)
+
+				var res response
+				testutil.Ok(t, json.Unmarshal(w.Body.Bytes(), &res))
+				testutil.Equals(t, statusSuccess, res.Status)
+				return
+			}
+			testutil.Assert(t, tcase.exp != nil, "result encoded as protobuf")
+			testutil.Equals(t, ContentTypeProtobuf, w.Header().Get("Content-Type"))
+			testutil.Equals(t, []string{"warning"}, w.Header()[WarningsHeader])
+
+			var res prompb.QueryResult
+			testutil.Ok(t, res.Unmarshal(w.Body.Bytes()))
+			testutil.Equals(t, tcase.exp, &res)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// maxInflightMirrorRequests is the maximum number of requests sent to the mirror at the same time. Requests selected
+// for mirroring above this limit are dropped, so a slow mirror does not pile up goroutines and buffered responses.
+const maxInflightMirrorRequests = 20
+
+// Mirror sends a percentage of query requests to a secondary query API, e.g. a querier in front of upgraded store
+// gateways, and compares checksums of its results with results of the primary response. The primary response is
+// never affected, mirrored requests are sent after it was written.
+// Mirrored requests carry the headers of the primary request, e.g. for authorization.
+// NOTE: Primary responses are buffered in memory until the checksums are computed, mirrored responses are decoded as
+// they are read.
+type Mirror struct {
+	logger     log.Logger
+	client     *http.Client
+	url        *url.URL
+	percentage float64
+	gate       chan struct{}
+
+	now    func() time.Time
+	sample func() float64
+
+	requests  *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	divergent *prometheus.CounterVec
+	dropped   *prometheus.CounterVec
+}
+
+// NewMirror returns a Mirror sending the given percentage of query requests to the query API at the given URL.
+// The URL is the prefix of the /api/v1 path, e.g. http://querier-canary:10902.
+func NewMirror(logger log.Logger, reg prometheus.Registerer, u *url.URL, percentage float64, timeout time.Duration) (*Mirror, error) {
+	if percentage < 0 || percentage > 100 {
+		return nil, errors.Errorf("invalid mirror percentage %v, must be between 0 and 100", percentage)
+	}
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	m := &Mirror{
+		logger:     logger,
+		client:     &http.Client{Timeout: timeout},
+		url:        u,
+		percentage: percentage,
+		gate:       make(chan struct{}, maxInflightMirrorRequests),
+		now:        time.Now,
+		sample:     rand.Float64,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_mirror_requests_total",
+			Help: "Total number of query requests sent to the mirror.",
+		}, []string{"handler"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_mirror_failures_total",
+			Help: "Total number of mirrored query requests which failed or returned an error.",
+		}, []string{"handler"}),
+		divergent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_mirror_divergent_responses_total",
+			Help: "Total number of mirrored query requests with results not matching the results of the primary response.",
+		}, []string{"handler"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_mirror_dropped_requests_total",
+			Help: "Total number of query requests selected for mirroring which were dropped due to too many in-flight mirrored requests.",
+		}, []string{"handler"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.requests, m.failures, m.divergent, m.dropped)
+	}
+	return m, nil
+}
+
+// Handler wraps the handler of the given query endpoint, mirroring a percentage of its successful requests.
+func (m *Mirror) Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only JSON encoded results can be compared.
+		if m.percentage == 0 || acceptsProtobuf(r) || m.sample()*100 >= m.percentage {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Instant queries without time are evaluated at the time of the request. Both queries have to be evaluated
+		// at the same time to return the same results.
+		if name == "query" && r.Form.Get("time") == "" {
+			r.Form.Set("time", strconv.FormatFloat(float64(m.now().UnixNano())/1e9, 'f', -1, 64))
+		}
+		form := url.Values{}
+		for k, v := range r.Form {
+			form[k] = append([]string(nil), v...)
+		}
+		header := mirrorHeader(r.Header)
+
+		tw := &teeResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(tw, r)
+		if tw.status != http.StatusOK {
+			return
+		}
+
+		select {
+		case m.gate <- struct{}{}:
+		default:
+			m.dropped.WithLabelValues(name).Inc()
+			return
+		}
+		go func() {
+			defer func() { <-m.gate }()
+			m.compare(name, header, form, tw.body.Bytes())
+		}()
+	})
+}
+
+// compare sends the request to the mirror and compares its results with results of the primary response.
+func (m *Mirror) compare(name string, header http.Header, form url.Values, primary []byte) {
+	m.requests.WithLabelValues(name).Inc()
+
+	if err := m.doCompare(name, header, form, primary); err != nil {
+		m.failures.WithLabelValues(name).Inc()
+		level.Warn(m.logger).Log("msg", "mirrored query request failed", "handler", name, "query", form.Get("query"), "err", err)
+	}
+}
+
+func (m *Mirror) doCompare(name string, header http.Header, form url.Values, primary []byte) (err error) {
+	expected, err := resultChecksum(bytes.NewReader(primary))
+	if err != nil {
+		return errors.Wrap(err, "checksum of primary response")
+	}
+
+	u := *m.url
+	u.Path = path.Join(u.Path, "/api/v1", name)
+	req, err := http.NewRequest(http.MethodPost, u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "create mirrored request")
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send mirrored request")
+	}
+	defer runutil.ExhaustCloseWithErrCapture(&err, resp.Body, "mirrored response body")
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("mirror returned status %s", resp.Status)
+	}
+	got, err := resultChecksum(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "checksum of mirrored response")
+	}
+
+	if got != expected {
+		m.divergent.WithLabelValues(name).Inc()
+		level.Warn(m.logger).Log("msg", "mirrored query results diverge", "handler", name, "query", form.Get("query"))
+	}
+	return nil
+}
+
+// mirrorHeader returns headers of the primary request to be sent with the mirrored request, e.g. for authorization
+// or tenancy. Headers describing the body or encoding of the primary request and its response are left to the client.
+func mirrorHeader(h http.Header) http.Header {
+	res := h.Clone()
+	for _, k := range []string{"Content-Length", "Content-Type", "Content-Encoding", "Accept-Encoding", "Connection"} {
+		res.Del(k)
+	}
+	return res
+}
+
+// resultChecksum returns checksum of the result of the query response read from r. Warnings are not part of the
+// checksum. Elements of vector and matrix results are compared regardless of their order.
+func resultChecksum(r io.Reader) (string, error) {
+	var resp struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return "", errors.Wrap(err, "decode response")
+	}
+	if resp.Status != string(statusSuccess) {
+		return "", errors.Errorf("unexpected response status %q", resp.Status)
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(resp.Data.Result, &result); err != nil {
+		return "", errors.Wrap(err, "decode result")
+	}
+
+	// Maps are encoded with sorted keys, so the same result is always encoded the same way.
+	var elems [][]byte
+	if list, ok := result.([]interface{}); ok {
+		for _, e := range list {
+			eb, err := json.Marshal(e)
+			if err != nil {
+				return "", errors.Wrap(err, "encode result element")
+			}
+			elems = append(elems, eb)
+		}
+		sort.Slice(elems, func(i, j int) bool {
+			return bytes.Compare(elems[i], elems[j]) < 0
+		})
+	} else {
+		eb, err := json.Marshal(result)
+		if err != nil {
+			return "", errors.Wrap(err, "encode result")
+		}
+		elems = append(elems, eb)
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(resp.Data.ResultType))
+	for _, e := range elems {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write(e)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// teeResponseWriter records the status and a copy of the body of the response.
+type teeResponseWriter struct {
+	http.ResponseWriter
+
+	status int
+	body   bytes.Buffer
+}
+
+func (w *teeResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *teeResponseWriter) Write(b []byte) (int, error) {
+	_, _ = w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, so streamed primary responses are sent to the client as they are written.
+func (w *teeResponseWriter) Flush() {
+	flush(w.ResponseWriter)
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestResultChecksum(t *testing.T) {
+	a, err := resultChecksum(strings.NewReader(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1","b":"1"},"value":[1,"1"]},{"metric":{"a":"2"},"value":[1,"2"]}]}}`))
+	testutil.Ok(t, err)
+
+	// Order of elements, keys and warnings do not matter.
+	b, err := resultChecksum(strings.NewReader(`{"status":"success","warnings":["partial"],"data":{"resultType":"vector","result":[{"value":[1,"2"],"metric":{"a":"2"}},{"metric":{"b":"1","a":"1"},"value":[1,"1"]}]}}`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, a, b)
+
+	c, err := resultChecksum(strings.NewReader(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1","b":"1"},"value":[1,"1"]},{"metric":{"a":"2"},"value":[1,"3"]}]}}`))
+	testutil.Ok(t, err)
+	testutil.Assert(t, a != c, "different results have the same checksum")
+
+	s1, err := resultChecksum(strings.NewReader(`{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`))
+	testutil.Ok(t, err)
+	s2, err := resultChecksum(strings.NewReader(`{"status":"success","data":{"resultType":"scalar","result":[1,"2"]}}`))
+	testutil.Ok(t, err)
+	testutil.Assert(t, s1 != s2, "different results have the same checksum")
+
+	_, err = resultChecksum(strings.NewReader(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	testutil.NotOk(t, err)
+}
+
+func TestMirror(t *testing.T) {
+	const (
+		result          = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1"},"value":[10,"1"]}]}}`
+		divergentResult = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"a":"1"},"value":[10,"2"]}]}}`
+	)
+
+	var (
+		mirrorResp = result
+		mirrorCode = http.StatusOK
+		mirrorReq  url.Values
+		mirrorAuth string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/prefix/api/v1/query", r.URL.Path)
+		testutil.Ok(t, r.ParseForm())
+		mirrorReq = r.Form
+		mirrorAuth = r.Header.Get("Authorization")
+		w.WriteHeader(mirrorCode)
+		fmt.Fprint(w, mirrorResp)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/prefix")
+	testutil.Ok(t, err)
+
+	_, err = NewMirror(nil, nil, u, 101, time.Minute)
+	testutil.NotOk(t, err)
+
+	m, err := NewMirror(nil, nil, u, 50, time.Minute)
+	testutil.Ok(t, err)
+	m.now = func() time.Time { return time.Unix(10, 0) }
+
+	var primaryTime string
+	h := m.Handler("query", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryTime = r.FormValue("time")
+		fmt.Fprint(w, result)
+		flush(w)
+	}))
+	serve := func(sample float64) {
+		m.sample = func() float64 { return sample }
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		h.ServeHTTP(rec, req)
+		testutil.Equals(t, http.StatusOK, rec.Code)
+		testutil.Equals(t, result, rec.Body.String())
+		testutil.Assert(t, rec.Flushed, "primary response not flushed")
+
+		// Wait for the mirrored request to complete.
+		for i := 0; i < cap(m.gate); i++ {
+			m.gate <- struct{}{}
+		}
+		for i := 0; i < cap(m.gate); i++ {
+			<-m.gate
+		}
+	}
+
+	// Requests not selected for mirroring are not sent to the mirror.
+	serve(0.9)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(m.requests.WithLabelValues("query")))
+	testutil.Equals(t, "", primaryTime)
+
+	// Mirrored instant queries are evaluated at the same time.
+	serve(0.1)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(m.requests.WithLabelValues("query")))
+	testutil.Equals(t, "10", primaryTime)
+	testutil.Equals(t, url.Values{"query": []string{"up"}, "time": []string{"10"}}, mirrorReq)
+	testutil.Equals(t, "Bearer secret", mirrorAuth)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(m.divergent.WithLabelValues("query")))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(m.failures.WithLabelValues("query")))
+
+	mirrorResp = divergentResult
+	serve(0.1)
+	testutil.Equals(t, 2.0, promtest.ToFloat64(m.requests.WithLabelValues("query")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(m.divergent.WithLabelValues("query")))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(m.failures.WithLabelValues("query")))
+
+	mirrorCode = http.StatusInternalServerError
+	serve(0.1)
+	testutil.Equals(t, 3.0, promtest.ToFloat64(m.requests.WithLabelValues("query")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(m.divergent.WithLabelValues("query")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(m.failures.WithLabelValues("query")))
+}
@@ -25,7 +25,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/NYTimes/gziphandler"
 	"github.com/go-kit/kit/log"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
@@ -38,7 +37,10 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/requestid"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/tenancy"
 	"github.com/thanos-io/thanos/pkg/tracing"
 )
 
@@ -60,6 +62,10 @@ const (
 	ErrorInternal ErrorType = "internal"
 )
 
+// streamFlushSeries is the number of series written by streamed responses between flushes of the response, so the
+// client receives the result while it is still being encoded.
+const streamFlushSeries = 100
+
 var corsHeaders = map[string]string{
 	"Access-Control-Allow-Headers":  "Accept, Accept-Encoding, Authorization, Content-Type, Origin",
 	"Access-Control-Allow-Methods":  "GET, OPTIONS",
@@ -82,6 +88,45 @@ type response struct {
 	ErrorType ErrorType   `json:"errorType,omitempty"`
 	Error     string      `json:"error,omitempty"`
 	Warnings  []string    `json:"warnings,omitempty"`
+
+	// Additional Thanos Response fields.
+	LimitWarnings []storepb.LimitWarning `json:"limitWarnings,omitempty"`
+	Annotations   []Annotation           `json:"annotations,omitempty"`
+}
+
+// AnnotationType is the source of an annotation of a response.
+type AnnotationType string
+
+const (
+	// AnnotationPartialResponse annotates data missing from the response, because a StoreAPI failed or did not
+	// match the query.
+	AnnotationPartialResponse AnnotationType = "partial_response"
+	// AnnotationStoreLimit annotates data truncated from the response, because a StoreAPI hit its limit.
+	AnnotationStoreLimit AnnotationType = "store_limit"
+	// AnnotationQuery annotates any other warning of the query evaluation, e.g. of PromQL.
+	AnnotationQuery AnnotationType = "query"
+)
+
+// Annotation is a structured warning of a response. Warnings carries the same warnings as plain messages.
+type Annotation struct {
+	Level   string         `json:"level"`
+	Type    AnnotationType `json:"type"`
+	Message string         `json:"message"`
+	// Store is the address of the StoreAPI the annotation is about, if known.
+	Store string `json:"store,omitempty"`
+}
+
+// newAnnotation returns the annotation of the warning.
+func newAnnotation(warn error) Annotation {
+	a := Annotation{Level: "warning", Type: AnnotationQuery, Message: warn.Error()}
+	switch w := errors.Cause(warn).(type) {
+	case storepb.LimitWarning:
+		a.Type = AnnotationStoreLimit
+		a.Store = w.Store
+	case query.PartialResponseWarning:
+		a.Type = AnnotationPartialResponse
+	}
+	return a
 }
 
 // Enables cross-site script calls.
@@ -106,6 +151,18 @@ type API struct {
 	reg                                    prometheus.Registerer
 	defaultInstantQueryMaxSourceResolution time.Duration
 
+	// storeViews are queryable creators of named subsets of stores, selected per request using the storeViewHeader.
+	storeViewHeader string
+	storeViews      map[string]query.QueryableCreator
+
+	// mirror, if set, sends a percentage of query requests to a secondary query API to compare results.
+	mirror *Mirror
+
+	// maxSubqueryPoints is the maximum number of points selected by a single subquery per evaluation, 0 means no limit.
+	maxSubqueryPoints int
+	// tenantHeader is the header of requests determining their tenant propagated to stores, see tenancy.HTTPMiddleware.
+	tenantHeader string
+
 	now func() time.Time
 }
 
@@ -119,6 +176,11 @@ func NewAPI(
 	enablePartialResponse bool,
 	replicaLabels []string,
 	defaultInstantQueryMaxSourceResolution time.Duration,
+	storeViewHeader string,
+	storeViews map[string]query.QueryableCreator,
+	mirror *Mirror,
+	maxSubqueryPoints int,
+	tenantHeader string,
 ) *API {
 	return &API{
 		logger:                                 logger,
@@ -129,6 +191,11 @@ func NewAPI(
 		replicaLabels:                          replicaLabels,
 		reg:                                    reg,
 		defaultInstantQueryMaxSourceResolution: defaultInstantQueryMaxSourceResolution,
+		storeViewHeader:                        storeViewHeader,
+		storeViews:                             storeViews,
+		mirror:                                 mirror,
+		maxSubqueryPoints:                      maxSubqueryPoints,
+		tenantHeader:                           tenantHeader,
 
 		now: time.Now,
 	}
@@ -137,17 +204,22 @@ func NewAPI(
 // Register the API's endpoints in the given router.
 func (api *API) Register(r *route.Router, tracer opentracing.Tracer, logger log.Logger, ins extpromhttp.InstrumentationMiddleware) {
 	instr := func(name string, f ApiFunc) http.HandlerFunc {
-		hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hf http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			SetCORS(w)
 			if data, warnings, err := f(r); err != nil {
 				RespondError(w, err, data)
 			} else if data != nil {
-				Respond(w, data, warnings)
+				if !acceptsProtobuf(r) || !respondProtobuf(w, data, warnings) {
+					Respond(w, data, warnings)
+				}
 			} else {
 				w.WriteHeader(http.StatusNoContent)
 			}
 		})
-		return ins.NewHandler(name, tracing.HTTPMiddleware(tracer, name, logger, gziphandler.GzipHandler(hf)))
+		if api.mirror != nil && (name == "query" || name == "query_range") {
+			hf = api.mirror.Handler(name, hf)
+		}
+		return ins.NewHandler(name, tracing.HTTPMiddleware(tracer, name, logger, requestid.HTTPMiddleware(tenancy.HTTPMiddleware(api.tenantHeader, compressHandler(hf)))))
 	}
 
 	r.Options("/*path", instr("options", api.options))
@@ -172,6 +244,63 @@ type queryData struct {
 
 	// Additional Thanos Response field.
 	Warnings []error `json:"warnings,omitempty"`
+
+	// stream enables writing of matrix results series by series. See respondStream.
+	stream bool
+}
+
+// parseStoreView returns the queryable creator of the store view requested by the store view header. The
+// default queryable creator is returned if the header is not set.
+func (api *API) parseStoreView(r *http.Request) (query.QueryableCreator, *ApiError) {
+	if api.storeViewHeader == "" {
+		return api.queryableCreate, nil
+	}
+	name := r.Header.Get(api.storeViewHeader)
+	if name == "" {
+		return api.queryableCreate, nil
+	}
+	qc, ok := api.storeViews[name]
+	if !ok {
+		return nil, &ApiError{errorBadData, errors.Errorf("unknown store view %q in %s header", name, api.storeViewHeader)}
+	}
+	return qc, nil
+}
+
+// checkSubqueries rejects queries with subqueries selecting more than the maximum number of points per evaluation,
+// e.g. [30d:1s]. Subqueries without step use the default evaluation interval. Queries which cannot be parsed are left
+// to the query engine to report.
+func (api *API) checkSubqueries(q string) *ApiError {
+	if api.maxSubqueryPoints <= 0 {
+		return nil
+	}
+	expr, err := promql.ParseExpr(q)
+	if err != nil {
+		return nil
+	}
+
+	var apiErr *ApiError
+	promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+		sq, ok := node.(*promql.SubqueryExpr)
+		if !ok || apiErr != nil {
+			return nil
+		}
+		step := sq.Step
+		if step == 0 {
+			step = time.Duration(promql.GetDefaultEvaluationInterval()) * time.Millisecond
+		}
+		if step <= 0 {
+			return nil
+		}
+		if points := int64(sq.Range / step); points > int64(api.maxSubqueryPoints) {
+			minStep := time.Duration(math.Ceil(sq.Range.Seconds()/float64(api.maxSubqueryPoints))) * time.Second
+			apiErr = &ApiError{errorBadData, errors.Errorf(
+				"subquery %s selects %d points per evaluation, more than the maximum of %d; use a step of at least %s or a shorter range",
+				sq.String(), points, api.maxSubqueryPoints, model.Duration(minStep).String(),
+			)}
+		}
+		return nil
+	})
+	return apiErr
 }
 
 func (api *API) parseEnableDedupParam(r *http.Request) (enableDeduplication bool, _ *ApiError) {
@@ -241,6 +370,19 @@ func (api *API) parsePartialResponseParam(r *http.Request) (enablePartialRespons
 	return enablePartialResponse, nil
 }
 
+func parseStreamParam(r *http.Request) (stream bool, _ *ApiError) {
+	const streamParam = "stream"
+
+	if val := r.FormValue(streamParam); val != "" {
+		var err error
+		stream, err = strconv.ParseBool(val)
+		if err != nil {
+			return false, &ApiError{errorBadData, errors.Wrapf(err, "'%s' parameter", streamParam)}
+		}
+	}
+	return stream, nil
+}
+
 func (api *API) options(r *http.Request) (interface{}, []error, *ApiError) {
 	return nil, nil, nil
 }
@@ -289,16 +431,25 @@ func (api *API) query(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	queryableCreate, apiErr := api.parseStoreView(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	if apiErr := api.checkSubqueries(r.FormValue("query")); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	// We are starting promQL tracing span here, because we have no control over promQL code.
 	span, ctx := tracing.StartSpan(ctx, "promql_instant_query")
 	defer span.Finish()
 
-	qry, err := api.queryEngine.NewInstantQuery(api.queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse), r.FormValue("query"), ts)
+	qry, err := api.queryEngine.NewInstantQuery(queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse), r.FormValue("query"), ts)
 	if err != nil {
 		return nil, nil, &ApiError{errorBadData, err}
 	}
 
-	res := qry.Exec(ctx)
+	res := qry.Exec(query.WithQueryHints(ctx, r.FormValue("query"), ts, ts, 0))
 	if res.Err != nil {
 		switch res.Err.(type) {
 		case promql.ErrQueryCanceled:
@@ -381,12 +532,26 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	stream, apiErr := parseStreamParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	queryableCreate, apiErr := api.parseStoreView(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	if apiErr := api.checkSubqueries(r.FormValue("query")); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	// We are starting promQL tracing span here, because we have no control over promQL code.
 	span, ctx := tracing.StartSpan(ctx, "promql_range_query")
 	defer span.Finish()
 
 	qry, err := api.queryEngine.NewRangeQuery(
-		api.queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse),
+		queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse),
 		r.FormValue("query"),
 		start,
 		end,
@@ -396,7 +561,7 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, &ApiError{errorBadData, err}
 	}
 
-	res := qry.Exec(ctx)
+	res := qry.Exec(query.WithQueryHints(ctx, r.FormValue("query"), start, end, step))
 	if res.Err != nil {
 		switch res.Err.(type) {
 		case promql.ErrQueryCanceled:
@@ -410,6 +575,7 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 	return &queryData{
 		ResultType: res.Value.Type(),
 		Result:     res.Value,
+		stream:     stream,
 	}, res.Warnings, nil
 }
 
@@ -421,12 +587,35 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, &ApiError{errorBadData, fmt.Errorf("invalid label name: %q", name)}
 	}
 
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, &ApiError{ErrorInternal, errors.Wrap(err, "parse form")}
+	}
+
+	var matcherSets [][]*labels.Matcher
+	for _, s := range r.Form["match[]"] {
+		matchers, err := promql.ParseMetricSelector(s)
+		if err != nil {
+			return nil, nil, &ApiError{errorBadData, err}
+		}
+		for _, m := range matchers {
+			if m.Name != name {
+				return nil, nil, &ApiError{errorBadData, fmt.Errorf("match[] selectors can only contain matchers for label %q, got matcher for %q", name, m.Name)}
+			}
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
 	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
 	if apiErr != nil {
 		return nil, nil, apiErr
 	}
 
-	q, err := api.queryableCreate(true, nil, 0, enablePartialResponse).Querier(ctx, math.MinInt64, math.MaxInt64)
+	queryableCreate, apiErr := api.parseStoreView(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	q, err := queryableCreate(true, nil, 0, enablePartialResponse).Querier(ctx, math.MinInt64, math.MaxInt64)
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
@@ -434,14 +623,33 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 
 	// TODO(fabxc): add back request context.
 
-	vals, warnings, err := q.LabelValues(name)
+	if len(matcherSets) == 0 {
+		vals, warnings, err := q.LabelValues(name)
+		if err != nil {
+			return nil, nil, &ApiError{errorExec, err}
+		}
+		return vals, warnings, nil
+	}
+
+	mq, ok := q.(labelValuesWithMatchersQuerier)
+	if !ok {
+		return nil, nil, &ApiError{errorExec, errors.New("querier does not support match[] for label values")}
+	}
+
+	// Values matching any of the selectors are returned.
+	vals, warnings, err := mq.LabelValuesWithMatchers(name, matcherSets...)
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
-
 	return vals, warnings, nil
 }
 
+// labelValuesWithMatchersQuerier is a querier able to push label values matchers down to StoreAPIs. It returns values
+// matching any of the given matcher sets.
+type labelValuesWithMatchersQuerier interface {
+	LabelValuesWithMatchers(name string, matcherSets ...[]*labels.Matcher) ([]string, storage.Warnings, error)
+}
+
 var (
 	minTime = time.Unix(math.MinInt64/1000+62135596801, 0)
 	maxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999)
@@ -502,8 +710,13 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	queryableCreate, apiErr := api.parseStoreView(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	// TODO(bwplotka): Support downsampling?
-	q, err := api.queryableCreate(enableDedup, replicaLabels, 0, enablePartialResponse).Querier(r.Context(), timestamp.FromTime(start), timestamp.FromTime(end))
+	q, err := queryableCreate(enableDedup, replicaLabels, 0, enablePartialResponse).Querier(r.Context(), timestamp.FromTime(start), timestamp.FromTime(end))
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
@@ -534,6 +747,13 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 }
 
 func Respond(w http.ResponseWriter, data interface{}, warnings []error) {
+	if qd, ok := data.(*queryData); ok && qd.stream {
+		if m, ok := qd.Result.(promql.Matrix); ok {
+			respondStream(w, m, warnings)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
@@ -541,10 +761,66 @@ func Respond(w http.ResponseWriter, data interface{}, warnings []error) {
 		Status: statusSuccess,
 		Data:   data,
 	}
+	resp.addWarnings(warnings)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (r *response) addWarnings(warnings []error) {
 	for _, warn := range warnings {
-		resp.Warnings = append(resp.Warnings, warn.Error())
+		r.Warnings = append(r.Warnings, warn.Error())
+		r.Annotations = append(r.Annotations, newAnnotation(warn))
+		if lw, ok := errors.Cause(warn).(storepb.LimitWarning); ok {
+			r.LimitWarnings = append(r.LimitWarnings, lw)
+		}
+	}
+}
+
+// respondStream writes the same response as Respond for the matrix result, but encodes and writes it series by
+// series, releasing each written series. The whole encoded response is never held in memory and the first bytes
+// are sent before the whole result is encoded.
+func respondStream(w http.ResponseWriter, m promql.Matrix, warnings []error) {
+	resp := &response{Status: statusSuccess}
+	resp.addWarnings(warnings)
+	envelope, err := json.Marshal(resp)
+	if err != nil {
+		RespondError(w, &ApiError{ErrorInternal, errors.Wrap(err, "marshal response")}, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	// Data is appended as the last field of the encoded response without data.
+	if _, err := w.Write(envelope[:len(envelope)-1]); err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(w, `,"data":{"resultType":%q,"result":[`, promql.ValueTypeMatrix); err != nil {
+		return
+	}
+	enc := json.NewEncoder(w)
+	for i := range m {
+		if i > 0 {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return
+			}
+		}
+		if err := enc.Encode(m[i]); err != nil {
+			return
+		}
+		m[i] = promql.Series{}
+		if (i+1)%streamFlushSeries == 0 {
+			flush(w)
+		}
+	}
+	_, _ = w.Write([]byte("]}}\n"))
+}
+
+// flush sends data written to the response so far to the client, if supported by the response writer. Otherwise
+// buffered data is sent once the buffer of the response writer is full.
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
 	}
-	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func RespondError(w http.ResponseWriter, apiErr *ApiError, data interface{}) {
@@ -606,7 +882,12 @@ func (api *API) labelNames(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
-	q, err := api.queryableCreate(true, nil, 0, enablePartialResponse).Querier(ctx, math.MinInt64, math.MaxInt64)
+	queryableCreate, apiErr := api.parseStoreView(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	q, err := queryableCreate(true, nil, 0, enablePartialResponse).Querier(ctx, math.MinInt64, math.MaxInt64)
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
@@ -36,12 +36,14 @@ import (
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
 	tsdb_labels "github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/compact"
 	"github.com/thanos-io/thanos/pkg/component"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/query"
 	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -98,7 +100,7 @@ func TestEndpoints(t *testing.T) {
 
 	now := time.Now()
 	api := &API{
-		queryableCreate: query.NewQueryableCreator(nil, store.NewTSDBStore(nil, nil, db, component.Query, nil)),
+		queryableCreate: query.NewQueryableCreator(nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), false),
 		queryEngine: promql.NewEngine(promql.EngineOpts{
 			Logger:        nil,
 			Reg:           nil,
@@ -410,6 +412,41 @@ func TestEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			endpoint: api.queryRange,
+			query: url.Values{
+				"query":  []string{"time()"},
+				"start":  []string{"0"},
+				"end":    []string{"2"},
+				"step":   []string{"1"},
+				"stream": []string{"true"},
+			},
+			response: &queryData{
+				ResultType: promql.ValueTypeMatrix,
+				Result: promql.Matrix{
+					promql.Series{
+						Points: []promql.Point{
+							{V: 0, T: timestamp.FromTime(start)},
+							{V: 1, T: timestamp.FromTime(start.Add(1 * time.Second))},
+							{V: 2, T: timestamp.FromTime(start.Add(2 * time.Second))},
+						},
+						Metric: nil,
+					},
+				},
+				stream: true,
+			},
+		},
+		{
+			endpoint: api.queryRange,
+			query: url.Values{
+				"query":  []string{"time()"},
+				"start":  []string{"0"},
+				"end":    []string{"2"},
+				"step":   []string{"1"},
+				"stream": []string{"sdfsf"},
+			},
+			errType: errorBadData,
+		},
 		// Missing query params in range queries.
 		{
 			endpoint: api.queryRange,
@@ -523,6 +560,65 @@ func TestEndpoints(t *testing.T) {
 				"boo",
 			},
 		},
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "__name__",
+			},
+			query: url.Values{
+				"match[]": []string{`{__name__=~"test_metric[0-9]"}`},
+			},
+			response: []string{
+				"test_metric1",
+				"test_metric2",
+			},
+		},
+		// Values matching any of the selectors are returned.
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "__name__",
+			},
+			query: url.Values{
+				"match[]": []string{`{__name__="test_metric1"}`, `{__name__=~".+replica.+", __name__!="foo"}`},
+			},
+			response: []string{
+				"test_metric1",
+				"test_metric_replica1",
+			},
+		},
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "foo",
+			},
+			query: url.Values{
+				"match[]": []string{`{foo=~"non-existing.+"}`},
+			},
+			response: []string{},
+		},
+		// Matchers for other labels are not supported.
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "foo",
+			},
+			query: url.Values{
+				"match[]": []string{`test_metric1`},
+			},
+			errType: errorBadData,
+		},
+		// Bad match[] parameter.
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "foo",
+			},
+			query: url.Values{
+				"match[]": []string{`{foo=~"("}`},
+			},
+			errType: errorBadData,
+		},
 		// Bad name parameter.
 		{
 			endpoint: api.labelValues,
@@ -874,6 +970,99 @@ func TestRespondSuccess(t *testing.T) {
 	}
 }
 
+func TestRespondAnnotations(t *testing.T) {
+	lw := storepb.LimitWarning{Store: "store1", Limit: "samples", Value: 10, Got: 11}
+	warnings := []error{
+		query.PartialResponseWarning("fetch series for store store2: unavailable"),
+		lw,
+		errors.New("other"),
+	}
+
+	rec := httptest.NewRecorder()
+	Respond(rec, "test", warnings)
+
+	var res response
+	testutil.Ok(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	testutil.Equals(t, []string{"fetch series for store store2: unavailable", lw.Error(), "other"}, res.Warnings)
+	testutil.Equals(t, []storepb.LimitWarning{lw}, res.LimitWarnings)
+	testutil.Equals(t, []Annotation{
+		{Level: "warning", Type: AnnotationPartialResponse, Message: "fetch series for store store2: unavailable"},
+		{Level: "warning", Type: AnnotationStoreLimit, Message: lw.Error(), Store: "store1"},
+		{Level: "warning", Type: AnnotationQuery, Message: "other"},
+	}, res.Annotations)
+}
+
+func TestRespondStream(t *testing.T) {
+	matrix := func() promql.Matrix {
+		return promql.Matrix{
+			{Metric: labels.FromStrings("a", "1"), Points: []promql.Point{{T: 1, V: 1}, {T: 2, V: 2}}},
+			{Metric: labels.FromStrings("a", "2", "b", "\"quoted\""), Points: []promql.Point{{T: 1, V: 3}}},
+			{Metric: labels.FromStrings("a", "3")},
+		}
+	}
+	warnings := []error{errors.New("warning")}
+
+	for _, tcase := range []struct {
+		name   string
+		result promql.Value
+	}{
+		{name: "matrix", result: matrix()},
+		{name: "empty matrix", result: promql.Matrix{}},
+		{name: "vector is not streamed", result: promql.Vector{{Metric: labels.FromStrings("a", "1"), Point: promql.Point{T: 1, V: 1}}}},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			get := func(data *queryData) []byte {
+				s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Respond(w, data, warnings)
+				}))
+				defer s.Close()
+
+				resp, err := http.Get(s.URL)
+				testutil.Ok(t, err)
+				defer func() { testutil.Ok(t, resp.Body.Close()) }()
+				testutil.Equals(t, http.StatusOK, resp.StatusCode)
+				testutil.Equals(t, "application/json", resp.Header.Get("Content-Type"))
+
+				body, err := ioutil.ReadAll(resp.Body)
+				testutil.Ok(t, err)
+				return body
+			}
+
+			exp := get(&queryData{ResultType: tcase.result.Type(), Result: tcase.result})
+			if m, ok := tcase.result.(promql.Matrix); ok && len(m) > 0 {
+				tcase.result = matrix()
+			}
+			got := get(&queryData{ResultType: tcase.result.Type(), Result: tcase.result, stream: true})
+
+			var expRes, gotRes interface{}
+			testutil.Ok(t, json.Unmarshal(exp, &expRes))
+			testutil.Ok(t, json.Unmarshal(got, &gotRes))
+			testutil.Equals(t, expRes, gotRes)
+		})
+	}
+}
+
+func TestRespondStream_Flush(t *testing.T) {
+	matrix := func(n int) promql.Matrix {
+		m := make(promql.Matrix, 0, n)
+		for i := 0; i < n; i++ {
+			m = append(m, promql.Series{Metric: labels.FromStrings("a", fmt.Sprintf("%d", i))})
+		}
+		return m
+	}
+
+	rec := httptest.NewRecorder()
+	respondStream(rec, matrix(streamFlushSeries-1), nil)
+	testutil.Assert(t, !rec.Flushed, "response flushed before writing enough series")
+
+	rec = httptest.NewRecorder()
+	respondStream(rec, matrix(streamFlushSeries), nil)
+	testutil.Assert(t, rec.Flushed, "response not flushed")
+
+	var res interface{}
+	testutil.Ok(t, json.Unmarshal(rec.Body.Bytes(), &res))
+}
+
 func TestRespondError(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, &ApiError{errorTimeout, errors.New("message")}, "test")
@@ -1150,3 +1339,66 @@ func TestParseDownsamplingParamMillis(t *testing.T) {
 
 	}
 }
+
+func TestParseStoreView(t *testing.T) {
+	var created []string
+	creator := func(name string) query.QueryableCreator {
+		return func(bool, []string, int64, bool) storage.Queryable {
+			created = append(created, name)
+			return nil
+		}
+	}
+	api := API{
+		queryableCreate: creator("default"),
+		storeViewHeader: "X-Thanos-Store-View",
+		storeViews:      map[string]query.QueryableCreator{"tenant-a": creator("tenant-a")},
+	}
+
+	for _, view := range []string{"", "tenant-a"} {
+		r := &http.Request{Header: http.Header{}}
+		r.Header.Set("X-Thanos-Store-View", view)
+		qc, apiErr := api.parseStoreView(r)
+		testutil.Assert(t, apiErr == nil, "unexpected error %v", apiErr)
+		qc(true, nil, 0, false)
+	}
+	testutil.Equals(t, []string{"default", "tenant-a"}, created)
+
+	r := &http.Request{Header: http.Header{}}
+	r.Header.Set("X-Thanos-Store-View", "tenant-b")
+	_, apiErr := api.parseStoreView(r)
+	testutil.Assert(t, apiErr != nil, "expected error for unknown store view")
+	testutil.Equals(t, errorBadData, apiErr.Typ)
+}
+
+func TestCheckSubqueries(t *testing.T) {
+	promql.SetDefaultEvaluationInterval(time.Minute)
+
+	api := API{maxSubqueryPoints: 11000}
+	for _, tcase := range []struct {
+		query string
+		err   string
+	}{
+		{query: `up`},
+		{query: `max_over_time(rate(up[5m])[1d:1m])`},
+		// Subqueries without step use the default evaluation interval.
+		{query: `max_over_time(rate(up[5m])[7d:])`},
+		{query: `max_over_time(rate(up[5m])[30d:])`, err: "subquery rate(up[5m])[30d:] selects 43200 points per evaluation, more than the maximum of 11000; use a step of at least 236s or a shorter range"},
+		{query: `sum(max_over_time(up[30d:1s]))`, err: "subquery up[30d:1s] selects 2592000 points per evaluation, more than the maximum of 11000; use a step of at least 236s or a shorter range"},
+		// Invalid queries are reported by the query engine.
+		{query: `max_over_time(up[30d:1s]`},
+	} {
+		t.Run(tcase.query, func(t *testing.T) {
+			apiErr := api.checkSubqueries(tcase.query)
+			if tcase.err == "" {
+				testutil.Assert(t, apiErr == nil, "unexpected error %v", apiErr)
+				return
+			}
+			testutil.Assert(t, apiErr != nil, "expected error")
+			testutil.Equals(t, errorBadData, apiErr.Typ)
+			testutil.Equals(t, tcase.err, apiErr.Err.Error())
+		})
+	}
+
+	// Without maximum all subqueries are accepted.
+	testutil.Assert(t, (&API{}).checkSubqueries(`max_over_time(up[30d:1s])`) == nil, "unexpected error")
+}
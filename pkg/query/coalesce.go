@@ -0,0 +1,200 @@
+package query
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/tenancy"
+)
+
+// SelectCoalescer coalesces identical series requests executed at the same time, e.g. by repeated panels of a
+// dashboard, so their fan-out to stores is executed only once and the result is shared by all of them. Responses are
+// only recorded for sharing once an identical request joins, and requests arriving after the first response was
+// sent unrecorded are executed on their own. Results larger than the maximum size are not shared, requests waiting
+// for them are executed on their own.
+type SelectCoalescer struct {
+	maxSize int
+
+	requests  prometheus.Counter
+	coalesced prometheus.Counter
+}
+
+// NewSelectCoalescer returns a SelectCoalescer recording results of at most maxSize bytes for sharing. Zero maxSize
+// records results of any size.
+func NewSelectCoalescer(reg prometheus.Registerer, maxSize int) *SelectCoalescer {
+	c := &SelectCoalescer{
+		maxSize: maxSize,
+		requests: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_coalescer_series_requests_total",
+			Help: "Total number of series requests passed through the coalescer.",
+		}),
+		coalesced: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_coalescer_coalesced_series_requests_total",
+			Help: "Total number of series requests which waited for the result of an identical in-flight series request.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(c.requests, c.coalesced)
+	}
+	return c
+}
+
+// Wrap returns a store coalescing identical in-flight series requests to the given store.
+func (c *SelectCoalescer) Wrap(s storepb.StoreServer) storepb.StoreServer {
+	return &coalescingStore{StoreServer: s, c: c, inflight: map[string]*coalescedCall{}}
+}
+
+type coalescedCall struct {
+	done chan struct{}
+	// unshared is closed once the result stops being shared, so waiting requests execute on their own.
+	unshared chan struct{}
+
+	// mtx guards the fields recording the result while the call is in flight.
+	mtx     sync.Mutex
+	waiters int
+	shared  bool
+	size    int
+	resps   []*storepb.SeriesResponse
+
+	// err and canceled are set before done is closed.
+	err      error
+	canceled bool
+}
+
+// join registers a request waiting for the result of the call. It returns false if the result is not shared.
+func (c *coalescedCall) join() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.shared {
+		return false
+	}
+	c.waiters++
+	return true
+}
+
+func (c *coalescedCall) isShared() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.shared
+}
+
+// record records the response for waiting requests, until the result exceeds the maximum size. Responses sent before
+// any request is waiting are not recorded, which stops sharing of the result.
+func (c *coalescedCall) record(r *storepb.SeriesResponse, maxSize int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.shared {
+		return
+	}
+	if c.waiters > 0 {
+		c.size += r.Size()
+		if maxSize <= 0 || c.size <= maxSize {
+			c.resps = append(c.resps, copySeriesResponse(r))
+			return
+		}
+	}
+	c.shared = false
+	c.resps = nil
+	close(c.unshared)
+}
+
+type coalescingStore struct {
+	storepb.StoreServer
+	c *SelectCoalescer
+
+	mtx      sync.Mutex
+	inflight map[string]*coalescedCall
+}
+
+// Series implements the storepb.StoreServer interface.
+func (s *coalescingStore) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.c.requests.Inc()
+	key := req.String()
+	// Requests of different tenants may be answered differently by stores.
+	if tenant, ok := tenancy.FromContext(srv.Context()); ok {
+		key = tenant + "/" + key
+	}
+
+	s.mtx.Lock()
+	if call, ok := s.inflight[key]; ok {
+		s.mtx.Unlock()
+		if !call.join() {
+			return s.StoreServer.Series(req, srv)
+		}
+		s.c.coalesced.Inc()
+
+		select {
+		case <-call.done:
+		case <-call.unshared:
+		case <-srv.Context().Done():
+			return srv.Context().Err()
+		}
+		// The result exceeded the maximum size or the shared request failed only because its caller went away,
+		// execute the request on our own.
+		if !call.isShared() || call.canceled {
+			return s.StoreServer.Series(req, srv)
+		}
+		if call.err != nil {
+			return call.err
+		}
+		for _, r := range call.resps {
+			if err := srv.Send(copySeriesResponse(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	call := &coalescedCall{done: make(chan struct{}), unshared: make(chan struct{}), shared: true}
+	s.inflight[key] = call
+	s.mtx.Unlock()
+
+	rec := &recordingSeriesServer{Store_SeriesServer: srv, call: call, maxSize: s.c.maxSize}
+	err := s.StoreServer.Series(req, rec)
+
+	s.mtx.Lock()
+	delete(s.inflight, key)
+	s.mtx.Unlock()
+
+	call.err = err
+	call.canceled = err != nil && srv.Context().Err() != nil
+	close(call.done)
+
+	// Failures to send to our own server are ours only, the shared request is not affected by them.
+	if rec.sendErr != nil {
+		return rec.sendErr
+	}
+	return err
+}
+
+// recordingSeriesServer records copies of responses sent to the wrapped server for requests waiting for the call.
+// Responses are still recorded after sending to the wrapped server failed, so the request is completed for requests
+// sharing its result.
+type recordingSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	call    *coalescedCall
+	maxSize int
+	sendErr error
+}
+
+func (s *recordingSeriesServer) Send(r *storepb.SeriesResponse) error {
+	s.call.record(r, s.maxSize)
+	if s.sendErr == nil {
+		s.sendErr = s.Store_SeriesServer.Send(r)
+	}
+	return nil
+}
+
+// copySeriesResponse returns a copy of the response which can be modified by the receiver, e.g. by sorting its labels
+// and chunks, without affecting other receivers. Chunk data is shared.
+func copySeriesResponse(r *storepb.SeriesResponse) *storepb.SeriesResponse {
+	series := r.GetSeries()
+	if series == nil {
+		return r
+	}
+	return storepb.NewSeriesResponse(&storepb.Series{
+		Labels: append([]storepb.Label(nil), series.Labels...),
+		Chunks: append([]storepb.AggrChunk(nil), series.Chunks...),
+	})
+}
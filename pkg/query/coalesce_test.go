@@ -0,0 +1,245 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// blockingStoreServer serves series requests once released.
+type blockingStoreServer struct {
+	storeServer
+
+	mtx     sync.Mutex
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStoreServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.started <- struct{}{}
+	<-s.release
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.storeServer.Series(r, srv)
+}
+
+// pausingStoreServer sends the first response of series requests and the other ones once released.
+type pausingStoreServer struct {
+	storeServer
+
+	mtx     sync.Mutex
+	sent    chan struct{}
+	release chan struct{}
+}
+
+func (s *pausingStoreServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.mtx.Lock()
+	s.reqs = append(s.reqs, r)
+	s.mtx.Unlock()
+
+	for i, resp := range s.resps {
+		if i == 1 {
+			s.sent <- struct{}{}
+			<-s.release
+		}
+		if err := srv.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failingSeriesServer fails to send any response.
+type failingSeriesServer struct {
+	seriesServer
+}
+
+func (s *failingSeriesServer) Send(*storepb.SeriesResponse) error {
+	return errors.New("send failed")
+}
+
+func TestSelectCoalescer(t *testing.T) {
+	s := &blockingStoreServer{
+		storeServer: storeServer{resps: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{0, 0}, {1, 1}}),
+			storepb.NewWarnSeriesResponse(errors.New("partial response")),
+		}},
+		started: make(chan struct{}, 3),
+		release: make(chan struct{}),
+	}
+	c := NewSelectCoalescer(nil, 0)
+	store := c.Wrap(s)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]*seriesServer, 3)
+		errs    = make([]error, 3)
+	)
+	series := func(i int, req *storepb.SeriesRequest) {
+		defer wg.Done()
+		results[i] = &seriesServer{ctx: context.Background()}
+		errs[i] = store.Series(req, results[i])
+	}
+
+	wg.Add(1)
+	go series(0, &storepb.SeriesRequest{MinTime: 0, MaxTime: 10})
+	<-s.started
+
+	// An identical request shares the result of the in-flight one, a different one is executed.
+	wg.Add(2)
+	go series(1, &storepb.SeriesRequest{MinTime: 0, MaxTime: 10})
+	go series(2, &storepb.SeriesRequest{MinTime: 0, MaxTime: 20})
+	<-s.started
+	for promtest.ToFloat64(c.coalesced) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(s.release)
+	wg.Wait()
+
+	testutil.Equals(t, 2, len(s.reqs))
+	testutil.Equals(t, 3.0, promtest.ToFloat64(c.requests))
+	for i := range results {
+		testutil.Ok(t, errs[i])
+		testutil.Equals(t, 1, len(results[i].seriesSet))
+		testutil.Equals(t, []string{"partial response"}, results[i].warnings)
+	}
+
+	// Results are not shared by receivers.
+	results[0].seriesSet[0].Labels[0].Value = "c"
+	testutil.Equals(t, "b", results[1].seriesSet[0].Labels[0].Value)
+
+	// Already finished requests are not shared.
+	testutil.Ok(t, store.Series(&storepb.SeriesRequest{MinTime: 0, MaxTime: 10}, &seriesServer{ctx: context.Background()}))
+	testutil.Equals(t, 3, len(s.reqs))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(c.coalesced))
+}
+
+func TestSelectCoalescer_SendErrorsOfEachRequest(t *testing.T) {
+	s := &blockingStoreServer{
+		storeServer: storeServer{resps: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{0, 0}, {1, 1}}),
+		}},
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	c := NewSelectCoalescer(nil, 0)
+	store := c.Wrap(s)
+	req := &storepb.SeriesRequest{MinTime: 0, MaxTime: 10}
+
+	var (
+		wg        sync.WaitGroup
+		leaderErr error
+		follower  = &seriesServer{ctx: context.Background()}
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		leaderErr = store.Series(req, &failingSeriesServer{seriesServer{ctx: context.Background()}})
+	}()
+	<-s.started
+
+	// The request sharing the result is not failed by the failed send of the request executed.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		testutil.Ok(t, store.Series(req, follower))
+	}()
+	for promtest.ToFloat64(c.coalesced) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(s.release)
+	wg.Wait()
+
+	testutil.NotOk(t, leaderErr)
+	testutil.Equals(t, 1, len(s.reqs))
+	testutil.Equals(t, 1, len(follower.seriesSet))
+}
+
+func TestSelectCoalescer_NotSharedAfterFirstResponse(t *testing.T) {
+	s := &pausingStoreServer{
+		storeServer: storeServer{resps: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{0, 0}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{0, 0}}),
+		}},
+		sent:    make(chan struct{}, 2),
+		release: make(chan struct{}),
+	}
+	c := NewSelectCoalescer(nil, 0)
+	store := c.Wrap(s)
+	req := &storepb.SeriesRequest{MinTime: 0, MaxTime: 10}
+
+	var (
+		wg      sync.WaitGroup
+		results = []*seriesServer{{ctx: context.Background()}, {ctx: context.Background()}}
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		testutil.Ok(t, store.Series(req, results[0]))
+	}()
+	<-s.sent
+
+	// Responses are not recorded without waiting requests, so the identical request arriving after the first
+	// response is executed on its own.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		testutil.Ok(t, store.Series(req, results[1]))
+	}()
+	<-s.sent
+	close(s.release)
+	wg.Wait()
+
+	testutil.Equals(t, 2, len(s.reqs))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(c.coalesced))
+	for _, r := range results {
+		testutil.Equals(t, 2, len(r.seriesSet))
+	}
+}
+
+func TestSelectCoalescer_MaxSize(t *testing.T) {
+	s := &blockingStoreServer{
+		storeServer: storeServer{resps: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{0, 0}, {1, 1}}),
+		}},
+		started: make(chan struct{}, 2),
+		release: make(chan struct{}),
+	}
+	c := NewSelectCoalescer(nil, 1)
+	store := c.Wrap(s)
+	req := &storepb.SeriesRequest{MinTime: 0, MaxTime: 10}
+
+	var (
+		wg      sync.WaitGroup
+		results = []*seriesServer{{ctx: context.Background()}, {ctx: context.Background()}}
+	)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			testutil.Ok(t, store.Series(req, results[i]))
+		}(i)
+		if i == 0 {
+			<-s.started
+		}
+	}
+	for promtest.ToFloat64(c.coalesced) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(s.release)
+	wg.Wait()
+
+	// The result exceeds the maximum size, so the waiting request is executed on its own.
+	testutil.Equals(t, 2, len(s.reqs))
+	for _, r := range results {
+		testutil.Equals(t, 1, len(r.seriesSet))
+	}
+}
@@ -0,0 +1,76 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+type evalKey struct{}
+
+// eval describes evaluation of a PromQL query, so query hints can be derived from parameters of its selects.
+type eval struct {
+	start, end, step int64
+	// lookbackMatrix is true if the query has a matrix selector with range equal to the lookback delta, so its
+	// selects cannot be told apart from selects of vector selectors.
+	lookbackMatrix bool
+}
+
+// WithQueryHints returns a context which enables passing query hints to StoreAPIs for selects made while
+// evaluating the given PromQL query, from start to end with the given step. Step is 0 for instant queries.
+// Hints are not passed for queries with subqueries, as their selectors are evaluated with different steps.
+func WithQueryHints(ctx context.Context, qs string, start, end time.Time, step time.Duration) context.Context {
+	expr, err := promql.ParseExpr(qs)
+	if err != nil {
+		// The engine will return the error.
+		return ctx
+	}
+
+	e := eval{
+		start: timestamp.FromTime(start),
+		end:   timestamp.FromTime(end),
+		step:  int64(step / time.Millisecond),
+	}
+	hasSubquery := false
+	promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+		switch n := node.(type) {
+		case *promql.SubqueryExpr:
+			hasSubquery = true
+		case *promql.MatrixSelector:
+			if n.Range == promql.LookbackDelta {
+				e.lookbackMatrix = true
+			}
+		}
+		return nil
+	})
+	if hasSubquery {
+		return ctx
+	}
+	return context.WithValue(ctx, evalKey{}, e)
+}
+
+// queryHints returns hints for the select with the given parameters, or nil if they are not known.
+func queryHints(ctx context.Context, params *storage.SelectParams) *storepb.QueryHints {
+	e, ok := ctx.Value(evalKey{}).(eval)
+	if !ok || params == nil {
+		return nil
+	}
+
+	// Selects start before the first step by the range of the selector and are shifted by its offset.
+	rng := (params.End - params.Start) - (e.end - e.start)
+	if rng < 0 {
+		return nil
+	}
+	return &storepb.QueryHints{
+		StartMillis:      params.Start + rng,
+		EndMillis:        params.End,
+		StepMillis:       e.step,
+		RangeMillis:      rng,
+		LatestSampleOnly: rng == int64(promql.LookbackDelta/time.Millisecond) && !e.lookbackMatrix,
+		Func:             params.Func,
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
+	"golang.org/x/sync/errgroup"
 )
 
 // QueryableCreator returns implementation of promql.Queryable that fetches data from the proxy store API endpoints.
@@ -22,7 +27,10 @@ import (
 type QueryableCreator func(deduplicate bool, replicaLabels []string, maxResolutionMillis int64, partialResponse bool) storage.Queryable
 
 // NewQueryableCreator creates QueryableCreator.
-func NewQueryableCreator(logger log.Logger, proxy storepb.StoreServer) QueryableCreator {
+// If downsampleRaw is enabled, raw chunks returned by the stores are downsampled on the fly to the highest
+// resolution allowed by maxResolutionMillis, so the freshest data not yet covered by downsampled blocks
+// has the same resolution as the rest of the result.
+func NewQueryableCreator(logger log.Logger, proxy storepb.StoreServer, downsampleRaw bool) QueryableCreator {
 	return func(deduplicate bool, replicaLabels []string, maxResolutionMillis int64, partialResponse bool) storage.Queryable {
 		return &queryable{
 			logger:              logger,
@@ -31,10 +39,17 @@ func NewQueryableCreator(logger log.Logger, proxy storepb.StoreServer) Queryable
 			deduplicate:         deduplicate,
 			maxResolutionMillis: maxResolutionMillis,
 			partialResponse:     partialResponse,
+			downsampleRaw:       downsampleRaw,
 		}
 	}
 }
 
+// PartialResponseWarning is a warning of a StoreAPI, which failed or did not match the query, so its data is missing
+// from the partial response.
+type PartialResponseWarning string
+
+func (w PartialResponseWarning) Error() string { return string(w) }
+
 type queryable struct {
 	logger              log.Logger
 	replicaLabels       []string
@@ -42,11 +57,12 @@ type queryable struct {
 	deduplicate         bool
 	maxResolutionMillis int64
 	partialResponse     bool
+	downsampleRaw       bool
 }
 
 // Querier returns a new storage querier against the underlying proxy store API.
 func (q *queryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return newQuerier(ctx, q.logger, mint, maxt, q.replicaLabels, q.proxy, q.deduplicate, int64(q.maxResolutionMillis), q.partialResponse), nil
+	return newQuerier(ctx, q.logger, mint, maxt, q.replicaLabels, q.proxy, q.deduplicate, int64(q.maxResolutionMillis), q.partialResponse, q.downsampleRaw), nil
 }
 
 type querier struct {
@@ -59,6 +75,7 @@ type querier struct {
 	deduplicate         bool
 	maxResolutionMillis int64
 	partialResponse     bool
+	downsampleRaw       bool
 }
 
 // newQuerier creates implementation of storage.Querier that fetches data from the proxy
@@ -72,6 +89,7 @@ func newQuerier(
 	deduplicate bool,
 	maxResolutionMillis int64,
 	partialResponse bool,
+	downsampleRaw bool,
 ) *querier {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -93,6 +111,7 @@ func newQuerier(
 		deduplicate:         deduplicate,
 		maxResolutionMillis: maxResolutionMillis,
 		partialResponse:     partialResponse,
+		downsampleRaw:       downsampleRaw,
 	}
 }
 
@@ -171,6 +190,12 @@ func (q *querier) Select(params *storage.SelectParams, ms ...*labels.Matcher) (s
 
 	queryAggrs, resAggr := aggrsFromFunc(params.Func)
 
+	// Chunks downsampled on the fly have to be complete, so stores must not skip any of them.
+	var hints *storepb.QueryHints
+	if !q.downsampleRaw || downsampleResolution(q.maxResolutionMillis) == 0 {
+		hints = queryHints(q.ctx, params)
+	}
+
 	resp := &seriesServer{ctx: ctx}
 	if err := q.proxy.Series(&storepb.SeriesRequest{
 		MinTime:                 q.mint,
@@ -179,13 +204,27 @@ func (q *querier) Select(params *storage.SelectParams, ms ...*labels.Matcher) (s
 		MaxResolutionWindow:     q.maxResolutionMillis,
 		Aggregates:              queryAggrs,
 		PartialResponseDisabled: !q.partialResponse,
+		Hints:                   hints,
 	}, resp); err != nil {
 		return nil, nil, errors.Wrap(err, "proxy Series()")
 	}
 
 	var warns storage.Warnings
 	for _, w := range resp.warnings {
-		warns = append(warns, errors.New(w))
+		if lw, ok := storepb.ParseLimitWarning(w); ok {
+			warns = append(warns, lw)
+			continue
+		}
+		warns = append(warns, PartialResponseWarning(w))
+	}
+
+	if q.downsampleRaw {
+		if res := downsampleResolution(q.maxResolutionMillis); res > 0 {
+			resp.seriesSet, err = downsampleRawChunks(resp.seriesSet, res)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "downsample raw chunks")
+			}
+		}
 	}
 
 	if !q.isDedupEnabled() {
@@ -215,6 +254,90 @@ func (q *querier) Select(params *storage.SelectParams, ms ...*labels.Matcher) (s
 	return newDedupSeriesSet(set, q.replicaLabels), warns, nil
 }
 
+// downsampleResolution returns the highest standard downsampling resolution that is not higher than
+// the given maximum resolution. It returns 0 if only raw data is allowed.
+func downsampleResolution(maxResolutionMillis int64) int64 {
+	for _, res := range []int64{downsample.ResLevel2, downsample.ResLevel1} {
+		if res <= maxResolutionMillis {
+			return res
+		}
+	}
+	return 0
+}
+
+// downsampleRawChunks replaces the raw chunks of each series with aggregated chunks of the given resolution.
+// Already aggregated chunks are kept as they are. Subsequent frames of the same series are merged first,
+// so aggregation windows are not cut at frame boundaries.
+func downsampleRawChunks(set []storepb.Series, resolution int64) ([]storepb.Series, error) {
+	merged := set[:0]
+	for _, s := range set {
+		if len(merged) > 0 && storepb.CompareLabels(merged[len(merged)-1].Labels, s.Labels) == 0 {
+			merged[len(merged)-1].Chunks = append(merged[len(merged)-1].Chunks, s.Chunks...)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	set = merged
+
+	for i, s := range set {
+		sort.Slice(s.Chunks, func(i, j int) bool {
+			return s.Chunks[i].MinTime < s.Chunks[j].MinTime
+		})
+
+		var (
+			raw    []chunkenc.Chunk
+			chunks = make([]storepb.AggrChunk, 0, len(s.Chunks))
+		)
+		for _, c := range s.Chunks {
+			if c.Raw == nil {
+				chunks = append(chunks, c)
+				continue
+			}
+			chk, err := chunkenc.FromData(chunkEncoding(c.Raw.Type), c.Raw.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode raw chunk of series %v", s.Labels)
+			}
+			raw = append(raw, chk)
+		}
+		if len(raw) == 0 {
+			continue
+		}
+
+		// Counters keep their min and max aggregates, as the name of a series does not tell its type reliably.
+		downsampled, err := downsample.DownsampleRawChunks(raw, resolution, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "downsample series %v", s.Labels)
+		}
+		for _, c := range downsampled {
+			ac, ok := c.Chunk.(*downsample.AggrChunk)
+			if !ok {
+				return nil, errors.Errorf("unexpected downsampled chunk type %T", c.Chunk)
+			}
+			out := storepb.AggrChunk{MinTime: c.MinTime, MaxTime: c.MaxTime}
+			for at, dst := range map[downsample.AggrType]**storepb.Chunk{
+				downsample.AggrCount:   &out.Count,
+				downsample.AggrSum:     &out.Sum,
+				downsample.AggrMin:     &out.Min,
+				downsample.AggrMax:     &out.Max,
+				downsample.AggrCounter: &out.Counter,
+			} {
+				x, err := ac.Get(at)
+				if err == downsample.ErrAggrNotExist && (at == downsample.AggrMin || at == downsample.AggrMax) {
+					// Counter series have no min and max aggregates, stores serve the counter aggregate instead.
+					x, err = ac.Get(downsample.AggrCounter)
+				}
+				if err != nil {
+					return nil, errors.Wrapf(err, "get %s aggregate", at)
+				}
+				*dst = &storepb.Chunk{Type: storepb.Chunk_XOR, Data: x.Bytes()}
+			}
+			chunks = append(chunks, out)
+		}
+		set[i].Chunks = chunks
+	}
+	return set, nil
+}
+
 // sortDedupLabels re-sorts the set so that the same series with different replica
 // labels are coming right after each other.
 func sortDedupLabels(set []storepb.Series, replicaLabels map[string]struct{}) {
@@ -239,20 +362,51 @@ func sortDedupLabels(set []storepb.Series, replicaLabels map[string]struct{}) {
 
 // LabelValues returns all potential values for a label name.
 func (q *querier) LabelValues(name string) ([]string, storage.Warnings, error) {
+	return q.LabelValuesWithMatchers(name)
+}
+
+// LabelValuesWithMatchers returns all potential values for a label name matching any of the given matcher sets, i.e.
+// values matching all matchers of at least one set. Each set is pushed down to StoreAPIs in a separate request, see
+// storepb.LabelValuesRequest for their semantics, and the results are merged. All values are returned if no sets are given.
+func (q *querier) LabelValuesWithMatchers(name string, matcherSets ...[]*labels.Matcher) ([]string, storage.Warnings, error) {
 	span, ctx := tracing.StartSpan(q.ctx, "querier_label_values")
 	defer span.Finish()
 
-	resp, err := q.proxy.LabelValues(ctx, &storepb.LabelValuesRequest{Label: name, PartialResponseDisabled: !q.partialResponse})
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "proxy LabelValues()")
+	if len(matcherSets) == 0 {
+		matcherSets = [][]*labels.Matcher{nil}
 	}
 
-	var warns storage.Warnings
-	for _, w := range resp.Warnings {
-		warns = append(warns, errors.New(w))
-	}
+	var (
+		g, gctx = errgroup.WithContext(ctx)
+		mtx     sync.Mutex
+		sets    [][]string
+		warns   storage.Warnings
+	)
+	for _, matchers := range matcherSets {
+		sms, err := translateMatchers(matchers...)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "convert matchers")
+		}
 
-	return resp.Values, warns, nil
+		g.Go(func() error {
+			resp, err := q.proxy.LabelValues(gctx, &storepb.LabelValuesRequest{Label: name, PartialResponseDisabled: !q.partialResponse, Matchers: sms})
+			if err != nil {
+				return errors.Wrap(err, "proxy LabelValues()")
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			sets = append(sets, resp.Values)
+			for _, w := range resp.Warnings {
+				warns = append(warns, PartialResponseWarning(w))
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return strutil.MergeUnsortedSlices(sets...), warns, nil
 }
 
 // LabelNames returns all the unique label names present in the block in sorted order.
@@ -267,7 +421,7 @@ func (q *querier) LabelNames() ([]string, storage.Warnings, error) {
 
 	var warns storage.Warnings
 	for _, w := range resp.Warnings {
-		warns = append(warns, errors.New(w))
+		warns = append(warns, PartialResponseWarning(w))
 	}
 
 	return resp.Names, warns, nil
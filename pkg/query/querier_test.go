@@ -7,6 +7,7 @@ import (
 	"math"
 	"math/rand"
 	"reflect"
+	"regexp"
 	"strconv"
 	"testing"
 
@@ -25,7 +26,7 @@ import (
 func TestQueryableCreator_MaxResolution(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 	testProxy := &storeServer{resps: []*storepb.SeriesResponse{}}
-	queryableCreator := NewQueryableCreator(nil, testProxy)
+	queryableCreator := NewQueryableCreator(nil, testProxy, false)
 
 	oneHourMillis := int64(1*time.Hour) / int64(time.Millisecond)
 	queryable := queryableCreator(false, nil, oneHourMillis, false)
@@ -55,7 +56,7 @@ func TestQuerier_DownsampledData(t *testing.T) {
 		},
 	}
 
-	q := NewQueryableCreator(nil, testProxy)(false, nil, 9999999, false)
+	q := NewQueryableCreator(nil, testProxy, false)(false, nil, 9999999, false)
 
 	engine := promql.NewEngine(
 		promql.EngineOpts{
@@ -176,7 +177,7 @@ func TestQuerier_Series(t *testing.T) {
 
 	// Querier clamps the range to [1,300], which should drop some samples of the result above.
 	// The store API allows endpoints to send more data then initially requested.
-	q := newQuerier(context.Background(), nil, 1, 300, []string{""}, testProxy, false, 0, true)
+	q := newQuerier(context.Background(), nil, 1, 300, []string{""}, testProxy, false, 0, true, false)
 	defer func() { testutil.Ok(t, q.Close()) }()
 
 	res, _, err := q.Select(&storage.SelectParams{})
@@ -217,6 +218,187 @@ func TestQuerier_Series(t *testing.T) {
 	testutil.Equals(t, len(expected), i)
 }
 
+// labelValuesStoreServer returns its values filtered by the matchers of the requested label.
+type labelValuesStoreServer struct {
+	storepb.StoreServer
+
+	values []string
+}
+
+func (s *labelValuesStoreServer) LabelValues(_ context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	res := &storepb.LabelValuesResponse{Values: []string{}, Warnings: []string{"warning"}}
+Outer:
+	for _, v := range s.values {
+		for _, m := range r.Matchers {
+			if !regexp.MustCompile("^(?:" + m.Value + ")$").MatchString(v) {
+				continue Outer
+			}
+		}
+		res.Values = append(res.Values, v)
+	}
+	return res, nil
+}
+
+func TestQuerier_LabelValuesWithMatchers(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	q := newQuerier(context.Background(), nil, 0, 1, []string{""}, &labelValuesStoreServer{values: []string{"a1", "a2", "b1", "b2"}}, false, 0, true, false)
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	vals, warns, err := q.LabelValues("l")
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"a1", "a2", "b1", "b2"}, vals)
+	testutil.Equals(t, 1, len(warns))
+
+	matcher := func(re string) *labels.Matcher {
+		m, err := labels.NewMatcher(labels.MatchRegexp, "l", re)
+		testutil.Ok(t, err)
+		return m
+	}
+
+	// Values matching any of the sets are returned, without duplicates.
+	vals, warns, err = q.LabelValuesWithMatchers("l",
+		[]*labels.Matcher{matcher("b.*"), matcher(".*2")},
+		[]*labels.Matcher{matcher("a1|b2")},
+	)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"a1", "b2"}, vals)
+	testutil.Equals(t, 2, len(warns))
+}
+
+func TestQuerier_DownsampleRaw(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	var raw []sample
+	for i := 0; i < 10; i++ {
+		raw = append(raw, sample{t: int64(i) * 60 * 1000, v: float64(i)})
+	}
+	testProxy := &storeServer{
+		resps: []*storepb.SeriesResponse{
+			// Raw chunks from Sidecar, split across two frames.
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), raw[:4]),
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), raw[4:]),
+		},
+	}
+
+	for _, tcase := range []struct {
+		fn            string
+		maxResolution int64
+		downsampleRaw bool
+		expected      []sample
+	}{
+		{
+			fn:            "max_over_time",
+			maxResolution: 5 * 60 * 1000,
+			downsampleRaw: false,
+			expected:      raw,
+		},
+		{
+			fn:            "max_over_time",
+			maxResolution: 0,
+			downsampleRaw: true,
+			expected:      raw,
+		},
+		{
+			fn:            "max_over_time",
+			maxResolution: 5 * 60 * 1000,
+			downsampleRaw: true,
+			expected:      []sample{{299999, 4}, {540000, 9}},
+		},
+		{
+			fn:            "count_over_time",
+			maxResolution: 5 * 60 * 1000,
+			downsampleRaw: true,
+			expected:      []sample{{299999, 5}, {540000, 5}},
+		},
+		{
+			fn:            "",
+			maxResolution: 5 * 60 * 1000,
+			downsampleRaw: true,
+			expected:      []sample{{299999, 2}, {540000, 7}},
+		},
+	} {
+		if ok := t.Run(fmt.Sprintf("%s-%d-%v", tcase.fn, tcase.maxResolution, tcase.downsampleRaw), func(t *testing.T) {
+			q := newQuerier(context.Background(), nil, 0, 600000, nil, testProxy, false, tcase.maxResolution, true, tcase.downsampleRaw)
+			defer func() { testutil.Ok(t, q.Close()) }()
+
+			res, _, err := q.Select(&storage.SelectParams{Func: tcase.fn})
+			testutil.Ok(t, err)
+
+			testutil.Assert(t, res.Next(), "expected one series")
+			testutil.Equals(t, labels.FromStrings("a", "a"), res.At().Labels())
+			testutil.Equals(t, tcase.expected, expandSeries(t, res.At().Iterator()))
+			testutil.Assert(t, !res.Next(), "expected one series")
+			testutil.Ok(t, res.Err())
+		}); !ok {
+			return
+		}
+	}
+}
+
+func TestQuerier_QueryHints(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	engine := promql.NewEngine(
+		promql.EngineOpts{
+			MaxConcurrent: 10,
+			MaxSamples:    math.MaxInt32,
+			Timeout:       10 * time.Second,
+		},
+	)
+	start, end := time.Unix(3600, 0), time.Unix(7200, 0)
+
+	for _, tcase := range []struct {
+		query    string
+		instant  bool
+		expected *storepb.QueryHints
+	}{
+		{
+			query:    "a",
+			expected: &storepb.QueryHints{StartMillis: 3600000, EndMillis: 7200000, StepMillis: 60000, RangeMillis: 300000, LatestSampleOnly: true},
+		},
+		{
+			query:    "sum(a offset 10m)",
+			expected: &storepb.QueryHints{StartMillis: 3000000, EndMillis: 6600000, StepMillis: 60000, RangeMillis: 300000, LatestSampleOnly: true, Func: "sum"},
+		},
+		{
+			query:    "rate(a[1m])",
+			expected: &storepb.QueryHints{StartMillis: 3600000, EndMillis: 7200000, StepMillis: 60000, RangeMillis: 60000, Func: "rate"},
+		},
+		{
+			query:    "a[5m]",
+			instant:  true,
+			expected: &storepb.QueryHints{StartMillis: 7200000, EndMillis: 7200000, RangeMillis: 300000},
+		},
+		{
+			query: "max_over_time(a[10m:1m])",
+		},
+	} {
+		t.Run(tcase.query, func(t *testing.T) {
+			testProxy := &storeServer{}
+			q := NewQueryableCreator(nil, testProxy, false)(false, nil, 0, false)
+
+			var (
+				qry promql.Query
+				err error
+				ctx context.Context
+			)
+			if tcase.instant {
+				qry, err = engine.NewInstantQuery(q, tcase.query, end)
+				ctx = WithQueryHints(context.Background(), tcase.query, end, end, 0)
+			} else {
+				qry, err = engine.NewRangeQuery(q, tcase.query, start, end, time.Minute)
+				ctx = WithQueryHints(context.Background(), tcase.query, start, end, time.Minute)
+			}
+			testutil.Ok(t, err)
+			testutil.Ok(t, qry.Exec(ctx).Err)
+
+			testutil.Equals(t, 1, len(testProxy.reqs))
+			testutil.Equals(t, tcase.expected, testProxy.reqs[0].Hints)
+		})
+	}
+}
+
 func TestSortReplicaLabel(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -705,9 +887,11 @@ type storeServer struct {
 	storepb.StoreServer
 
 	resps []*storepb.SeriesResponse
+	reqs  []*storepb.SeriesRequest
 }
 
 func (s *storeServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.reqs = append(s.reqs, r)
 	for _, resp := range s.resps {
 		err := srv.Send(resp)
 		if err != nil {
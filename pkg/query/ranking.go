@@ -0,0 +1,242 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// latencyDecay is the weight of the latest observation in the moving averages of latency and errors.
+	latencyDecay = 0.3
+	// errorPenalty scales the latency of a store by how often its requests fail, so stores which recovered from
+	// failures are ranked below stores which did not fail.
+	errorPenalty = 10
+	// replicaSampleInterval is the interval of selections of replicas in which a replica other than the best ranked
+	// one is preferred, so the latency of all replicas is kept up to date and recovered replicas are preferred again.
+	replicaSampleInterval = 20
+)
+
+// storeLatency tracks exponentially weighted moving averages of the duration of series requests to a store and of
+// the ratio of failed series requests.
+type storeLatency struct {
+	mtx        sync.Mutex
+	observed   bool
+	lastFailed bool
+	latency    float64
+	errRatio   float64
+}
+
+func (l *storeLatency) observe(d time.Duration, err error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+	l.lastFailed = err != nil
+	if !l.observed {
+		l.observed = true
+		l.latency, l.errRatio = d.Seconds(), failed
+		return
+	}
+	l.latency = latencyDecay*d.Seconds() + (1-latencyDecay)*l.latency
+	l.errRatio = latencyDecay*failed + (1-latencyDecay)*l.errRatio
+}
+
+// score returns the rank of the store, lower is better. Stores without observed requests have the best score, so each
+// replica is tried at least once. Stores which failed their last request have the worst score, however fast they fail.
+func (l *storeLatency) score() float64 {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if !l.observed {
+		return 0
+	}
+	if l.lastFailed {
+		return math.Inf(1)
+	}
+	return l.latency * (1 + errorPenalty*l.errRatio)
+}
+
+// Series implements the storepb.StoreClient interface, tracking how long series requests take until the last
+// response.
+func (s *storeRef) Series(ctx context.Context, r *storepb.SeriesRequest, opts ...grpc.CallOption) (storepb.Store_SeriesClient, error) {
+	start := time.Now()
+	sc, err := s.StoreClient.Series(ctx, r, opts...)
+	if err != nil {
+		s.observe(ctx, start, err)
+		return nil, err
+	}
+	return &latencySeriesClient{Store_SeriesClient: sc, ctx: ctx, start: start, store: s}, nil
+}
+
+// observe records a series request which started at the given time and finished with the given error. Requests
+// canceled by the querier, e.g. because other stores already failed, tell nothing about the store.
+func (s *storeRef) observe(ctx context.Context, start time.Time, err error) {
+	if ctx.Err() != nil || status.Code(err) == codes.Canceled {
+		return
+	}
+	s.latency.observe(time.Since(start), err)
+}
+
+type latencySeriesClient struct {
+	storepb.Store_SeriesClient
+
+	ctx   context.Context
+	start time.Time
+	store *storeRef
+	done  bool
+}
+
+func (c *latencySeriesClient) Recv() (*storepb.SeriesResponse, error) {
+	resp, err := c.Store_SeriesClient.Recv()
+	if err != nil && !c.done {
+		c.done = true
+		if err == io.EOF {
+			c.store.observe(c.ctx, c.start, nil)
+		} else {
+			c.store.observe(c.ctx, c.start, err)
+		}
+	}
+	return resp, err
+}
+
+// PreferFastestReplicas returns function returning stores from the given function, but stores of the same type with
+// identical label sets and time range, e.g. replicas of store gateways of the same bucket, are replaced by a single
+// store sending series requests to the replica with the lowest latency of series requests. Failing series requests
+// rank a store worst and increase its latency once it recovers. Each replicaSampleInterval selection prefers another
+// replica, so latencies of all replicas are tracked.
+// NOTE: Stores of the same type with identical label sets and time range must serve the same data, as only one of them
+// is queried.
+func PreferFastestReplicas(stores func() []store.Client) func() []store.Client {
+	var selections uint64
+	return func() []store.Client {
+		var (
+			res    []store.Client
+			groups = map[string]int{}
+			// replicas holds the replicas of stores of res, by their index.
+			replicas = map[int][]*storeRef{}
+		)
+		for _, st := range stores() {
+			ref, ok := st.(*storeRef)
+			if !ok {
+				res = append(res, st)
+				continue
+			}
+			mint, maxt := ref.TimeRange()
+			key := fmt.Sprintf("%v/%s/%d/%d", ref.StoreType(), ref.LabelSetsString(), mint, maxt)
+
+			i, ok := groups[key]
+			if !ok {
+				groups[key] = len(res)
+				replicas[len(res)] = []*storeRef{ref}
+				res = append(res, st)
+				continue
+			}
+			replicas[i] = append(replicas[i], ref)
+		}
+
+		n := atomic.AddUint64(&selections, 1)
+		for i, refs := range replicas {
+			if len(refs) == 1 {
+				continue
+			}
+			scores := make(map[*storeRef]float64, len(refs))
+			for _, ref := range refs {
+				scores[ref] = ref.latency.score()
+			}
+			sort.Slice(refs, func(i, j int) bool {
+				si, sj := scores[refs[i]], scores[refs[j]]
+				if si != sj {
+					return si < sj
+				}
+				return refs[i].addr < refs[j].addr
+			})
+			if n%replicaSampleInterval == 0 {
+				// Prefer another replica, keeping the rest ordered by rank.
+				j := 1 + int(n/replicaSampleInterval)%(len(refs)-1)
+				refs = append(append([]*storeRef{refs[j]}, refs[:j]...), refs[j+1:]...)
+			}
+			res[i] = &replicaGroup{storeRef: refs[0], replicas: refs}
+		}
+		return res
+	}
+}
+
+// replicaGroup is a store of replicas serving the same data. It sends series requests to the first replica and falls
+// back to the next ones in order if the request fails before the first response. Other requests are sent to the first
+// replica only.
+type replicaGroup struct {
+	*storeRef
+
+	replicas []*storeRef
+}
+
+func (g *replicaGroup) Series(ctx context.Context, r *storepb.SeriesRequest, opts ...grpc.CallOption) (storepb.Store_SeriesClient, error) {
+	c := &fallbackSeriesClient{ctx: ctx, req: r, opts: opts, next: g.replicas}
+	if err := c.fallBack(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// fallbackSeriesClient is a series stream of a replica, replaced by a stream of the next replica if it fails before the
+// first response.
+type fallbackSeriesClient struct {
+	storepb.Store_SeriesClient
+
+	ctx      context.Context
+	req      *storepb.SeriesRequest
+	opts     []grpc.CallOption
+	next     []*storeRef
+	received bool
+}
+
+func (c *fallbackSeriesClient) Recv() (*storepb.SeriesResponse, error) {
+	for {
+		resp, err := c.Store_SeriesClient.Recv()
+		if err == nil {
+			c.received = true
+			return resp, nil
+		}
+		if err == io.EOF || c.received || c.ctx.Err() != nil || len(c.next) == 0 {
+			return nil, err
+		}
+		if c.fallBack() != nil {
+			return nil, err
+		}
+	}
+}
+
+// fallBack starts the series request on the next replica which accepts it. It returns the error of the last replica
+// if none does.
+func (c *fallbackSeriesClient) fallBack() error {
+	var err error
+	for len(c.next) > 0 && c.ctx.Err() == nil {
+		ref := c.next[0]
+		c.next = c.next[1:]
+
+		var sc storepb.Store_SeriesClient
+		if sc, err = ref.Series(c.ctx, c.req, c.opts...); err == nil {
+			c.Store_SeriesClient = sc
+			return nil
+		}
+	}
+	if err == nil {
+		err = c.ctx.Err()
+	}
+	return err
+}
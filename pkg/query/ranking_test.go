@@ -0,0 +1,186 @@
+package query
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+)
+
+// erroringStoreClient answers series requests with a stream ending with the given error.
+type erroringStoreClient struct {
+	storepb.StoreClient
+	err error
+}
+
+func (c *erroringStoreClient) Series(context.Context, *storepb.SeriesRequest, ...grpc.CallOption) (storepb.Store_SeriesClient, error) {
+	return &erroringSeriesClient{err: c.err}, nil
+}
+
+type erroringSeriesClient struct {
+	storepb.Store_SeriesClient
+	err error
+}
+
+func (c *erroringSeriesClient) Recv() (*storepb.SeriesResponse, error) { return nil, c.err }
+
+func testStoreRef(addr string, lset ...string) *storeRef {
+	ref := &storeRef{addr: addr}
+	var labelSet storepb.LabelSet
+	for i := 0; i < len(lset); i += 2 {
+		labelSet.Labels = append(labelSet.Labels, storepb.Label{Name: lset[i], Value: lset[i+1]})
+	}
+	ref.Update([]storepb.LabelSet{labelSet}, 0, 100, component.Store)
+	return ref
+}
+
+func addrs(stores []store.Client) []string {
+	var res []string
+	for _, st := range stores {
+		res = append(res, st.Addr())
+	}
+	return res
+}
+
+func TestPreferFastestReplicas(t *testing.T) {
+	a := testStoreRef("a", "bucket", "1")
+	b := testStoreRef("b", "bucket", "1")
+	c := testStoreRef("c", "bucket", "2")
+
+	stores := PreferFastestReplicas(func() []store.Client { return []store.Client{a, b, c} })
+
+	// Without observed requests replicas are ordered by address.
+	testutil.Equals(t, []string{"a", "c"}, addrs(stores()))
+
+	// Unobserved replica is tried next.
+	a.latency.observe(time.Second, nil)
+	testutil.Equals(t, []string{"b", "c"}, addrs(stores()))
+
+	b.latency.observe(2*time.Second, nil)
+	testutil.Equals(t, []string{"a", "c"}, addrs(stores()))
+
+	// Failing requests rank the store worst, however fast.
+	a.latency.observe(time.Millisecond, errors.New("failed"))
+	testutil.Equals(t, []string{"b", "c"}, addrs(stores()))
+
+	// Recovered stores are still penalized by past failures.
+	a.latency.observe(time.Second, nil)
+	testutil.Equals(t, []string{"b", "c"}, addrs(stores()))
+}
+
+func TestPreferFastestReplicas_SamplesOtherReplicas(t *testing.T) {
+	a := testStoreRef("a", "bucket", "1")
+	b := testStoreRef("b", "bucket", "1")
+	c := testStoreRef("c", "bucket", "1")
+	a.latency.observe(time.Second, nil)
+	b.latency.observe(2*time.Second, nil)
+	c.latency.observe(3*time.Second, nil)
+
+	stores := PreferFastestReplicas(func() []store.Client { return []store.Client{a, b, c} })
+
+	preferred := map[string]int{}
+	for i := 0; i < 2*replicaSampleInterval; i++ {
+		preferred[addrs(stores())[0]]++
+	}
+	testutil.Equals(t, map[string]int{"a": 2*replicaSampleInterval - 2, "b": 1, "c": 1}, preferred)
+}
+
+// seriesStoreClient answers series requests with a stream of a single series.
+type seriesStoreClient struct {
+	storepb.StoreClient
+}
+
+func (c *seriesStoreClient) Series(context.Context, *storepb.SeriesRequest, ...grpc.CallOption) (storepb.Store_SeriesClient, error) {
+	return &seriesClient{}, nil
+}
+
+type seriesClient struct {
+	storepb.Store_SeriesClient
+	sent bool
+}
+
+func (c *seriesClient) Recv() (*storepb.SeriesResponse, error) {
+	if c.sent {
+		return nil, io.EOF
+	}
+	c.sent = true
+	return storepb.NewSeriesResponse(&storepb.Series{Labels: []storepb.Label{{Name: "a", Value: "1"}}}), nil
+}
+
+func TestPreferFastestReplicas_FallsBackToOtherReplicas(t *testing.T) {
+	a := testStoreRef("a", "bucket", "1")
+	b := testStoreRef("b", "bucket", "1")
+	a.StoreClient = &erroringStoreClient{err: errors.New("failed")}
+	b.StoreClient = &seriesStoreClient{}
+
+	stores := PreferFastestReplicas(func() []store.Client { return []store.Client{a, b} })
+	st := stores()
+	testutil.Equals(t, []string{"a"}, addrs(st))
+
+	sc, err := st[0].Series(context.Background(), &storepb.SeriesRequest{})
+	testutil.Ok(t, err)
+	resp, err := sc.Recv()
+	testutil.Ok(t, err)
+	testutil.Equals(t, "a", resp.GetSeries().Labels[0].Name)
+	_, err = sc.Recv()
+	testutil.Equals(t, io.EOF, err)
+
+	// The failed replica is ranked worst.
+	testutil.Equals(t, []string{"b"}, addrs(stores()))
+
+	// Requests fail once all replicas failed.
+	b.StoreClient = &erroringStoreClient{err: errors.New("failed b")}
+	sc, err = stores()[0].Series(context.Background(), &storepb.SeriesRequest{})
+	testutil.Ok(t, err)
+	_, err = sc.Recv()
+	testutil.NotOk(t, err)
+}
+
+func TestPreferFastestReplicas_DifferentTimeRange(t *testing.T) {
+	a := testStoreRef("a", "bucket", "1")
+	b := testStoreRef("b", "bucket", "1")
+	b.Update(b.LabelSets(), 50, 100, component.Store)
+
+	stores := PreferFastestReplicas(func() []store.Client { return []store.Client{a, b} })
+	testutil.Equals(t, []string{"a", "b"}, addrs(stores()))
+}
+
+func TestStoreRef_SeriesObservesLatency(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		err      error
+		cancel   bool
+		observed bool
+		errRatio float64
+	}{
+		{name: "success", err: io.EOF, observed: true},
+		{name: "failure", err: errors.New("failed"), observed: true, errRatio: 1},
+		{name: "canceled", err: context.Canceled, cancel: true},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			ref := testStoreRef("a")
+			ref.StoreClient = &erroringStoreClient{err: tcase.err}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tcase.cancel {
+				cancel()
+			}
+
+			sc, err := ref.Series(ctx, &storepb.SeriesRequest{})
+			testutil.Ok(t, err)
+			_, err = sc.Recv()
+			testutil.Equals(t, tcase.err, err)
+
+			testutil.Equals(t, tcase.observed, ref.latency.observed)
+			testutil.Equals(t, tcase.errRatio, ref.latency.errRatio)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// RuleRouter routes series requests for series of recording rules to the rulers evaluating them, as discovered from
+// the rules API of each ruler. Within the time range of a ruler's StoreAPI, other stores serving only the external
+// label sets of the rulers evaluating the rule, e.g. store gateways of the bucket the rulers upload to, are skipped, as
+// they hold the same series.
+type RuleRouter struct {
+	logger log.Logger
+	client *http.Client
+	// apis are URLs of rules APIs of rulers by address of their StoreAPI.
+	apis map[string]string
+
+	mtx sync.RWMutex
+	// rules are names of recording rules by address of the StoreAPI of the ruler evaluating them.
+	rules map[string]map[string]struct{}
+
+	routed prometheus.Counter
+}
+
+// NewRuleRouter returns a router for rulers with the given URLs of rules APIs by address of their StoreAPI. Rules are
+// loaded by Update.
+func NewRuleRouter(logger log.Logger, reg prometheus.Registerer, apis map[string]string) *RuleRouter {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	r := &RuleRouter{
+		logger: logger,
+		client: http.DefaultClient,
+		apis:   apis,
+		rules:  map[string]map[string]struct{}{},
+		routed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_rule_routed_series_requests_total",
+			Help: "Total number of series requests for series of recording rules which skipped stores holding the same series as the rulers evaluating them.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(r.routed)
+	}
+	return r
+}
+
+// Update loads the recording rules of all rulers. Rules of rulers failing to respond are dropped, so requests are not
+// routed to them until they respond again.
+func (r *RuleRouter) Update(ctx context.Context) {
+	rules := make(map[string]map[string]struct{}, len(r.apis))
+	for addr, u := range r.apis {
+		names, err := r.recordingRules(ctx, u)
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to load rules of ruler", "store", addr, "url", u, "err", err)
+			continue
+		}
+		rules[addr] = names
+	}
+
+	r.mtx.Lock()
+	r.rules = rules
+	r.mtx.Unlock()
+}
+
+// recordingRules returns the names of recording rules served by the rules API with the given URL.
+func (r *RuleRouter) recordingRules(ctx context.Context, base string) (map[string]struct{}, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse URL %s", base)
+	}
+	u.Path = path.Join(u.Path, "/api/v1/rules")
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "request %s", u)
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(r.logger, resp.Body, "rules response body")
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("request %s: unexpected status %s", u, resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Groups []struct {
+				Rules []struct {
+					Name string `json:"name"`
+					Type string `json:"type"`
+				} `json:"rules"`
+			} `json:"groups"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "decode rules")
+	}
+	names := map[string]struct{}{}
+	for _, g := range body.Data.Groups {
+		for _, rule := range g.Rules {
+			if rule.Type == "recording" {
+				names[rule.Name] = struct{}{}
+			}
+		}
+	}
+	return names, nil
+}
+
+// Route implements the store.SeriesRouter interface. Requests are routed if they select a single metric name, which
+// is a recording rule of rulers covering the whole time range of the request. Stores with label sets other than the
+// label sets of these rulers and stores without label sets are still queried, as they may hold other series of the
+// same name.
+func (r *RuleRouter) Route(stores []store.Client, req *storepb.SeriesRequest) []store.Client {
+	var name string
+	for _, m := range req.Matchers {
+		if m.Name == labels.MetricName && m.Type == storepb.LabelMatcher_EQ {
+			name = m.Value
+		}
+	}
+	if name == "" {
+		return stores
+	}
+
+	r.mtx.RLock()
+	var (
+		rulers    = map[store.Client]struct{}{}
+		labelSets = map[string]struct{}{}
+	)
+	for _, st := range stores {
+		if _, ok := r.rules[st.Addr()][name]; !ok {
+			continue
+		}
+		mint, maxt := st.TimeRange()
+		if mint > req.MinTime || maxt < req.MaxTime {
+			continue
+		}
+		rulers[st] = struct{}{}
+		for _, ls := range st.LabelSets() {
+			labelSets[storepb.LabelsToString(ls.Labels)] = struct{}{}
+		}
+	}
+	r.mtx.RUnlock()
+	if len(rulers) == 0 {
+		return stores
+	}
+
+	res := make([]store.Client, 0, len(stores))
+	for _, st := range stores {
+		if _, ok := rulers[st]; ok || !onlyLabelSets(st.LabelSets(), labelSets) {
+			res = append(res, st)
+		}
+	}
+	if len(res) < len(stores) {
+		r.routed.Inc()
+	}
+	return res
+}
+
+// onlyLabelSets returns true if all of the given label sets are among the allowed ones, false if there are none.
+func onlyLabelSets(lsets []storepb.LabelSet, allowed map[string]struct{}) bool {
+	if len(lsets) == 0 {
+		return false
+	}
+	for _, ls := range lsets {
+		if _, ok := allowed[storepb.LabelsToString(ls.Labels)]; !ok {
+			return false
+		}
+	}
+	return true
+}
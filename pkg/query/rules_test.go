@@ -0,0 +1,62 @@
+package query
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestRuleRouter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/api/v1/rules", r.URL.Path)
+		_, _ = w.Write([]byte(`{"status":"success","data":{"groups":[{"name":"a","rules":[
+			{"name":"job:up:sum","type":"recording"},
+			{"name":"JobDown","type":"alerting"}
+		]}]}}`))
+	}))
+	defer srv.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	ruler := testStoreRef("ruler", "replica", "r1")
+	ruler.Update(ruler.LabelSets(), 50, 100, component.Rule)
+	otherRuler := testStoreRef("other-ruler", "replica", "r2")
+	bucket := testStoreRef("bucket", "replica", "r1")
+	otherBucket := testStoreRef("other-bucket", "replica", "r3")
+	sidecar := testStoreRef("sidecar")
+	stores := []store.Client{ruler, otherRuler, bucket, otherBucket, sidecar}
+
+	r := NewRuleRouter(nil, nil, map[string]string{"ruler": srv.URL, "other-ruler": failing.URL})
+	req := func(mint, maxt int64, name string) *storepb.SeriesRequest {
+		return &storepb.SeriesRequest{
+			MinTime:  mint,
+			MaxTime:  maxt,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: name}},
+		}
+	}
+
+	// Without loaded rules all stores are queried.
+	testutil.Equals(t, addrs(stores), addrs(r.Route(stores, req(60, 90, "job:up:sum"))))
+
+	r.Update(context.Background())
+	testutil.Equals(t, []string{"ruler", "other-ruler", "other-bucket", "sidecar"}, addrs(r.Route(stores, req(60, 90, "job:up:sum"))))
+
+	// The ruler does not cover the time range.
+	testutil.Equals(t, addrs(stores), addrs(r.Route(stores, req(0, 90, "job:up:sum"))))
+	// Alerting rules and other metrics are not routed.
+	testutil.Equals(t, addrs(stores), addrs(r.Route(stores, req(60, 90, "JobDown"))))
+	testutil.Equals(t, addrs(stores), addrs(r.Route(stores, req(60, 90, "up"))))
+	testutil.Equals(t, addrs(stores), addrs(r.Route(stores, &storepb.SeriesRequest{
+		MinTime:  60,
+		MaxTime:  90,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "__name__", Value: "job:up:sum"}},
+	})))
+}
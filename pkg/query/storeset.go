@@ -214,6 +214,8 @@ type storeRef struct {
 	minTime   int64
 	maxTime   int64
 
+	latency storeLatency
+
 	logger log.Logger
 }
 
@@ -495,6 +497,25 @@ func (s *StoreSet) Get() []store.Client {
 	return stores
 }
 
+// StoresOf returns function returning stores from the given function whose address is one of addresses returned by
+// addrs. It allows to query only a subset of stores of a StoreSet.
+func StoresOf(stores func() []store.Client, addrs func() []string) func() []store.Client {
+	return func() []store.Client {
+		set := map[string]struct{}{}
+		for _, addr := range addrs() {
+			set[addr] = struct{}{}
+		}
+
+		var res []store.Client
+		for _, st := range stores() {
+			if _, ok := set[st.Addr()]; ok {
+				res = append(res, st)
+			}
+		}
+		return res
+	}
+}
+
 func (s *StoreSet) Close() {
 	s.storesMtx.Lock()
 	defer s.storesMtx.Unlock()
@@ -536,3 +536,18 @@ func TestStoreSet_Update_NoneAvailable(t *testing.T) {
 	expected := newStoreAPIStats()
 	testutil.Equals(t, expected, storeSet.storesMetric.storeNodes)
 }
+
+func TestStoresOf(t *testing.T) {
+	stores := func() []store.Client {
+		return []store.Client{&storeRef{addr: "a:1"}, &storeRef{addr: "b:1"}, &storeRef{addr: "c:1"}}
+	}
+	addrsOf := func(stores []store.Client) (addrs []string) {
+		for _, st := range stores {
+			addrs = append(addrs, st.Addr())
+		}
+		return addrs
+	}
+
+	testutil.Equals(t, []string{"a:1", "c:1"}, addrsOf(StoresOf(stores, func() []string { return []string{"c:1", "x:1", "a:1"} })()))
+	testutil.Equals(t, []string(nil), addrsOf(StoresOf(stores, func() []string { return nil })()))
+}
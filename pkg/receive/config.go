@@ -18,12 +18,25 @@ import (
 	"gopkg.in/fsnotify.v1"
 )
 
+// HashringAlgorithm is the algorithm used to place time series on the endpoints of a hashring.
+type HashringAlgorithm string
+
+const (
+	// AlgorithmHashmod places a time series on the endpoint selected by its hash modulo the number of endpoints.
+	// It is the default algorithm. Changing the number of endpoints moves most of the time series to other endpoints.
+	AlgorithmHashmod HashringAlgorithm = "hashmod"
+	// AlgorithmKetama places a time series on the endpoint owning the section of the ring its hash falls into,
+	// using consistent hashing. Adding or removing an endpoint moves only the time series of its sections.
+	AlgorithmKetama HashringAlgorithm = "ketama"
+)
+
 // HashringConfig represents the configuration for a hashring
 // a receive node knows about.
 type HashringConfig struct {
-	Hashring  string   `json:"hashring,omitempty"`
-	Tenants   []string `json:"tenants,omitempty"`
-	Endpoints []string `json:"endpoints"`
+	Hashring  string            `json:"hashring,omitempty"`
+	Tenants   []string          `json:"tenants,omitempty"`
+	Endpoints []string          `json:"endpoints"`
+	Algorithm HashringAlgorithm `json:"algorithm,omitempty"`
 }
 
 // ConfigWatcher is able to watch a file containing a hashring configuration
@@ -46,6 +59,8 @@ type ConfigWatcher struct {
 
 	// last is the last known configuration.
 	last []HashringConfig
+	// reloadc receives reload requests, answered with the error of the reload.
+	reloadc chan chan error
 }
 
 // NewConfigWatcher creates a new ConfigWatcher.
@@ -63,6 +78,7 @@ func NewConfigWatcher(logger log.Logger, r prometheus.Registerer, path string, i
 	}
 	c := &ConfigWatcher{
 		ch:       make(chan []HashringConfig),
+		reloadc:  make(chan chan error),
 		path:     path,
 		interval: time.Duration(interval),
 		logger:   logger,
@@ -163,6 +179,9 @@ func (cw *ConfigWatcher) Run(ctx context.Context) {
 			// those files forever.
 			cw.refresh(ctx)
 
+		case errc := <-cw.reloadc:
+			errc <- cw.refresh(ctx)
+
 		case err := <-cw.watcher.Errors:
 			if err != nil {
 				cw.errorCounter.Inc()
@@ -172,6 +191,36 @@ func (cw *ConfigWatcher) Run(ctx context.Context) {
 	}
 }
 
+// Reload reads the configuration file and applies it if it is valid, e.g. on SIGHUP. Invalid configurations are not
+// applied, the last known configuration is kept and the error is returned. It must only be called while Run runs.
+func (cw *ConfigWatcher) Reload(ctx context.Context) error {
+	errc := make(chan error, 1)
+	select {
+	case cw.reloadc <- errc:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Load reads and validates the configuration file, without applying it.
+func (cw *ConfigWatcher) Load() ([]HashringConfig, error) {
+	cfgContent, err := cw.readFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "read configuration file")
+	}
+	config, err := cw.loadConfig(cfgContent)
+	if err != nil {
+		return nil, errors.Wrap(err, "load configuration file")
+	}
+	return config, nil
+}
+
 // C returns a chan that gets hashring configuration updates.
 func (cw *ConfigWatcher) C() <-chan []HashringConfig {
 	return cw.ch
@@ -195,35 +244,53 @@ func (cw *ConfigWatcher) readFile() ([]byte, error) {
 // loadConfig loads raw configuration content and returns a configuration.
 func (cw *ConfigWatcher) loadConfig(content []byte) ([]HashringConfig, error) {
 	var config []HashringConfig
-	err := json.Unmarshal(content, &config)
-	return config, err
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, err
+	}
+	if len(config) == 0 {
+		return nil, errors.New("no hashrings configured")
+	}
+	for _, c := range config {
+		switch c.Algorithm {
+		case "", AlgorithmHashmod, AlgorithmKetama:
+		default:
+			return nil, errors.Errorf("unknown algorithm %q of hashring %q", c.Algorithm, c.Hashring)
+		}
+		if len(c.Endpoints) == 0 {
+			return nil, errors.Errorf("hashring %q has no endpoints", c.Hashring)
+		}
+	}
+	return config, nil
 }
 
-// refresh reads the configured file and sends the hashring configuration on the channel.
-func (cw *ConfigWatcher) refresh(ctx context.Context) {
+// refresh reads the configured file and sends the hashring configuration on the channel if it changed. The
+// configuration is validated before, invalid configurations are not sent.
+func (cw *ConfigWatcher) refresh(ctx context.Context) error {
 	cw.refreshCounter.Inc()
 	cfgContent, err := cw.readFile()
 	if err != nil {
 		cw.errorCounter.Inc()
+		cw.successGauge.Set(0)
 		level.Error(cw.logger).Log("msg", "failed to read configuration file", "err", err, "path", cw.path)
-		return
+		return errors.Wrap(err, "read configuration file")
 	}
 
 	config, err := cw.loadConfig(cfgContent)
 	if err != nil {
 		cw.errorCounter.Inc()
+		cw.successGauge.Set(0)
 		level.Error(cw.logger).Log("msg", "failed to load configuration file", "err", err, "path", cw.path)
-		return
+		return errors.Wrap(err, "load configuration file")
 	}
+	cw.successGauge.Set(1)
 
 	// If there was no change to the configuration, return early.
 	if reflect.DeepEqual(cw.last, config) {
-		return
+		return nil
 	}
 	cw.changesCounter.Inc()
 	// Save the last known configuration.
 	cw.last = config
-	cw.successGauge.Set(1)
 	cw.lastSuccessTimeGauge.Set(float64(time.Now().Unix()))
 	cw.hashGauge.Set(hashAsMetricValue(cfgContent))
 
@@ -235,9 +302,9 @@ func (cw *ConfigWatcher) refresh(ctx context.Context) {
 	level.Debug(cw.logger).Log("msg", "refreshed hashring config")
 	select {
 	case <-ctx.Done():
-		return
+		return ctx.Err()
 	case cw.ch <- config:
-		return
+		return nil
 	}
 }
 
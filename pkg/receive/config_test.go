@@ -0,0 +1,61 @@
+package receive
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestConfigWatcher_Reload(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "config-watcher")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	path := filepath.Join(dir, "hashrings.json")
+	testutil.Ok(t, ioutil.WriteFile(path, []byte(`[{"endpoints":["a"]}]`), 0666))
+
+	// A long refresh interval, so only reloads and the initial refresh read the file.
+	cw, err := NewConfigWatcher(nil, nil, path, model.Duration(time.Hour))
+	testutil.Ok(t, err)
+	go cw.Run(ctx)
+	testutil.Equals(t, []HashringConfig{{Endpoints: []string{"a"}}}, <-cw.C())
+
+	received := make(chan []HashringConfig, 10)
+	go func() {
+		for cfg := range cw.C() {
+			received <- cfg
+		}
+	}()
+
+	for _, invalid := range []string{
+		`[{"endpoints":["a"]`,
+		`[]`,
+		`[{"hashring":"foo","endpoints":[]}]`,
+		`[{"endpoints":["a"],"algorithm":"random"}]`,
+	} {
+		testutil.Ok(t, ioutil.WriteFile(path, []byte(invalid), 0666))
+		testutil.NotOk(t, cw.Reload(ctx))
+	}
+
+	testutil.Ok(t, ioutil.WriteFile(path, []byte(`[{"endpoints":["a","b"]}]`), 0666))
+	testutil.Ok(t, cw.Reload(ctx))
+	// Unchanged configurations are not sent again.
+	testutil.Ok(t, cw.Reload(ctx))
+
+	select {
+	case cfg := <-received:
+		testutil.Equals(t, []HashringConfig{{Endpoints: []string{"a", "b"}}}, cfg)
+	case <-ctx.Done():
+		t.Fatal("no configuration received")
+	}
+	testutil.Equals(t, 0, len(received))
+}
@@ -0,0 +1,371 @@
+package receive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// forwardBufferFileSuffix is the suffix of files of buffered forward requests.
+const forwardBufferFileSuffix = ".req"
+
+// errForwardBufferFull is returned when a request does not fit into the forward buffer.
+var errForwardBufferFull = errors.New("forward buffer is full")
+
+// forwardRequest is a write request forwarded to another node of the hashring.
+type forwardRequest struct {
+	Endpoint string `json:"endpoint"`
+	Tenant   string `json:"tenant"`
+	Replica  uint64 `json:"replica"`
+	// Time is the time the request was buffered at in milliseconds since epoch.
+	Time int64 `json:"time,omitempty"`
+	// Body is the snappy compressed write request. It is stored raw after the JSON header line.
+	Body []byte `json:"-"`
+}
+
+// key returns the key deduplicating buffered requests, so retries of a write request by the remote writer are buffered
+// once.
+func (r *forwardRequest) key() string {
+	h := sha256.New()
+	for _, s := range []string{r.Endpoint, r.Tenant, strconv.FormatUint(r.Replica, 10)} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	_, _ = h.Write(r.Body)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// ForwardBuffer buffers forward requests on disk while their endpoints are unavailable, so write requests are not
+// failed back to every remote writer on short outages of a single node. Requests are synced to disk before they are
+// acknowledged and deduplicated. Buffered requests are retried in order per endpoint until they succeed, are rejected
+// by the endpoint or are older than the maximum age. The buffer is bounded, requests exceeding it fail as without
+// buffer. Buffered requests survive restarts.
+type ForwardBuffer struct {
+	logger  log.Logger
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+
+	mtx  sync.Mutex
+	seq  uint64
+	size int64
+	// sizes are sizes of files of buffered requests by file name.
+	sizes map[string]int64
+	// keys are file names of buffered requests by their deduplication key.
+	keys map[string]string
+
+	buffered     prometheus.Counter
+	deduplicated prometheus.Counter
+	replayed     *prometheus.CounterVec
+	droppedStale prometheus.Counter
+	requests     prometheus.GaugeFunc
+	bytes        prometheus.GaugeFunc
+}
+
+// NewForwardBuffer returns a forward buffer in the given directory using at most maxSize bytes, loading requests
+// buffered before. Requests buffered longer than maxAge ago are dropped instead of replayed, as their samples are out
+// of the window the TSDB of the endpoint accepts. Zero maxAge replays requests of any age.
+func NewForwardBuffer(logger log.Logger, reg prometheus.Registerer, dir string, maxSize int64, maxAge time.Duration) (*ForwardBuffer, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, errors.Wrap(err, "create forward buffer dir")
+	}
+	b := &ForwardBuffer{
+		logger:  logger,
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		sizes:   map[string]int64{},
+		keys:    map[string]string{},
+		buffered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_forward_buffer_buffered_total",
+			Help: "Total number of forward requests buffered while their endpoint was unavailable.",
+		}),
+		deduplicated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_forward_buffer_deduplicated_total",
+			Help: "Total number of forward requests not buffered as the same request was buffered already.",
+		}),
+		replayed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_receive_forward_buffer_replayed_total",
+			Help: "Total number of buffered forward requests replayed to their endpoint, by result. Rejected requests are dropped.",
+		}, []string{"result"}),
+		droppedStale: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_forward_buffer_dropped_stale_total",
+			Help: "Total number of buffered forward requests dropped without replay as they were buffered longer than the maximum age ago.",
+		}),
+	}
+	b.requests = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_receive_forward_buffer_requests",
+		Help: "Number of forward requests in the forward buffer.",
+	}, func() float64 {
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+		return float64(len(b.sizes))
+	})
+	b.bytes = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_receive_forward_buffer_bytes",
+		Help: "Size of forward requests in the forward buffer in bytes.",
+	}, func() float64 {
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+		return float64(b.size)
+	})
+
+	files, err := b.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		fi, err := os.Stat(filepath.Join(dir, f))
+		if err != nil {
+			return nil, errors.Wrap(err, "stat buffered forward request")
+		}
+		b.sizes[f] = fi.Size()
+		b.size += fi.Size()
+		seq, key := parseForwardBufferFile(f)
+		if seq >= b.seq {
+			b.seq = seq + 1
+		}
+		if key != "" {
+			b.keys[key] = f
+		}
+	}
+	if len(files) > 0 {
+		level.Info(logger).Log("msg", "loaded buffered forward requests", "requests", len(files), "bytes", b.size)
+	}
+
+	if reg != nil {
+		reg.MustRegister(b.buffered, b.deduplicated, b.replayed, b.droppedStale, b.requests, b.bytes)
+	}
+	return b, nil
+}
+
+// parseForwardBufferFile returns the sequence number and deduplication key of the buffered request from the name
+// <seq>-<key>.req of its file.
+func parseForwardBufferFile(name string) (uint64, string) {
+	parts := strings.SplitN(strings.TrimSuffix(name, forwardBufferFileSuffix), "-", 2)
+	seq, _ := strconv.ParseUint(parts[0], 10, 64)
+	if len(parts) < 2 {
+		return seq, ""
+	}
+	return seq, parts[1]
+}
+
+// files returns names of files of buffered requests in the order they were buffered.
+func (b *ForwardBuffer) files() ([]string, error) {
+	fis, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read forward buffer dir")
+	}
+	var files []string
+	for _, fi := range fis {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), forwardBufferFileSuffix) {
+			continue
+		}
+		files = append(files, fi.Name())
+	}
+	// Names are zero padded sequence numbers.
+	sort.Strings(files)
+	return files, nil
+}
+
+// Add buffers the forward request and returns once it is synced to disk. Requests equal to a buffered request are not
+// buffered again. It returns errForwardBufferFull if the request does not fit into the buffer.
+func (b *ForwardBuffer) Add(r *forwardRequest) error {
+	if r.Time == 0 {
+		r.Time = timestamp.FromTime(time.Now())
+	}
+	header, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "encode forward request")
+	}
+	size := int64(len(header) + 1 + len(r.Body))
+	key := r.key()
+
+	b.mtx.Lock()
+	if _, ok := b.keys[key]; ok {
+		b.mtx.Unlock()
+		b.deduplicated.Inc()
+		return nil
+	}
+	if b.maxSize > 0 && b.size+size > b.maxSize {
+		b.mtx.Unlock()
+		return errForwardBufferFull
+	}
+	name := fmt.Sprintf("%020d-%s%s", b.seq, key, forwardBufferFileSuffix)
+	b.seq++
+	b.size += size
+	b.sizes[name] = size
+	b.keys[key] = name
+	b.mtx.Unlock()
+
+	var buf bytes.Buffer
+	buf.Grow(int(size))
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(r.Body)
+
+	if err := writeFileSync(filepath.Join(b.dir, name), buf.Bytes()); err != nil {
+		b.forget(name)
+		return errors.Wrap(err, "write buffered forward request")
+	}
+	b.buffered.Inc()
+	return nil
+}
+
+// writeFileSync writes the file atomically, so partial requests are never replayed, and syncs it to disk, so
+// acknowledged requests are not lost on crashes.
+func writeFileSync(p string, c []byte) error {
+	f, err := os.OpenFile(p+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(c); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// Rename syncs the directory, persisting the rename.
+	return fileutil.Rename(p+".tmp", p)
+}
+
+// forget drops the buffered request from the accounting of the buffer.
+func (b *ForwardBuffer) forget(name string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.size -= b.sizes[name]
+	delete(b.sizes, name)
+	if _, key := parseForwardBufferFile(name); key != "" && b.keys[key] == name {
+		delete(b.keys, key)
+	}
+}
+
+func (b *ForwardBuffer) read(name string) (*forwardRequest, error) {
+	c, err := ioutil.ReadFile(filepath.Join(b.dir, name))
+	if err != nil {
+		return nil, errors.Wrap(err, "read buffered forward request")
+	}
+	header, err := bufio.NewReader(bytes.NewReader(c)).ReadBytes('\n')
+	if err != nil {
+		return nil, errors.Wrapf(err, "read header of buffered forward request %s", name)
+	}
+	r := &forwardRequest{}
+	if err := json.Unmarshal(header, r); err != nil {
+		return nil, errors.Wrapf(err, "decode header of buffered forward request %s", name)
+	}
+	r.Body = c[len(header):]
+	return r, nil
+}
+
+func (b *ForwardBuffer) remove(name string) error {
+	if err := os.Remove(filepath.Join(b.dir, name)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove buffered forward request")
+	}
+	b.forget(name)
+	return nil
+}
+
+// Replay sends buffered requests to their endpoints in the order they were buffered. Requests which fail with
+// retryable errors are kept, together with later requests of their endpoint, so requests of an endpoint are replayed in
+// order. Requests rejected by the endpoint or older than the maximum age are dropped.
+func (b *ForwardBuffer) Replay(ctx context.Context, send func(context.Context, *forwardRequest) error) error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+
+	unavailable := map[string]struct{}{}
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r, err := b.read(f)
+		if err != nil {
+			// Unreadable requests are never going to succeed.
+			level.Warn(b.logger).Log("msg", "dropping unreadable buffered forward request", "file", f, "err", err)
+			b.replayed.WithLabelValues("dropped").Inc()
+			if err := b.remove(f); err != nil {
+				return err
+			}
+			continue
+		}
+		if b.maxAge > 0 && timestamp.Time(r.Time).Before(time.Now().Add(-b.maxAge)) {
+			level.Warn(b.logger).Log("msg", "dropping stale buffered forward request", "endpoint", r.Endpoint, "buffered", timestamp.Time(r.Time))
+			b.droppedStale.Inc()
+			if err := b.remove(f); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := unavailable[r.Endpoint]; ok {
+			continue
+		}
+
+		if err := send(ctx, r); err != nil {
+			if isRetryableForwardErr(err) {
+				b.replayed.WithLabelValues("error").Inc()
+				unavailable[r.Endpoint] = struct{}{}
+				continue
+			}
+			level.Warn(b.logger).Log("msg", "dropping buffered forward request rejected by endpoint", "endpoint", r.Endpoint, "err", err)
+			b.replayed.WithLabelValues("dropped").Inc()
+		} else {
+			b.replayed.WithLabelValues("success").Inc()
+		}
+		if err := b.remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run replays buffered requests every interval until the context is canceled.
+func (b *ForwardBuffer) Run(ctx context.Context, interval time.Duration, send func(context.Context, *forwardRequest) error) error {
+	return runutil.Repeat(interval, ctx.Done(), func() error {
+		if err := b.Replay(ctx, send); err != nil && ctx.Err() == nil {
+			level.Warn(b.logger).Log("msg", "failed to replay buffered forward requests", "err", err)
+		}
+		return nil
+	})
+}
+
+// isRetryableForwardErr returns true if the forward request failed because the endpoint was unavailable, e.g. with
+// a network error or a 5xx status, rather than rejecting the request.
+func isRetryableForwardErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	code, convErr := strconv.Atoi(errors.Cause(err).Error())
+	if convErr != nil {
+		return true
+	}
+	return code >= 500 || code == 429
+}
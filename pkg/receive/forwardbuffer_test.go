@@ -0,0 +1,166 @@
+package receive
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestForwardBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-forward-buffer")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	b, err := NewForwardBuffer(nil, nil, dir, 1024, 0)
+	testutil.Ok(t, err)
+
+	for _, r := range []*forwardRequest{
+		{Endpoint: "a", Tenant: "t1", Replica: 1, Body: []byte("a1\nbody")},
+		{Endpoint: "b", Tenant: "t1", Body: []byte("b1")},
+		{Endpoint: "a", Tenant: "t2", Body: []byte("a2")},
+		{Endpoint: "c", Body: []byte("c1")},
+	} {
+		testutil.Ok(t, b.Add(r))
+	}
+	// Equal requests are buffered once.
+	testutil.Ok(t, b.Add(&forwardRequest{Endpoint: "b", Tenant: "t1", Body: []byte("b1")}))
+	testutil.Equals(t, 4, len(b.sizes))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(b.deduplicated))
+	testutil.Equals(t, errForwardBufferFull, b.Add(&forwardRequest{Endpoint: "a", Body: make([]byte, 1024)}))
+
+	// Endpoint a is still unavailable, c rejects its request.
+	var sent []string
+	testutil.Ok(t, b.Replay(context.Background(), func(_ context.Context, r *forwardRequest) error {
+		sent = append(sent, string(r.Body))
+		switch r.Endpoint {
+		case "a":
+			return errors.New("503")
+		case "c":
+			return errors.New("400")
+		}
+		return nil
+	}))
+	testutil.Equals(t, []string{"a1\nbody", "b1", "c1"}, sent)
+
+	// Buffered requests survive restarts.
+	b, err = NewForwardBuffer(nil, nil, dir, 1024, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(b.sizes))
+	testutil.Ok(t, b.Add(&forwardRequest{Endpoint: "a", Tenant: "t2", Body: []byte("a2")}))
+	testutil.Equals(t, 2, len(b.sizes))
+	testutil.Ok(t, b.Add(&forwardRequest{Endpoint: "a", Tenant: "t3", Body: []byte("a3")}))
+
+	var replayed []forwardRequest
+	testutil.Ok(t, b.Replay(context.Background(), func(_ context.Context, r *forwardRequest) error {
+		r.Time = 0
+		replayed = append(replayed, *r)
+		return nil
+	}))
+	testutil.Equals(t, []forwardRequest{
+		{Endpoint: "a", Tenant: "t1", Replica: 1, Body: []byte("a1\nbody")},
+		{Endpoint: "a", Tenant: "t2", Body: []byte("a2")},
+		{Endpoint: "a", Tenant: "t3", Body: []byte("a3")},
+	}, replayed)
+	testutil.Equals(t, 0, len(b.sizes))
+	testutil.Equals(t, int64(0), b.size)
+
+	files, err := b.files()
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(files))
+}
+
+func TestForwardBuffer_MaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-forward-buffer-max-age")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	b, err := NewForwardBuffer(nil, nil, dir, 0, time.Hour)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, b.Add(&forwardRequest{Endpoint: "a", Body: []byte("stale"), Time: timestamp.FromTime(time.Now().Add(-2 * time.Hour))}))
+	testutil.Ok(t, b.Add(&forwardRequest{Endpoint: "a", Body: []byte("fresh")}))
+
+	var sent []string
+	testutil.Ok(t, b.Replay(context.Background(), func(_ context.Context, r *forwardRequest) error {
+		sent = append(sent, string(r.Body))
+		return nil
+	}))
+	testutil.Equals(t, []string{"fresh"}, sent)
+	testutil.Equals(t, 0, len(b.sizes))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(b.droppedStale))
+}
+
+func TestIsRetryableForwardErr(t *testing.T) {
+	testutil.Assert(t, !isRetryableForwardErr(nil), "nil error is not retryable")
+	testutil.Assert(t, isRetryableForwardErr(errors.New("dial tcp: connection refused")), "network errors are retryable")
+	testutil.Assert(t, isRetryableForwardErr(errors.New("503")), "5xx statuses are retryable")
+	testutil.Assert(t, isRetryableForwardErr(errors.New("429")), "429 status is retryable")
+	testutil.Assert(t, !isRetryableForwardErr(errors.New("409")), "conflicts are not retryable")
+	testutil.Assert(t, !isRetryableForwardErr(errors.New("400")), "bad requests are not retryable")
+}
+
+func TestReceive_ForwardBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-receive-forward-buffer")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	appendables := []*fakeAppendable{
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+	}
+	handlers, hashring, close := newHandlerHashring(appendables, 1)
+	defer close()
+
+	fb, err := NewForwardBuffer(nil, nil, dir, 0, 0)
+	testutil.Ok(t, err)
+	handlers[0].options.ForwardBuffer = fb
+
+	// Find a series forwarded by the first node to the second one.
+	var ts prompb.TimeSeries
+	for i := 0; ; i++ {
+		ts = prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "foo", Value: strconv.Itoa(i)}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1}},
+		}
+		if endpointHit(t, hashring, 1, handlers[1].options.Endpoint, "", &ts) {
+			break
+		}
+	}
+	wreq := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{ts}}
+
+	// The second node is unavailable, the forwarded request is buffered and succeeds the write request.
+	writer := handlers[1].writer
+	handlers[1].SetWriter(nil)
+	status, err := makeRequest(handlers[0], "", wreq)
+	testutil.Ok(t, err)
+	testutil.Equals(t, http.StatusOK, status)
+	testutil.Equals(t, 1, len(fb.sizes))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(handlers[0].forwardRequestsBuffered))
+
+	// Retries of the write request are not buffered again.
+	status, err = makeRequest(handlers[0], "", wreq)
+	testutil.Ok(t, err)
+	testutil.Equals(t, http.StatusOK, status)
+	testutil.Equals(t, 1, len(fb.sizes))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(fb.deduplicated))
+
+	// Replays keep the request while the node is unavailable.
+	testutil.Ok(t, fb.Replay(context.Background(), handlers[0].send))
+	testutil.Equals(t, 1, len(fb.sizes))
+
+	handlers[1].SetWriter(writer)
+	testutil.Ok(t, fb.Replay(context.Background(), handlers[0].send))
+	testutil.Equals(t, 0, len(fb.sizes))
+	testutil.Equals(t, 1, len(appendables[1].appender.(*fakeAppender).samples[labels.FromStrings("foo", ts.Labels[0].Value).String()]))
+}
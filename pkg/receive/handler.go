@@ -3,29 +3,39 @@ package receive
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	stdlog "log"
 	"net"
 	"net/http"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	conntrack "github.com/mwitkow/go-conntrack"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage"
 	terrors "github.com/prometheus/prometheus/tsdb/errors"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	thanostls "github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/tracing"
 )
 
@@ -36,6 +46,41 @@ const (
 	DefaultReplicaHeader = "THANOS-REPLICA"
 )
 
+const (
+	// EncodingSnappy is the content encoding of remote write requests compressed with snappy. It is assumed if
+	// no Content-Encoding header is given, as done by Prometheus.
+	EncodingSnappy = "snappy"
+	// EncodingZstd is the content encoding of remote write requests compressed with zstd.
+	EncodingZstd = "zstd"
+)
+
+// supportedEncodings are advertised in the Accept-Encoding header of responses, so clients can negotiate the
+// content encoding of subsequent requests.
+var supportedEncodings = strings.Join([]string{EncodingSnappy, EncodingZstd}, ", ")
+
+// MaxDecodedRequestSize is the maximum size of decompressed remote write requests. Bigger requests are rejected before
+// they are decompressed in full, so small compressed requests cannot exhaust the memory of the receiver. It also limits
+// the size of compressed requests.
+const MaxDecodedRequestSize = 64 << 20
+
+var (
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+)
+
+// sharedZstdDecoder returns the zstd decoder shared by all requests. It decodes up to GOMAXPROCS requests at the
+// same time.
+func sharedZstdDecoder() (*zstd.Decoder, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil,
+			zstd.WithDecoderConcurrency(runtime.GOMAXPROCS(0)),
+			zstd.WithDecoderMaxMemory(MaxDecodedRequestSize),
+		)
+	})
+	return zstdDecoder, zstdDecoderErr
+}
+
 // conflictErr is returned whenever an operation fails due to any conflict-type error.
 var conflictErr = errors.New("conflict")
 
@@ -49,6 +94,22 @@ type Options struct {
 	ReplicaHeader     string
 	ReplicationFactor uint64
 	Tracer            opentracing.Tracer
+	// TLSConfig, if not nil, is used to serve the HTTP endpoints over TLS.
+	TLSConfig *tls.Config
+	// ForwardTLSConfig, if not nil, is used by forward requests to https endpoints.
+	ForwardTLSConfig *tls.Config
+	// TenantActivity, if not nil, records tenants of local writes.
+	TenantActivity *TenantActivity
+	// ForwardBuffer, if not nil, buffers forward requests to unavailable endpoints, see RunForwardBuffer.
+	ForwardBuffer *ForwardBuffer
+	// Metadata, if not nil, stores metric metadata of remote write requests and serves it on the metadata endpoint.
+	Metadata *MetadataStore
+	// Flusher, if not nil, flushes the head of the local TSDB into a block and uploads it on requests to
+	// the flush endpoint. It must return once the block is uploaded. The flush endpoint is disabled if nil.
+	Flusher func(ctx context.Context) error
+	// RelabelConfigs are applied to series of write requests received from clients, until replaced with
+	// SetRelabelConfigs. Series with empty label sets after relabeling are dropped.
+	RelabelConfigs []*relabel.Config
 }
 
 // Handler serves a Prometheus remote write receiving HTTP endpoint.
@@ -60,11 +121,13 @@ type Handler struct {
 	options  *Options
 	listener net.Listener
 
-	mtx      sync.RWMutex
-	hashring Hashring
+	mtx            sync.RWMutex
+	hashring       Hashring
+	relabelConfigs []*relabel.Config
 
 	// Metrics.
-	forwardRequestsTotal *prometheus.CounterVec
+	forwardRequestsTotal    *prometheus.CounterVec
+	forwardRequestsBuffered prometheus.Counter
 }
 
 func NewHandler(logger log.Logger, o *Options) *Handler {
@@ -72,29 +135,40 @@ func NewHandler(logger log.Logger, o *Options) *Handler {
 		logger = log.NewNopLogger()
 	}
 
-	client := &http.Client{}
+	var transport http.RoundTripper = http.DefaultTransport
+	if o.ForwardTLSConfig != nil {
+		transport = thanostls.NewTransport(o.ForwardTLSConfig)
+	}
+	client := &http.Client{Transport: transport}
 	if o.Tracer != nil {
-		client.Transport = tracing.HTTPTripperware(logger, http.DefaultTransport)
+		client.Transport = tracing.HTTPTripperware(logger, transport)
 	}
 
 	h := &Handler{
-		client:  client,
-		logger:  logger,
-		writer:  o.Writer,
-		router:  route.New(),
-		options: o,
+		client:         client,
+		logger:         logger,
+		writer:         o.Writer,
+		router:         route.New(),
+		options:        o,
+		relabelConfigs: o.RelabelConfigs,
 		forwardRequestsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "thanos_receive_forward_requests_total",
 				Help: "The number of forward requests.",
 			}, []string{"result"},
 		),
+		forwardRequestsBuffered: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "thanos_receive_forward_requests_buffered_total",
+				Help: "The number of failed forward requests buffered for replay. They count as successful towards the replication quorum.",
+			},
+		),
 	}
 
 	ins := extpromhttp.NewNopInstrumentationMiddleware()
 	if o.Registry != nil {
 		ins = extpromhttp.NewInstrumentationMiddleware(o.Registry)
-		o.Registry.MustRegister(h.forwardRequestsTotal)
+		o.Registry.MustRegister(h.forwardRequestsTotal, h.forwardRequestsBuffered)
 	}
 
 	readyf := h.testReady
@@ -106,6 +180,9 @@ func NewHandler(logger log.Logger, o *Options) *Handler {
 	}
 
 	h.router.Post("/api/v1/receive", instrf("receive", readyf(h.receive)))
+	h.router.Post("/api/v1/flush", instrf("flush", readyf(h.flush)))
+	h.router.Get("/api/v1/hashring", instrf("hashring", readyf(h.endpoints)))
+	h.router.Get("/api/v1/metadata", instrf("metadata", h.metadata))
 
 	return h
 }
@@ -130,6 +207,13 @@ func (h *Handler) Hashring(hashring Hashring) {
 	h.hashring = hashring
 }
 
+// SetRelabelConfigs replaces the relabel configs applied to series of write requests received from clients.
+func (h *Handler) SetRelabelConfigs(cfgs []*relabel.Config) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.relabelConfigs = cfgs
+}
+
 // Verifies whether the server is ready or not.
 func (h *Handler) isReady() bool {
 	h.mtx.RLock()
@@ -186,13 +270,94 @@ func (h *Handler) Run() error {
 	errlog := stdlog.New(log.NewStdlibAdapter(level.Error(h.logger)), "", 0)
 
 	httpSrv := &http.Server{
-		Handler:  nethttp.Middleware(opentracing.GlobalTracer(), mux, operationName),
-		ErrorLog: errlog,
+		Handler:   nethttp.Middleware(opentracing.GlobalTracer(), mux, operationName),
+		ErrorLog:  errlog,
+		TLSConfig: h.options.TLSConfig,
 	}
 
+	if h.options.TLSConfig != nil {
+		return httpSrv.ServeTLS(h.listener, "", "")
+	}
 	return httpSrv.Serve(h.listener)
 }
 
+// flush flushes the head of the local TSDB into a block and uploads it, e.g. before moving tenants
+// to other nodes. The response is sent once the block is uploaded.
+func (h *Handler) flush(w http.ResponseWriter, r *http.Request) {
+	if h.options.Flusher == nil {
+		http.Error(w, "flushing is not enabled", http.StatusNotImplemented)
+		return
+	}
+	if err := h.options.Flusher(r.Context()); err != nil {
+		level.Error(h.logger).Log("msg", "failed to flush storage", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// EndpointsResponse is the response of the hashring endpoint.
+type EndpointsResponse struct {
+	// Endpoints of the hashring handling the tenant of the request.
+	Endpoints []string `json:"endpoints"`
+}
+
+// endpoints responds with the endpoints of the currently loaded hashring handling the tenant given by
+// the tenant query parameter, so tools can verify that a hashring change has been applied.
+func (h *Handler) endpoints(w http.ResponseWriter, r *http.Request) {
+	h.mtx.RLock()
+	hashring := h.hashring
+	h.mtx.RUnlock()
+
+	eh, ok := hashring.(interface {
+		Endpoints(tenant string) ([]string, error)
+	})
+	if !ok {
+		http.Error(w, "hashring does not expose its endpoints", http.StatusNotImplemented)
+		return
+	}
+	endpoints, err := eh.Endpoints(r.URL.Query().Get("tenant"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(EndpointsResponse{Endpoints: endpoints}); err != nil {
+		level.Warn(h.logger).Log("msg", "failed to write hashring response", "err", err)
+	}
+}
+
+// MetadataResponse is the response of the metadata endpoint, in the format of the metadata API of Prometheus.
+type MetadataResponse struct {
+	Status string                      `json:"status"`
+	Data   map[string][]MetricMetadata `json:"data"`
+}
+
+// metadata responds with the metric metadata received for the tenant of the request, of the metric given by the
+// metric query parameter only and of at most limit metrics, if given.
+func (h *Handler) metadata(w http.ResponseWriter, r *http.Request) {
+	if h.options.Metadata == nil {
+		http.Error(w, "storing metadata is not enabled", http.StatusNotImplemented)
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	resp := MetadataResponse{
+		Status: "success",
+		Data:   h.options.Metadata.Metadata(r.Header.Get(h.options.TenantHeader), r.URL.Query().Get("metric"), limit),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Warn(h.logger).Log("msg", "failed to write metadata response", "err", err)
+	}
+}
+
 // replica encapsulates the replica number of a request and if the request is
 // already replicated.
 type replica struct {
@@ -201,15 +366,30 @@ type replica struct {
 }
 
 func (h *Handler) receive(w http.ResponseWriter, r *http.Request) {
-	compressed, err := ioutil.ReadAll(r.Body)
+	w.Header().Set("Accept-Encoding", supportedEncodings)
+
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	if encoding == "" {
+		encoding = EncodingSnappy
+	}
+	if encoding != EncodingSnappy && encoding != EncodingZstd {
+		http.Error(w, fmt.Sprintf("unsupported content encoding %q, supported: %s", encoding, supportedEncodings), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	compressed, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxDecodedRequestSize+1))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if len(compressed) > MaxDecodedRequestSize {
+		http.Error(w, fmt.Sprintf("request exceeds maximum size of %d bytes", MaxDecodedRequestSize), http.StatusRequestEntityTooLarge)
+		return
+	}
 
-	reqBuf, err := snappy.Decode(nil, compressed)
+	reqBuf, err := decode(encoding, compressed)
 	if err != nil {
-		level.Error(h.logger).Log("msg", "snappy decode error", "err", err)
+		level.Error(h.logger).Log("msg", "decode error", "encoding", encoding, "err", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -238,6 +418,25 @@ func (h *Handler) receive(w http.ResponseWriter, r *http.Request) {
 
 	tenant := r.Header.Get(h.options.TenantHeader)
 
+	// Series are relabeled by the node receiving the request from the client only, forwarded series are relabeled
+	// already.
+	if !rep.replicated {
+		h.mtx.RLock()
+		cfgs := h.relabelConfigs
+		h.mtx.RUnlock()
+		relabelSeries(cfgs, &wreq)
+	}
+
+	// Metadata is stored by the node receiving the request from the client only, it is not forwarded.
+	if h.options.Metadata != nil && !rep.replicated {
+		md, err := requestMetadata(&wreq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.options.Metadata.Add(tenant, md)
+	}
+
 	// Forward any time series as necessary. All time series
 	// destined for the local node will be written to the receiver.
 	// Time series will be replicated as necessary.
@@ -251,6 +450,55 @@ func (h *Handler) receive(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// relabelSeries applies the relabel configs to the series of the write request, dropping series with empty label
+// sets after relabeling.
+func relabelSeries(cfgs []*relabel.Config, wreq *prompb.WriteRequest) {
+	if len(cfgs) == 0 {
+		return
+	}
+	series := wreq.Timeseries[:0]
+	for _, ts := range wreq.Timeseries {
+		lset := make(labels.Labels, 0, len(ts.Labels))
+		for _, l := range ts.Labels {
+			lset = append(lset, labels.Label{Name: l.Name, Value: l.Value})
+		}
+		lset = relabel.Process(lset, cfgs...)
+		if len(lset) == 0 {
+			continue
+		}
+		ts.Labels = make([]prompb.Label, 0, len(lset))
+		for _, l := range lset {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+		}
+		series = append(series, ts)
+	}
+	wreq.Timeseries = series
+}
+
+// decode decompresses the remote write request body with the given content encoding. Requests decompressing to more
+// than MaxDecodedRequestSize bytes are rejected.
+func decode(encoding string, compressed []byte) ([]byte, error) {
+	if encoding != EncodingZstd {
+		n, err := snappy.DecodedLen(compressed)
+		if err != nil {
+			return nil, err
+		}
+		if n > MaxDecodedRequestSize {
+			return nil, errors.Errorf("decoded request exceeds maximum size of %d bytes", MaxDecodedRequestSize)
+		}
+		return snappy.Decode(nil, compressed)
+	}
+	d, err := sharedZstdDecoder()
+	if err != nil {
+		return nil, errors.Wrap(err, "create zstd decoder")
+	}
+	res, err := d.DecodeAll(compressed, nil)
+	if err == zstd.ErrDecoderSizeExceeded || err == zstd.ErrFrameSizeExceeded || len(res) > MaxDecodedRequestSize {
+		return nil, errors.Errorf("decoded request exceeds maximum size of %d bytes", MaxDecodedRequestSize)
+	}
+	return res, err
+}
+
 // forward accepts a write request, batches its time series by
 // corresponding endpoint, and forwards them in parallel to the
 // correct endpoint. Requests destined for the local node are written
@@ -333,6 +581,10 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 					err = errors.New("storage is not ready")
 				} else {
 					err = h.writer.Write(wreqs[endpoint])
+					// Samples might be written even if some of them failed.
+					if h.options.TenantActivity != nil {
+						h.options.TenantActivity.Record(tenant)
+					}
 					// When a MultiError is added to another MultiError, the error slices are concatenated, not nested.
 					// To avoid breaking the counting logic, we need to flatten the error.
 					if errs, ok := err.(terrors.MultiError); ok {
@@ -359,14 +611,12 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 				ec <- err
 				return
 			}
-			req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(snappy.Encode(nil, buf)))
-			if err != nil {
-				level.Error(h.logger).Log("msg", "creating request", "err", err, "endpoint", endpoint)
-				ec <- err
-				return
+			fr := &forwardRequest{
+				Endpoint: endpoint,
+				Tenant:   tenant,
+				Replica:  replicas[endpoint].n,
+				Body:     snappy.Encode(nil, buf),
 			}
-			req.Header.Add(h.options.TenantHeader, tenant)
-			req.Header.Add(h.options.ReplicaHeader, strconv.FormatUint(replicas[endpoint].n, 10))
 
 			// Increment the counters as necessary now that
 			// the requests will go out.
@@ -384,16 +634,14 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 
 			// Actually make the request against the endpoint
 			// we determined should handle these time series.
-			var res *http.Response
-			res, err = h.client.Do(req.WithContext(ctx))
-			if err != nil {
+			if err = h.send(ctx, fr); err != nil {
 				level.Error(h.logger).Log("msg", "forwarding request", "err", err, "endpoint", endpoint)
-				ec <- err
-				return
-			}
-			if res.StatusCode != http.StatusOK {
-				err = errors.New(strconv.Itoa(res.StatusCode))
-				level.Error(h.logger).Log("msg", "forwarding returned non-200 status", "err", err, "endpoint", endpoint)
+				// Buffered requests are synced to disk and replayed until they succeed, so they succeed the write
+				// request. Failing them would make the remote writer retry and buffer the request again.
+				if h.bufferForward(ctx, fr, err) {
+					ec <- nil
+					return
+				}
 				ec <- err
 				return
 			}
@@ -416,6 +664,55 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 	return errs.Err()
 }
 
+// send sends the forward request to its endpoint. Non-200 statuses are returned as errors with the status code as
+// the message.
+func (h *Handler) send(ctx context.Context, r *forwardRequest) error {
+	req, err := http.NewRequest("POST", r.Endpoint, bytes.NewBuffer(r.Body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	req.Header.Add(h.options.TenantHeader, r.Tenant)
+	req.Header.Add(h.options.ReplicaHeader, strconv.FormatUint(r.Replica, 10))
+
+	res, err := h.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(h.logger, res.Body, "forward response body")
+	if res.StatusCode != http.StatusOK {
+		return errors.New(strconv.Itoa(res.StatusCode))
+	}
+	return nil
+}
+
+// bufferForward buffers the forward request which failed with the given error, if the forward buffer is enabled and
+// the endpoint was unavailable. It returns true if the request was buffered.
+func (h *Handler) bufferForward(ctx context.Context, r *forwardRequest, err error) bool {
+	// Requests canceled by the remote writer are retried by the remote writer itself.
+	if h.options.ForwardBuffer == nil || ctx.Err() != nil || !isRetryableForwardErr(err) {
+		return false
+	}
+	if err := h.options.ForwardBuffer.Add(r); err != nil {
+		level.Warn(h.logger).Log("msg", "failed to buffer forward request", "endpoint", r.Endpoint, "err", err)
+		return false
+	}
+	h.forwardRequestsBuffered.Inc()
+	return true
+}
+
+// RunForwardBuffer retries buffered forward requests every interval until the context is canceled. It returns
+// immediately if the forward buffer is not enabled.
+func (h *Handler) RunForwardBuffer(ctx context.Context, interval time.Duration) error {
+	if h.options.ForwardBuffer == nil {
+		return nil
+	}
+	return h.options.ForwardBuffer.Run(ctx, interval, func(ctx context.Context, r *forwardRequest) error {
+		ctx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		return h.send(ctx, r)
+	})
+}
+
 // replicate replicates a write request to (replication-factor) nodes
 // selected by the tenant and time series.
 // The function only returns when all replication requests have finished
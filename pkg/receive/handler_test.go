@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"sync"
 	"testing"
@@ -11,8 +12,11 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage"
 	terrors "github.com/prometheus/prometheus/tsdb/errors"
@@ -142,7 +146,7 @@ func newHandlerHashring(appendables []*fakeAppendable, replicationFactor uint64)
 			TenantHeader:      DefaultTenantHeader,
 			ReplicaHeader:     DefaultReplicaHeader,
 			ReplicationFactor: replicationFactor,
-			Writer:            NewWriter(log.NewNopLogger(), appendables[i]),
+			Writer:            NewWriter(log.NewNopLogger(), appendables[i], nil, nil),
 		})
 		handlers = append(handlers, h)
 		ts := httptest.NewServer(h.router)
@@ -496,6 +500,96 @@ func TestReceive(t *testing.T) {
 	}
 }
 
+func TestReceiveContentEncoding(t *testing.T) {
+	wreq := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "foo", Value: "bar"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1}},
+			},
+		},
+	}
+	buf, err := proto.Marshal(wreq)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling request: %v", err)
+	}
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating zstd encoder: %v", err)
+	}
+	zstdBuf := enc.EncodeAll(buf, nil)
+	if err := enc.Close(); err != nil {
+		t.Fatalf("unexpected error closing zstd encoder: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+		samples  int
+	}{
+		{
+			name:    "no encoding defaults to snappy",
+			body:    snappy.Encode(nil, buf),
+			status:  http.StatusOK,
+			samples: 1,
+		},
+		{
+			name:     "snappy",
+			encoding: "snappy",
+			body:     snappy.Encode(nil, buf),
+			status:   http.StatusOK,
+			samples:  1,
+		},
+		{
+			name:     "zstd",
+			encoding: "zstd",
+			body:     zstdBuf,
+			status:   http.StatusOK,
+			samples:  1,
+		},
+		{
+			name:     "zstd with snappy body",
+			encoding: "zstd",
+			body:     snappy.Encode(nil, buf),
+			status:   http.StatusBadRequest,
+		},
+		{
+			name:     "unsupported encoding",
+			encoding: "gzip",
+			body:     buf,
+			status:   http.StatusUnsupportedMediaType,
+		},
+	} {
+		a := &fakeAppendable{appender: newFakeAppender(nil, nil, nil, nil)}
+		handlers, _, close := newHandlerHashring([]*fakeAppendable{a}, 1)
+
+		req, err := http.NewRequest("POST", handlers[0].options.Endpoint, bytes.NewBuffer(tc.body))
+		if err != nil {
+			t.Fatalf("test case %q: unexpected error creating request: %v", tc.name, err)
+		}
+		if tc.encoding != "" {
+			req.Header.Set("Content-Encoding", tc.encoding)
+		}
+		res, err := handlers[0].client.Do(req)
+		if err != nil {
+			t.Fatalf("test case %q: unexpected error making request: %v", tc.name, err)
+		}
+		close()
+
+		if res.StatusCode != tc.status {
+			t.Errorf("test case %q: expected status %d, got %d", tc.name, tc.status, res.StatusCode)
+		}
+		if got := res.Header.Get("Accept-Encoding"); got != supportedEncodings {
+			t.Errorf("test case %q: expected Accept-Encoding %q, got %q", tc.name, supportedEncodings, got)
+		}
+		if got := len(a.appender.(*fakeAppender).samples[`{foo="bar"}`]); got != tc.samples {
+			t.Errorf("test case %q: expected %d samples, got %d", tc.name, tc.samples, got)
+		}
+	}
+}
+
 // endpointHit is a helper to determine if a given endpoint in a hashring would be selected
 // for a given time series, tenant, and replication factor.
 func endpointHit(t *testing.T, h Hashring, rf uint64, endpoint, tenant string, timeSeries *prompb.TimeSeries) bool {
@@ -545,3 +639,58 @@ func makeRequest(h *Handler, tenant string, wreq *prompb.WriteRequest) (int, err
 	}
 	return res.StatusCode, nil
 }
+
+func TestDecodeMaxSize(t *testing.T) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating zstd encoder: %v", err)
+	}
+	defer enc.Close()
+
+	for _, tc := range []struct {
+		size int
+		ok   bool
+	}{
+		{size: MaxDecodedRequestSize, ok: true},
+		{size: MaxDecodedRequestSize + 1, ok: false},
+	} {
+		buf := make([]byte, tc.size)
+		for encoding, body := range map[string][]byte{
+			EncodingSnappy: snappy.Encode(nil, buf),
+			EncodingZstd:   enc.EncodeAll(buf, nil),
+		} {
+			res, err := decode(encoding, body)
+			if tc.ok && (err != nil || len(res) != tc.size) {
+				t.Errorf("encoding %q, size %d: unexpected error %v or decoded size %d", encoding, tc.size, err, len(res))
+			}
+			if !tc.ok && err == nil {
+				t.Errorf("encoding %q, size %d: expected error decoding request exceeding maximum size", encoding, tc.size)
+			}
+		}
+	}
+}
+
+func TestRelabelSeries(t *testing.T) {
+	cfgs := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__name__"},
+			Regex:        relabel.MustNewRegexp("dropped"),
+			Action:       relabel.Drop,
+		},
+		{
+			Regex:  relabel.MustNewRegexp("replica"),
+			Action: relabel.LabelDrop,
+		},
+	}
+	wreq := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{Labels: []prompb.Label{{Name: "__name__", Value: "dropped"}}},
+		{Labels: []prompb.Label{{Name: "__name__", Value: "kept"}, {Name: "replica", Value: "a"}}},
+		{Labels: []prompb.Label{{Name: "replica", Value: "a"}}},
+	}}
+	relabelSeries(cfgs, wreq)
+
+	exp := []prompb.TimeSeries{{Labels: []prompb.Label{{Name: "__name__", Value: "kept"}}}}
+	if !reflect.DeepEqual(exp, wreq.Timeseries) {
+		t.Errorf("expected series %v, got %v", exp, wreq.Timeseries)
+	}
+}
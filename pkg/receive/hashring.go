@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 
 	"github.com/prometheus/prometheus/prompb"
@@ -85,12 +86,96 @@ func (s simpleHashring) GetN(tenant string, ts *prompb.TimeSeries, n uint64) (st
 	return s[(hash(tenant, ts)+n)%uint64(len(s))], nil
 }
 
+// numVirtualNodes is the number of sections each endpoint owns on a ketama hashring.
+// More sections spread time series more evenly between endpoints.
+const numVirtualNodes = 160
+
+type section struct {
+	hash     uint64
+	endpoint int
+}
+
+// ketamaHashring represents a group of nodes handling write requests,
+// placing time series on them using consistent hashing.
+type ketamaHashring struct {
+	endpoints []string
+	sections  []section
+}
+
+func newKetamaHashring(endpoints []string) *ketamaHashring {
+	h := &ketamaHashring{
+		endpoints: endpoints,
+		sections:  make([]section, 0, len(endpoints)*numVirtualNodes),
+	}
+	b := make([]byte, 0, 256)
+	for i, e := range endpoints {
+		for j := 0; j < numVirtualNodes; j++ {
+			b = append(b[:0], e...)
+			b = append(b, sep)
+			b = strconv.AppendInt(b, int64(j), 10)
+			h.sections = append(h.sections, section{hash: xxhash.Sum64(b), endpoint: i})
+		}
+	}
+	sort.Slice(h.sections, func(i, j int) bool {
+		if h.sections[i].hash == h.sections[j].hash {
+			return h.sections[i].endpoint < h.sections[j].endpoint
+		}
+		return h.sections[i].hash < h.sections[j].hash
+	})
+	return h
+}
+
+// Get returns a target to handle the given tenant and time series.
+func (k *ketamaHashring) Get(tenant string, ts *prompb.TimeSeries) (string, error) {
+	return k.GetN(tenant, ts, 0)
+}
+
+// GetN returns the nth target to handle the given tenant and time series.
+// Targets are distinct endpoints found by walking the ring from the section the hash falls into.
+func (k *ketamaHashring) GetN(tenant string, ts *prompb.TimeSeries, n uint64) (string, error) {
+	if n >= uint64(len(k.endpoints)) {
+		return "", &insufficientNodesError{have: uint64(len(k.endpoints)), want: n + 1}
+	}
+	v := hash(tenant, ts)
+	i := sort.Search(len(k.sections), func(i int) bool {
+		return k.sections[i].hash >= v
+	})
+
+	var (
+		seen  = make([]bool, len(k.endpoints))
+		found uint64
+	)
+	for j := 0; j < len(k.sections); j++ {
+		s := k.sections[(i+j)%len(k.sections)]
+		if seen[s.endpoint] {
+			continue
+		}
+		if found == n {
+			return k.endpoints[s.endpoint], nil
+		}
+		seen[s.endpoint] = true
+		found++
+	}
+	return "", &insufficientNodesError{have: found, want: n + 1}
+}
+
+// newHashring creates a hashring for the endpoints using the given algorithm.
+func newHashring(algorithm HashringAlgorithm, endpoints []string) Hashring {
+	switch algorithm {
+	case AlgorithmKetama:
+		return newKetamaHashring(endpoints)
+	default:
+		return simpleHashring(endpoints)
+	}
+}
+
 // multiHashring represents a set of hashrings.
 // Which hashring to use for a tenant is determined
 // by the tenants field of the hashring configuration.
 type multiHashring struct {
 	cache      map[string]Hashring
 	hashrings  []Hashring
+	endpoints  [][]string
 	tenantSets []map[string]struct{}
 
 	// We need a mutex to guard concurrent access
@@ -136,6 +221,27 @@ func (m *multiHashring) GetN(tenant string, ts *prompb.TimeSeries, n uint64) (st
 	return "", errors.New("no matching hashring to handle tenant")
 }
 
+// Endpoints returns the endpoints of the hashring handling the given tenant.
+func (m *multiHashring) Endpoints(tenant string) ([]string, error) {
+	for i, t := range m.tenantSets {
+		if _, ok := t[tenant]; ok || t == nil {
+			return m.endpoints[i], nil
+		}
+	}
+	return nil, errors.New("no matching hashring to handle tenant")
+}
+
+// Endpoints returns the single node.
+func (s SingleNodeHashring) Endpoints(_ string) ([]string, error) {
+	return []string{string(s)}, nil
+}
+
+// TenantEndpoints returns the endpoints of the hashring of the configuration handling the given tenant,
+// as selected by the tenants field of each hashring.
+func TenantEndpoints(cfg []HashringConfig, tenant string) ([]string, error) {
+	return newMultiHashring(cfg).(*multiHashring).Endpoints(tenant)
+}
+
 // newMultiHashring creates a multi-tenant hashring for a given slice of
 // groups.
 // Which hashring to use for a tenant is determined
@@ -146,7 +252,8 @@ func newMultiHashring(cfg []HashringConfig) Hashring {
 	}
 
 	for _, h := range cfg {
-		m.hashrings = append(m.hashrings, simpleHashring(h.Endpoints))
+		m.hashrings = append(m.hashrings, newHashring(h.Algorithm, h.Endpoints))
+		m.endpoints = append(m.endpoints, h.Endpoints)
 		var t map[string]struct{}
 		if len(h.Tenants) != 0 {
 			t = make(map[string]struct{})
@@ -166,7 +273,8 @@ func newMultiHashring(cfg []HashringConfig) Hashring {
 // Which hashring to use for a tenant is determined
 // by the tenants field of the hashring configuration.
 // The updates chan is closed before exiting.
-func HashringFromConfig(ctx context.Context, updates chan<- Hashring, cw *ConfigWatcher) {
+// If given, onConfig is called with each configuration before its hashring is sent.
+func HashringFromConfig(ctx context.Context, updates chan<- Hashring, cw *ConfigWatcher, onConfig func([]HashringConfig)) {
 	go cw.Run(ctx)
 	defer close(updates)
 
@@ -176,6 +284,9 @@ func HashringFromConfig(ctx context.Context, updates chan<- Hashring, cw *Config
 			if !ok {
 				return
 			}
+			if onConfig != nil {
+				onConfig(cfg)
+			}
 			updates <- newMultiHashring(cfg)
 		case <-ctx.Done():
 			return
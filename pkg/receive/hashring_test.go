@@ -1,6 +1,7 @@
 package receive
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/prometheus/prometheus/prompb"
@@ -132,6 +133,25 @@ func TestHashringGet(t *testing.T) {
 			},
 			tenant: "tenant1",
 		},
+		{
+			name: "many nodes ketama",
+			cfg: []HashringConfig{
+				{
+					Endpoints: []string{"node1", "node2", "node3"},
+					Tenants:   []string{"tenant1"},
+					Algorithm: AlgorithmKetama,
+				},
+				{
+					Endpoints: []string{"node4", "node5", "node6"},
+				},
+			},
+			nodes: map[string]struct{}{
+				"node1": struct{}{},
+				"node2": struct{}{},
+				"node3": struct{}{},
+			},
+			tenant: "tenant1",
+		},
 		{
 			name: "many nodes default",
 			cfg: []HashringConfig{
@@ -167,3 +187,72 @@ func TestHashringGet(t *testing.T) {
 		}
 	}
 }
+
+func TestKetamaHashringGetN(t *testing.T) {
+	ts := &prompb.TimeSeries{
+		Labels: []prompb.Label{
+			{
+				Name:  "foo",
+				Value: "bar",
+			},
+		},
+	}
+
+	h := newKetamaHashring([]string{"node1", "node2", "node3"})
+	nodes := map[string]struct{}{}
+	for n := uint64(0); n < 3; n++ {
+		node, err := h.GetN("tenant1", ts, n)
+		if err != nil {
+			t.Fatalf("unexpected error for n=%d: %v", n, err)
+		}
+		nodes[node] = struct{}{}
+	}
+	if len(nodes) != 3 {
+		t.Errorf("expected 3 distinct nodes, got %v", nodes)
+	}
+	if _, err := h.GetN("tenant1", ts, 3); err == nil {
+		t.Errorf("expected error for n=3")
+	}
+	if _, err := newKetamaHashring(nil).Get("tenant1", ts); err == nil {
+		t.Errorf("expected error for empty hashring")
+	}
+}
+
+func TestKetamaHashringScaleUp(t *testing.T) {
+	var (
+		before = newKetamaHashring([]string{"node1", "node2", "node3"})
+		after  = newKetamaHashring([]string{"node1", "node2", "node3", "node4"})
+		moved  int
+		total  = 10000
+	)
+	for i := 0; i < total; i++ {
+		ts := &prompb.TimeSeries{
+			Labels: []prompb.Label{
+				{
+					Name:  "series",
+					Value: strconv.Itoa(i),
+				},
+			},
+		}
+		b, err := before.Get("tenant1", ts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		a, err := after.Get("tenant1", ts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if a == b {
+			continue
+		}
+		// Series only move to the added node.
+		if a != "node4" {
+			t.Fatalf("series %d moved from %q to %q", i, b, a)
+		}
+		moved++
+	}
+	// The added node takes over about a quarter of series.
+	if moved < total/8 || moved > total*3/8 {
+		t.Errorf("expected about %d series to move, moved %d", total/4, moved)
+	}
+}
@@ -0,0 +1,136 @@
+package receive
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"gopkg.in/yaml.v2"
+)
+
+// TenantHintsConfig is the configuration of preferences of a tenant of a hashring for processing of its blocks by the
+// compactor.
+type TenantHintsConfig struct {
+	// Hashring the hints apply to, empty for the default hashring.
+	Hashring string `yaml:"hashring"`
+	Tenant   string `yaml:"tenant"`
+	// Retention of blocks of raw, 5m and 1h resolution. Zero means the retention of the compactor.
+	RetentionRaw model.Duration `yaml:"retention_raw"`
+	Retention5m  model.Duration `yaml:"retention_5m"`
+	Retention1h  model.Duration `yaml:"retention_1h"`
+	// DisableDownsampling disables downsampling of blocks of the tenant.
+	DisableDownsampling bool `yaml:"disable_downsampling"`
+}
+
+// TenantHintsKey identifies the hints of a tenant of a hashring.
+type TenantHintsKey struct {
+	Hashring string
+	Tenant   string
+}
+
+// TenantHints are hints written into meta.json of blocks by hashring and tenant.
+type TenantHints map[TenantHintsKey]*metadata.ThanosHints
+
+// ParseTenantHints parses the YAML list of TenantHintsConfig.
+func ParseTenantHints(content []byte) (TenantHints, error) {
+	var cfgs []TenantHintsConfig
+	if err := yaml.UnmarshalStrict(content, &cfgs); err != nil {
+		return nil, errors.Wrap(err, "parse tenant hints")
+	}
+
+	hints := make(TenantHints, len(cfgs))
+	for _, c := range cfgs {
+		if c.Tenant == "" {
+			return nil, errors.New("tenant hints without tenant")
+		}
+		k := TenantHintsKey{Hashring: c.Hashring, Tenant: c.Tenant}
+		if _, ok := hints[k]; ok {
+			return nil, errors.Errorf("duplicate hints of tenant %q of hashring %q", c.Tenant, c.Hashring)
+		}
+		hints[k] = &metadata.ThanosHints{
+			Tenant:              c.Tenant,
+			RetentionRaw:        int64(time.Duration(c.RetentionRaw) / time.Millisecond),
+			Retention5m:         int64(time.Duration(c.Retention5m) / time.Millisecond),
+			Retention1h:         int64(time.Duration(c.Retention1h) / time.Millisecond),
+			DisableDownsampling: c.DisableDownsampling,
+		}
+	}
+	return hints, nil
+}
+
+// ForEndpoint returns the hints of the tenant of the hashrings the endpoint belongs to, nil if none are configured.
+// Blocks of an endpoint hold the series of all tenants of its hashrings, so it fails if hints of multiple tenants are
+// configured for them, or if the tenant of the hints is not a tenant of the hashring.
+func (h TenantHints) ForEndpoint(cfgs []HashringConfig, endpoint string) (*metadata.ThanosHints, error) {
+	var (
+		res     *metadata.ThanosHints
+		tenants []string
+	)
+	for _, c := range cfgs {
+		if !contains(c.Endpoints, endpoint) {
+			continue
+		}
+		for k, hints := range h {
+			if k.Hashring != c.Hashring {
+				continue
+			}
+			if len(c.Tenants) > 0 && !contains(c.Tenants, k.Tenant) {
+				return nil, errors.Errorf("tenant %q of hints is not a tenant of hashring %q", k.Tenant, c.Hashring)
+			}
+			if res == nil || res.Tenant != hints.Tenant {
+				tenants = append(tenants, k.Tenant)
+			}
+			res = hints
+		}
+	}
+	if len(tenants) > 1 {
+		sort.Strings(tenants)
+		return nil, errors.Errorf("hints of multiple tenants %v configured for hashrings of endpoint %s", tenants, endpoint)
+	}
+	return res, nil
+}
+
+// EndpointHints tracks the hints of the tenant of the hashrings of the local endpoint across hashring changes.
+type EndpointHints struct {
+	logger   log.Logger
+	hints    TenantHints
+	endpoint string
+
+	mtx     sync.RWMutex
+	current *metadata.ThanosHints
+}
+
+// NewEndpointHints returns EndpointHints of the given endpoint. It has no hints until updated with the configuration
+// of hashrings.
+func NewEndpointHints(logger log.Logger, hints TenantHints, endpoint string) *EndpointHints {
+	return &EndpointHints{logger: logger, hints: hints, endpoint: endpoint}
+}
+
+// Update sets the hints for the given configuration of hashrings. Blocks are uploaded without hints if the hints are
+// ambiguous for the configuration.
+func (e *EndpointHints) Update(cfgs []HashringConfig) {
+	h, err := e.hints.ForEndpoint(cfgs, e.endpoint)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "invalid tenant hints for hashrings of the node, uploading blocks without hints", "err", err)
+	} else if h == nil {
+		level.Info(e.logger).Log("msg", "no tenant hints configured for hashrings of the node, uploading blocks without hints")
+	} else {
+		level.Info(e.logger).Log("msg", "attaching hints of tenant to uploaded blocks", "tenant", h.Tenant)
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.current = h
+}
+
+// Get returns the current hints, nil if none apply.
+func (e *EndpointHints) Get() *metadata.ThanosHints {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.current
+}
@@ -0,0 +1,93 @@
+package receive
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseTenantHints(t *testing.T) {
+	hints, err := ParseTenantHints([]byte(`
+- hashring: a
+  tenant: a
+  retention_raw: 30d
+  retention_1h: 1y
+- tenant: b
+  disable_downsampling: true
+- hashring: c
+  tenant: b
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, TenantHints{
+		{Hashring: "a", Tenant: "a"}: {Tenant: "a", RetentionRaw: 30 * 24 * 60 * 60 * 1000, Retention1h: 365 * 24 * 60 * 60 * 1000},
+		{Tenant: "b"}:                {Tenant: "b", DisableDownsampling: true},
+		{Hashring: "c", Tenant: "b"}: {Tenant: "b"},
+	}, hints)
+
+	_, err = ParseTenantHints([]byte(`
+- tenant: a
+- tenant: a
+`))
+	testutil.NotOk(t, err)
+
+	_, err = ParseTenantHints([]byte(`- retention_raw: 30d`))
+	testutil.NotOk(t, err)
+
+	_, err = ParseTenantHints([]byte(`- tenant: a
+  retention: 30d`))
+	testutil.NotOk(t, err)
+}
+
+func TestTenantHints_ForEndpoint(t *testing.T) {
+	hints := TenantHints{
+		{Hashring: "a", Tenant: "a"}: {Tenant: "a", DisableDownsampling: true},
+		{Hashring: "b", Tenant: "b"}: {Tenant: "b", RetentionRaw: 1000},
+		{Hashring: "c", Tenant: "c"}: {Tenant: "c"},
+		{Hashring: "c", Tenant: "d"}: {Tenant: "d"},
+	}
+	cfgs := []HashringConfig{
+		{Hashring: "a", Tenants: []string{"a"}, Endpoints: []string{"node-1", "node-2"}},
+		{Hashring: "b", Endpoints: []string{"node-3"}},
+		{Hashring: "c", Endpoints: []string{"node-4"}},
+		{Hashring: "e", Tenants: []string{"e"}, Endpoints: []string{"node-5"}},
+	}
+
+	for _, tcase := range []struct {
+		endpoint string
+		exp      *metadata.ThanosHints
+		err      bool
+	}{
+		{endpoint: "node-2", exp: &metadata.ThanosHints{Tenant: "a", DisableDownsampling: true}},
+		{endpoint: "node-3", exp: &metadata.ThanosHints{Tenant: "b", RetentionRaw: 1000}},
+		// Blocks of the hashring hold series of both tenants with hints.
+		{endpoint: "node-4", err: true},
+		{endpoint: "node-5"},
+		{endpoint: "node-6"},
+	} {
+		t.Run(tcase.endpoint, func(t *testing.T) {
+			h, err := hints.ForEndpoint(cfgs, tcase.endpoint)
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.exp, h)
+		})
+	}
+
+	// Hints of a tenant not routed to the hashring are invalid.
+	_, err := TenantHints{{Hashring: "a", Tenant: "b"}: {Tenant: "b"}}.ForEndpoint(cfgs, "node-1")
+	testutil.NotOk(t, err)
+
+	// Hints follow the node across hashring changes.
+	e := NewEndpointHints(log.NewNopLogger(), hints, "node-1")
+	testutil.Assert(t, e.Get() == nil, "no hints before the first update")
+	e.Update(cfgs)
+	testutil.Equals(t, &metadata.ThanosHints{Tenant: "a", DisableDownsampling: true}, e.Get())
+	e.Update([]HashringConfig{{Hashring: "b", Endpoints: []string{"node-1"}}})
+	testutil.Equals(t, &metadata.ThanosHints{Tenant: "b", RetentionRaw: 1000}, e.Get())
+	e.Update([]HashringConfig{{Hashring: "c", Endpoints: []string{"node-1"}}})
+	testutil.Assert(t, e.Get() == nil, "no hints for ambiguous tenant")
+}
@@ -0,0 +1,54 @@
+package receive
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LimitsConfig is the configuration of limits of tenants, reloadable without restarting receive.
+type LimitsConfig struct {
+	// ReadMaxConcurrencyPerTenant limits concurrent series requests per tenant. Zero means no limit.
+	ReadMaxConcurrencyPerTenant int `yaml:"read_max_concurrency_per_tenant"`
+	// MetadataMaxTenants and MetadataMaxMetricsPerTenant limit stored metadata. Zero means no limit.
+	MetadataMaxTenants          int `yaml:"metadata_max_tenants"`
+	MetadataMaxMetricsPerTenant int `yaml:"metadata_max_metrics_per_tenant"`
+	// Tenants override the limits of single tenants.
+	Tenants map[string]TenantLimitsConfig `yaml:"tenants"`
+}
+
+// TenantLimitsConfig are limits of a single tenant, taking precedence over the limits of all tenants.
+type TenantLimitsConfig struct {
+	// ReadMaxConcurrency limits concurrent series requests of the tenant. Zero means no limit.
+	ReadMaxConcurrency int `yaml:"read_max_concurrency"`
+}
+
+// ParseLimitsConfig parses the YAML limits configuration. Limits not given in the configuration keep the values of
+// defaults.
+func ParseLimitsConfig(content []byte, defaults LimitsConfig) (LimitsConfig, error) {
+	cfg := defaults
+	if err := yaml.UnmarshalStrict(content, &cfg); err != nil {
+		return LimitsConfig{}, errors.Wrap(err, "parse limits configuration")
+	}
+	if cfg.ReadMaxConcurrencyPerTenant < 0 || cfg.MetadataMaxTenants < 0 || cfg.MetadataMaxMetricsPerTenant < 0 {
+		return LimitsConfig{}, errors.New("limits must not be negative")
+	}
+	for tenant, l := range cfg.Tenants {
+		if l.ReadMaxConcurrency < 0 {
+			return LimitsConfig{}, errors.Errorf("limits of tenant %q must not be negative", tenant)
+		}
+	}
+	return cfg, nil
+}
+
+// ReadMaxConcurrency returns the limit of concurrent series requests of the tenant.
+func (c LimitsConfig) ReadMaxConcurrency(tenant string) int {
+	if l, ok := c.Tenants[tenant]; ok {
+		return l.ReadMaxConcurrency
+	}
+	return c.ReadMaxConcurrencyPerTenant
+}
+
+// MetadataLimits returns the limits of stored metadata.
+func (c LimitsConfig) MetadataLimits() MetadataLimits {
+	return MetadataLimits{MaxTenants: c.MetadataMaxTenants, MaxMetricsPerTenant: c.MetadataMaxMetricsPerTenant}
+}
@@ -0,0 +1,33 @@
+package receive
+
+import (
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseLimitsConfig(t *testing.T) {
+	defaults := LimitsConfig{ReadMaxConcurrencyPerTenant: 5, MetadataMaxTenants: 10, MetadataMaxMetricsPerTenant: 100}
+
+	// Limits not given keep the defaults.
+	cfg, err := ParseLimitsConfig([]byte(`
+metadata_max_tenants: 20
+tenants:
+  a:
+    read_max_concurrency: 1
+  b:
+    read_max_concurrency: 0
+`), defaults)
+	testutil.Ok(t, err)
+	testutil.Equals(t, MetadataLimits{MaxTenants: 20, MaxMetricsPerTenant: 100}, cfg.MetadataLimits())
+	testutil.Equals(t, 1, cfg.ReadMaxConcurrency("a"))
+	testutil.Equals(t, 0, cfg.ReadMaxConcurrency("b"))
+	testutil.Equals(t, 5, cfg.ReadMaxConcurrency("c"))
+
+	_, err = ParseLimitsConfig([]byte(`read_max_concurrency: 1`), defaults)
+	testutil.NotOk(t, err)
+	_, err = ParseLimitsConfig([]byte(`metadata_max_tenants: -1`), defaults)
+	testutil.NotOk(t, err)
+	_, err = ParseLimitsConfig([]byte("tenants:\n  a:\n    read_max_concurrency: -1\n"), defaults)
+	testutil.NotOk(t, err)
+}
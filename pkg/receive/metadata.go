@@ -0,0 +1,284 @@
+package receive
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// MetadataFilename is the name of the file in the TSDB directory metric metadata is persisted in.
+const MetadataFilename = "thanos.metadata.json"
+
+// maxMetadataPerMetric is the maximum number of distinct metadata kept per metric, e.g. of targets exposing different
+// HELP texts. The least recently received metadata is dropped first.
+const maxMetadataPerMetric = 10
+
+// MetricMetadata is the metadata of a metric, i.e. its type, HELP and unit, as served by the metadata API.
+type MetricMetadata struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+}
+
+type storedMetadata struct {
+	MetricMetadata
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// MetadataLimits bound the memory used by a MetadataStore, as tenants and metric names are given by clients. Zero
+// values are unlimited.
+type MetadataLimits struct {
+	// MaxTenants is the maximum number of tenants metadata is kept for. Metadata of further tenants is dropped.
+	MaxTenants int
+	// MaxMetricsPerTenant is the maximum number of metrics metadata is kept for per tenant. Metadata of further metrics
+	// of the tenant is dropped.
+	MaxMetricsPerTenant int
+}
+
+// MetadataStore holds metric metadata received with remote write requests per tenant, so HELP and TYPE information of
+// push-based pipelines, e.g. Prometheus in agent mode, is not lost. It is persisted in the TSDB directory, so metadata
+// received before a restart is still served. Metadata not received for longer than the retention is dropped on each
+// persist, freeing room for other tenants and metrics within the limits.
+type MetadataStore struct {
+	path      string
+	retention time.Duration
+	now       func() time.Time
+
+	mtx      sync.RWMutex
+	limits   MetadataLimits
+	metadata map[string]map[string][]storedMetadata
+	dirty    bool
+
+	dropped *prometheus.CounterVec
+}
+
+// NewMetadataStore returns a MetadataStore persisted in the given directory, loading previously persisted metadata.
+// Zero retention keeps metadata forever.
+func NewMetadataStore(reg prometheus.Registerer, dir string, retention time.Duration, limits MetadataLimits) (*MetadataStore, error) {
+	s := &MetadataStore{
+		path:      filepath.Join(dir, MetadataFilename),
+		retention: retention,
+		limits:    limits,
+		now:       time.Now,
+		metadata:  map[string]map[string][]storedMetadata{},
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_receive_metadata_dropped_total",
+			Help: "Total number of received metric metadata dropped as the limit of tenants or metrics per tenant was reached.",
+		}, []string{"reason"}),
+	}
+	if reg != nil {
+		reg.MustRegister(s.dropped)
+	}
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read metadata")
+	}
+	if err := json.Unmarshal(b, &s.metadata); err != nil {
+		return nil, errors.Wrapf(err, "decode metadata %s", s.path)
+	}
+	return s, nil
+}
+
+// SetLimits replaces the limits of stored metadata. Metadata stored already is kept, even if above the limits.
+func (s *MetadataStore) SetLimits(limits MetadataLimits) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.limits = limits
+}
+
+// Add records metadata of metrics of the tenant by metric name.
+func (s *MetadataStore) Add(tenant string, metadata map[string]MetricMetadata) {
+	if len(metadata) == 0 {
+		return
+	}
+	now := s.now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	metrics, ok := s.metadata[tenant]
+	if !ok {
+		if s.limits.MaxTenants > 0 && len(s.metadata) >= s.limits.MaxTenants {
+			s.dropped.WithLabelValues("tenant_limit").Add(float64(len(metadata)))
+			return
+		}
+		metrics = map[string][]storedMetadata{}
+		s.metadata[tenant] = metrics
+	}
+	for metric, m := range metadata {
+		stored, ok := metrics[metric]
+		if !ok && s.limits.MaxMetricsPerTenant > 0 && len(metrics) >= s.limits.MaxMetricsPerTenant {
+			s.dropped.WithLabelValues("metric_limit").Inc()
+			continue
+		}
+		i := -1
+		for j := range stored {
+			if stored[j].MetricMetadata == m {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			stored = append(stored, storedMetadata{MetricMetadata: m, LastSeen: now})
+			if len(stored) > maxMetadataPerMetric {
+				sort.Slice(stored, func(i, j int) bool { return stored[i].LastSeen.After(stored[j].LastSeen) })
+				stored = stored[:maxMetadataPerMetric]
+			}
+			metrics[metric] = stored
+			s.dirty = true
+			continue
+		}
+		// Metadata is sent periodically, it does not need to be more precise than a minute.
+		if now.Sub(stored[i].LastSeen) >= time.Minute {
+			stored[i].LastSeen = now
+			s.dirty = true
+		}
+	}
+}
+
+// Metadata returns the metadata of metrics of the tenant by metric name, of the given metric only if not empty, and of
+// at most limit metrics if limit is positive.
+func (s *MetadataStore) Metadata(tenant, metric string, limit int) map[string][]MetricMetadata {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	metrics := s.metadata[tenant]
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		if metric == "" || name == metric {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	res := map[string][]MetricMetadata{}
+	for _, name := range names {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+		for _, m := range metrics[name] {
+			if s.retention > 0 && s.now().Sub(m.LastSeen) > s.retention {
+				continue
+			}
+			res[name] = append(res[name], m.MetricMetadata)
+		}
+	}
+	return res
+}
+
+// Persist writes the metadata to its file if it changed, dropping metadata not received for longer than the retention.
+func (s *MetadataStore) Persist() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.expire()
+	if !s.dirty {
+		return nil
+	}
+	b, err := json.Marshal(s.metadata)
+	if err != nil {
+		return errors.Wrap(err, "encode metadata")
+	}
+
+	// Make any changes to the file appear atomic.
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write metadata file")
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.Wrap(err, "rename metadata file")
+	}
+	s.dirty = false
+	return nil
+}
+
+// expire drops metadata not received for longer than the retention, and metrics and tenants without metadata left.
+func (s *MetadataStore) expire() {
+	if s.retention <= 0 {
+		return
+	}
+	now := s.now()
+	for tenant, metrics := range s.metadata {
+		for metric, stored := range metrics {
+			kept := stored[:0]
+			for _, m := range stored {
+				if now.Sub(m.LastSeen) <= s.retention {
+					kept = append(kept, m)
+				}
+			}
+			if len(kept) < len(stored) {
+				s.dirty = true
+			}
+			if len(kept) == 0 {
+				delete(metrics, metric)
+				continue
+			}
+			metrics[metric] = kept
+		}
+		if len(metrics) == 0 {
+			delete(s.metadata, tenant)
+			s.dirty = true
+		}
+	}
+}
+
+// writeRequestMetadata is the metadata field of remote write requests of newer Prometheus versions, which the
+// vendored WriteRequest does not know. It is decoded from the unrecognized fields of the request.
+type writeRequestMetadata struct {
+	Metadata []*metricMetadataProto `protobuf:"bytes,3,rep,name=metadata,proto3"`
+}
+
+func (m *writeRequestMetadata) Reset()         { *m = writeRequestMetadata{} }
+func (m *writeRequestMetadata) String() string { return proto.CompactTextString(m) }
+func (*writeRequestMetadata) ProtoMessage()    {}
+
+// metricMetadataProto is the MetricMetadata message of the Prometheus remote write protocol.
+type metricMetadataProto struct {
+	Type             int32  `protobuf:"varint,1,opt,name=type,proto3"`
+	MetricFamilyName string `protobuf:"bytes,2,opt,name=metric_family_name,json=metricFamilyName,proto3"`
+	Help             string `protobuf:"bytes,4,opt,name=help,proto3"`
+	Unit             string `protobuf:"bytes,5,opt,name=unit,proto3"`
+}
+
+func (m *metricMetadataProto) Reset()         { *m = metricMetadataProto{} }
+func (m *metricMetadataProto) String() string { return proto.CompactTextString(m) }
+func (*metricMetadataProto) ProtoMessage()    {}
+
+// metricTypes are the names of the MetricType enum values of the Prometheus remote write protocol, as served by the
+// metadata API.
+var metricTypes = []string{"unknown", "counter", "gauge", "histogram", "gaugehistogram", "summary", "info", "stateset"}
+
+// requestMetadata returns the metric metadata of the remote write request by metric name.
+func requestMetadata(wreq *prompb.WriteRequest) (map[string]MetricMetadata, error) {
+	if len(wreq.XXX_unrecognized) == 0 {
+		return nil, nil
+	}
+	var md writeRequestMetadata
+	if err := proto.Unmarshal(wreq.XXX_unrecognized, &md); err != nil {
+		return nil, errors.Wrap(err, "decode metadata")
+	}
+	res := make(map[string]MetricMetadata, len(md.Metadata))
+	for _, m := range md.Metadata {
+		if m.MetricFamilyName == "" {
+			continue
+		}
+		typ := metricTypes[0]
+		if m.Type > 0 && int(m.Type) < len(metricTypes) {
+			typ = metricTypes[m.Type]
+		}
+		res[m.MetricFamilyName] = MetricMetadata{Type: typ, Help: m.Help, Unit: m.Unit}
+	}
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package receive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// testWriteRequest is a remote write request of newer Prometheus versions, with metadata.
+type testWriteRequest struct {
+	Timeseries []prompb.TimeSeries    `protobuf:"bytes,1,rep,name=timeseries,proto3"`
+	Metadata   []*metricMetadataProto `protobuf:"bytes,3,rep,name=metadata,proto3"`
+}
+
+func (m *testWriteRequest) Reset()         { *m = testWriteRequest{} }
+func (m *testWriteRequest) String() string { return proto.CompactTextString(m) }
+func (*testWriteRequest) ProtoMessage()    {}
+
+func TestRequestMetadata(t *testing.T) {
+	buf, err := proto.Marshal(&testWriteRequest{
+		Timeseries: []prompb.TimeSeries{{Labels: []prompb.Label{{Name: "__name__", Value: "up"}}, Samples: []prompb.Sample{{Value: 1, Timestamp: 1}}}},
+		Metadata: []*metricMetadataProto{
+			{Type: 2, MetricFamilyName: "up", Help: "Target is up."},
+			{Type: 1, MetricFamilyName: "requests_total", Help: "Requests.", Unit: "requests"},
+			{Type: 42, MetricFamilyName: "future"},
+			{Type: 1},
+		},
+	})
+	testutil.Ok(t, err)
+
+	var wreq prompb.WriteRequest
+	testutil.Ok(t, proto.Unmarshal(buf, &wreq))
+	testutil.Equals(t, 1, len(wreq.Timeseries))
+
+	md, err := requestMetadata(&wreq)
+	testutil.Ok(t, err)
+	testutil.Equals(t, map[string]MetricMetadata{
+		"up":             {Type: "gauge", Help: "Target is up."},
+		"requests_total": {Type: "counter", Help: "Requests.", Unit: "requests"},
+		"future":         {Type: "unknown"},
+	}, md)
+
+	// Requests of older Prometheus versions have no metadata.
+	md, err = requestMetadata(&prompb.WriteRequest{Timeseries: wreq.Timeseries})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(md))
+}
+
+func TestMetadataStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata-store")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	now := time.Unix(1000, 0)
+	s, err := NewMetadataStore(nil, dir, time.Hour, MetadataLimits{})
+	testutil.Ok(t, err)
+	s.now = func() time.Time { return now }
+
+	up := MetricMetadata{Type: "gauge", Help: "Target is up."}
+	s.Add("a", map[string]MetricMetadata{"up": up, "old": {Type: "counter"}})
+	now = now.Add(30 * time.Minute)
+	s.Add("a", map[string]MetricMetadata{"up": up})
+	s.Add("b", map[string]MetricMetadata{"up": {Type: "gauge", Help: "Other HELP."}})
+	testutil.Equals(t, map[string][]MetricMetadata{"old": {{Type: "counter"}}, "up": {up}}, s.Metadata("a", "", 0))
+	testutil.Equals(t, map[string][]MetricMetadata{"old": {{Type: "counter"}}}, s.Metadata("a", "", 1))
+	testutil.Equals(t, map[string][]MetricMetadata{"up": {up}}, s.Metadata("a", "up", 0))
+	testutil.Equals(t, map[string][]MetricMetadata{}, s.Metadata("c", "", 0))
+
+	// Distinct metadata of a metric are kept, up to a limit.
+	for i := 0; i < 2*maxMetadataPerMetric; i++ {
+		now = now.Add(time.Second)
+		s.Add("b", map[string]MetricMetadata{"up": {Type: "gauge", Help: fmt.Sprintf("HELP %d.", i)}})
+	}
+	testutil.Equals(t, maxMetadataPerMetric, len(s.Metadata("b", "up", 0)["up"]))
+	testutil.Equals(t, "HELP 19.", s.Metadata("b", "up", 0)["up"][0].Help)
+
+	// Metadata is known after restart until it was not received within the retention.
+	now = now.Add(45 * time.Minute)
+	testutil.Ok(t, s.Persist())
+	s, err = NewMetadataStore(nil, dir, time.Hour, MetadataLimits{})
+	testutil.Ok(t, err)
+	s.now = func() time.Time { return now }
+	testutil.Equals(t, map[string][]MetricMetadata{"up": {up}}, s.Metadata("a", "", 0))
+	testutil.Equals(t, maxMetadataPerMetric, len(s.Metadata("b", "up", 0)["up"]))
+
+	h := NewHandler(nil, &Options{TenantHeader: DefaultTenantHeader, Metadata: s})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metadata?metric=up", nil)
+	req.Header.Set(DefaultTenantHeader, "a")
+	w := httptest.NewRecorder()
+	h.router.ServeHTTP(w, req)
+	testutil.Equals(t, http.StatusOK, w.Code)
+	var resp MetadataResponse
+	testutil.Ok(t, json.NewDecoder(w.Body).Decode(&resp))
+	testutil.Equals(t, MetadataResponse{Status: "success", Data: map[string][]MetricMetadata{"up": {up}}}, resp)
+}
+
+func TestMetadataStore_Limits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata-store-limits")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	now := time.Unix(1000, 0)
+	s, err := NewMetadataStore(nil, dir, time.Hour, MetadataLimits{MaxTenants: 1, MaxMetricsPerTenant: 2})
+	testutil.Ok(t, err)
+	s.now = func() time.Time { return now }
+
+	up := MetricMetadata{Type: "gauge"}
+	s.Add("a", map[string]MetricMetadata{"up": up, "b": up})
+	s.Add("a", map[string]MetricMetadata{"c": up})
+	s.Add("b", map[string]MetricMetadata{"up": up})
+	testutil.Equals(t, map[string][]MetricMetadata{"b": {up}, "up": {up}}, s.Metadata("a", "", 0))
+	testutil.Equals(t, map[string][]MetricMetadata{}, s.Metadata("b", "", 0))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(s.dropped.WithLabelValues("metric_limit")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(s.dropped.WithLabelValues("tenant_limit")))
+
+	// Expired metadata frees room for other metrics and tenants, even if nothing was added meanwhile.
+	now = now.Add(30 * time.Minute)
+	s.Add("a", map[string]MetricMetadata{"up": up})
+	testutil.Ok(t, s.Persist())
+	now = now.Add(45 * time.Minute)
+	testutil.Ok(t, s.Persist())
+	s.Add("a", map[string]MetricMetadata{"c": up})
+	testutil.Equals(t, map[string][]MetricMetadata{"c": {up}, "up": {up}}, s.Metadata("a", "", 0))
+
+	now = now.Add(2 * time.Hour)
+	testutil.Ok(t, s.Persist())
+	s.Add("b", map[string]MetricMetadata{"up": up})
+	testutil.Equals(t, map[string][]MetricMetadata{"up": {up}}, s.Metadata("b", "", 0))
+}
@@ -0,0 +1,124 @@
+package receive
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ReadLimitsOptions configure the read path of receive.
+type ReadLimitsOptions struct {
+	// TenantHeader is the header determining the tenant of write requests. Tenant of series requests is given by
+	// gRPC metadata with the lower-cased header as the key.
+	TenantHeader string
+	// MaxConcurrentPerTenant limits concurrent series requests per tenant until limits are set with SetLimits.
+	// Requests without tenant are limited together. Zero means no limit.
+	MaxConcurrentPerTenant int
+	// Activity, if not nil, is used to respond to series requests of tenants without local data immediately, with a
+	// warning only.
+	Activity *TenantActivity
+}
+
+// ReadLimiter limits series requests to local storage per tenant, so heavy queries of one tenant, or global queries,
+// do not stall ingestion of the receive node, and responds to series requests of tenants without local data without
+// touching the storage.
+type ReadLimiter struct {
+	opts ReadLimitsOptions
+
+	mtx      sync.Mutex
+	limits   LimitsConfig
+	inflight map[string]int
+
+	rejected       prometheus.Counter
+	shortCircuited prometheus.Counter
+}
+
+// NewReadLimiter returns a ReadLimiter.
+func NewReadLimiter(reg prometheus.Registerer, opts ReadLimitsOptions) *ReadLimiter {
+	r := &ReadLimiter{
+		opts:     opts,
+		limits:   LimitsConfig{ReadMaxConcurrencyPerTenant: opts.MaxConcurrentPerTenant},
+		inflight: map[string]int{},
+		rejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_read_rejected_series_requests_total",
+			Help: "Total number of series requests rejected due to too many concurrent series requests of the tenant.",
+		}),
+		shortCircuited: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_read_short_circuited_series_requests_total",
+			Help: "Total number of series requests of tenants without local data answered without querying the storage.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(r.rejected, r.shortCircuited)
+	}
+	return r
+}
+
+// SetLimits replaces the limits of concurrent series requests. Series requests in flight are not affected.
+func (r *ReadLimiter) SetLimits(limits LimitsConfig) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.limits = limits
+}
+
+// Wrap returns a store applying the limits to series requests to the given store.
+func (r *ReadLimiter) Wrap(s storepb.StoreServer) storepb.StoreServer {
+	return &readLimitedStore{StoreServer: s, r: r}
+}
+
+// tenant returns the tenant of the series request, if any.
+func (r *ReadLimiter) tenant(srv storepb.Store_SeriesServer) (string, bool) {
+	md, ok := metadata.FromIncomingContext(srv.Context())
+	if !ok {
+		return "", false
+	}
+	v := md.Get(strings.ToLower(r.opts.TenantHeader))
+	if len(v) == 0 {
+		return "", false
+	}
+	return v[0], true
+}
+
+type readLimitedStore struct {
+	storepb.StoreServer
+	r *ReadLimiter
+}
+
+// Series implements the storepb.StoreServer interface.
+func (s *readLimitedStore) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	r := s.r
+	tenant, ok := r.tenant(srv)
+	if ok && r.opts.Activity != nil && !r.opts.Activity.Active(tenant) {
+		r.shortCircuited.Inc()
+		return srv.Send(storepb.NewWarnSeriesResponse(errors.Errorf("tenant %q has no local data, skipped querying the TSDB", tenant)))
+	}
+
+	r.mtx.Lock()
+	limit := r.limits.ReadMaxConcurrency(tenant)
+	if limit <= 0 {
+		r.mtx.Unlock()
+	} else {
+		if r.inflight[tenant] >= limit {
+			r.mtx.Unlock()
+			r.rejected.Inc()
+			return status.Errorf(codes.ResourceExhausted, "too many concurrent series requests of tenant %q, limit is %d", tenant, limit)
+		}
+		r.inflight[tenant]++
+		r.mtx.Unlock()
+
+		defer func() {
+			r.mtx.Lock()
+			defer r.mtx.Unlock()
+			if r.inflight[tenant]--; r.inflight[tenant] == 0 {
+				delete(r.inflight, tenant)
+			}
+		}()
+	}
+	return s.StoreServer.Series(req, srv)
+}
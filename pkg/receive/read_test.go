@@ -0,0 +1,133 @@
+package receive
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantActivity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tenant-activity")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	now := time.Unix(1000, 0)
+	a, err := NewTenantActivity(dir, time.Hour)
+	testutil.Ok(t, err)
+	a.now = func() time.Time { return now }
+
+	a.Record("a")
+	now = now.Add(30 * time.Minute)
+	a.Record("b")
+	testutil.Assert(t, a.Active("a"), "tenant a not active")
+	testutil.Assert(t, a.Active("b"), "tenant b not active")
+	testutil.Assert(t, !a.Active("c"), "tenant c active")
+
+	// Tenants are known after restart until they have no local data anymore.
+	now = now.Add(45 * time.Minute)
+	testutil.Ok(t, a.Persist())
+	a, err = NewTenantActivity(dir, time.Hour)
+	testutil.Ok(t, err)
+	a.now = func() time.Time { return now }
+	testutil.Assert(t, !a.Active("a"), "tenant a active")
+	testutil.Assert(t, a.Active("b"), "tenant b not active")
+	testutil.Equals(t, 1, len(a.lastWrite))
+}
+
+type blockingSeriesStore struct {
+	storepb.StoreServer
+	release chan struct{}
+	calls   int32
+}
+
+func (s *blockingSeriesStore) Series(_ *storepb.SeriesRequest, _ storepb.Store_SeriesServer) error {
+	atomic.AddInt32(&s.calls, 1)
+	<-s.release
+	return nil
+}
+
+type tenantSeriesServer struct {
+	storepb.Store_SeriesServer
+	ctx context.Context
+
+	warnings []string
+}
+
+func (s *tenantSeriesServer) Context() context.Context { return s.ctx }
+
+func (s *tenantSeriesServer) Send(r *storepb.SeriesResponse) error {
+	s.warnings = append(s.warnings, r.GetWarning())
+	return nil
+}
+
+func newTenantSeriesServer(tenant string) *tenantSeriesServer {
+	ctx := context.Background()
+	if tenant != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("thanos-tenant", tenant))
+	}
+	return &tenantSeriesServer{ctx: ctx}
+}
+
+func TestReadLimiter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "read-limiter")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	a, err := NewTenantActivity(dir, 0)
+	testutil.Ok(t, err)
+	a.Record("a")
+	a.Record("b")
+
+	s := &blockingSeriesStore{release: make(chan struct{})}
+	r := NewReadLimiter(nil, ReadLimitsOptions{TenantHeader: DefaultTenantHeader, MaxConcurrentPerTenant: 1, Activity: a})
+	store := r.Wrap(s)
+
+	// Tenants without local data are answered immediately, with a warning.
+	cold := newTenantSeriesServer("cold")
+	testutil.Ok(t, store.Series(&storepb.SeriesRequest{}, cold))
+	testutil.Equals(t, []string{`tenant "cold" has no local data, skipped querying the TSDB`}, cold.warnings)
+	testutil.Equals(t, int32(0), atomic.LoadInt32(&s.calls))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.shortCircuited))
+
+	done := make(chan error)
+	go func() { done <- store.Series(&storepb.SeriesRequest{}, newTenantSeriesServer("a")) }()
+	for {
+		r.mtx.Lock()
+		n := r.inflight["a"]
+		r.mtx.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Requests above the limit of the tenant are rejected, other tenants are not affected.
+	err = store.Series(&storepb.SeriesRequest{}, newTenantSeriesServer("a"))
+	testutil.NotOk(t, err)
+	testutil.Equals(t, codes.ResourceExhausted, status.Code(err))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.rejected))
+
+	// Limits of single tenants override the limit of all tenants once set.
+	r.SetLimits(LimitsConfig{ReadMaxConcurrencyPerTenant: 1, Tenants: map[string]TenantLimitsConfig{"a": {ReadMaxConcurrency: 2}}})
+	go func() { done <- store.Series(&storepb.SeriesRequest{}, newTenantSeriesServer("a")) }()
+	go func() { done <- store.Series(&storepb.SeriesRequest{}, newTenantSeriesServer("b")) }()
+	go func() { done <- store.Series(&storepb.SeriesRequest{}, newTenantSeriesServer("")) }()
+	for atomic.LoadInt32(&s.calls) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	close(s.release)
+	for i := 0; i < 4; i++ {
+		testutil.Ok(t, <-done)
+	}
+	testutil.Equals(t, 0, len(r.inflight))
+}
@@ -0,0 +1,156 @@
+package receive
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// RebalanceOptions configure moving a tenant from one node to another, see Rebalance.
+type RebalanceOptions struct {
+	Tenant string
+	// Source and Target are the endpoints of the nodes as given in hashring configurations.
+	Source string
+	Target string
+	// HashringsFile is the hashring configuration file watched by all nodes.
+	HashringsFile string
+	// Hashrings is the updated hashring configuration moving the tenant.
+	Hashrings []HashringConfig
+}
+
+// Rebalance moves a tenant from the source node to the target node by applying the updated hashring configuration.
+// The source node flushes its head and uploads it before the configuration is changed, which keeps the second flush
+// short. Rebalance waits until the source node and all nodes of the tenant's updated hashring have loaded the
+// configuration and flushes the source node again, so it has uploaded all samples it received for the tenant.
+// Samples are thus neither missing nor written by both nodes.
+//
+// NOTE: Nodes run a single TSDB for all tenants, so the flushes affect all tenants of the source node.
+func Rebalance(ctx context.Context, logger log.Logger, client *http.Client, interval time.Duration, o RebalanceOptions) error {
+	b, err := ioutil.ReadFile(o.HashringsFile)
+	if err != nil {
+		return errors.Wrap(err, "read hashrings file")
+	}
+	var current []HashringConfig
+	if err := json.Unmarshal(b, &current); err != nil {
+		return errors.Wrap(err, "parse hashrings file")
+	}
+	from, err := TenantEndpoints(current, o.Tenant)
+	if err != nil {
+		return errors.Wrapf(err, "current hashring of tenant %q", o.Tenant)
+	}
+	to, err := TenantEndpoints(o.Hashrings, o.Tenant)
+	if err != nil {
+		return errors.Wrapf(err, "updated hashring of tenant %q", o.Tenant)
+	}
+	if !contains(from, o.Source) || contains(to, o.Source) {
+		return errors.Errorf("updated hashring does not move tenant %q from %s", o.Tenant, o.Source)
+	}
+	if contains(from, o.Target) || !contains(to, o.Target) {
+		return errors.Errorf("updated hashring does not move tenant %q to %s", o.Tenant, o.Target)
+	}
+
+	level.Info(logger).Log("msg", "flushing source", "endpoint", o.Source)
+	if err := flushEndpoint(ctx, client, o.Source); err != nil {
+		return errors.Wrapf(err, "flush %s", o.Source)
+	}
+
+	level.Info(logger).Log("msg", "applying hashrings", "file", o.HashringsFile)
+	b, err = json.MarshalIndent(o.Hashrings, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal hashrings")
+	}
+	tmp := o.HashringsFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write hashrings file")
+	}
+	if err := os.Rename(tmp, o.HashringsFile); err != nil {
+		return errors.Wrap(err, "rename hashrings file")
+	}
+
+	for _, e := range append([]string{o.Source}, to...) {
+		level.Info(logger).Log("msg", "waiting for node to apply hashrings", "endpoint", e)
+		if err := runutil.Retry(interval, ctx.Done(), func() error {
+			endpoints, err := endpointsOf(ctx, client, e, o.Tenant)
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(endpoints, to) {
+				return errors.Errorf("endpoints %v of tenant %q not updated yet", endpoints, o.Tenant)
+			}
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "wait for %s", e)
+		}
+	}
+	level.Info(logger).Log("msg", "flushing source", "endpoint", o.Source)
+	if err := flushEndpoint(ctx, client, o.Source); err != nil {
+		return errors.Wrapf(err, "flush %s", o.Source)
+	}
+	level.Info(logger).Log("msg", "tenant moved", "tenant", o.Tenant, "source", o.Source, "target", o.Target)
+	return nil
+}
+
+func contains(endpoints []string, e string) bool {
+	for _, x := range endpoints {
+		if x == e {
+			return true
+		}
+	}
+	return false
+}
+
+// doAPI sends the request to the API path of the node with the given endpoint and returns the response body.
+func doAPI(ctx context.Context, client *http.Client, method, endpoint, path string, query url.Values) ([]byte, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse endpoint %s", endpoint)
+	}
+	u.Path = path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "request %s", u)
+	}
+	defer runutil.CloseWithLogOnErr(nil, resp.Body, "%s response body", path)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("request %s: unexpected status %s: %s", u, resp.Status, b)
+	}
+	return b, nil
+}
+
+func flushEndpoint(ctx context.Context, client *http.Client, endpoint string) error {
+	_, err := doAPI(ctx, client, http.MethodPost, endpoint, "/api/v1/flush", nil)
+	return err
+}
+
+func endpointsOf(ctx context.Context, client *http.Client, endpoint, tenant string) ([]string, error) {
+	b, err := doAPI(ctx, client, http.MethodGet, endpoint, "/api/v1/hashring", url.Values{"tenant": []string{tenant}})
+	if err != nil {
+		return nil, err
+	}
+	var r EndpointsResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, errors.Wrap(err, "decode hashring response")
+	}
+	return r.Endpoints, nil
+}
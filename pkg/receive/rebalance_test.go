@@ -0,0 +1,122 @@
+package receive
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestRebalance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-receive-rebalance")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	appendables := []*fakeAppendable{
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+	}
+	handlers, _, close := newHandlerHashring(appendables, 1)
+	defer close()
+
+	var flushes int32
+	handlers[0].options.Flusher = func(context.Context) error {
+		atomic.AddInt32(&flushes, 1)
+		return nil
+	}
+
+	e0, e1 := handlers[0].options.Endpoint, handlers[1].options.Endpoint
+	current := []HashringConfig{
+		{Hashring: "foo", Tenants: []string{"foo"}, Endpoints: []string{e0}},
+		{Endpoints: []string{e0, e1}},
+	}
+	updated := []HashringConfig{
+		{Hashring: "foo", Tenants: []string{"foo"}, Endpoints: []string{e1}},
+		{Endpoints: []string{e0, e1}},
+	}
+	for _, h := range handlers {
+		h.Hashring(newMultiHashring(current))
+	}
+	b, err := json.Marshal(current)
+	testutil.Ok(t, err)
+	file := filepath.Join(dir, "hashrings.json")
+	testutil.Ok(t, ioutil.WriteFile(file, b, 0666))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The hashring does not move the tenant to the source.
+	testutil.NotOk(t, Rebalance(ctx, log.NewNopLogger(), http.DefaultClient, 10*time.Millisecond, RebalanceOptions{
+		Tenant:        "foo",
+		Source:        e1,
+		Target:        e0,
+		HashringsFile: file,
+		Hashrings:     updated,
+	}))
+	testutil.Equals(t, int32(0), atomic.LoadInt32(&flushes))
+
+	// Apply the configuration to the nodes once written, like config watchers do.
+	done := make(chan struct{})
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for ctx.Err() == nil {
+			var cfg []HashringConfig
+			if b, err := ioutil.ReadFile(file); err == nil && json.Unmarshal(b, &cfg) == nil && reflect.DeepEqual(cfg, updated) {
+				for _, h := range handlers {
+					h.Hashring(newMultiHashring(cfg))
+				}
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	testutil.Ok(t, Rebalance(ctx, log.NewNopLogger(), http.DefaultClient, 10*time.Millisecond, RebalanceOptions{
+		Tenant:        "foo",
+		Source:        e0,
+		Target:        e1,
+		HashringsFile: file,
+		Hashrings:     updated,
+	}))
+	<-done
+	testutil.Equals(t, int32(2), atomic.LoadInt32(&flushes))
+
+	// The tenant is already moved.
+	testutil.NotOk(t, Rebalance(ctx, log.NewNopLogger(), http.DefaultClient, 10*time.Millisecond, RebalanceOptions{
+		Tenant:        "foo",
+		Source:        e0,
+		Target:        e1,
+		HashringsFile: file,
+		Hashrings:     updated,
+	}))
+}
+
+func TestHandler_Flush(t *testing.T) {
+	appendables := []*fakeAppendable{{appender: newFakeAppender(nil, nil, nil, nil)}}
+	handlers, _, close := newHandlerHashring(appendables, 1)
+	defer close()
+	ctx := context.Background()
+
+	err := flushEndpoint(ctx, http.DefaultClient, handlers[0].options.Endpoint)
+	testutil.NotOk(t, err)
+
+	handlers[0].options.Flusher = func(context.Context) error { return nil }
+	testutil.Ok(t, flushEndpoint(ctx, http.DefaultClient, handlers[0].options.Endpoint))
+
+	endpoints, err := endpointsOf(ctx, http.DefaultClient, handlers[0].options.Endpoint, "foo")
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{handlers[0].options.Endpoint}, endpoints)
+
+	// Not ready nodes do not flush.
+	handlers[0].SetWriter(nil)
+	testutil.NotOk(t, flushEndpoint(ctx, http.DefaultClient, handlers[0].options.Endpoint))
+}
@@ -0,0 +1,110 @@
+package receive
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TenantActivityFilename is the name of the file in the TSDB directory tenant activity is persisted in.
+const TenantActivityFilename = "thanos.tenants.json"
+
+// TenantActivity tracks times of the last local writes of tenants, so the read path can tell tenants without local data.
+// It is persisted in the TSDB directory, so tenants written before a restart are still known. Tenants without writes
+// for longer than the retention have no local data anymore.
+type TenantActivity struct {
+	path      string
+	retention time.Duration
+	now       func() time.Time
+
+	mtx       sync.RWMutex
+	lastWrite map[string]time.Time
+	dirty     bool
+}
+
+// NewTenantActivity returns TenantActivity persisted in the given directory, loading previously persisted activity.
+// Zero retention keeps tenants forever.
+func NewTenantActivity(dir string, retention time.Duration) (*TenantActivity, error) {
+	a := &TenantActivity{
+		path:      filepath.Join(dir, TenantActivityFilename),
+		retention: retention,
+		now:       time.Now,
+		lastWrite: map[string]time.Time{},
+	}
+	b, err := ioutil.ReadFile(a.path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read tenant activity")
+	}
+	if err := json.Unmarshal(b, &a.lastWrite); err != nil {
+		return nil, errors.Wrapf(err, "decode tenant activity %s", a.path)
+	}
+	return a, nil
+}
+
+// Record records a local write of the tenant.
+func (a *TenantActivity) Record(tenant string) {
+	now := a.now()
+
+	// Writes are frequent, the activity does not need to be more precise than a minute.
+	a.mtx.RLock()
+	t, ok := a.lastWrite[tenant]
+	a.mtx.RUnlock()
+	if ok && now.Sub(t) < time.Minute {
+		return
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.lastWrite[tenant] = now
+	a.dirty = true
+}
+
+// Active returns true if the tenant may have local data.
+func (a *TenantActivity) Active(tenant string) bool {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	t, ok := a.lastWrite[tenant]
+	if !ok {
+		return false
+	}
+	return a.retention == 0 || a.now().Sub(t) <= a.retention
+}
+
+// Persist writes the activity to its file if it changed, dropping tenants without local data.
+func (a *TenantActivity) Persist() error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if !a.dirty {
+		return nil
+	}
+	for tenant, t := range a.lastWrite {
+		if a.retention > 0 && a.now().Sub(t) > a.retention {
+			delete(a.lastWrite, tenant)
+		}
+	}
+	b, err := json.Marshal(a.lastWrite)
+	if err != nil {
+		return errors.Wrap(err, "encode tenant activity")
+	}
+
+	// Make any changes to the file appear atomic.
+	tmp := a.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write tenant activity file")
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		return errors.Wrap(err, "rename tenant activity file")
+	}
+	a.dirty = false
+	return nil
+}
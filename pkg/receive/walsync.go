@@ -0,0 +1,187 @@
+package receive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WALSyncPolicy describes when the WAL of the receive TSDB is fsynced.
+//
+// TSDB writes WAL records to the operating system on every commit, so acknowledged samples survive a crash of
+// the process. Only fsync guarantees they survive a crash of the node as well. The policy defines the window of
+// acknowledged samples which can be lost when the node crashes.
+type WALSyncPolicy string
+
+const (
+	// WALSyncSegment keeps the TSDB behaviour of fsyncing WAL segments only once they are completed. On node
+	// crash, up to a whole WAL segment (128MiB) of acknowledged samples can be lost.
+	WALSyncSegment WALSyncPolicy = "segment"
+	// WALSyncRequest fsyncs the WAL before a write request is acknowledged, so no acknowledged samples can be lost.
+	// Concurrent requests waiting for fsync are batched into a single fsync.
+	WALSyncRequest WALSyncPolicy = "request"
+	// WALSyncInterval fsyncs the WAL periodically. On node crash, acknowledged samples written within the last
+	// interval can be lost.
+	WALSyncInterval WALSyncPolicy = "interval"
+)
+
+// WALSyncer fsyncs the WAL of TSDB stored in a directory. Concurrent Sync calls are batched, so a single fsync
+// covers all calls which started before it.
+type WALSyncer struct {
+	dir string
+
+	mtx     sync.Mutex
+	cond    *sync.Cond
+	syncing bool
+	// next collects Sync calls waiting for the next fsync.
+	next *syncBatch
+
+	syncDuration prometheus.Histogram
+	syncFailures prometheus.Counter
+	batchSize    prometheus.Histogram
+}
+
+// NewWALSyncer returns a WALSyncer of the WAL of TSDB in the given directory.
+func NewWALSyncer(reg prometheus.Registerer, tsdbDir string) *WALSyncer {
+	s := &WALSyncer{
+		dir: filepath.Join(tsdbDir, "wal"),
+		syncDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "thanos_receive_wal_fsync_duration_seconds",
+			Help:    "Duration of WAL fsyncs.",
+			Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}),
+		syncFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_wal_fsync_failures_total",
+			Help: "Total number of failed WAL fsyncs.",
+		}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "thanos_receive_wal_fsync_batch_size",
+			Help:    "Number of sync calls, e.g. write requests, covered by a single WAL fsync.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+	}
+	s.cond = sync.NewCond(&s.mtx)
+	if reg != nil {
+		reg.MustRegister(s.syncDuration, s.syncFailures, s.batchSize)
+	}
+	return s
+}
+
+// syncBatch is a group of Sync calls covered by a single fsync.
+type syncBatch struct {
+	size int
+	done bool
+	err  error
+}
+
+// Sync returns once the WAL is fsynced, including everything written before the call.
+func (s *WALSyncer) Sync() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.next == nil {
+		s.next = &syncBatch{}
+	}
+	b := s.next
+	b.size++
+
+	for !b.done {
+		if s.syncing {
+			s.cond.Wait()
+			continue
+		}
+
+		// No fsync is in progress, so b is the next batch. Do the fsync on behalf of all its calls, while
+		// calls arriving meanwhile are collected into a new batch.
+		s.next = nil
+		s.syncing = true
+		s.mtx.Unlock()
+		err := s.fsync()
+		s.mtx.Lock()
+
+		s.syncing = false
+		b.done, b.err = true, err
+		s.batchSize.Observe(float64(b.size))
+		if err != nil {
+			s.syncFailures.Inc()
+		}
+		s.cond.Broadcast()
+	}
+	return b.err
+}
+
+// fsync syncs the last two WAL segments. TSDB fsyncs completed segments asynchronously, so records written
+// before the last segment was cut may not be synced yet.
+func (s *WALSyncer) fsync() error {
+	begin := time.Now()
+	defer func() { s.syncDuration.Observe(time.Since(begin).Seconds()) }()
+
+	segs, err := lastSegments(s.dir, 2)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		if err := syncFile(seg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastSegments returns paths of up to n WAL segments with highest index in the directory. No segments are
+// returned if the directory does not exist, e.g. while the WAL is being flushed.
+func lastSegments(dir string, n int) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read WAL dir")
+	}
+
+	type segment struct {
+		idx  int
+		name string
+	}
+	var segs []segment
+	for _, f := range files {
+		idx, err := strconv.Atoi(f.Name())
+		if err != nil || f.IsDir() {
+			continue
+		}
+		segs = append(segs, segment{idx: idx, name: f.Name()})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].idx > segs[j].idx })
+	if len(segs) > n {
+		segs = segs[:n]
+	}
+
+	paths := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		paths = append(paths, filepath.Join(dir, seg.name))
+	}
+	return paths, nil
+}
+
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		// Segment was removed by WAL truncation in the meantime.
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "open WAL segment")
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "fsync WAL segment %s", path)
+	}
+	return errors.Wrap(f.Close(), "close WAL segment")
+}
@@ -0,0 +1,102 @@
+package receive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/tsdb"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestLastSegments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-wal-sync")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	// Missing WAL directory is not an error, as it is removed while flushing.
+	segs, err := lastSegments(filepath.Join(dir, "wal"), 2)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(segs))
+
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "wal", "checkpoint.00000009"), os.ModePerm))
+	for _, name := range []string{"00000009", "00000011", "00000010", "tmp"} {
+		testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "wal", name), nil, os.ModePerm))
+	}
+
+	segs, err = lastSegments(filepath.Join(dir, "wal"), 2)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{filepath.Join(dir, "wal", "00000011"), filepath.Join(dir, "wal", "00000010")}, segs)
+}
+
+func TestWALSyncer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-wal-sync")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	db, err := tsdb.Open(dir, nil, nil, &tsdb.Options{
+		MinBlockDuration: model.Duration(2 * time.Hour),
+		MaxBlockDuration: model.Duration(2 * time.Hour),
+		WALCompression:   true,
+	})
+	testutil.Ok(t, err)
+
+	reg := prometheus.NewRegistry()
+	s := NewWALSyncer(reg, dir)
+	rs := &tsdb.ReadyStorage{}
+	rs.Set(db, 0)
+	w := NewWriter(log.NewNopLogger(), rs, s, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- w.Write(&prompb.WriteRequest{
+				Timeseries: []prompb.TimeSeries{
+					{
+						Labels:  []prompb.Label{{Name: "foo", Value: strconv.Itoa(i)}},
+						Samples: []prompb.Sample{{Value: 1, Timestamp: 1}},
+					},
+				},
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, db.Close())
+
+	testutil.Equals(t, 0.0, promtest.ToFloat64(s.syncFailures))
+
+	// Every write request has to be covered by a fsync, but concurrent ones can share it.
+	mfs, err := reg.Gather()
+	testutil.Ok(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "thanos_receive_wal_fsync_batch_size" {
+			continue
+		}
+		h := mf.GetMetric()[0].GetHistogram()
+		testutil.Equals(t, 20.0, h.GetSampleSum())
+		testutil.Assert(t, h.GetSampleCount() >= 1 && h.GetSampleCount() <= 20, "unexpected number of fsyncs %d", h.GetSampleCount())
+	}
+
+	// Failed fsync is returned to callers.
+	testutil.Ok(t, os.RemoveAll(filepath.Join(dir, "wal")))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "wal"), nil, os.ModePerm))
+	testutil.NotOk(t, s.Sync())
+	testutil.Equals(t, 1.0, promtest.ToFloat64(s.syncFailures))
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/prompb"
+	"github.com/thanos-io/thanos/pkg/exemplars"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
 
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/storage"
@@ -19,14 +21,20 @@ type Appendable interface {
 }
 
 type Writer struct {
-	logger log.Logger
-	append Appendable
+	logger    log.Logger
+	append    Appendable
+	syncer    *WALSyncer
+	exemplars *exemplars.Storage
 }
 
-func NewWriter(logger log.Logger, app Appendable) *Writer {
+// NewWriter returns a writer appending write requests to the given appendable. If syncer is not nil, the WAL is
+// fsynced before Write returns. If exemplars is not nil, exemplars of write requests are added to it.
+func NewWriter(logger log.Logger, app Appendable, syncer *WALSyncer, exemplars *exemplars.Storage) *Writer {
 	return &Writer{
-		logger: logger,
-		append: app,
+		logger:    logger,
+		append:    app,
+		syncer:    syncer,
+		exemplars: exemplars,
 	}
 }
 
@@ -35,6 +43,9 @@ func (r *Writer) Write(wreq *prompb.WriteRequest) error {
 		numOutOfOrder  = 0
 		numDuplicates  = 0
 		numOutOfBounds = 0
+
+		numExemplarsOutOfOrder = 0
+		numExemplarsInvalid    = 0
 	)
 
 	app, err := r.append.Appender()
@@ -69,6 +80,31 @@ func (r *Writer) Write(wreq *prompb.WriteRequest) error {
 				level.Debug(r.logger).Log("msg", "Out of bounds metric", "lset", lset.String(), "sample", s.String())
 			}
 		}
+
+		// Exemplars are not known to the vendored remote write protocol, so they are decoded from unrecognized fields.
+		if r.exemplars == nil || len(t.XXX_unrecognized) == 0 {
+			continue
+		}
+		var tse exemplarspb.TimeSeriesExemplars
+		if err := tse.Unmarshal(t.XXX_unrecognized); err != nil {
+			numExemplarsInvalid++
+			level.Debug(r.logger).Log("msg", "Invalid exemplars", "lset", lset.String(), "err", err)
+			continue
+		}
+		for _, e := range tse.Exemplars {
+			if err := r.exemplars.Add(lset, e); err == exemplars.ErrOutOfOrderExemplar {
+				numExemplarsOutOfOrder++
+				level.Debug(r.logger).Log("msg", "Out of order exemplar", "lset", lset.String(), "exemplar", e.String())
+			}
+		}
+	}
+
+	// Exemplars are best effort, so failing ones do not fail the request.
+	if numExemplarsOutOfOrder > 0 {
+		level.Warn(r.logger).Log("msg", "Error on ingesting out-of-order exemplars", "num_dropped", numExemplarsOutOfOrder)
+	}
+	if numExemplarsInvalid > 0 {
+		level.Warn(r.logger).Log("msg", "Error on decoding exemplars", "num_dropped", numExemplarsInvalid)
 	}
 
 	if numOutOfOrder > 0 {
@@ -86,6 +122,10 @@ func (r *Writer) Write(wreq *prompb.WriteRequest) error {
 
 	if err := app.Commit(); err != nil {
 		errs.Add(errors.Wrap(err, "commit samples"))
+	} else if r.syncer != nil {
+		if err := r.syncer.Sync(); err != nil {
+			errs.Add(errors.Wrap(err, "sync WAL"))
+		}
 	}
 
 	return errs.Err()
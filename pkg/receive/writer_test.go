@@ -0,0 +1,49 @@
+package receive
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/thanos-io/thanos/pkg/exemplars"
+	"github.com/thanos-io/thanos/pkg/exemplars/exemplarspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestWriter_Exemplars(t *testing.T) {
+	es := []exemplarspb.Exemplar{
+		{Labels: []storepb.Label{{Name: "trace_id", Value: "1"}}, Value: 1, Ts: 10},
+		{Labels: []storepb.Label{{Name: "trace_id", Value: "2"}}, Value: 2, Ts: 20},
+		// Out of order exemplars do not fail the request.
+		{Labels: []storepb.Label{{Name: "trace_id", Value: "3"}}, Value: 3, Ts: 15},
+	}
+	unrecognized, err := (&exemplarspb.TimeSeriesExemplars{Exemplars: es}).Marshal()
+	testutil.Ok(t, err)
+
+	wreq := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "a"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 10}},
+		},
+	}}
+	// Encode and decode the request, as exemplars are only known to newer versions of the protocol.
+	wreq.Timeseries[0].XXX_unrecognized = unrecognized
+	b, err := wreq.Marshal()
+	testutil.Ok(t, err)
+	wreq = &prompb.WriteRequest{}
+	testutil.Ok(t, wreq.Unmarshal(b))
+
+	app := newFakeAppender(nil, nil, nil, nil)
+	storage := exemplars.NewStorage(nil, 10)
+	w := NewWriter(log.NewNopLogger(), &fakeAppendable{appender: app}, nil, storage)
+	testutil.Ok(t, w.Write(wreq))
+
+	lset := labels.FromStrings("__name__", "up", "job", "a")
+	testutil.Equals(t, []prompb.Sample{{Value: 1, Timestamp: 10}}, app.samples[lset.String()])
+
+	m, err := labels.NewMatcher(labels.MatchEqual, "job", "a")
+	testutil.Ok(t, err)
+	testutil.Equals(t, []exemplars.Data{{SeriesLabels: lset, Exemplars: es[:2]}}, storage.Select(0, 100, []*labels.Matcher{m}))
+}
@@ -83,6 +83,7 @@ type Reloader struct {
 	ruleDirs      []string
 	watchInterval time.Duration
 	retryInterval time.Duration
+	client        *http.Client
 
 	lastCfgHash  []byte
 	lastRuleHash []byte
@@ -107,6 +108,7 @@ func New(logger log.Logger, reloadURL *url.URL, cfgFile string, cfgOutputFile st
 		ruleDirs:      ruleDirs,
 		watchInterval: 3 * time.Minute,
 		retryInterval: 5 * time.Second,
+		client:        http.DefaultClient,
 	}
 }
 
@@ -115,6 +117,12 @@ func (r *Reloader) WithWatchInterval(duration time.Duration) {
 	r.watchInterval = duration
 }
 
+// WithTransport sets the transport of reload requests, e.g. to restrict TLS versions and cipher suites of https
+// reload URLs.
+func (r *Reloader) WithTransport(transport http.RoundTripper) {
+	r.client = &http.Client{Transport: transport}
+}
+
 // Watch starts to watch periodically the config file and rules and process them until the context
 // gets canceled. Config file gets env expanded if cfgOutputFile is specified and reload is trigger if
 // config or rules changed.
@@ -326,7 +334,7 @@ func (r *Reloader) triggerReload(ctx context.Context) error {
 	}
 	req = req.WithContext(ctx)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := r.client.Do(req)
 	if err != nil {
 		return errors.Wrap(err, "reload request failed")
 	}
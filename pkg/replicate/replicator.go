@@ -0,0 +1,315 @@
+package replicate
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// Replicator replicates blocks from one bucket to another, e.g. to keep a warm standby bucket in another region.
+// Each run is incremental: only blocks missing in the target bucket are copied. Blocks are replicated only once their
+// upload to the source bucket finished, i.e. once their meta.json exists, and their meta.json is copied last, so
+// readers of the target bucket never see partially replicated blocks.
+type Replicator struct {
+	logger    log.Logger
+	fromBkt   objstore.BucketReader
+	toBkt     objstore.Bucket
+	deletions DeletionConfig
+
+	now func() time.Time
+
+	runs              *prometheus.CounterVec
+	blocksReplicated  prometheus.Counter
+	bytesReplicated   prometheus.Counter
+	blocksDeleted     prometheus.Counter
+	deletionsSkipped  *prometheus.CounterVec
+	blocksBehind      prometheus.Gauge
+	behindSeconds     prometheus.Gauge
+	lastSuccessfulRun prometheus.Gauge
+}
+
+// DeletionConfig configures propagation of deletions of blocks from the source bucket to the target bucket.
+type DeletionConfig struct {
+	// Propagate enables deletion of blocks from the target bucket which were deleted from the source bucket, e.g. by
+	// compaction or retention. Nothing is deleted if the source bucket lists no blocks at all.
+	Propagate bool
+	// MaxPerRun is the maximum number of blocks deleted by a single run, oldest first. Remaining blocks are deleted
+	// by the next runs. 0 means no limit.
+	MaxPerRun int
+	// MinBlockAge is the minimum age of blocks, based on their ULID, to be deleted, so replicas of recent blocks are
+	// not deleted due to an inconsistent listing of the source bucket.
+	MinBlockAge time.Duration
+}
+
+// New returns a Replicator copying blocks from fromBkt to toBkt and, if enabled, propagating deletions of blocks
+// according to the deletion config.
+func New(logger log.Logger, reg prometheus.Registerer, fromBkt objstore.BucketReader, toBkt objstore.Bucket, deletions DeletionConfig) *Replicator {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	r := &Replicator{
+		logger:    logger,
+		fromBkt:   fromBkt,
+		toBkt:     toBkt,
+		deletions: deletions,
+		now:       time.Now,
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_replicate_runs_total",
+			Help: "Total number of replication runs by result.",
+		}, []string{"result"}),
+		blocksReplicated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_replicate_blocks_replicated_total",
+			Help: "Total number of blocks replicated to the target bucket.",
+		}),
+		bytesReplicated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_replicate_bytes_replicated_total",
+			Help: "Total number of bytes replicated to the target bucket.",
+		}),
+		blocksDeleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_replicate_blocks_deleted_total",
+			Help: "Total number of blocks deleted from the target bucket since they were deleted from the source bucket.",
+		}),
+		deletionsSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_replicate_deletions_skipped_total",
+			Help: "Total number of blocks deleted from the source bucket which were not deleted from the target bucket by a run, by reason.",
+		}, []string{"reason"}),
+		blocksBehind: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_replicate_blocks_behind",
+			Help: "Number of blocks of the source bucket not replicated to the target bucket yet.",
+		}),
+		behindSeconds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_replicate_behind_seconds",
+			Help: "Age of the oldest block of the source bucket not replicated to the target bucket yet, based on its ULID. Zero if all blocks are replicated.",
+		}),
+		lastSuccessfulRun: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_replicate_last_successful_run_timestamp_seconds",
+			Help: "Timestamp of the last successful replication run.",
+		}),
+	}
+	r.runs.WithLabelValues("success")
+	r.runs.WithLabelValues("error")
+	for _, reason := range []string{skipReasonEmptySource, skipReasonMaxPerRun, skipReasonMinBlockAge} {
+		r.deletionsSkipped.WithLabelValues(reason)
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			r.runs,
+			r.blocksReplicated,
+			r.bytesReplicated,
+			r.blocksDeleted,
+			r.deletionsSkipped,
+			r.blocksBehind,
+			r.behindSeconds,
+			r.lastSuccessfulRun,
+		)
+	}
+	return r
+}
+
+// Run replicates all blocks missing in the target bucket and, if enabled, deletes blocks missing in the source bucket
+// from the target bucket.
+func (r *Replicator) Run(ctx context.Context) error {
+	if err := r.run(ctx); err != nil {
+		r.runs.WithLabelValues("error").Inc()
+		return err
+	}
+	r.runs.WithLabelValues("success").Inc()
+	r.lastSuccessfulRun.Set(float64(r.now().Unix()))
+	return nil
+}
+
+func (r *Replicator) run(ctx context.Context) error {
+	fromIDs, err := completeBlocks(ctx, r.fromBkt)
+	if err != nil {
+		return errors.Wrap(err, "list blocks of source bucket")
+	}
+	toIDs, err := completeBlocks(ctx, r.toBkt)
+	if err != nil {
+		return errors.Wrap(err, "list blocks of target bucket")
+	}
+
+	var missing []ulid.ULID
+	for id := range fromIDs {
+		if _, ok := toIDs[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	// Oldest blocks are replicated first, so the lag is reduced from its oldest end.
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Compare(missing[j]) < 0
+	})
+	r.updateLag(missing)
+
+	for i, id := range missing {
+		if err := r.replicateBlock(ctx, id); err != nil {
+			return errors.Wrapf(err, "replicate block %s", id)
+		}
+		r.blocksReplicated.Inc()
+		r.updateLag(missing[i+1:])
+		level.Info(r.logger).Log("msg", "replicated block", "block", id)
+	}
+
+	if !r.deletions.Propagate {
+		return nil
+	}
+	return r.propagateDeletions(ctx, fromIDs, toIDs)
+}
+
+const (
+	skipReasonEmptySource = "empty_source"
+	skipReasonMaxPerRun   = "max_per_run"
+	skipReasonMinBlockAge = "min_block_age"
+)
+
+// propagateDeletions deletes blocks missing in the source bucket from the target bucket, within the limits of the
+// deletion config.
+func (r *Replicator) propagateDeletions(ctx context.Context, fromIDs, toIDs map[ulid.ULID]struct{}) error {
+	var deleted []ulid.ULID
+	for id := range toIDs {
+		if _, ok := fromIDs[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+	// An empty listing is more likely a misconfigured or failing source bucket than all blocks being deleted.
+	if len(fromIDs) == 0 {
+		r.deletionsSkipped.WithLabelValues(skipReasonEmptySource).Add(float64(len(deleted)))
+		level.Warn(r.logger).Log("msg", "source bucket lists no blocks, not propagating deletions", "blocks", len(deleted))
+		return nil
+	}
+
+	sort.Slice(deleted, func(i, j int) bool {
+		return deleted[i].Compare(deleted[j]) < 0
+	})
+	n := 0
+	for i, id := range deleted {
+		if r.deletions.MaxPerRun > 0 && n >= r.deletions.MaxPerRun {
+			r.deletionsSkipped.WithLabelValues(skipReasonMaxPerRun).Add(float64(len(deleted) - i))
+			level.Warn(r.logger).Log("msg", "maximum number of deletions per run reached, remaining blocks are deleted by next runs", "remaining", len(deleted)-i)
+			break
+		}
+		if age := r.now().Sub(ulid.Time(id.Time())); age < r.deletions.MinBlockAge {
+			r.deletionsSkipped.WithLabelValues(skipReasonMinBlockAge).Inc()
+			level.Debug(r.logger).Log("msg", "block deleted from source bucket is too young to be deleted", "block", id, "age", age)
+			continue
+		}
+		if err := block.Delete(ctx, r.logger, r.toBkt, id); err != nil {
+			return errors.Wrapf(err, "delete block %s from target bucket", id)
+		}
+		n++
+		r.blocksDeleted.Inc()
+		level.Info(r.logger).Log("msg", "deleted block from target bucket, it was deleted from source bucket", "block", id)
+	}
+	return nil
+}
+
+func (r *Replicator) updateLag(missing []ulid.ULID) {
+	r.blocksBehind.Set(float64(len(missing)))
+	if len(missing) == 0 {
+		r.behindSeconds.Set(0)
+		return
+	}
+	r.behindSeconds.Set(r.now().Sub(ulid.Time(missing[0].Time())).Seconds())
+}
+
+// replicateBlock copies all objects of the block, its meta.json last. The target block is marked as being uploaded
+// until its meta.json is copied, so it is not cleaned up as a partial block meanwhile.
+func (r *Replicator) replicateBlock(ctx context.Context, id ulid.ULID) error {
+	var objs []string
+	if err := iterRecursive(ctx, r.fromBkt, id.String(), func(name string) error {
+		objs = append(objs, name)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "list block objects")
+	}
+
+	if err := block.MarkUpload(ctx, r.toBkt, id); err != nil {
+		return err
+	}
+	metaFile := path.Join(id.String(), block.MetaFilename)
+	markFile := path.Join(id.String(), metadata.UploadMarkFilename)
+	for _, name := range objs {
+		if name == metaFile || name == markFile {
+			continue
+		}
+		if err := r.copyObject(ctx, name); err != nil {
+			return err
+		}
+	}
+	if err := r.copyObject(ctx, metaFile); err != nil {
+		return err
+	}
+	block.DeleteUploadMark(ctx, r.logger, r.toBkt, id)
+	return nil
+}
+
+func (r *Replicator) copyObject(ctx context.Context, name string) (err error) {
+	rc, err := r.fromBkt.Get(ctx, name)
+	if err != nil {
+		return errors.Wrapf(err, "get %s", name)
+	}
+	defer runutil.CloseWithErrCapture(&err, rc, "close source object %s", name)
+
+	cr := &countingReader{r: rc}
+	if err := r.toBkt.Upload(ctx, name, cr); err != nil {
+		return errors.Wrapf(err, "upload %s", name)
+	}
+	r.bytesReplicated.Add(float64(cr.n))
+	return nil
+}
+
+// completeBlocks returns IDs of blocks of the bucket having meta.json.
+func completeBlocks(ctx context.Context, bkt objstore.BucketReader) (map[ulid.ULID]struct{}, error) {
+	res := map[ulid.ULID]struct{}{}
+	err := bkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if !ok {
+			return nil
+		}
+		ok, err := bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+		if err != nil {
+			return errors.Wrapf(err, "check meta file of %s", id)
+		}
+		if ok {
+			res[id] = struct{}{}
+		}
+		return nil
+	})
+	return res, err
+}
+
+func iterRecursive(ctx context.Context, bkt objstore.BucketReader, dir string, f func(string) error) error {
+	return bkt.Iter(ctx, dir, func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			return iterRecursive(ctx, bkt, name, f)
+		}
+		return f(name)
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
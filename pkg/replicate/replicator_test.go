@@ -0,0 +1,137 @@
+package replicate
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func uploadBlock(t *testing.T, bkt objstore.Bucket, id ulid.ULID, withMeta bool) {
+	ctx := context.Background()
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), "index"), bytes.NewBufferString("index")))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), "chunks", "000001"), bytes.NewBufferString("chunks")))
+	if withMeta {
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewBufferString("{}")))
+	}
+}
+
+func objects(t *testing.T, bkt *inmem.Bucket) (res []string) {
+	for name := range bkt.Objects() {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestReplicator_Run(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		a = ulid.MustNew(1000, nil)
+		b = ulid.MustNew(2000, nil)
+		c = ulid.MustNew(3000, nil)
+		d = ulid.MustNew(4000, nil)
+	)
+	fromBkt, toBkt := inmem.NewBucket(), inmem.NewBucket()
+	uploadBlock(t, fromBkt, a, true)
+	uploadBlock(t, fromBkt, b, true)
+	// Blocks still being uploaded are not replicated.
+	uploadBlock(t, fromBkt, c, false)
+
+	r := New(nil, nil, fromBkt, toBkt, DeletionConfig{})
+	r.now = func() time.Time { return time.Unix(10, 0) }
+
+	testutil.Ok(t, r.Run(ctx))
+	testutil.Equals(t, []string{
+		a.String() + "/chunks/000001", a.String() + "/index", a.String() + "/meta.json",
+		b.String() + "/chunks/000001", b.String() + "/index", b.String() + "/meta.json",
+	}, objects(t, toBkt))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(r.blocksReplicated))
+	testutil.Equals(t, 2*float64(len("index")+len("chunks")+len("{}")), promtest.ToFloat64(r.bytesReplicated))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(r.blocksBehind))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(r.behindSeconds))
+	testutil.Equals(t, 10.0, promtest.ToFloat64(r.lastSuccessfulRun))
+
+	// Only new blocks are replicated by consecutive runs.
+	testutil.Ok(t, fromBkt.Upload(ctx, path.Join(c.String(), block.MetaFilename), bytes.NewBufferString("{}")))
+	testutil.Ok(t, block.Delete(ctx, log.NewNopLogger(), fromBkt, a))
+
+	testutil.Ok(t, r.Run(ctx))
+	testutil.Equals(t, 3.0, promtest.ToFloat64(r.blocksReplicated))
+	testutil.Equals(t, 9, len(objects(t, toBkt)))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(r.blocksDeleted))
+
+	// Deletions are propagated if enabled.
+	uploadBlock(t, fromBkt, d, true)
+	r = New(nil, nil, fromBkt, toBkt, DeletionConfig{Propagate: true})
+	testutil.Ok(t, r.Run(ctx))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.blocksReplicated))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.blocksDeleted))
+	testutil.Equals(t, objects(t, fromBkt), objects(t, toBkt))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.runs.WithLabelValues("success")))
+}
+
+func TestReplicator_PropagateDeletions(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		a = ulid.MustNew(1000, nil)
+		b = ulid.MustNew(2000, nil)
+		c = ulid.MustNew(3000, nil)
+		d = ulid.MustNew(9000, nil)
+		e = ulid.MustNew(10000, nil)
+	)
+	fromBkt, toBkt := inmem.NewBucket(), inmem.NewBucket()
+	for _, id := range []ulid.ULID{a, b, c, d} {
+		uploadBlock(t, toBkt, id, true)
+	}
+
+	r := New(nil, nil, fromBkt, toBkt, DeletionConfig{Propagate: true, MaxPerRun: 2, MinBlockAge: 5 * time.Second})
+	r.now = func() time.Time { return time.Unix(10, 0) }
+
+	// Nothing is deleted if the source bucket lists no blocks.
+	testutil.Ok(t, r.Run(ctx))
+	testutil.Equals(t, 12, len(objects(t, toBkt)))
+	testutil.Equals(t, 4.0, promtest.ToFloat64(r.deletionsSkipped.WithLabelValues(skipReasonEmptySource)))
+
+	// At most two blocks are deleted by each run, oldest first. Blocks younger than the minimum age are not deleted.
+	uploadBlock(t, fromBkt, e, true)
+	testutil.Ok(t, r.Run(ctx))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(r.blocksDeleted))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(r.deletionsSkipped.WithLabelValues(skipReasonMaxPerRun)))
+	testutil.Ok(t, r.Run(ctx))
+	testutil.Equals(t, 3.0, promtest.ToFloat64(r.blocksDeleted))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.deletionsSkipped.WithLabelValues(skipReasonMinBlockAge)))
+
+	var ids []string
+	for _, name := range objects(t, toBkt) {
+		if path.Base(name) == block.MetaFilename {
+			ids = append(ids, path.Dir(name))
+		}
+	}
+	testutil.Equals(t, []string{d.String(), e.String()}, ids)
+}
+
+func TestReplicator_updateLag(t *testing.T) {
+	r := New(nil, nil, nil, nil, DeletionConfig{})
+	r.now = func() time.Time { return time.Unix(100, 0) }
+
+	r.updateLag([]ulid.ULID{ulid.MustNew(40000, nil), ulid.MustNew(90000, nil)})
+	testutil.Equals(t, 2.0, promtest.ToFloat64(r.blocksBehind))
+	testutil.Equals(t, 60.0, promtest.ToFloat64(r.behindSeconds))
+
+	r.updateLag(nil)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(r.blocksBehind))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(r.behindSeconds))
+}
@@ -0,0 +1,65 @@
+package requestid
+
+import (
+	"context"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryClientInterceptor returns a new unary client interceptor sending the request ID held by the context as gRPC
+// metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a new streaming client interceptor sending the request ID held by the context as gRPC
+// metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor injecting the request ID received as gRPC metadata into
+// the context. Spans started before are tagged with it.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(incomingContext(ctx), req)
+	}
+}
+
+// StreamServerInterceptor returns a new streaming server interceptor injecting the request ID received as gRPC
+// metadata into the context. Spans started before are tagged with it.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrappedStream := grpc_middleware.WrapServerStream(stream)
+		wrappedStream.WrappedContext = incomingContext(stream.Context())
+
+		return handler(srv, wrappedStream)
+	}
+}
+
+func outgoingContext(ctx context.Context) context.Context {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, metadataKey, id)
+}
+
+func incomingContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	ids := md.Get(metadataKey)
+	if len(ids) == 0 || !validID.MatchString(ids[0]) {
+		return ctx
+	}
+	tagSpan(ctx, ids[0])
+	return ContextWithID(ctx, ids[0])
+}
@@ -0,0 +1,77 @@
+// Package requestid propagates IDs of requests across components, so logs and traces of all components handling a
+// query can be correlated. An ID is accepted from the client or generated by the first component handling the request,
+// and sent along with all gRPC requests made on behalf of it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"regexp"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/opentracing/opentracing-go"
+)
+
+// HeaderName is the HTTP header of request IDs, in requests and responses.
+const HeaderName = "X-Request-ID"
+
+// metadataKey is the gRPC metadata key of request IDs.
+const metadataKey = "x-request-id"
+
+// spanTag is the tag of spans of requests with an ID.
+const spanTag = "request_id"
+
+// validID matches request IDs accepted from clients. Other IDs are replaced, so they cannot inject anything into logs.
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type contextKey struct{}
+
+var requestIDKey = contextKey{}
+
+// New returns a new request ID.
+func New() string {
+	return ulid.MustNew(ulid.Now(), rand.Reader).String()
+}
+
+// ContextWithID returns a new `context.Context` that holds the given request ID.
+func ContextWithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// FromContext returns the request ID held by the context, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
+
+// Logger returns the logger with the request ID held by the context, if any.
+func Logger(ctx context.Context, logger log.Logger) log.Logger {
+	if id, ok := FromContext(ctx); ok {
+		return log.With(logger, "request_id", id)
+	}
+	return logger
+}
+
+// tagSpan tags the span in the context with the request ID, if both are present.
+func tagSpan(ctx context.Context, id string) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span.SetTag(spanTag, id)
+	}
+}
+
+// HTTPMiddleware returns an HTTP handler that takes the request ID given by the client or generates a new one, injects
+// it into the request context and returns it in the response header. Spans started before are tagged with it.
+func HTTPMiddleware(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderName)
+		if !validID.MatchString(id) {
+			id = New()
+		}
+		w.Header().Set(HeaderName, id)
+		tagSpan(r.Context(), id)
+
+		next.ServeHTTP(w, r.WithContext(ContextWithID(r.Context(), id)))
+	}
+}
@@ -0,0 +1,64 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	var got string
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	}))
+
+	for _, tcase := range []struct {
+		header   string
+		accepted bool
+	}{
+		{header: "", accepted: false},
+		{header: "client-id.1:2", accepted: true},
+		{header: "id\nwith newline", accepted: false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(HeaderName, tcase.header)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		testutil.Assert(t, got != "", "no request ID for header %q", tcase.header)
+		testutil.Equals(t, got, w.Header().Get(HeaderName))
+		testutil.Equals(t, tcase.accepted, got == tcase.header)
+	}
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	testutil.Ok(t, UnaryClientInterceptor()(context.Background(), "/method", nil, nil, nil, invoker))
+	testutil.Equals(t, 0, len(outgoing.Get(metadataKey)))
+
+	testutil.Ok(t, UnaryClientInterceptor()(ContextWithID(context.Background(), "id"), "/method", nil, nil, nil, invoker))
+	testutil.Equals(t, []string{"id"}, outgoing.Get(metadataKey))
+
+	var got string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got, _ = FromContext(ctx)
+		return nil, nil
+	}
+	_, err := UnaryServerInterceptor()(metadata.NewIncomingContext(context.Background(), outgoing), nil, &grpc.UnaryServerInfo{}, handler)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "id", got)
+
+	got = ""
+	_, err = UnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "", got)
+}
@@ -24,18 +24,22 @@ type API struct {
 	logger        log.Logger
 	now           func() time.Time
 	ruleRetriever RulesRetriever
+	evalWarnings  *thanosrule.EvalWarnings
 	reg           prometheus.Registerer
 }
 
+// NewAPI returns rules API. Health of rules is reported taking the given evaluation warnings into account, if not nil.
 func NewAPI(
 	logger log.Logger,
 	reg prometheus.Registerer,
 	ruleRetriever RulesRetriever,
+	evalWarnings *thanosrule.EvalWarnings,
 ) *API {
 	return &API{
 		logger:        logger,
 		now:           time.Now,
 		ruleRetriever: ruleRetriever,
+		evalWarnings:  evalWarnings,
 		reg:           reg,
 	}
 }
@@ -78,9 +82,10 @@ func (api *API) rules(r *http.Request) (interface{}, []error, *qapi.ApiError) {
 		for _, r := range grp.Rules() {
 			var enrichedRule rule
 
+			health, err := api.evalWarnings.Health(grp.PartialResponseStrategy, r)
 			lastError := ""
-			if r.LastError() != nil {
-				lastError = r.LastError().Error()
+			if err != nil {
+				lastError = err.Error()
 			}
 
 			switch rule := r.(type) {
@@ -92,7 +97,7 @@ func (api *API) rules(r *http.Request) (interface{}, []error, *qapi.ApiError) {
 					Labels:                  rule.Labels(),
 					Annotations:             rule.Annotations(),
 					Alerts:                  rulesAlertsToAPIAlerts(grp.PartialResponseStrategy, rule.ActiveAlerts()),
-					Health:                  rule.Health(),
+					Health:                  health,
 					LastError:               lastError,
 					Type:                    "alerting",
 					PartialResponseStrategy: rule.PartialResponseStrategy.String(),
@@ -102,7 +107,7 @@ func (api *API) rules(r *http.Request) (interface{}, []error, *qapi.ApiError) {
 					Name:      rule.Name(),
 					Query:     rule.Query().String(),
 					Labels:    rule.Labels(),
-					Health:    rule.Health(),
+					Health:    health,
 					LastError: lastError,
 					Type:      "recording",
 				}
@@ -165,6 +165,7 @@ func TestEndpoints(t *testing.T) {
 			nil,
 			prometheus.DefaultRegisterer,
 			algr,
+			nil,
 		)
 		testEndpoints(t, api)
 	})
@@ -7,10 +7,15 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cespare/xxhash"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
@@ -36,6 +41,129 @@ type RuleGroups struct {
 type RuleGroup struct {
 	rulefmt.RuleGroup
 	PartialResponseStrategy *storepb.PartialResponseStrategy
+	QueryOffset             model.Duration
+	EvaluationJitter        model.Duration
+}
+
+// QueryOptions control how rules of a group query Thanos Query.
+type QueryOptions struct {
+	// Offset shifts evaluation time of queries into the past, e.g. to account for delayed ingestion.
+	Offset time.Duration
+	// Jitter is the maximum random delay of queries. It spreads queries of groups evaluated at the same time
+	// without changing evaluation time.
+	Jitter time.Duration
+}
+
+// Shard selects rule groups evaluated by a ruler when groups are sharded across multiple rulers. Groups are assigned
+// to shards by the hash of their name.
+type Shard struct {
+	Index uint64
+	Total uint64
+}
+
+// Contains returns true if the group with the given name belongs to the shard. All groups belong to a nil shard.
+func (s *Shard) Contains(group string) bool {
+	if s == nil || s.Total <= 1 {
+		return true
+	}
+	return xxhash.Sum64String(group)%s.Total == s.Index
+}
+
+// GroupQueryOptions holds query options of loaded rule groups. Rule manager does not pass the group to the query
+// function, so options are looked up by partial response strategy and query expression of the rule.
+type GroupQueryOptions struct {
+	mtx  sync.RWMutex
+	opts map[storepb.PartialResponseStrategy]map[string]QueryOptions
+}
+
+// Get returns query options of the rule with the given expression.
+func (o *GroupQueryOptions) Get(s storepb.PartialResponseStrategy, expr string) QueryOptions {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+	return o.opts[s][expr]
+}
+
+func (o *GroupQueryOptions) set(opts map[storepb.PartialResponseStrategy]map[string]QueryOptions) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.opts = opts
+}
+
+// HealthWarn is the health of rules which last evaluation succeeded with partial response warnings, so its result
+// might be based on incomplete data.
+const HealthWarn rules.RuleHealth = "warn"
+
+// EvalWarnings holds partial response warnings of the last evaluation of rules. Like query options, they are keyed
+// by partial response strategy and query expression of the rule.
+type EvalWarnings struct {
+	mtx   sync.RWMutex
+	warns map[storepb.PartialResponseStrategy]map[string][]string
+
+	rulesWithWarnings *prometheus.GaugeVec
+}
+
+// NewEvalWarnings returns empty EvalWarnings.
+func NewEvalWarnings(reg prometheus.Registerer) *EvalWarnings {
+	w := &EvalWarnings{
+		warns: map[storepb.PartialResponseStrategy]map[string][]string{},
+		rulesWithWarnings: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_rule_evaluation_warnings_rules",
+			Help: "The number of rules which last evaluation returned partial response warnings.",
+		}, []string{"strategy"}),
+	}
+	if reg != nil {
+		reg.MustRegister(w.rulesWithWarnings)
+	}
+	return w
+}
+
+// Set sets warnings of the last evaluation of the rule with the given expression. No warnings clear previous ones.
+func (w *EvalWarnings) Set(s storepb.PartialResponseStrategy, expr string, warns []string) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if len(warns) == 0 {
+		delete(w.warns[s], expr)
+	} else {
+		if _, ok := w.warns[s]; !ok {
+			w.warns[s] = map[string][]string{}
+		}
+		w.warns[s][expr] = warns
+	}
+	w.rulesWithWarnings.WithLabelValues(strings.ToLower(s.String())).Set(float64(len(w.warns[s])))
+}
+
+// Reset removes all warnings, e.g. when rules are reloaded.
+func (w *EvalWarnings) Reset() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	for s := range w.warns {
+		w.rulesWithWarnings.WithLabelValues(strings.ToLower(s.String())).Set(0)
+	}
+	w.warns = map[storepb.PartialResponseStrategy]map[string][]string{}
+}
+
+// Health returns health and last error of the rule. Rules which last evaluation succeeded with warnings have
+// HealthWarn health and the warnings as last error.
+func (w *EvalWarnings) Health(s storepb.PartialResponseStrategy, r rules.Rule) (rules.RuleHealth, error) {
+	if w == nil || r.Health() != rules.HealthGood {
+		return r.Health(), r.LastError()
+	}
+
+	q, ok := r.(interface{ Query() promql.Expr })
+	if !ok {
+		return r.Health(), r.LastError()
+	}
+
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+
+	warns, ok := w.warns[s][q.Query().String()]
+	if !ok {
+		return r.Health(), r.LastError()
+	}
+	return HealthWarn, errors.Errorf("partial response: %s", strings.Join(warns, ", "))
 }
 
 type Managers map[storepb.PartialResponseStrategy]*rules.Manager
@@ -62,7 +190,9 @@ func (m Managers) AlertingRules() []AlertingRule {
 
 func (r *RuleGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	rs := struct {
-		String string `yaml:"partial_response_strategy"`
+		String           string         `yaml:"partial_response_strategy"`
+		QueryOffset      model.Duration `yaml:"query_offset"`
+		EvaluationJitter model.Duration `yaml:"evaluation_jitter"`
 	}{}
 
 	errMsg := fmt.Sprintf("failed to unmarshal 'partial_response_strategy'. Possible values are %s", strings.Join(storepb.PartialResponseStrategyValues, ","))
@@ -85,9 +215,15 @@ func (r *RuleGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		p = storepb.PartialResponseStrategy_value[storepb.PartialResponseStrategy_ABORT.String()]
 	}
 
+	if rs.QueryOffset < 0 || rs.EvaluationJitter < 0 {
+		return errors.Errorf("group %q: query_offset and evaluation_jitter must not be negative", rg.Name)
+	}
+
 	ps := storepb.PartialResponseStrategy(p)
 	r.RuleGroup = rg
 	r.PartialResponseStrategy = &ps
+	r.QueryOffset = rs.QueryOffset
+	r.EvaluationJitter = rs.EvaluationJitter
 	return nil
 }
 
@@ -101,19 +237,25 @@ func (r RuleGroup) MarshalYAML() (interface{}, error) {
 	rs := struct {
 		RuleGroup               rulefmt.RuleGroup `yaml:",inline"`
 		PartialResponseStrategy *string           `yaml:"partial_response_strategy,omitempty"`
+		QueryOffset             model.Duration    `yaml:"query_offset,omitempty"`
+		EvaluationJitter        model.Duration    `yaml:"evaluation_jitter,omitempty"`
 	}{
 		RuleGroup:               r.RuleGroup,
 		PartialResponseStrategy: ps,
+		QueryOffset:             r.QueryOffset,
+		EvaluationJitter:        r.EvaluationJitter,
 	}
 	return rs, nil
 }
 
 // Update updates rules from given files to all managers we hold. We decide which groups should go where, based on
-// special field in RuleGroup file.
-func (m *Managers) Update(dataDir string, evalInterval time.Duration, files []string) error {
+// special field in RuleGroup file. Query options of groups are stored in the given queryOpts, if not nil. Only groups
+// of the given shard are loaded.
+func (m *Managers) Update(dataDir string, evalInterval time.Duration, files []string, queryOpts *GroupQueryOptions, shard *Shard) error {
 	var (
 		errs     = tsdberrors.MultiError{}
 		filesMap = map[storepb.PartialResponseStrategy][]string{}
+		optsMap  = map[storepb.PartialResponseStrategy]map[string]QueryOptions{}
 	)
 
 	if err := os.RemoveAll(path.Join(dataDir, tmpRuleDir)); err != nil {
@@ -140,6 +282,13 @@ func (m *Managers) Update(dataDir string, evalInterval time.Duration, files []st
 		// rules.Manager. The problem is that it uses yaml.UnmarshalStrict for some reasons.
 		mapped := map[storepb.PartialResponseStrategy]*rulefmt.RuleGroups{}
 		for _, rg := range rg.Groups {
+			if !shard.Contains(rg.Name) {
+				continue
+			}
+			if err := addQueryOptions(optsMap, rg, evalInterval); err != nil {
+				errs = append(errs, errors.Wrapf(err, "file %s", fn))
+				continue
+			}
 			if _, ok := mapped[*rg.PartialResponseStrategy]; !ok {
 				mapped[*rg.PartialResponseStrategy] = &rulefmt.RuleGroups{}
 			}
@@ -168,6 +317,10 @@ func (m *Managers) Update(dataDir string, evalInterval time.Duration, files []st
 
 	}
 
+	if queryOpts != nil {
+		queryOpts.set(optsMap)
+	}
+
 	for s, fs := range filesMap {
 		updater, ok := (*m)[s]
 		if !ok {
@@ -184,3 +337,38 @@ func (m *Managers) Update(dataDir string, evalInterval time.Duration, files []st
 
 	return errs.Err()
 }
+
+// addQueryOptions adds query options of rules of the given group to the map. Jitter has to be lower than the group
+// interval, so evaluations do not overlap. Groups with the same partial response
+// strategy cannot use the same query expression with different options, as they would be indistinguishable.
+func addQueryOptions(optsMap map[storepb.PartialResponseStrategy]map[string]QueryOptions, rg RuleGroup, evalInterval time.Duration) error {
+	o := QueryOptions{Offset: time.Duration(rg.QueryOffset), Jitter: time.Duration(rg.EvaluationJitter)}
+
+	interval := evalInterval
+	if rg.Interval != 0 {
+		interval = time.Duration(rg.Interval)
+	}
+	if o.Jitter >= interval {
+		return errors.Errorf("group %q: evaluation_jitter %s must be lower than group interval %s", rg.Name, o.Jitter, interval)
+	}
+
+	if _, ok := optsMap[*rg.PartialResponseStrategy]; !ok {
+		optsMap[*rg.PartialResponseStrategy] = map[string]QueryOptions{}
+	}
+	opts := optsMap[*rg.PartialResponseStrategy]
+
+	for _, r := range rg.Rules {
+		expr, err := promql.ParseExpr(r.Expr)
+		if err != nil {
+			// Invalid expression is reported by the rule manager.
+			continue
+		}
+		// Rule manager queries using the expression printed back from its parsed form.
+		q := expr.String()
+		if prev, ok := opts[q]; ok && prev != o {
+			return errors.Errorf("group %q: expression %q is used with different query_offset or evaluation_jitter by another group with the same partial_response_strategy", rg.Name, r.Expr)
+		}
+		opts[q] = o
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package thanosrule
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -10,7 +12,10 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/log"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
@@ -99,7 +104,7 @@ groups:
 		path.Join(dir, "wrong.yaml"),
 		path.Join(dir, "combined.yaml"),
 		path.Join(dir, "combined_wrong.yaml"),
-	})
+	}, nil, nil)
 
 	testutil.NotOk(t, err)
 	testutil.Assert(t, strings.HasPrefix(err.Error(), "2 errors: failed to unmarshal 'partial_response_strategy'"), err.Error())
@@ -125,6 +130,170 @@ groups:
 	testutil.Equals(t, "something7", g[3].Name())
 }
 
+func TestUpdate_QueryOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_query_options")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	testutil.Ok(t, ioutil.WriteFile(path.Join(dir, "options.yaml"), []byte(`
+groups:
+- name: "offset"
+  query_offset: "1m"
+  evaluation_jitter: "5s"
+  rules:
+  - record: "some"
+    expr: "sum(up)"
+- name: "same expression with other strategy"
+  partial_response_strategy: "warn"
+  rules:
+  - record: "some"
+    expr: "sum (up)"
+- name: "no options"
+  rules:
+  - record: "other"
+    expr: "up"
+`), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(path.Join(dir, "wrong.yaml"), []byte(`
+groups:
+- name: "jitter exceeds interval"
+  evaluation_jitter: "10s" # Err 1
+  rules:
+  - record: "some"
+    expr: "rate(up[5m])"
+- name: "conflicting options"
+  query_offset: "2m" # Err 2
+  rules:
+  - record: "some"
+    expr: "sum(up)"
+`), os.ModePerm))
+
+	opts := rules.ManagerOptions{
+		Logger: log.NewLogfmtLogger(os.Stderr),
+	}
+	m := Managers{
+		storepb.PartialResponseStrategy_ABORT: rules.NewManager(&opts),
+		storepb.PartialResponseStrategy_WARN:  rules.NewManager(&opts),
+	}
+	queryOpts := &GroupQueryOptions{}
+
+	err = m.Update(dir, 10*time.Second, []string{
+		path.Join(dir, "options.yaml"),
+		path.Join(dir, "wrong.yaml"),
+	}, queryOpts, nil)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.HasPrefix(err.Error(), "2 errors: "), err.Error())
+
+	// Invalid groups are not loaded.
+	testutil.Equals(t, 2, len(m[storepb.PartialResponseStrategy_ABORT].RuleGroups()))
+	testutil.Equals(t, 1, len(m[storepb.PartialResponseStrategy_WARN].RuleGroups()))
+
+	// Options are looked up by expression as printed by the rule manager.
+	testutil.Equals(t, QueryOptions{Offset: time.Minute, Jitter: 5 * time.Second}, queryOpts.Get(storepb.PartialResponseStrategy_ABORT, "sum(up)"))
+	testutil.Equals(t, QueryOptions{}, queryOpts.Get(storepb.PartialResponseStrategy_WARN, "sum(up)"))
+	testutil.Equals(t, QueryOptions{}, queryOpts.Get(storepb.PartialResponseStrategy_ABORT, "up"))
+	testutil.Equals(t, QueryOptions{}, queryOpts.Get(storepb.PartialResponseStrategy_ABORT, "rate(up[5m])"))
+}
+
+func TestShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_shard")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	testutil.Ok(t, ioutil.WriteFile(path.Join(dir, "groups.yaml"), []byte(`
+groups:
+- name: "a"
+  rules:
+  - record: "a"
+    expr: "up"
+- name: "b"
+  rules:
+  - record: "b"
+    expr: "up"
+- name: "c"
+  rules:
+  - record: "c"
+    expr: "up"
+- name: "d"
+  rules:
+  - record: "d"
+    expr: "up"
+`), os.ModePerm))
+
+	var nilShard *Shard
+	testutil.Assert(t, nilShard.Contains("a"), "nil shard does not contain group")
+	testutil.Assert(t, (&Shard{Index: 0, Total: 1}).Contains("a"), "single shard does not contain group")
+
+	// Every group is evaluated by exactly one of the shards.
+	opts := rules.ManagerOptions{
+		Logger: log.NewLogfmtLogger(os.Stderr),
+	}
+	groups := map[string]struct{}{}
+	for i := uint64(0); i < 3; i++ {
+		m := Managers{
+			storepb.PartialResponseStrategy_ABORT: rules.NewManager(&opts),
+			storepb.PartialResponseStrategy_WARN:  rules.NewManager(&opts),
+		}
+		shard := &Shard{Index: i, Total: 3}
+		testutil.Ok(t, m.Update(path.Join(dir, fmt.Sprintf("shard-%d", i)), 10*time.Second, []string{path.Join(dir, "groups.yaml")}, nil, shard))
+
+		for _, g := range m[storepb.PartialResponseStrategy_ABORT].RuleGroups() {
+			testutil.Assert(t, shard.Contains(g.Name()), "group %s loaded by wrong shard", g.Name())
+			_, ok := groups[g.Name()]
+			testutil.Assert(t, !ok, "group %s loaded by multiple shards", g.Name())
+			groups[g.Name()] = struct{}{}
+		}
+	}
+	testutil.Equals(t, 4, len(groups))
+}
+
+func TestEvalWarnings(t *testing.T) {
+	expr, err := promql.ParseExpr("sum (up)")
+	testutil.Ok(t, err)
+	r := rules.NewRecordingRule("some", expr, labels.Labels{})
+
+	// Not evaluated rules keep their health.
+	var w *EvalWarnings
+	h, err := w.Health(storepb.PartialResponseStrategy_WARN, r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, rules.HealthUnknown, h)
+
+	_, err = r.Eval(context.Background(), time.Now(), func(context.Context, string, time.Time) (promql.Vector, error) {
+		return promql.Vector{}, nil
+	}, nil)
+	testutil.Ok(t, err)
+
+	w = NewEvalWarnings(nil)
+	h, err = w.Health(storepb.PartialResponseStrategy_WARN, r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, rules.HealthGood, h)
+
+	w.Set(storepb.PartialResponseStrategy_WARN, "sum(up)", []string{"store a unavailable", "store b unavailable"})
+	h, err = w.Health(storepb.PartialResponseStrategy_WARN, r)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, HealthWarn, h)
+	testutil.Equals(t, "partial response: store a unavailable, store b unavailable", err.Error())
+	testutil.Equals(t, 1.0, promtest.ToFloat64(w.rulesWithWarnings.WithLabelValues("warn")))
+
+	// Warnings are kept per strategy.
+	h, err = w.Health(storepb.PartialResponseStrategy_ABORT, r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, rules.HealthGood, h)
+
+	// Evaluation without warnings clears them.
+	w.Set(storepb.PartialResponseStrategy_WARN, "sum(up)", nil)
+	h, err = w.Health(storepb.PartialResponseStrategy_WARN, r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, rules.HealthGood, h)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(w.rulesWithWarnings.WithLabelValues("warn")))
+
+	w.Set(storepb.PartialResponseStrategy_WARN, "sum(up)", []string{"store a unavailable"})
+	w.Reset()
+	h, err = w.Health(storepb.PartialResponseStrategy_WARN, r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, rules.HealthGood, h)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(w.rulesWithWarnings.WithLabelValues("warn")))
+}
+
 func TestRuleGroupMarshalYAML(t *testing.T) {
 	const expected = `groups:
 - name: something1
@@ -32,6 +32,7 @@ type metrics struct {
 	uploads           prometheus.Counter
 	uploadFailures    prometheus.Counter
 	uploadedCompacted prometheus.Gauge
+	uploadsBackfilled prometheus.Counter
 }
 
 func newMetrics(r prometheus.Registerer, uploadCompacted bool) *metrics {
@@ -53,6 +54,10 @@ func newMetrics(r prometheus.Registerer, uploadCompacted bool) *metrics {
 		Name: "thanos_shipper_upload_failures_total",
 		Help: "Total number of failed object uploads",
 	})
+	m.uploadsBackfilled = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_shipper_backfilled_uploads_total",
+		Help: "Total number of uploaded blocks detected as backfilled into the TSDB directory",
+	})
 	m.uploadedCompacted = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "thanos_shipper_upload_compacted_done",
 		Help: "If 1 it means shipper uploaded all compacted blocks from the filesystem.",
@@ -64,6 +69,7 @@ func newMetrics(r prometheus.Registerer, uploadCompacted bool) *metrics {
 			m.dirSyncFailures,
 			m.uploads,
 			m.uploadFailures,
+			m.uploadsBackfilled,
 		)
 		if uploadCompacted {
 			r.MustRegister(m.uploadedCompacted)
@@ -82,10 +88,15 @@ type Shipper struct {
 	labels          func() labels.Labels
 	source          metadata.SourceType
 	uploadCompacted bool
+	// uploadBackfilled enables upload of compacted blocks detected as backfilled.
+	uploadBackfilled bool
+	hints            func() *metadata.ThanosHints
 }
 
 // New creates a new shipper that detects new TSDB blocks in dir and uploads them
 // to remote if necessary. It attaches the Thanos metadata section in each meta JSON file.
+// If uploadBackfilled is true, compacted blocks which do not overlap with any block in the bucket
+// are considered as backfilled into dir, e.g. by promtool, and uploaded as well once their index is verified.
 func New(
 	logger log.Logger,
 	r prometheus.Registerer,
@@ -93,6 +104,7 @@ func New(
 	bucket objstore.Bucket,
 	lbls func() labels.Labels,
 	source metadata.SourceType,
+	uploadBackfilled bool,
 ) *Shipper {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -102,12 +114,13 @@ func New(
 	}
 
 	return &Shipper{
-		logger:  logger,
-		dir:     dir,
-		bucket:  bucket,
-		labels:  lbls,
-		metrics: newMetrics(r, false),
-		source:  source,
+		logger:           logger,
+		dir:              dir,
+		bucket:           bucket,
+		labels:           lbls,
+		metrics:          newMetrics(r, false),
+		source:           source,
+		uploadBackfilled: uploadBackfilled,
 	}
 }
 
@@ -140,6 +153,12 @@ func NewWithCompacted(
 	}
 }
 
+// SetHints sets the function returning hints for the compactor attached to the Thanos metadata section of uploaded
+// blocks. It must be called before the first Sync.
+func (s *Shipper) SetHints(hints func() *metadata.ThanosHints) {
+	s.hints = hints
+}
+
 // Timestamps returns the minimum timestamp for which data is available and the highest timestamp
 // of blocks that were successfully uploaded.
 func (s *Shipper) Timestamps() (minTime, maxSyncTime int64, err error) {
@@ -224,11 +243,11 @@ func (c *lazyOverlapChecker) sync(ctx context.Context) error {
 	return nil
 }
 
-func (c *lazyOverlapChecker) IsOverlapping(ctx context.Context, newMeta tsdb.BlockMeta) error {
+func (c *lazyOverlapChecker) overlaps(ctx context.Context, newMeta tsdb.BlockMeta) (tsdb.Overlaps, error) {
 	if !c.synced {
 		level.Info(c.logger).Log("msg", "gathering all existing blocks from the remote bucket for check", "id", newMeta.ULID.String())
 		if err := c.sync(ctx); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -237,8 +256,16 @@ func (c *lazyOverlapChecker) IsOverlapping(ctx context.Context, newMeta tsdb.Blo
 	sort.Slice(metas, func(i, j int) bool {
 		return metas[i].MinTime < metas[j].MinTime
 	})
-	if o := tsdb.OverlappingBlocks(metas); len(o) > 0 {
-		// TODO(bwplotka): Consider checking if overlaps relates to block in concern?
+	// TODO(bwplotka): Consider checking if overlaps relates to block in concern?
+	return tsdb.OverlappingBlocks(metas), nil
+}
+
+func (c *lazyOverlapChecker) IsOverlapping(ctx context.Context, newMeta tsdb.BlockMeta) error {
+	o, err := c.overlaps(ctx, newMeta)
+	if err != nil {
+		return err
+	}
+	if len(o) > 0 {
 		return errors.Errorf("shipping compacted block %s is blocked; overlap spotted: %s", newMeta.ULID, o.String())
 	}
 	return nil
@@ -300,13 +327,37 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 		}
 
 		// We only ship of the first compacted block level as normal flow.
+		var backfilled bool
 		if m.Compaction.Level > 1 {
-			if !s.uploadCompacted {
+			switch {
+			case s.uploadCompacted:
+				if err := checker.IsOverlapping(ctx, m.BlockMeta); err != nil {
+					level.Error(s.logger).Log("msg", "found overlap or error during sync, cannot upload compacted block", "err", err)
+					uploadErrs++
+					return nil
+				}
+			case s.uploadBackfilled:
+				// Blocks compacted by Prometheus overlap with their source blocks we uploaded before. Blocks
+				// not overlapping with anything in the bucket were backfilled and their data is missing there.
+				o, err := checker.overlaps(ctx, m.BlockMeta)
+				if err != nil {
+					level.Error(s.logger).Log("msg", "error during sync, cannot check compacted block for backfill", "block", m.ULID, "err", err)
+					uploadErrs++
+					return nil
+				}
+				if len(o) > 0 {
+					return nil
+				}
+				backfilled = true
+			default:
 				return nil
 			}
+		}
 
-			if err := checker.IsOverlapping(ctx, m.BlockMeta); err != nil {
-				level.Error(s.logger).Log("msg", "found overlap or error during sync, cannot upload compacted block", "err", err)
+		if backfilled {
+			level.Info(s.logger).Log("msg", "detected backfilled block, verifying it before upload", "block", m.ULID)
+			if err := block.VerifyIndex(s.logger, filepath.Join(s.dir, m.ULID.String(), block.IndexFilename), m.MinTime, m.MaxTime); err != nil {
+				level.Error(s.logger).Log("msg", "backfilled block is invalid, cannot upload it", "block", m.ULID, "err", err)
 				uploadErrs++
 				return nil
 			}
@@ -323,6 +374,11 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 
 		uploaded++
 		s.metrics.uploads.Inc()
+		if backfilled {
+			// Further backfilled blocks must not overlap with this one.
+			checker.metas = append(checker.metas, m.BlockMeta)
+			s.metrics.uploadsBackfilled.Inc()
+		}
 		return nil
 	}); err != nil {
 		s.metrics.dirSyncFailures.Inc()
@@ -374,6 +430,9 @@ func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
 		meta.Thanos.Labels = lset.Map()
 	}
 	meta.Thanos.Source = s.source
+	if s.hints != nil {
+		meta.Thanos.Hints = s.hints()
+	}
 	if err := metadata.Write(s.logger, updir, meta); err != nil {
 		return errors.Wrap(err, "write meta file")
 	}
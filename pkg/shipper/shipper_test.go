@@ -1,6 +1,7 @@
 package shipper
 
 import (
+	"context"
 	"io/ioutil"
 	"math"
 	"os"
@@ -9,8 +10,12 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/ulid"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -21,7 +26,7 @@ func TestShipperTimestamps(t *testing.T) {
 		testutil.Ok(t, os.RemoveAll(dir))
 	}()
 
-	s := New(nil, nil, dir, nil, nil, metadata.TestSource)
+	s := New(nil, nil, dir, nil, nil, metadata.TestSource, false)
 
 	// Missing thanos meta file.
 	_, _, err = s.Timestamps()
@@ -77,3 +82,65 @@ func TestShipperTimestamps(t *testing.T) {
 	testutil.Equals(t, int64(1000), mint)
 	testutil.Equals(t, int64(2000), maxt)
 }
+
+func TestShipper_SyncBackfilledBlocks(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "shipper-test-backfill")
+	testutil.Ok(t, err)
+	defer func() {
+		testutil.Ok(t, os.RemoveAll(dir))
+	}()
+
+	extLset := labels.FromStrings("prometheus", "prom-1")
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+
+	createBlock := func(mint, maxt int64, level int) ulid.ULID {
+		id, err := testutil.CreateBlock(ctx, dir, series, 10, mint, maxt, nil, 0)
+		testutil.Ok(t, err)
+		if level > 1 {
+			m, err := metadata.Read(path.Join(dir, id.String()))
+			testutil.Ok(t, err)
+			m.Compaction.Level = level
+			testutil.Ok(t, metadata.Write(log.NewNopLogger(), path.Join(dir, id.String()), m))
+		}
+		return id
+	}
+	var (
+		// Block uploaded in the normal flow.
+		b1 = createBlock(0, 1000, 1)
+		// Block compacted by Prometheus, overlapping with the uploaded one.
+		b2 = createBlock(0, 2000, 2)
+		// Block backfilled into the TSDB directory.
+		b3 = createBlock(5000, 6000, 2)
+	)
+
+	bkt := inmem.NewBucket()
+	s := New(nil, nil, dir, bkt, func() labels.Labels { return extLset }, metadata.TestSource, false)
+	uploaded, err := s.Sync(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, uploaded)
+
+	hints := &metadata.ThanosHints{Tenant: "a", DisableDownsampling: true}
+	s = New(nil, nil, dir, bkt, func() labels.Labels { return extLset }, metadata.TestSource, true)
+	s.SetHints(func() *metadata.ThanosHints { return hints })
+	uploaded, err = s.Sync(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, uploaded)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(s.metrics.uploadsBackfilled))
+
+	for id, exists := range map[ulid.ULID]bool{b1: true, b2: false, b3: true} {
+		ok, err := bkt.Exists(ctx, path.Join(id.String(), block.MetaFilename))
+		testutil.Ok(t, err)
+		testutil.Equals(t, exists, ok)
+	}
+	m, err := block.DownloadMeta(ctx, log.NewNopLogger(), bkt, b3)
+	testutil.Ok(t, err)
+	testutil.Equals(t, extLset.Map(), m.Thanos.Labels)
+	testutil.Equals(t, hints, m.Thanos.Hints)
+
+	// Backfilled block was uploaded once.
+	uploaded, err = s.Sync(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, uploaded)
+}
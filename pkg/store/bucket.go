@@ -37,6 +37,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/pool"
+	"github.com/thanos-io/thanos/pkg/requestid"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/strutil"
@@ -84,9 +85,15 @@ type bucketStoreMetrics struct {
 	seriesGetAllDuration  prometheus.Histogram
 	seriesMergeDuration   prometheus.Histogram
 	resultSeriesCount     prometheus.Summary
+	chunksSkipped         prometheus.Counter
 	chunkSizeBytes        prometheus.Histogram
 	queriesDropped        prometheus.Counter
 	queriesLimit          prometheus.Gauge
+
+	tenantQueries         *prometheus.CounterVec
+	tenantSeriesTouched   *prometheus.CounterVec
+	tenantDataFetched     *prometheus.CounterVec
+	tenantFetchOperations *prometheus.CounterVec
 }
 
 func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
@@ -153,6 +160,10 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 		Name: "thanos_bucket_store_series_result_series",
 		Help: "Number of series observed in the final result of a query.",
 	})
+	m.chunksSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_series_chunks_skipped_total",
+		Help: "Total number of chunks skipped, as query hints of the request show they cannot influence the query result.",
+	})
 
 	m.chunkSizeBytes = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: "thanos_bucket_store_sent_chunk_size_bytes",
@@ -171,6 +182,23 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 		Help: "Number of maximum concurrent queries.",
 	})
 
+	m.tenantQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_tenant_series_requests_total",
+		Help: "Total number of series requests touching blocks of the tenant, given by the value of the tenant external label of blocks.",
+	}, []string{"tenant"})
+	m.tenantSeriesTouched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_tenant_series_touched_total",
+		Help: "Total number of series of blocks of the tenant touched by series requests.",
+	}, []string{"tenant"})
+	m.tenantDataFetched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_tenant_data_fetched_bytes_total",
+		Help: "Total size of items of a data type of blocks of the tenant fetched from the bucket by series requests.",
+	}, []string{"tenant", "data_type"})
+	m.tenantFetchOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_tenant_fetch_operations_total",
+		Help: "Total number of get range operations of the bucket fetching data of blocks of the tenant for series requests.",
+	}, []string{"tenant"})
+
 	if reg != nil {
 		reg.MustRegister(
 			m.blockLoads,
@@ -186,9 +214,14 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 			m.seriesGetAllDuration,
 			m.seriesMergeDuration,
 			m.resultSeriesCount,
+			m.chunksSkipped,
 			m.chunkSizeBytes,
 			m.queriesDropped,
 			m.queriesLimit,
+			m.tenantQueries,
+			m.tenantSeriesTouched,
+			m.tenantDataFetched,
+			m.tenantFetchOperations,
 		)
 	}
 	return &m
@@ -201,6 +234,14 @@ type indexCache interface {
 	Series(b ulid.ULID, id uint64) ([]byte, bool)
 }
 
+// ActiveQueryTracker tracks queries in flight, e.g. promql.ActiveQueryTracker, which logs them to a memory mapped file
+// surviving crashes, so queries in flight during a crash are logged on the next start.
+type ActiveQueryTracker interface {
+	// Insert tracks the query and returns the index to delete it with once it is done.
+	Insert(query string) int
+	Delete(insertIndex int)
+}
+
 // FilterConfig is a configuration, which Store uses for filtering metrics.
 type FilterConfig struct {
 	MinTime, MaxTime model.TimeOrDurationValue
@@ -232,12 +273,19 @@ type BucketStore struct {
 	// samplesLimiter limits the number of samples per each Series() call.
 	samplesLimiter *Limiter
 	partitioner    partitioner
+	// fetches limits concurrent chunk range reads of all blocks and schedules them by deadline of their requests.
+	fetches *fetchScheduler
 
 	filterConfig  *FilterConfig
 	relabelConfig []*relabel.Config
 
 	labelSets                map[uint64]labels.Labels
 	enableCompatibilityLabel bool
+
+	// tenantLabel is the external label of blocks by which resources used by series requests are accounted.
+	tenantLabel string
+	// activeQueries tracks series requests in flight, if set.
+	activeQueries ActiveQueryTracker
 }
 
 // NewBucketStore creates a new bucket backed store that implements the store API against
@@ -253,9 +301,14 @@ func NewBucketStore(
 	maxConcurrent int,
 	debugLogging bool,
 	blockSyncConcurrency int,
+	maxGapSize uint64,
+	maxRangeSize uint64,
 	filterConf *FilterConfig,
 	relabelConfig []*relabel.Config,
 	enableCompatibilityLabel bool,
+	tenantLabel string,
+	maxConcurrentFetches int,
+	activeQueries ActiveQueryTracker,
 ) (*BucketStore, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -264,14 +317,15 @@ func NewBucketStore(
 	if maxConcurrent < 0 {
 		return nil, errors.Errorf("max concurrency value cannot be lower than 0 (got %v)", maxConcurrent)
 	}
+	if maxConcurrentFetches < 0 {
+		return nil, errors.Errorf("max concurrent fetches value cannot be lower than 0 (got %v)", maxConcurrentFetches)
+	}
 
 	chunkPool, err := pool.NewBytesPool(maxChunkSize, 50e6, 2, maxChunkPoolBytes)
 	if err != nil {
 		return nil, errors.Wrap(err, "create chunk pool")
 	}
 
-	const maxGapSize = 512 * 1024
-
 	metrics := newBucketStoreMetrics(reg)
 	s := &BucketStore{
 		logger:               logger,
@@ -288,10 +342,13 @@ func NewBucketStore(
 			extprom.WrapRegistererWithPrefix("thanos_bucket_store_series_", reg),
 		),
 		samplesLimiter:           NewLimiter(maxSampleCount, metrics.queriesDropped),
-		partitioner:              gapBasedPartitioner{maxGapSize: maxGapSize},
+		partitioner:              newGapBasedPartitioner(maxGapSize, maxRangeSize, reg),
+		fetches:                  newFetchScheduler(maxConcurrentFetches, reg),
 		filterConfig:             filterConf,
 		relabelConfig:            relabelConfig,
 		enableCompatibilityLabel: enableCompatibilityLabel,
+		tenantLabel:              tenantLabel,
+		activeQueries:            activeQueries,
 	}
 	s.metrics = metrics
 
@@ -480,6 +537,7 @@ func (s *BucketStore) addBlock(ctx context.Context, id ulid.ULID) (err error) {
 		s.indexCache,
 		s.chunkPool,
 		s.partitioner,
+		s.fetches,
 	)
 	if err != nil {
 		return errors.Wrap(err, "new bucket block")
@@ -536,6 +594,21 @@ func (s *BucketStore) removeBlock(id ulid.ULID) error {
 	return os.RemoveAll(b.dir)
 }
 
+// Metas returns metas of all blocks loaded by the store, sorted by block ID.
+func (s *BucketStore) Metas() []metadata.Meta {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := make([]metadata.Meta, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		res = append(res, *b.meta)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ULID.Compare(res[j].ULID) < 0
+	})
+	return res
+}
+
 // TimeRange returns the minimum and maximum timestamp of data available in the store.
 func (s *BucketStore) TimeRange() (mint, maxt int64) {
 	s.mtx.RLock()
@@ -705,7 +778,7 @@ func blockSeries(
 			return s.lset[i].Name < s.lset[j].Name
 		})
 
-		for _, meta := range chks {
+		for i, meta := range chks {
 			if meta.MaxTime < req.MinTime {
 				continue
 			}
@@ -713,6 +786,15 @@ func blockSeries(
 				break
 			}
 
+			nextMinTime := int64(math.MaxInt64)
+			if i+1 < len(chks) {
+				nextMinTime = chks[i+1].MinTime
+			}
+			if !chunkNeeded(req.Hints, meta.MinTime, meta.MaxTime, nextMinTime) {
+				indexr.stats.chunksSkipped++
+				continue
+			}
+
 			if err := chunkr.addPreload(meta.Ref); err != nil {
 				return nil, nil, errors.Wrap(err, "add chunk preload")
 			}
@@ -774,13 +856,13 @@ func populateChunk(out *storepb.AggrChunk, in chunkenc.Chunk, aggrs []storepb.Ag
 			}
 			out.Sum = &storepb.Chunk{Type: storepb.Chunk_XOR, Data: x.Bytes()}
 		case storepb.Aggr_MIN:
-			x, err := ac.Get(downsample.AggrMin)
+			x, err := getMinMaxAggr(ac, downsample.AggrMin)
 			if err != nil {
 				return errors.Errorf("aggregate %s does not exist", downsample.AggrMin)
 			}
 			out.Min = &storepb.Chunk{Type: storepb.Chunk_XOR, Data: x.Bytes()}
 		case storepb.Aggr_MAX:
-			x, err := ac.Get(downsample.AggrMax)
+			x, err := getMinMaxAggr(ac, downsample.AggrMax)
 			if err != nil {
 				return errors.Errorf("aggregate %s does not exist", downsample.AggrMax)
 			}
@@ -796,6 +878,16 @@ func populateChunk(out *storepb.AggrChunk, in chunkenc.Chunk, aggrs []storepb.Ag
 	return nil
 }
 
+// getMinMaxAggr returns the min or max aggregate of the chunk. Chunks of counter series have no min and max aggregates,
+// their counter aggregate is returned instead. It approximates both for counters without resets.
+func getMinMaxAggr(ac downsample.AggrChunk, at downsample.AggrType) (chunkenc.Chunk, error) {
+	x, err := ac.Get(at)
+	if err == downsample.ErrAggrNotExist {
+		return ac.Get(downsample.AggrCounter)
+	}
+	return x, err
+}
+
 // debugFoundBlockSetOverview logs on debug level what exactly blocks we used for query in terms of
 // labels and resolution. This is important because we allow mixed resolution results, so it is quite crucial
 // to be aware what exactly resolution we see on query.
@@ -833,6 +925,8 @@ func debugFoundBlockSetOverview(logger log.Logger, mint, maxt, maxResolutionMill
 
 // Series implements the storepb.StoreServer interface.
 func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) (err error) {
+	logger := requestid.Logger(srv.Context(), s.logger)
+
 	{
 		span, _ := tracing.StartSpan(srv.Context(), "store_query_gate_ismyturn")
 		err := s.queryGate.IsMyTurn(srv.Context())
@@ -843,6 +937,16 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	}
 	defer s.queryGate.Done()
 
+	// Series requests are tracked once they passed the gate, so the tracker never holds more than maxConcurrent queries.
+	if s.activeQueries != nil {
+		q := fmt.Sprintf("series%s start=%d end=%d max_resolution=%d", matchersString(req.Matchers), req.MinTime, req.MaxTime, req.MaxResolutionWindow)
+		if id, ok := requestid.FromContext(srv.Context()); ok {
+			q += " request_id=" + id
+		}
+		i := s.activeQueries.Insert(q)
+		defer s.activeQueries.Delete(i)
+	}
+
 	matchers, err := translateMatchers(req.Matchers)
 	if err != nil {
 		return status.Error(codes.InvalidArgument, err.Error())
@@ -851,10 +955,11 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	req.MaxTime = s.limitMaxTime(req.MaxTime)
 
 	var (
-		stats = &queryStats{}
-		g     run.Group
-		res   []storepb.SeriesSet
-		mtx   sync.Mutex
+		stats   = &queryStats{}
+		g       run.Group
+		res     []storepb.SeriesSet
+		mtx     sync.Mutex
+		tenants = map[string]struct{}{}
 	)
 	s.mtx.RLock()
 
@@ -866,13 +971,17 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		blocks := bs.getFor(req.MinTime, req.MaxTime, req.MaxResolutionWindow)
 
 		if s.debugLogging {
-			debugFoundBlockSetOverview(s.logger, req.MinTime, req.MaxTime, req.MaxResolutionWindow, bs.labels, blocks)
+			debugFoundBlockSetOverview(logger, req.MinTime, req.MaxTime, req.MaxResolutionWindow, bs.labels, blocks)
 		}
 
 		for _, b := range blocks {
 			stats.blocksQueried++
 
 			b := b
+			tenant := s.tenant(b)
+			if s.tenantLabel != "" {
+				tenants[tenant] = struct{}{}
+			}
 			ctx, cancel := context.WithCancel(srv.Context())
 
 			// We must keep the readers open until all their data has been sent.
@@ -880,8 +989,8 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 			chunkr := b.chunkReader(ctx)
 
 			// Defer all closes to the end of Series method.
-			defer runutil.CloseWithLogOnErr(s.logger, indexr, "series block")
-			defer runutil.CloseWithLogOnErr(s.logger, chunkr, "series block")
+			defer runutil.CloseWithLogOnErr(logger, indexr, "series block")
+			defer runutil.CloseWithLogOnErr(logger, chunkr, "series block")
 
 			g.Add(func() error {
 				part, pstats, err := blockSeries(ctx,
@@ -901,6 +1010,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 				res = append(res, part)
 				stats = stats.merge(pstats)
 				mtx.Unlock()
+				s.accountTenant(tenant, pstats)
 
 				return nil
 			}, func(err error) {
@@ -927,8 +1037,12 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		s.metrics.seriesDataSizeTouched.WithLabelValues("chunks").Observe(float64(stats.chunksTouchedSizeSum))
 		s.metrics.seriesDataSizeFetched.WithLabelValues("chunks").Observe(float64(stats.chunksFetchedSizeSum))
 		s.metrics.resultSeriesCount.Observe(float64(stats.mergedSeriesCount))
+		s.metrics.chunksSkipped.Add(float64(stats.chunksSkipped))
+		for tenant := range tenants {
+			s.metrics.tenantQueries.WithLabelValues(tenant).Inc()
+		}
 
-		level.Debug(s.logger).Log("msg", "stats query processed",
+		level.Debug(logger).Log("msg", "stats query processed",
 			"stats", fmt.Sprintf("%+v", stats), "err", err)
 	}()
 
@@ -940,6 +1054,15 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		span.Finish()
 
 		if err != nil {
+			// With partial response allowed, tell the client which limit was hit instead of failing the whole request.
+			if lerr, ok := errors.Cause(err).(*LimitError); ok && !req.PartialResponseDisabled {
+				level.Warn(logger).Log("msg", "samples limit exceeded, returning partial response", "err", err)
+				w := storepb.LimitWarning{Limit: "samples", Value: lerr.Limit, Got: lerr.Got}
+				if err := srv.Send(storepb.NewLimitWarnSeriesResponse(w)); err != nil {
+					return status.Error(codes.Unknown, errors.Wrap(err, "send limit warning response").Error())
+				}
+				return nil
+			}
 			return status.Error(codes.Aborted, err.Error())
 		}
 		stats.getAllDuration = time.Since(begin)
@@ -979,6 +1102,26 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	return nil
 }
 
+// tenant returns the value of the tenant label of the block.
+func (s *BucketStore) tenant(b *bucketBlock) string {
+	if s.tenantLabel == "" {
+		return ""
+	}
+	return b.meta.Thanos.Labels[s.tenantLabel]
+}
+
+// accountTenant accounts resources used by a series request in a block of the tenant, if tenant accounting is enabled.
+func (s *BucketStore) accountTenant(tenant string, stats *queryStats) {
+	if s.tenantLabel == "" {
+		return
+	}
+	s.metrics.tenantSeriesTouched.WithLabelValues(tenant).Add(float64(stats.seriesTouched))
+	s.metrics.tenantDataFetched.WithLabelValues(tenant, "postings").Add(float64(stats.postingsFetchedSizeSum))
+	s.metrics.tenantDataFetched.WithLabelValues(tenant, "series").Add(float64(stats.seriesFetchedSizeSum))
+	s.metrics.tenantDataFetched.WithLabelValues(tenant, "chunks").Add(float64(stats.chunksFetchedSizeSum))
+	s.metrics.tenantFetchOperations.WithLabelValues(tenant).Add(float64(stats.postingsFetchCount + stats.seriesFetchCount + stats.chunksFetchCount))
+}
+
 func chunksSize(chks []storepb.AggrChunk) (size int) {
 	for _, chk := range chks {
 		size += chk.Size() // This gets the encoded proto size.
@@ -1031,13 +1174,22 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 	var sets [][]string
 
 	for _, b := range s.blocks {
+		match, matchers, err := labelValuesMatchers(req.Label, req.Matchers, labels.FromMap(b.meta.Thanos.Labels))
+		if err != nil {
+			s.mtx.RUnlock()
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if !match {
+			continue
+		}
+
 		indexr := b.indexReader(gctx)
 		// TODO(fabxc): only aggregate chunk metas first and add a subsequent fetch stage
 		// where we consolidate requests.
 		g.Go(func() error {
 			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label values")
 
-			res := indexr.LabelValues(req.Label)
+			res := filterValues(indexr.LabelValues(req.Label), matchers)
 
 			mtx.Lock()
 			sets = append(sets, res)
@@ -1146,11 +1298,14 @@ func (s *bucketBlockSet) getFor(mint, maxt, maxResolutionMillis int64) (bs []*bu
 			break
 		}
 
-		if i+1 < len(s.resolutions) {
+		// Blocks may overlap, e.g. blocks of shards of series of the same time range, which leave no gap to fill.
+		if i+1 < len(s.resolutions) && start < b.meta.MinTime {
 			bs = append(bs, s.getFor(start, b.meta.MinTime, s.resolutions[i+1])...)
 		}
 		bs = append(bs, b)
-		start = b.meta.MaxTime
+		if b.meta.MaxTime > start {
+			start = b.meta.MaxTime
+		}
 	}
 
 	if i+1 < len(s.resolutions) {
@@ -1198,6 +1353,7 @@ type bucketBlock struct {
 	pendingReaders sync.WaitGroup
 
 	partitioner partitioner
+	fetches     *fetchScheduler
 
 	labels labels.Labels
 }
@@ -1211,6 +1367,7 @@ func newBucketBlock(
 	indexCache indexCache,
 	chunkPool *pool.BytesPool,
 	p partitioner,
+	fetches *fetchScheduler,
 ) (b *bucketBlock, err error) {
 	b = &bucketBlock{
 		logger:      logger,
@@ -1220,6 +1377,7 @@ func newBucketBlock(
 		chunkPool:   chunkPool,
 		dir:         dir,
 		partitioner: p,
+		fetches:     fetches,
 	}
 	err, meta := loadMeta(ctx, logger, bkt, dir, id)
 	if err != nil {
@@ -1523,8 +1681,22 @@ func toPostingGroup(lvalsFn func(name string) []string, m labels.Matcher) *posti
 		return newPostingGroup(labels.Labels{{Name: em.Name(), Value: em.Value()}}, merge)
 	}
 
+	// Regexes matching a small set of literals, e.g. "a|b|c", are looked up as equal matchers, avoiding a scan
+	// of all label values. Otherwise, values not having the literal prefix of the regex are skipped without
+	// evaluating it.
+	var prefix string
+	if rm, ok := m.(*labels.RegexpMatcher); ok {
+		if vals, ok := regexSetMatches(rm.Value()); ok {
+			for _, val := range vals {
+				matchingLabels = append(matchingLabels, labels.Label{Name: m.Name(), Value: val})
+			}
+			return newPostingGroup(matchingLabels, merge)
+		}
+		prefix = regexLiteralPrefix(rm.Value())
+	}
+
 	for _, val := range lvalsFn(m.Name()) {
-		if m.Matches(val) {
+		if strings.HasPrefix(val, prefix) && m.Matches(val) {
 			matchingLabels = append(matchingLabels, labels.Label{Name: m.Name(), Value: val})
 		}
 	}
@@ -1721,13 +1893,48 @@ type partitioner interface {
 }
 
 type gapBasedPartitioner struct {
-	maxGapSize uint64
+	maxGapSize   uint64
+	maxRangeSize uint64
+
+	requestedRanges prometheus.Counter
+	requestedBytes  prometheus.Counter
+	expandedRanges  prometheus.Counter
+	expandedBytes   prometheus.Counter
+}
+
+// newGapBasedPartitioner returns a partitioner which combines ranges separated by at most maxGapSize bytes,
+// as long as the combined range does not exceed maxRangeSize bytes. 0 maxRangeSize disables the size limit.
+func newGapBasedPartitioner(maxGapSize, maxRangeSize uint64, reg prometheus.Registerer) *gapBasedPartitioner {
+	g := &gapBasedPartitioner{
+		maxGapSize:   maxGapSize,
+		maxRangeSize: maxRangeSize,
+	}
+	g.requestedRanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_partitioner_requested_ranges_total",
+		Help: "Total number of byte ranges requested from object storage before partitioning.",
+	})
+	g.requestedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_partitioner_requested_bytes_total",
+		Help: "Total size of byte ranges requested from object storage before partitioning.",
+	})
+	g.expandedRanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_partitioner_expanded_ranges_total",
+		Help: "Total number of byte ranges fetched from object storage after partitioning.",
+	})
+	g.expandedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_partitioner_expanded_bytes_total",
+		Help: "Total size of byte ranges fetched from object storage after partitioning, including gaps.",
+	})
+	if reg != nil {
+		reg.MustRegister(g.requestedRanges, g.requestedBytes, g.expandedRanges, g.expandedBytes)
+	}
+	return g
 }
 
 // Partition partitions length entries into n <= length ranges that cover all
 // input ranges by combining entries that are separated by reasonably small gaps.
 // It is used to combine multiple small ranges from object storage into bigger, more efficient/cheaper ones.
-func (g gapBasedPartitioner) Partition(length int, rng func(int) (uint64, uint64)) (parts []part) {
+func (g *gapBasedPartitioner) Partition(length int, rng func(int) (uint64, uint64)) (parts []part) {
 	j := 0
 	k := 0
 	for k < length {
@@ -1736,21 +1943,31 @@ func (g gapBasedPartitioner) Partition(length int, rng func(int) (uint64, uint64
 
 		p := part{}
 		p.start, p.end = rng(j)
+		requestedBytes := p.end - p.start
 
-		// Keep growing the range until the end or we encounter a large gap.
+		// Keep growing the range until the end or we encounter a large gap or the range grows too big.
 		for ; k < length; k++ {
 			s, e := rng(k)
 
 			if p.end+g.maxGapSize < s {
 				break
 			}
+			if g.maxRangeSize > 0 && p.end < e && e-p.start > g.maxRangeSize {
+				break
+			}
 
+			requestedBytes += e - s
 			if p.end <= e {
 				p.end = e
 			}
 		}
 		p.elemRng = [2]int{j, k}
 		parts = append(parts, p)
+
+		g.requestedRanges.Add(float64(k - j))
+		g.requestedBytes.Add(float64(requestedBytes))
+		g.expandedRanges.Inc()
+		g.expandedBytes.Add(float64(p.end - p.start))
 	}
 	return parts
 }
@@ -1795,6 +2012,8 @@ type bucketChunkReader struct {
 	ctx   context.Context
 	block *bucketBlock
 	stats *queryStats
+	// deadline by which chunks are scheduled to be fetched.
+	deadline time.Time
 
 	preloads [][]uint32
 	mtx      sync.Mutex
@@ -1805,10 +2024,15 @@ type bucketChunkReader struct {
 }
 
 func newBucketChunkReader(ctx context.Context, block *bucketBlock) *bucketChunkReader {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultFetchDeadline)
+	}
 	return &bucketChunkReader{
 		ctx:      ctx,
 		block:    block,
 		stats:    &queryStats{},
+		deadline: deadline,
 		preloads: make([][]uint32, len(block.chunkObjs)),
 		chunks:   map[uint64]chunkenc.Chunk{},
 	}
@@ -1872,7 +2096,11 @@ func (r *bucketChunkReader) preload(samplesLimiter *Limiter) error {
 func (r *bucketChunkReader) loadChunks(ctx context.Context, offs []uint32, seq int, start, end uint32) error {
 	begin := time.Now()
 
+	if err := r.block.fetches.Start(ctx, r.deadline); err != nil {
+		return errors.Wrapf(err, "wait for turn to read range for %d", seq)
+	}
 	b, err := r.block.readChunkRange(ctx, seq, int64(start), int64(end-start))
+	r.block.fetches.Done()
 	if err != nil {
 		return errors.Wrapf(err, "read range for %d", seq)
 	}
@@ -1972,6 +2200,7 @@ type queryStats struct {
 	chunksFetchedSizeSum   int
 	chunksFetchCount       int
 	chunksFetchDurationSum time.Duration
+	chunksSkipped          int
 
 	getAllDuration    time.Duration
 	mergedSeriesCount int
@@ -2002,6 +2231,7 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 	s.chunksFetchedSizeSum += o.chunksFetchedSizeSum
 	s.chunksFetchCount += o.chunksFetchCount
 	s.chunksFetchDurationSum += o.chunksFetchDurationSum
+	s.chunksSkipped += o.chunksSkipped
 
 	s.getAllDuration += o.getAllDuration
 	s.mergedSeriesCount += o.mergedSeriesCount
@@ -12,6 +12,7 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/tsdb/labels"
@@ -25,6 +26,8 @@ import (
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -151,7 +154,7 @@ func prepareStoreWithTestBlocks(t testing.TB, dir string, bkt objstore.Bucket, m
 		maxTime: maxTime,
 	}
 
-	store, err := NewBucketStore(s.logger, nil, bkt, dir, s.cache, 0, maxSampleCount, 20, false, 20, filterConf, relabelConfig, true)
+	store, err := NewBucketStore(s.logger, nil, bkt, dir, s.cache, 0, maxSampleCount, 20, false, 20, 512*1024, 0, filterConf, relabelConfig, true, "", 0, nil)
 	testutil.Ok(t, err)
 	s.store = store
 
@@ -419,6 +422,40 @@ func TestBucketStore_e2e(t *testing.T) {
 	})
 }
 
+func TestBucketStore_SamplesLimit_e2e(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "test_bucketstore_samples_limit_e2e")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	s := prepareStoreWithTestBlocks(t, dir, inmem.NewBucket(), false, 1, emptyRelabelConfig)
+	defer s.Close()
+	s.cache.SwapWith(noopCache{})
+
+	req := &storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+		MinTime:  s.minTime,
+		MaxTime:  s.maxTime,
+	}
+
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, s.store.Series(req, srv))
+	testutil.Equals(t, 0, len(srv.SeriesSet))
+	testutil.Equals(t, 1, len(srv.Warnings), "got %v", srv.Warnings)
+
+	w, ok := storepb.ParseLimitWarning(srv.Warnings[0])
+	testutil.Assert(t, ok, "expected limit warning, got %v", srv.Warnings[0])
+	testutil.Equals(t, "samples", w.Limit)
+	testutil.Equals(t, uint64(1), w.Value)
+
+	req.PartialResponseDisabled = true
+	err = s.store.Series(req, newStoreSeriesServer(ctx))
+	testutil.NotOk(t, err)
+	testutil.Equals(t, codes.Aborted, status.Code(err))
+}
+
 type naivePartitioner struct{}
 
 func (g naivePartitioner) Partition(length int, rng func(int) (uint64, uint64)) (parts []part) {
@@ -455,6 +492,36 @@ func TestBucketStore_ManyParts_e2e(t *testing.T) {
 	})
 }
 
+// With no gap allowed, ranges separated by any number of bytes are fetched separately.
+// Results have to be the same but with fewer, bigger GetRange requests for the default gap size.
+func TestBucketStore_MaxGapSize_e2e(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "test_bucketstore_max_gap_size_e2e")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	s := prepareStoreWithTestBlocks(t, dir, inmem.NewBucket(), false, 0, emptyRelabelConfig)
+	defer s.Close()
+	s.cache.SwapWith(noopCache{})
+
+	expanded := map[uint64]float64{}
+	for _, maxGapSize := range []uint64{0, 512 * 1024} {
+		p := newGapBasedPartitioner(maxGapSize, 0, nil)
+		s.store.mtx.Lock()
+		s.store.partitioner = p
+		for _, b := range s.store.blocks {
+			b.partitioner = p
+		}
+		s.store.mtx.Unlock()
+
+		testBucketStore_e2e(t, ctx, s)
+		expanded[maxGapSize] = promtest.ToFloat64(p.expandedRanges)
+	}
+	testutil.Assert(t, expanded[512*1024] < expanded[0], "expected fewer fetched ranges with gap coalescing, got %v", expanded)
+}
+
 func TestBucketStore_TimePartitioning_e2e(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -481,11 +548,11 @@ func TestBucketStore_TimePartitioning_e2e(t *testing.T) {
 	hourAfter := time.Now().Add(1 * time.Hour)
 	filterMaxTime := model.TimeOrDurationValue{Time: &hourAfter}
 
-	store, err := NewBucketStore(nil, nil, bkt, dir, noopCache{}, 0, 0, 20, false, 20,
+	store, err := NewBucketStore(nil, nil, bkt, dir, noopCache{}, 0, 0, 20, false, 20, 512*1024, 0,
 		&FilterConfig{
 			MinTime: minTimeDuration,
 			MaxTime: filterMaxTime,
-		}, emptyRelabelConfig, true)
+		}, emptyRelabelConfig, true, "", 0, nil)
 	testutil.Ok(t, err)
 
 	err = store.SyncBlocks(ctx)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/ioutil"
 	"math"
+	"os"
 	"path"
 	"path/filepath"
 	"sort"
@@ -16,9 +17,11 @@ import (
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"github.com/oklog/ulid"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	prommodel "github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
@@ -361,6 +364,76 @@ func TestBucketBlockSet_labelMatchers(t *testing.T) {
 	}
 }
 
+func TestRegexSetMatches(t *testing.T) {
+	for _, tcase := range []struct {
+		re       string
+		expected []string
+		ok       bool
+	}{
+		{re: "^(?:foo)$", expected: []string{"foo"}, ok: true},
+		{re: "^(?:a|b|c)$", expected: []string{"a", "b", "c"}, ok: true},
+		{re: "^(?:foo|foobar|foo)$", expected: []string{"foo", "foobar"}, ok: true},
+		{re: "^(?:foo-(bar|baz)-[12])$", expected: []string{"foo-bar-1", "foo-bar-2", "foo-baz-1", "foo-baz-2"}, ok: true},
+		{re: "^(?:api(-v2)?)$", expected: []string{"api", "api-v2"}, ok: true},
+		{re: "^(?:foo.*)$"},
+		{re: "^(?:foo|bar+)$"},
+		{re: "^(?:(?i)foo)$"},
+		{re: "^(?:[^a])$"},
+		{re: "^(?:[a-z][a-z])$"},
+		{re: "foo"},
+		{re: "^(?:foo"},
+	} {
+		t.Run(tcase.re, func(t *testing.T) {
+			vals, ok := regexSetMatches(tcase.re)
+			testutil.Equals(t, tcase.ok, ok)
+			testutil.Equals(t, tcase.expected, vals)
+		})
+	}
+}
+
+func TestRegexLiteralPrefix(t *testing.T) {
+	testutil.Equals(t, "foo", regexLiteralPrefix("^(?:foo.*)$"))
+	testutil.Equals(t, "foo", regexLiteralPrefix("^(?:foo(bar|baz.+))$"))
+	testutil.Equals(t, "", regexLiteralPrefix("^(?:.*foo)$"))
+	testutil.Equals(t, "", regexLiteralPrefix("^(?:(?i)foo.*)$"))
+	testutil.Equals(t, "", regexLiteralPrefix("^(?:foo.*|bar)$"))
+}
+
+func TestToPostingGroup_Regex(t *testing.T) {
+	lvals := []string{"bar", "baz", "foo", "foo-1", "foo-2", "foobar", "qux"}
+	lvalsFn := func(name string) []string {
+		testutil.Equals(t, "a", name)
+		return lvals
+	}
+
+	for _, re := range []string{"foo", "foo|bar", "foo-[0-9]", "foo.*", "fo+", "ba(r|z)", "(?i)FOO.*", "missing|qux", ".*bar"} {
+		t.Run(re, func(t *testing.T) {
+			m := labels.NewMustRegexpMatcher("a", "^(?:"+re+")$")
+
+			var expected []string
+			for _, v := range lvals {
+				if m.Matches(v) {
+					expected = append(expected, v)
+				}
+			}
+
+			// Expanded regexes may look up values which do not exist, resulting in empty postings.
+			var got []string
+			for _, l := range toPostingGroup(lvalsFn, m).keys {
+				testutil.Equals(t, "a", l.Name)
+				testutil.Assert(t, m.Matches(l.Value), "key %s does not match regex", l.Value)
+				for _, v := range lvals {
+					if v == l.Value {
+						got = append(got, v)
+					}
+				}
+			}
+			sort.Strings(got)
+			testutil.Equals(t, expected, got)
+		})
+	}
+}
+
 func TestGapBasedPartitioner_Partition(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -414,13 +487,107 @@ func TestGapBasedPartitioner_Partition(t *testing.T) {
 			expected: []part{{start: 1, end: maxGapSize + 100, elemRng: [2]int{0, 3}}},
 		},
 	} {
-		res := gapBasedPartitioner{maxGapSize: maxGapSize}.Partition(len(c.input), func(i int) (uint64, uint64) {
+		res := newGapBasedPartitioner(maxGapSize, 0, nil).Partition(len(c.input), func(i int) (uint64, uint64) {
 			return uint64(c.input[i][0]), uint64(c.input[i][1])
 		})
 		testutil.Equals(t, c.expected, res)
 	}
 }
 
+func TestGapBasedPartitioner_MaxRangeSize(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	input := [][2]int{{0, 10}, {10, 20}, {25, 30}, {30, 60}, {60, 70}}
+	rng := func(i int) (uint64, uint64) {
+		return uint64(input[i][0]), uint64(input[i][1])
+	}
+
+	p := newGapBasedPartitioner(10, 30, nil)
+	testutil.Equals(t, []part{
+		{start: 0, end: 30, elemRng: [2]int{0, 3}},
+		// Single range bigger than max range size is still fetched as it is.
+		{start: 30, end: 60, elemRng: [2]int{3, 4}},
+		{start: 60, end: 70, elemRng: [2]int{4, 5}},
+	}, p.Partition(len(input), rng))
+
+	testutil.Equals(t, float64(5), promtest.ToFloat64(p.requestedRanges))
+	testutil.Equals(t, float64(65), promtest.ToFloat64(p.requestedBytes))
+	testutil.Equals(t, float64(3), promtest.ToFloat64(p.expandedRanges))
+	testutil.Equals(t, float64(70), promtest.ToFloat64(p.expandedBytes))
+
+	// No max range size merges everything within the gap.
+	testutil.Equals(t, []part{
+		{start: 0, end: 70, elemRng: [2]int{0, 5}},
+	}, newGapBasedPartitioner(10, 0, nil).Partition(len(input), rng))
+}
+
+func TestBucketStore_accountTenant(t *testing.T) {
+	var m metadata.Meta
+	m.Thanos.Labels = map[string]string{"team": "a", "region": "eu"}
+	b := &bucketBlock{meta: &m}
+	stats := &queryStats{
+		seriesTouched:          3,
+		postingsFetchedSizeSum: 10,
+		seriesFetchedSizeSum:   20,
+		chunksFetchedSizeSum:   30,
+		postingsFetchCount:     1,
+		seriesFetchCount:       1,
+		chunksFetchCount:       2,
+	}
+
+	// Tenant accounting is disabled without tenant label.
+	s := &BucketStore{metrics: newBucketStoreMetrics(nil)}
+	testutil.Equals(t, "", s.tenant(b))
+	s.accountTenant(s.tenant(b), stats)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(s.metrics.tenantSeriesTouched.WithLabelValues("")))
+
+	s = &BucketStore{metrics: newBucketStoreMetrics(nil), tenantLabel: "team"}
+	testutil.Equals(t, "a", s.tenant(b))
+	s.accountTenant(s.tenant(b), stats)
+	s.accountTenant(s.tenant(b), stats)
+	testutil.Equals(t, 6.0, promtest.ToFloat64(s.metrics.tenantSeriesTouched.WithLabelValues("a")))
+	testutil.Equals(t, 20.0, promtest.ToFloat64(s.metrics.tenantDataFetched.WithLabelValues("a", "postings")))
+	testutil.Equals(t, 40.0, promtest.ToFloat64(s.metrics.tenantDataFetched.WithLabelValues("a", "series")))
+	testutil.Equals(t, 60.0, promtest.ToFloat64(s.metrics.tenantDataFetched.WithLabelValues("a", "chunks")))
+	testutil.Equals(t, 8.0, promtest.ToFloat64(s.metrics.tenantFetchOperations.WithLabelValues("a")))
+}
+
+type fakeActiveQueryTracker struct {
+	queries map[int]string
+	logged  []string
+}
+
+func (f *fakeActiveQueryTracker) Insert(query string) int {
+	f.logged = append(f.logged, query)
+	f.queries[len(f.logged)] = query
+	return len(f.logged)
+}
+
+func (f *fakeActiveQueryTracker) Delete(insertIndex int) { delete(f.queries, insertIndex) }
+
+func TestBucketStore_ActiveQueries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bucketstore-active-queries-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	tracker := &fakeActiveQueryTracker{queries: map[int]string{}}
+	bucketStore, err := NewBucketStore(nil, nil, inmem.NewBucket(), dir, noopCache{}, 0, 0, 20, false, 20, 512*1024, 0,
+		filterConf, emptyRelabelConfig, true, "", 0, tracker)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, bucketStore.Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"},
+			{Type: storepb.LabelMatcher_NRE, Name: "b", Value: "2|\\d"},
+		},
+		MinTime:             0,
+		MaxTime:             10,
+		MaxResolutionWindow: 300000,
+	}, newStoreSeriesServer(context.Background())))
+	testutil.Equals(t, []string{`series{a="1",b!~"2|\\d"} start=0 end=10 max_resolution=300000`}, tracker.logged)
+	testutil.Equals(t, 0, len(tracker.queries))
+}
+
 func TestBucketStore_Info(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -441,9 +608,14 @@ func TestBucketStore_Info(t *testing.T) {
 		0,
 		false,
 		20,
+		512*1024,
+		0,
 		filterConf,
 		emptyRelabelConfig,
 		true,
+		"",
+		0,
+		nil,
 	)
 	testutil.Ok(t, err)
 
@@ -500,11 +672,11 @@ func TestBucketStore_isBlockInMinMaxRange(t *testing.T) {
 	hourBefore := model.TimeOrDurationValue{Dur: &hourBeforeDur}
 
 	// bucketStore accepts blocks in range [0, now-1h].
-	bucketStore, err := NewBucketStore(nil, nil, inmem.NewBucket(), dir, noopCache{}, 0, 0, 20, false, 20,
+	bucketStore, err := NewBucketStore(nil, nil, inmem.NewBucket(), dir, noopCache{}, 0, 0, 20, false, 20, 512*1024, 0,
 		&FilterConfig{
 			MinTime: minTimeDuration,
 			MaxTime: hourBefore,
-		}, emptyRelabelConfig, true)
+		}, emptyRelabelConfig, true, "", 0, nil)
 	testutil.Ok(t, err)
 
 	inRange, err := bucketStore.isBlockInMinMaxRange(context.TODO(), id1)
@@ -573,8 +745,8 @@ func TestBucketStore_selectorBlocks(t *testing.T) {
 		err = yaml.Unmarshal([]byte(sc.relabelContentYaml), &relabelConf)
 		testutil.Ok(t, err)
 
-		bucketStore, err := NewBucketStore(nil, nil, bkt, dir, noopCache{}, 0, 0, 20, false, 20,
-			filterConf, relabelConf, true)
+		bucketStore, err := NewBucketStore(nil, nil, bkt, dir, noopCache{}, 0, 0, 20, false, 20, 512*1024, 0,
+			filterConf, relabelConf, true, "", 0, nil)
 		testutil.Ok(t, err)
 
 		for _, id := range []ulid.ULID{id1, id2, id3} {
@@ -641,9 +813,14 @@ func TestBucketStore_InfoWithLabels(t *testing.T) {
 		0,
 		false,
 		20,
+		512*1024,
+		0,
 		filterConf,
 		relabelConfig,
 		true,
+		"",
+		0,
+		nil,
 	)
 	testutil.Ok(t, err)
 
@@ -670,3 +847,23 @@ func TestBucketStore_InfoWithLabels(t *testing.T) {
 		},
 	}, resp.LabelSets)
 }
+
+func TestPopulateChunk_CounterSeries(t *testing.T) {
+	counter := chunkenc.NewXORChunk()
+	app, err := counter.Appender()
+	testutil.Ok(t, err)
+	app.Append(1, 10)
+
+	// Chunks of counter series have no min and max aggregates, the counter aggregate is served instead.
+	var chks [5]chunkenc.Chunk
+	chks[downsample.AggrCounter] = counter
+	in := downsample.EncodeAggrChunk(chks)
+
+	var out storepb.AggrChunk
+	testutil.Ok(t, populateChunk(&out, in, []storepb.Aggr{storepb.Aggr_MIN, storepb.Aggr_MAX, storepb.Aggr_COUNTER}))
+	testutil.Equals(t, counter.Bytes(), out.Min.Data)
+	testutil.Equals(t, counter.Bytes(), out.Max.Data)
+	testutil.Equals(t, counter.Bytes(), out.Counter.Data)
+
+	testutil.NotOk(t, populateChunk(&out, in, []storepb.Aggr{storepb.Aggr_SUM}))
+}
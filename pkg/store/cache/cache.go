@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/golang/snappy"
 	lru "github.com/hashicorp/golang-lru/simplelru"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
@@ -56,6 +57,7 @@ type IndexCache struct {
 	lru              *lru.LRU
 	maxSizeBytes     uint64
 	maxItemSizeBytes uint64
+	compressPostings bool
 
 	curSize uint64
 
@@ -67,6 +69,9 @@ type IndexCache struct {
 	currentSize      *prometheus.GaugeVec
 	totalCurrentSize *prometheus.GaugeVec
 	overflow         *prometheus.CounterVec
+
+	postingsUncompressedBytes prometheus.Counter
+	postingsCompressedBytes   prometheus.Counter
 }
 
 type Opts struct {
@@ -74,6 +79,9 @@ type Opts struct {
 	MaxSizeBytes uint64
 	// MaxItemSizeBytes represents maximum size of single item.
 	MaxItemSizeBytes uint64
+	// CompressPostings enables snappy compression of postings entries. Postings compress well,
+	// so this allows many more entries to fit into the cache at the cost of CPU on every access.
+	CompressPostings bool
 }
 
 // NewIndexCache creates a new thread-safe LRU cache for index entries and ensures the total cache
@@ -87,6 +95,7 @@ func NewIndexCache(logger log.Logger, reg prometheus.Registerer, opts Opts) (*In
 		logger:           logger,
 		maxSizeBytes:     opts.MaxSizeBytes,
 		maxItemSizeBytes: opts.MaxItemSizeBytes,
+		compressPostings: opts.CompressPostings,
 	}
 
 	c.evicted = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -145,6 +154,16 @@ func NewIndexCache(logger log.Logger, reg prometheus.Registerer, opts Opts) (*In
 	c.totalCurrentSize.WithLabelValues(cacheTypePostings)
 	c.totalCurrentSize.WithLabelValues(cacheTypeSeries)
 
+	c.postingsUncompressedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_postings_uncompressed_bytes_total",
+		Help: "Total number of bytes of postings added to the index cache before compression.",
+	})
+
+	c.postingsCompressedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_postings_compressed_bytes_total",
+		Help: "Total number of bytes of postings added to the index cache after compression.",
+	})
+
 	if reg != nil {
 		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 			Name: "thanos_store_index_cache_max_size_bytes",
@@ -159,6 +178,9 @@ func NewIndexCache(logger log.Logger, reg prometheus.Registerer, opts Opts) (*In
 			return float64(c.maxItemSizeBytes)
 		}))
 		reg.MustRegister(c.requests, c.hits, c.added, c.evicted, c.current, c.currentSize, c.totalCurrentSize, c.overflow)
+		if c.compressPostings {
+			reg.MustRegister(c.postingsUncompressedBytes, c.postingsCompressedBytes)
+		}
 	}
 
 	// Initialize LRU cache with a high size limit since we will manage evictions ourselves
@@ -174,13 +196,14 @@ func NewIndexCache(logger log.Logger, reg prometheus.Registerer, opts Opts) (*In
 		"maxItemSizeBytes", c.maxItemSizeBytes,
 		"maxSizeBytes", c.maxSizeBytes,
 		"maxItems", "math.MaxInt64",
+		"compressPostings", c.compressPostings,
 	)
 	return c, nil
 }
 
 func (c *IndexCache) onEvict(key, val interface{}) {
 	k := key.(cacheKey).keyType()
-	entrySize := sliceHeaderSize + uint64(len(val.([]byte)))
+	entrySize := entrySize(val.([]byte))
 
 	c.evicted.WithLabelValues(string(k)).Inc()
 	c.current.WithLabelValues(string(k)).Dec()
@@ -190,6 +213,12 @@ func (c *IndexCache) onEvict(key, val interface{}) {
 	c.curSize -= entrySize
 }
 
+// entrySize returns the number of bytes held by the given cached value including its slice header.
+// Capacity is used instead of length, as that is what is actually allocated.
+func entrySize(v []byte) uint64 {
+	return sliceHeaderSize + uint64(cap(v))
+}
+
 func (c *IndexCache) get(typ string, key cacheKey) ([]byte, bool) {
 	c.requests.WithLabelValues(typ).Inc()
 
@@ -205,7 +234,11 @@ func (c *IndexCache) get(typ string, key cacheKey) ([]byte, bool) {
 }
 
 func (c *IndexCache) set(typ string, key cacheKey, val []byte) {
-	var size = sliceHeaderSize + uint64(len(val))
+	// The caller may be passing in a sub-slice of a huge array. Copy the data
+	// to ensure we don't waste huge amounts of space for something small.
+	v := make([]byte, len(val))
+	copy(v, val)
+	var size = entrySize(v)
 
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
@@ -219,10 +252,6 @@ func (c *IndexCache) set(typ string, key cacheKey, val []byte) {
 		return
 	}
 
-	// The caller may be passing in a sub-slice of a huge array. Copy the data
-	// to ensure we don't waste huge amounts of space for something small.
-	v := make([]byte, len(val))
-	copy(v, val)
 	c.lru.Add(key, v)
 
 	c.added.WithLabelValues(typ).Inc()
@@ -274,11 +303,27 @@ func (c *IndexCache) reset() {
 // SetPostings sets the postings identfied by the ulid and label to the value v,
 // if the postings already exists in the cache it is not mutated.
 func (c *IndexCache) SetPostings(b ulid.ULID, l labels.Label, v []byte) {
+	if c.compressPostings {
+		// Snappy allocates the maximum encoded length, set copies the result into an exactly sized slice.
+		encoded := snappy.Encode(nil, v)
+		c.postingsUncompressedBytes.Add(float64(len(v)))
+		c.postingsCompressedBytes.Add(float64(len(encoded)))
+		v = encoded
+	}
 	c.set(cacheTypePostings, cacheKey{b, cacheKeyPostings(l)}, v)
 }
 
 func (c *IndexCache) Postings(b ulid.ULID, l labels.Label) ([]byte, bool) {
-	return c.get(cacheTypePostings, cacheKey{b, cacheKeyPostings(l)})
+	v, ok := c.get(cacheTypePostings, cacheKey{b, cacheKeyPostings(l)})
+	if !ok || !c.compressPostings {
+		return v, ok
+	}
+	decoded, err := snappy.Decode(nil, v)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to decode cached postings, treating as cache miss", "err", err)
+		return nil, false
+	}
+	return decoded, true
 }
 
 // SetSeries sets the series identfied by the ulid and id to the value v,
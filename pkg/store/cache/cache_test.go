@@ -10,6 +10,7 @@ import (
 
 	"github.com/fortytw2/leaktest"
 	"github.com/go-kit/kit/log"
+	"github.com/golang/snappy"
 	"github.com/hashicorp/golang-lru/simplelru"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
@@ -144,6 +145,42 @@ func TestIndexCache_UpdateItem(t *testing.T) {
 	}
 }
 
+func TestIndexCache_CompressPostings(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	metrics := prometheus.NewRegistry()
+	cache, err := NewIndexCache(log.NewNopLogger(), metrics, Opts{
+		MaxItemSizeBytes: 1e3,
+		MaxSizeBytes:     1e3,
+		CompressPostings: true,
+	})
+	testutil.Ok(t, err)
+
+	id := ulid.MustNew(0, nil)
+	lbls := labels.Label{Name: "test", Value: "123"}
+	postings := bytes.Repeat([]byte{1, 2, 3, 4}, 100)
+
+	cache.SetPostings(id, lbls, postings)
+	buf, ok := cache.Postings(id, lbls)
+	testutil.Assert(t, ok, "key exists")
+	testutil.Equals(t, postings, buf)
+
+	// Only the compressed entry is accounted, with no unused capacity left over from encoding.
+	compressed := snappy.Encode(nil, postings)
+	testutil.Assert(t, len(compressed) < len(postings), "postings expected to compress")
+	testutil.Equals(t, uint64(sliceHeaderSize+len(compressed)), cache.curSize)
+	testutil.Equals(t, float64(cache.curSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(len(postings)), promtest.ToFloat64(cache.postingsUncompressedBytes))
+	testutil.Equals(t, float64(len(compressed)), promtest.ToFloat64(cache.postingsCompressedBytes))
+
+	// Series are never compressed.
+	cache.SetSeries(id, 1234, postings)
+	buf, ok = cache.Series(id, 1234)
+	testutil.Assert(t, ok, "key exists")
+	testutil.Equals(t, postings, buf)
+	testutil.Equals(t, float64(sliceHeaderSize+len(postings)), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
+}
+
 // This should not happen as we hardcode math.MaxInt, but we still add test to check this out.
 func TestIndexCache_MaxNumberOfItemsHit(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultFetchDeadline is the deadline of fetches of requests without deadline, relative to the start of the request.
+// It matches the default query timeout of the querier, so such requests are scheduled as if sent by a querier.
+const defaultFetchDeadline = 2 * time.Minute
+
+// fetchScheduler limits concurrent range reads of chunks from the bucket. Reads waiting for their turn are started
+// earliest deadline first, so reads of older requests nearing their deadline are not starved by reads of newer
+// requests. Waiting reads of canceled requests, e.g. abandoned by the querier, are dropped without reading anything.
+type fetchScheduler struct {
+	maxConcurrent int
+
+	mtx      sync.Mutex
+	inflight int
+	queue    fetchQueue
+	seq      uint64
+
+	waiting      prometheus.Gauge
+	waitDuration prometheus.Histogram
+	canceled     prometheus.Counter
+}
+
+// newFetchScheduler returns a fetchScheduler. Zero maxConcurrent means no limit.
+func newFetchScheduler(maxConcurrent int, reg prometheus.Registerer) *fetchScheduler {
+	s := &fetchScheduler{
+		maxConcurrent: maxConcurrent,
+		waiting: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_bucket_store_chunk_fetches_waiting",
+			Help: "Number of chunk range reads waiting for their turn.",
+		}),
+		waitDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "thanos_bucket_store_chunk_fetch_wait_duration_seconds",
+			Help:    "How long chunk range reads waited for their turn.",
+			Buckets: []float64{0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}),
+		canceled: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "thanos_bucket_store_chunk_fetches_canceled_total",
+			Help: "Total number of chunk range reads dropped while waiting for their turn, because their request was canceled.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(s.waiting, s.waitDuration, s.canceled)
+	}
+	return s
+}
+
+// Start waits until a read with the given deadline may start. If it returns nil, Done must be called once the read
+// finished.
+func (s *fetchScheduler) Start(ctx context.Context, deadline time.Time) error {
+	if s == nil || s.maxConcurrent <= 0 {
+		return nil
+	}
+
+	s.mtx.Lock()
+	if s.inflight < s.maxConcurrent {
+		s.inflight++
+		s.mtx.Unlock()
+		return nil
+	}
+	s.seq++
+	f := &fetch{deadline: deadline, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.queue, f)
+	s.mtx.Unlock()
+
+	s.waiting.Inc()
+	defer s.waiting.Dec()
+	start := time.Now()
+
+	select {
+	case <-f.ready:
+		s.waitDuration.Observe(time.Since(start).Seconds())
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mtx.Lock()
+	if f.index < 0 {
+		// The turn was passed to us meanwhile, pass it on.
+		s.mtx.Unlock()
+		s.Done()
+	} else {
+		heap.Remove(&s.queue, f.index)
+		s.mtx.Unlock()
+	}
+	s.canceled.Inc()
+	return ctx.Err()
+}
+
+// Done finishes a read started by Start, passing its turn to the waiting read with the earliest deadline.
+func (s *fetchScheduler) Done() {
+	if s == nil || s.maxConcurrent <= 0 {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.queue.Len() == 0 {
+		s.inflight--
+		return
+	}
+	close(heap.Pop(&s.queue).(*fetch).ready)
+}
+
+type fetch struct {
+	deadline time.Time
+	// seq orders reads with the same deadline by their arrival.
+	seq   uint64
+	ready chan struct{}
+	// index in the queue, -1 once removed from the queue.
+	index int
+}
+
+// fetchQueue implements heap.Interface ordering reads by earliest deadline.
+type fetchQueue []*fetch
+
+func (q fetchQueue) Len() int { return len(q) }
+
+func (q fetchQueue) Less(i, j int) bool {
+	if !q[i].deadline.Equal(q[j].deadline) {
+		return q[i].deadline.Before(q[j].deadline)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q fetchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *fetchQueue) Push(x interface{}) {
+	f := x.(*fetch)
+	f.index = len(*q)
+	*q = append(*q, f)
+}
+
+func (q *fetchQueue) Pop() interface{} {
+	old := *q
+	f := old[len(old)-1]
+	old[len(old)-1] = nil
+	f.index = -1
+	*q = old[:len(old)-1]
+	return f
+}
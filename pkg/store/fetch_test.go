@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestFetchScheduler(t *testing.T) {
+	var nilScheduler *fetchScheduler
+	testutil.Ok(t, nilScheduler.Start(context.Background(), time.Now()))
+	nilScheduler.Done()
+
+	s := newFetchScheduler(1, nil)
+	now := time.Now()
+	testutil.Ok(t, s.Start(context.Background(), now))
+
+	started := make(chan time.Time, 3)
+	errs := make(chan error, 3)
+	start := func(ctx context.Context, deadline time.Time) {
+		waiting := promtest.ToFloat64(s.waiting)
+		go func() {
+			if err := s.Start(ctx, deadline); err != nil {
+				errs <- err
+				return
+			}
+			started <- deadline
+		}()
+		for promtest.ToFloat64(s.waiting) == waiting {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start(context.Background(), now.Add(3*time.Second))
+	start(ctx, now.Add(time.Second))
+	start(context.Background(), now.Add(2*time.Second))
+
+	// Reads of canceled requests are dropped.
+	cancel()
+	testutil.Equals(t, context.Canceled, <-errs)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(s.canceled))
+
+	// Waiting reads start earliest deadline first.
+	s.Done()
+	testutil.Equals(t, now.Add(2*time.Second), <-started)
+	s.Done()
+	testutil.Equals(t, now.Add(3*time.Second), <-started)
+	s.Done()
+
+	testutil.Equals(t, 0, s.inflight)
+	testutil.Equals(t, 0, s.queue.Len())
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"math"
+
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// chunkNeeded returns true if the chunk with the given time range can influence the result of the query evaluation
+// described by the hints. nextMinTime is the min time of the following chunk of the same series or math.MaxInt64
+// if there is none. Chunks of a series are expected to be sorted by min time.
+func chunkNeeded(h *storepb.QueryHints, minTime, maxTime, nextMinTime int64) bool {
+	if h == nil {
+		return true
+	}
+
+	// The chunk is used by a step if the step falls within [minTime, maxTime+range], as the chunk overlaps
+	// the range before it then.
+	last := maxTime + h.RangeMillis
+	if h.LatestSampleOnly && nextMinTime > maxTime && nextMinTime != math.MaxInt64 {
+		// The following chunk holds later samples for all steps from its min time on. Overlapping chunks are
+		// kept, as it is not known which of them holds the latest sample.
+		if nextMinTime-1 < last {
+			last = nextMinTime - 1
+		}
+	}
+	return stepWithin(h, minTime, last)
+}
+
+// stepWithin returns true if any evaluation step described by the hints is within [mint, maxt].
+func stepWithin(h *storepb.QueryHints, mint, maxt int64) bool {
+	if mint > maxt {
+		return false
+	}
+	if h.StepMillis <= 0 {
+		return h.StartMillis >= mint && h.StartMillis <= maxt
+	}
+
+	// Find the first step not before mint.
+	step := h.StartMillis
+	if mint > step {
+		step += ((mint - step + h.StepMillis - 1) / h.StepMillis) * h.StepMillis
+	}
+	return step <= maxt && step <= h.EndMillis
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"math"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestChunkNeeded(t *testing.T) {
+	// Range query from 1000 to 5000 with step 1000 and range 100.
+	matrix := &storepb.QueryHints{StartMillis: 1000, EndMillis: 5000, StepMillis: 1000, RangeMillis: 100}
+	latest := &storepb.QueryHints{StartMillis: 1000, EndMillis: 5000, StepMillis: 1000, RangeMillis: 100, LatestSampleOnly: true}
+	instant := &storepb.QueryHints{StartMillis: 3000, EndMillis: 3000, RangeMillis: 300, LatestSampleOnly: true}
+
+	for _, tcase := range []struct {
+		name                          string
+		hints                         *storepb.QueryHints
+		minTime, maxTime, nextMinTime int64
+		expected                      bool
+	}{
+		{name: "no hints", minTime: 1200, maxTime: 1300, nextMinTime: math.MaxInt64, expected: true},
+		{name: "between steps", hints: matrix, minTime: 1200, maxTime: 1800, nextMinTime: math.MaxInt64},
+		{name: "within range before step", hints: matrix, minTime: 1200, maxTime: 1900, nextMinTime: math.MaxInt64, expected: true},
+		{name: "covers step", hints: matrix, minTime: 1950, maxTime: 2010, nextMinTime: math.MaxInt64, expected: true},
+		{name: "before first step", hints: matrix, minTime: 0, maxTime: 800, nextMinTime: math.MaxInt64},
+		{name: "after last step", hints: matrix, minTime: 5001, maxTime: 6000, nextMinTime: math.MaxInt64},
+		{name: "latest sample in the next chunk", hints: latest, minTime: 1950, maxTime: 1980, nextMinTime: 1990},
+		{name: "latest sample in the chunk", hints: latest, minTime: 1950, maxTime: 1980, nextMinTime: 2010, expected: true},
+		{name: "overlapping next chunk", hints: latest, minTime: 1950, maxTime: 2010, nextMinTime: 1990, expected: true},
+		{name: "latest sample in the last chunk", hints: latest, minTime: 1950, maxTime: 1980, nextMinTime: math.MaxInt64, expected: true},
+		{name: "instant query", hints: instant, minTime: 2800, maxTime: 2900, nextMinTime: 3100, expected: true},
+		{name: "instant query, later chunk", hints: instant, minTime: 2800, maxTime: 2900, nextMinTime: 2950},
+		{name: "instant query, outside lookback", hints: instant, minTime: 2000, maxTime: 2600, nextMinTime: math.MaxInt64},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			testutil.Equals(t, tcase.expected, chunkNeeded(tcase.hints, tcase.minTime, tcase.maxTime, tcase.nextMinTime))
+		})
+	}
+}
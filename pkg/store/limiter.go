@@ -1,7 +1,8 @@
 package store
 
 import (
-	"github.com/pkg/errors"
+	"fmt"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -25,7 +26,17 @@ func (l *Limiter) Check(num uint64) error {
 	}
 	if num > l.limit {
 		l.failedCounter.Inc()
-		return errors.Errorf("limit %v violated (got %v)", l.limit, num)
+		return &LimitError{Limit: l.limit, Got: num}
 	}
 	return nil
 }
+
+// LimitError is returned by Check when the limit is exceeded.
+type LimitError struct {
+	Limit uint64
+	Got   uint64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit %v violated (got %v)", e.Limit, e.Got)
+}
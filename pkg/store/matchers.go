@@ -1,11 +1,31 @@
 package store
 
 import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 )
 
+// matchersString returns the matchers in PromQL selector syntax, e.g. {a="1",b=~"2|3"}.
+func matchersString(ms []storepb.LabelMatcher) string {
+	ops := map[storepb.LabelMatcher_Type]string{
+		storepb.LabelMatcher_EQ:  "=",
+		storepb.LabelMatcher_NEQ: "!=",
+		storepb.LabelMatcher_RE:  "=~",
+		storepb.LabelMatcher_NRE: "!~",
+	}
+	parts := make([]string, 0, len(ms))
+	for _, m := range ms {
+		parts = append(parts, fmt.Sprintf("%s%s%q", m.Name, ops[m.Type], m.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
 func translateMatcher(m storepb.LabelMatcher) (labels.Matcher, error) {
 	switch m.Type {
 	case storepb.LabelMatcher_EQ:
@@ -37,3 +57,164 @@ func translateMatchers(ms []storepb.LabelMatcher) (res []labels.Matcher, err err
 	}
 	return res, nil
 }
+
+// labelValuesMatchers returns matchers to be applied to values of the given label. Matchers for other labels are
+// matched against the external labels instead, and false is returned if any of them does not match.
+func labelValuesMatchers(label string, ms []storepb.LabelMatcher, externalLabels labels.Labels) (bool, []labels.Matcher, error) {
+	var res []labels.Matcher
+	for _, m := range ms {
+		tm, err := translateMatcher(m)
+		if err != nil {
+			return false, nil, err
+		}
+		if m.Name == label {
+			res = append(res, tm)
+			continue
+		}
+		if !tm.Matches(externalLabels.Get(m.Name)) {
+			return false, nil, nil
+		}
+	}
+	return true, res, nil
+}
+
+// filterValues returns values matching all the given matchers. It modifies the given slice.
+func filterValues(values []string, ms []labels.Matcher) []string {
+	if len(ms) == 0 {
+		return values
+	}
+	res := values[:0]
+Outer:
+	for _, v := range values {
+		for _, m := range ms {
+			if !m.Matches(v) {
+				continue Outer
+			}
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// maxRegexSetMatches is the maximum number of values a regex matcher is expanded to. Regex matchers matching more
+// values are evaluated against all label values.
+const maxRegexSetMatches = 256
+
+// regexSetMatches returns all values matched by the fully anchored regex if they form a small set of literals,
+// e.g. "^(?:a|b|c)$" or "^(?:foo-(bar|baz))$". False is returned for regexes matching potentially unlimited
+// or too many values, e.g. "^(?:foo.*)$", and for case insensitive ones.
+func regexSetMatches(re string) ([]string, bool) {
+	subs, ok := anchoredRegex(re)
+	if !ok {
+		return nil, false
+	}
+	vals, ok := literalSet(&syntax.Regexp{Op: syntax.OpConcat, Sub: subs})
+	if !ok {
+		return nil, false
+	}
+
+	sort.Strings(vals)
+	res := vals[:0]
+	for i, v := range vals {
+		if i == 0 || v != vals[i-1] {
+			res = append(res, v)
+		}
+	}
+	return res, true
+}
+
+// regexLiteralPrefix returns the literal prefix all values matched by the fully anchored regex start with,
+// e.g. "foo" for "^(?:foo.*)$". Empty string is returned if there is no such prefix.
+func regexLiteralPrefix(re string) string {
+	subs, ok := anchoredRegex(re)
+	if !ok || len(subs) == 0 {
+		return ""
+	}
+	if subs[0].Op != syntax.OpLiteral || subs[0].Flags&syntax.FoldCase != 0 {
+		return ""
+	}
+	return string(subs[0].Rune)
+}
+
+// anchoredRegex parses the regex and returns its parts between the begin and end of text anchors. False is
+// returned if the regex is not anchored on both ends.
+func anchoredRegex(re string) ([]*syntax.Regexp, bool) {
+	parsed, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	parsed = parsed.Simplify()
+	if parsed.Op != syntax.OpConcat || len(parsed.Sub) < 2 {
+		return nil, false
+	}
+	if parsed.Sub[0].Op != syntax.OpBeginText || parsed.Sub[len(parsed.Sub)-1].Op != syntax.OpEndText {
+		return nil, false
+	}
+	return parsed.Sub[1 : len(parsed.Sub)-1], true
+}
+
+// literalSet returns all strings matched by the regex if it matches a finite set of at most maxRegexSetMatches
+// strings. Returned strings can contain duplicates.
+func literalSet(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+
+	case syntax.OpCharClass:
+		var res []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if len(res)+int(re.Rune[i+1]-re.Rune[i])+1 > maxRegexSetMatches {
+				return nil, false
+			}
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				res = append(res, string(r))
+			}
+		}
+		return res, true
+
+	case syntax.OpCapture:
+		return literalSet(re.Sub[0])
+
+	case syntax.OpQuest:
+		res, ok := literalSet(re.Sub[0])
+		if !ok || len(res)+1 > maxRegexSetMatches {
+			return nil, false
+		}
+		return append(res, ""), true
+
+	case syntax.OpAlternate:
+		var res []string
+		for _, sub := range re.Sub {
+			vals, ok := literalSet(sub)
+			if !ok || len(res)+len(vals) > maxRegexSetMatches {
+				return nil, false
+			}
+			res = append(res, vals...)
+		}
+		return res, true
+
+	case syntax.OpConcat:
+		res := []string{""}
+		for _, sub := range re.Sub {
+			vals, ok := literalSet(sub)
+			if !ok || len(res)*len(vals) > maxRegexSetMatches {
+				return nil, false
+			}
+			next := make([]string, 0, len(res)*len(vals))
+			for _, prefix := range res {
+				for _, v := range vals {
+					next = append(next, prefix+v)
+				}
+			}
+			res = next
+		}
+		return res, true
+	}
+	return nil, false
+}
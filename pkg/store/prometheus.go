@@ -26,6 +26,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/requestid"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/tracing"
@@ -195,8 +196,9 @@ func (p *PrometheusStore) Series(r *storepb.SeriesRequest, s storepb.Store_Serie
 
 func (p *PrometheusStore) handleSampledPrometheusResponse(s storepb.Store_SeriesServer, httpResp *http.Response, querySpan opentracing.Span, externalLabels labels.Labels) error {
 	ctx := s.Context()
+	logger := requestid.Logger(ctx, p.logger)
 
-	level.Debug(p.logger).Log("msg", "started handling ReadRequest_SAMPLED response type.")
+	level.Debug(logger).Log("msg", "started handling ReadRequest_SAMPLED response type.")
 
 	resp, err := p.fetchSampledResponse(ctx, httpResp)
 	querySpan.Finish()
@@ -215,7 +217,7 @@ func (p *PrometheusStore) handleSampledPrometheusResponse(s storepb.Store_Series
 			// As found in https://github.com/thanos-io/thanos/issues/381
 			// Prometheus can give us completely empty time series. Ignore these with log until we figure out that
 			// this is expected from Prometheus perspective.
-			level.Warn(p.logger).Log(
+			level.Warn(logger).Log(
 				"msg",
 				"found timeseries without any chunk. See https://github.com/thanos-io/thanos/issues/381 for details",
 				"lset",
@@ -239,12 +241,13 @@ func (p *PrometheusStore) handleSampledPrometheusResponse(s storepb.Store_Series
 			return err
 		}
 	}
-	level.Debug(p.logger).Log("msg", "handled ReadRequest_SAMPLED request.", "series", len(resp.Results[0].Timeseries))
+	level.Debug(logger).Log("msg", "handled ReadRequest_SAMPLED request.", "series", len(resp.Results[0].Timeseries))
 	return nil
 }
 
 func (p *PrometheusStore) handleStreamedPrometheusResponse(s storepb.Store_SeriesServer, httpResp *http.Response, querySpan opentracing.Span, externalLabels labels.Labels) error {
-	level.Debug(p.logger).Log("msg", "started handling ReadRequest_STREAMED_XOR_CHUNKS streamed read response.")
+	logger := requestid.Logger(s.Context(), p.logger)
+	level.Debug(logger).Log("msg", "started handling ReadRequest_STREAMED_XOR_CHUNKS streamed read response.")
 
 	framesNum := 0
 	seriesNum := 0
@@ -254,7 +257,7 @@ func (p *PrometheusStore) handleStreamedPrometheusResponse(s storepb.Store_Serie
 		querySpan.SetTag("series", seriesNum)
 		querySpan.Finish()
 	}()
-	defer runutil.CloseWithLogOnErr(p.logger, httpResp.Body, "prom series request body")
+	defer runutil.CloseWithLogOnErr(logger, httpResp.Body, "prom series request body")
 
 	var (
 		lastSeries string
@@ -277,7 +280,7 @@ func (p *PrometheusStore) handleStreamedPrometheusResponse(s storepb.Store_Serie
 		}
 
 		if len(res.ChunkedSeries) != 1 {
-			level.Warn(p.logger).Log("msg", "Prometheus ReadRequest_STREAMED_XOR_CHUNKS returned non 1 series in frame", "series", len(res.ChunkedSeries))
+			level.Warn(logger).Log("msg", "Prometheus ReadRequest_STREAMED_XOR_CHUNKS returned non 1 series in frame", "series", len(res.ChunkedSeries))
 		}
 
 		framesNum++
@@ -320,7 +323,7 @@ func (p *PrometheusStore) handleStreamedPrometheusResponse(s storepb.Store_Serie
 			}
 		}
 	}
-	level.Debug(p.logger).Log("msg", "handled ReadRequest_STREAMED_XOR_CHUNKS request.", "frames", framesNum, "series", seriesNum)
+	level.Debug(logger).Log("msg", "handled ReadRequest_STREAMED_XOR_CHUNKS request.", "frames", framesNum, "series", seriesNum)
 	return nil
 }
 
@@ -399,6 +402,9 @@ func (p *PrometheusStore) startPromSeries(ctx context.Context, q *prompb.Query)
 	}
 	preq.Header.Add("Content-Encoding", "snappy")
 	preq.Header.Set("Content-Type", "application/x-stream-protobuf")
+	if id, ok := requestid.FromContext(ctx); ok {
+		preq.Header.Set(requestid.HeaderName, id)
+	}
 	spanReqDo, ctx := tracing.StartSpan(ctx, "query_prometheus_request")
 	preq = preq.WithContext(ctx)
 	presp, err := p.client.Do(preq)
@@ -555,9 +561,17 @@ func (p *PrometheusStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesR
 func (p *PrometheusStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	externalLset := p.externalLabels()
 
+	match, matchers, err := labelValuesMatchers(r.Label, r.Matchers, externalLset)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelValuesResponse{Values: []string{}}, nil
+	}
+
 	// First check for matching external label which has priority.
 	if l := externalLset.Get(r.Label); l != "" {
-		return &storepb.LabelValuesResponse{Values: []string{l}}, nil
+		return &storepb.LabelValuesResponse{Values: filterValues([]string{l}, matchers)}, nil
 	}
 
 	u := *p.base
@@ -609,5 +623,5 @@ func (p *PrometheusStore) LabelValues(ctx context.Context, r *storepb.LabelValue
 		return nil, status.Error(code, m.Error)
 	}
 
-	return &storepb.LabelValuesResponse{Values: m.Data}, nil
+	return &storepb.LabelValuesResponse{Values: filterValues(m.Data, matchers)}, nil
 }
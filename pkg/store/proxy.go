@@ -17,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/requestid"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
@@ -49,6 +50,13 @@ type ProxyStore struct {
 	selectorLabels labels.Labels
 
 	responseTimeout time.Duration
+	router          SeriesRouter
+}
+
+// SeriesRouter returns the stores a series request is sent to out of the given stores, e.g. to skip stores holding
+// the same data as other, more authoritative stores.
+type SeriesRouter interface {
+	Route(stores []Client, r *storepb.SeriesRequest) []Client
 }
 
 // NewProxyStore returns a new ProxyStore that uses the given clients that implements storeAPI to fan-in all series to the client.
@@ -74,6 +82,12 @@ func NewProxyStore(
 	return s
 }
 
+// SetSeriesRouter sets the router selecting the stores series requests are sent to. All stores matching a request are
+// queried without router.
+func (s *ProxyStore) SetSeriesRouter(r SeriesRouter) {
+	s.router = r
+}
+
 // Info returns store information about the external labels this store have.
 func (s *ProxyStore) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb.InfoResponse, error) {
 	res := &storepb.InfoResponse{
@@ -181,6 +195,8 @@ func (s ctxRespSender) send(r *storepb.SeriesResponse) {
 // Series returns all series for a requested time range and label matcher. Requested series are taken from other
 // stores and proxied to RPC client. NOTE: Resulted data are not trimmed exactly to min and max time range.
 func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	logger := requestid.Logger(srv.Context(), s.logger)
+
 	match, newMatchers, err := matchesExternalLabels(r.Matchers, s.selectorLabels)
 	if err != nil {
 		return status.Error(codes.InvalidArgument, err.Error())
@@ -212,6 +228,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 				Aggregates:              r.Aggregates,
 				MaxResolutionWindow:     r.MaxResolutionWindow,
 				PartialResponseDisabled: r.PartialResponseDisabled,
+				Hints:                   r.Hints,
 			}
 			wg = &sync.WaitGroup{}
 		)
@@ -221,7 +238,11 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 			closeFn()
 		}()
 
-		for _, st := range s.stores() {
+		stores := s.stores()
+		if s.router != nil {
+			stores = s.router.Route(stores, r)
+		}
+		for _, st := range stores {
 			// We might be able to skip the store if its meta information indicates
 			// it cannot have series matching our query.
 			// NOTE: all matchers are validated in matchesExternalLabels method so we explicitly ignore error.
@@ -249,7 +270,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 				}
 				err = errors.Wrapf(err, "fetch series for %s %s", storeID, st)
 				if r.PartialResponseDisabled {
-					level.Error(s.logger).Log("err", err, "msg", "partial response disabled; aborting request")
+					level.Error(logger).Log("err", err, "msg", "partial response disabled; aborting request")
 					return err
 				}
 				respSender.send(storepb.NewWarnSeriesResponse(err))
@@ -258,15 +279,15 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 
 			// Schedule streamSeriesSet that translates gRPC streamed response
 			// into seriesSet (if series) or respCh if warnings.
-			seriesSet = append(seriesSet, startStreamSeriesSet(seriesCtx, s.logger, closeSeries,
+			seriesSet = append(seriesSet, startStreamSeriesSet(seriesCtx, logger, closeSeries,
 				wg, sc, respSender, st.String(), !r.PartialResponseDisabled, s.responseTimeout))
 		}
 
-		level.Debug(s.logger).Log("msg", strings.Join(storeDebugMsgs, ";"))
+		level.Debug(logger).Log("msg", strings.Join(storeDebugMsgs, ";"))
 		if len(seriesSet) == 0 {
 			// This is indicates that configured StoreAPIs are not the ones end user expects.
 			err := errors.New("No store matched for this query")
-			level.Warn(s.logger).Log("err", err, "stores", strings.Join(storeDebugMsgs, ";"))
+			level.Warn(logger).Log("err", err, "stores", strings.Join(storeDebugMsgs, ";"))
 			respSender.send(storepb.NewWarnSeriesResponse(err))
 			return nil
 		}
@@ -287,7 +308,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 	}
 
 	if err := g.Wait(); err != nil {
-		level.Error(s.logger).Log("err", err)
+		level.Error(logger).Log("err", err)
 		return err
 	}
 	return nil
@@ -368,6 +389,13 @@ func startStreamSeriesSet(
 			}
 
 			if w := r.GetWarning(); w != "" {
+				if lw, ok := storepb.ParseLimitWarning(w); ok {
+					if lw.Store == "" {
+						lw.Store = s.name
+					}
+					s.warnCh.send(storepb.NewLimitWarnSeriesResponse(lw))
+					continue
+				}
 				s.warnCh.send(storepb.NewWarnSeriesResponse(errors.New(w)))
 				continue
 			}
@@ -539,12 +567,32 @@ func (s *ProxyStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequ
 		g, gctx  = errgroup.WithContext(ctx)
 	)
 
+	ms, err := translateMatchers(r.Matchers)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// Stores not supporting matchers yet ignore them and return all values, so matchers of the requested label are
+	// applied to the merged values again.
+	var valueMatchers []labels.Matcher
+	for _, m := range ms {
+		if m.Name() == r.Label {
+			valueMatchers = append(valueMatchers, m)
+		}
+	}
+
 	for _, st := range s.stores() {
 		store := st
+
+		// Skip stores with external labels not matching the requested matchers.
+		if ok, _ := labelSetsMatch(store.LabelSets(), r.Matchers); !ok {
+			continue
+		}
+
 		g.Go(func() error {
 			resp, err := store.LabelValues(gctx, &storepb.LabelValuesRequest{
 				Label:                   r.Label,
 				PartialResponseDisabled: r.PartialResponseDisabled,
+				Matchers:                r.Matchers,
 			})
 			if err != nil {
 				err = errors.Wrapf(err, "fetch label values from store %s", store)
@@ -572,7 +620,7 @@ func (s *ProxyStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequ
 	}
 
 	return &storepb.LabelValuesResponse{
-		Values:   strutil.MergeUnsortedSlices(all...),
+		Values:   filterValues(strutil.MergeUnsortedSlices(all...), valueMatchers),
 		Warnings: warnings,
 	}, nil
 }
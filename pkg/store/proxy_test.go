@@ -602,6 +602,41 @@ func TestProxyStore_Series_RequestParamsProxied(t *testing.T) {
 	testutil.Assert(t, proto.Equal(req, m.LastSeriesReq), "request was not proxied properly to underlying storeAPI: %s vs %s", req, m.LastSeriesReq)
 }
 
+func TestProxyStore_Series_LimitWarning(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	cls := []Client{
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storepb.NewLimitWarnSeriesResponse(storepb.LimitWarning{Limit: "samples", Value: 10, Got: 20}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+	}
+	q := NewProxyStore(nil,
+		func() []Client { return cls },
+		component.Query,
+		nil,
+		0*time.Second,
+	)
+
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(&storepb.SeriesRequest{
+		MinTime:  1,
+		MaxTime:  300,
+		Matchers: []storepb.LabelMatcher{{Name: "a", Value: "a", Type: storepb.LabelMatcher_EQ}},
+	}, s))
+
+	testutil.Equals(t, 1, len(s.Warnings), "got %v", s.Warnings)
+	w, ok := storepb.ParseLimitWarning(s.Warnings[0])
+	testutil.Assert(t, ok, "expected limit warning, got %v", s.Warnings[0])
+	// Store identity is filled in by the proxy.
+	testutil.Equals(t, storepb.LimitWarning{Store: "test", Limit: "samples", Value: 10, Got: 20}, w)
+}
+
 func TestProxyStore_Series_RegressionFillResponseChannel(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -693,6 +728,60 @@ func TestProxyStore_LabelValues(t *testing.T) {
 	testutil.Equals(t, 1, len(resp.Warnings))
 }
 
+func TestProxyStore_LabelValuesWithMatchers(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	m1 := &mockedStoreAPI{
+		RespLabelValues: &storepb.LabelValuesResponse{Values: []string{"1"}},
+	}
+	m2 := &mockedStoreAPI{
+		RespLabelValues: &storepb.LabelValuesResponse{Values: []string{"2"}},
+	}
+	cls := []Client{
+		&testClient{
+			StoreClient: m1,
+			labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "ext", Value: "1"}}}},
+		},
+		&testClient{
+			StoreClient: m2,
+			labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "ext", Value: "2"}}}},
+		},
+	}
+	q := NewProxyStore(nil,
+		func() []Client { return cls },
+		component.Query,
+		nil,
+		0*time.Second,
+	)
+
+	ctx := context.Background()
+	req := &storepb.LabelValuesRequest{
+		Label: "a",
+		Matchers: []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: "ext", Value: "1"},
+			{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|2"},
+		},
+		PartialResponseDisabled: true,
+	}
+	resp, err := q.LabelValues(ctx, req)
+	testutil.Ok(t, err)
+	testutil.Assert(t, proto.Equal(req, m1.LastLabelValuesReq), "request was not proxied properly to underlying storeAPI: %s vs %s", req, m1.LastLabelValuesReq)
+	testutil.Assert(t, m2.LastLabelValuesReq == nil, "request should not be sent to store with non-matching external labels")
+	testutil.Equals(t, []string{"1"}, resp.Values)
+
+	// Matchers of the requested label are applied to values of stores ignoring them.
+	m1.RespLabelValues = &storepb.LabelValuesResponse{Values: []string{"1", "3"}}
+	resp, err = q.LabelValues(ctx, req)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"1"}, resp.Values)
+
+	_, err = q.LabelValues(ctx, &storepb.LabelValuesRequest{
+		Label:    "a",
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "("}},
+	})
+	testutil.NotOk(t, err)
+}
+
 func TestProxyStore_LabelNames(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
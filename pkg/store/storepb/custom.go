@@ -1,6 +1,8 @@
 package storepb
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/prometheus/prometheus/pkg/labels"
@@ -22,6 +24,53 @@ func NewWarnSeriesResponse(err error) *SeriesResponse {
 	}
 }
 
+// limitWarningPrefix marks series response warnings which carry an encoded LimitWarning.
+const limitWarningPrefix = "limit warning: "
+
+// LimitWarning describes a limit hit by a StoreAPI which caused its series to be dropped from the response.
+type LimitWarning struct {
+	// Store is the identity of the StoreAPI that hit the limit. It is filled in by the proxy if empty.
+	Store string `json:"store"`
+	// Limit is the name of the limit that was hit, e.g "samples".
+	Limit string `json:"limit"`
+	// Value is the configured limit.
+	Value uint64 `json:"value"`
+	// Got is the number that exceeded the limit.
+	Got uint64 `json:"got"`
+}
+
+func (w LimitWarning) Error() string {
+	store := w.Store
+	if store == "" {
+		store = "unknown"
+	}
+	return fmt.Sprintf("store %s exceeded %s limit %d (got %d), its data is missing from the result", store, w.Limit, w.Value, w.Got)
+}
+
+// NewLimitWarnSeriesResponse returns a warning series response which carries the given LimitWarning.
+func NewLimitWarnSeriesResponse(w LimitWarning) *SeriesResponse {
+	// Marshaling a struct of strings and integers cannot fail.
+	b, _ := json.Marshal(w)
+	return &SeriesResponse{
+		Result: &SeriesResponse_Warning{
+			Warning: limitWarningPrefix + string(b),
+		},
+	}
+}
+
+// ParseLimitWarning decodes a LimitWarning from the given series response warning.
+// It returns false if the warning does not carry a LimitWarning.
+func ParseLimitWarning(warning string) (LimitWarning, bool) {
+	var w LimitWarning
+	if !strings.HasPrefix(warning, limitWarningPrefix) {
+		return w, false
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(warning, limitWarningPrefix)), &w); err != nil {
+		return w, false
+	}
+	return w, true
+}
+
 func NewSeriesResponse(series *Series) *SeriesResponse {
 	return &SeriesResponse{
 		Result: &SeriesResponse_Series{
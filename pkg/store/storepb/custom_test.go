@@ -260,3 +260,17 @@ func seriesEquals(t *testing.T, expected []rawSeries, gotSS SeriesSet) {
 	}
 
 }
+
+func TestLimitWarning(t *testing.T) {
+	w := LimitWarning{Store: "store-1", Limit: "samples", Value: 100, Got: 120}
+
+	got, ok := ParseLimitWarning(NewLimitWarnSeriesResponse(w).GetWarning())
+	testutil.Assert(t, ok, "expected limit warning to be parsed")
+	testutil.Equals(t, w, got)
+
+	_, ok = ParseLimitWarning(NewWarnSeriesResponse(errors.New("warning")).GetWarning())
+	testutil.Assert(t, !ok, "expected plain warning not to be parsed as limit warning")
+
+	_, ok = ParseLimitWarning(limitWarningPrefix + "{")
+	testutil.Assert(t, !ok, "expected malformed limit warning not to be parsed")
+}
@@ -271,9 +271,12 @@ type SeriesRequest struct {
 	PartialResponseDisabled bool `protobuf:"varint,6,opt,name=partial_response_disabled,json=partialResponseDisabled,proto3" json:"partial_response_disabled,omitempty"`
 	// TODO(bwplotka): Move Thanos components to use strategy instead. Including QueryAPI.
 	PartialResponseStrategy PartialResponseStrategy `protobuf:"varint,7,opt,name=partial_response_strategy,json=partialResponseStrategy,proto3,enum=thanos.PartialResponseStrategy" json:"partial_response_strategy,omitempty"`
-	XXX_NoUnkeyedLiteral    struct{}                `json:"-"`
-	XXX_unrecognized        []byte                  `json:"-"`
-	XXX_sizecache           int32                   `json:"-"`
+	// Optional hints describing how the query uses the requested series. StoreAPIs can use them to skip
+	// chunks which cannot influence query results. Hints are best effort, so all chunks can be returned.
+	Hints                *QueryHints `protobuf:"bytes,8,opt,name=hints,proto3" json:"hints,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
 }
 
 func (m *SeriesRequest) Reset()         { *m = SeriesRequest{} }
@@ -309,6 +312,58 @@ func (m *SeriesRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_SeriesRequest proto.InternalMessageInfo
 
+// QueryHints describes the evaluation of the PromQL selector the series are requested for.
+type QueryHints struct {
+	// Timestamps of the first and last evaluation step in milliseconds, shifted by the offset of the selector.
+	StartMillis int64 `protobuf:"varint,1,opt,name=start_millis,json=startMillis,proto3" json:"start_millis,omitempty"`
+	EndMillis   int64 `protobuf:"varint,2,opt,name=end_millis,json=endMillis,proto3" json:"end_millis,omitempty"`
+	// Query step in milliseconds. 0 for instant queries, which are evaluated at start_millis only.
+	StepMillis int64 `protobuf:"varint,3,opt,name=step_millis,json=stepMillis,proto3" json:"step_millis,omitempty"`
+	// Only samples within range_millis before each step are used, e.g. the range of a matrix selector or
+	// the lookback delta of a vector selector.
+	RangeMillis int64 `protobuf:"varint,4,opt,name=range_millis,json=rangeMillis,proto3" json:"range_millis,omitempty"`
+	// If true, only the latest sample within the range before each step is used, as for vector selectors.
+	LatestSampleOnly bool `protobuf:"varint,5,opt,name=latest_sample_only,json=latestSampleOnly,proto3" json:"latest_sample_only,omitempty"`
+	// Function or aggregation surrounding the selector, e.g. "rate".
+	Func                 string   `protobuf:"bytes,6,opt,name=func,proto3" json:"func,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueryHints) Reset()         { *m = QueryHints{} }
+func (m *QueryHints) String() string { return proto.CompactTextString(m) }
+func (*QueryHints) ProtoMessage()    {}
+func (*QueryHints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{4}
+}
+func (m *QueryHints) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryHints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryHints.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryHints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryHints.Merge(m, src)
+}
+func (m *QueryHints) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryHints) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryHints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryHints proto.InternalMessageInfo
+
 type SeriesResponse struct {
 	// Types that are valid to be assigned to Result:
 	//	*SeriesResponse_Series
@@ -323,7 +378,7 @@ func (m *SeriesResponse) Reset()         { *m = SeriesResponse{} }
 func (m *SeriesResponse) String() string { return proto.CompactTextString(m) }
 func (*SeriesResponse) ProtoMessage()    {}
 func (*SeriesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{4}
+	return fileDescriptor_77a6da22d6a3feb1, []int{5}
 }
 func (m *SeriesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -472,7 +527,7 @@ func (m *LabelNamesRequest) Reset()         { *m = LabelNamesRequest{} }
 func (m *LabelNamesRequest) String() string { return proto.CompactTextString(m) }
 func (*LabelNamesRequest) ProtoMessage()    {}
 func (*LabelNamesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+	return fileDescriptor_77a6da22d6a3feb1, []int{6}
 }
 func (m *LabelNamesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -513,7 +568,7 @@ func (m *LabelNamesResponse) Reset()         { *m = LabelNamesResponse{} }
 func (m *LabelNamesResponse) String() string { return proto.CompactTextString(m) }
 func (*LabelNamesResponse) ProtoMessage()    {}
 func (*LabelNamesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{6}
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
 }
 func (m *LabelNamesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -547,16 +602,20 @@ type LabelValuesRequest struct {
 	PartialResponseDisabled bool   `protobuf:"varint,2,opt,name=partial_response_disabled,json=partialResponseDisabled,proto3" json:"partial_response_disabled,omitempty"`
 	// TODO(bwplotka): Move Thanos components to use strategy instead. Including QueryAPI.
 	PartialResponseStrategy PartialResponseStrategy `protobuf:"varint,3,opt,name=partial_response_strategy,json=partialResponseStrategy,proto3,enum=thanos.PartialResponseStrategy" json:"partial_response_strategy,omitempty"`
-	XXX_NoUnkeyedLiteral    struct{}                `json:"-"`
-	XXX_unrecognized        []byte                  `json:"-"`
-	XXX_sizecache           int32                   `json:"-"`
+	// Matchers restrict returned values of the requested label. Only values matching all matchers for the requested label
+	// are returned. Matchers for other labels are matched against external labels of the store (or empty value if the store
+	// does not have such external label) and no values are returned if any of them does not match.
+	Matchers             []LabelMatcher `protobuf:"bytes,4,rep,name=matchers,proto3" json:"matchers"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
 func (m *LabelValuesRequest) Reset()         { *m = LabelValuesRequest{} }
 func (m *LabelValuesRequest) String() string { return proto.CompactTextString(m) }
 func (*LabelValuesRequest) ProtoMessage()    {}
 func (*LabelValuesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
 }
 func (m *LabelValuesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -597,7 +656,7 @@ func (m *LabelValuesResponse) Reset()         { *m = LabelValuesResponse{} }
 func (m *LabelValuesResponse) String() string { return proto.CompactTextString(m) }
 func (*LabelValuesResponse) ProtoMessage()    {}
 func (*LabelValuesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
 }
 func (m *LabelValuesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -634,6 +693,7 @@ func init() {
 	proto.RegisterType((*InfoResponse)(nil), "thanos.InfoResponse")
 	proto.RegisterType((*LabelSet)(nil), "thanos.LabelSet")
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
+	proto.RegisterType((*QueryHints)(nil), "thanos.QueryHints")
 	proto.RegisterType((*SeriesResponse)(nil), "thanos.SeriesResponse")
 	proto.RegisterType((*LabelNamesRequest)(nil), "thanos.LabelNamesRequest")
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
@@ -644,56 +704,64 @@ func init() {
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 772 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x4f, 0x6f, 0xfa, 0x46,
-	0x10, 0x65, 0x6d, 0x30, 0x78, 0x48, 0x90, 0xb3, 0x21, 0x89, 0x71, 0x25, 0x82, 0x38, 0xa1, 0xb4,
-	0x22, 0x2d, 0x55, 0x5b, 0xb5, 0x37, 0x20, 0x8e, 0x82, 0x9a, 0x40, 0xbb, 0x40, 0xe8, 0x9f, 0x03,
-	0x35, 0xc9, 0xd6, 0xb1, 0x64, 0x6c, 0xea, 0x35, 0x4d, 0x72, 0xed, 0xe7, 0xe9, 0xb7, 0xe8, 0x25,
-	0xc7, 0x5e, 0x7b, 0xa9, 0xda, 0x7c, 0x8a, 0x1e, 0x2b, 0xaf, 0xd7, 0x80, 0xdb, 0x24, 0xd2, 0x4f,
-	0xdc, 0x76, 0xde, 0x1b, 0xcf, 0xec, 0x7b, 0x3b, 0xbb, 0x06, 0x35, 0x58, 0xdc, 0x34, 0x17, 0x81,
-	0x1f, 0xfa, 0x58, 0x09, 0xef, 0x2c, 0xcf, 0x67, 0x46, 0x31, 0x7c, 0x5c, 0x50, 0x16, 0x83, 0x46,
-	0xd9, 0xf6, 0x6d, 0x9f, 0x2f, 0x4f, 0xa3, 0x55, 0x8c, 0xd6, 0x77, 0xa1, 0xd8, 0xf3, 0x7e, 0xf4,
-	0x09, 0xfd, 0x69, 0x49, 0x59, 0x58, 0xff, 0x03, 0xc1, 0x4e, 0x1c, 0xb3, 0x85, 0xef, 0x31, 0x8a,
-	0xdf, 0x07, 0xc5, 0xb5, 0x66, 0xd4, 0x65, 0x3a, 0xaa, 0xc9, 0x8d, 0x62, 0x6b, 0xb7, 0x19, 0xd7,
-	0x6e, 0x5e, 0x46, 0x68, 0x27, 0xfb, 0xf4, 0xe7, 0x71, 0x86, 0x88, 0x14, 0x5c, 0x81, 0xc2, 0xdc,
-	0xf1, 0xa6, 0xa1, 0x33, 0xa7, 0xba, 0x54, 0x43, 0x0d, 0x99, 0xe4, 0xe7, 0x8e, 0x37, 0x72, 0xe6,
-	0x94, 0x53, 0xd6, 0x43, 0x4c, 0xc9, 0x82, 0xb2, 0x1e, 0x38, 0x75, 0x0a, 0x2a, 0x0b, 0xfd, 0x80,
-	0x8e, 0x1e, 0x17, 0x54, 0xcf, 0xd6, 0x50, 0xa3, 0xd4, 0xda, 0x4b, 0xba, 0x0c, 0x13, 0x82, 0xac,
-	0x73, 0xf0, 0x27, 0x00, 0xbc, 0xe1, 0x94, 0xd1, 0x90, 0xe9, 0x39, 0xbe, 0x2f, 0x2d, 0xb5, 0xaf,
-	0x21, 0x0d, 0xc5, 0xd6, 0x54, 0x57, 0xc4, 0xac, 0xfe, 0x19, 0x14, 0x12, 0xf2, 0x9d, 0x64, 0xd5,
-	0xff, 0x91, 0x60, 0x77, 0x48, 0x03, 0x87, 0x32, 0x61, 0x53, 0x4a, 0x28, 0x7a, 0x5d, 0xa8, 0x94,
-	0x16, 0xfa, 0x69, 0x44, 0x85, 0x37, 0x77, 0x34, 0x60, 0xba, 0xcc, 0xdb, 0x96, 0x53, 0x6d, 0xaf,
-	0x62, 0x52, 0x74, 0x5f, 0xe5, 0xe2, 0x16, 0x1c, 0x44, 0x25, 0x03, 0xca, 0x7c, 0x77, 0x19, 0x3a,
-	0xbe, 0x37, 0xbd, 0x77, 0xbc, 0x5b, 0xff, 0x9e, 0x9b, 0x25, 0x93, 0xfd, 0xb9, 0xf5, 0x40, 0x56,
-	0xdc, 0x84, 0x53, 0xf8, 0x03, 0x00, 0xcb, 0xb6, 0x03, 0x6a, 0x5b, 0x21, 0x8d, 0x3d, 0x2a, 0xb5,
-	0x76, 0x92, 0x6e, 0x6d, 0xdb, 0x0e, 0xc8, 0x06, 0x8f, 0xbf, 0x80, 0xca, 0xc2, 0x0a, 0x42, 0xc7,
-	0x72, 0xa3, 0x2e, 0xfc, 0xe4, 0xa7, 0xb7, 0x0e, 0xb3, 0x66, 0x2e, 0xbd, 0xd5, 0x95, 0x1a, 0x6a,
-	0x14, 0xc8, 0x91, 0x48, 0x48, 0x26, 0xe3, 0x4c, 0xd0, 0xf8, 0xfb, 0x17, 0xbe, 0x65, 0x61, 0x60,
-	0x85, 0xd4, 0x7e, 0xd4, 0xf3, 0xfc, 0x38, 0x8f, 0x93, 0xc6, 0x5f, 0xa5, 0x6b, 0x0c, 0x45, 0xda,
-	0xff, 0x8a, 0x27, 0x44, 0xfd, 0x07, 0x28, 0x25, 0xce, 0x8b, 0x81, 0x6c, 0x80, 0xc2, 0x38, 0xc2,
-	0x8d, 0x2f, 0xb6, 0x4a, 0xab, 0x51, 0xe1, 0xe8, 0x45, 0x86, 0x08, 0x1e, 0x1b, 0x90, 0xbf, 0xb7,
-	0x02, 0xcf, 0xf1, 0x6c, 0x7e, 0x10, 0xea, 0x45, 0x86, 0x24, 0x40, 0xa7, 0x00, 0x4a, 0x40, 0xd9,
-	0xd2, 0x0d, 0xeb, 0xbf, 0x22, 0xd8, 0xe3, 0xee, 0xf7, 0xad, 0xf9, 0xfa, 0x80, 0xdf, 0x34, 0x04,
-	0x6d, 0x61, 0x88, 0xb4, 0xa5, 0x21, 0xe7, 0x80, 0x37, 0x77, 0x2b, 0x4c, 0x29, 0x43, 0xce, 0x8b,
-	0x00, 0x3e, 0xcd, 0x2a, 0x89, 0x03, 0x6c, 0x40, 0x41, 0xe8, 0x65, 0xba, 0xc4, 0x89, 0x55, 0x5c,
-	0xff, 0x0d, 0x89, 0x42, 0xd7, 0x96, 0xbb, 0x5c, 0xeb, 0x2e, 0x43, 0x8e, 0x0f, 0x3d, 0xd7, 0xa8,
-	0x92, 0x38, 0x78, 0xdb, 0x0d, 0x69, 0x0b, 0x37, 0xe4, 0x2d, 0xdd, 0xe8, 0xc1, 0x7e, 0x4a, 0x84,
-	0xb0, 0xe3, 0x10, 0x94, 0x9f, 0x39, 0x22, 0xfc, 0x10, 0xd1, 0x5b, 0x86, 0x9c, 0x10, 0x50, 0x57,
-	0x8f, 0x0d, 0x2e, 0x42, 0x7e, 0xdc, 0xff, 0xb2, 0x3f, 0x98, 0xf4, 0xb5, 0x0c, 0x56, 0x21, 0xf7,
-	0xf5, 0xd8, 0x24, 0xdf, 0x6a, 0x08, 0x17, 0x20, 0x4b, 0xc6, 0x97, 0xa6, 0x26, 0x45, 0x19, 0xc3,
-	0xde, 0x99, 0xd9, 0x6d, 0x13, 0x4d, 0x8e, 0x32, 0x86, 0xa3, 0x01, 0x31, 0xb5, 0x6c, 0x84, 0x13,
-	0xb3, 0x6b, 0xf6, 0xae, 0x4d, 0x2d, 0x77, 0xd2, 0x84, 0xa3, 0x57, 0x24, 0x45, 0x95, 0x26, 0x6d,
-	0x22, 0xca, 0xb7, 0x3b, 0x03, 0x32, 0xd2, 0xd0, 0x49, 0x07, 0xb2, 0xd1, 0xd5, 0xc4, 0x79, 0x90,
-	0x49, 0x7b, 0x12, 0x73, 0xdd, 0xc1, 0xb8, 0x3f, 0xd2, 0x50, 0x84, 0x0d, 0xc7, 0x57, 0x9a, 0x14,
-	0x2d, 0xae, 0x7a, 0x7d, 0x4d, 0xe6, 0x8b, 0xf6, 0x37, 0x71, 0x4f, 0x9e, 0x65, 0x12, 0x2d, 0xd7,
-	0xfa, 0x45, 0x82, 0x1c, 0x17, 0x82, 0x3f, 0x82, 0x6c, 0xf4, 0x94, 0xe3, 0xfd, 0xc4, 0xde, 0x8d,
-	0x87, 0xde, 0x28, 0xa7, 0x41, 0x61, 0xdc, 0xe7, 0xa0, 0xc4, 0xd7, 0x08, 0x1f, 0xa4, 0xaf, 0x55,
-	0xf2, 0xd9, 0xe1, 0x7f, 0xe1, 0xf8, 0xc3, 0x0f, 0x11, 0xee, 0x02, 0xac, 0x07, 0x13, 0x57, 0x52,
-	0x0f, 0xdb, 0xe6, 0xd5, 0x32, 0x8c, 0x97, 0x28, 0xd1, 0xff, 0x1c, 0x8a, 0x1b, 0xe7, 0x89, 0xd3,
-	0xa9, 0xa9, 0x49, 0x35, 0xde, 0x7b, 0x91, 0x8b, 0xeb, 0x74, 0x2a, 0x4f, 0x7f, 0x57, 0x33, 0x4f,
-	0xcf, 0x55, 0xf4, 0xfb, 0x73, 0x15, 0xfd, 0xf5, 0x5c, 0x45, 0xdf, 0xe5, 0xf9, 0xef, 0x63, 0x31,
-	0x9b, 0x29, 0xfc, 0xbf, 0xf7, 0xf1, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xe8, 0xcb, 0x18, 0x01,
-	0x2f, 0x07, 0x00, 0x00,
+	// 909 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x72, 0xe3, 0x44,
+	0x10, 0xce, 0x58, 0xfe, 0x53, 0x2b, 0x49, 0x69, 0x27, 0xde, 0x5d, 0xc5, 0x14, 0x89, 0x57, 0x27,
+	0x57, 0xd8, 0xca, 0x82, 0x29, 0xa0, 0xe0, 0x66, 0x67, 0xbd, 0x15, 0x17, 0x1b, 0x9b, 0x1d, 0xdb,
+	0x1b, 0x7e, 0x0e, 0x46, 0x89, 0x67, 0x15, 0x55, 0xc9, 0x92, 0xd0, 0x8c, 0x49, 0x7c, 0xe5, 0x51,
+	0x38, 0xf3, 0x20, 0x39, 0x72, 0xe0, 0xc2, 0x85, 0x82, 0xbc, 0x06, 0x17, 0x6a, 0x7e, 0x64, 0x5b,
+	0x90, 0x4d, 0xb1, 0x95, 0xdb, 0xcc, 0xf7, 0xb5, 0xba, 0xa7, 0xbf, 0xee, 0x9e, 0x11, 0x98, 0x69,
+	0x72, 0x7e, 0x98, 0xa4, 0x31, 0x8f, 0x71, 0x99, 0x5f, 0x78, 0x51, 0xcc, 0xea, 0x16, 0x5f, 0x24,
+	0x94, 0x29, 0xb0, 0x5e, 0xf3, 0x63, 0x3f, 0x96, 0xcb, 0x67, 0x62, 0xa5, 0x50, 0x77, 0x0b, 0xac,
+	0x5e, 0xf4, 0x26, 0x26, 0xf4, 0x87, 0x39, 0x65, 0xdc, 0xfd, 0x1d, 0xc1, 0xa6, 0xda, 0xb3, 0x24,
+	0x8e, 0x18, 0xc5, 0x1f, 0x40, 0x39, 0xf4, 0xce, 0x68, 0xc8, 0x1c, 0xd4, 0x30, 0x9a, 0x56, 0x6b,
+	0xeb, 0x50, 0xf9, 0x3e, 0x7c, 0x29, 0xd0, 0x4e, 0xf1, 0xfa, 0x8f, 0xfd, 0x0d, 0xa2, 0x4d, 0xf0,
+	0x2e, 0x54, 0x67, 0x41, 0x34, 0xe1, 0xc1, 0x8c, 0x3a, 0x85, 0x06, 0x6a, 0x1a, 0xa4, 0x32, 0x0b,
+	0xa2, 0x51, 0x30, 0xa3, 0x92, 0xf2, 0xae, 0x14, 0x65, 0x68, 0xca, 0xbb, 0x92, 0xd4, 0x33, 0x30,
+	0x19, 0x8f, 0x53, 0x3a, 0x5a, 0x24, 0xd4, 0x29, 0x36, 0x50, 0x73, 0xbb, 0xf5, 0x20, 0x8b, 0x32,
+	0xcc, 0x08, 0xb2, 0xb2, 0xc1, 0x9f, 0x00, 0xc8, 0x80, 0x13, 0x46, 0x39, 0x73, 0x4a, 0xf2, 0x5c,
+	0x76, 0xee, 0x5c, 0x43, 0xca, 0xf5, 0xd1, 0xcc, 0x50, 0xef, 0x99, 0xfb, 0x19, 0x54, 0x33, 0xf2,
+	0x9d, 0xd2, 0x72, 0x7f, 0x36, 0x60, 0x6b, 0x48, 0xd3, 0x80, 0x32, 0x2d, 0x53, 0x2e, 0x51, 0xf4,
+	0xf6, 0x44, 0x0b, 0xf9, 0x44, 0x3f, 0x15, 0x14, 0x3f, 0xbf, 0xa0, 0x29, 0x73, 0x0c, 0x19, 0xb6,
+	0x96, 0x0b, 0x7b, 0xa2, 0x48, 0x1d, 0x7d, 0x69, 0x8b, 0x5b, 0xf0, 0x50, 0xb8, 0x4c, 0x29, 0x8b,
+	0xc3, 0x39, 0x0f, 0xe2, 0x68, 0x72, 0x19, 0x44, 0xd3, 0xf8, 0x52, 0x8a, 0x65, 0x90, 0x9d, 0x99,
+	0x77, 0x45, 0x96, 0xdc, 0xa9, 0xa4, 0xf0, 0x53, 0x00, 0xcf, 0xf7, 0x53, 0xea, 0x7b, 0x9c, 0x2a,
+	0x8d, 0xb6, 0x5b, 0x9b, 0x59, 0xb4, 0xb6, 0xef, 0xa7, 0x64, 0x8d, 0xc7, 0x5f, 0xc0, 0x6e, 0xe2,
+	0xa5, 0x3c, 0xf0, 0xc2, 0x49, 0xaa, 0x2b, 0x3f, 0x99, 0x06, 0xcc, 0x3b, 0x0b, 0xe9, 0xd4, 0x29,
+	0x37, 0x50, 0xb3, 0x4a, 0x1e, 0x6b, 0x83, 0xac, 0x33, 0x9e, 0x6b, 0x1a, 0x7f, 0x77, 0xcb, 0xb7,
+	0x8c, 0xa7, 0x1e, 0xa7, 0xfe, 0xc2, 0xa9, 0xc8, 0x72, 0xee, 0x67, 0x81, 0xbf, 0xca, 0xfb, 0x18,
+	0x6a, 0xb3, 0xff, 0x38, 0xcf, 0x08, 0xdc, 0x84, 0xd2, 0x45, 0x10, 0x71, 0xe6, 0x54, 0x1b, 0xa8,
+	0x69, 0xb5, 0x70, 0xe6, 0xe8, 0xd5, 0x9c, 0xa6, 0x8b, 0x63, 0xc1, 0x10, 0x65, 0xe0, 0xfe, 0x86,
+	0x00, 0x56, 0x28, 0x7e, 0x02, 0x9b, 0x8c, 0x7b, 0x29, 0x9f, 0xcc, 0x82, 0x30, 0x0c, 0x98, 0xae,
+	0x92, 0x25, 0xb1, 0x13, 0x09, 0xe1, 0xf7, 0x01, 0x68, 0x34, 0xcd, 0x0c, 0x54, 0xad, 0x4c, 0x1a,
+	0x4d, 0x35, 0xbd, 0x0f, 0x16, 0xe3, 0x34, 0xc9, 0x78, 0xd5, 0xb4, 0x20, 0x20, 0x6d, 0xf0, 0x04,
+	0x36, 0x53, 0x2f, 0xf2, 0x69, 0x66, 0xa1, 0xaa, 0x61, 0x49, 0x4c, 0x9b, 0x3c, 0x05, 0x1c, 0x0a,
+	0x81, 0xf9, 0x84, 0x79, 0xb3, 0x24, 0xa4, 0x93, 0x38, 0x0a, 0x17, 0x4e, 0x49, 0x0a, 0x6a, 0x2b,
+	0x66, 0x28, 0x89, 0x41, 0x14, 0x2e, 0x30, 0x86, 0xe2, 0x9b, 0x79, 0x74, 0x2e, 0x05, 0x37, 0x89,
+	0x5c, 0xbb, 0xdf, 0xc3, 0x76, 0xd6, 0x7a, 0x7a, 0x22, 0x9b, 0x50, 0x66, 0x12, 0x91, 0x39, 0x59,
+	0xad, 0xed, 0xe5, 0xac, 0x48, 0xf4, 0x78, 0x83, 0x68, 0x1e, 0xd7, 0xa1, 0x72, 0xe9, 0xa5, 0x51,
+	0x10, 0xf9, 0x32, 0x3b, 0xf3, 0x78, 0x83, 0x64, 0x40, 0xa7, 0x0a, 0xe5, 0x94, 0xb2, 0x79, 0xc8,
+	0xdd, 0x5f, 0x10, 0x3c, 0x90, 0xed, 0xd7, 0xf7, 0x66, 0xab, 0x0e, 0xbf, 0xb3, 0x23, 0xd0, 0x3d,
+	0x3a, 0xa2, 0x70, 0xbf, 0x8e, 0x70, 0x5f, 0x00, 0x5e, 0x3f, 0xad, 0x16, 0xa5, 0x06, 0xa5, 0x48,
+	0x00, 0x72, 0x9c, 0x4d, 0xa2, 0x36, 0xb8, 0x0e, 0x55, 0x9d, 0xaf, 0xa8, 0xaf, 0x20, 0x96, 0x7b,
+	0xf7, 0x6f, 0xa4, 0x1d, 0xbd, 0xf6, 0xc2, 0xf9, 0x2a, 0xef, 0x1a, 0x94, 0xe4, 0xd4, 0xcb, 0x1c,
+	0x4d, 0xa2, 0x36, 0x77, 0xab, 0x51, 0xb8, 0x87, 0x1a, 0xc6, 0x3d, 0xe7, 0x63, 0xfd, 0x4a, 0x29,
+	0xfe, 0xff, 0x2b, 0xc5, 0xed, 0xc1, 0x4e, 0x2e, 0x79, 0x2d, 0xe3, 0x23, 0x28, 0xff, 0x28, 0x11,
+	0xad, 0xa3, 0xde, 0xdd, 0x25, 0xe4, 0x01, 0x01, 0x73, 0x79, 0x4b, 0x63, 0x0b, 0x2a, 0xe3, 0xfe,
+	0x97, 0xfd, 0xc1, 0x69, 0xdf, 0xde, 0xc0, 0x26, 0x94, 0x5e, 0x8d, 0xbb, 0xe4, 0x1b, 0x1b, 0xe1,
+	0x2a, 0x14, 0xc9, 0xf8, 0x65, 0xd7, 0x2e, 0x08, 0x8b, 0x61, 0xef, 0x79, 0xf7, 0xa8, 0x4d, 0x6c,
+	0x43, 0x58, 0x0c, 0x47, 0x03, 0xd2, 0xb5, 0x8b, 0x02, 0x27, 0xdd, 0xa3, 0x6e, 0xef, 0x75, 0xd7,
+	0x2e, 0x1d, 0x1c, 0xc2, 0xe3, 0xb7, 0x48, 0x21, 0x3c, 0x9d, 0xb6, 0x89, 0x76, 0xdf, 0xee, 0x0c,
+	0xc8, 0xc8, 0x46, 0x07, 0x1d, 0x28, 0x8a, 0x3b, 0x0d, 0x57, 0xc0, 0x20, 0xed, 0x53, 0xc5, 0x1d,
+	0x0d, 0xc6, 0xfd, 0x91, 0x8d, 0x04, 0x36, 0x1c, 0x9f, 0xd8, 0x05, 0xb1, 0x38, 0xe9, 0xf5, 0x6d,
+	0x43, 0x2e, 0xda, 0x5f, 0xab, 0x98, 0xd2, 0xaa, 0x4b, 0xec, 0x52, 0xeb, 0xa7, 0x02, 0x94, 0x64,
+	0x22, 0xf8, 0x23, 0x28, 0x8a, 0x37, 0x10, 0xef, 0x64, 0x52, 0xae, 0xbd, 0x90, 0xf5, 0x5a, 0x1e,
+	0xd4, 0xc2, 0x7d, 0x0e, 0x65, 0x35, 0x7e, 0xf8, 0x61, 0x7e, 0x1c, 0xb3, 0xcf, 0x1e, 0xfd, 0x1b,
+	0x56, 0x1f, 0x7e, 0x88, 0xf0, 0x11, 0xc0, 0xaa, 0xa1, 0xf1, 0x6e, 0xae, 0x7c, 0xeb, 0x23, 0x59,
+	0xaf, 0xdf, 0x46, 0xe9, 0xf8, 0x2f, 0xc0, 0x5a, 0xab, 0x27, 0xce, 0x9b, 0xe6, 0x3a, 0xbc, 0xfe,
+	0xde, 0xad, 0x9c, 0xf2, 0xd3, 0xd9, 0xbd, 0xfe, 0x6b, 0x6f, 0xe3, 0xfa, 0x66, 0x0f, 0xfd, 0x7a,
+	0xb3, 0x87, 0xfe, 0xbc, 0xd9, 0x43, 0xdf, 0x56, 0xe4, 0xbb, 0x9b, 0x9c, 0x9d, 0x95, 0xe5, 0x0f,
+	0xc3, 0xc7, 0xff, 0x0c, 0x00, 0x05, 0xc1, 0xf5, 0xb6, 0x68, 0x08, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1096,6 +1164,18 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
 	if m.PartialResponseStrategy != 0 {
 		i = encodeVarintRpc(dAtA, i, uint64(m.PartialResponseStrategy))
 		i--
@@ -1112,20 +1192,20 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		dAtA[i] = 0x30
 	}
 	if len(m.Aggregates) > 0 {
-		dAtA2 := make([]byte, len(m.Aggregates)*10)
-		var j1 int
+		dAtA3 := make([]byte, len(m.Aggregates)*10)
+		var j2 int
 		for _, num := range m.Aggregates {
 			for num >= 1<<7 {
-				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA3[j2] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j1++
+				j2++
 			}
-			dAtA2[j1] = uint8(num)
-			j1++
+			dAtA3[j2] = uint8(num)
+			j2++
 		}
-		i -= j1
-		copy(dAtA[i:], dAtA2[:j1])
-		i = encodeVarintRpc(dAtA, i, uint64(j1))
+		i -= j2
+		copy(dAtA[i:], dAtA3[:j2])
+		i = encodeVarintRpc(dAtA, i, uint64(j2))
 		i--
 		dAtA[i] = 0x2a
 	}
@@ -1161,6 +1241,70 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QueryHints) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryHints) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Func) > 0 {
+		i -= len(m.Func)
+		copy(dAtA[i:], m.Func)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.Func)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.LatestSampleOnly {
+		i--
+		if m.LatestSampleOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.RangeMillis != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.RangeMillis))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.StepMillis != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.StepMillis))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.EndMillis != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.EndMillis))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StartMillis != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.StartMillis))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *SeriesResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1341,6 +1485,20 @@ func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
 	if m.PartialResponseStrategy != 0 {
 		i = encodeVarintRpc(dAtA, i, uint64(m.PartialResponseStrategy))
 		i--
@@ -1519,6 +1677,41 @@ func (m *SeriesRequest) Size() (n int) {
 	if m.PartialResponseStrategy != 0 {
 		n += 1 + sovRpc(uint64(m.PartialResponseStrategy))
 	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *QueryHints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartMillis != 0 {
+		n += 1 + sovRpc(uint64(m.StartMillis))
+	}
+	if m.EndMillis != 0 {
+		n += 1 + sovRpc(uint64(m.EndMillis))
+	}
+	if m.StepMillis != 0 {
+		n += 1 + sovRpc(uint64(m.StepMillis))
+	}
+	if m.RangeMillis != 0 {
+		n += 1 + sovRpc(uint64(m.RangeMillis))
+	}
+	if m.LatestSampleOnly {
+		n += 2
+	}
+	l = len(m.Func)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1620,6 +1813,12 @@ func (m *LabelValuesRequest) Size() (n int) {
 	if m.PartialResponseStrategy != 0 {
 		n += 1 + sovRpc(uint64(m.PartialResponseStrategy))
 	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -2205,6 +2404,224 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &QueryHints{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryHints) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryHints: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryHints: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartMillis", wireType)
+			}
+			m.StartMillis = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartMillis |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndMillis", wireType)
+			}
+			m.EndMillis = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndMillis |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StepMillis", wireType)
+			}
+			m.StepMillis = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StepMillis |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RangeMillis", wireType)
+			}
+			m.RangeMillis = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RangeMillis |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LatestSampleOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.LatestSampleOnly = bool(v != 0)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Func", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Func = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -2662,6 +3079,40 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -0,0 +1,340 @@
+// Package storetesting provides a conformance test suite for StoreAPI implementations.
+//
+// It is meant to be used by any StoreAPI server, including third party ones, to validate that it follows
+// the semantics Thanos Querier relies on.
+package storetesting
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// samplesPerSeries is the number of samples appended to each in-range series, one per second.
+const samplesPerSeries = 120
+
+// AcceptanceTest runs the StoreAPI conformance suite against the store returned by newStore.
+//
+// The suite appends its test data using the given appender and commits it before newStore is called, so the
+// store under test has to expose everything appended through it. extLset are the external labels the store
+// is expected to advertise and attach to every returned series. The test data does not use any of the
+// external label names.
+//
+// The suite validates:
+// * Info advertising external labels and a time range covering the appended data.
+// * Series being sorted by labels and having external labels attached.
+// * Label matchers, including ones matching external labels.
+// * Time range filtering of series and samples.
+// * Errors being returned instead of warnings when partial response is disabled.
+// * LabelNames and LabelValues.
+func AcceptanceTest(t *testing.T, appender tsdb.Appender, extLset labels.Labels, newStore func() storepb.StoreServer) {
+	baseT := timestamp.FromTime(time.Now().AddDate(0, 0, -2)) / 1000 * 1000
+
+	var (
+		mint = baseT
+		maxt = baseT + (samplesPerSeries-1)*1000
+
+		inRange = []labels.Labels{
+			labels.FromStrings("__name__", "up", "a", "1", "b", "1"),
+			labels.FromStrings("__name__", "up", "a", "1", "b", "2"),
+			labels.FromStrings("__name__", "up", "a", "2", "b", "1"),
+			labels.FromStrings("__name__", "up", "a", "2", "b", "2", "c", "x"),
+		}
+		// outOfRange series has all samples after the time range queried by the suite.
+		outOfRange = labels.FromStrings("__name__", "up", "a", "3")
+	)
+
+	for _, lset := range inRange {
+		for i := int64(0); i < samplesPerSeries; i++ {
+			_, err := appender.Add(lset, baseT+i*1000, float64(i))
+			testutil.Ok(t, err)
+		}
+	}
+	for i := int64(0); i < samplesPerSeries; i++ {
+		_, err := appender.Add(outOfRange, maxt+int64(time.Hour/time.Millisecond)+i*1000, float64(i))
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, appender.Commit())
+
+	store := newStore()
+
+	t.Run("info", func(t *testing.T) {
+		resp, err := store.Info(context.Background(), &storepb.InfoRequest{})
+		testutil.Ok(t, err)
+
+		testutil.Equals(t, labelsToProto(extLset), nonNil(resp.Labels))
+		if len(extLset) > 0 {
+			var found bool
+			for _, ls := range resp.LabelSets {
+				if storepb.CompareLabels(ls.Labels, labelsToProto(extLset)) == 0 {
+					found = true
+				}
+			}
+			testutil.Assert(t, found, "external labels %v not advertised in label sets %v", extLset, storepb.LabelSetsToString(resp.LabelSets))
+		}
+		testutil.Assert(t, resp.MinTime <= mint, "advertised min time %d is after the data min time %d", resp.MinTime, mint)
+		testutil.Assert(t, resp.MaxTime >= maxt, "advertised max time %d is before the data max time %d", resp.MaxTime, maxt)
+	})
+
+	t.Run("series sorted with external labels", func(t *testing.T) {
+		srv := series(t, store, &storepb.SeriesRequest{
+			MinTime:  mint,
+			MaxTime:  maxt,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"}},
+		})
+
+		// Provide the series in non-sorted order to make sure the expectation is sorted.
+		var exp [][]storepb.Label
+		for i := len(inRange) - 1; i >= 0; i-- {
+			exp = append(exp, labelsToProto(extend(inRange[i], extLset)))
+		}
+		sort.Slice(exp, func(i, j int) bool { return storepb.CompareLabels(exp[i], exp[j]) < 0 })
+
+		testutil.Equals(t, exp, seriesLabels(srv.SeriesSet))
+	})
+
+	for _, tcase := range []struct {
+		name     string
+		matchers []storepb.LabelMatcher
+		exp      []labels.Labels
+	}{
+		{
+			name:     "EQ",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+			exp:      inRange[:2],
+		},
+		{
+			name: "NEQ",
+			matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+				{Type: storepb.LabelMatcher_NEQ, Name: "a", Value: "1"},
+			},
+			exp: inRange[2:],
+		},
+		{
+			name:     "RE",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "b", Value: "2|3"}},
+			exp:      []labels.Labels{inRange[1], inRange[3]},
+		},
+		{
+			name: "NRE",
+			matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+				{Type: storepb.LabelMatcher_NRE, Name: "b", Value: "2|3"},
+			},
+			exp: []labels.Labels{inRange[0], inRange[2]},
+		},
+		{
+			name: "empty label matcher matching series without the label",
+			matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+				{Type: storepb.LabelMatcher_EQ, Name: "c", Value: ""},
+			},
+			exp: inRange[:3],
+		},
+		{
+			name:     "no series matching",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "non-existing"}},
+		},
+		{
+			name:     "matching external labels",
+			matchers: append(extMatchers(extLset, ""), storepb.LabelMatcher{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "2"}),
+			exp:      inRange[2:],
+		},
+		{
+			name:     "not matching external labels",
+			matchers: append(extMatchers(extLset, "-non-existing"), storepb.LabelMatcher{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "2"}),
+		},
+	} {
+		if len(extLset) == 0 && tcase.name == "not matching external labels" {
+			continue
+		}
+		t.Run("matchers "+tcase.name, func(t *testing.T) {
+			srv := series(t, store, &storepb.SeriesRequest{MinTime: mint, MaxTime: maxt, Matchers: tcase.matchers})
+
+			exp := [][]storepb.Label{}
+			for _, lset := range tcase.exp {
+				exp = append(exp, labelsToProto(extend(lset, extLset)))
+			}
+			testutil.Equals(t, exp, seriesLabels(srv.SeriesSet))
+		})
+	}
+
+	t.Run("time range", func(t *testing.T) {
+		// Query the second half of the in-range data only.
+		qmint := mint + samplesPerSeries/2*1000
+
+		srv := series(t, store, &storepb.SeriesRequest{
+			MinTime:  qmint,
+			MaxTime:  maxt,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"}},
+		})
+		testutil.Equals(t, len(inRange), len(srv.SeriesSet))
+
+		for _, s := range srv.SeriesSet {
+			// The returned data may exceed the requested time bounds, so only count samples within them.
+			var n int
+			for _, c := range s.Chunks {
+				testutil.Assert(t, c.MaxTime >= qmint && c.MinTime <= maxt, "chunk [%d, %d] outside of requested range [%d, %d]", c.MinTime, c.MaxTime, qmint, maxt)
+				testutil.Assert(t, c.Raw != nil, "raw chunk expected")
+
+				chk, err := chunkenc.FromData(chunkenc.EncXOR, c.Raw.Data)
+				testutil.Ok(t, err)
+
+				it := chk.Iterator(nil)
+				for it.Next() {
+					if ts, _ := it.At(); ts >= qmint && ts <= maxt {
+						n++
+					}
+				}
+				testutil.Ok(t, it.Err())
+			}
+			testutil.Equals(t, samplesPerSeries/2, n)
+		}
+	})
+
+	t.Run("partial response disabled", func(t *testing.T) {
+		srv := newSeriesServer(context.Background())
+		err := store.Series(&storepb.SeriesRequest{
+			MinTime:                 mint,
+			MaxTime:                 maxt,
+			Matchers:                []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "("}},
+			PartialResponseDisabled: true,
+		}, srv)
+		testutil.NotOk(t, err)
+		testutil.Equals(t, 0, len(srv.Warnings), "expected error instead of warnings %v", srv.Warnings)
+	})
+
+	t.Run("label names", func(t *testing.T) {
+		resp, err := store.LabelNames(context.Background(), &storepb.LabelNamesRequest{})
+		testutil.Ok(t, err)
+
+		// Including external labels is up to the implementation.
+		names := map[string]struct{}{}
+		for _, n := range resp.Names {
+			names[n] = struct{}{}
+		}
+		for _, n := range []string{"__name__", "a", "b", "c"} {
+			_, ok := names[n]
+			testutil.Assert(t, ok, "label name %s missing in %v", n, resp.Names)
+		}
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		resp, err := store.LabelValues(context.Background(), &storepb.LabelValuesRequest{Label: "a"})
+		testutil.Ok(t, err)
+		testutil.Equals(t, []string{"1", "2", "3"}, resp.Values)
+
+		resp, err = store.LabelValues(context.Background(), &storepb.LabelValuesRequest{Label: "non-existing"})
+		testutil.Ok(t, err)
+		testutil.Equals(t, 0, len(resp.Values))
+
+		resp, err = store.LabelValues(context.Background(), &storepb.LabelValuesRequest{
+			Label:    "a",
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|3"}},
+		})
+		testutil.Ok(t, err)
+		testutil.Equals(t, []string{"1", "3"}, resp.Values)
+
+		if len(extLset) > 0 {
+			// Matchers on other labels are matched against external labels.
+			resp, err = store.LabelValues(context.Background(), &storepb.LabelValuesRequest{
+				Label:    "a",
+				Matchers: append(extMatchers(extLset, "-different"), storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}),
+			})
+			testutil.Ok(t, err)
+			testutil.Equals(t, 0, len(resp.Values))
+		}
+	})
+}
+
+func series(t *testing.T, store storepb.StoreServer, r *storepb.SeriesRequest) *seriesServer {
+	srv := newSeriesServer(context.Background())
+	testutil.Ok(t, store.Series(r, srv))
+	testutil.Equals(t, 0, len(srv.Warnings), "unexpected warnings %v", srv.Warnings)
+	return srv
+}
+
+// extMatchers returns equal matchers for all external labels with the given suffix added to their values.
+func extMatchers(extLset labels.Labels, suffix string) (ms []storepb.LabelMatcher) {
+	for _, l := range extLset {
+		ms = append(ms, storepb.LabelMatcher{Type: storepb.LabelMatcher_EQ, Name: l.Name, Value: l.Value + suffix})
+	}
+	return ms
+}
+
+// extend returns the label set with the external labels added. External labels overwrite existing ones.
+func extend(lset, extLset labels.Labels) labels.Labels {
+	m := lset.Map()
+	for _, l := range extLset {
+		m[l.Name] = l.Value
+	}
+	return labels.FromMap(m)
+}
+
+func labelsToProto(lset labels.Labels) []storepb.Label {
+	res := make([]storepb.Label, 0, len(lset))
+	for _, l := range lset {
+		res = append(res, storepb.Label{Name: l.Name, Value: l.Value})
+	}
+	return res
+}
+
+func nonNil(lset []storepb.Label) []storepb.Label {
+	if lset == nil {
+		return []storepb.Label{}
+	}
+	return lset
+}
+
+func seriesLabels(set []storepb.Series) [][]storepb.Label {
+	res := make([][]storepb.Label, 0, len(set))
+	for _, s := range set {
+		res = append(res, s.Labels)
+	}
+	return res
+}
+
+// seriesServer collects series and warnings sent by a StoreAPI Series call.
+type seriesServer struct {
+	// This field just exist to pseudo-implement the unused methods of the interface.
+	storepb.Store_SeriesServer
+
+	ctx context.Context
+
+	SeriesSet []storepb.Series
+	Warnings  []string
+}
+
+func newSeriesServer(ctx context.Context) *seriesServer {
+	return &seriesServer{ctx: ctx}
+}
+
+func (s *seriesServer) Send(r *storepb.SeriesResponse) error {
+	if r.GetWarning() != "" {
+		s.Warnings = append(s.Warnings, r.GetWarning())
+		return nil
+	}
+	if r.GetSeries() == nil {
+		return errors.New("no series")
+	}
+	// Implementations are allowed to reuse the response, so copy it.
+	series := *r.GetSeries()
+	series.Labels = append([]storepb.Label(nil), series.Labels...)
+	series.Chunks = append([]storepb.AggrChunk(nil), series.Chunks...)
+	s.SeriesSet = append(s.SeriesSet, series)
+	return nil
+}
+
+func (s *seriesServer) Context() context.Context {
+	return s.ctx
+}
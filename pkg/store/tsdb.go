@@ -218,6 +218,14 @@ func (s *TSDBStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest
 func (s *TSDBStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (
 	*storepb.LabelValuesResponse, error,
 ) {
+	match, matchers, err := labelValuesMatchers(r.Label, r.Matchers, s.externalLabels)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelValuesResponse{Values: []string{}}, nil
+	}
+
 	q, err := s.db.Querier(math.MinInt64, math.MaxInt64)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
@@ -228,5 +236,5 @@ func (s *TSDBStore) LabelValues(ctx context.Context, r *storepb.LabelValuesReque
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	return &storepb.LabelValuesResponse{Values: res}, nil
+	return &storepb.LabelValuesResponse{Values: filterValues(res, matchers)}, nil
 }
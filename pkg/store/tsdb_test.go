@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/store/storetesting"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -48,3 +49,16 @@ func TestTSDBStore_Series_SplitSamplesIntoChunksWithMaxSizeOfUint16_e2e(t *testi
 		return tsdbStore
 	})
 }
+
+func TestTSDBStore_Acceptance(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := testutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	extLset := labels.FromStrings("region", "eu-west")
+	storetesting.AcceptanceTest(t, db.Appender(), extLset, func() storepb.StoreServer {
+		return NewTSDBStore(nil, nil, db, component.Rule, extLset)
+	})
+}
@@ -0,0 +1,268 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// seriesCallQueryRe matches Series calls as logged to the active query log of the store gateway, e.g.
+// series{a="1"} start=1 end=2 max_resolution=0 request_id=foo.
+var seriesCallQueryRe = regexp.MustCompile(`^series(\{.*\})((?: [a-z_]+=\S*)*)$`)
+
+// ReadWarmUpQueries reads queries to warm up a store with. Both the active query log of Prometheus and Thanos
+// components, i.e. a JSON array of objects with a query field, and files of one query per line are read. Empty lines
+// and lines starting with # are ignored.
+func ReadWarmUpQueries(r io.Reader) ([]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read queries")
+	}
+	// Active query logs are memory mapped files padded with zeros, missing the closing bracket while in use.
+	b = bytes.TrimSpace(bytes.Trim(b, "\x00"))
+
+	var queries []string
+	if bytes.HasPrefix(b, []byte("[")) {
+		var entries []struct {
+			Query string `json:"query"`
+		}
+		if !bytes.HasSuffix(b, []byte("]")) {
+			b = append(bytes.TrimSuffix(b, []byte(",")), ']')
+		}
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, errors.Wrap(err, "decode query log")
+		}
+		for _, e := range entries {
+			if e.Query != "" {
+				queries = append(queries, e.Query)
+			}
+		}
+		return queries, nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(make([]byte, 0, 64*1024), len(b)+1)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	return queries, errors.Wrap(s.Err(), "read queries")
+}
+
+// WarmUpRequests returns the series requests selecting the data of the given queries. Queries are either Series calls
+// as logged by the store gateway, which select their recorded time range, or PromQL expressions, whose selectors
+// select the lookback duration before now. Duplicate requests are returned once.
+func WarmUpRequests(queries []string, now time.Time, lookback time.Duration) ([]storepb.SeriesRequest, error) {
+	var (
+		res  []storepb.SeriesRequest
+		seen = map[string]struct{}{}
+	)
+	add := func(r storepb.SeriesRequest) {
+		if _, ok := seen[r.String()]; ok {
+			return
+		}
+		seen[r.String()] = struct{}{}
+		res = append(res, r)
+	}
+	for _, q := range queries {
+		if m := seriesCallQueryRe.FindStringSubmatch(q); m != nil {
+			r, err := seriesCallRequest(m[1], m[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse series call %q", q)
+			}
+			add(r)
+			continue
+		}
+
+		expr, err := promql.ParseExpr(q)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse query %q", q)
+		}
+		var (
+			reqs []storepb.SeriesRequest
+			ierr error
+		)
+		promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+			var (
+				ms                []*labels.Matcher
+				offset, selection time.Duration
+			)
+			switch n := node.(type) {
+			case *promql.VectorSelector:
+				ms, offset = n.LabelMatchers, n.Offset
+			case *promql.MatrixSelector:
+				ms, offset, selection = n.LabelMatchers, n.Offset, n.Range
+			default:
+				return nil
+			}
+			matchers, err := storepbMatchers(ms)
+			if err != nil {
+				ierr = err
+				return err
+			}
+			reqs = append(reqs, storepb.SeriesRequest{
+				MinTime:    timestamp.FromTime(now.Add(-lookback - offset - selection)),
+				MaxTime:    timestamp.FromTime(now.Add(-offset)),
+				Matchers:   matchers,
+				Aggregates: []storepb.Aggr{storepb.Aggr_COUNT, storepb.Aggr_SUM},
+			})
+			return nil
+		})
+		if ierr != nil {
+			return nil, errors.Wrapf(ierr, "query %q", q)
+		}
+		if len(reqs) == 0 {
+			return nil, errors.Errorf("query %q selects no series", q)
+		}
+		for _, r := range reqs {
+			add(r)
+		}
+	}
+	return res, nil
+}
+
+// seriesCallRequest returns the series request of a logged Series call of the given selector and key=value fields.
+func seriesCallRequest(selector, fields string) (storepb.SeriesRequest, error) {
+	ms, err := promql.ParseMetricSelector(selector)
+	if err != nil {
+		return storepb.SeriesRequest{}, err
+	}
+	matchers, err := storepbMatchers(ms)
+	if err != nil {
+		return storepb.SeriesRequest{}, err
+	}
+	r := storepb.SeriesRequest{Matchers: matchers, Aggregates: []storepb.Aggr{storepb.Aggr_COUNT, storepb.Aggr_SUM}}
+	for _, f := range strings.Fields(fields) {
+		kv := strings.SplitN(f, "=", 2)
+		var dst *int64
+		switch kv[0] {
+		case "start":
+			dst = &r.MinTime
+		case "end":
+			dst = &r.MaxTime
+		case "max_resolution":
+			dst = &r.MaxResolutionWindow
+		default:
+			continue
+		}
+		if *dst, err = strconv.ParseInt(kv[1], 10, 64); err != nil {
+			return storepb.SeriesRequest{}, errors.Wrapf(err, "parse %s", kv[0])
+		}
+	}
+	return r, nil
+}
+
+func storepbMatchers(ms []*labels.Matcher) ([]storepb.LabelMatcher, error) {
+	res := make([]storepb.LabelMatcher, 0, len(ms))
+	for _, m := range ms {
+		var t storepb.LabelMatcher_Type
+		switch m.Type {
+		case labels.MatchEqual:
+			t = storepb.LabelMatcher_EQ
+		case labels.MatchNotEqual:
+			t = storepb.LabelMatcher_NEQ
+		case labels.MatchRegexp:
+			t = storepb.LabelMatcher_RE
+		case labels.MatchNotRegexp:
+			t = storepb.LabelMatcher_NRE
+		default:
+			return nil, errors.Errorf("unrecognized matcher type %d", m.Type)
+		}
+		res = append(res, storepb.LabelMatcher{Type: t, Name: m.Name, Value: m.Value})
+	}
+	return res, nil
+}
+
+// WarmUp runs the series requests against the store with the given concurrency and discards their responses, so the
+// caches of the store, e.g. the index cache of a BucketStore, are populated before it serves queries. Failed requests
+// are logged only, an error is returned if the context is done before all requests finished.
+func WarmUp(ctx context.Context, logger log.Logger, s storepb.StoreServer, reqs []storepb.SeriesRequest, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg             sync.WaitGroup
+		reqc           = make(chan storepb.SeriesRequest)
+		mtx            sync.Mutex
+		series, failed int
+		begin          = time.Now()
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for r := range reqc {
+				r := r
+				srv := &discardSeriesServer{ctx: ctx}
+				err := s.Series(&r, srv)
+				if err != nil && ctx.Err() == nil {
+					level.Debug(logger).Log("msg", "warm-up request failed", "matchers", matchersString(r.Matchers), "err", err)
+				}
+
+				mtx.Lock()
+				series += srv.series
+				if err != nil {
+					failed++
+				}
+				mtx.Unlock()
+			}
+		}()
+	}
+
+Loop:
+	for _, r := range reqs {
+		select {
+		case <-ctx.Done():
+			break Loop
+		case reqc <- r:
+		}
+	}
+	close(reqc)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "warm up")
+	}
+	level.Info(logger).Log("msg", "warm-up done", "requests", len(reqs), "failed", failed, "series", series, "duration", time.Since(begin))
+	return nil
+}
+
+// discardSeriesServer counts and discards the series sent by a Series call.
+type discardSeriesServer struct {
+	// This field just exist to pseudo-implement the unused methods of the interface.
+	storepb.Store_SeriesServer
+	ctx context.Context
+
+	series int
+}
+
+func (s *discardSeriesServer) Send(r *storepb.SeriesResponse) error {
+	if r.GetSeries() != nil {
+		s.series++
+	}
+	return nil
+}
+
+func (s *discardSeriesServer) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestReadWarmUpQueries(t *testing.T) {
+	for _, tcase := range []struct {
+		input    string
+		expected []string
+	}{
+		{
+			input:    "# Slow queries.\nup{job=\"a\"}\n\n  rate(foo[5m])  \n",
+			expected: []string{`up{job="a"}`, "rate(foo[5m])"},
+		},
+		{
+			input:    `[{"query":"up","timestamp_sec":1},{"query":"","timestamp_sec":2}]`,
+			expected: []string{"up"},
+		},
+		{
+			// Active query log in use, padded with zeros.
+			input:    `[{"query":"up","timestamp_sec":1},{"query":"foo","timestamp_sec":2},` + strings.Repeat("\x00", 10),
+			expected: []string{"up", "foo"},
+		},
+		{
+			input: "[\x00\x00",
+		},
+	} {
+		queries, err := ReadWarmUpQueries(strings.NewReader(tcase.input))
+		testutil.Ok(t, err)
+		testutil.Equals(t, tcase.expected, queries)
+	}
+
+	_, err := ReadWarmUpQueries(strings.NewReader(`[{"query":`))
+	testutil.NotOk(t, err)
+}
+
+func TestWarmUpRequests(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	aggrs := []storepb.Aggr{storepb.Aggr_COUNT, storepb.Aggr_SUM}
+
+	reqs, err := WarmUpRequests([]string{
+		`series{a="1",b=~"2|3"} start=10 end=20 max_resolution=300000 request_id=foo`,
+		`sum(rate(foo{a!="1"}[5m] offset 1h)) / up`,
+		"up",
+	}, now, time.Hour)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []storepb.SeriesRequest{
+		{
+			MinTime: 10, MaxTime: 20, MaxResolutionWindow: 300000, Aggregates: aggrs,
+			Matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"},
+				{Type: storepb.LabelMatcher_RE, Name: "b", Value: "2|3"},
+			},
+		},
+		{
+			MinTime: timestamp.FromTime(now.Add(-2*time.Hour - 5*time.Minute)), MaxTime: timestamp.FromTime(now.Add(-time.Hour)), Aggregates: aggrs,
+			Matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_NEQ, Name: "a", Value: "1"},
+				{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "foo"},
+			},
+		},
+		{
+			MinTime: timestamp.FromTime(now.Add(-time.Hour)), MaxTime: timestamp.FromTime(now), Aggregates: aggrs,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "__name__", Value: "up"}},
+		},
+	}, reqs)
+
+	for _, q := range []string{"sum(", "1 + 1", `series{a=} start=1`, `series{a="1"} start=foo`} {
+		_, err := WarmUpRequests([]string{q}, now, time.Hour)
+		testutil.NotOk(t, err)
+	}
+}
+
+// countingCache counts the items set in the index cache.
+type countingCache struct {
+	noopCache
+
+	mtx      sync.Mutex
+	postings int
+	series   int
+}
+
+func (c *countingCache) SetPostings(b ulid.ULID, l labels.Label, v []byte) {
+	c.mtx.Lock()
+	c.postings++
+	c.mtx.Unlock()
+}
+
+func (c *countingCache) SetSeries(b ulid.ULID, id uint64, v []byte) {
+	c.mtx.Lock()
+	c.series++
+	c.mtx.Unlock()
+}
+
+func TestWarmUp(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test_warmup")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	s := prepareStoreWithTestBlocks(t, dir, inmem.NewBucket(), false, 0, emptyRelabelConfig)
+	defer s.Close()
+
+	cache := &countingCache{}
+	s.cache.SwapWith(cache)
+
+	reqs, err := WarmUpRequests([]string{`{a="1"}`, `{a="2",b="1"}`}, time.Now(), time.Hour)
+	testutil.Ok(t, err)
+	for i := range reqs {
+		reqs[i].MinTime, reqs[i].MaxTime = s.minTime, s.maxTime
+	}
+	testutil.Ok(t, WarmUp(ctx, s.logger, s.store, reqs, 2))
+	testutil.Assert(t, cache.postings > 0, "no postings cached")
+	testutil.Assert(t, cache.series > 0, "no series cached")
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	testutil.NotOk(t, WarmUp(cctx, s.logger, s.store, reqs, 2))
+}
@@ -0,0 +1,46 @@
+// Package tenancy propagates tenants of query requests to StoreAPI servers, e.g. to receivers limiting series requests
+// per tenant. The tenant is given by an HTTP header of the query request and sent along with all gRPC requests made on
+// behalf of it as gRPC metadata, with the lower-cased header as the key.
+package tenancy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+type contextKey struct{}
+
+var tenantKey = contextKey{}
+
+// ContextWithTenant returns a new `context.Context` that holds the given tenant and sends it as gRPC metadata with the
+// lower-cased header as the key.
+func ContextWithTenant(ctx context.Context, header, tenant string) context.Context {
+	ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(header), tenant)
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// FromContext returns the tenant held by the context, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey).(string)
+	return tenant, ok && tenant != ""
+}
+
+// HTTPMiddleware returns an HTTP handler that injects the tenant given by the header of the request, if any, into the
+// request context. Empty header disables propagation of tenants.
+func HTTPMiddleware(header string, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tenant := r.Header.Get(header)
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithTenant(r.Context(), header, tenant)))
+	}
+}
@@ -0,0 +1,43 @@
+package tenancy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	var ctx context.Context
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+
+	for _, tcase := range []struct {
+		header string
+		tenant string
+		exp    string
+	}{
+		{header: "THANOS-TENANT", tenant: "", exp: ""},
+		{header: "THANOS-TENANT", tenant: "team-a", exp: "team-a"},
+		{header: "", tenant: "team-a", exp: ""},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("THANOS-TENANT", tcase.tenant)
+		HTTPMiddleware(tcase.header, next).ServeHTTP(httptest.NewRecorder(), r)
+
+		tenant, ok := FromContext(ctx)
+		testutil.Equals(t, tcase.exp != "", ok)
+		testutil.Equals(t, tcase.exp, tenant)
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if tcase.exp == "" {
+			testutil.Equals(t, 0, len(md.Get("thanos-tenant")))
+			continue
+		}
+		testutil.Equals(t, []string{tcase.exp}, md.Get("thanos-tenant"))
+	}
+}
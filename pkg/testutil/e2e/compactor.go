@@ -0,0 +1,60 @@
+package e2e
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// defaultCompactionLevels are the same compaction levels the compactor uses by default.
+var defaultCompactionLevels = []int64{
+	int64(1 * time.Hour / time.Millisecond),
+	int64(2 * time.Hour / time.Millisecond),
+	int64(8 * time.Hour / time.Millisecond),
+	int64(2 * 24 * time.Hour / time.Millisecond),
+	int64(14 * 24 * time.Hour / time.Millisecond),
+}
+
+// RunCompactor runs a single iteration of the compactor against the given bucket, using dir as the working
+// directory. It compacts all groups and, if any retention is given, applies retention by resolution afterwards.
+// Downsampling is not included.
+func RunCompactor(ctx context.Context, logger log.Logger, bkt objstore.Bucket, dir string, retentionByResolution map[compact.ResolutionLevel]time.Duration) error {
+	garbageCollectedBlocks := prometheus.NewCounter(prometheus.CounterOpts{})
+	sy, err := compact.NewSyncer(logger, nil, bkt, 0, 20, nil, nil, nil, nil, garbageCollectedBlocks, 0, 0, "", "")
+	if err != nil {
+		return errors.Wrap(err, "create syncer")
+	}
+	grouper := compact.NewDefaultGrouper(logger, bkt, false, false, nil, garbageCollectedBlocks, false)
+
+	comp, err := tsdb.NewLeveledCompactor(ctx, nil, logger, defaultCompactionLevels, downsample.NewPool())
+	if err != nil {
+		return errors.Wrap(err, "create compactor")
+	}
+
+	bc, err := compact.NewBucketCompactor(logger, sy, grouper, comp, dir, bkt, nil, compact.BucketCompactorOptions{
+		Concurrency:         1,
+		DownloadConcurrency: 1,
+		GroupOrder:          compact.GroupOrderBacklog,
+		Ranges:              defaultCompactionLevels,
+		GarbageCollect:      true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create bucket compactor")
+	}
+
+	if err := bc.Compact(ctx); err != nil {
+		return errors.Wrap(err, "compaction failed")
+	}
+
+	if len(retentionByResolution) == 0 {
+		return nil
+	}
+	return errors.Wrap(compact.ApplyRetentionPolicyByResolution(ctx, logger, bkt, retentionByResolution), "retention failed")
+}
@@ -0,0 +1,32 @@
+// Package e2e allows to start Thanos components in-process for integration testing.
+//
+// Components are started on random local ports and talk to each other the same way as when deployed
+// separately: store gateway and sidecar expose the StoreAPI over gRPC, querier connects to them and
+// exposes the Prometheus HTTP API. This allows to write integration tests without docker-compose or
+// prebuilt Thanos binaries.
+package e2e
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/objtesting"
+)
+
+// listenLocal returns a listener on a random free local port.
+func listenLocal() (net.Listener, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "listen on local address")
+	}
+	return l, nil
+}
+
+// NewMinioBucket starts Minio binary (see testutil.MinioBinary) storing data in the given directory and returns
+// a bucket client for a freshly created bucket in it.
+// The returned function empties the bucket and stops Minio.
+func NewMinioBucket(t testing.TB, dir string) (objstore.Bucket, func(), error) {
+	return objtesting.NewMinioBucket(t, dir)
+}
@@ -0,0 +1,78 @@
+package e2e
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/promclient"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func countBlocks(t *testing.T, bkt objstore.Bucket) (n int) {
+	testutil.Ok(t, bkt.Iter(context.Background(), "", func(name string) error {
+		if _, ok := block.IsBlockDir(name); ok {
+			n++
+		}
+		return nil
+	}))
+	return n
+}
+
+func TestStoreGatewayQuerierCompactor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "test-e2e-harness")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	logger := log.NewNopLogger()
+	bkt := inmem.NewBucket()
+
+	var (
+		series  = []labels.Labels{labels.FromStrings("__name__", "a", "b", "1"), labels.FromStrings("__name__", "a", "b", "2")}
+		extLset = labels.FromStrings("ext", "1")
+		hour    = int64(time.Hour / time.Millisecond)
+	)
+	// Create three 1h blocks. The first two are compacted into a single 2h block, the newest one is left as it is.
+	for i := int64(0); i < 3; i++ {
+		id, err := testutil.CreateBlock(ctx, dir, series, 100, i*hour, (i+1)*hour, extLset, 0)
+		testutil.Ok(t, err)
+		testutil.Ok(t, block.Upload(ctx, logger, bkt, filepath.Join(dir, id.String())))
+	}
+	testutil.Equals(t, 3, countBlocks(t, bkt))
+
+	testutil.Ok(t, RunCompactor(ctx, logger, bkt, filepath.Join(dir, "compact"), nil))
+	testutil.Equals(t, 2, countBlocks(t, bkt))
+
+	s, err := StartStoreGateway(ctx, logger, bkt, filepath.Join(dir, "store"))
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, s.Stop()) }()
+
+	q, err := StartQuerier(logger, []string{s.GRPCAddr()}, nil)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, q.Stop()) }()
+
+	u, err := url.Parse(q.URL())
+	testutil.Ok(t, err)
+
+	res, warnings, err := promclient.QueryInstant(ctx, logger, u, "count_over_time(a[4h])", timestamp.Time(3*hour), promclient.QueryOptions{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(warnings), "got %v", warnings)
+	testutil.Equals(t, 2, len(res))
+	for _, r := range res {
+		testutil.Equals(t, "1", string(r.Metric["ext"]))
+		testutil.Equals(t, 300, int(r.Value))
+	}
+}
@@ -0,0 +1,124 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/component"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/query"
+	v1 "github.com/thanos-io/thanos/pkg/query/api"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store"
+	"google.golang.org/grpc"
+)
+
+// Querier is a running in-process querier.
+type Querier struct {
+	*StoreAPI
+
+	httpAddr string
+	httpSrv  *http.Server
+	stores   *query.StoreSet
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartQuerier starts querier which queries the StoreAPIs available under the given gRPC addresses and
+// deduplicates series by the given replica labels. Querier exposes the Prometheus HTTP API under HTTPAddr
+// and the StoreAPI under GRPCAddr.
+func StartQuerier(logger log.Logger, storeAddrs []string, replicaLabels []string) (*Querier, error) {
+	stores := query.NewStoreSet(
+		logger,
+		nil,
+		func() (specs []query.StoreSpec) {
+			for _, addr := range storeAddrs {
+				specs = append(specs, query.NewGRPCStoreSpec(addr))
+			}
+			return specs
+		},
+		[]grpc.DialOption{grpc.WithInsecure()},
+		5*time.Minute,
+	)
+
+	var (
+		proxy  = store.NewProxyStore(logger, stores.Get, component.Query, nil, 0)
+		engine = promql.NewEngine(promql.EngineOpts{
+			Logger:        logger,
+			MaxConcurrent: 20,
+			MaxSamples:    math.MaxInt32,
+			Timeout:       2 * time.Minute,
+		})
+		api = v1.NewAPI(logger, nil, engine, query.NewQueryableCreator(logger, proxy, false), false, true, replicaLabels, 0, "", nil, nil, 0, "")
+	)
+
+	l, err := listenLocal()
+	if err != nil {
+		return nil, err
+	}
+	router := route.New()
+	api.Register(router.WithPrefix("/api/v1"), opentracing.NoopTracer{}, logger, extpromhttp.NewNopInstrumentationMiddleware())
+
+	grpcSrv, err := startStoreAPI(proxy)
+	if err != nil {
+		runutil.CloseWithLogOnErr(logger, l, "querier HTTP listener")
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Querier{
+		StoreAPI: grpcSrv,
+		httpAddr: l.Addr().String(),
+		httpSrv:  &http.Server{Handler: router},
+		stores:   stores,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	// Discover stores before the first query is made.
+	stores.Update(ctx)
+
+	go func() {
+		defer close(q.done)
+		_ = runutil.Repeat(time.Second, ctx.Done(), func() error {
+			stores.Update(ctx)
+			return nil
+		})
+	}()
+	go func() {
+		_ = q.httpSrv.Serve(l)
+	}()
+	return q, nil
+}
+
+// HTTPAddr returns the address the Prometheus HTTP API listens on.
+func (q *Querier) HTTPAddr() string {
+	return q.httpAddr
+}
+
+// URL returns the base URL of the Prometheus HTTP API.
+func (q *Querier) URL() string {
+	return fmt.Sprintf("http://%s", q.httpAddr)
+}
+
+// UpdateStores refreshes the set of StoreAPIs the querier is connected to.
+func (q *Querier) UpdateStores(ctx context.Context) {
+	q.stores.Update(ctx)
+}
+
+// Stop stops the querier.
+func (q *Querier) Stop() error {
+	q.cancel()
+	<-q.done
+	q.stores.Close()
+	q.StoreAPI.Stop()
+	return q.httpSrv.Close()
+}
@@ -0,0 +1,145 @@
+package e2e
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store"
+	storecache "github.com/thanos-io/thanos/pkg/store/cache"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc"
+)
+
+// StoreAPI is a running in-process gRPC StoreAPI server.
+type StoreAPI struct {
+	grpcAddr string
+	srv      *grpc.Server
+	done     chan struct{}
+}
+
+func startStoreAPI(srv storepb.StoreServer) (*StoreAPI, error) {
+	l, err := listenLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &StoreAPI{
+		grpcAddr: l.Addr().String(),
+		srv:      grpc.NewServer(grpc.MaxSendMsgSize(math.MaxInt32)),
+		done:     make(chan struct{}),
+	}
+	storepb.RegisterStoreServer(s.srv, srv)
+
+	go func() {
+		defer close(s.done)
+		_ = s.srv.Serve(l)
+	}()
+	return s, nil
+}
+
+// GRPCAddr returns the address the StoreAPI listens on.
+func (s *StoreAPI) GRPCAddr() string {
+	return s.grpcAddr
+}
+
+// Stop stops the gRPC server and waits until it is done.
+func (s *StoreAPI) Stop() {
+	s.srv.Stop()
+	<-s.done
+}
+
+// StoreGateway is a running in-process store gateway.
+type StoreGateway struct {
+	*StoreAPI
+
+	bs *store.BucketStore
+}
+
+// StartStoreGateway starts store gateway serving all blocks from the given bucket. Blocks are cached in dir.
+// Blocks uploaded after the start are visible only after calling Sync.
+func StartStoreGateway(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string) (*StoreGateway, error) {
+	indexCache, err := storecache.NewIndexCache(logger, nil, storecache.Opts{
+		MaxSizeBytes:     250 * 1024 * 1024,
+		MaxItemSizeBytes: 125 * 1024 * 1024,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create index cache")
+	}
+
+	minTime, maxTime := time.Unix(0, 0), time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+	bs, err := store.NewBucketStore(
+		logger,
+		nil,
+		bkt,
+		dir,
+		indexCache,
+		2*1024*1024*1024,
+		0,
+		20,
+		false,
+		20,
+		512*1024,
+		0,
+		&store.FilterConfig{
+			MinTime: model.TimeOrDurationValue{Time: &minTime},
+			MaxTime: model.TimeOrDurationValue{Time: &maxTime},
+		},
+		nil,
+		false,
+		"",
+		0,
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create bucket store")
+	}
+
+	if err := bs.InitialSync(ctx); err != nil {
+		runutil.CloseWithLogOnErr(logger, bs, "bucket store")
+		return nil, errors.Wrap(err, "initial sync")
+	}
+
+	s, err := startStoreAPI(bs)
+	if err != nil {
+		runutil.CloseWithLogOnErr(logger, bs, "bucket store")
+		return nil, err
+	}
+	return &StoreGateway{StoreAPI: s, bs: bs}, nil
+}
+
+// Sync loads blocks which appeared in the bucket and drops removed ones.
+func (s *StoreGateway) Sync(ctx context.Context) error {
+	return s.bs.SyncBlocks(ctx)
+}
+
+// Stop stops the store gateway and releases its blocks.
+func (s *StoreGateway) Stop() error {
+	s.StoreAPI.Stop()
+	return s.bs.Close()
+}
+
+// StartSidecar starts the StoreAPI part of sidecar exposing data of Prometheus available under the given URL.
+// Block upload is not included.
+func StartSidecar(logger log.Logger, promURL *url.URL, externalLabels labels.Labels) (*StoreAPI, error) {
+	ps, err := store.NewPrometheusStore(
+		logger,
+		nil,
+		promURL,
+		component.Sidecar,
+		func() labels.Labels { return externalLabels },
+		func() (int64, int64) { return math.MinInt64, math.MaxInt64 },
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create Prometheus store")
+	}
+	return startStoreAPI(ps)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/block/writer"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"golang.org/x/sync/errgroup"
 )
@@ -308,11 +309,15 @@ func createBlock(
 	resolution int64,
 	tombstones bool,
 ) (id ulid.ULID, err error) {
-	h, err := tsdb.NewHead(nil, nil, nil, 10000000000)
+	w, err := writer.New(log.NewNopLogger(), dir, metadata.Thanos{
+		Labels:     extLset.Map(),
+		Downsample: metadata.ThanosDownsample{Resolution: resolution},
+		Source:     metadata.TestSource,
+	})
 	if err != nil {
-		return id, errors.Wrap(err, "create head block")
+		return id, errors.Wrap(err, "create block writer")
 	}
-	defer runutil.CloseWithErrCapture(&err, h, "TSDB Head")
+	defer runutil.CloseWithErrCapture(&err, w, "block writer")
 
 	var g errgroup.Group
 	var timeStepSize = (maxt - mint) / int64(numSamples+1)
@@ -330,7 +335,7 @@ func createBlock(
 			t := mint
 
 			for i := 0; i < numSamples; i++ {
-				app := h.Appender()
+				app := w.Appender()
 
 				for _, lset := range batch {
 					_, err := app.Add(lset, t, rand.Float64())
@@ -353,22 +358,10 @@ func createBlock(
 	if err := g.Wait(); err != nil {
 		return id, err
 	}
-	c, err := tsdb.NewLeveledCompactor(ctx, nil, log.NewNopLogger(), []int64{maxt - mint}, nil)
-	if err != nil {
-		return id, errors.Wrap(err, "create compactor")
-	}
 
-	id, err = c.Write(dir, h, mint, maxt, nil)
+	id, err = w.Flush(ctx, mint, maxt)
 	if err != nil {
-		return id, errors.Wrap(err, "write block")
-	}
-
-	if _, err = metadata.InjectThanos(log.NewNopLogger(), filepath.Join(dir, id.String()), metadata.Thanos{
-		Labels:     extLset.Map(),
-		Downsample: metadata.ThanosDownsample{Resolution: resolution},
-		Source:     metadata.TestSource,
-	}, nil); err != nil {
-		return id, errors.Wrap(err, "finalize block")
+		return id, err
 	}
 
 	if !tombstones {
@@ -0,0 +1,126 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// NewServerConfig returns the TLS configuration of a server identified by the given certificate and key, verifying
+// client certificates against the client CA if given. It returns nil if neither certificate nor key are given, so
+// the server does not use TLS.
+func NewServerConfig(logger log.Logger, cert, key, clientCA string, opts Options) (*tls.Config, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	if key == "" && cert == "" {
+		if clientCA != "" {
+			return nil, errors.New("when a client CA is used a server key and certificate must also be provided")
+		}
+
+		level.Info(logger).Log("msg", "disabled TLS, key and cert must be set to enable")
+		return nil, nil
+	}
+
+	if key == "" || cert == "" {
+		return nil, errors.New("both server key and certificate must be provided")
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if err := opts.Apply(tlsCfg); err != nil {
+		return nil, errors.Wrap(err, "server TLS options")
+	}
+
+	tlsCert, err := tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "server credentials")
+	}
+
+	level.Info(logger).Log("msg", "enabled server side TLS")
+
+	tlsCfg.Certificates = []tls.Certificate{tlsCert}
+
+	if clientCA != "" {
+		caPEM, err := ioutil.ReadFile(clientCA)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading client CA")
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("building client CA")
+		}
+		tlsCfg.ClientCAs = certPool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+
+		level.Info(logger).Log("msg", "server TLS client verification enabled")
+	}
+	return tlsCfg, nil
+}
+
+// NewClientConfig returns the TLS configuration of a client verifying servers against the given CA, or the system
+// certificate pool if empty, and identified by the given certificate and key if given.
+func NewClientConfig(logger log.Logger, cert, key, caCert, serverName string, opts Options) (*tls.Config, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	var certPool *x509.CertPool
+
+	if caCert != "" {
+		caPEM, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading client CA")
+		}
+
+		certPool = x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("building client CA")
+		}
+		level.Info(logger).Log("msg", "TLS Client using provided certificate pool")
+	} else {
+		var err error
+		certPool, err = x509.SystemCertPool()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading system certificate pool")
+		}
+		level.Info(logger).Log("msg", "TLS Client using system certificate pool")
+	}
+
+	tlsCfg := &tls.Config{
+		RootCAs: certPool,
+	}
+
+	if serverName != "" {
+		tlsCfg.ServerName = serverName
+	}
+	if err := opts.Apply(tlsCfg); err != nil {
+		return nil, errors.Wrap(err, "client TLS options")
+	}
+
+	if cert != "" {
+		cert, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "client credentials")
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+		level.Info(logger).Log("msg", "TLS Client authentication enabled")
+	}
+	return tlsCfg, nil
+}
+
+// NewTransport returns an HTTP transport with the settings of http.DefaultTransport using the TLS configuration for
+// https URLs.
+func NewTransport(tlsCfg *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsCfg
+	return t
+}
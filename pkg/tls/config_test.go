@@ -0,0 +1,64 @@
+package tls
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewTransport(t *testing.T) {
+	if FIPS {
+		t.Skip("TLS 1.3 is not allowed in FIPS mode")
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "test-tls-transport")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ca := filepath.Join(dir, "ca.pem")
+	testutil.Ok(t, ioutil.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0666))
+
+	for _, tcase := range []struct {
+		minVersion string
+		ok         bool
+	}{
+		{minVersion: "1.2", ok: true},
+		{minVersion: "1.3", ok: false},
+	} {
+		t.Run(tcase.minVersion, func(t *testing.T) {
+			cfg, err := NewClientConfig(nil, "", "", ca, "", Options{MinVersion: tcase.minVersion})
+			testutil.Ok(t, err)
+
+			resp, err := (&http.Client{Transport: NewTransport(cfg)}).Get(srv.URL)
+			if !tcase.ok {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Ok(t, resp.Body.Close())
+		})
+	}
+}
+
+func TestNewServerConfig(t *testing.T) {
+	cfg, err := NewServerConfig(nil, "", "", "", Options{})
+	testutil.Ok(t, err)
+	testutil.Assert(t, cfg == nil, "TLS is disabled without certificate and key")
+
+	_, err = NewServerConfig(nil, "", "", "ca.pem", Options{})
+	testutil.NotOk(t, err)
+	_, err = NewServerConfig(nil, "cert.pem", "", "", Options{})
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,8 @@
+//go:build !fips
+// +build !fips
+
+package tls
+
+// FIPS is true if Thanos is built with the fips build tag, restricting TLS to FIPS-approved protocol versions and
+// cipher suites.
+const FIPS = false
@@ -0,0 +1,137 @@
+// Package tls restricts the TLS protocol versions and cipher suites of gRPC and HTTP servers and clients.
+package tls
+
+import (
+	"crypto/tls"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// cipherSuites are the cipher suites of TLS 1.2 and below supported by crypto/tls, by name.
+var cipherSuites = map[string]uint16{
+	"TLS_RSA_WITH_RC4_128_SHA":                tls.TLS_RSA_WITH_RC4_128_SHA,
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA":           tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA256":         tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA":        tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA":          tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA":     tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":  tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+}
+
+// fipsCipherSuites are the cipher suites approved by FIPS 140-2 for TLS 1.2, in order of preference.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsCurves are the elliptic curves approved by FIPS 140-2.
+var fipsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// Options are the TLS protocol versions and cipher suites allowed for connections.
+type Options struct {
+	// MinVersion is the minimum TLS version, one of 1.0, 1.1, 1.2 and 1.3. Empty keeps the minimum version of the
+	// configuration.
+	MinVersion string
+	// CipherSuites are the names of cipher suites allowed for TLS 1.2 and below, as named by crypto/tls. Empty allows
+	// the default cipher suites of Go, or all FIPS-approved ones in FIPS mode.
+	CipherSuites []string
+}
+
+// CipherSuiteNames returns the names of all supported cipher suites, sorted.
+func CipherSuiteNames() []string {
+	names := make([]string, 0, len(cipherSuites))
+	for name := range cipherSuites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply restricts the configuration to the protocol versions and cipher suites of the options. If Thanos is built
+// in FIPS mode, it also restricts the configuration to TLS 1.2 with FIPS-approved cipher suites and curves and fails
+// for options allowing anything else.
+func (o Options) Apply(cfg *tls.Config) error {
+	return o.apply(cfg, FIPS)
+}
+
+func (o Options) apply(cfg *tls.Config, fips bool) error {
+	if o.MinVersion != "" {
+		v, ok := versions[o.MinVersion]
+		if !ok {
+			return errors.Errorf("unknown TLS version %q", o.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+
+	var suites []uint16
+	for _, name := range o.CipherSuites {
+		id, ok := cipherSuites[name]
+		if !ok {
+			return errors.Errorf("unknown cipher suite %q", name)
+		}
+		if fips && !containsSuite(fipsCipherSuites, id) {
+			return errors.Errorf("cipher suite %s is not FIPS-approved", name)
+		}
+		suites = append(suites, id)
+	}
+	if len(suites) > 0 {
+		cfg.CipherSuites = suites
+	}
+	if !fips {
+		return nil
+	}
+
+	if cfg.MinVersion < tls.VersionTLS12 {
+		if o.MinVersion != "" {
+			return errors.Errorf("TLS version %s is not FIPS-approved, use 1.2", o.MinVersion)
+		}
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if cfg.MinVersion > tls.VersionTLS12 {
+		return errors.New("TLS 1.3 cannot be restricted to FIPS-approved cipher suites, use 1.2")
+	}
+	// Cipher suites of TLS 1.3 are not configurable in Go, so it is disabled.
+	cfg.MaxVersion = tls.VersionTLS12
+	if len(cfg.CipherSuites) == 0 {
+		cfg.CipherSuites = fipsCipherSuites
+	}
+	cfg.CurvePreferences = fipsCurves
+	cfg.PreferServerCipherSuites = true
+	return nil
+}
+
+func containsSuite(suites []uint16, id uint16) bool {
+	for _, s := range suites {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package tls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestOptions_Apply(t *testing.T) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	testutil.Ok(t, Options{}.apply(cfg, false))
+	testutil.Equals(t, &tls.Config{MinVersion: tls.VersionTLS12}, cfg)
+
+	cfg = &tls.Config{}
+	testutil.Ok(t, Options{MinVersion: "1.1", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305"}}.apply(cfg, false))
+	testutil.Equals(t, uint16(tls.VersionTLS11), cfg.MinVersion)
+	testutil.Equals(t, []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305}, cfg.CipherSuites)
+
+	testutil.NotOk(t, Options{MinVersion: "2.0"}.apply(&tls.Config{}, false))
+	testutil.NotOk(t, Options{CipherSuites: []string{"TLS_UNKNOWN"}}.apply(&tls.Config{}, false))
+
+	cfg = &tls.Config{}
+	testutil.Ok(t, Options{}.apply(cfg, true))
+	testutil.Equals(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	testutil.Equals(t, uint16(tls.VersionTLS12), cfg.MaxVersion)
+	testutil.Equals(t, fipsCipherSuites, cfg.CipherSuites)
+	testutil.Equals(t, fipsCurves, cfg.CurvePreferences)
+
+	cfg = &tls.Config{}
+	testutil.Ok(t, Options{MinVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}}.apply(cfg, true))
+	testutil.Equals(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}, cfg.CipherSuites)
+
+	testutil.NotOk(t, Options{MinVersion: "1.1"}.apply(&tls.Config{}, true))
+	testutil.NotOk(t, Options{MinVersion: "1.3"}.apply(&tls.Config{}, true))
+	testutil.NotOk(t, Options{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305"}}.apply(&tls.Config{}, true))
+}
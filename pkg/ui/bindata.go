@@ -185,7 +185,7 @@ func pkgUiTemplatesAlertsHtml() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiTemplatesBucketHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x54\x4d\x6f\xdb\x3c\x0c\xbe\xe7\x57\xf0\xd5\x8b\x01\x1b\x50\x47\xe9\xfa\x81\x2e\x8d\x33\xec\xeb\x56\xa0\xc3\x56\xec\x32\xec\x20\x5b\x4c\xa4\x54\x96\x3c\x89\xb1\x13\x18\xfe\xef\x83\x2d\x3b\x69\xba\x62\x39\x28\xa4\x48\x3e\xe4\x43\x52\x6e\x1a\x89\x2b\x6d\x11\x98\x42\x21\x59\xdb\x4e\x00\x00\x16\x05\x92\x00\x45\x54\x26\xf8\x7b\xab\xab\x94\x79\x5c\x79\x0c\x8a\x41\xee\x2c\xa1\xa5\x94\x5d\xcc\x66\x8c\x2f\x27\xd1\x3f\xe4\x5e\x97\x04\xb4\x2f\x31\x65\x84\x3b\xe2\x1b\x51\x89\x78\xcb\x20\xf8\x3c\x65\x1d\x5a\x98\x73\x5e\xd7\xf5\x74\x1d\x48\x90\xce\xa7\xb9\x2b\x78\xae\x84\xa7\xc0\x8d\x13\x12\xfd\x74\x13\xd8\x72\xc1\x63\xe0\xf2\x04\xbb\x07\x69\x1a\x28\x05\xa9\xaf\x1e\x57\x7a\x07\x6d\xcb\x23\x10\xdf\x04\x9e\x6d\xf3\x47\xa4\xe9\x26\xbc\xaf\xd2\xa6\x81\x6c\xab\x8d\xfc\x81\x3e\x68\x67\xa1\x6d\x9f\xa2\x36\x0d\x5a\xd9\xb6\x93\xc9\x91\xfc\xc0\xea\xc0\xff\x9f\x7c\x62\x5d\x50\x09\x0f\xa4\x84\x75\x01\x52\x68\xe2\x5d\xf7\x33\x22\x43\x33\x87\xa6\x99\xde\x75\x52\xdb\x9e\x1d\x6d\xe8\x7d\x6f\xf9\xe2\xfd\xc9\xfd\xd0\x5e\x94\x1f\xa8\xb7\x7f\x3b\xea\x27\x7e\x99\x71\xf9\x63\xe8\x5d\x3e\xf6\xe2\x50\x30\xb4\xb7\xb1\xf0\x03\xc9\xa8\x4a\x5d\x81\x96\x29\x43\xef\x19\xe4\x46\x84\x90\xf6\x5c\x85\xb6\xe8\x19\x04\xda\x1b\x4c\x99\xd4\xa1\x34\x62\x3f\x07\xeb\x2c\xde\x8e\xfc\xc6\xf8\x21\x4c\x18\xf4\x04\xfd\x99\xd4\xc2\x5b\x6d\xd7\x0c\xbc\xeb\xe2\xfb\xcb\xae\xc3\x52\x57\xc3\xd0\xa2\x38\x79\x0e\x72\xc8\x9d\xac\xcc\x56\x4b\xd6\x17\xf7\xc9\x15\xa5\xc8\x49\x3b\x1b\x0e\x25\x29\xd4\x6b\x45\x73\x38\x9f\xcd\x5e\xdd\x42\xad\x25\xa9\x41\x39\xe4\x89\xe0\xff\x25\x49\x2c\xf7\xe1\xfe\xf3\xfd\xeb\x8d\x08\x88\x85\xc8\xb4\x7c\x33\x87\x07\x85\x60\x70\x8d\x56\x82\x0e\xe0\xac\xd9\x83\x80\x50\x08\x63\x80\xb0\x28\x9d\x17\x7e\x0f\xb5\xf3\x8f\xc2\xbb\xad\x95\x40\xda\x18\xa8\x11\x94\xa8\x10\x32\x24\x42\x0f\xb5\xd8\x07\x70\x2b\x08\xca\xd5\xda\xae\x81\x3a\xcc\x6e\xac\x61\x1a\xd3\x7e\x47\x84\x71\xb5\xd7\x9a\xd4\x36\xeb\xb7\x3a\x2e\x46\xa2\xdd\x20\x71\x1d\xc2\x16\x03\x3f\x7f\x7b\x79\xfd\x7f\x2f\xe7\xae\x28\xd0\x52\x72\x35\xbb\xbe\xbe\x39\xbf\x78\x77\x03\x2b\xe7\xe3\xf3\xda\x51\x04\x4f\x92\xe7\x73\x8c\x74\xfe\x1e\xe5\xd8\xce\x17\x07\x0a\xa5\x90\x52\xdb\x75\x42\xae\x9c\xc3\xd5\xac\xdc\x9d\xcc\x58\x5d\x2e\xe1\x2e\xb6\x69\xc1\xd5\xe5\x13\x0b\x89\xcc\xe0\x98\x2b\x2a\xfd\x99\x04\xf2\xba\x44\x39\x68\xca\x55\xe8\x47\x4b\xf1\x04\x3a\x82\x64\x4e\xee\x97\x0b\x1e\xff\x8f\xe0\xbc\x0f\x78\x61\x5d\x7a\x0e\x47\xc7\xae\x1d\x3f\xbb\x23\x11\x36\x57\xce\xa7\x81\x84\xa7\x5f\xd0\x9c\x90\x6c\x0f\xfe\x8a\x0a\x73\xd6\xe5\x82\xe6\x64\x89\xda\xf1\x81\x44\xf8\xf1\x23\xf0\x27\x00\x00\xff\xff\x6b\xa9\x29\xb6\x01\x05\x00\x00")
+var _pkgUiTemplatesBucketHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x54\x4d\x6f\xdb\x30\x0c\xbd\xf7\x57\x70\x1e\x06\x6c\x40\x1d\xa7\x6b\x1b\x74\x69\x9d\x61\x5f\xb7\x02\x1d\xd6\x62\x97\x61\x07\xd9\x62\x22\xa5\xb2\xe5\x49\x4c\x9c\xc0\xf0\x7f\x1f\x6d\x39\x49\x53\x74\xf3\x41\x21\x45\xf2\x91\x8f\xa4\xd2\x34\x12\xe7\xba\x44\x88\x14\x0a\x19\xb5\xed\x09\xf0\x77\x53\x20\x09\x50\x44\x55\x8c\x7f\x56\x7a\x9d\x46\x0e\xe7\x0e\xbd\x8a\x20\xb7\x25\x61\x49\x69\x74\x3e\x1e\x47\xc9\xec\x24\xf8\xfb\xdc\xe9\x8a\x80\xb6\x15\xa6\x11\xe1\x86\x92\xa5\x58\x8b\x70\x1b\x81\x77\x79\x1a\x75\x68\x7e\x9a\x24\x75\x5d\x8f\x16\x9e\x04\xe9\x7c\x94\xdb\x22\xc9\x95\x70\xe4\x13\x63\x85\x44\x37\x5a\xfa\x68\x76\x93\x84\xc0\xd9\x11\x76\x0f\xd2\x34\x50\x09\x52\xdf\xb9\x1c\xbd\x81\xb6\x4d\x02\x50\xb2\xf4\x49\xb6\xca\x1f\x91\x18\xe0\xe3\x3a\x65\xb7\x6c\xa5\x8d\xfc\x89\xce\x6b\x5b\xb2\xe3\x53\xd4\xa6\xc1\x52\x32\x53\x16\x76\xe4\x07\x56\x7b\xfe\xff\xe5\x13\xea\x82\xb5\x70\x40\x4a\x94\xd6\x43\x0a\x4d\xb8\xeb\x3e\x23\x32\x34\x53\x68\x9a\xd1\x6d\x27\xb5\xed\xe9\xc1\x86\xce\xf5\x96\x6f\xce\x1d\xdd\x0f\xed\x45\xf9\x89\x7a\xfb\x8f\x83\x7e\xe4\x97\x19\x9b\x3f\xfa\xde\xe5\x73\x2f\x1e\x59\xbb\x66\x60\xb0\xde\xf7\xe2\x40\x07\xda\xeb\x40\x6b\xdf\x82\xa0\x4a\xbd\x06\x2d\xd3\x88\xab\xe2\xc1\x1a\xe1\x7d\xda\x77\x42\x70\x4f\xf8\xc6\xd3\xd6\x30\x7d\xa9\x7d\x65\xc4\x76\x0a\xa5\x2d\xf1\x7a\xc7\x7e\x17\x3f\x84\x09\x83\x8e\xa0\x3f\xe3\x5a\xb8\x52\x97\x8b\x08\x9c\xed\xe2\xfb\xcb\xae\xff\xec\x3e\x8c\x34\x88\x27\xcf\x41\xf6\xb9\xe3\xb9\x59\x69\x19\xf5\xc5\x7d\xb1\x45\x25\x72\xe2\x31\xfa\x7d\x49\x0a\xf5\x42\x71\xa7\xce\xc6\xe3\x37\xd7\x50\x6b\x49\x6a\x50\xf6\x79\x02\xf8\xab\x38\x0e\xe5\x3e\xdc\x7d\xbd\x7b\xbb\x14\x1e\xb1\x10\x99\x96\xef\xa6\xf0\xa0\x10\x0c\x2e\x78\x15\x40\x7b\xb0\xa5\xd9\x82\x00\x5f\x08\x63\x80\xb0\xa8\xac\x13\x6e\x0b\xb5\x75\x8f\xc2\xd9\x15\x3b\x91\x66\x4b\x8d\xa0\xc4\x1a\x21\x43\x22\x74\x50\x8b\x2d\x87\xce\xc1\x2b\x5b\x33\x63\x5e\x07\x0c\xe3\xf7\xa3\x90\xf6\x1e\x11\x76\x8b\xbf\xd0\xa4\x56\x59\xbf\xf3\x61\x6d\x62\x6d\x07\x29\xd1\xde\xaf\xd0\x27\x67\xef\x2f\x26\xaf\x7b\x99\xbd\x0a\x5e\xc8\xf8\x72\x3c\x99\x5c\x9d\x9d\x7f\xb8\x82\xb9\x75\xe1\xf1\x6d\x28\x80\xc7\xf1\xf3\x39\x06\x3a\xd1\x3f\xdb\xf9\xe2\x40\xf9\x41\x49\xc9\xc5\xc7\x64\xab\x29\x5c\x8e\xab\xcd\xd1\x8c\xd5\xc5\x0c\x6e\x43\x9b\x6e\x12\x56\x0e\x16\x12\x99\xc1\x5d\xae\xa0\xf4\x67\xec\x89\x97\x0c\xe5\xa0\x29\xbb\xe6\x4e\x0d\x96\xe2\x09\x74\x00\xc9\xac\xdc\xf2\xcc\xc2\xef\x01\x3c\xe9\x03\x5e\x58\x97\x9e\xc3\xc1\xb1\x6b\xc7\xaf\xee\x88\x45\x99\x2b\xeb\x52\x7e\x03\x8e\x7e\x43\x73\x44\xb2\xdd\xfb\x2b\x2a\xcc\x69\x97\x0b\x9a\xa3\x25\x6a\x77\x0f\x24\xc0\xef\xfe\x22\xfe\x02\x83\x86\x53\x3a\x1f\x05\x00\x00")
 
 func pkgUiTemplatesBucketHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -200,7 +200,7 @@ func pkgUiTemplatesBucketHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/templates/bucket.html", size: 1281, mode: os.FileMode(420), modTime: time.Unix(1565577418, 0)}
+	info := bindataFileInfo{name: "pkg/ui/templates/bucket.html", size: 1311, mode: os.FileMode(420), modTime: time.Unix(1791980151, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -245,7 +245,7 @@ func pkgUiTemplatesGraphHtml() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiTemplatesQuery_menuHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x54\xc1\x8e\xd4\x30\x0c\x3d\xb3\x5f\x61\x65\x25\x6e\xa5\x77\x68\x7b\xe0\x02\xdc\x10\xbb\x77\xe4\x4e\xdc\x36\x9a\x34\x89\x12\x67\x18\x54\xf5\xdf\x51\x3a\x33\x25\xed\xec\x48\x1c\x38\xa5\xb1\x9c\xe7\xf7\xec\xe7\x4e\x93\xa4\x4e\x19\x02\x61\xf0\x24\xe6\xf9\x09\x00\xa0\x32\x78\x82\x83\xc6\x10\xea\x14\x6e\xd1\x43\xa7\xce\x24\x0b\xb6\x0e\x2e\x81\x82\xce\x0e\x8d\x2c\xc2\x78\x0b\x48\xf4\x47\x68\xfb\xe5\x14\xcd\x82\x03\x50\x49\xb5\x22\x1d\xac\x61\x54\x86\x7c\xd1\xe9\xa8\xe4\x9a\x03\x50\xb5\x91\xd9\x1a\xe0\xdf\x8e\x6a\x71\xb9\x88\x2d\x81\x82\x6d\xdf\x6b\xf2\x02\x24\x32\x5e\x6f\x09\x53\x6b\x74\x81\x6e\x61\xf4\x3d\x71\x2d\x9e\x0d\x9e\x8a\x54\x8f\x0c\x0b\x40\xaf\xf0\xca\x97\x64\x2d\x3a\xd4\xe9\xc1\x12\x4d\x39\xde\xea\x4b\x99\xdd\x0b\x8d\x2d\xe9\x5a\xbc\x2e\xa5\x92\x4a\xd5\x23\x2b\x6b\x32\xe2\x00\x55\x70\x68\xde\xa6\x5a\xa8\x43\x4a\xae\xca\x94\x92\x89\x2d\x2f\x02\xb3\x08\xee\x00\x5a\x8f\x46\x0a\x18\x3c\x75\xb5\x98\x26\x70\xc8\xc3\x77\x4f\x9d\x3a\xc3\x3c\x97\xa2\x79\x1d\xd0\xd8\x50\x95\x98\x61\xa4\x46\x2b\xb9\xd3\xb1\x85\xbd\x35\x0b\xd6\xae\x6d\x94\x44\xbd\xcb\x4f\x8e\xc8\x33\x00\x2a\xad\xb2\x9c\x42\x31\x8d\xa2\xd9\xd0\x2f\xb4\x32\xc7\x87\xd4\x7b\x8f\x6e\x10\xcd\x97\x74\x24\xfa\x55\xa9\xd5\xff\xad\x10\xd8\x7a\x0a\xa2\x79\x59\xce\xbf\x35\xde\x3d\x00\x07\xe9\xad\x93\xf6\x97\xd9\x29\x5d\xa6\x72\xa9\xf1\x2c\xf6\xd5\xd7\x47\xd7\x51\xef\x4c\xb9\x42\x82\xb7\x3a\x33\xf4\xe2\xaa\x01\x83\xb3\x2e\xba\x5a\xb0\x8f\xf4\xc0\x9c\xcd\x0b\x23\xc7\xb0\x75\xd7\x01\x3d\xf1\xea\xa7\xcd\xf4\xef\x97\x6d\x65\x38\x92\x89\x77\xda\x72\xcf\xad\x99\x4b\xaf\x1f\xf7\x35\x11\x12\xcd\x8f\x68\x58\x8d\x04\xef\x71\x74\x9f\xe0\x73\x54\x5a\xc2\x37\xd3\x59\x3f\x2e\xbb\xf1\x16\xab\x52\xaa\xd3\x6e\xc8\xff\x36\xf6\xfb\x81\x3c\x70\xc1\xc0\xec\xc2\xc7\xb2\xec\x15\x0f\xb1\xfd\x70\xb0\x63\xc9\xcb\x8a\x14\xca\x5e\xbf\x04\xdc\xfe\x0d\x3f\x5b\x8d\xe6\x28\x9a\xaf\xa4\xdd\x1d\xdd\x3d\xb3\xaa\x8c\x3a\x5f\xdd\x4c\x4a\x76\xa9\x4a\x83\xa7\xe6\x69\x9a\xc8\xc8\x79\x7e\xfa\x13\x00\x00\xff\xff\x66\x4c\xe0\x1e\x54\x05\x00\x00")
+var _pkgUiTemplatesQuery_menuHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xad\x56\x5d\x8f\xd4\x3a\x0c\x7d\xbe\xfc\x0a\x13\xa4\xab\x59\x89\x4e\xdf\x61\x3a\x0f\x08\x04\x2b\x81\x84\x2e\xcb\xf3\xca\x6d\xdc\x36\xda\xb4\x09\x49\xba\xec\x6a\x34\xff\xfd\x3a\x9d\xb6\xdb\x76\x76\x04\x12\xcc\x4b\x1d\x8f\x3f\x8e\xed\xe3\xb4\x87\x83\xa4\x52\xb5\x04\xa2\xc5\x7b\x71\x3c\xbe\x00\xfe\xed\x58\x86\x42\xa3\xf7\x59\x54\xe7\xe8\xa0\x54\x0f\x24\x93\x60\x2c\x9c\x14\x09\x3d\x58\x6c\x65\xe2\x9b\x51\x21\xd1\xdd\x41\x5e\xf5\x4f\xb1\xef\xe3\x70\x24\xa9\xa6\x48\x85\x69\x03\x72\x2a\x97\x94\xba\x53\x72\xb2\x61\xab\xbc\x0b\xc1\xb4\x10\x1e\x2d\x65\xe2\x74\x10\x4b\x00\x9c\xba\xaa\x34\x39\x01\x12\x03\x0e\xa7\x18\x53\x6b\xb4\x9e\x46\x35\xba\x8a\x42\x26\x5e\xb1\x53\x12\xf3\x51\x1b\x04\xa0\x53\x38\xe0\x25\x99\x89\x12\x75\x74\xe8\xb5\xd1\xc6\x19\x7d\x4a\xb3\xf2\xd0\x98\x93\xce\xc4\x4d\x9f\x2a\x56\xa9\x2a\x0c\x8a\x91\x3d\x01\x67\xe8\x9e\xc3\x3e\x0f\x35\x51\x45\x34\xde\xa5\xd1\x64\x56\x6c\x7a\x2a\x70\xa6\xc1\x55\x80\xdc\x31\x54\x01\xb5\xa3\x32\x13\x87\x03\x58\x0c\xf5\x57\x3e\xa8\x07\x38\x1e\x53\xb1\xbf\xa9\xb1\x35\x7e\x97\xe2\x2c\x46\x6c\xb4\x92\xab\x3a\x96\x61\xc7\x66\xc1\xd4\xb5\x45\x25\x9d\x5e\xd9\x47\x46\xcc\x2d\xd8\x46\xab\x99\x4d\xa2\x02\x35\x5c\xe0\x1c\x7e\xa2\x55\x7b\x77\x11\x7a\xe5\xd0\xd6\x62\xff\x31\x3e\x22\xfc\x5d\xaa\xd5\xdf\xcd\xe0\x83\x71\xe4\xc5\xfe\x5b\xff\x7c\xca\xf1\xcf\x85\xe0\x20\x9d\xb1\xd2\xfc\x6c\x57\x95\xf6\x53\x39\xe5\x78\x25\xd6\xd9\x27\xa7\x61\xd4\x2b\x52\x4e\x21\x81\xa9\x35\x23\x74\xcf\xaa\x1a\xbd\x35\xb6\xb3\x99\x08\xae\xa3\x0b\xe4\x64\xf8\x18\x3a\xbf\x64\x57\x81\x8e\xc2\xc4\xa7\xc5\xf4\xcf\x97\x6d\x42\xd8\x50\xdb\x9d\xd5\x36\xe7\xdc\x64\xd9\xf7\xfa\x72\x5f\x23\x20\xb1\xff\xaf\x6b\x83\x6a\x08\xfe\xc5\xc6\xbe\x85\x77\x9d\xd2\x12\xae\xdb\xd2\xb8\xa6\xdf\x8d\xe7\x50\xa5\x0c\x6b\x35\xe4\xdf\x1b\xfb\x8b\x4b\x90\xd7\x2c\xa8\x43\xb0\xfe\x4d\x9a\x56\x2a\xd4\x5d\xbe\x2d\x4c\x93\x86\x7e\x45\x12\x65\x06\x49\xc0\x78\x37\xdc\xe6\x1a\xd9\x75\xff\x89\xb4\x3d\x83\xbb\x46\xb6\x4b\x3b\xfd\xcb\x25\x81\x46\x27\xd8\x05\xf3\x67\xcb\xc2\x34\x8b\xfb\x1b\xaf\xcf\xdb\xc6\x48\xba\x1d\xb9\x15\x54\x88\x2c\x1a\xee\xa1\xfe\x9a\x8d\xff\x8b\xfd\xfb\x51\x7c\x6e\x95\x96\xc8\x17\x43\x98\x1d\x76\x29\xc3\x18\x44\x5f\x38\x65\xc3\x68\x94\xa6\x70\x53\x13\x84\x9a\x78\xda\xca\xc3\x1d\xd9\x00\xaa\x8d\x0a\xd0\xa6\x40\x0d\x71\xd5\xb0\xa2\xd7\xe0\x0d\xa8\x00\x68\xad\x56\xe4\x21\x18\x40\xad\x99\x3e\x15\x1f\x4c\xd9\x3b\xfc\xe8\xc8\x3d\xc2\xf7\xeb\xed\x10\x7c\x53\x76\x6d\x11\xf9\xb2\xb9\x82\xc3\x04\xf2\x9e\xdf\x33\x9e\x42\x2c\xeb\x0b\x57\x05\x19\x4c\x66\xd4\x62\xae\x49\xce\xad\x01\xa4\x29\x3a\xa6\x77\xd8\xe6\x46\x3e\x6e\xfb\xb6\x7e\x56\x3e\x6c\x4f\x8d\xdb\xf4\xad\x4c\xfa\x56\xbd\x86\x31\xc0\xdb\x99\x7f\x5f\xc6\xb7\x53\x15\x5b\x4e\x7c\xcd\x13\xfa\x95\xd7\xf1\x49\x9c\x41\xdd\x2c\x42\x55\xe7\xa1\xae\x20\xcb\x32\x38\xad\xfc\x2c\xda\x54\x01\xbb\x7c\xd0\x14\xc5\x77\x8f\xd7\x72\x73\xce\x82\xab\x2d\x4a\xf9\xe1\x9e\x0d\x62\x89\xc4\x6f\xd1\x8d\x28\xb4\x2a\xee\x18\xe5\x53\x97\x96\xfd\x99\x03\x7c\x79\xa9\x59\xc3\x5b\xd9\x2f\xd0\x2e\xda\x44\x5b\xeb\x28\x66\x7e\x4f\x25\x76\x3a\x6c\xe6\xed\x98\xe4\xe3\xd5\xa8\xe7\x0b\x6a\x60\xd2\xe1\x40\xad\xe4\x6f\x8a\xff\x01\xe3\x8a\x3b\xfc\x65\x08\x00\x00")
 
 func pkgUiTemplatesQuery_menuHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -260,7 +260,7 @@ func pkgUiTemplatesQuery_menuHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/templates/query_menu.html", size: 1364, mode: os.FileMode(420), modTime: time.Unix(1565577418, 0)}
+	info := bindataFileInfo{name: "pkg/ui/templates/query_menu.html", size: 2149, mode: os.FileMode(420), modTime: time.Unix(1791980573, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -285,7 +285,7 @@ func pkgUiTemplatesRule_menuHtml() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiTemplatesRulesHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x55\x5d\x6f\xd3\x30\x14\x7d\xef\xaf\xb8\x32\x3c\xc0\x43\x12\x18\x4f\x20\xa7\x68\x82\x69\x43\x1a\x13\xda\x06\x0f\x43\x30\xb9\xf1\xed\x62\xe1\xda\xc1\x76\xca\x3a\xe3\xff\x8e\xec\xb4\x5b\xfa\xb5\x52\x24\xfa\x10\xf5\x3a\x39\xf7\x9c\xfb\x69\xef\x39\x8e\x85\x42\x20\x35\x32\x4e\x42\x18\x50\x29\xd4\x0f\x70\xb3\x06\x4b\xe2\xf0\xd6\x15\x95\xb5\x04\x0c\xca\x92\x58\x37\x93\x68\x6b\x44\x47\xa0\x36\x38\x2e\x89\xf7\xd0\x30\x57\x7f\x32\x38\x16\xb7\x10\x42\x61\x1d\x73\xa2\x8a\x98\xc2\xb4\x12\x6d\x5e\x59\xfb\x76\x5a\x7a\x0f\xa3\x56\x48\xfe\x05\x8d\x15\x5a\x41\x08\x64\x38\xf0\x1e\x15\x0f\x61\x30\x78\x10\x51\x69\xe5\x50\xb9\xa8\x03\x80\x72\x31\x85\x4a\x32\x6b\xcb\xf4\x82\x09\x85\x26\x1b\xcb\x56\x70\x32\x1c\x00\x00\xd0\xfa\x60\x78\x1e\x69\x68\x51\x1f\xcc\x8f\x1c\x1b\x49\x5c\xc0\x3a\x23\x3d\xb3\x91\x36\x1c\x0d\x2e\xb0\x00\xde\x1b\xa6\x6e\x10\xf2\xe8\xe2\xd8\xe8\xb6\xb1\x89\xb7\xfb\x51\x17\x33\x32\xbc\xb7\xe3\x89\xe9\x9b\xf1\x80\x43\xa5\xa5\x6d\x98\x2a\xc9\x2b\x32\x8c\x72\x28\x9b\xa7\xe6\x89\xf7\x06\xcf\xb1\x91\xac\xc2\x43\x29\x81\x3c\xfb\xfa\x9d\x65\x77\x87\xd9\xd5\x8b\xec\xf5\xb7\xe7\x04\xc8\xd3\x97\x04\xf2\x33\x36\xc1\x10\x08\x28\x36\xc1\x98\xcf\x3d\x30\x43\xef\xe7\x7f\x53\xf8\xb4\x70\x7c\x4d\x5f\xd2\xe4\xbd\x18\x43\x7e\x8c\xee\x68\xca\x64\xcb\x9c\xd0\xea\x52\x4c\xd0\x3a\x36\x69\xf2\x0f\xf6\x0a\x8d\x0e\xe1\x0c\xa7\x68\xbc\x47\x69\x31\x04\xef\xad\x50\x15\x6e\x03\x85\x00\xec\x46\xcf\xeb\xb7\x93\xbc\x6e\x27\x4c\x89\x3b\x7c\xdf\x9a\xe4\x66\xc5\xeb\xe2\x38\xbf\xc0\x4a\x2b\x6e\xb7\x78\xa4\x45\x3f\xfd\xb4\x58\x29\x0f\x75\x23\xcd\x67\x3b\xcb\x95\x5a\xb8\x24\x63\xad\x5c\xf6\x0b\xc5\x4d\xed\xde\x8c\xb4\xe4\x24\xb5\xd1\xc6\x20\xb6\x43\x2e\x1c\x73\xfb\x62\x8e\x8c\xd1\x66\x4f\xcc\x29\xb3\x0e\x1e\xf2\xb5\x2f\xe3\x3d\x10\x62\xfd\x1e\x4f\xeb\xca\x50\xf4\xe7\x61\x5b\xff\x77\x83\x16\x87\xfd\xba\x42\x29\x53\x57\x9e\x5c\x7e\x3c\xbd\x50\xa2\x69\xd0\xc1\xcf\x16\xcd\xec\xf3\xf9\x69\x2c\xeb\x26\xe1\x73\x07\x71\x71\x20\x59\x7e\x0d\x40\xe3\x6c\x2d\x3e\x61\x12\x8d\x83\xf4\xcc\xbc\x87\xfc\x04\x99\x74\x35\xfc\x86\x48\xde\x19\x97\xfa\x5d\xfc\x16\x42\x80\xe4\xf0\x5a\x28\x2e\x2a\xe6\xb4\x81\xb8\xcb\xb2\xb6\x69\xd0\x54\xcc\xae\x33\xc5\xc8\xe7\x1e\x97\x82\xee\x72\x14\x65\xac\x48\x7f\x2c\x18\x8c\x55\xb6\x6b\x1c\xdd\x1c\xc6\x72\xa6\x36\x58\xe7\xd9\x16\x2d\x8f\x25\x31\xab\x21\xa5\x54\xf7\xbc\x6d\x92\x19\x49\xbb\x2d\xbb\x53\xfc\x66\xb5\xff\x6b\x6b\xfc\x85\x9e\x7f\xda\x1c\xbb\xba\xbb\x4f\xbe\xd0\xdd\x5b\x22\x66\x79\x83\xac\xa8\x3a\xd3\xa9\xd7\x2c\x74\xf7\x15\x1f\x6c\x0b\xa1\xcf\xbb\xcc\x49\x8b\xde\xa2\xa2\x45\xba\x9a\xa2\x41\x0b\x2e\xa6\x0f\x77\xe2\x9f\x00\x00\x00\xff\xff\xb5\xd1\x9f\xde\x98\x07\x00\x00")
+var _pkgUiTemplatesRulesHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xb5\x55\x4d\x6f\xdb\x30\x0c\xbd\xe7\x57\x10\x5a\x0f\xdb\xc1\xce\x3e\x4e\x2b\xec\x0c\xc5\x56\xb4\x03\xba\x62\x48\xba\x1d\x3a\x6c\x85\x62\x33\xb1\x30\x45\xf2\x24\x39\x6d\xaa\xf9\xbf\x8f\x72\x92\xc6\x49\x9a\x66\x19\xb0\x1c\x1c\x51\x12\xf9\x48\x3e\x92\xf2\x3e\xc7\x91\x50\x08\xac\x40\x9e\xb3\xba\xee\x24\x52\xa8\x9f\xe0\x66\x25\xa6\xcc\xe1\x9d\xeb\x66\xd6\x32\x30\x28\x53\x66\xdd\x4c\xa2\x2d\x10\x1d\x83\xc2\xe0\x28\x65\xde\x43\xc9\x5d\xf1\x99\x04\x71\x07\x75\xdd\xb5\x8e\x3b\x91\x05\x9d\xae\xa9\xe8\x72\x4c\xab\x77\xd3\x94\xee\x0d\x2b\x21\xf3\xaf\x68\xac\xd0\x8a\x6e\xb2\x5e\xc7\x7b\x54\x39\x21\xd2\x62\xe9\x44\xa6\x95\x43\xe5\x82\x1f\x00\x49\x2e\xa6\x90\x49\x6e\x6d\xda\x1c\x70\xba\x62\xa2\x91\xac\x44\x4e\xda\x40\xbf\xa4\x78\xdd\xeb\x07\x98\xa4\x4b\xab\xf9\x96\xe3\x43\x89\x4b\xb5\xb9\xd0\x7c\xa3\xa1\x36\x39\x1a\x5c\xea\x02\x78\x6f\xb8\x1a\x23\xc4\xc1\xc4\x99\xd1\x55\x69\x1b\xdc\xe5\xe9\x91\x75\x86\x3b\x1c\xcf\xe0\x38\x85\xf8\x33\x37\x4e\x70\xd9\x47\x5b\x6a\x65\x71\xb0\x38\x6b\x69\x24\x2e\xe4\xb0\xf7\x20\x87\x1d\xd3\x16\xc3\x46\x0e\x99\x96\xb6\xe4\x2a\x65\x6f\x58\x2f\x04\x90\xf0\x45\x32\x9f\x91\x43\xd8\xc7\x52\xf2\x0c\x4f\xa4\x04\xf6\xfc\xdb\x0f\x1e\xdd\x9f\x44\xd7\x2f\xa3\xb7\xdf\x5f\x30\x60\x47\xaf\x18\xc4\x97\x7c\x82\x94\x3f\x50\xf4\x1f\x18\x38\x40\xa7\xe7\xfd\x62\xd9\x24\x2c\xe9\xba\x7c\xcb\xbf\xc6\x27\xef\xc5\x08\xe2\x33\x74\xa7\x53\x2e\x2b\xa2\x54\xab\x2b\x31\x41\x62\x77\x52\xc6\x1f\xed\x35\x1a\x5d\xd7\x97\x38\x45\x43\x24\x4a\x4b\x06\xbd\xb7\x42\x65\xb8\x4b\xa9\xae\x81\x8f\xf5\x82\xf1\xbd\xe0\x45\x35\xe1\x4a\xdc\xe3\x87\xca\x34\x66\x36\xac\x2e\xb7\xe3\x01\x52\x5d\xe4\x76\x87\x45\x92\x5b\xe9\x27\x69\x9d\x9e\xc4\x0d\x75\x3e\xdb\x4b\x57\x53\xf4\x29\x1b\x51\x01\x46\xb7\x28\xc6\x85\x3b\x1e\x6a\x49\x55\x14\xaa\xe6\xd1\x20\x76\xab\x0c\xa8\x3b\x0e\xd5\x39\x35\x46\x9b\x03\x75\x2e\xb8\x75\xb0\xca\xd7\xa1\x88\x0f\x8a\x10\xf8\x7b\x3a\xad\x1b\x6d\xd4\xee\xa0\x5d\xf5\x3f\x6f\xcd\x30\x1e\x6e\x32\x94\xb2\xa9\xca\xf3\xab\x4f\x17\x03\x25\xca\x12\x1d\xfc\xaa\xd0\xcc\xbe\xf4\x2f\x02\xad\x8f\x39\xbe\x30\x10\x46\x0d\xb2\xf5\x63\xba\x10\x7a\x6b\x79\x85\x4b\x34\x0e\x9a\x6f\x44\x23\x28\x40\x9e\x23\x97\xae\x80\x55\x6f\xc7\xf0\xbb\x75\x70\xa5\xdf\x07\x55\x1a\x50\xd0\xd8\xbf\x11\x2a\x17\x19\x77\xda\x40\x18\x86\x51\x45\x1e\x9a\x8c\xdb\x6d\xe0\x26\x11\x8f\x02\xac\xa5\x64\x9e\xc1\xe0\xe4\x46\x60\x4f\x85\x8a\xa1\x06\xec\x16\xa4\xf7\xb7\x82\xa0\x02\x6a\x60\xbc\xa9\x94\xa7\x81\x77\x25\x27\x0f\x0c\x9a\xcd\x90\x1b\x66\x02\x0b\xdb\xee\x06\xf0\xf9\xf4\xde\x1b\xc4\xb6\xe2\xff\x9c\x2d\x7f\xe1\xcf\x3f\xcd\x97\x7d\x3d\xd0\x06\x5f\xfa\xdd\xd9\xd1\x07\x5b\x49\xb9\xd4\x0d\x89\x16\xe6\xef\x60\xde\xd9\x15\x42\x1b\x77\x1d\x93\x4e\x56\xe3\x8c\x84\xf0\xe4\x05\x21\xe9\xd2\x2b\xba\x7a\x6b\xff\x00\xec\x6f\xed\xe3\xf0\x07\x00\x00")
 
 func pkgUiTemplatesRulesHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -300,7 +300,7 @@ func pkgUiTemplatesRulesHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/templates/rules.html", size: 1944, mode: os.FileMode(420), modTime: time.Unix(1567492426, 0)}
+	info := bindataFileInfo{name: "pkg/ui/templates/rules.html", size: 2032, mode: os.FileMode(420), modTime: time.Unix(1791968488, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -385,7 +385,7 @@ func pkgUiStaticCssGraphCss() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiStaticCssPrometheusCss = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\x91\xd1\x6a\xf3\x30\x0c\x85\xef\xf3\x14\xba\x2e\xb8\x2d\x3f\xff\x18\xb8\xcf\xb0\xbb\xdd\x17\x25\x56\x6a\x81\x23\x19\x47\x69\xd2\x8d\xbd\xfb\x48\x9c\x8e\xde\x18\x7c\xf8\xce\xb1\x75\x74\x3a\xc0\x87\xde\x09\x82\xce\x02\x9d\x8a\x91\x18\xb4\xd4\xe1\x34\x12\xcc\x04\x11\xef\x04\x08\x3d\x2f\x14\x40\xf0\xde\x62\x01\x8b\x68\xc0\x23\xbc\x9d\xf3\x02\x86\x29\xc1\xcc\x16\xe1\xdf\x7a\xcd\x18\x02\xcb\x0d\x0e\xa7\xa6\xd5\xf0\x80\xef\x06\x9e\x9a\x33\xcd\x1e\xde\xcf\x79\xb9\xbc\x88\xad\x9a\xe9\xe0\x37\xf7\xa5\xf9\x69\x9a\xe3\x68\x68\x74\x65\x09\xdc\xa1\x69\x79\x8d\xf0\x70\x86\xff\x79\xa9\x67\xa5\x13\x1b\x15\x4c\x57\x9d\x2c\x4f\x06\x16\x36\xbe\x57\x31\xd7\xe3\xc0\xe9\xe1\x61\x50\xd1\x31\x63\x47\xd5\xd1\x4d\x65\xd4\xe2\xb2\xb2\x18\xd5\xf8\x2a\x79\xd8\xb5\xca\x99\x6a\x32\xce\x8e\x45\x76\x6c\xc0\xc5\xcd\x1c\x2c\x7a\x10\x15\x5a\xc7\x30\x5a\xcc\x61\xe2\x9b\x78\x48\xd4\xdb\xfe\x29\x6c\x29\x6d\x96\x39\xb2\x91\xdb\x5e\x5f\x4d\x65\xc0\xb4\x21\xa7\x03\x7c\x46\x7a\x36\x8a\x21\x8c\x10\xb5\xf0\x97\x8a\x61\xfa\x6b\x11\x53\x21\x0c\x8f\xb5\xcd\xe3\x8e\x1e\xd7\x25\x21\x0b\x15\xd7\xa7\x89\xeb\xb8\xaf\x05\xad\xf1\xbf\x01\x00\x00\xff\xff\x85\xe5\xf2\x63\xd6\x01\x00\x00")
+var _pkgUiStaticCssPrometheusCss = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xb5\x54\xc9\x6e\xdc\x30\x0c\xbd\xcf\x57\x10\xe8\xa5\x0d\x22\x27\x0d\xa6\x28\xe0\x5c\x7b\xe9\xa1\xb7\xf6\x6c\xd0\x96\x6c\x0b\x23\x8b\xaa\x4c\x67\x66\x1a\xf4\xdf\x4b\xc9\xb3\x79\x92\x2e\x97\xc2\x80\x61\x91\x8f\xcb\x7b\x14\x7d\x77\x03\x5f\xe8\xc9\x80\xa6\xad\x87\x86\x3c\x1b\xcf\x50\x9b\x06\xa7\xd1\xc0\xd6\x40\x8f\xe2\x44\x68\xed\xce\x68\xf0\xf8\x54\x63\x04\xee\x91\xc1\x8e\xf0\xe1\x3e\xec\x80\xd1\x39\xd8\x5a\xee\xe1\x21\x1d\x03\x6a\x6d\x7d\x07\x37\x77\xab\x9a\xf4\x1e\x9e\x57\x70\xb4\x29\xa6\x50\xc2\x47\x41\x3d\x5e\x18\x6b\x62\xa6\xa1\xcc\xd1\x8f\xab\x9f\xab\x55\x31\x32\xb2\xa9\xac\xd7\xb6\x41\xa6\x78\x99\xa2\x84\x7b\x58\x4b\x95\xfc\x9e\xd1\xce\xb2\x89\xe8\x2a\x9a\x38\x4c\x0c\xac\x33\xbe\x15\x26\xaa\xc5\xc1\xba\x7d\x09\x03\x79\x1a\x03\x36\x66\x8e\x68\xa6\x38\x52\x54\x81\xac\xb0\x9d\xd3\xcf\xa6\x12\x0e\xb6\x19\xc7\x44\x8e\x6d\x50\xd6\xfb\x03\x6c\xc0\x9d\xda\x5a\xcd\x7d\x09\x9e\xbc\x49\x34\xd8\xec\x58\xa1\xb3\x9d\x2f\xc1\x99\x96\x0f\x4d\x61\x6d\x5c\x0e\xd9\xf6\xd2\x9f\xca\xd5\x53\x50\x1c\xd0\x65\xc8\xdd\x0d\x7c\xed\xcd\x51\x51\x61\x37\x42\x4f\xd1\xfe\x90\xbe\xd1\x9d\x54\x44\x17\x0d\x8a\x8a\xa2\x66\x71\x80\x16\x69\x48\x68\xa5\x25\xd5\xba\xc9\xce\x74\x2f\x05\x3a\xa6\xff\x84\x71\x23\xd4\xb5\xb9\x05\xa6\xae\x73\x32\x3f\xeb\x65\x76\xa7\xa2\xd4\xe6\xd3\xf7\xc9\xc4\x3d\x7c\xfb\x5c\x1c\x67\x56\x68\x89\x54\x29\x72\xd6\x86\x5c\x92\xe6\x8d\xd6\x3a\x11\xae\xb1\xd9\x74\x91\x26\xaf\xd5\xd1\xf3\xde\xa4\x67\x66\x7e\x8e\x2d\x5a\x61\xab\x52\xb7\x91\xdc\x2d\x5c\x7a\x9a\x69\x94\x99\xab\xd1\x38\xd3\xf0\xc2\xf5\x8a\x29\x29\x8c\x22\xc3\xed\x22\x79\x88\x34\x18\x69\x7f\x1a\xe5\xa6\xc8\xe0\xab\xd4\x53\x80\x22\x1f\x96\xd5\x7e\x07\xad\xd9\x2f\x81\x5d\xc4\xd0\x57\x14\xb8\x12\xd7\x3f\x72\x7f\xa8\xd3\x93\x9d\x14\xb5\x0c\xe5\xe8\x58\xaf\xd7\x2f\x14\x61\xac\x9d\x59\xd6\x7c\xfd\xfa\x2e\xea\xbe\x96\x44\x8d\x1c\x6d\x90\x91\x72\xde\x32\x8e\xa5\xe7\x5e\x51\xab\x78\x1f\xcc\x5b\xd2\xfa\xdd\xb2\xcc\x1c\xd4\xcb\xa6\xc7\x73\xc8\x7c\x7c\xfe\x33\xb1\xab\xea\x72\x77\x94\x24\x1b\xe7\x2f\x67\xfd\xa6\xc0\x86\xed\xd3\x15\x2d\x1d\x29\xa4\x5f\x8a\x1a\x8c\x9f\x96\xae\x9a\x88\xa5\x7b\x0c\x4a\xcb\x9e\xb3\x1d\x4c\xb0\xcd\x46\x94\x93\xcd\xea\x0c\xff\x0f\xdd\x4f\xdd\x88\xd8\xc3\x5f\x05\x8e\xd2\xce\xd8\xe3\xb6\xca\xf7\x01\x8a\x7d\xc5\xc9\x92\x2f\xe2\x92\xca\x35\x72\x97\x91\xa2\xb6\x65\x37\x2f\x4f\x6b\x9d\x3b\xf3\xb8\xaa\xfa\x0b\xf9\x85\x50\x69\x7c\x05\x00\x00")
 
 func pkgUiStaticCssPrometheusCssBytes() ([]byte, error) {
 	return bindataRead(
@@ -400,7 +400,7 @@ func pkgUiStaticCssPrometheusCss() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/static/css/prometheus.css", size: 470, mode: os.FileMode(420), modTime: time.Unix(1565577418, 0)}
+	info := bindataFileInfo{name: "pkg/ui/static/css/prometheus.css", size: 1404, mode: os.FileMode(420), modTime: time.Unix(1791980573, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -485,7 +485,7 @@ func pkgUiStaticJsAlertsJs() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiStaticJsBucketJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\x4d\x6f\xe3\x36\x13\xbe\xfb\x57\xcc\xfa\x35\x20\x09\xeb\x57\x76\x80\x9e\xe2\x28\xc0\x36\xbb\x87\x16\x8b\xa6\xd8\xe4\xd4\x20\x80\x69\x71\x64\xb1\xa6\x48\x95\x1c\xc5\xf1\x1a\xfa\xef\x05\x29\xc9\x96\x1d\x25\x9b\x2d\xe1\x83\x45\x72\x9e\xf9\x7a\x38\x33\x6b\xad\xd7\x12\xe3\x34\x67\x86\x6c\x2c\x35\xe3\x61\x90\x56\xc6\xa0\xa2\x60\x0a\xfb\x11\x00\x40\x50\xb2\x74\xc3\xd6\x68\x83\x4b\x78\x08\x48\x14\x28\x85\xc2\xe0\x71\x54\x47\x8b\xd1\x29\x80\x45\xba\x55\x5f\x35\xe3\x37\x4c\xca\x15\x4b\x37\x21\x37\x6c\x1b\x2d\x46\xa3\xac\x52\x29\x09\xad\xc0\x6d\x84\x51\x0b\x2d\x32\x08\x29\x67\x4a\xdb\xd8\x60\x66\xd0\xe6\xc8\x3f\x11\x24\x09\x8c\xe7\xf3\xf9\xc5\xff\xfd\xef\x7e\x3e\xbf\xf4\xbf\xbf\xc6\x9d\x9c\x5b\xad\x1c\x1a\x03\x09\x8c\xef\x76\x2a\xcd\x8d\x56\xe2\xbb\x50\x6b\x58\x49\x9d\x6e\x2c\x64\x46\x17\x60\xb0\xd0\x84\x60\x49\x1b\xb6\xc6\xf1\xc2\x03\xd4\xa3\x73\xfd\x0e\xe7\x43\x02\xaa\x92\xb2\xaf\x65\x12\x8e\xff\x87\xc6\x8c\xa3\xd8\xe6\x7a\x1b\x46\x71\x26\x14\x0f\x83\x98\x49\x34\x14\x44\x31\xe1\x33\x85\xbf\xdf\xdd\xfe\x11\x5b\x32\x42\xad\x45\xb6\xeb\x21\x4e\x3d\xde\x14\x7e\x89\xa2\xc5\x01\xd2\x22\xdd\x8b\x02\x75\x45\x61\x17\x95\xb0\xaf\xd2\x2d\xa9\x53\xe6\x0e\x62\x83\x3e\x29\x3d\xf1\x7a\x0a\x17\xf3\xf9\x7c\xee\xa2\xea\xbf\x01\xa5\xc5\x41\x93\x73\xc1\x31\xec\xee\xb9\xf5\xc4\x0c\xa4\x5a\x11\x13\x0a\x5d\xd8\xb8\x4e\xab\x02\x15\xc5\x6b\xa4\x2f\x12\xdd\xdf\x5f\x77\xbf\xf1\x30\xb8\xd1\x45\xc9\xbc\x69\x36\xe8\xe9\xf6\xf2\x2e\xd5\x90\x80\xc2\x2d\xb4\xd9\x7f\x12\xb6\x62\x52\x7c\x6f\x4c\xbe\x6f\x19\x12\x1e\x34\x9d\x21\x70\x46\xec\x9e\xad\x24\xbe\x85\xf2\xb9\xbb\x14\x9e\x49\x93\x20\x89\x16\x12\xd8\xd7\x3d\xcf\x0e\x98\x31\xe3\xfc\x46\xcb\xaa\x50\xe1\x9e\x76\x25\x5e\x42\xd0\x64\x26\x98\x82\xe0\x97\x10\x7c\xc3\x52\x8a\x94\x05\x75\x0f\xf7\xfd\xd2\x5f\xd9\x0a\xe5\xbb\x65\x39\x23\xec\x24\xef\x88\x19\xfa\x4f\x92\x5f\x14\xf7\x72\xc3\x82\xdf\xf4\xd6\x76\x94\x6b\x78\x7f\xc2\xa4\xb8\x60\xe5\x91\x67\xfc\x9c\x68\x6e\xcd\x66\x70\xef\x82\x0a\xc2\x02\xe5\x08\x99\x30\x96\x20\xf5\x06\x81\xce\xfc\x5e\xf7\xec\xe3\x01\xe9\x21\xc0\x4f\x50\x29\xf1\x4f\x85\xf0\xa7\xd1\x05\x52\x8e\x95\x05\xe9\x62\xe7\xde\x2d\x81\xe0\xa8\x48\x64\x02\x2d\x20\x4b\x73\xb0\x39\x33\xdc\xe9\xaf\x2c\xf2\x21\x3c\x66\x5b\x33\xbc\x9d\x19\x94\x06\x2d\x2a\x9a\x82\xa6\x1c\xcd\x56\x58\x04\x26\x65\xa3\xc2\x02\x33\x08\x5c\xd8\x52\xb2\xdd\x30\x1c\x3e\x13\x1a\xc5\xa4\xdc\x39\x64\x06\x12\xd7\xa8\xf8\x4b\xe7\x1a\x7d\x09\xbc\xfa\x50\xbb\xd5\xab\x24\x8d\x9b\x1f\x12\x18\x8f\x5f\xbb\x0d\x2d\x97\x37\xb8\x73\xaf\x30\xee\x4b\xda\x87\xfe\xd7\xe3\xe2\x55\x00\xa7\xd2\x03\x24\x50\x29\x8e\x99\x50\x38\x98\xde\x13\x7f\x72\xa3\xb7\xb0\xf4\x2c\x86\xc9\xbe\xaf\xa9\x06\xa5\x09\x32\x5d\x29\x0e\x42\xc1\x64\x7f\xbb\xfa\x1b\x53\x8a\x37\xb8\xb3\xe1\x99\x89\x51\xbd\x7c\xdd\xae\x17\x05\x69\x68\x19\xa4\xca\x28\x17\x80\x37\x80\x06\x4f\x7e\x08\xdf\xe5\xac\x29\x14\x0f\xc7\xba\x7c\xee\xc4\x0f\x62\xdb\xe2\xfc\x4c\x74\x5b\xcd\x77\x5e\x65\xd8\x0f\x60\x63\x4c\x14\x4b\x54\x6b\xca\xe1\x23\x5c\x44\xaf\x6b\x3f\x60\xbd\x69\x7d\xe7\xe2\xcf\x06\x10\x8e\xe1\x7f\x43\xfe\xa5\x6c\x7d\xd2\x4a\xba\xd5\xb0\x3d\x81\xa5\xbc\x84\xc9\x9e\xc7\xe9\xa1\x73\xc4\x12\x9f\x50\xd6\x53\x30\x68\x9b\xb3\xd6\x01\xae\xb7\xca\xb2\xa2\x94\x18\x1b\xb4\x5a\x56\xee\xf6\x10\xa3\x5a\x2b\x1f\xbc\x99\xd3\x46\xd5\xd4\xb7\x8c\xcf\x8c\x30\xe4\x71\x21\x94\xeb\x36\xd1\xe9\x26\x7b\xf6\x9b\x67\xe9\xad\xa3\xbe\xf9\xbe\x8b\xc5\x7e\x18\x39\x54\xd3\xfe\xf9\x6c\x06\x77\xb9\xde\x1e\x0a\x45\x5b\x21\x1c\x31\x94\xee\xed\x36\xf5\x66\x8b\x06\x5d\x5f\x3f\x16\x90\x17\xf5\x20\x19\xaa\x07\xae\x55\x37\xc0\x87\x01\xe3\xd4\xe8\x4c\x1b\x08\x25\x12\x3c\x6c\x70\x37\x85\x27\x26\x2b\x7c\x74\x35\xb9\xe5\x16\x2a\x32\x02\x0f\xf4\x1a\x22\xa8\x7b\xf0\x09\x2c\xaf\xc8\x5c\xc3\x15\xe5\x60\x53\x5d\x62\x32\x36\x7a\x3b\xbe\x9e\xec\x3d\x62\x7d\x35\xa3\xdc\x9d\xf2\xeb\xc9\x7e\x83\x3b\xf7\xcd\xaf\xe1\x6a\x46\xe6\x7a\x20\x2d\x47\xab\x81\x7c\x1b\xa7\x95\xe6\xbb\x71\x14\xb3\xb2\x44\xc5\x43\xa3\xb7\x67\x6e\x1c\xc9\x54\xb7\xe3\x57\xdd\x1b\x09\x8f\x1c\x2f\x58\xd9\xb9\xe0\x5b\xbd\x9b\xec\xda\x89\xed\xbd\x91\x70\x10\x27\x63\x22\x7c\x4c\x60\xd9\xb8\xe5\x58\xd8\x38\x0c\xcb\x6e\x0e\xec\xf1\x8c\x16\xa3\x7a\xf4\x6f\x00\x00\x00\xff\xff\x46\xd5\x4b\x47\x12\x0b\x00\x00")
+var _pkgUiStaticJsBucketJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9d\x57\x6d\x6f\xdb\x36\x10\xfe\xee\x5f\x71\x55\x0b\x58\x46\x55\xc5\xc1\x5a\x0c\x88\xeb\x0e\x6d\xd7\x0f\x2d\x8a\x65\x68\xf2\x69\x69\x80\x30\xe2\xc9\xe6\x42\x93\x1e\x49\x35\x75\x03\xfd\xf7\x1d\x49\xc9\x96\x6d\x39\x4d\x2a\xa4\xa8\x49\xea\x9e\x7b\x7b\xee\x8e\x9a\x69\x3d\x93\x98\x17\x73\x66\x9c\xcd\xa5\x66\x3c\x1d\x16\x95\x31\xa8\xdc\x30\x83\xbb\x01\xd0\x33\x5c\xb2\xe2\x86\xcd\xd0\x0e\x4f\xe0\x62\xe8\xc4\x02\xa5\x50\x38\xbc\x1c\xd4\xa3\xc9\x60\xb6\x05\x60\xd1\x9d\xaa\xcf\x84\xf2\x9e\x49\x79\x4d\x62\x29\x37\xec\x96\x5e\x1b\x94\x95\x2a\x9c\xd0\x0a\xfc\x46\x3a\x6a\xa0\x45\x09\xa9\x9b\x33\xa5\x6d\x6e\xb0\x34\x68\xe7\xc8\xdf\x3a\x98\x4e\x21\x19\x8f\xc7\xc7\x2f\xc2\xdf\xf9\x78\x7c\x12\xfe\xfe\x49\x5a\x39\xff\x34\x72\x68\x0c\xd0\xeb\x67\x2b\x55\xcc\x8d\x56\xe2\x87\x50\x33\xb8\x96\xba\xb8\xb1\x50\x1a\xbd\x00\x83\x0b\xed\x10\xac\xd3\x86\x9c\x48\x26\x01\xa0\x1e\xec\xea\xf7\x38\x4f\xa6\xa0\x2a\x29\xbb\x5a\x9e\xa5\xc9\x53\x3a\x4a\x46\xb9\x9d\x6b\x32\x3c\x2f\x85\xa2\x18\xe5\x4c\xa2\x71\xc3\x51\xee\xf0\xbb\x4b\x3f\x9d\x9d\xfe\x95\x5b\x67\x48\xb5\x28\x57\x1d\xc4\x2c\xe0\x65\xf0\x72\x34\x9a\xac\x21\x29\x48\xe7\x14\x44\x5d\xb9\xb4\x8d\x4a\xda\x55\xe9\x1f\xb2\x9f\xf9\x03\x0a\x4b\x48\x4a\x47\xbc\xce\xe0\x98\x82\x33\xf6\x51\x0d\x6b\x40\x69\xb1\xd7\xe4\xb9\xe0\x98\xb6\xef\xf9\xe7\x1b\x33\x50\x68\xe5\x18\x25\xd0\x87\x8d\xeb\xa2\x5a\x50\xae\xf3\x19\xba\x0f\x12\xfd\xcf\x77\xab\x8f\xe4\xdf\x7b\xbd\xa0\xac\x7b\x0b\xec\xb0\xa3\x3b\xc8\xfb\x54\x93\xac\xc2\x5b\x68\xb2\xff\x4d\xd8\x8a\x49\xf1\x23\x9a\x7c\xde\x30\x24\x5d\x6b\xda\x41\xe0\xcc\xb1\x73\x76\x2d\xf1\x3e\x94\x3f\xdb\x97\xd2\x1d\x69\x27\x9c\x44\x4b\xa2\x77\x75\xc7\xb3\x35\x66\xce\x38\x7f\xaf\x65\xb5\x50\xe9\x9d\x5b\x2d\xf1\x04\x86\x31\x33\xc4\x67\xc1\x69\xf5\x05\x97\x52\x14\x6c\x58\x77\x70\x1f\x2e\xfd\x99\x5d\xa3\x7c\xbc\xac\xd1\xd2\xaf\x9d\xd6\xd2\x89\xe5\x2f\xea\xb6\x6e\x25\x71\x03\x16\x97\x0f\x85\xa2\x33\x6c\x81\xce\x1c\xa5\xf0\x97\x24\x3f\x28\x1e\xe4\xfa\x05\xbf\xe8\x5b\xdb\xb2\x3f\x96\xe0\x16\xa9\xf3\x05\x5b\x6e\x28\xcf\x77\x39\xef\x9f\xa3\x23\x38\xf7\xf9\x05\x61\xa9\xc0\x11\x4a\x61\xac\x23\xca\x7a\x83\x40\x97\x61\xaf\xed\x40\x79\x8f\x74\x1f\xe0\x5b\xa8\x94\xf8\xaf\x42\xf8\x9b\xda\x01\x12\x42\x65\x41\xfa\x34\xfa\x16\xe2\xc8\x2f\x62\xbd\x28\x05\x71\x0a\x59\x31\x07\x4b\xfc\xe6\x5e\x7f\x65\x91\xf7\xe1\x31\xdb\x98\x11\xec\x2c\x61\x49\x7d\x8b\x20\x32\xd0\xb4\x6d\x6e\x05\x15\x23\x75\xbf\xa8\xc2\x02\x33\x08\x5c\xd8\xa5\x64\xab\x7e\x38\x6a\x20\x68\x14\x49\xac\x3c\x32\x03\x89\x33\x54\x7c\xdf\xb9\xa8\x6f\x0a\x07\x7b\x46\xfb\x74\x9a\x5a\x74\x93\xda\x5a\x92\x1c\x7a\xbb\x2d\xab\x1b\x5c\xf9\x86\x90\x77\x25\xed\x45\x77\x75\x39\x39\x08\xe0\x55\x06\x80\x29\xc5\x9a\x23\xf5\x48\xe4\xf7\x29\x8c\xfd\xdb\xe8\x5b\xb8\x0a\x05\x05\xcf\xee\xba\x9a\x6a\x50\xda\x41\xa9\x09\x0b\x84\xa2\xc3\xd3\xeb\x7f\xb1\x70\x39\xa9\xb0\xe9\x8e\x89\xa3\xfa\xea\xb0\x5d\x7b\xbd\xb1\xef\x31\xe8\x2a\xa3\x7c\x00\xee\x01\x1a\xfc\x12\x7c\x9b\xb3\xd8\xb3\x2e\x36\x23\x62\xd7\x89\x9f\xc4\xb6\xc1\x79\x4c\x74\x1b\xcd\x67\x41\x65\xda\x0d\x60\x34\x66\x94\x4b\x54\x33\x37\x87\xe7\x70\x3c\x9a\xfc\x1c\xeb\x5e\xeb\x5b\x17\x1f\x1b\xc0\x4e\xf8\xef\x91\xdf\x97\xad\xb7\xa6\x5a\xa7\x98\xde\xc5\xc1\x4f\x9d\x82\x8b\xb2\x44\x7f\x8f\x21\x05\x96\xfa\x47\x98\x65\xa1\x1a\xfd\x20\x57\x9e\x57\xc4\x3f\xdb\x34\x15\x61\x80\x36\x33\xb0\xda\xaf\x56\x34\x18\x03\x05\xfd\x00\x8d\x5d\x21\xd4\xf6\x7e\x51\xfa\xca\x31\x61\x1a\xad\x63\xc2\x09\xc8\xb2\xc5\x92\x5a\xe2\x46\xf3\xbe\x63\x3e\xad\x5e\xf2\x0d\x8c\x0f\xe5\xb2\xcd\xe1\x15\x55\x87\xff\x59\x07\x89\x13\xaa\x07\xfa\xaf\x1e\xf5\xf0\xbe\x1e\xf4\x5a\x68\x75\x65\x8a\xc6\xca\x62\x3d\xd9\xf3\x76\xfb\x8f\xde\xed\x96\x1f\x27\x30\xde\x57\x14\x1b\x0b\x99\x26\xbd\x39\x5b\xe2\x12\xbf\x51\x05\x67\xad\x52\x7f\xde\xfc\xf4\x95\xda\x6b\x5f\x33\x16\xa3\xab\x3c\xaf\xa4\xe0\xf5\x57\x15\x80\x1e\x03\x9f\x75\x52\xdd\x46\xe9\xab\xba\x82\xe7\xbd\xd1\x25\x55\xfe\xf2\x41\xf7\x0c\x24\x42\x2f\x84\xf2\xf7\x16\xba\xd0\xe9\x8f\x67\xa7\x4d\xd5\x8c\x6a\x78\x01\xdb\xaf\xb1\xef\x7d\xaf\xf5\xa4\x62\x8b\x8c\x73\x26\x1d\x72\x98\x19\x5d\x2d\x89\x84\xd4\xd6\x9a\x1b\xea\x82\x99\x1b\x3a\x28\xb5\x21\xbe\xc1\xc6\xcd\x40\xd4\xb9\x98\xcd\x25\xfd\x23\xd1\x7e\xe6\x85\x1b\x00\xc4\x4b\xeb\xe4\xc0\x1b\x64\xb7\xaf\xce\xc8\xcd\xb0\xb4\x17\x31\xc4\x97\xfd\xa4\x8c\x22\x4f\x1e\xd0\x6b\xda\xb4\x3d\xa7\xbc\x7d\x55\x94\x07\x2f\x19\x75\xd4\x21\x2f\x61\x6d\x90\x59\xad\x0e\x75\xe9\xd6\x85\x8e\x6c\xb8\xfe\xc7\x88\x25\xc4\xcd\xe4\x29\x2f\x7e\x7b\xf5\xf2\x55\x42\x4c\x4c\x9e\x96\x65\x71\x3c\xfe\x3d\xe9\xa3\xfe\x81\xc6\x72\x11\x6a\x27\x8b\x94\xcd\x5a\xa3\xb3\xa8\x39\x83\x1e\x0e\x6c\x6f\x36\x19\xdf\x89\x56\x3d\xea\x76\xa0\x70\x27\xce\xc3\xa7\xcd\xfa\x42\xd4\x3d\x27\x32\x9c\x51\xd7\x59\xcf\xfa\x66\xc8\xfb\x78\x53\xda\x37\xbb\xf1\xca\x70\x8b\xbe\x4b\xa1\xdb\x24\x7d\x6f\xa4\x4f\xfb\x46\xba\xbf\xf8\x47\xe0\xf5\xe7\xca\xb6\xd1\x9e\x66\xa9\x44\x07\x17\x34\x08\x32\x22\x88\xac\xf0\xd2\xf3\xb3\x19\x0f\xd4\x2d\x0d\xdd\x83\xda\x09\xd1\x97\x77\x3f\xb3\x29\xdd\xaf\x9d\x79\x03\xaf\xa9\x3b\xd8\x42\x2f\x71\x9a\xd0\x76\xf2\xe6\xd9\x5d\x40\xac\x5f\x1f\xb9\xb9\x3f\xe5\xb4\x43\x8a\xfc\x9a\xd3\xfa\x88\x64\x7a\x58\xb0\xb1\x1a\x5c\xf8\x28\x70\xd7\x9a\xaf\xc8\x03\xb6\x5c\xd2\x66\x4a\xd0\x3b\x6e\x6c\x52\x5d\x37\x1f\x73\x75\xe7\x03\x73\x33\xa6\xe8\xba\xd9\xba\x10\xba\x8c\xff\x4e\x6c\x98\xf3\xd0\x48\x78\x88\xad\x8f\xce\x40\xf6\xe8\x96\xa7\x78\x74\x18\xae\xda\xaf\xca\xee\x40\x9b\x90\x59\xff\x03\x7c\x14\xf5\x74\x60\x0f\x00\x00")
 
 func pkgUiStaticJsBucketJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -500,12 +500,12 @@ func pkgUiStaticJsBucketJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/static/js/bucket.js", size: 2834, mode: os.FileMode(420), modTime: time.Unix(1565577418, 0)}
+	info := bindataFileInfo{name: "pkg/ui/static/js/bucket.js", size: 3936, mode: os.FileMode(420), modTime: time.Unix(1791980151, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _pkgUiStaticJsGraphJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe4\xbd\xeb\x76\xdb\x38\xb2\x28\xfc\xdf\x4f\x81\xb0\xb3\x22\x2a\x96\x28\x3b\x3d\xdd\xdf\xb4\x64\xb9\xbf\x74\xec\x4c\xbc\x77\x2e\x1e\xdb\xe9\xcb\x76\x7b\x7b\x41\x24\x24\x31\xa6\x48\x0e\x00\xd9\x52\x27\x7a\xac\xf3\x02\xe7\xc9\xce\x42\xe1\x42\x80\x84\x2e\x4e\xcf\xcc\x3a\x97\xfc\x90\x43\x5c\x0a\x85\x42\x55\xa1\x50\x28\x16\xef\x31\x45\xe7\xb4\x98\x11\x3e\x25\x73\x86\x86\xf6\xc3\x97\x2f\xe8\xf3\x6a\xb0\x27\x9a\x4c\x28\x2e\xa7\x57\x64\x56\x66\x98\x93\xc1\x1e\x94\x9d\xbd\x3f\xff\x78\x75\x7b\x72\xfa\xd3\x87\x8f\xef\x5f\x9d\xde\xfe\xf2\xf2\xec\x0a\x0d\xd1\x77\x07\x07\x03\xd4\xeb\xa1\x19\x83\x46\x97\xa7\xaf\x3e\xbc\x3f\x41\x43\x74\x78\x70\x70\x30\xd8\xdb\xab\xc0\x47\x7f\x13\x30\xd1\x10\x8d\xe7\x79\xcc\xd3\x22\x0f\x49\x46\x66\x24\xe7\x1d\x54\x94\xe2\x99\x75\xd0\x14\xe7\x49\x46\x5e\x4d\x71\x3e\x21\xfa\xe9\x82\xcc\x8a\x7b\xd2\x46\x9f\xf7\x10\xe2\xd3\x94\x45\x24\x43\x43\xa4\xfa\x0e\x74\x21\x20\xfc\xe6\xea\xdd\x5b\x34\x44\xf9\x3c\xcb\x4c\x85\x82\x8d\x86\x7a\x14\x53\x63\x0f\x86\x86\xce\xd8\xb5\x36\x12\x05\x1b\x75\x89\x0e\x72\x50\x0c\x45\x8f\xb6\xe8\xba\x32\xfd\x69\x1a\xdf\xb1\x29\x7e\xd0\x73\x77\x50\x4b\x30\xc7\x68\x88\xae\x6f\x06\x7b\xba\x28\xcd\x53\x9e\xe2\x2c\xfd\x83\x84\xed\xc1\xde\xca\x43\xc0\x88\xa7\x33\xf2\x1a\xc7\xbc\xa0\x62\x52\x02\x8d\x60\x19\xf4\xd1\xf7\x07\xe8\xb9\xfc\x79\xf1\x17\xf4\x1c\x7d\xfb\xfd\x77\x1d\x51\xf5\xd0\xac\xfa\xff\xa0\x22\xa9\x55\x40\xe1\xb4\x2a\x84\xe7\x19\x3c\xc3\x7f\x59\xd0\x47\x87\x7e\x8c\x18\x27\xe5\xcf\x38\x9b\x13\x81\xd0\xb5\x68\x7c\xc8\x82\x0e\x0a\x0e\x0f\xe4\x9f\x99\xf8\xfd\x0e\x7e\x0f\xe5\x9f\x6f\x0f\xe4\xd3\x54\xfc\xbe\x80\xdf\xef\xe1\xf7\x50\x3e\x1c\x26\x50\x91\x04\x30\xf4\xe1\x03\x3c\xc1\xef\x5f\xe0\xf7\xaf\xf0\x7b\xb8\x84\xf2\x65\xb0\x77\xe3\x43\x2b\x9f\xcf\xe0\x3f\x02\x2b\x1f\x2b\x46\x25\x2d\x78\xc1\x97\x25\xb1\xc8\xde\x5c\x64\xc1\xd5\x8c\x64\x63\x34\x84\x25\x12\xab\x27\x1e\xa3\x34\x71\xa4\xa7\x3e\xe8\xfe\x3e\xac\x6a\xaf\x87\x2e\x09\x47\x09\x19\xe3\x79\xc6\x35\x0f\x46\x1a\x88\x7e\x06\x60\x0a\xec\xa0\x5e\x49\x05\x4b\xde\xa6\x79\x39\xe7\xba\x95\xaf\xea\xcb\x17\xa0\xa8\xe8\x9e\x8e\x51\xe8\xb4\xe3\x78\x84\x86\xc3\x21\x9a\xe7\x09\x19\xa7\x39\x49\x34\x03\x37\x5b\xa1\x43\x60\x61\x85\xfc\x09\xc5\x0f\x52\x1b\xa0\xb8\xc8\x39\x2d\x32\x86\x70\x9e\xc0\x03\x4e\x73\x42\xd1\x98\x16\x33\xf4\x06\xe4\x60\x84\x29\x43\x5c\x69\x8d\x68\x4f\x11\xaf\x92\x40\x39\x64\xab\xc4\x7c\x7a\x4e\xc9\x38\x5d\xb4\xfa\xe8\xfc\xe5\xd5\x9b\xdb\xf3\x8b\xd3\xd7\x67\xbf\x76\x64\xf5\x68\x9e\x66\xc9\xcf\x84\xb2\xb4\xc8\x5b\x7d\xf4\xd3\xc7\xb3\xb7\x27\xb7\x3f\x9f\x5e\x5c\x9e\x7d\x78\xaf\x85\xeb\xd3\xdf\xe7\x84\x2e\x23\xb2\xe0\x24\x4f\x42\xa3\x3f\xec\xd9\xb4\x0d\x1d\x6d\xdd\xf0\x34\x7c\x37\x67\x1c\xc7\x53\x12\x51\x92\x27\x84\x86\x8e\xaa\x33\xba\xa8\x5d\x75\x27\x59\x84\xcb\x52\x8c\xe3\x42\x6b\xeb\x05\xfe\x1b\xe1\x88\x92\x31\xa1\x24\x8f\x09\x43\xbc\x40\x38\xcb\x10\x9f\x12\x94\xe6\x9c\x50\xc2\x78\x9a\x4f\xb4\xc6\x62\x28\xcd\xa1\xae\x22\xaa\xa4\x23\xce\x13\x09\x6e\x94\xe6\x09\x22\xf7\x24\xe7\x4a\xbd\x50\xe0\x17\xa3\x96\x7f\xa1\x02\x1d\xaa\x59\x81\x64\xd1\x38\xcd\x93\x30\xf8\x06\x6a\x6f\x1f\x64\x75\x80\xf6\x35\x43\x55\x53\xf9\x87\xa0\xda\xeb\x82\xce\xd0\xd0\x81\xa5\x20\xc8\xfa\xdb\x71\x41\x67\x81\x99\xdd\xcb\x39\x2f\xba\x94\x30\x21\x1c\x02\x6f\x4e\x16\x1c\x61\x4a\x30\x2a\x72\x24\x39\xaf\xa0\x68\x56\xcc\x19\x89\xb3\x34\xbe\x53\xa8\xca\x1e\x57\x64\xc1\xa1\xad\xa3\xf6\x35\xf3\x01\x77\x8c\xc7\x8c\x70\xd0\xe8\x91\xfc\xff\x1b\x92\x4e\xa6\x1c\x75\x45\x49\x9c\xa5\x24\x57\x25\x03\xe8\xf3\x54\xf4\x8f\x62\xc6\xc2\xd6\x14\x8a\x5b\x1d\xd4\xc2\x73\x5e\xb4\xea\xa5\x24\x8b\x58\x4c\x8b\x2c\x53\x00\xf7\xd5\x58\x5a\x45\x9b\xf5\x5d\x94\xd4\x4f\x0f\xae\xb0\xbf\xce\xf1\x8c\x0c\x45\xbb\x9b\xc0\xe2\x8b\x45\x49\xa3\x3b\xb2\x2c\x29\x61\x2c\xac\xa6\xa7\x67\x17\x17\x39\xe3\x88\x08\x16\x10\x52\xf5\xad\xc4\x5f\x88\x26\x89\x1e\xa6\x69\x3c\x45\xc3\xa1\xaa\x7e\xf6\x0c\x3d\x21\x11\x9b\xa6\x63\xfe\x9f\x64\xa9\x01\xd4\x17\x2d\x62\xf3\xd1\x2c\xe5\x61\x7b\xa0\xaa\x49\x54\x52\x60\x94\x13\xa9\x5e\x74\xcd\x4a\x51\x0a\x36\xa4\xa8\xc8\xc3\xd6\x1d\x59\xce\x4b\xb9\x5a\xad\x0e\x4a\xc8\xa8\x98\xe7\x31\x09\x1b\xfb\x19\xaa\xad\x5b\xb5\xa7\x21\xb4\xea\xf8\x4c\x00\x29\x28\xab\xb6\x4b\xcf\x08\x58\xc1\x43\x94\x75\xe0\x9b\x00\x60\x23\x96\x22\x67\x6f\xcd\x56\xbb\x84\x24\xf3\xf2\x27\x9e\x6b\x49\xa8\x08\xa5\xd8\x19\x1a\xdc\x8e\x78\x6e\xaf\x5a\x8e\x47\x19\x39\x11\x35\xeb\xfa\x01\x99\xe4\x9a\x03\x04\x7b\xd1\x4b\x4c\xc5\x4e\x71\x41\x58\x59\xe4\x8c\x6c\x1a\x5d\x35\xbd\xa5\xaa\x6d\x0d\x91\x1a\xa4\x1d\x90\xa9\x03\xb4\xf1\x82\x8d\xe0\xcc\xde\x22\x36\x00\xb2\x76\x0d\x1b\x86\xd0\x8b\x77\x24\xd9\x34\x27\xd5\xa4\x36\x15\x55\xba\xc3\xc8\xaa\xa5\x3d\x6a\x9a\x33\x42\xf9\x3b\xc2\x69\x1a\xaf\x83\xc0\x48\x46\x62\x05\x42\xb6\xbf\x9d\x41\x07\x87\x04\x64\x4c\x09\x9b\x9e\x09\x89\xba\xc7\xd9\x2e\xb0\x54\x97\x9b\xc0\xe2\x2a\x21\xb6\x45\x46\xae\x60\x2f\xf4\x29\x49\xd5\x20\xa8\x6d\x30\xa2\x03\x5a\xd3\x45\x6a\x66\xa3\xeb\xed\xe1\x38\x1e\x31\x7f\x2f\x7c\x2d\x0c\xc4\x2e\x2f\x26\x93\x8c\x0c\x5b\x1c\x8f\x5a\xf6\x74\x45\xc7\x88\xfc\xa3\xb1\xcf\xb7\xc5\x4f\x18\xb0\x69\xf1\x50\x6f\x5d\xe4\xb2\x3c\x8f\x46\xd0\x34\xe8\xa0\xa6\x84\x0a\xa5\xcc\x31\x9d\x80\x52\x7e\x1a\x92\x48\x3e\x28\x25\xe0\xb1\x17\x64\xbd\xe0\x67\x92\xf3\xb0\x1d\xa5\x79\x42\x16\xa1\xdd\xde\x96\x5f\x5d\x21\xf4\xe0\xd3\x30\xf8\x46\xec\x53\x0a\x02\xe6\x9c\x86\x01\xa6\x29\xee\x6a\x5b\x23\x68\xb7\xa3\x29\x66\xaf\x32\xcc\x58\x18\x50\x92\x15\x38\x09\xda\x35\x05\x29\xd5\x22\x58\x04\xb6\x06\x5c\x99\x2d\xec\x82\xf0\x39\xcd\x91\x30\xd2\x19\x1a\x17\xf1\x9c\xa1\x11\x8e\xef\xc4\x4e\x0d\xca\x3f\xcd\x19\x27\x38\x41\xc5\x18\x49\x58\x62\xc3\x8e\x7c\x0c\x1a\x8d\x60\x69\xee\xc8\x32\x29\x1e\x72\x61\x7e\x52\x80\xed\xa5\x64\xa5\xcc\x60\x4c\x87\x24\x50\x7c\x8f\xb3\xd0\x7d\x6a\xab\x36\x12\xea\x1a\x05\xbf\x6a\x57\xaa\x92\xd2\x62\xcd\xe6\x25\xeb\x82\x76\x34\x4d\x13\x45\x75\xe8\xf2\x80\x69\x2e\xec\x11\x7f\x27\x55\xdb\xec\x06\x8d\x5f\xca\xad\x7c\x3d\x8b\x0b\xa5\x5e\x17\x0c\x2d\x88\x06\x82\xd3\xc5\x6a\xbd\x7c\xb9\x48\xd9\xda\xd6\xcb\x5b\xbc\x48\x99\xd5\x3c\x23\x13\x92\x27\x6b\xd0\x91\x95\xb6\x8e\x2a\xd3\x3c\x27\xeb\x68\xa5\x6a\xed\x7d\xe2\x1e\x67\x97\x1c\xf3\x35\xc2\x09\xf5\xb7\x4c\x34\xb0\xa5\x99\xe4\xc9\x09\xe6\xc4\xdf\xc7\xd2\x83\x24\x4f\x9a\xfa\x57\x75\x16\xe7\x42\x22\x4e\x79\x65\x1a\xdf\x11\x1a\x4a\x66\xca\x8a\x18\x67\xa4\x8f\x5a\x24\x6f\x49\x43\x59\x98\x69\x98\xf7\x51\xeb\xb7\xdf\x7e\xfb\xad\xfb\xee\x5d\xf7\xe4\x04\xbd\x79\xd3\x9f\xcd\x54\x3d\x2f\x8a\x6c\x84\xe9\x79\x86\x63\xb0\x3c\xfb\xa8\x35\x2a\x38\x2f\x74\x3d\x4b\x13\xf2\xd3\xf2\x32\x4d\x48\x1f\x71\x3a\x27\xaa\x74\x5a\x3c\x5c\x15\x09\x5e\xfe\x34\xe7\xbc\xc8\xeb\x55\xaf\x32\x82\x69\xb3\xb0\x60\x0e\x10\x81\xfd\x7f\x15\xb9\x40\xf7\xe3\xd5\x2b\x18\x6f\xd5\xf6\x1e\x4c\x0c\x21\x5c\xa1\xa9\x28\x81\xc3\x96\xf8\xef\x55\x3a\x23\xe7\x40\x8f\x56\x1b\x08\xb4\x0e\x8c\x3c\xbc\xd4\xe0\x08\xc5\x97\x94\xca\xa6\xb0\x65\xb5\x8d\x3e\xfb\x74\x88\xc2\xd6\xb7\xad\x68\xc3\xa4\x09\x62\x5e\x0a\xbc\x2e\x64\x73\x0d\xc4\x28\x11\x76\x69\x36\xc9\x86\xd5\xa5\xa4\xdd\xde\x4b\xa5\x36\x80\x33\x5b\xeb\xb0\x55\xb3\x58\x67\x85\x58\xcf\xad\x4c\x26\x9b\x35\xf9\x4c\x96\xff\x69\x36\xeb\x33\xf6\x7f\x12\xa7\x89\x96\x8c\xe3\x59\x69\x6f\x74\x89\x14\xd6\x9c\x3c\xa0\x93\x06\x53\x99\x1e\xcf\x0f\x0f\x0e\x0e\xda\x15\x7b\x56\x04\x5c\xcb\x9d\xe2\x47\xf2\x22\x22\x19\x23\x4d\x74\xec\xc5\x71\x78\x7f\x07\xe0\xeb\x01\x39\xdc\xaf\x20\x7d\x15\xf3\x6b\xc7\x07\x5f\x66\x04\x38\x57\x5a\x85\x0d\xd6\x15\x8d\xd2\xb8\x30\x16\x63\x65\x43\x4a\x7e\x6c\x45\x93\x6c\x59\x4e\x45\x93\x96\xb5\xf3\xbb\x32\x11\x36\x76\xf4\x0a\x0a\x4e\x12\xb5\xfb\x8f\x78\xde\x2d\x69\x3a\xc3\x74\x19\x98\x23\x90\x00\x6c\xb5\x31\x83\x75\xe3\x29\x89\xef\x6a\xed\x28\xf8\xe9\x1a\x4d\xe7\x39\x34\x26\x89\x6e\xae\xd6\x6c\x1d\x4a\x0e\x98\xc7\x61\xd5\x18\x6a\x33\x66\xce\x24\x56\xda\xf9\xe1\x2c\x4a\x68\x29\x19\x0b\xc7\xda\xf9\x4b\xd3\xd7\x47\x7b\x71\xfa\xac\xb6\xdc\xff\xb8\xfc\xf0\xbe\x5a\x8d\x5e\x0f\x9d\x8d\x2d\x77\xc5\x03\x66\x48\x8d\xd2\x81\xe2\x82\xa6\x93\x34\xc7\x19\x62\x84\xa6\x84\x21\xf0\x69\x4e\x0a\x8e\x66\x73\x8e\x39\x49\x2a\x38\x21\x13\x9a\x25\x69\x83\xfb\xe8\x81\xa0\x9c\x90\x44\x58\x60\x94\xc0\x41\x99\xce\x63\x8e\x52\x2e\xdd\x49\x0e\x64\x81\x11\xc0\x8d\xec\xf5\x50\xce\x53\x69\xdc\x52\x9c\x33\xa1\xa7\x4e\x84\xd0\xd4\xe6\x62\x1f\x89\x1b\x1a\xb6\x41\x8b\x1f\x51\xeb\xa0\x85\xfa\x42\xe9\x6a\x73\xad\x4e\x6d\x03\x48\x2a\x7c\x70\xf7\x85\xf6\x21\xb6\xd7\x43\x70\xbe\xcc\xd2\x18\x0b\xea\x57\x96\x24\x83\xf2\x53\x38\x82\x7a\x77\x02\x77\x2f\xb0\xce\xaa\xde\xfd\xc0\x92\xd1\x93\xea\x2c\xec\x01\x5a\x97\x52\x7d\x72\x5e\x2b\xa3\x0e\xa7\xd8\x48\xdb\xa2\xfa\x18\xf9\x7b\xb4\x0c\x36\xa4\xf0\xb1\x72\xf5\x28\xd9\xaa\x4b\x97\x26\x67\xe8\x71\x37\xac\x93\x2d\xef\x92\x79\x49\xb8\x8e\xcb\xec\x51\x6d\x6e\x3a\x97\x07\x7f\xa4\x0f\xfe\x16\x43\x9d\xbb\x5e\x84\x0d\xac\x65\x33\x56\xcd\xf7\xb0\x8d\xb9\xce\x7d\x4e\x8f\xad\x5b\x41\xd3\x55\xb2\xcb\x96\xe0\x9f\x90\xcd\x76\xff\x0e\x95\xff\x6f\x50\xe0\x4d\xa2\xda\xcc\xe6\x21\xde\x26\xb6\xf3\x2d\xe8\x16\x82\xae\x63\x42\x3f\x5e\xae\x87\xae\xe1\x92\xf4\x63\x55\xb9\x52\x2c\x5a\xea\x43\xbc\xa5\x48\xb5\xff\x64\x73\x2b\x9f\x17\x61\xdd\xf9\x5f\x31\xfb\x18\x67\x8c\x0c\x8c\x4d\x68\x1f\x04\xcd\xf9\xb6\x39\x27\x69\x48\x8f\xc0\x2a\xd5\x3e\xa7\xf8\x16\x9c\x66\x37\x41\xdb\xb3\x0e\xda\x4f\x10\x53\x82\x19\xb9\x50\x08\xda\x83\x6e\x02\x9e\x90\x1d\x80\x27\xc4\x03\x7c\x57\xd4\x49\x9e\xec\x82\xf8\x69\x9e\x3c\x12\xed\x2d\x80\x35\xd2\x16\xe0\x5d\x51\x96\xb6\xeb\x2e\x58\xbf\x83\x96\x8f\x44\x7c\x3b\x78\x8d\xbb\x0b\xde\xeb\x13\xf2\x9c\x08\x6b\x8e\x1e\xe9\x73\x14\x75\x01\x25\xa5\x38\x26\x05\x1d\xf4\x99\x93\x05\xef\x7b\xe0\x81\x36\xee\xa0\x59\x21\xce\x4b\xc1\x88\x8c\x0b\x4a\x82\x55\xc3\x7b\xa4\x9d\x4a\x62\x87\xa0\x04\x9e\xd2\x7c\x52\x49\xaa\xbc\x85\x11\x6a\x49\x2a\x6c\xcf\x61\x51\x7b\x41\x45\x23\x75\x42\x34\x3d\x36\xea\x1b\xd9\x6a\x93\xb4\x19\x77\xaa\xd0\x80\xe2\x08\x73\x42\xd3\xb1\x72\x60\xf5\x7a\xc8\xba\x71\x85\xb5\x42\xd3\x94\xf1\x82\x2e\xd5\xc9\xed\x09\x9c\x43\x2f\x79\x41\xf1\x84\x44\x13\xc2\xcf\x38\x99\x85\x81\x6a\x54\x79\x00\x9d\x66\xac\xde\xac\x03\xb6\x63\xc4\x38\x4d\xf3\x49\x3a\x5e\x86\xd7\x37\x6d\xf7\x88\x54\x16\xe5\x3c\xc3\x9c\x9c\x01\xfd\x85\x66\x94\x6b\xc0\x94\x66\x30\x1b\x93\xe5\xa1\xb3\xe9\xd0\x50\x3d\x2b\xff\x15\x79\x75\xd5\xec\xd2\x63\xdd\x3e\xca\xdc\x0b\x67\x59\x38\xa2\xc5\x03\x23\x54\x74\xb6\xcf\xac\x6d\x41\x1f\x51\x18\xb6\x51\x4f\xc5\x5d\x88\x2e\x4f\x23\xfc\x09\x2f\xc2\xca\x7e\x12\x28\x15\x49\x1f\x05\x7f\x3b\xbd\x0a\x3a\xa6\x78\x4e\x33\xe7\x1a\x16\xed\xa3\xa0\x87\xcb\xb4\x77\x7f\xd8\x83\xb5\xf9\x11\x7e\x87\x1c\x86\xb0\x3a\x0a\x13\xfc\x6a\x59\x0a\x26\xfd\xc4\x8a\xdc\xaa\x01\xfa\xcc\xe3\x98\x30\xd6\xaf\x26\x28\x1a\x75\xe0\xfe\xf0\x92\x63\x3e\x67\xae\x25\x29\x89\x2d\xda\x08\x0b\x9d\xcf\x19\x7a\x32\x1c\xa2\x40\x81\x09\xea\x8d\xab\x25\x98\x16\x0f\xa7\x94\x16\x34\x0c\xe0\x8f\xe4\xa7\x34\x9f\x80\x6f\x20\x72\x0d\x42\xf9\x4f\xf2\xab\x5b\xbe\x72\x9e\xe4\x1a\xd0\x7b\x43\x6d\xc0\x0b\x0e\x21\x94\xb0\x79\xc6\xaf\x0f\x6e\x06\x8d\x1e\x49\x3a\x16\xab\xf6\x0e\xf3\x69\x84\x47\x2c\xb4\x17\xac\x6b\xc1\x93\xbc\xe5\x4e\x1c\xfa\x1e\x0f\xd1\xb7\x07\xcd\x99\x42\x68\x88\x98\xe7\x2f\xd2\x3b\x1b\x36\x66\x84\x50\x70\x94\xa4\xf7\x28\x16\xbb\xe7\xf0\xf7\x00\x67\x84\x72\x04\xbf\x5d\xe5\xd2\xfd\x3d\x38\x3e\x62\x9c\x16\xf9\xe4\x58\x81\x79\x72\xd4\x53\x05\xe8\x84\x70\x12\x73\x92\xa0\x00\xed\x7b\x80\x0b\xe4\x22\x5e\xbc\x4e\x17\x24\x09\x5f\xb4\xbd\x6d\x02\xc4\xc4\x49\x2e\x61\x40\x77\xe8\x22\x6f\xc4\xd1\x88\xf0\x07\x42\x72\xb4\x2c\xe6\x86\x89\xe1\x14\x28\x8e\x79\x92\x2a\x91\x1d\x87\x44\x49\x26\x8e\x92\x45\x8e\x70\x1c\xcf\x29\xe6\x44\x82\x84\x2e\x00\x1b\x44\x67\x06\xb7\xba\x31\x9e\x33\x82\xe6\x39\x59\x94\x72\x06\x52\x9d\xc8\x55\x62\xd1\x51\x2f\x49\xef\x8f\x83\x1a\xbe\xed\x75\x6b\xbf\xaa\x78\x18\xdc\xe7\x7d\xdf\x69\x4a\xfe\xf3\x33\x9f\xb0\x5a\xbc\xbc\x27\xc7\x58\xad\x8b\xea\xa9\x14\xc4\x5a\x95\xb4\x53\x68\x4a\x4d\xe8\xbd\x22\xbf\x49\xe0\x33\x3c\x22\x59\xef\xf6\x56\x6c\x0c\xb7\xb7\xbd\x7b\x08\xeb\x31\x3d\xd7\x49\xfc\xe3\x64\xfd\x11\x72\xbe\x99\xc8\xf8\x1e\xa7\x99\xa0\x10\x92\xb7\x81\xec\x89\x2b\xed\x75\x39\x5f\x55\x62\x57\xe2\x09\x79\x55\xe4\xe3\x74\x12\xe1\x2c\xab\x28\x6c\xe4\x1c\xb6\x55\x5e\x24\x45\x1f\x25\x85\xf1\x57\x00\x3e\x55\x87\x1f\xd1\x07\x8a\x62\x9c\xa3\x94\xa3\x4f\x73\xc6\x51\x96\xde\x13\xc1\xb8\x82\xb3\xc5\x10\x66\xbc\x71\x41\x51\x08\x27\x24\x88\x46\x42\x29\x3a\xf2\xe3\x10\x65\x24\x9f\xf0\xe9\x00\xa5\xfb\xfb\x1e\x5a\xd8\x86\xc2\xf5\xc1\x8d\xf1\x02\xe2\x24\x09\xc5\x8e\xf0\x01\x9e\x43\x2f\xe8\xeb\xf4\xa6\xe3\x1f\xf4\x3a\xbd\x69\xb7\xbd\x74\x82\x41\xc7\xf3\x3f\xfe\x58\x5e\x80\x44\x99\x28\x1e\xf9\x0f\x84\xad\x0f\x61\x6d\x1d\x87\xf0\xa2\x6d\xb3\x7c\x86\xcb\x3e\xfa\xbc\x5a\x3b\x90\xb0\x0a\x04\x7f\xe1\x29\xc1\x32\xdc\xa6\x3a\x9f\x6b\x38\x9b\xe4\xf2\xeb\xd9\x65\xa5\x3d\xc7\x5b\xa4\xd3\xc1\xd0\x96\x48\x40\x16\x50\x91\x71\x1f\xac\x98\xd3\x58\xec\x1c\x40\xa2\x37\xd2\x22\x89\x52\x66\x9f\xbf\xac\xb5\x30\xad\x34\x1b\xc4\x45\x1e\x63\xee\x5f\xc8\x36\xea\xfb\xd7\xd1\x8d\x4d\xe1\x86\x92\x92\x42\x78\xce\x8b\x4b\xb0\x44\xfb\xd2\x56\x53\x7e\x75\xc0\xb4\xaf\xfe\xca\xb2\x94\x93\x19\xeb\x83\x31\x21\x0b\x66\x98\xc7\x53\x62\xd1\x1d\x85\xa2\x4d\xdd\x53\xf8\x40\xd0\x14\xdf\x13\xc5\x00\xc0\xf5\xf1\x9c\x52\x92\x73\x49\x87\x0e\x62\x77\x69\x59\x77\x31\x59\xfc\x25\x09\x01\x2a\x01\x76\x3d\x78\x6c\x2c\x71\xb3\x83\xdd\x7c\xb0\xbe\xf1\x0c\x97\x82\x83\x57\x1b\x9a\x50\xcd\xe7\x50\x18\x8d\xd3\x8c\x13\x1a\x56\xd0\x23\x65\xc1\x87\x3d\xd4\x9b\x74\x50\x10\xb4\x3b\x6a\x83\x96\xf4\x73\xe4\xa3\xa4\x42\x57\xea\x7d\xd7\xb1\x90\xca\x82\x71\x51\xa7\xf7\xe0\x6a\x8f\x5a\xb5\xb7\xa2\x17\x8d\x0b\x7a\x8a\xe3\x69\x65\x9e\x53\x8f\xb2\xa8\xcd\xfc\x9a\x46\xda\xa9\x7a\x83\x86\x88\x0e\x3c\x23\x1a\x89\x54\x36\xbd\x58\x64\x94\xe6\x5e\x78\x3a\x2c\x68\x4f\xb1\x11\xe5\x4d\x06\xb1\x14\x3f\x3c\x46\xa2\x59\x85\x35\xee\x8c\x6c\xbc\xb5\x82\xf4\x62\x3f\xba\x89\x58\x5c\x50\x69\x4a\x79\xea\xb1\xaa\xaf\xa6\xa5\xe7\x00\x9e\xad\x03\xf4\x23\xc2\x91\xbc\xdf\x7a\x55\xcc\x4a\x4c\x49\x38\x12\x92\x94\x9a\xb9\x1b\x2a\x58\x93\x67\x6e\x40\x01\x30\xfa\xd5\x34\x65\xb0\x1f\x40\xb0\xdf\x14\xa2\x03\x11\x1e\x73\x61\xd6\x70\x8e\xe3\x29\x58\x00\x53\x82\x8c\x04\xa2\x32\x9b\x4f\xd2\xbc\x83\x30\x43\x29\x97\x50\x0a\x3e\x25\xf4\x21\x65\x04\x8d\x28\xc1\x77\xac\xd6\x43\xd3\x08\x67\x29\x5f\x46\x7b\x6b\xc2\x09\x1c\xed\x32\x4a\xf3\x44\xfd\xff\xf4\x9e\xe4\x9c\x69\x15\xba\xda\xa8\xd3\x26\x84\x7f\x30\x31\x9a\xdb\x4d\x8c\x5a\x4c\xe7\x6a\xe0\x06\x7a\x42\x10\x92\x8e\x04\x46\x28\xb0\x82\x8d\x14\xff\x07\xe6\xb6\x56\x17\x30\x4e\x4a\xb7\x24\x29\x1e\x72\x86\x67\x65\x46\xea\x2d\xc1\x53\xaf\x1f\xed\xcb\x2f\x21\x40\x37\xeb\x3d\x0c\xb2\x4d\x3b\x22\x8e\xd8\x40\xb0\x4a\x47\x07\x67\xda\x47\x30\x61\x01\x55\x81\xe6\x91\x78\xb4\x22\x57\xa2\x34\x7f\x49\x29\x5e\x86\xa2\xbc\xe3\x4c\xbd\x2d\xcc\x78\xcb\x8a\x87\x88\x3f\x05\x05\xec\x29\xb5\xc5\xa3\x63\xe4\xd8\xfa\x8a\xa6\x70\x26\xbf\xb1\x46\x86\x3e\xb6\x9b\xda\xe6\x46\x73\x01\x2e\x83\x18\x6b\x67\x55\xbb\x85\x0c\xd6\xa9\xc7\xef\xc8\x23\x3f\x70\xbb\x89\x8f\xdf\x66\xa0\x62\xca\xc8\x89\xb0\xcb\xd3\xc2\x71\x04\xc3\x4a\x5f\x91\x05\xaf\x58\x07\x8a\x2e\x4e\xd5\x71\xf5\x82\x4c\x4e\x17\x65\x18\xfc\x77\x78\x7d\xd0\xfd\xe1\x66\xbf\x1d\x5e\x2f\x1f\x92\xe9\x8c\xdd\xec\xb7\x9f\xca\xbd\x58\x74\x92\x7b\x8d\x60\x21\x03\x31\x82\xb2\x50\x81\x33\x17\xbc\x4f\x54\xd3\x36\xfa\xac\x8d\x3d\xa0\x8d\xa8\x53\x55\x9a\xd8\x4f\x86\xe8\xdb\x9a\x2b\xfc\xfb\x03\xed\x0b\x10\xa3\x02\x99\xd1\x10\xc1\xf4\xce\x72\xae\x01\x5c\x1f\xde\x18\xcc\xe6\x79\x2a\x76\x06\x5d\xf3\xe2\xc6\x22\x9f\xec\xff\xbc\x19\x12\x6e\x05\xec\x5f\x0b\x00\x37\x3b\x18\x19\x96\xb3\x6f\x67\x99\x04\xe2\x5c\xaa\x33\x58\xe5\x83\xaf\xd6\x4a\x6e\xb6\x55\xa4\xa0\x15\x71\xe4\x33\x4f\x37\xc4\xf9\xfb\x8c\x54\x41\x73\x07\x85\x23\x1f\x0a\x1b\x80\x82\x11\xea\x5e\xba\xd6\x70\xdd\xd2\xb9\x71\x75\xd5\x74\xda\xa0\x0d\x3e\xe3\xea\x1c\x68\x9f\x1b\x56\xbb\x38\x75\x1c\xef\xec\xbf\x7f\xc1\xb6\xaf\x14\xea\xa2\x43\xb1\xaa\xc7\x72\x75\xbb\xdd\xb5\xab\x76\xfc\xff\xce\xaa\x4d\x08\x3f\x35\xf1\x5a\xdb\x97\x0c\x14\x8e\x13\xe5\xf5\xe5\x0b\x72\x0a\x5c\xac\xa9\x8e\x3a\x54\x0e\x64\xa5\x6b\xdc\x0b\xde\xed\x71\x4e\xdb\xcf\x24\x62\xff\xa6\x97\x8f\x9b\x8c\x15\xfc\x22\xaf\x60\x4c\x77\x2b\xe6\x8f\x55\x85\x26\x9e\x45\xa1\x9f\xc0\x7b\x61\x5b\x10\x63\x5e\x9c\x00\xd4\xc6\x57\x6b\x76\x21\x8b\x42\x68\x47\x4d\x7a\x9a\x7b\xee\x44\xd7\x90\x25\x27\x0f\x0a\x65\xb5\x74\x9a\x40\x36\x91\x95\x18\xaa\xb6\x70\xfc\xde\x59\x7e\x51\x0f\xbd\xe8\xa0\x96\x72\x97\xb5\xbc\xf4\x56\x80\xad\x3a\x97\xf5\x77\x54\x48\xff\xea\x79\xb3\xf9\x88\x53\x1c\xf3\xff\xad\x26\x3f\x21\xfc\x9d\x8e\x90\x7b\x8c\x58\xab\xb0\x3a\x23\xd5\x2a\x7e\xea\xb1\x42\xbd\x43\x00\xd7\xee\x32\xfd\x88\x89\x78\x44\xfa\x9d\x85\xa6\x26\xb2\x2a\xfb\x5a\x81\x6e\x22\xb4\x5d\x9e\x77\x8f\x97\xdb\x51\x9c\x1f\x49\x95\xcd\x9c\xad\x89\xd4\x10\xe8\xc3\x83\x75\x8c\xaa\xba\xfc\x93\x84\xf4\x5f\x3f\x1b\x23\xa6\xff\xea\x29\x59\xad\x77\x7f\x93\x32\xce\x08\xa6\xd2\x63\xd7\x76\x0b\xf5\x7d\x47\xbb\xb6\xff\x36\x2c\x84\x6a\xef\x5f\xed\xd5\x2f\xfa\xd9\xb4\x78\x08\x3d\x81\xdd\x11\x99\x95\x7c\x19\xda\xc1\x8e\x98\xf2\x0d\xb7\x6b\xff\x0c\xbb\x4d\xbd\x1a\x57\x64\x73\x75\x7a\x32\xc7\x8d\xf5\x87\x56\xfd\xea\x8c\x3e\x23\xdf\x04\x6d\x3d\xfb\x2f\x5f\xe4\x6d\xd3\x0c\x2f\x42\xf8\xcf\x38\x2b\x0a\xea\x5a\x74\x3d\xf4\xe2\xbb\x83\x76\x07\x1d\x5a\x07\xac\xc5\x25\x38\xab\x2e\x6c\x4c\xbc\x08\xd8\x6f\xcb\xcc\xf0\xe2\x56\x3a\xb9\x6e\xab\x29\xd4\x50\xd2\x43\x54\x81\xf0\x0d\xf3\xc2\xba\xa4\x04\x64\x34\xf7\xd7\x95\x96\x69\x67\xdf\xc3\x02\x7e\xbf\x4e\xa9\x73\x0b\xab\x0b\x23\x3c\x2a\xa8\xa5\x95\xe1\xc8\x46\x33\x13\xcb\x24\xaf\x18\xf4\x63\x89\x29\x9e\x55\xaf\xa5\x06\x00\x25\xe8\xd7\xcf\xd0\x26\xf4\x5a\xb6\x97\x61\x60\x68\xb8\x26\x3a\x0e\xfd\x88\x5a\x9c\xce\x09\x04\xd6\x80\x93\x55\x8a\x99\xea\x5c\x7f\x85\xcb\x82\xb3\x29\x4e\xa7\x09\x71\xd3\x4b\xbe\xc6\xab\xa0\x06\x05\xbe\x46\x43\xb3\x26\x5d\x87\x83\x07\x76\x53\xf9\x42\x85\x6a\x38\x70\x81\x10\x31\xeb\x6a\xe1\x9d\x5a\x2f\x6b\xc0\x49\xb9\xc1\x69\xb2\xdf\x9c\x66\xe2\xe0\xb2\xe1\xd6\x59\xc6\xc4\x04\x2a\xea\x41\x2e\x9d\xad\x53\x3c\x37\x4c\x76\x9c\x10\x68\x26\x4d\xcc\x66\xe3\x81\x8c\xc4\x72\xc2\xbe\xd4\x5c\xb8\xd4\x00\x92\x2f\x77\xc5\xf6\xab\xf1\x7c\x25\x63\xa4\xb6\x63\x6a\x45\x86\x49\xb6\x95\xff\xa9\x39\xbc\x7e\x9d\x52\x34\x5c\x77\x0b\x58\x93\x70\xf9\xbe\x95\xac\x0c\xda\xce\xed\xe0\x9c\x66\xdb\xee\xfc\x44\x79\x5f\x21\xf1\xef\xbe\x07\x84\x5e\x70\x11\xb4\xe5\xbe\xaf\x31\x94\xba\x0c\x67\x6b\xc0\xeb\x0d\xc7\x6d\xeb\xbd\x19\x73\x3c\xaf\x72\x2d\xd5\x93\x7b\x71\x65\xa8\x12\x9a\xbb\x45\x77\x7d\xb7\x5d\x70\x2d\xa6\xb4\x03\xa1\x9f\x75\xda\x89\x32\xf4\x64\x88\x02\x50\x7b\x35\x8a\x81\x12\xa6\xd4\x26\x8f\xe8\xb3\x98\xd2\x48\x2b\x1f\x08\xac\x7e\xe2\xcb\x0a\xa0\xff\x11\x2a\xb8\xa9\xde\x47\x52\xde\x86\xec\x09\xd5\xb5\x3b\xcb\xf5\xbd\x22\x0b\xee\x74\xda\x7a\xcf\x4b\x16\x24\x9e\xc3\xcb\xf3\xea\x9e\x31\x40\xfb\x02\x6c\xe3\x5a\xdd\xa2\x5e\x5c\xcc\xca\x8c\x70\xb2\x33\x01\x87\x6b\x08\xb8\x9e\x99\xc0\xd0\xae\xfc\x9f\xde\x40\x9c\x6e\x65\x4d\x0c\x9c\x8e\xbc\xe0\x38\x13\xc5\x97\x32\x60\x1e\x72\x53\x6c\x5a\x21\x19\xe9\xbe\x61\x99\xd6\x76\x52\x77\x45\x42\x78\x61\x5f\x08\x58\x8c\x33\x4c\x1b\xa1\x34\x4d\x94\x0e\x3d\x8b\x9b\x8e\x37\x8e\x02\x18\xe6\xf3\x2c\xdb\x0e\x7d\x13\x18\xed\x59\xf4\xf2\xc9\xca\xf5\x06\x55\x96\xdc\x94\xcf\xb2\x30\x78\x5b\x60\x19\x20\x22\x19\xc5\x2c\xd1\x3e\x0a\x66\x0c\x1d\x8d\x28\xea\x1d\xa3\x6a\x23\x92\xad\xac\xed\x6a\x1f\x05\xba\x99\xa8\x09\xae\x04\xe6\x32\xe2\x44\xbe\xdd\x20\x7b\xd4\x26\x54\xbf\xac\xab\xc7\x97\x56\xa8\xef\x70\xbd\x6c\x44\xc0\xde\x41\x66\x6c\xb2\xc5\x5f\x22\x7a\x44\x42\xa7\x40\xdb\x5a\xb9\xb6\x7f\xb7\x05\xa6\x19\x2b\x7c\x77\xcb\xdd\x1a\xb8\xd5\xaa\x8f\xab\x09\xb0\xc3\x94\x7f\x31\xef\xa4\xee\x3e\x69\xa5\x9d\xe5\xda\x3b\xd3\xd6\x35\x8f\x99\xb8\x07\x83\x47\x0c\x6f\x4f\xde\x54\xec\x36\x7d\xe7\xad\xc1\x1d\x86\xb7\x0d\x3f\xc1\x9a\xc5\x9c\x9f\x9d\x68\x99\x7b\x48\xf3\xa4\x78\x90\x33\xba\x92\x95\xf5\x96\xe6\x8c\x94\xd6\xde\x93\xf7\x9d\x60\x6a\xaf\x3e\x56\xc7\x18\x38\x8b\x69\x08\xee\xed\x8b\x79\xe3\x5c\x0f\x89\x86\x1a\x2f\x26\xd5\xa3\xc0\xca\x1f\x56\xea\xf1\xef\x42\x79\xfd\xd5\xca\x3d\xc8\x46\x61\x66\xf0\x5c\xa5\x9d\xda\x4e\x6d\x99\xf3\xe5\x2d\x1e\x91\xcc\x31\xd2\x20\xec\x89\x55\x24\x87\xe7\x4b\x88\x1b\x65\x2a\x45\x93\xe5\x73\x87\x5a\x94\xe6\xc8\xee\x26\x89\x22\xab\xc4\xa6\xac\x63\xa8\x2c\x75\x6b\x43\x8d\xca\x39\x9b\x86\x55\x68\x00\xda\x57\x60\xf7\xad\x98\x00\xb5\xe3\xb1\x18\x97\xe4\xcd\xd5\xbb\xb7\x0a\xcf\x6b\xf8\x63\x42\x76\x56\xae\x13\x2a\xd3\xb3\x73\x43\x02\x65\xf1\xef\x41\x35\x94\xc6\xe4\x53\x91\xe6\x61\x70\x34\xa2\xc7\x41\x5b\x0e\x0f\x31\x73\x5b\x89\x29\xa3\x68\xae\x8a\x2b\xf6\x5e\xde\x95\xae\x25\x27\xd7\x2d\x54\x4d\xa4\x89\x23\x0e\xb0\xad\x16\x8c\xfa\x39\x18\x6c\x22\xfe\x56\xea\x6f\x27\xbf\x87\xfe\x86\xe4\xc3\xdf\x03\x43\x17\x4d\x5f\x51\xfe\x7b\x60\x62\x84\x60\xf7\x11\x3f\x6a\x36\xfb\x43\x1f\x19\x3b\x92\x86\xab\xc0\xf2\xad\xc9\x0e\xbb\x5d\xac\xfe\xac\xae\x21\x0d\x2d\xe1\x5e\xb1\x22\xa5\x94\x58\x68\xfa\x3a\x2b\x30\x57\xf5\x5a\x28\x53\xf6\x1e\xbf\x17\x65\xc6\x35\xd2\xeb\xa1\x60\xff\x2c\x1f\x07\x1d\x14\x74\xd5\x5f\x78\x46\x0f\x69\x96\xa1\x11\x91\xc0\x12\x21\x4e\x05\x7a\x8f\xdf\xa3\xd1\xd2\x86\xdf\x8e\xd0\xd5\x94\x68\x50\x31\xce\x5b\x5c\x74\x82\x68\x72\x92\x74\x10\x2b\xe0\xcd\x5c\xc4\xa7\x64\x86\x30\x43\x13\x5c\x32\x14\x82\x25\x10\xd9\x2e\x53\x9d\xfa\x6c\xe5\x5c\x99\x6e\x25\x8a\xf3\x6e\x5f\xfd\x5c\xb5\xd1\x4f\x56\xe2\x8c\x70\xf3\x7a\xef\x85\xca\xc4\x16\xbd\x2a\xb2\x82\x46\xe7\xb2\xb2\xf2\x2c\x81\x71\x6e\x19\x4c\x82\x87\x66\x98\xd3\x74\x11\xb8\x2a\xaa\x32\x52\x55\x44\x5c\xca\x50\x5e\x70\x54\x8c\x91\x6c\x0f\x31\x1c\x4f\xd0\x79\x46\x30\x23\x2a\xc1\x0f\x46\x71\x41\x29\x89\x39\x24\x9c\x20\x8c\xa5\x45\x6e\xc2\x43\x15\x35\x24\x9f\xaf\x2a\x4f\x2e\xd6\xf1\x88\xd4\x04\xb9\x54\x7a\x93\xb3\x7a\xb0\xc2\xc0\x3c\x49\x2e\xae\xa2\x15\x38\x53\xb2\x0a\x66\xa0\x6b\xa4\xa9\x30\x07\x6d\x1b\x0e\x6c\x55\xc5\xac\x58\xa9\x9a\x89\xaf\xa3\x23\x2a\xd5\x24\x3d\xbd\x8e\x4a\xa8\x06\xae\xa2\x0b\x0d\x60\x53\x57\x29\x31\x43\x0a\x7b\x94\x3e\xfc\x76\x9c\xee\x7d\xf5\xd7\x3d\x8b\x72\x26\x63\x25\x98\x4b\x29\x4b\x80\xe4\xbf\xda\x20\xe2\xdf\xa2\x2f\xef\xef\xaf\x0f\x6e\xec\x18\xad\x65\xdf\xda\x1b\x41\x32\x25\xb4\xeb\xc3\x9b\x76\x65\x95\x56\xf1\x43\xd5\x21\x24\x13\x47\x38\xc5\x81\x11\x3c\x86\xb2\x87\x3c\xcc\x03\x39\xc0\xec\x6d\x04\x72\x31\x4b\x70\x65\x0c\x30\xac\x18\x03\x05\x88\xb3\x0c\xcd\x52\xc6\x84\xa9\xc2\x38\x29\x59\x54\xb1\x00\x79\x30\x16\xb6\x52\x99\x52\x0c\x0a\xeb\x90\x61\x94\x28\xb7\xb6\x7d\xe3\x23\x1a\x20\x8e\x8e\xdc\x72\x92\x27\xa2\x74\xbf\xde\x9a\x94\x4e\xe8\xdf\xcb\x2c\x2b\x1e\x00\xfa\x58\x28\x0d\x81\x5e\x59\xa4\x39\x47\x69\x2e\x63\xb8\xe3\x65\x64\xdf\xf3\x4a\x93\xdf\xc4\xc1\x08\x1c\x9f\x3d\x43\xb2\xf8\xba\x2c\xd8\x4d\xb4\x40\x47\x62\xdc\xc6\xb0\xd2\x2b\x68\x2f\xa7\x99\xb8\x54\xe9\x16\x10\xcb\x34\x2f\x0b\xc8\xc8\xa7\x16\xaa\x7e\x5c\xad\x81\xf8\xbc\xe8\x23\xde\x41\x2a\xb0\x75\xd5\x6e\x06\xdf\x20\x64\xd2\x37\x9a\xbe\xd5\xc2\x56\x57\x2a\x78\x47\xfb\xaf\x91\x1b\x73\xe3\x75\x15\xd0\xc4\xa6\xa0\xf6\xfa\xb9\x66\x18\x64\xbe\x81\xcc\x95\x38\x5f\x22\x4e\x71\x4c\x98\x50\x53\x38\x47\x64\x91\xca\xac\x74\xa0\xc6\x23\x37\xa5\x4a\xe5\x18\xb7\x86\xab\xf2\xb1\xc4\xd3\x34\x4b\x28\xc9\xc3\xb6\x27\x90\xa9\x6a\x5b\x7b\x47\x08\x2a\x20\xc3\x8b\x53\xb1\xaa\xa7\x8a\x79\x1a\xb6\x2c\xb3\x25\x90\x39\x62\x8e\xa5\x49\x62\x59\xd9\x3a\x57\x4c\xad\xb9\x4a\x12\xd3\x6c\x5f\xa1\xdf\xc8\xe5\xb7\xad\x11\x0c\x55\xdd\x12\x90\x3c\x51\x77\x04\x6b\x3d\xdb\x82\xf2\xaf\x8a\xfc\x5e\xc8\x2e\x2f\xd0\xc7\xf7\x67\xbf\x22\x93\x4c\x42\xe7\xf2\xb3\x3c\x08\xbb\x5f\x9e\x7e\xf9\x82\xbe\xfd\x5e\x8d\x70\x38\xd5\x69\x25\x23\xcf\x05\x86\x46\xb3\x6b\x06\x32\xd3\xdc\xae\x77\xce\x71\x02\x11\xd3\xea\xa5\xfe\x87\x94\x4f\x51\x9a\xdf\xa7\x2c\x1d\x65\x04\x05\x42\x2a\x02\xa9\x30\x19\xc2\x1c\x02\x17\x63\x88\x45\x9e\x53\x92\xa0\x45\x57\x2c\x02\x1a\x15\xf3\x3c\xc1\x00\x80\xe4\x6c\x4e\x09\xd3\xe0\xf9\x14\x73\xc9\x79\x0c\x61\x4a\x50\x92\xb2\x32\xc3\x4b\x92\xc8\x91\x30\x1a\xa7\x8b\x0a\x0e\x50\xc1\xc9\xd1\x94\xe3\xb2\x84\x10\xcb\x02\x86\x36\x71\xdd\x06\xbe\x98\xb8\xee\x06\x4d\xaa\xf4\x01\x95\xfa\xb9\x3e\x10\x5a\xe6\xb8\xa2\x9a\x15\xc6\x22\x69\x34\xcf\x21\x25\x1f\xe8\x03\xd3\xaa\xa1\x17\x56\x75\xb8\xae\x76\xeb\xa2\x43\xa9\xcd\xd4\x8a\x34\x46\x31\x2a\x47\x35\xf0\x0e\x50\xbd\x0c\xfe\xbe\x78\x40\x31\x25\xf0\x56\xcc\x94\x80\x6d\xe3\x0a\x71\x23\xe7\xac\x6d\xfd\xc8\x6c\x05\x12\x03\x15\x60\xd8\xb7\x98\xdf\xec\x7f\x32\x8b\x62\xbf\xba\x5d\xb2\x04\x1b\xfc\x1b\x32\xa9\x62\xd8\xee\x80\x3a\xee\xa8\xe3\x67\xc2\xa7\x1b\xfa\xfc\x22\xea\xc1\x39\xf6\xd7\x83\x0e\x7a\x61\xfa\xc9\x53\x19\xa1\x7d\x4f\x72\x8a\x1f\x55\xcc\x67\x80\xfa\x28\xc8\xd2\x9c\x68\x4f\x35\x9c\xfe\xca\x22\xc3\xca\x97\x23\xea\x30\x55\xee\x69\xed\xaf\x31\xfc\xae\xa2\xd8\x53\xd1\x12\xcf\x79\x11\x74\x1c\xa2\xbe\x4e\xf3\x04\xde\x2f\x62\x44\x71\x66\x8b\xa1\x19\x5e\xf4\x66\x69\xbe\xb7\x26\x6d\x86\x50\xba\x9c\x56\xa6\x45\xaf\x87\x7e\x99\x92\x5c\xe7\xc7\x10\x76\xa1\xcc\xdd\x96\x98\xbd\x78\x86\x17\xd5\x5e\xbc\x41\x16\x79\xe5\x5d\x72\x72\x38\xc4\x73\x4a\x65\xf9\x3b\x1b\x92\x4c\x83\xa3\x76\x30\x3f\x44\x51\x7a\x2e\x76\xe4\xba\x0f\xd4\x54\x44\x4b\x74\x5c\x1b\xe0\xd9\x33\x64\x57\x3f\xf1\x39\xf8\xea\x28\x59\x1d\x3c\x5e\x5a\xb3\x95\x0a\x4a\xec\x0f\xdd\xde\x8a\xdb\xed\x0d\xc3\xe1\xe5\x48\x92\x6f\x86\x17\xcf\x0f\xa3\x83\xef\xd6\x37\x4b\x73\x4d\x1b\x67\xa7\x87\x15\x80\xba\xb3\x7c\x9c\xe6\x29\x5f\x0e\x6a\x2b\xd3\x75\x2b\x1e\xb9\x42\xff\x9c\x45\x38\x02\x1c\x77\x21\xbd\x9c\xcb\x46\x82\xfb\xd6\x78\xb6\xe3\xca\xce\x76\x5f\xcf\x95\x95\xc7\x01\xb0\x1a\xc2\x32\xd5\xe3\x02\xfd\x8b\x89\xf6\x2b\x7f\xf3\xda\xd5\x14\xbf\x5d\xdd\xce\x97\x9f\x67\x3d\xf0\xf0\x20\x3a\x7c\x1e\x9a\x97\x32\x45\x61\x57\xc0\x6b\x57\x87\x92\x2d\xc3\x6e\x85\xb0\xd2\x4e\x35\xc1\x4a\x0b\x65\x9a\x34\xf5\x6e\x04\xe6\x0f\xdc\x10\x7c\x96\x5a\xa6\xef\x53\xd9\xd6\xfb\xdb\xcb\x2d\xb0\x7e\x53\xaa\x7c\x2d\x30\xa9\xf7\x0a\x9a\x92\x9c\x1b\x4d\x49\xc6\x3a\x9e\x9e\xa7\xf1\xdd\x6b\x95\xe0\x0b\x5e\x62\x91\xd9\xbe\xfe\xf3\xdd\x4f\x57\x1d\xcf\x1e\x01\xe8\xa8\x3d\xc2\x7e\xc9\xdb\x25\x9d\x4a\xac\x5c\xcd\x62\x5a\xdc\x13\x7a\x42\x38\x4e\x33\xff\x5c\xde\x54\x0d\x76\x9b\x90\x44\xd3\x79\xdf\x24\x94\x3a\xbf\x83\x16\x1d\xb4\x74\xd5\xa6\x8a\x8a\x6a\x1d\xb1\x12\xe7\xda\x54\x14\x85\xc1\x71\x0b\xed\x57\x17\x38\x0b\xf4\x1c\x0c\xb8\x76\xc4\x8b\x8f\x57\xaf\xa4\x63\x27\x6c\xa3\x7d\xd4\x3a\xea\x89\xbe\xc7\xad\x81\x05\x96\x3d\x60\x1e\x4f\x9b\x80\x61\x1e\xb7\xb2\x36\x90\x49\x35\x86\xc1\x08\xc7\x77\x13\x2a\x4c\xa2\xae\x3a\x1d\xb6\xe0\x74\x03\xea\x02\x4a\xc4\x30\xc2\x72\x6d\x0e\x14\x17\x39\x57\x31\x12\x72\xc8\x7d\xa4\x66\x1b\xf9\xfc\x69\x60\x98\x49\xa7\x5a\x1f\xd9\x0e\xc6\xa5\x9a\x89\x2c\x31\x43\x58\x11\x60\xd0\x60\x44\x81\x2c\x7a\x54\xab\x48\x79\x85\x2b\x1f\xaa\x8b\x46\xd3\x5e\x01\x6f\x84\xce\xd3\xe8\x59\xf8\xb7\x50\xe7\xb5\x47\x64\x37\x63\x90\x6c\x64\x08\x6b\xb4\x69\x3a\x99\x66\xc2\x34\x81\x5c\x8f\x9e\x21\x7f\x22\x53\x7c\x9f\x16\x34\x52\xaa\xfa\x8d\xee\x10\xa2\x9d\x58\x4f\xe2\xd5\x57\x7f\xdd\xc1\xd9\x94\x64\xf7\xf2\x1a\x61\x87\x91\xaf\xc0\x3a\xd8\x8d\xe1\xd7\x8d\x6a\x47\x12\x98\xcc\x28\x5b\x9d\xe0\x2c\xfd\xe3\x6b\x8e\x9c\xae\x9a\xaa\x5f\xf8\x79\x34\x81\x39\x14\x98\x50\x84\xaf\x35\x11\x37\x58\x05\x95\xba\xd9\x21\xe6\xdb\x13\x1c\xb2\x25\x58\xc3\x4f\x13\x71\xb6\x56\x58\xa8\x54\x67\x0c\x95\x98\x31\x78\x47\xb8\xca\x84\x36\x2e\xa8\xb1\x07\xe5\x81\x07\x1c\xa6\x56\xfa\x33\x86\xef\xc9\x9e\x3a\x15\x59\x49\xcf\x5e\xfe\xc7\xcb\x5f\x4d\xbe\x27\x71\x8a\x29\x68\x42\xa8\xcc\x97\xd6\x35\x3e\x51\x94\x72\xe9\xb6\xb5\xc6\x94\xc0\x1e\x84\x25\x2a\x20\xce\x19\xa1\xe2\x80\x25\xce\x47\xf2\x15\x33\xc0\xc7\x4e\x70\x6b\x72\xa5\x29\x7f\xa3\x73\x50\xf4\xe7\x58\x03\xe7\xeb\x56\x77\x84\xd7\x6b\xfa\xbe\x00\x34\xc1\x3d\xc4\xd0\x58\x68\xc4\x9a\x27\xb4\xe9\x17\xb8\xc2\x23\x37\xc3\x92\x9d\x1e\xc8\xba\x21\x32\xb9\xd8\x76\xe2\x82\x5a\xe8\x4d\x2d\xa6\x15\xef\xc4\x07\x32\x56\xb1\xca\x73\xb4\x19\x4b\x9b\xd2\xd2\x1f\xae\x2f\x48\x7e\x2a\x92\xa5\x26\xb5\x05\xce\x4d\x39\x7d\x0b\xe9\x00\x10\x1f\x15\x89\x4a\x36\x08\xfd\x9c\x40\x46\xf6\x90\xf2\x78\x1a\xd6\xee\xff\xd5\x9b\xc9\x98\x11\x14\xdc\x93\x98\x17\x34\xe8\xef\xd9\xe6\xa1\x79\xed\x55\x09\xb5\xd8\x48\x9a\xd7\xf2\xee\xca\xea\xe1\x95\xb3\x24\x38\xe2\xf4\xf8\x88\x27\x28\x2e\x32\xb1\x87\x0d\x5b\x2f\x5a\xc7\x47\xe9\x71\x2e\x17\xfc\xa8\x97\x1e\x1f\xf5\x78\x22\x7e\xe8\x71\x50\x7b\xd7\xd2\x3e\x4a\x3b\xf8\xa8\xcd\x20\x9d\xa5\x5c\xaa\x4d\x1b\xdd\x76\xcd\xab\x69\xbd\xbd\xe4\x89\x29\x70\x5f\xa9\x87\xc5\x54\x06\xae\x4e\xea\x91\xde\xd8\xdb\xae\xb9\xb5\xf2\xb9\xb6\x8d\x67\x7b\xb0\x89\x16\xc7\xb5\xfb\x3b\x09\x52\xdd\xb2\x09\x5a\xa8\x26\xca\x73\x7d\x7d\x78\x53\x55\xd9\x64\x92\x84\x81\xf7\x44\x07\x66\x21\xd5\xf5\x84\x77\x21\xff\x2f\x5d\xb0\xfb\xaf\x5f\xb0\xfb\xfa\x82\x99\x97\xfe\xae\xc8\x42\xe0\x1d\x98\xcb\x0f\x83\xde\x27\x89\xde\x27\x74\x84\xee\xf5\xdd\x82\xc6\xed\x93\x9b\x9f\xa1\x82\xb4\x3f\x34\x8d\xaf\x3f\xdd\xa8\x25\x45\xff\xbf\x58\x66\xbb\xfc\x40\x2e\xf5\x88\xf6\x8e\x03\xd7\xc1\xfc\x27\x79\xc9\xc2\x64\x67\x56\x52\xb7\x3f\x92\x95\xfc\xa3\xcb\x26\xce\x48\xf6\x4a\xac\xe3\xdc\xfa\x40\x60\x53\x6f\x1e\x08\x9a\x38\x03\x59\xb3\x76\xc7\x6c\x6f\x19\x54\x39\x48\xfb\xde\x9d\xe8\x63\xce\xe6\x65\x59\x50\x4e\x12\xf5\xf6\x26\xdc\xdc\x35\x80\xac\xbe\xda\xce\xf2\x7f\x36\xca\x97\xb6\xa5\xfe\x6d\x19\xc7\x49\x6e\x0d\x7e\xe1\x2f\xde\x19\xa7\xea\x7c\x67\xe3\xb5\xac\x10\xc3\x23\x76\xbb\xb4\x93\x18\x2d\xcd\x3e\x2f\xab\x8e\x87\xe8\x90\xbc\xf8\x4b\xed\x85\x98\x70\x89\x7a\xb2\x3c\xe2\x85\x75\x70\x0a\x7e\x0b\x06\x6e\x16\x64\x1b\xca\xe1\x1a\x28\x87\x75\x28\xff\xb5\x01\xca\xe1\x5f\xfd\x50\x0e\xff\x5a\x87\x72\xba\x09\xca\x77\x6b\xa0\x7c\x57\x87\x72\xbe\x09\xca\x8b\x35\x50\x5e\xd4\xa1\x5c\x6d\x80\xf2\x83\x1f\xc8\x0f\x75\x18\x7f\xdb\x00\xe3\x7b\x3f\x8c\xef\xeb\x30\xde\x6d\x80\x51\x7f\x61\x5a\xc1\xf8\xb6\x0e\xe3\x6e\x3d\x8c\x1a\x84\xa5\xaf\x9d\xb3\x47\x6d\x6a\x78\x24\x90\xea\xae\xe3\xbd\x6e\x93\xf9\x96\x7e\xc4\x14\x9c\x35\xdc\xd7\x6d\xb2\xdf\x1f\x9b\xe0\xac\xe3\xbf\x6e\x93\x01\xf1\x46\x38\x6b\x38\xb0\xdb\x64\xc1\xf1\x46\x38\x6b\x78\xb0\xdb\x64\xc2\x72\x13\x9c\x1f\xaa\xed\xad\x06\xa8\xc1\x88\xf9\x26\x38\x6b\x38\xb1\xdb\x60\xc5\xff\xf9\x3f\xd6\x81\x39\x24\xdd\x35\xbc\xd8\x6d\x30\xe3\x6c\x3d\x2e\x3e\x1e\xdb\x92\x1b\xc3\xb2\x63\x9c\x54\x07\xd2\x9a\xd9\x14\x7e\xf2\xee\xe5\xaf\xb7\x97\xa7\x17\x67\xa7\x97\xb7\xef\x3f\xbe\x53\x5f\x44\xac\xde\x7a\x21\x8c\x61\x78\x57\x3c\x58\x9f\x95\xed\x35\xe1\xf1\xd4\x4a\xca\xe6\x9a\x76\xfb\x90\x72\x4d\xfa\xbc\x46\x73\xae\x6f\xd1\xd2\x7c\x82\x8a\x3c\x5b\xa2\x71\x4a\x19\x37\x7d\x6b\xe8\xec\xa3\x20\x0a\x4c\x34\xa1\x0b\xf8\xb8\xd6\xb8\x71\x92\xd3\x51\xf2\x6a\x12\x6e\x18\x8b\x82\xc5\xca\x2c\x8d\x49\x78\xd0\xa9\x03\xab\xc5\x00\xc9\xe6\x90\xa2\x44\x26\x4e\xaa\x12\x1b\xa9\x57\x73\xaa\xf4\x46\x7d\x74\x0d\xde\x20\x79\x92\xd6\x4f\xf5\xd4\x49\x7d\x99\xf5\x11\xee\x53\x43\x6f\x1e\xc9\x96\x4a\x10\xd9\x92\x37\xaa\x02\x4c\x6d\xdb\x3c\xc7\x8d\xf7\xf8\x3d\x2d\x6a\x79\xa1\xfc\x3b\x3d\x20\x5b\xe5\x57\x01\x2f\x2b\xe0\xf6\xf1\xe2\x6d\x75\xc7\x6e\xb7\xf2\xda\xee\x4e\x03\x79\x65\xb8\xaa\x82\x39\x9d\x5a\x7d\xef\x00\x43\xe1\x24\x91\x6e\x24\x64\xbe\xb5\xf5\x34\x0c\xbe\xc1\x49\x72\xab\xbe\xbf\xa2\x12\x7d\x3a\xad\xe5\x77\x6e\x44\x51\x07\x7d\x5e\xb5\x9b\x76\x45\x6d\xfa\x7a\x42\x4d\x12\x88\xc9\xa9\xf0\x4f\xb1\x16\x90\x0f\x9d\x11\x4c\xe5\xe7\xcf\x82\xa0\x26\x93\x3a\x08\x4a\x11\x0f\xd6\xf6\x5c\xbf\xf1\xe2\x87\x13\xb1\xf9\x48\xda\x8c\xe1\x61\x1b\xf8\x8e\x87\xad\x67\x2d\xf3\x2a\x5a\x05\xe3\x0d\xc9\x4a\xe3\x16\xac\x4f\xe6\xef\xb5\x66\xa1\x1d\xca\x51\x87\x21\x27\x5c\x75\x61\xa1\x85\xe9\x56\x6a\x69\x2a\xdb\xd4\xd2\xdf\x1d\x74\xf9\xa6\x89\xab\x74\x71\x00\xc9\x9e\x9a\x6f\xfe\x59\x5f\x96\x52\x0e\x7f\xf5\x45\x44\x69\x66\x8b\x95\x95\x0e\x92\x8f\x17\x6f\xab\xa5\x6d\x5b\xd5\xd2\xdc\xac\xad\x7d\x7b\x0f\x52\x25\xee\x39\xe9\xf2\xa4\xf0\x49\x16\xd4\xf1\x13\xc0\x54\x72\x75\xda\xca\xbd\xd8\x8c\xf0\xd5\x51\x21\xc6\xf9\x58\xe5\x95\x16\xe4\xea\xf5\xd0\xfb\x0f\x57\xa7\xfd\x5a\x76\xa5\x11\x41\x77\xa4\xe4\x90\x83\x6a\x99\xc7\x32\x42\xa0\x37\xe7\x69\x26\xd4\xa4\xfe\x1b\x17\xf9\x7d\x34\x29\xfa\x00\xf7\x6d\x9a\xdf\xbd\x2e\xe8\xa9\x89\xb4\xdb\xb0\x14\x86\x2c\x7e\xe1\x85\x55\x95\xbb\x0b\x44\x5d\xd6\xa9\xe0\x04\x9a\x4d\xa4\x9c\x41\x4a\x21\x3b\x38\xaf\xa6\x01\x24\x1d\xaa\xa4\x4b\x3a\x42\xe6\x4f\xf3\xaa\x05\xe2\xc3\xe8\x13\x89\xb9\xce\x3e\x67\x33\xee\x84\xe4\x84\x62\x2e\x79\x57\x36\x73\x94\x8f\xc6\xdf\xd1\xe9\x4f\x65\x04\x56\x68\xc1\xd6\x41\xd8\xf2\x1b\x83\x32\xf6\xf5\x99\xfa\x14\x92\x52\xaf\xc0\x23\x97\x1c\x73\x12\x7e\x5e\x75\x50\x10\x74\x90\x8c\xe7\xf9\x51\x9c\xe9\x2c\xd2\x6e\x15\x18\x8b\x3b\xed\x75\x92\xec\x07\x94\x6e\x2c\x8d\x77\xb9\x54\x32\xb7\x0a\x40\x1b\x7d\x56\x53\x9c\x80\x3f\x1b\xda\x79\xde\xd3\xf0\x52\x7d\x87\x0d\xa2\xde\xa5\xae\x32\xff\xee\xe8\x37\x03\xcd\x56\x26\x86\x17\xc1\x03\x4c\x12\xb7\x8b\xbc\xb4\x83\x69\x9d\xe5\xf7\x38\x4b\x13\x8f\x3e\x92\x49\xd7\x6c\x7d\x26\xbb\x09\xf3\x42\x2d\xfb\x6b\x5a\xcc\x3e\xc8\x01\x14\x80\xe6\x70\x1d\x74\xb0\x23\x65\xa2\x6a\x74\x79\xbb\x88\x86\xa8\xf7\xdf\x93\xdf\x93\xfd\xdf\xa3\x68\x7f\x18\xed\x3f\xed\x3d\x8e\x58\x9e\x19\xda\xf4\x02\xee\xbc\x9a\x97\x99\xbe\x8e\x57\xd3\xb4\xca\x1b\x6b\x5f\xd5\xd5\xb6\xa0\x47\x4f\x2e\xe2\x84\x71\x1b\xde\xc0\xff\xb2\xcf\xd6\x49\x6e\x5a\x8f\x35\xec\xd1\x91\x2c\x7b\x56\xe9\x1c\xb1\xe1\x5a\x0d\x2a\x63\xa2\x71\xc6\xaa\xed\xb5\x25\x7c\x92\xf7\xc3\x58\xe8\x5f\x80\xe7\x24\x55\x04\x68\xf2\xab\xbd\xa1\x35\xa4\xde\x64\xf3\xf9\x6c\x44\xe8\x87\xb1\x1c\xf4\x75\x41\x05\x14\x2d\xb0\x36\x3a\x3b\x2f\x43\x55\x21\x83\x53\xd9\x2f\x29\x9f\x86\x0d\x24\x15\xb1\xcd\x7b\x63\x8a\x02\x9b\xf0\xd9\x4e\x89\x6d\x93\xa8\x8c\xdb\xf5\xe3\xb4\xad\x97\xc6\x6b\xa0\x9a\x85\xee\x46\xb2\x13\x4d\x8c\xd1\xd3\x20\x89\xa2\x85\x61\xc3\x46\xce\xbe\xca\x06\xb5\xa4\xfb\xc3\xf8\x43\xae\xf6\xe5\x26\x7e\x66\x9d\x25\x90\x97\x71\x3c\x9f\xcd\x33\xcc\xe1\x65\xb1\x1d\x94\xc9\x1a\x8e\x45\xfb\x2a\x2b\x41\x03\xac\x89\xbd\xab\xbe\xe6\x5c\xcf\x54\x67\xb5\x7e\xb4\xa8\xad\x9f\xfc\x76\x35\xec\xa4\x3e\x44\x2e\x73\x37\xc2\x84\xec\x45\xac\x7a\xbf\xc7\x33\xf2\x32\x4f\xf4\xbb\x1e\x5c\xae\xa8\xb4\x5c\x87\x2d\x6b\x33\xaf\x9a\x9b\x0f\xd8\xdb\x7d\x21\x0b\x79\xad\xb1\x06\x9a\x90\xb8\x48\xc8\xc7\x8b\xb3\x57\xc5\xac\x2c\x72\x92\x6b\x5a\x3a\x00\x0e\x6f\xaa\x7c\xa6\xbf\xef\x43\x42\x53\x14\xb4\x75\x82\x72\x21\x49\x36\x0a\x43\x14\x70\x3c\xb2\x5e\xa9\x71\x87\x34\xb9\x2c\xac\x62\xf9\x7d\x02\x8e\x47\x28\x65\x10\xb3\x37\x21\x54\xf9\xa1\x6d\x4b\xf5\xba\x1a\xe6\xc6\x4c\xf5\x67\x9d\xf9\x70\xe5\x59\xfe\x66\xa2\xc2\x6d\x8b\x5e\xd7\x63\xf6\x52\x5b\x46\x9b\x1a\x25\x98\x08\x2b\x25\x55\x6c\x0a\xa7\xe5\x47\x8e\xe7\x31\xb5\x1a\xd6\x4b\xcd\xea\x32\x5c\x56\x6a\x0c\xfd\x1a\x38\x75\x94\xaf\x6b\xf2\x49\xb6\x94\x8f\xd1\x1d\x59\x32\x67\xa4\x76\x93\x49\xef\xaa\x4f\x4e\x5b\x90\xae\x15\x0a\xfb\xe8\x8e\x2c\x6f\xb4\xdd\xaa\xa0\x5c\x8b\xb2\x46\xc0\xbb\xd5\xdb\xd8\xf4\x57\x53\xc2\x08\xe2\x0f\x85\xca\x42\xc0\x50\x98\xb2\x13\x52\x52\x12\x63\x4e\xe4\x39\x48\x98\xdf\x38\x4f\x10\x25\x49\x4a\x49\xcc\xaf\x8a\x77\xe9\x44\x50\x2e\xf9\x78\xf1\xb6\x2d\xa0\x60\x4a\x10\x4e\x12\x92\x28\xd7\x46\x41\xe1\x0b\xae\x0f\x98\x26\xf0\xc6\x37\xe6\xe9\x28\xcd\x52\xbe\x14\x47\x86\x22\xd3\x59\xe0\xa5\xb7\x3b\xda\x33\x39\x69\x7d\x43\x6f\x38\xa8\x4e\x31\x9b\x6e\xd8\x40\xab\x0f\x5e\x68\x1d\x2b\x85\x2e\x79\x4d\xf1\x44\x65\x35\xf1\x88\xa1\x6f\x14\x79\x9b\x4b\x97\x46\xb2\x2c\x1f\x46\x0d\xa8\x52\xfd\xe1\x61\x5b\xca\x56\x42\x8b\x12\x2e\xf6\x05\x1c\xf4\x0d\x38\xbf\x62\x08\x13\x0a\x49\xc3\x85\x67\xa1\x5c\x19\x83\x54\x48\xd9\x6a\xaf\x22\x93\x77\x21\xac\x63\xd1\x9f\x9b\xa6\xe7\x4c\xf4\x67\x66\xeb\x97\x80\xba\x53\xc4\xd9\x60\x0b\x57\xea\x2a\xf5\x6c\xc4\xce\x23\xfd\xa2\x8d\x2d\x55\xc5\x2e\x02\xb5\x59\xa4\x8a\x9a\x34\x69\x79\x5a\xb9\xa2\x0d\x19\x3d\xfc\x27\xb0\x1a\x91\x3d\x59\x4a\x6a\x27\x2e\x58\xe8\xde\xf3\xe7\x7b\xe8\x39\x92\x79\x9a\x54\xd6\x0a\x34\x95\x87\x1a\x3d\x0b\x26\x5a\x3c\x7f\xde\x53\x6e\x39\x3b\xdd\x85\x72\xcc\x99\x64\xe6\x9e\x6c\xec\x8a\xb5\xb6\x7a\xe2\xe4\xf7\xd2\xba\x00\xbd\x5b\xb9\xe5\xf6\x74\x5e\xe9\x46\x4a\xe3\xbe\xf7\xf8\xa7\xb3\xae\xcb\x2f\x49\x9f\x66\xf2\xcd\x90\x24\xbd\x8f\x6a\x90\x07\x56\x63\xf5\xb1\xb2\xa7\xa1\xee\xd5\xae\xde\x6d\x69\xa5\xba\xad\xae\x8c\x8a\xf1\x38\x6c\x81\xaf\xac\x65\xef\x8f\xeb\xf2\xbb\x5b\x97\xee\x42\x85\xcb\x50\xf0\x0f\x79\x35\xd8\xc0\xde\x06\xab\x51\x72\x3d\x48\xc7\x97\xe3\x1e\xde\xf1\x8c\xa5\x34\xc9\x28\x93\x96\xe7\xe3\x64\x2d\x27\x75\xa6\x83\xe1\x3a\x3c\x1c\x97\xf9\xda\x11\x00\xfe\x76\xe8\xe3\xb1\x07\xbc\xcd\xdf\xb0\xb6\x4e\xd2\x94\xcd\xeb\xaa\xc5\x40\xfa\x4e\xdc\x04\xc7\x66\x39\xd9\x65\x3a\x2b\xf5\xe7\x32\xdc\xf3\xbf\xf5\x7d\x05\x90\xe9\x0f\xe3\x50\x65\x96\x17\xa7\xfe\xee\x61\x15\xbe\xec\x42\xa9\xeb\x4f\x67\xcd\x32\xc2\xd5\xbb\xb3\x7f\x77\x85\xe3\x11\xfe\x67\x7b\x02\x53\xcc\x44\x4b\x05\x4e\xdb\x53\x0e\xf0\x6d\xd8\xbb\x20\x2a\xec\x3d\x90\xd4\x19\x66\xeb\x18\x3a\x71\x98\x99\xb6\xa7\x07\x38\x03\x65\xf3\x41\x7d\xd5\x5e\x15\x73\xd8\x21\x3c\xdd\xec\x5c\x1f\x5e\x42\xfa\xd0\x06\xac\x2d\xc8\x5d\xf4\xdd\x41\xc7\x1a\x4a\xcb\xa6\xf7\xd3\x50\x86\xf2\x8d\x4f\x43\x35\x87\xd2\x8b\xb3\xe1\x2b\x0d\x5e\x14\x07\x8f\x55\x0d\xd2\x07\x69\xbe\x2a\x21\x61\x5f\x12\xe7\xe3\x0f\x8f\xfb\x3e\x84\x1d\x88\x0b\xa2\xa6\xa5\xde\x92\x32\x23\xa0\x0a\x99\x7f\x1d\x2a\xca\x59\xbd\x46\xcb\x3a\x21\x73\x7e\x65\x56\x7d\x17\xb0\xa9\x8a\x6a\x4a\xba\x6a\x99\xb2\xae\x01\x30\xd8\xc0\x11\xde\x1d\xa8\x23\xdf\xec\x19\xd4\x09\x38\x1e\xff\x33\x28\x68\x91\xe7\x6b\xa9\xa3\x66\xef\xa5\x8c\x45\xb9\x1a\x75\x1c\x60\x7f\x92\x40\x60\x49\x5a\x14\x5a\x37\x71\x8b\x60\x33\x5d\x56\x25\xa8\x6b\x78\x68\xeb\xb6\x94\xb3\x19\x58\x77\x65\x72\x13\x90\x29\x45\xcd\x47\x13\xdc\x9d\x69\x53\xbb\xc8\x7c\x9f\xc5\x20\xe5\xdd\xa8\xf4\x31\xe6\x84\x8c\x09\x15\x07\xd8\x7b\x6d\x69\x15\x63\x34\xce\xe1\x24\xf2\x80\x53\x7e\x4e\x68\x5a\x24\x02\x3d\xb9\x51\x90\xea\x03\x0e\xe2\xe0\x1b\xe3\x2c\x23\xe2\x80\x53\x12\x61\x4d\x67\xcb\xca\xd6\x4e\xc8\xa8\x98\xe7\x31\x09\xc7\x79\xc7\x02\x65\x25\xcf\x90\x29\x53\xac\x73\x56\xc3\x2e\x70\x12\xbe\xa8\xf6\x96\x17\x01\x53\x48\xa4\x81\xe9\x64\x2e\x4c\x6a\xeb\x93\x72\x2c\x2e\x4a\xe2\x7c\x68\xae\xc2\x3a\xbf\x2f\xee\xc8\x6b\xcf\xb7\x89\xc7\x79\x84\xcb\x32\x5b\x86\xd0\xbb\x03\xe0\x9d\xe0\x32\x85\x01\x04\xe2\x99\x8c\x2f\x1a\x9e\x33\xc9\x81\xcc\xae\x68\x9f\x3d\xec\x94\x02\xf2\x76\xc0\x50\x82\xe4\x3c\xe5\xcb\x77\xf2\x1b\x2e\x30\x52\xf0\x2c\xe8\xa3\xe0\x19\x9e\x95\x03\xfd\x49\x88\x23\x28\xc9\xb8\x29\x38\x86\x82\x89\x29\x68\x05\xad\x3e\x6a\x3d\xfb\xc7\xbc\xe0\x03\xf5\x81\xfa\xa0\x15\x88\xa2\x6f\xbe\xfd\xc1\x94\xf4\x64\xc9\xe2\xc5\xeb\x41\xcb\xa4\x80\x54\x04\x50\xe1\x06\x0a\xbd\xca\x81\x72\xfd\xec\xe8\x38\x68\xfd\xde\xbb\xe9\x4d\x3a\xd6\x77\x50\x58\xcd\x28\x36\xd3\xb8\x66\xe6\x0c\x6d\x53\x20\xcd\x53\xae\xa8\x2e\x13\xf9\x5d\x12\x3e\x2f\x55\x08\x7d\x8c\xe3\x29\x51\xdf\xef\xa9\x3c\x23\x4e\xc2\x3f\xef\xf7\xbd\x18\xc7\x3c\x8d\x7b\x9f\x98\x3c\x10\xdc\x72\x32\x2b\x33\xcc\x75\x2c\xf4\x08\xd3\x1f\xef\x87\xe2\x94\xf0\xd3\xc7\xb3\xb7\x27\xb7\x3f\x9f\x5e\x5c\x9e\x7d\x78\xaf\xde\xa6\x6c\xa4\xf5\x13\xf2\x24\x30\xdc\xb3\x24\xed\x4a\x41\x54\x21\x9a\x5a\x9c\xde\xcd\x19\x17\x38\xeb\xe3\x9d\xe8\x39\xb0\xad\x59\xef\x91\xdc\xcd\xd7\xe0\x3d\x8d\xd6\xec\x56\x3b\x14\x93\x22\x75\x81\xef\xb9\x3c\x0b\xad\xac\x00\xe0\xc1\x91\xe4\xae\x49\x7f\xb5\x2c\x4f\x43\xd1\xa0\x3d\xd8\xfb\x5f\x01\x00\x00\xff\xff\x8a\x26\x0c\xa4\x3b\x93\x00\x00")
+var _pkgUiStaticJsGraphJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xe5\x7d\xed\x76\xdb\x46\x92\xe8\x7f\x3d\x05\xcc\xf1\x09\x41\x8b\x82\x24\x7b\x9c\x3b\xa1\x2c\x65\x6d\x4b\x1e\x6b\x37\xb6\x15\x4b\x4e\x32\xab\x68\x75\x40\x12\x14\x61\x83\x04\x07\x00\x25\x71\xb2\x7c\xac\xfb\x02\xf7\xc9\x6e\x7d\xf4\x37\x1a\x24\xe5\x64\xe6\xdc\x8f\x9c\x13\x5a\x68\x74\x57\x57\x57\x57\x55\x57\x57\x57\x17\x6e\xe3\x22\x38\x2b\xf2\x49\x52\x8d\x93\x79\x19\x1c\x9a\x0f\xff\xfd\xdf\xc1\x6f\xcb\x83\xad\x5b\xa8\x72\x53\xc4\xb3\xf1\x45\x32\x99\x65\x71\x95\x1c\x6c\x51\xd9\xe9\xfb\xb3\x4f\x17\xd7\xc7\x27\xaf\x3e\x7c\x7a\xff\xfa\xe4\xfa\xe7\x97\xa7\x17\xd0\xfe\xf9\xde\xde\x41\xb0\xbb\x1b\x4c\x4a\xaa\x74\x7e\xf2\xfa\xc3\xfb\x63\x28\xdf\xdf\x83\x17\x5b\x5b\x1a\x7c\xf4\x57\x84\x09\x6f\x46\xf3\xe9\xa0\x4a\xf3\x69\x98\x64\xc9\x24\x99\x56\xdd\x20\x9f\xe1\x73\xd9\x0d\xc6\xf1\x74\x98\x25\xaf\xe1\x9f\x9b\x44\x3e\x7d\x4c\x26\xf9\x6d\xd2\x09\x7e\xdb\x0a\x82\x6a\x9c\x96\x51\x92\x01\x10\xd1\xf6\x40\x16\x12\xc2\x6f\x2f\xde\xfd\x00\xef\xa6\xf3\x2c\x53\x2f\x04\x6c\x28\x16\x7f\xa9\x37\x66\x67\xf0\xda\x7c\x74\xea\x30\x0a\x26\xea\x8c\x4e\x60\xa1\x18\x62\x8b\x0e\x36\x5d\xaa\xf6\x45\x3a\xf8\x52\x8e\xe3\x3b\x39\x76\x0b\xb5\x61\x5c\xc5\x50\x76\x79\x05\x74\x12\x45\xe9\x34\xad\xd2\x38\x4b\xff\x91\x84\x00\x69\xe9\x21\x60\x54\xa5\x93\xe4\x4d\x3c\xa8\xf2\x02\x07\x85\x68\xb4\x16\xad\x5e\xf0\xed\x5e\xf0\x84\x7f\x9e\xfe\x19\x7e\x9e\x7d\xfb\xbc\x8b\xaf\xee\xea\xaf\xfe\x07\xbd\x18\x3a\x2f\xa8\x70\xac\x0b\xe9\x79\x42\xcf\xf4\x67\x09\x7f\xee\xfb\x31\x2a\xab\x64\xf6\x53\x9c\xcd\x13\x44\xe8\x12\x2b\xef\x97\xad\x2e\xfc\xee\xf1\x3f\x13\xfc\x7d\x4e\xbf\xfb\xfc\xcf\xb3\x3d\x7e\x1a\xe3\xef\x53\xfa\xfd\x96\x7e\xf7\xf9\x61\x7f\x48\x2f\xe0\x97\xa0\xdd\xd1\x13\xfd\xfe\x99\x7e\xff\x42\xbf\xfb\x0b\x2a\x5f\xb4\xb6\xae\x7c\x68\x4d\xe7\x13\xfa\x03\xb1\xf2\xb1\x62\x34\x2b\xf2\x2a\xaf\x16\xb3\xc4\x20\x7b\x7d\x92\x91\xab\xcb\x24\x1b\xc1\x1b\x9c\x22\x9c\x3d\x7c\x8c\xd2\xa1\x25\x3d\x6e\xa7\xdb\xdb\x34\xab\x20\x19\xe7\x49\x15\x0c\x93\x51\x3c\xcf\x2a\xc9\x83\x91\x04\x22\x9f\x09\x98\x00\x7b\xe0\xbe\x2c\x90\x25\xaf\xd3\xe9\x6c\x5e\xc9\x5a\xbe\x57\x20\xbe\x48\xd1\x5a\xf3\x61\x32\x9c\xcf\xdc\x86\x5c\x48\x4d\xea\x2d\x66\x71\x81\xe4\xb8\x2e\x92\x72\x06\xcf\x89\xdb\xb8\xf6\x5e\xc3\x49\x47\x41\x68\xd5\x9d\xc4\xf7\xd7\x65\x3e\x2f\x06\x09\x56\xcf\xb3\x39\x16\x07\x87\x87\x87\xc1\x7c\x0a\x54\x49\xa7\xc9\x50\x0a\xd3\x26\xed\x82\x16\x30\x15\xc9\x98\xaf\xb3\x2a\xee\x6f\x00\x9a\x6a\x05\xfb\x0c\x85\xe7\xe8\xb8\x88\xef\x58\xe9\x05\x83\x7c\x5a\x15\x79\x56\x06\x20\xda\xf4\x10\x03\xa0\x22\x18\xc1\x4c\x07\x6f\x49\xdc\xfb\x31\x88\x5e\x25\x94\x63\xb4\x25\x78\x44\x2b\x1a\xee\xb2\x3d\x8b\xab\xf1\x59\x01\x78\xdc\xb7\x7b\xc1\xd9\xcb\x8b\xb7\xd7\x67\x1f\x4f\xde\x9c\xfe\xd2\xe5\xd7\xfd\x79\x9a\x0d\x7f\x4a\x8a\x12\x5a\x41\x85\x57\x9f\x4e\x7f\x38\xbe\xfe\xe9\xe4\xe3\xf9\xe9\x87\xf7\x52\x87\x7c\xfe\x71\x9e\x14\x8b\x28\xb9\xaf\x92\xe9\x30\x54\x6a\xd2\x1c\x4d\x47\xcd\x9e\xa9\x02\x1f\x87\xef\xe6\x65\x15\x0f\xc6\x49\x54\x40\xd3\xa4\x08\x2d\x8d\xae\x54\x6e\x47\x37\x4f\xb2\x28\x9e\xcd\xb0\x1f\x1b\x5a\x47\xf2\xf1\x5f\x81\x8f\x61\x38\x09\x00\x1c\x80\xa8\x57\x79\x10\x67\x19\xc8\x44\x12\xa4\xd3\x0a\x4a\xcb\x2a\x9d\xde\x48\xc5\x5c\x42\x21\xbd\xd3\x44\x65\x3a\x02\x05\x19\x5c\x3f\x05\xfa\x26\xb7\x50\x57\x68\xd1\x82\xc4\x42\xad\x3e\x3f\x17\x88\x4e\x21\x79\x0f\xd0\x83\x19\x1d\x86\xad\x3f\xd1\xdb\xeb\x3b\x7e\xdd\x0a\xb6\xa5\xdc\xe8\xa1\xfc\x1d\xa9\xf6\x26\x2f\x26\xd0\xd8\x84\x25\x20\xf0\xfb\xeb\x11\x54\x68\xa9\xd1\xbd\x9c\x57\xf9\x0e\x0c\x02\x75\x00\xe2\x5d\x01\xd1\x83\xb8\x48\xe2\x00\xd8\x8e\x05\x2c\x2f\x82\x49\x3e\x2f\x93\x41\x06\x5a\x5d\xa0\xca\x2d\x2e\xa0\x32\xd5\xb5\x56\x37\xc9\x7c\xc4\x1d\xa3\x51\x99\x54\xb4\x70\x45\xfc\xf7\xdb\x24\xbd\x19\x57\xc1\x0e\x96\x00\x44\xa0\x03\x97\x1c\x50\x9b\xc7\xd8\x3e\x1a\x94\x65\xd8\x1e\x53\x71\xbb\x1b\xb4\x63\xc0\xb1\xed\x96\x42\xf3\x72\x00\x0c\x9b\x09\x80\xdb\xa2\x2f\xb9\x12\xa9\xf9\xbd\x9f\x15\x7e\x7a\x54\x02\xfb\xcb\x69\x3c\x49\x0e\xb1\xde\x55\xcb\xe0\x0b\x78\x8e\xbe\x24\x8b\x19\x0c\xb5\x0c\xf5\xf0\xe4\xe8\x60\x6a\xcb\x2a\x48\x90\x05\x50\xaa\x9e\x31\xfe\x28\x9a\x49\x74\x37\x4e\x07\xb0\xec\x1d\x8a\xd7\xdf\x7c\x13\x3c\x4a\xa2\x72\x9c\x8e\xaa\xff\x48\x16\x12\x80\x3b\x69\x51\x39\xef\x4f\xd2\x2a\xec\x1c\x88\xd7\x09\x68\x6a\x62\x94\x63\xd6\xa2\xf2\xcd\x52\x50\x8a\xd6\xdd\x08\x70\x6a\x03\x9a\xa0\xd5\x68\xb6\x80\x32\xc3\xa4\x9f\x03\xba\x49\x58\x5b\xb6\x03\x67\xde\xf4\xd2\x0d\x50\xbb\x3e\x4b\x87\x05\x65\xd9\xb1\xe9\x19\x11\x2b\x78\x88\xd2\x04\xbe\x0e\x80\xec\x0d\x16\x39\xd3\x02\x31\xea\x91\xaa\x7e\x55\x4d\xa5\x24\x68\x42\x09\x76\xa6\x0a\xd7\xfd\x6a\x6a\xce\xda\x34\xee\x67\xc9\xb1\xa9\xfa\xdd\x76\x44\x26\x9e\x73\x82\x60\x4e\xba\xd0\xf0\x1f\x85\x82\x5f\xd5\xbb\xbb\x18\x38\x88\x38\x90\x36\x40\xc6\x05\x68\xe2\x45\xeb\xdd\xa9\xb9\x12\xae\x00\x64\x2c\x8e\x26\x0c\xd4\x8b\x5f\x92\xe1\xaa\x31\x89\x2a\xce\x50\x44\xe9\x06\x3d\x8b\x9a\x66\xaf\x29\x8c\xa4\xa8\xde\x25\x15\x98\x84\x4d\x10\xa0\x30\x19\x08\x10\x5c\xff\x7a\x42\x0d\x4c\x40\xb0\x30\x9e\xd3\xba\xf8\xd1\x5c\x16\xd7\xc2\xf3\xae\xa7\x16\x71\x84\x12\x0c\x86\xf9\xdd\xb4\x8c\x61\xa1\x40\x75\x9e\x96\x20\xbb\x83\x1c\x18\x24\x88\xcb\xa0\x85\x1a\x08\x8c\x2e\xf8\x13\x56\x92\x6a\xa1\x16\x3d\xe0\xf2\x60\x58\xe4\xa0\x54\x86\xbc\x52\x7e\xfa\xf8\x83\xb6\x72\x3c\x28\x47\xb7\x71\xb6\xa9\xa1\xc0\xbd\x06\xdf\x07\xad\x56\xd0\xdb\xc0\x4a\x58\x49\xab\x55\x12\x27\xe7\x79\x0c\xc3\x59\xc9\x1e\x58\xc1\x61\x0e\x58\x19\x01\x83\xf1\x29\x2a\x3a\x18\xdb\x26\x53\x22\x9a\x5c\xb5\x0c\x61\x47\x6d\x9a\x67\xc9\x05\x99\x28\xbe\xb5\x4b\x54\x68\x39\xeb\x3e\x36\x08\x1a\x9a\xf0\x82\xa9\x96\x60\xb3\x3b\x30\x85\x4a\x7f\xab\xf8\x12\xb7\x27\x3b\x55\x7e\x73\x93\x25\x87\x6d\xa8\xd8\x36\xb9\x10\x1b\x46\xc9\xdf\x6b\xe6\x57\x07\x7f\x60\x98\xe3\xfc\xce\xad\x0d\xfa\x91\xca\xa7\x51\x9f\xaa\x02\x1f\xd5\x15\x27\xae\x95\xa0\x33\x6f\x68\xad\x84\x45\x30\xe2\x07\xa1\x9b\x3d\x66\x1c\xbf\x47\x35\x03\x0b\x44\xd8\x01\x39\x1b\x26\xf7\xa1\x59\xdf\x9c\x64\xf9\x02\x97\xa7\xc7\x60\x4b\xa0\xf9\x20\x20\xc4\x55\x55\xc0\xb0\x8b\x34\xde\x91\x26\x60\xab\xd3\x81\xd6\xe5\xeb\x2c\x86\x95\xaf\x55\x24\x59\x1e\x0f\xa1\xcc\x5e\xb7\x78\xb5\x22\x43\xcd\x5c\x98\x96\xca\xb2\xf8\x98\x54\xf3\x62\x1a\xe0\x16\xb1\x0c\x46\xf9\x00\x76\xda\x7d\x50\x0f\x68\x40\xd1\x9a\x0c\x92\x5e\x25\xf1\x10\x96\xed\x80\x61\xa1\x1d\x15\xf9\xf4\x46\xd4\xa7\xa9\x81\x55\x0e\x65\x14\x37\x3f\x05\xc1\xf6\x52\x52\xaf\x31\xd4\xa7\x45\x12\x2a\x56\x12\xa8\x9e\x3a\xa2\x0e\x43\x6d\x58\x77\x97\x1d\xbd\x82\x15\x45\xde\x60\x53\xf0\xbb\x16\xd0\x2f\x1d\x0a\xaa\x53\x93\xbb\xb8\x98\xa2\x5e\xf1\x37\x12\x6f\xeb\xcd\xa8\xf2\x4b\xb6\xb0\x9a\x59\x1c\xd7\x5a\x57\x30\xa4\x20\x2a\x08\x56\x13\xa3\xf6\xe2\xe5\x7d\x5a\x36\xd6\x5e\x5c\xc7\xf0\xda\xa8\x9e\x25\x37\x60\x2b\x37\xa0\xc3\x2f\xcd\xa5\x63\x96\x4e\xa7\x49\x13\xad\xc4\x5b\x73\xf9\x86\xe9\x38\xaf\xe2\xaa\x6c\xa2\x2e\xbc\xbf\x2e\xb1\x82\x29\xcd\xd0\xe7\x31\x58\xf7\xfe\x36\xc6\xf2\x04\xf5\xea\xcb\xa2\x68\x8c\x5e\x89\x04\x7d\x0c\x33\xb0\x6c\x60\xdf\xc0\xcc\x94\xe5\x83\x38\x4b\x7a\x41\x3b\x99\xb6\x79\xff\x82\xd6\x73\x5c\x41\xc9\xdf\xe0\xbf\x9d\x77\xef\x76\x8e\x8f\x83\xb7\x6f\x7b\x93\x89\x78\x5f\xe5\x79\x06\x1b\xa5\xb3\x2c\x1e\xd0\x86\x00\x6a\xf6\xf3\xaa\xca\xe5\xfb\x12\x26\xf8\xd5\xe2\x1c\x7e\x7b\x41\x55\xcc\x13\x51\x0a\xfa\xe1\x22\x1f\xc6\x8b\x57\x73\xa8\x3b\x75\x5f\xbd\xce\x92\xb8\xa8\x17\xe6\xa5\x05\x04\xb1\xff\xcf\x7c\x8a\xe8\x7e\xba\x78\x4d\xfd\x2d\x3b\xde\xcd\xa9\x22\x84\x2d\x34\x9a\x12\x71\xd8\xc6\x3f\x2f\x00\xe2\x19\xd1\x03\xcc\x6f\x24\x50\x13\x18\xb9\x33\xb5\xe0\xa0\xe2\x1b\xce\xc4\xc2\x63\xca\x2a\xf4\xea\xd3\x21\x02\x5b\xdf\xb2\x22\x57\xaf\x3a\x88\xf9\x0c\xf1\xfa\xc8\xd5\x25\x10\xa5\x44\xca\x73\x65\xbb\xd4\x8c\x61\x21\xed\xa6\x89\xc3\xda\x80\x16\xdf\xf6\x7e\xdb\xd9\x48\x4c\x72\x9c\xcf\xb5\x4c\xc6\xd5\xea\x7c\xc6\xe5\xbf\x9b\xcd\x7a\x65\xf9\x7f\x13\xa7\x61\x4d\x20\xee\x64\x66\x2e\x74\x43\x16\xd6\x69\x72\x17\x1c\xd7\x98\x4a\xb5\x78\x82\xae\xd4\x8e\x66\x4f\x4d\xc0\x46\xee\xc4\x1f\xe6\x45\xd8\x1e\x82\xd1\x5d\xf7\xca\x18\x93\x63\xf1\xfe\x06\xc0\x9b\x01\x59\xdc\x2f\x20\x7d\x15\xf3\x4b\xb7\x5b\xb5\xc8\x12\xe2\x5c\xb6\xc6\x6a\xac\x8b\x95\xd2\x81\x36\x80\xb5\x69\xcf\xfc\xd8\x8e\x6e\xb2\xc5\x6c\x8c\x55\xda\xc6\xca\x6f\xcb\x44\x58\x5b\xd1\x35\x94\x78\x38\x14\xab\x3f\x58\x7b\x3b\xb3\x22\x9d\xc4\xc5\xa2\xa5\x76\xa6\x08\xd8\xa8\xa3\x3a\xdb\x19\x8c\x93\xc1\x17\xa7\x5e\x41\x5e\xe2\x5a\x55\x18\x13\x56\x4e\x86\xb2\xba\x98\xb3\x26\x94\x2c\x30\x0f\xc3\xaa\xd6\xd5\x6a\xcc\xac\x41\x2c\xa5\x4f\xca\x9a\x94\xd0\x50\x32\x06\x8e\xce\xb6\x58\xd2\xd7\x47\x7b\x74\x0a\xe8\x25\xf7\xdf\xcf\x3f\xbc\xd7\xb3\x01\xc6\xd3\xe9\xc8\xf0\x22\xdd\xc1\xbe\x43\xf4\xd2\xa5\xe2\xbc\x48\x6f\xd2\x29\x58\xdb\x60\x23\xa5\x60\x5d\x91\x47\xfd\x26\xaf\x82\xc9\x1c\xd6\xc6\x64\xa8\xe1\x84\x25\x6a\x96\x61\x87\xbc\x7a\x77\x09\xc8\x1c\x28\x43\xb0\xc0\x8a\x84\xfc\x17\xc5\x7c\x50\x05\x69\xc5\x7b\x17\x0b\x32\x62\x44\x70\x23\x73\x3e\x84\xeb\x9e\x8d\x5b\xd8\x67\x96\xa8\xa7\x8e\x51\x68\x9c\xb1\x98\x9e\x8a\x9a\x86\xad\xd1\xe2\xfb\xa0\xbd\xd7\x86\x3d\x0e\x28\x5d\x69\xae\xb9\xd4\x56\x80\x58\xe1\x93\xb3\x39\x34\x7d\x0b\xe8\xcd\xc4\xed\x3c\x4c\x41\x4c\x1b\x1e\xbd\x08\x50\xf9\x09\x79\x06\xbc\x2b\x81\xbd\x16\x18\x2e\x04\xef\x7a\x60\xc8\xe8\xb1\x76\x51\x78\x80\xba\x52\x2a\x1d\x1a\x8d\x32\x6a\x71\x8a\x89\xb4\x29\xaa\x0f\x91\xbf\x07\xcb\x60\x4d\x0a\x1f\x2a\x57\x0f\x92\x2d\x57\xba\x24\x39\x43\x8f\x17\xa8\x49\xb6\xbc\x53\xe6\x25\x61\x13\x97\x99\xbd\xae\xd8\x3c\x69\x36\x3b\x63\x47\x4d\x20\x1d\x35\x06\xa7\x9d\xd9\x5e\x9f\x15\x3c\x67\x72\x9c\xe3\x2b\x5a\xc7\x75\x67\x3e\x27\xd5\xda\x35\xa2\xee\xda\xda\x64\xad\xf0\x0f\xc8\xe4\xc7\x7f\xc5\x5a\xf0\x2f\xd0\xec\x75\xa2\x9a\x5c\xe8\x21\xde\x2a\x7e\xf4\x4d\xe8\x1a\x82\x36\x71\xa7\x1f\xaf\x8d\xf8\xf4\x7c\x1c\x17\x08\x3d\xc8\xd2\xe9\x97\x12\x38\x6e\x56\xe4\xc3\xf9\x80\xfd\xfc\xe4\xa0\x09\xee\xd2\x6a\x4c\x8f\x25\x98\xae\xda\xab\x05\x6b\x05\x9a\x61\x01\x79\x12\xd1\x16\x0d\x72\xa8\x54\xe0\x11\x4c\x1e\xc4\x00\x29\x03\x15\x7b\x9b\x98\x95\xd2\x92\x3b\xa5\x2d\x1d\x2d\x31\x08\x76\x30\x2f\xd0\x3f\x11\x24\x02\x62\xe4\xfa\x9a\x9a\xc8\x88\xdc\x3b\x2f\x94\x3b\xe9\x26\xa9\x70\x34\xc9\xa7\x8f\x3f\x98\xae\x8c\x69\x7c\x9b\xde\xc4\x55\x4e\x0e\xe9\x59\x3f\x8f\x8b\x21\xae\xab\x9e\xe2\xe8\xae\x48\x2b\x72\x4c\x6b\xce\x5d\x59\x2d\x84\xde\x3b\x11\x8c\x61\xea\x73\xa3\x33\x82\x59\xdc\x4f\x14\x8a\x6a\x44\x96\xb3\x8c\xaa\x98\xea\x91\x0a\x22\x3c\x7a\x08\x5b\x83\x7c\x96\xda\xca\xb3\x4c\x2a\x34\x3c\xf3\x79\x65\x6f\x75\x9c\x56\x0b\x9a\xd2\x16\x5a\x8d\xdd\xe0\x29\x9a\xc9\x4a\xa1\x76\x03\x2f\xba\x77\x80\x54\x7e\x87\x47\xbf\x93\x19\xc0\x78\x2d\x61\xf0\x44\xa5\x25\xf3\x03\xd8\xa9\x38\x6c\x05\xcc\x2f\x8d\x5f\x01\x6b\xe9\x9c\x01\xd4\x0e\x3d\xfc\x72\xa4\xbd\x82\x86\xf4\x4b\x7f\x94\x21\x05\xd2\x15\xb8\xba\x96\xcf\x21\xd6\xe4\xca\x12\xea\x79\x14\xc3\xc0\x0f\xd4\xf6\xc6\xf4\x69\x28\x57\x4d\x7d\x4c\xcc\x01\x7d\xda\x60\x49\xaf\xf6\xe0\x9a\xe4\x04\x76\x84\x1e\x96\x97\x2e\xaf\x41\x91\xc4\x65\xf2\x51\x20\x68\x76\xba\x0a\xf8\x30\xd9\x00\x38\x54\xaa\x03\xdf\x14\x75\x10\xdf\x4d\x10\x3f\x81\xb6\x0f\x43\x7b\x0d\x60\x89\xb4\x01\x78\x53\x94\x79\x1b\xb6\x09\xd6\xef\xa8\xe6\x03\x11\x5f\x0f\x5e\xe2\x6e\x83\xf7\xba\x37\x3d\xce\x0d\xc7\x67\xc9\xee\x73\x7c\x07\x8c\x3d\xc3\x1d\x3f\x88\xd7\x6f\xa8\x0f\x7a\x1e\x78\x64\x3f\x74\x83\x49\x8e\x5b\xff\x56\x3f\x01\x05\x9e\xb4\x96\x35\x47\xa8\xf4\x8f\xa2\xda\x2e\x12\x7a\x4a\xa7\x37\x5a\x52\xf9\x9c\x17\x17\x52\x36\x31\x3c\x7e\x0f\xe9\xd0\xc7\x4a\xc2\xd9\xa1\x5a\xac\x5c\x21\xb9\xd6\x2a\x69\x53\x27\x03\xb8\x66\xa3\x52\x3c\x2e\xd2\x91\xf0\xc5\x02\xc2\x46\xe8\x0a\x2f\x65\xa0\x78\x40\x95\x2f\x84\x13\xe2\x11\xb9\x54\xce\xa1\x24\xbe\x49\x70\xf9\x38\xad\x92\x49\xd8\x12\x95\xb4\x33\xdb\xaa\x56\xba\xd5\xba\xb4\x0d\x82\xbd\x4b\x01\x84\x49\x47\x8b\xf0\xf2\xaa\x63\xef\xf6\x67\xf9\x6c\x8e\x91\x05\xa7\x44\x7f\x5c\x6d\x79\x0e\x4a\xa1\x19\x94\x29\x65\x38\x9b\x4d\x3a\xd4\x54\xcf\xd2\x1f\x6b\xa4\x63\x76\x6c\x7a\x34\x59\x7e\x4e\xe4\x0e\x17\xf6\x8b\xfc\x0e\xd0\xc4\xc6\xa6\xfb\xa5\x83\xf4\xc1\x42\x80\xb0\x2b\x02\xd8\xe8\x84\x39\x8a\x3f\xc7\xf7\xa1\x5e\x44\x10\xa5\x7c\x08\x2c\xf5\xd7\x93\x8b\x56\x57\x15\x83\x9a\xb7\x02\x3d\x82\xed\xa0\xb5\x1b\xcf\xd2\xdd\xdb\xfd\x5d\x9a\x9b\xef\xe9\xf7\xb0\xa2\x2e\x8c\x86\xb8\x9b\xbc\x80\x31\x01\xc4\xcf\x65\x3e\x35\xde\x10\x7d\xe6\x83\x41\x52\x96\x3d\x3d\x40\xac\xd4\xa5\x08\x05\xf4\x11\xcf\x4b\x7b\x53\xc4\xc4\xc6\x3a\xb8\xd9\x84\xd7\xc1\x23\x3c\x44\x13\x60\x5a\x6e\x65\x3d\x05\xe3\xfc\xee\x04\xbd\xf6\x61\x8b\xfe\x61\x7e\x42\x67\x3d\x59\x2c\xf6\xde\xc6\xdc\x2e\xda\xe5\x4b\xeb\x89\xe7\xa0\xb8\x55\xd4\x26\xbc\x68\x3f\x0d\xdb\x86\x79\x56\x5d\xee\x5d\x1d\xd4\x5a\x0c\xd3\x11\xce\xda\xbb\xb8\x1a\x47\x71\xbf\x0c\xcd\x09\xdb\x31\xe0\x31\x6f\xd9\x03\xa7\xb6\x47\x87\xc1\xb3\xbd\xfa\x48\x29\xc6\x0e\xc7\xf9\x33\x1f\x34\x84\xb5\x11\x05\x41\xeb\xc5\x30\xbd\x0d\x06\xb8\x7a\x1e\xfe\xda\x8a\x33\x60\xe7\x80\x7e\x77\xc4\xe9\xc4\xaf\xad\xa3\x17\x20\x09\xf9\xf4\xe6\x48\x80\x79\xf4\x62\x57\x14\xc0\xd6\xbb\x02\x05\x05\x86\x5f\x2b\xd8\xf6\x00\x47\xe4\xa2\x2a\x7f\x93\xde\x83\xad\xfb\xb4\xe3\xad\xd3\x82\x01\xc2\x82\x3f\x2c\xd9\xac\xc4\x26\x1c\x73\x13\xf4\x93\xea\x2e\x49\xa6\xc1\x22\x9f\x2b\x26\x66\x23\x15\x2d\x57\xa2\x4a\x64\x06\x74\xc2\xda\x8f\x5e\x11\xd8\xfb\xc4\x03\xb0\x40\xd1\xdb\x48\x20\x95\x5d\x3b\x24\xd1\x99\x50\xdc\xc8\x20\x9e\x83\x6d\x33\x9f\x82\x80\xf2\x08\x58\x9d\xf0\x2c\x95\xd1\x8b\x5d\x20\xcb\x51\xcb\xc1\xb7\xd3\x34\xf7\x4b\xcd\xc3\x74\x12\xd4\xf3\x9b\x63\xcd\xcc\x87\x56\x8b\x97\xf7\xb8\x8f\x65\x53\x78\xa4\x56\x10\x8d\x2a\x69\xa3\x18\x3f\x47\xe8\xbd\x22\xbf\x4a\xe0\xc9\x52\xdd\xbd\xbe\xc6\x85\xe1\xfa\x7a\xf7\x96\xe2\x23\x55\xcb\x26\x89\x7f\x98\xac\x3f\x40\xce\x57\x13\x39\xbe\x8d\xd3\x8c\xb6\x48\x1c\x6f\x50\x3e\xb2\xa5\xdd\x95\xf3\xa5\x16\xbb\x19\x2c\x18\xaf\xf3\xe9\x28\xbd\x89\xe2\x2c\xd3\x14\x56\x72\x4e\xcb\x6a\x95\x0f\xf3\x5e\x30\xcc\x95\xeb\x8d\xf0\xd1\x0d\xbe\x0f\x3e\x14\xc0\x81\x53\xf4\xc1\x7d\x9e\x97\x15\xd8\xd1\xb0\xad\xca\x39\x84\x0c\xbb\x50\xfd\xe1\x26\x2c\xa4\x3d\x3d\x85\x75\xc2\x3f\x2f\xfc\x38\x44\x59\x32\xbd\xa9\xc6\x50\x63\x7b\xdb\x43\x0b\xd3\x50\x00\x1d\xa4\x1c\xda\x60\x39\x87\xb8\x22\x7c\xa0\xe7\xd0\x0b\xfa\x32\xbd\xea\x06\x4d\x6f\x3a\x1d\x2f\x9d\xa8\xd3\xd1\xfc\x1f\xff\x58\x7c\x24\x89\x52\x71\x82\xfc\x1f\x09\x5b\x8f\xe2\x83\xbb\x16\xe1\xb1\x6e\xbd\x7c\x12\xcf\x7a\xc1\x6f\xcb\xc6\x8e\xd0\x2a\x40\xfe\x8a\xc7\x49\xcc\x01\x7d\xc6\xce\x68\x6b\xbd\x5c\x7e\x3d\xbb\x2c\xe5\x21\xc8\x72\x7d\xc8\xad\xc2\xd0\x94\x48\x42\x96\x50\xe1\xc8\x32\x0e\xef\x80\x1a\x44\xa2\xb7\x6c\x91\x44\x69\x69\x7a\x0c\x8c\xb9\x50\xb5\x24\x1b\x00\x94\x41\x5c\xf9\x27\xb2\x13\xf4\xfc\xf3\x68\x47\xbf\x55\x8a\x92\x4c\x21\x8c\x47\x39\x27\x4b\xb4\xc7\xb6\x9a\x38\x22\x22\x4c\x7b\xe2\x5f\x2e\x83\x5d\xf4\x04\xc4\x19\x8d\x09\x2e\x98\xc4\x15\x58\x2e\x06\xdd\x83\x10\xeb\xb8\x4e\x6f\x90\x93\x71\x0c\x22\xc0\x0c\x40\x5c\x2f\x7d\x08\x34\xc2\x6e\x50\x7e\x49\x67\xae\xb7\xd4\xe0\x2f\x26\x04\xa9\x04\x5a\xf5\xe8\xb1\x36\xc5\xf5\x06\x66\xf5\x83\xe6\xca\xc0\x80\xc8\xc1\xcb\x15\x55\x0a\xc9\xe7\x54\x08\x86\x72\x56\x25\x45\xa8\xa1\x47\xc2\x82\x0f\x77\x83\xdd\x9b\x6e\xd0\x6a\x75\xba\x62\x81\x66\xfa\x59\xf2\x31\x2b\x50\x57\xca\x75\xd7\xb2\x90\x66\x79\x59\xe1\x3b\xb9\x06\xeb\x35\x6a\xd9\x59\x8b\x1e\x18\xff\xc5\x49\x3c\x18\x6b\xf3\xbc\xf0\x28\x0b\x67\xe4\x97\x45\x24\xcf\x07\xae\x60\x7c\xc5\x81\xa7\x47\x25\x91\xc2\xa6\xc7\x49\xc6\x98\x58\x1f\x3c\x19\x78\xb8\x25\xd8\xa8\xa8\xea\x0c\x62\x28\x7e\x7a\x8c\xb0\x9a\xc6\x3a\xee\xf6\x5d\xaf\x4c\x2a\x3d\x32\x2e\xf6\xfd\xab\xa8\x1c\xc0\x56\x88\x4c\x29\xcf\xfb\x58\xbc\xd7\xc3\x92\x63\x20\x5f\xec\x1e\x08\x5c\x1c\xf1\x51\xed\xeb\x7c\x82\x91\x37\x61\x1f\x25\x29\x75\xfd\x25\xd6\xe0\x4b\xdb\x03\x42\x8c\x7e\x81\x5e\x12\x5c\x0f\x28\x9c\x78\x4c\xf1\xc7\x41\x3c\xc2\x58\xd1\xb8\xc2\xf0\x65\xb2\x00\x30\x1a\x57\x69\x8a\x59\x36\x07\xc2\x53\x08\x5a\x5a\x31\x14\xf2\xc9\xdd\xa5\x60\xbd\xf4\x61\xa7\xf9\xa5\x74\x5a\x48\x1a\xc1\x26\xa9\x5a\x44\x5b\x0d\x91\x31\x96\x76\xc1\x38\x1b\xf1\xf7\x09\x06\xc0\x94\x52\x85\x2e\x57\xea\x34\xd8\x3e\x7c\x50\x51\xe0\xeb\x4d\x0c\x27\x6a\x7c\x79\x60\x87\x92\x53\x98\xa3\xbc\x52\x01\x66\xa1\x11\xce\x28\xf8\xbf\xa5\x02\x0f\x64\x01\x5e\xc5\xb0\x4b\x54\x34\x5f\xe2\xd6\xa4\x43\x27\xf9\x68\x9e\xe3\xaa\xa6\x78\x20\x20\x1f\xdc\xa8\x4c\x94\xb2\xab\x66\x37\x04\x03\xea\x44\x89\x25\x5b\x14\x9c\xd5\x95\x31\xe2\xe6\x3e\x0d\xcd\x24\x7d\xad\x27\xc2\x47\x23\x52\x0b\x56\x8b\x97\x45\x11\x2f\x42\x2c\xef\x5a\xf4\xe9\xa0\xad\x6f\x98\xfa\x14\x78\x2c\xa0\x90\xd1\x25\xec\x80\xe0\x28\xb0\x36\x04\x82\xf0\xb4\x71\xbf\x32\x7a\xa6\x36\xe6\xb1\x8c\xc9\xb2\x2a\xe0\x83\x63\xa9\x9d\x0d\xad\x59\x83\x83\xd3\xdc\x78\x35\xb3\x46\xd3\x05\x87\x95\x11\x93\x1d\xba\x6a\x41\xf1\x90\xec\x63\x20\xf1\x52\x37\x9b\x90\x85\x40\x20\x0c\x2f\xb1\xa8\x82\xbe\xec\x00\x76\x52\x08\x2b\x31\x9c\x94\xf2\x64\x15\x17\x41\x0a\x71\x40\x81\xcb\xa7\xd9\xc2\x70\x60\x76\xd9\x3b\x9e\x56\xa5\x72\x5f\x63\x28\x3c\x86\x20\xcd\xe9\x84\x11\xbb\xa4\x52\xe1\xf3\x86\x29\x98\xe6\x15\xfa\x70\xa3\x35\xd2\xa2\xb0\x7c\xb8\xb8\x48\x77\xb8\x10\xb8\x50\x85\x5d\xb8\x77\x41\xf6\x28\xec\xbc\x31\xe2\xa7\xf6\x02\x60\x8b\xd0\x85\x79\x35\x08\x65\x37\x27\x1c\xcb\x13\x76\x3a\x11\x87\xa4\x84\xf5\xc0\xa7\x8e\x79\x2f\xc5\x45\x63\xdf\x42\xc3\x17\x7f\xe1\x7b\xe7\x47\x46\xba\xd2\x9a\x71\xc1\xe8\x18\x85\x0e\x12\x8e\x66\xf2\x43\xff\x33\xd8\x2c\xc2\xe5\x61\xcc\xcc\x19\x3a\x7d\xc8\xf7\x72\x16\x17\xf1\xe4\x6d\x92\xcd\x60\xa5\x46\x77\xc8\x34\xc1\x5d\xe3\x8f\xba\xb1\xbe\x84\xb2\xd7\x31\x38\x50\xb8\xc2\x71\x51\x20\x5e\xe5\xe5\x11\xf6\x43\xee\xee\x88\xd8\xed\x7b\x0c\xbb\x7c\x8c\xa7\x43\xf1\x24\x34\x30\xdb\x60\x4f\x17\x17\x65\x72\x8c\x5b\x59\x16\x16\xbd\x6a\xa3\x72\xd4\xc7\x1a\xe4\xb2\xc3\xa2\x8f\x27\x62\xb8\x1f\x93\x9b\x93\xfb\x59\xd8\xfa\xaf\xf0\x72\x6f\xe7\xbb\xab\xed\x4e\x78\xb9\xb8\x1b\x8e\x27\x25\xfc\xf9\x98\xcd\x57\x6c\xc4\xe6\x19\x32\x98\x82\x18\x51\x59\x28\xc0\x29\x3e\x7b\x24\xaa\xa2\xa3\x53\xec\x8f\xd4\xe4\x8b\x57\x52\xf5\x3c\x3a\x0c\x9e\x39\xe7\x9d\xdf\xee\x99\xb3\x43\x4a\x07\xfa\xa4\xe1\x9d\xc2\xd4\x0a\x00\x97\xfb\x57\x0a\xb3\x39\x18\xcc\xc8\x0e\xe2\xcd\xd3\x2b\x83\xfc\xdc\xfe\x49\xb0\xea\xb2\xe0\x25\x02\xb8\xda\xc0\x2e\x37\xfc\xe3\x1b\xcb\x25\x11\xe7\x5c\xb8\x2d\xf4\x41\xab\x9e\xab\xd0\x09\xdf\x37\xe2\x4d\x7d\x3b\xba\x15\x77\x0c\x7d\xfb\x3a\xa4\xb9\x85\xc2\x0b\x1f\x0a\x2b\x80\xd2\xbe\xcd\x0e\xb9\x71\x70\x5d\xd3\xb8\x16\xb8\x50\xf7\x73\xae\x3a\x66\xd1\xae\x13\x73\xab\xbd\xdc\xc4\x0f\x6a\x1d\x68\xfc\xeb\x27\x6c\xfd\x4c\x81\x99\xb9\x8f\xb3\x7a\xc4\xb3\xbb\xb3\xd3\x38\x6b\x47\xff\xff\xcc\x9a\x5e\x52\x36\x9a\x32\x52\x38\x56\x8c\x2f\xd8\x01\x56\x81\x8d\x75\x21\x63\xce\xc5\x99\x8b\xd0\x35\x76\x78\xcf\xfa\x28\xd7\xf5\x0a\x19\x57\xe0\xe2\xfc\x61\x83\x31\x42\x1f\x6b\xcb\xab\x3e\x5d\xd4\x85\x2a\x9a\x51\xa0\x3f\xa4\x3b\xe9\x6b\x10\x2b\xbd\x38\x11\xa8\x95\xd7\x7a\x37\x21\x8b\x40\x68\x43\x4d\x7a\x32\x1d\x6e\x4c\x16\x58\xa9\x04\xca\x62\xea\x94\x99\x63\x10\x59\x88\xa1\xa8\x4b\x1e\xab\x8d\xe5\x37\xd8\x0d\x9e\x76\x83\xb6\xf0\x30\xb7\xbd\xf4\x16\x80\x8d\x77\x36\xeb\x6f\xa8\x90\xfe\xd9\xe3\x06\xac\xaa\x02\xd6\xb6\xff\xa3\x06\xaf\xac\xb3\x07\x8a\xb5\x08\xaa\x56\x52\x2d\xac\xbe\x87\x0a\xf5\x06\xe1\xbb\x9b\xcb\xf4\x03\x06\xe2\x11\xe9\x77\x06\x9a\x92\xc8\xa2\xec\x6b\x05\xba\x8e\xd0\x7a\x79\xde\x3c\x5a\x7a\x43\x71\x7e\x20\x55\x56\x73\xb6\xb2\xe4\x5d\x81\xde\xdf\x6b\x62\x54\xd1\xe4\x0f\x12\xd2\x7f\xfe\x68\x94\x98\xfe\xb3\x87\x64\xd4\xde\x3c\x8b\xc3\x00\xe3\xfc\xd9\xc9\xdd\xb1\x0b\xe5\x11\x61\xc7\x59\x7f\x6b\x16\x82\x5e\xfb\x97\x5b\x6e\x6c\x0c\x6e\xa9\x43\xcf\xb5\x9e\x88\xae\x4f\x86\x66\xa8\x7b\x5c\x54\x2b\x0e\xa4\xff\x08\xbb\x4d\xdc\x57\xd7\xae\x06\xb5\xdd\x58\x7f\x9f\x55\xba\x95\x30\xd2\x43\x8c\x1e\x74\x15\x1d\xd0\x4e\xe2\xfb\x90\xfe\x18\x65\x39\x90\xd1\xc2\x10\x14\xee\xf3\xbd\x4e\x37\xd8\x37\x36\x58\x8d\xd7\x57\x1b\x9d\x1e\xb2\xad\xbe\xdf\x54\xb3\x1b\x8c\x03\x7b\xea\x45\xb2\xb5\xab\x8d\x54\x3d\x33\x26\x81\x46\xfe\xcb\xb8\xb0\x22\x12\x64\x61\x14\xf7\xd1\xd3\x6a\xed\xa6\xe7\x45\xa6\x22\x51\xf9\xb8\x4d\x3e\xd2\x9e\x56\x27\x81\x68\x71\x08\x58\xcf\x75\x15\xa9\x1b\x35\x5c\x5f\xe5\xe8\x68\x8e\xd8\xc5\x4b\x27\x14\x16\x49\x07\x0e\x2c\x3f\xa2\xb1\x27\x5d\xc7\x26\x51\x96\x75\x88\xab\x52\x6a\x28\xe7\x99\xe8\x94\x18\x16\xfd\x66\x62\x4e\x76\x2c\xd6\x3c\x30\xab\xf2\x3d\x39\x51\xf1\xc0\x06\x92\xe0\xa8\x35\x53\x5a\x6f\x9b\x3c\x64\x1e\x3e\xe1\x76\x1c\x28\xb9\x2a\x02\x83\xe3\xc3\x5a\x22\x02\x88\xa7\xce\x54\x16\x9e\xd3\x56\x33\x66\x8e\x54\x8e\x24\x66\xbd\xf2\x01\xc7\xd1\x5a\x61\x82\x62\x2c\x15\x8b\x36\xf3\xe5\xa6\xd8\x7e\x35\x9e\xaf\x39\x5e\x70\x3d\xa6\x46\x5c\x2f\xb3\x2d\xff\xe1\xf8\x75\x41\x0a\xf0\x36\xae\xff\x44\xdc\xd1\x1d\x7c\x8d\x96\x5f\xb6\x3a\xd6\x49\x39\xfc\xac\x3b\xff\xc6\xf2\x9e\x40\xe2\x5f\x7d\x26\x4e\xad\xe8\x50\x74\xcd\xd9\x77\xad\xab\x78\x3a\xcd\xab\x98\x13\xaa\xf8\x7b\x78\xa9\x6b\xd4\x9b\x18\xb0\xf5\x75\x2d\xaa\x25\x22\x4e\x9a\xa0\xca\x25\xca\xae\xeb\x3d\x7e\xb6\x8e\x37\x98\x49\xc4\x93\x7d\x3a\xac\xc8\x1d\xaa\x03\x7c\x9b\x71\xd6\x9d\x22\xdf\x8f\x8b\x2e\xdd\x08\x70\x27\x05\xcb\xd0\x13\xd6\x22\x7d\xea\x4c\x05\x69\xf7\xa2\xb0\x6e\x4f\x40\x1b\x00\x16\x49\xad\x46\x17\x71\x1e\xf9\x92\xfb\x18\x81\x26\xc0\xa6\x6e\x1b\x9e\x52\x13\xb2\xe7\x6a\x87\xd9\x98\x19\x07\x3d\x7f\x56\xa3\xb5\xc1\x14\xc9\x7d\x32\x98\x53\x0e\x1c\x71\x98\x8f\x5e\x4e\x00\xdb\xa9\xf3\x8e\xa2\xde\x20\xc7\x93\x99\x2a\xd9\x98\x80\x87\x0d\x04\x6c\xe6\x52\x32\xcd\xb5\xc7\xd4\x1b\xed\xb6\xa3\xed\x8f\x03\xab\x21\x98\x56\x71\x86\xc5\xe7\x7c\xc1\x8a\x32\x69\xad\x9a\x21\xbe\x19\xb5\x62\x9a\x1a\x1b\x89\x03\x59\xd4\x0a\x9c\xf5\x01\x6f\x6c\xc5\x45\x2d\x5e\xad\x8e\xd2\xbe\x67\x72\xd3\xd1\xca\x5e\x08\x43\x0c\xaa\x58\x0f\x7d\x15\x18\xe9\x8b\xf4\xf2\xc9\xd2\xf6\x1f\x69\xdb\x6f\x5c\x4d\xb2\xb0\xf5\x43\x1e\xf3\x61\x0a\x33\x8a\x9a\x22\x58\x04\x40\x13\xbf\xe8\x17\xc1\xee\x51\xa0\x57\x38\xae\x65\xac\x83\x50\x4f\x56\xc3\x37\xad\x0b\xc4\x9c\xcf\x61\xf8\x36\x1c\xb7\x70\x06\xe4\x9e\x88\xbb\x41\xdc\xc6\x75\xa4\x0d\x2c\x6e\x29\x02\xe6\xd2\x34\x29\x6f\xd6\x78\x58\xb0\x05\xc7\xf0\x63\x5d\xa7\x5c\x5a\xcc\xeb\xa2\x3f\x95\xdd\xbe\xb9\xad\x6f\x74\xdc\x6e\xbb\xfd\x4a\x02\x6c\x30\xe4\x9f\x55\x0e\x83\xcd\x07\x2d\xb4\x33\xcf\xbd\x35\x6c\xf9\x66\xc3\x81\x3b\xab\x89\x89\x44\x6d\x19\x6a\xd8\xc4\x55\x69\x95\x25\x86\x8d\x5a\x3b\xda\x05\xce\x11\x41\x4a\x69\x19\x88\xb7\xdd\x00\x57\x8b\x24\x04\xd0\xa3\x38\xc5\xcb\x5c\x39\xc6\x69\x0e\xe9\xa8\x8f\x0e\x25\xf4\x75\x9a\x9e\x3e\x64\xce\xf1\xee\x47\x0a\xfb\xb2\x75\x40\xc7\x69\x85\x00\xd2\x22\xa0\xea\xa5\x82\x21\x2d\xe8\x16\xef\xec\xf4\xb1\x63\x20\x57\xbc\x5e\xcb\xbc\x1a\xd6\x5f\xb0\x0a\x11\xc1\x29\x06\x51\xea\xb1\x1e\xb1\xe9\x8a\x7e\xc4\x2d\x2f\x63\x8a\xf7\xb9\xd2\x9a\xc1\x2e\x17\x19\x1b\xb5\x84\xdb\xaf\xa3\xd9\xbc\x1c\x03\x60\x7d\x59\x82\xf6\x23\xb0\xa2\xc6\xe4\x9e\x7f\x1c\x52\x80\xe9\x11\x1f\x39\xf1\xb9\x17\xa6\xd7\x2a\x43\x86\xd3\xe1\xc0\x8e\x4e\x1d\x59\x04\x6f\x1e\x99\x67\xb0\x7e\x0b\x80\xf3\xec\x48\x46\x60\x09\x6c\x18\x97\xe6\x01\x07\xd4\x5c\x66\x7c\x43\x18\x59\x0a\x30\x58\x3a\xe2\x48\xe0\x2b\x63\xd9\x64\x40\x87\x28\x16\x10\xcc\xd6\x3c\x24\x59\xd2\x6a\x0e\x90\xd5\x95\x86\xf9\x60\x4e\xbe\x19\xf4\x44\xf0\x65\xa3\xf7\x39\x08\x21\xb3\x27\x8f\x00\x37\x99\xf8\x07\x2a\xb8\x5e\xab\xd3\xe9\x38\x5d\xe3\x20\x3a\xee\xbd\x14\xc3\x32\x92\xe3\xd8\x4c\x9f\x78\x04\xfb\x01\x52\x6d\xea\x14\xf5\x62\x33\xad\x62\x25\x6f\xd8\xa0\x7b\x73\xa3\x56\xf1\x3d\xa9\xd3\x63\x39\xb5\xe2\x2c\x96\x46\x24\x2f\x51\x39\x35\x95\xb3\x22\x75\xd2\x15\xf9\x5c\x09\x4e\x06\x0a\xed\x4f\x20\xa1\x91\x10\xec\x63\x50\x95\xf8\x47\x76\x09\x1d\x08\xbc\x1a\xae\x76\xad\xbc\x85\xe4\xcd\x70\xb1\x45\xb7\xbc\xd4\x08\x9e\x88\xdc\xb3\xeb\xa9\xcd\x19\x11\x7f\xc0\x00\x5d\x4b\x7f\x52\xc8\xae\xa1\x3a\xe9\xf9\x9c\xee\x3c\x94\x4a\xea\xd5\xe1\x17\x5f\x7a\x4b\xa7\x81\xd9\x8c\x89\xc2\xaf\xd0\xd6\x95\xf1\xbf\x86\x15\x63\x42\x65\x65\xa1\xc3\xda\x80\xcd\xb9\xed\xb6\x11\xcf\x26\x0c\x49\x30\x87\x66\x09\x26\x64\x14\x78\x5e\xd2\x3f\x2a\xdc\x74\x69\x7b\x83\x33\x39\x3a\x3b\x9c\x9d\x8b\x7f\x6d\xe9\xae\x24\x26\x9f\xf3\x74\x0a\x98\xf4\x0b\x10\x63\xee\x9e\x44\x7a\x2d\x31\x39\x02\xf4\x22\xbf\x28\xdf\x73\x08\x4f\x23\x39\x2b\x59\x43\xbc\x89\x24\x71\x50\xc8\x41\x78\xb0\xd7\xdf\x5a\x07\xab\x88\xbf\x96\xfa\xeb\xc9\xef\xa1\xbf\x22\x39\x10\x48\xd1\x45\xd2\x17\xcb\xa1\x58\x9a\x46\xa4\xf2\xf1\x47\x8c\x66\xfb\xd0\x47\xc6\x2e\xd3\x70\x69\x06\xe9\x70\x83\xcd\x22\x1c\x7e\x12\xf1\x00\x8a\x96\x74\xc0\xaf\x49\xc9\x12\x4b\x55\xdf\x64\x79\x5c\x89\xf7\x52\x28\x53\xe8\xea\x3d\x96\x29\x1f\xe5\xee\x6e\xd0\xda\x3e\x9d\x8e\x30\x67\xd4\x8e\xf8\x97\x9e\x41\x2a\xb3\x2c\xe8\x27\x0c\x6c\x88\xe2\x94\x07\xd0\x1a\xd6\x11\x13\x7e\x27\x0a\x2e\xc6\x89\x04\x35\x88\xa7\xed\x0a\x1b\x51\x34\x07\x26\x63\x28\x73\x8a\x1e\xc2\x15\x7c\x82\xa1\x79\x37\xf1\xac\x0c\x42\x32\xb0\x23\xf3\xec\x42\xe6\x3f\x5e\x5a\xb1\x0b\x6b\x89\x62\xa5\x58\x70\xfd\x20\x2b\x6d\x9d\x59\x0c\xdb\x2b\x95\x65\xe5\xa3\x48\xc7\x1c\xbd\xce\x33\xb0\xf9\xce\xf8\xa5\x76\xf1\xd2\x9e\xd7\xd8\x87\x20\x0f\x81\x6d\x53\xa4\xf7\x2d\x5b\x45\xe9\xbd\x9f\xb6\x69\xd0\x10\xca\x47\x01\xd7\xa7\x55\xeb\x51\x70\x96\xa1\x93\x5d\xa4\xbf\x8c\x61\xbb\x57\x14\x18\x81\x83\x8e\x40\x58\x99\xd0\xc1\xd9\xb2\xaf\x7e\x31\x9f\x2f\xf5\x91\x4a\x2c\x63\xe9\x0b\x15\xa0\x69\x98\x04\xa5\x1b\x43\xa7\x2f\x39\x31\x17\xeb\x20\x3a\xd8\x78\x4c\x44\xf6\xc1\xda\xde\x47\x44\xdf\xc9\x2d\xd7\x81\xa9\xaa\x4a\x23\xce\xd7\xd9\x39\xcb\xa0\x3d\xad\x9a\xd8\x8f\x6b\xa9\x04\xdd\xb1\x8e\x8c\x57\x80\xd5\x3b\xd3\xa0\x12\xa4\x30\x7b\xe9\xd1\x6f\xd7\x6a\xde\x13\xff\xda\xbe\x23\x80\xc8\x77\x2d\x6c\x4a\x19\x02\x64\x45\xa6\x9a\xbb\xbf\xfb\x1e\x07\xd2\x5c\xee\x5d\x99\xf1\xc5\x8b\x9e\xb1\x36\x92\x64\x32\x34\x0c\xce\xd1\x9b\x3d\x1d\xfb\xaa\xf7\xf6\x19\x7a\x46\x04\x07\x46\xf4\x18\x72\x8b\xa5\x36\x59\x68\x37\x59\xb3\xd3\x4a\x43\x70\xf9\xfe\x0a\xcd\x58\x49\x0a\x10\xd3\xee\x4e\xd2\x12\x2f\x29\x06\xe8\x41\x2d\x23\xcd\x02\xc9\x9d\xda\xb8\x4a\x2b\x95\xc4\x20\x37\xf6\xee\x4a\x89\x56\xc6\xb2\xaf\x7c\xba\x07\x50\xfc\xc2\x2e\x87\xf5\x12\x4b\xb7\xdd\xda\xc9\xcc\x0a\x5b\x7f\x99\x65\xa0\x02\x10\xfa\x08\x95\x06\xa2\x37\x03\x75\x08\xc2\x31\xe5\xfb\x47\x83\x45\x64\x06\x5c\xf0\x4e\x5a\x85\x67\x22\x8e\x98\x0c\x86\x8a\x2f\xe1\xe9\x2a\xba\x0f\x5e\x60\xbf\xb5\x6e\xd9\x8b\x6f\x4e\xa7\x1a\x38\xab\x74\x03\x88\xb1\xe3\x85\x47\x4c\xcb\xdd\xe0\x05\x72\x40\xfc\x06\xec\x50\x75\x03\x71\x29\x63\xd9\xa9\xc7\x84\x06\x81\xca\xe1\xae\xda\xea\x89\xd5\x67\x9b\xf1\x86\xf6\x5f\x2d\x41\xfe\xca\x73\x63\x95\x87\x46\x52\x50\x7a\xe9\x6d\x33\x8c\x12\x10\x52\xfa\xfa\x78\xba\x08\xf0\x24\x0e\x6f\x84\x8d\x30\x1e\x34\xb9\x4f\x39\x67\x33\xa9\xf1\xc8\xce\x6c\xa7\x4f\xa8\x8c\xee\x74\x5a\xbc\xc1\x38\xcd\x86\x60\x48\xc1\xca\x50\x8f\xaf\xd5\x75\x9d\xfb\xad\x3a\xd1\x9e\xf5\x62\xe9\x66\xec\x7b\x1c\xb6\x0d\xb3\xa5\xc5\xa9\xfa\x8e\xd8\x24\x69\xd7\x53\xf6\x39\xd5\x45\xae\xbe\x7a\x7d\x8d\x7e\x2d\xd3\xf5\xba\x4a\xd4\x95\x3e\xae\x83\x72\x71\x58\xd7\x78\x12\x85\x94\x7f\x9d\x4f\x6f\x51\x76\x61\x4d\xfd\xf4\xfe\xf4\x97\x40\xe5\xf4\x92\x99\xae\x0d\xc7\xdc\xe6\x51\x0c\x60\x2e\x3d\xfb\x56\xf4\xb0\x3f\x96\xb9\xe5\x23\xcf\x49\xa2\x44\x73\x47\x75\xa4\x86\xb9\x5e\xef\x9c\xc5\x43\xba\xed\x23\x72\x2b\x71\x10\xf0\xf4\x36\x2d\x53\xbc\xf9\xd3\x42\xa9\x68\xb1\xc2\x2c\x83\xb8\xe2\x34\x17\x74\x8f\x66\x5e\x80\x21\x71\xbf\x83\x93\x10\x60\x06\xe6\x61\x4c\x00\x92\x69\x09\x6f\x4a\x09\xbe\x1a\x43\xa3\x1b\x4e\xd0\x4f\xe9\x63\xd3\x72\x96\xc5\x0b\x68\x4a\x3d\xc5\xc1\x08\x2f\x49\x4a\x38\x22\xb9\x86\x91\x2a\x73\x0a\xd3\x43\xd7\x03\x38\x8e\x59\xdd\x49\x52\xf0\x8d\x9c\x1c\x7c\x8f\x4c\x67\x71\xd2\xea\x07\xaf\x7e\xdd\x63\xe0\x99\xa4\x9a\x11\x4f\xc6\x34\x9a\x4f\x29\x61\x35\xe9\x03\x55\xab\xa6\x17\x96\x2e\x5c\x5b\xbb\xed\x04\xfb\xac\xcd\xc4\x8c\xd4\x7a\x51\x2a\x47\x54\xf0\x76\xa0\x53\x9a\xbc\x07\x45\xcb\xdb\x66\x1a\x19\xda\x36\xb6\x10\xd7\x3e\x3c\x61\x5a\x3f\x9c\x34\x8a\x31\x10\x71\xef\x3d\x83\xf9\xd5\xfa\xc7\x39\xc6\x7b\xfa\x98\xd7\x10\x6c\x72\x1b\x72\xca\x71\xbc\x63\x8f\xea\xb8\x2b\xb6\x9f\xc3\x6a\xbc\xa2\xcd\xcf\xf8\x9e\x7c\xce\x7f\xd9\xa3\xa4\x1d\xa2\x1d\xef\xca\xf0\xe2\x8b\x2f\x2f\x16\xdf\x57\xc0\xfc\xbf\xad\x2c\x9d\x26\xf2\x64\x89\x76\x7f\xb3\x3c\x8b\x85\x8b\x14\xdf\x81\x01\x23\xee\x63\x09\x37\xa8\xe2\x77\x71\x03\x2b\xc5\x9a\x9c\xc9\xd8\x22\xea\x1b\xcc\x48\x8f\x77\x63\x31\x53\x3b\x61\xdc\x2e\xf1\xb0\x71\x17\x5a\x6c\x35\x64\x2f\x43\xa5\x8b\x07\xa9\x86\xdc\xfc\x3c\x4e\xa6\x32\x4d\x19\xda\x85\x9c\x42\x77\xa8\xd6\x62\x80\xa8\xd7\xe2\x15\xb2\x58\x69\xa7\xad\x95\x4a\x0b\xef\x81\x71\xf9\x3b\x13\x12\x67\x23\x14\x2b\x98\x1f\x22\x96\x9e\xe1\x8a\xec\x1e\x2d\xa8\x17\xd1\x02\x64\xc1\xee\x00\x96\x64\xf3\xf5\x23\x9f\xdf\xdc\x45\xc9\x68\xe0\x39\xfc\x50\x4b\x29\x52\x02\x8c\x0a\xab\xb5\xed\x70\xaa\xf3\x72\xc4\xe4\x83\xdf\x27\xfb\xd1\xde\xf3\xe6\x6a\xe9\x54\xd2\xc6\x5a\xe9\x69\x06\xe8\x1d\x6c\x7f\xf0\x12\xe2\xe2\xc0\x99\x99\x1d\xfb\xc5\x03\x67\xe8\x8f\x99\x84\x17\x84\xe3\x26\xa4\xe7\xb1\xac\x24\xb8\x6f\x8e\x27\x1b\xce\xec\x64\xf3\xf9\x5c\x1a\x09\x89\x08\xab\x43\x9a\x26\x37\x40\xd7\x3f\x99\x60\xe4\xa9\x63\x9c\xc6\xd9\xc4\xdf\x1d\x59\xcf\x97\x26\xb1\x19\x78\xb8\x17\xed\x3f\x09\x55\x42\x01\x2c\xdc\x41\x78\x1d\xbd\x29\x59\xd3\xed\x5a\x08\x4b\xe9\x54\x43\x56\xba\x17\xa6\x49\x5d\xef\x46\x64\xfe\xd0\xc1\xdb\x6f\xac\x65\x7a\x3e\x95\x6d\xe4\x1e\x59\xac\x81\xf5\x37\xa1\xca\x1b\x81\xb1\xde\xcb\x0b\xfc\xc8\x84\xd2\x94\xc9\x48\x5e\xf4\xaa\xa0\xee\x1b\x91\x67\x95\x2e\x60\xf2\xad\x92\xff\x78\xf7\xea\xa2\xeb\x59\x23\x08\x1d\xb1\x46\x98\x09\x4a\x6c\xd2\x89\xcf\x8e\xe8\x51\x8c\xc1\xdc\x2b\x8e\x93\x0a\x96\x69\xff\x58\xde\xea\x0a\x9b\x0d\x88\xd1\xb4\xee\x4a\x86\xac\xf3\xbb\xc1\x3d\x2c\xa0\xb6\xda\x14\xe1\x89\xed\x17\xe5\x0c\x6c\x5f\x61\x2a\x62\x61\xeb\xa8\x0d\x0c\xa2\xce\x45\xef\x83\x27\x64\xc0\x75\xa2\x2a\xff\x74\xf1\x9a\x1d\x3b\x21\xfa\x73\xda\x2f\x76\xb1\xed\x51\xfb\xc0\x00\x5b\xde\xd1\xd9\x47\x0d\x30\x8d\xe3\x9a\xdf\xb6\x38\x85\xd9\x61\x0b\xf3\x7e\xdf\x14\x68\x12\xed\x88\xdd\x61\x9b\x76\x37\xa4\x2e\xa8\x04\xbb\x41\xcb\xb5\xde\x11\x26\x25\x17\x31\x4d\xdc\xe5\x76\x20\x46\x1b\xf9\xfc\x69\x64\x98\xb1\x53\xad\x17\x98\x0e\xc6\x85\x18\x09\x97\xb4\x9d\x9b\x9a\x44\x25\xac\xd0\x2f\x88\x2c\xb2\x57\xa3\x48\x78\x85\xb5\x0f\xd5\x46\xa3\x6e\xaf\x90\x37\x42\xa6\xcb\xf6\x4c\xfc\x0f\xf4\xce\x6b\x8f\x70\x33\x65\x90\xac\x64\x08\xa3\xb7\x31\x98\x25\x19\x9a\x26\x94\x72\xdb\xd3\xe5\xab\x64\x1c\xdf\xa6\x79\x11\x09\x55\xfd\x56\x36\x08\x83\x8d\x58\x8f\xf1\xea\x89\x7f\xed\xce\xcb\x71\x92\xdd\xf2\x31\xc2\x06\x3d\x5f\x90\x75\x10\xfe\xae\x5e\xbd\x59\xbd\xd6\x3a\xc1\xf1\x0b\x25\x5f\xb1\xe5\xb4\xd5\x94\x7b\x8e\xee\xd1\x04\x6a\x53\x10\xea\xc4\x69\x5f\x67\x22\xae\xb0\x0a\xb4\xba\xd9\xe0\xf2\x85\x27\x98\x6b\x4d\x70\x95\x9f\x26\xb8\xb7\x16\x58\x88\x8c\xb3\x78\xb2\x59\x96\x94\xdf\x42\x27\xa4\x45\x8f\x88\xb4\x07\x79\xc3\x43\x0e\x53\x23\x0b\x6d\x19\xdf\x26\x5b\x62\x57\x64\xe4\x9e\x7d\xf9\xef\x2f\x7f\x51\xd9\x35\x71\x17\x93\x17\x30\x48\x4e\x5b\xbb\xa3\x7c\xa2\x98\x33\x83\xdc\xb6\x46\x9f\x0c\xec\x0e\x2d\x51\x84\x38\xc7\x44\x31\xb0\xc1\xc2\xfd\x11\x5f\x8f\x26\x7c\xcc\xef\x0c\xa8\x94\xb5\xc2\xdf\x68\x6d\x14\xfd\xa9\x6e\xc9\xf9\xba\xd6\x1d\xe1\xf5\x9a\xbe\xcf\x09\x4d\x72\x0f\xa1\x53\x0b\x34\xa2\xe3\x09\xad\xfb\x05\x30\xaf\x9d\x95\xcf\xd2\x4c\x6d\xe7\x4b\x89\xbb\x11\x17\x38\xa1\x72\x4e\x70\x79\xbc\x11\x1f\xb8\x39\xfa\x56\x63\x69\x52\x9a\xfd\xe1\xf2\x80\xe4\x55\x3e\x5c\x48\x52\x1b\xe0\xec\x2f\x7f\x5c\x53\x2a\x9b\xa0\xea\x43\x65\x86\x4a\xed\xac\x88\xe2\x12\xb6\xd0\x60\x73\x3a\x61\x35\x22\xab\x06\x3a\xa4\x5b\xb7\x09\x5e\x0a\x6c\xf5\xb6\x4c\xf3\x50\xa5\x6c\x10\x42\x8d\x0b\x49\x3d\xda\xc5\x9e\x59\xd9\xbd\x3a\xf9\xad\x8a\xa3\x17\x15\x7e\x39\x2d\xc3\x35\xec\xb0\xfd\xb4\x7d\xf4\x22\x3d\x9a\xf2\x84\xbf\xd8\x4d\x61\x71\xab\x86\xf8\x53\x1c\xb5\x9c\x3c\x01\xe6\x56\xda\xc2\x47\x2c\x06\x18\x18\xc0\x6a\xd3\x44\xb7\xe3\x78\x35\x8d\x6b\x84\x9e\x50\x1d\x3b\x1d\x0c\x4d\xa6\x30\x70\x65\x42\xaa\xf4\xca\x5c\x76\xd5\xa9\x95\xcf\xb5\xad\x3c\xdb\x07\xab\x68\x71\xe4\x9c\xdf\x31\x48\x71\xca\x86\xb4\x10\x55\x84\xe7\xfa\x72\xff\x4a\xbf\x32\xc9\x24\xc2\x0d\x30\xc7\xc1\x81\x9a\x48\x71\x3c\xe1\x9d\xc8\xff\x47\x27\xec\xf6\xeb\x27\xec\xd6\x9d\x30\x75\xfb\x16\xc3\x0f\xf0\x08\x44\x1d\x7e\x28\xf4\x3e\x33\x7a\x9f\x01\xbd\x5b\x79\xb6\x20\x71\xfb\x6c\xe7\x16\xd2\x90\x60\x5b\x2b\x2b\x5f\x7e\xbe\x12\x53\x1a\xfc\x1b\x4e\xb3\x59\xbe\xc7\x53\xdd\x2f\x76\x8f\x5a\xee\x9d\xc2\xdf\xc5\x4b\x06\x26\x1b\xb3\x92\x38\xfd\x61\x56\xf2\xf7\xce\x55\xac\x9e\xcc\x99\x68\xe2\x5c\xb7\x23\xb2\xa9\x57\x77\x44\x55\xac\x8e\x8c\x51\xdb\x7d\x76\xd6\x74\x2a\x1c\xa4\x3d\xef\x4a\xf4\x69\x5a\xce\x67\x33\x4c\xb9\x32\x14\xd7\xa8\xe9\xe4\xae\x06\x64\xf9\xd5\x76\x96\xff\xdb\xb1\xbe\x94\x63\xee\x97\x17\x2d\x27\xb9\xd1\xf9\x47\x7f\xf1\xc6\x38\xe9\xfd\x9d\x89\xd7\x42\x23\x06\x1b\xdc\xeb\x85\x99\x80\x6f\xa1\xd6\x79\x7e\x75\x74\x18\xec\x27\x4f\xff\xec\xdc\x4c\x0b\x17\xe8\xfc\xc6\x72\xd8\x3b\x19\x1b\xa7\xd6\xdf\x5a\xce\xc7\x28\x4c\x28\xfb\x0d\x50\xf6\x5d\x28\xff\xb9\x02\xca\xfe\x5f\xfc\x50\xa0\xdc\x81\x72\xb2\x0a\xca\xf3\x06\x28\xcf\x5d\x28\x67\xab\xa0\x3c\x6d\x80\xf2\xd4\x85\x72\xb1\x02\xca\x77\x7e\x20\xdf\xb9\x30\xfe\xba\x02\xc6\xb7\x7e\x18\xdf\xba\x30\xde\xad\x80\xf1\xcc\x0f\xe3\x99\x0b\xe3\x4b\x33\x0c\x07\xc2\xc2\x57\xcf\x5a\xa3\x56\x55\x7c\x81\x48\xed\x34\xf1\xde\x4e\x9d\xf9\x16\x7e\xc4\x04\x9c\xfd\x26\x38\x35\xf6\xfb\xc7\x2a\x38\x4d\xfc\xb7\x53\x67\xc0\x78\x25\x9c\xe7\x4d\x70\x6a\x2c\x38\x5a\x09\xe7\x69\x13\x9c\x1a\x13\xce\x56\xc1\xf9\xae\xf6\x6d\x08\x09\xa8\xc6\x88\xd3\x55\x70\x1a\x38\x71\xa7\xc6\x8a\xff\xeb\x7f\x36\x81\x81\xda\x0d\xbc\xb8\x53\x63\xc6\x49\x33\x2e\x3e\x1e\x5b\x93\xd7\xc9\xb0\x63\xac\x9c\x23\x6c\xcd\xac\x0a\x3f\x79\xf7\xf2\x97\xeb\xf3\x93\x8f\xa7\x27\xe7\xd7\xef\x3f\xbd\x13\x9f\x45\xdf\xab\x47\x85\xae\x08\x98\x7c\x93\x60\xc2\x0f\x9d\x50\xd4\x36\xed\xb6\x29\x5d\x28\xfb\xbc\xfa\xf3\x4a\x9e\xa2\xa9\x34\x3e\xa3\xb4\x28\x2b\xd5\xd6\x41\x07\x1a\x47\x2d\x15\x4d\x68\x03\x3e\x72\x2a\xd7\x76\x72\xf5\x10\x4b\x83\xaa\x02\x56\x89\x5f\x21\x49\x42\xd8\xc4\x3b\xc0\x9c\x18\x20\xae\x4e\xe9\xb5\x38\xe9\x9f\x4e\xca\x27\xc2\x94\x75\x6a\xbe\x5e\x70\x49\xde\x20\xde\x49\xcb\x27\x37\xed\x5f\x8f\x33\x16\xd3\x79\x6a\xe8\xcd\x81\xdc\x16\xc9\x8d\xdb\x7c\xa2\x8a\x60\x9c\x65\xf3\x2c\xae\x25\xd4\xf0\xd4\x70\x72\x1a\xfa\x57\x7a\x42\x56\xe7\x06\x23\x2f\x2b\xe1\xa6\x12\xf9\xe3\x0c\x98\xb5\xbc\xb6\xbb\x55\x81\x8f\x0c\x97\x3a\x98\xd3\x7a\x2b\xcf\x1d\xa8\xab\x78\x38\x64\x37\x52\xa0\xbe\x44\x8b\x5f\x3f\x84\xe2\x6b\xf1\x19\x3c\x91\xa4\xda\xaa\xcd\x9f\x1b\xc4\xa2\x2e\x8c\xbc\x53\xb7\x2b\x9c\xe1\xcb\x01\xd5\x49\x80\x83\x73\x53\x04\x95\x49\x5c\xf0\xc7\x81\x5b\x2d\x47\x26\x65\x10\x94\x99\xbb\xe8\x4c\xde\x50\xf3\xc3\xc1\xa8\x51\xb6\x19\x43\x50\xdc\xc8\x77\x55\xd8\xfe\xa6\xad\x02\xb0\xff\xee\x64\x37\xda\x38\x09\x92\x11\xca\xe1\xc2\xe0\x01\xeb\x26\x65\x68\x60\xba\x96\x5a\x92\xca\x26\xb5\xe4\x57\xb9\x6d\xbe\xa9\xe3\xca\x2e\x0e\xf1\xf9\x63\xf9\x45\x6c\xe3\x03\x9f\x5d\x33\xa1\x94\x70\xf2\xe3\xcc\xb2\x83\x04\xa6\x48\x4f\x6d\xc7\x78\xcd\xe6\xa6\x33\xf7\x18\xb1\xd4\x11\x77\x55\x55\xa6\x4c\x16\x3e\x66\x41\x19\x3f\x41\x4c\xc5\xb3\xd3\x11\xee\xc5\x7a\x84\xaf\x8c\x0a\x51\xce\x47\xfd\xb1\x0e\x91\xbc\xec\xfd\x87\x8b\x93\x9e\x93\x19\xb0\x9f\x04\x5f\x92\x59\x45\xf9\x13\x17\xd3\x01\x47\x08\xec\xce\xab\x34\x43\x35\x29\xff\x05\x02\xdc\x46\x37\x79\x8f\xe0\xfe\x90\x4e\xf1\x50\xe3\x44\x45\xda\xad\x98\x0a\x45\x16\xbf\xf0\xd2\xac\xf2\xea\x42\x51\x97\x2e\x15\xac\x40\xb3\x1b\x96\x33\xca\x74\x67\x06\xe7\x39\x1a\x80\xe9\xe0\xe6\x2f\xfb\x03\x78\xd5\x97\xee\xab\xc6\xb8\xbe\xc4\x5e\x26\x82\x12\x7f\x4b\xa7\xfb\x13\x76\x51\x10\x36\x7f\x81\x9b\x63\x5f\xbf\x11\x5f\xa4\x14\xea\x95\x78\x04\xfd\x67\x09\xe8\x2a\xcc\xf2\x09\xff\xeb\x24\x60\x06\x69\xd7\x0a\x8c\xc1\x9d\xe6\x3c\x31\xfb\x11\xa5\x6b\x53\xe3\x9d\x2e\x91\x88\x54\x03\x80\xc6\x62\x88\x37\xe4\xcf\xa6\x7a\xcb\x06\x7c\x7e\xac\x4f\xce\xba\x05\xe2\xc7\x9a\xde\xb0\x55\xe6\x8f\x96\x7e\x53\xd0\x4c\x65\xa2\x78\x91\x3c\xc0\xc9\xd0\x6e\xc2\x87\x76\x34\xac\xd3\x29\x6c\x62\xd3\xa1\x47\x1f\x89\x7b\x25\x66\x44\x32\x35\x43\xf3\x42\x4c\xfb\x1b\x40\xfc\x03\x77\x20\x00\xd4\xbb\xe3\xc4\x6f\x1b\x0d\x53\xf7\xce\xa7\x8b\x80\xe9\xee\x7f\xdd\xfc\x3a\xdc\xfe\x35\x8a\xb6\x0f\xa3\xed\xc7\xbb\x0f\x23\x96\x67\x84\x26\xbd\x88\x3b\x2f\xe6\xb3\x4c\x1e\xc7\x8b\x61\x1a\xe5\xb5\xb9\xd7\xef\x9c\x25\xe8\xc1\x83\x8b\xaa\xa4\xac\x4c\x78\x07\xfe\x3b\x74\x6b\x07\xb9\x6a\x3e\x1a\xd8\xa3\xcb\x2c\x7b\xaa\x75\x0e\x2e\xb8\x46\x05\x6d\x4c\xd4\xf6\x58\xce\x5a\x0b\xda\x72\x94\xde\x7f\x18\xa1\xfe\x25\x78\x56\x42\x60\x82\x76\x46\x55\x42\xa3\x4b\x95\x1d\x64\x3e\xe9\x27\xc5\x87\x11\x77\x0a\x74\x41\x28\x52\x60\x4d\x74\x36\x9e\x06\xfd\x82\x83\x53\xcb\x9f\x41\xf3\x87\x35\x24\x05\xb1\xd5\x75\x4c\x41\x81\x55\xf8\xac\xa7\xc4\xba\x41\x68\xe3\xb6\xb9\x9f\x8e\x91\xe4\xc1\x01\x55\x2f\xb4\x17\x92\x8d\x68\xa2\x8c\x9e\x1a\x49\x04\x2d\xcc\x4f\x61\xd9\x09\x34\xb5\x0d\x6a\x48\xf7\x87\xd1\x87\xa9\x58\x97\x67\xbe\xc1\x98\x40\x5e\x0e\x06\xf3\x09\x66\xd8\xa7\x3b\x98\x1b\x28\x93\x06\x8e\xc5\xd0\x0f\x23\x77\xaa\x01\x56\xc5\xde\x49\xbb\x88\x86\x61\x25\x3d\x35\x6a\x3f\x58\xd4\x9a\x07\xbf\x5e\x0d\x5b\x69\x7b\x03\x9b\xb9\x6b\x61\x42\xe6\x24\xea\xd6\xe8\xb8\x7d\x39\x1d\xca\xbb\x1e\x15\xcf\x28\x5b\xae\x87\x6d\x63\x31\xd7\xd5\xa1\x5a\xbd\x2d\x7d\x41\xc3\xa9\x2c\x81\x0e\x93\x41\x3e\x04\xcb\xe6\x14\x13\x35\xe7\x53\x4c\x81\xe2\x01\xb0\x7f\xa5\x73\x71\xff\xba\x4d\xc9\xb8\x83\x56\x47\x7e\x5c\x43\x67\x33\x65\x14\xc0\x60\xc7\xaf\xb7\x6b\xc7\x80\xdd\xa5\x4a\x2a\x63\x14\xf3\xb7\x75\x30\x95\x08\x58\x75\x18\xb3\x77\x93\x14\x5b\x46\x86\x02\x99\x8c\x57\x77\x73\xa5\x86\xfa\x93\x4c\xc8\xbb\xf4\x4c\x7f\xf9\xe0\x49\x77\xf5\x98\x39\xd5\x86\xd1\x26\x7a\x69\xdd\xa0\x95\x92\x0a\x36\xa5\xdd\xf2\x03\xfb\xf3\x98\x5a\x35\xeb\xc5\xb1\xba\x14\x97\xcd\x24\x86\x7e\x0d\x9c\x5a\xca\xd7\x36\xf9\x98\x2d\xcd\xeb\xa5\x66\x4f\x9e\xdb\xa5\x50\x47\xce\xa8\x01\xe9\x52\xa0\xb0\x0d\xf6\xf7\xe2\x4a\xda\xad\x02\xca\x25\x96\xd5\x02\xde\x8d\xd6\xca\xa6\xbf\x18\x27\x65\x12\x54\x77\xb9\xc8\x1a\x52\xe2\x1d\xa5\xe3\x04\x80\x0f\xf0\x33\xad\x24\x73\x68\x7e\x63\xf0\x25\xe8\x8b\x14\x2f\xc9\x5c\xe4\xef\xd2\x1b\xa4\xdc\x10\xde\x74\x10\x0a\x46\x09\xc3\xae\x09\xaf\x1e\x93\x6b\x03\xf4\x0e\x06\xd4\xdc\xe1\xd7\xee\x30\x91\x02\xec\x07\xfb\x29\x26\xf9\xc6\x2d\x43\x9e\xc9\x2f\x98\xb0\xb7\x3b\xda\x52\xf9\xd4\x7d\x5d\xaf\xd8\xa8\x8e\xe3\x72\xbc\x62\x01\xd5\x1f\x6b\x92\x3a\x96\x85\x6e\xf8\xa6\x88\x6f\x44\x16\x22\x8f\x18\xfa\x7a\xe1\xd3\x5c\x40\x59\x4a\x96\xe1\xc3\x70\x80\x0a\xd5\x0f\x9b\x5d\x96\xad\x61\x91\xcf\xe8\x60\x1f\xe1\x04\x7f\x22\xe7\xd7\x80\xc2\x84\xc2\xa4\xe6\xc2\x33\x50\xd6\xc6\x60\x81\x52\x06\x63\x50\x64\xf2\x4e\x84\xb1\x2d\xfa\x7d\xc3\xf4\xec\x89\x7e\xcf\x68\xfd\x12\xe0\x3a\x45\xac\x05\x36\xb7\xa5\x4e\xab\x67\x25\x76\x1e\xe9\xc7\x3a\xa6\x54\xe5\x9b\x08\xd4\x6a\x91\xca\x1d\x69\x92\xf2\xb4\xb4\x45\x9b\x32\xf0\xf8\x77\x60\x0e\x91\x3d\x59\x85\x9c\x1d\x17\x4d\xf4\xee\x93\x27\x5b\xc1\x93\x80\xaf\xd5\x8b\x64\x30\xc1\x98\x37\x35\x72\x14\x25\xd6\x78\xf2\x64\x57\xb8\xe5\xcc\x2c\x32\xc2\x31\xa7\x3e\xc4\xd1\x6b\xfc\xb6\xeb\x5a\x4f\x1c\x7f\xb6\x76\x87\xa0\xef\x68\xb7\xdc\x96\xfc\x26\x42\x2d\x1d\x7f\xcf\xbb\xfd\x93\x5f\x0c\x01\x43\x11\x2f\x64\x64\x7c\x33\x64\x98\xde\x46\x0e\xe4\x03\xa3\xb2\xf8\x34\xec\xe3\x50\xb6\xea\xe8\xbb\x2d\xed\x54\xd6\x95\x2f\xa3\x7c\x34\x0a\xdb\xe4\x2b\x6b\x9b\xeb\x63\xd3\xb7\x49\x8c\x43\x77\x54\xe1\x1c\x0a\xfe\x61\xaa\x3b\x3b\x30\x97\x41\xdd\xcb\x54\x76\xe2\xfd\x8c\x25\xdd\xf1\x1c\xb0\x34\x71\x94\x49\xdb\xf3\x29\xd8\xb6\x95\xc3\xd6\xc2\xb0\x09\x0f\xcb\x65\xde\xd8\x03\xc1\x5f\x0f\x1d\x28\x55\x07\x6f\xf2\x37\xcd\xad\x95\x8b\x68\xf5\xbc\x4a\x31\x60\xdf\x89\x9d\x77\x5f\x4d\x67\x79\x9e\x62\x4a\x9d\x77\xe2\xaa\x63\xc3\xb7\x81\x48\xa6\x3f\x8c\x42\xf1\x55\x14\xdc\xf5\xef\xec\xeb\xf0\x65\x1b\x8a\xab\x3f\xad\x39\xcb\x92\x4a\xdc\x9d\xfd\xd1\x16\x8e\x07\xf8\x9f\xcd\x01\x00\xc1\xb1\xa6\x00\x27\xed\x29\x0b\xf8\x3a\xec\x6d\x10\x1a\x7b\x0f\x24\xb1\x87\x59\xdb\x87\xcc\xe0\xa7\x86\xed\x69\x41\xce\x40\xae\x7e\xe0\xce\xda\xeb\x7c\x4e\x2b\x84\xa7\x99\x99\x42\xc7\x4b\x48\x1f\xda\x84\xb5\x01\x79\x27\x78\x0e\xfb\x30\x5d\x20\x65\xd3\xfb\x59\x43\x45\xf9\xda\x67\x0d\xeb\x5d\xc9\xc9\x59\xf1\x85\x21\x2f\x8a\x07\x0f\x55\x0d\xec\x83\x54\x5f\x44\x62\xd8\xe7\x89\xf5\xe1\xa2\x87\x7d\xdb\xc8\x0c\xc4\x25\x51\x93\x52\x6f\x48\x99\x12\x50\x81\xcc\x3f\x0f\x15\xe1\xac\x6e\xd0\xb2\x56\xc8\x9c\x5f\x99\xe9\x6f\xda\xd6\x55\x91\xa3\xa4\x75\xcd\xb4\xdc\x51\x00\x0e\x56\x70\x84\x77\x05\xea\xf2\xcd\x9e\x03\x97\x80\xa3\xd1\x1f\x41\x41\x83\x3c\x5f\x4b\x1d\x31\x7a\x2f\x65\x0c\xca\x39\xd4\xb1\x80\xfd\x4e\x02\x91\x25\x69\x50\xa8\x69\xe0\x06\xc1\x26\xb2\x4c\x27\x94\xac\x79\x68\x5d\x5b\xca\x5a\x0c\x8c\xb3\x32\x5e\x04\x38\xb7\xaf\xfa\xe0\x8f\xbd\x32\xad\xaa\x17\xa9\x6f\x8b\x29\xa4\xbc\x0b\x95\xdc\xc6\x1c\x27\xa3\xa4\xc0\x0d\xec\xad\xb4\xb4\xf2\x51\x30\x9a\xd2\x4e\xe4\x2e\x4e\xab\xb3\xa4\x48\x73\xfa\xfe\x09\x2f\x14\x89\xfe\xf8\x10\x6e\x7c\x81\xae\x98\x37\x09\xb6\xda\x78\x71\x6f\x98\x2d\xb4\xad\x3d\x4c\xf0\xa2\x24\xe8\xb4\xd1\xb4\x6b\x80\x32\x92\x67\x70\xca\x14\x63\x9f\x55\xb3\x0b\xac\x84\x2f\xa2\xbe\xe1\x45\x80\xe9\x47\x55\x05\xff\x50\xd6\x1d\xe3\x73\xa8\xe5\x20\xa7\x84\x49\xfa\x23\xa9\x1a\x6b\x18\xea\x97\xe4\x8d\x69\x7c\xc8\xee\xa7\x18\xb3\x95\x2d\x42\x6a\xdd\x25\xf0\x56\x70\x99\xc0\x80\x02\xf1\x54\xc6\x17\x09\xcf\x1a\xe4\x01\xa7\x6f\x32\xf7\x1e\x66\x4a\x01\x3e\x1d\x50\x94\x00\xdc\x61\x8b\xf7\x8e\xbf\x3f\xc6\x29\xa2\xbe\xc1\xf4\x50\xdf\xc4\x93\xd9\x81\x4c\x1a\xf5\x82\x4a\xb2\x4a\x15\x1c\x51\xc1\x8d\x2a\x68\xb7\xda\xbd\xa0\xfd\xcd\xdf\xe7\x79\x75\xd0\x16\x75\xda\x2d\x2c\xfa\xd3\xb3\xef\x54\xc9\x2e\x97\xdc\x3f\x7d\x73\xd0\x56\x29\x5b\x05\x01\x44\xb8\x81\x40\x4f\x3b\x50\x2e\xbf\x79\x71\xd4\x6a\xff\xba\x7b\x85\x7e\x14\xfd\x0d\xaf\xd2\x31\x8a\xd5\x30\x2e\x4b\xb5\x87\x36\x29\x80\x67\xc9\x82\xea\x9c\x78\x13\x98\x73\x3e\x13\x21\xf4\x03\x90\x8e\x44\x7c\x7b\x4e\x7b\x46\xac\x04\x9d\xde\x6f\x53\x62\x6a\xc3\x74\xb0\xfb\xb9\xe4\x0d\xc1\x35\x88\xf6\x0c\x3f\x91\x29\x02\xc8\xfa\x71\xf1\xfd\xed\x21\xee\x12\x5e\x7d\x3a\xfd\xe1\xf8\xfa\xa7\x93\x8f\xe7\xa7\x1f\xde\x77\xb7\xfc\x69\x38\x51\x9e\x10\xc3\x2d\x43\xd2\x2e\x04\x44\x11\xa2\x29\xc5\xe9\xdd\x1c\x03\xce\xc7\x89\xdc\xde\x61\x4b\xf3\xe3\x0b\xfe\x2d\xb9\x9d\xaf\xc1\xbb\x1b\x6d\xbc\xcd\x8f\xac\x22\x0e\xf0\x3d\x87\x67\xa1\x91\x15\x80\x3c\x38\x4c\x6e\xff\x77\x9f\x60\x5a\x1e\x87\x58\x01\xfe\xfe\xdf\x7b\x9d\xd2\x31\x40\x9f\x00\x00")
 
 func pkgUiStaticJsGraphJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -520,12 +520,12 @@ func pkgUiStaticJsGraphJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/static/js/graph.js", size: 37691, mode: os.FileMode(420), modTime: time.Unix(1565577418, 0)}
+	info := bindataFileInfo{name: "pkg/ui/static/js/graph.js", size: 40768, mode: os.FileMode(420), modTime: time.Unix(1791980573, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _pkgUiStaticJsGraph_templateHandlebar = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x5a\x5f\x8f\xe3\xb8\x0d\x7f\xef\xa7\x60\xd5\x97\x5d\x14\x9e\xdc\x5c\x71\xf7\x50\x38\x2e\xae\x7b\x83\x03\x0a\x0c\xb6\xd8\xdb\xbb\xd7\x80\xb1\xe8\x58\x1d\x59\xf2\x49\x72\xfe\x34\x98\xef\x5e\xc8\xb2\x3d\xce\x1f\x3b\xce\xcc\x36\xc0\xe4\x21\x9b\x91\x48\x8a\xa4\xc8\x1f\x69\x7a\xa1\xf9\xc4\x5c\xac\x41\xf0\x39\x5b\x19\x2c\xf3\xc5\xc6\x60\x59\x92\xd9\xef\x05\x7f\x7e\x66\x90\x4a\xb4\xf6\x68\x8f\x25\x7f\x82\xee\x13\x67\xda\x14\x2d\xd9\x1f\x15\x99\xdd\xc2\xaf\x1c\xd0\x34\x87\x34\x44\x7e\x3b\x32\x7a\x73\x44\x72\x48\x94\x6a\x19\xc9\x55\x74\xff\xdd\x09\x15\x40\xec\x68\xeb\xd0\x10\x82\xd1\x1b\x3b\x67\xf7\x0c\x4a\x89\x29\xe5\x5a\x72\x32\x73\xf6\xb0\x2d\x0d\x59\x2b\xb4\x82\x0f\xf5\x2f\xf8\x35\x17\x99\xfb\xeb\x83\x72\x64\x20\xd3\x06\x14\x6d\xa4\x50\x64\x3f\x32\x50\x58\xd0\x9c\xd1\xb6\x34\xac\xf6\x82\xff\x75\x64\x7c\xad\x70\xaa\x95\x33\x5a\x02\x75\xc2\x17\x42\x95\x95\x63\xc0\xd1\x61\x54\x1a\xbd\x16\x9c\xe6\xcc\xed\x4a\xc2\x9c\x90\x33\xc0\xca\xe9\x54\x17\xa5\x24\x47\x73\xa6\xb3\x8c\x81\x2d\x49\xca\x34\xa7\xf4\x69\xce\x32\x94\x96\x58\xb2\xdf\x7b\x91\xcf\xcf\xf1\xac\x35\xeb\xc4\x2f\x33\x2e\xd6\x13\x9c\xf5\xfd\x39\x5f\xf5\xc8\x68\x8d\x72\x61\x1d\x3a\x0b\x99\xd4\xe8\x22\x23\x56\xb9\x63\xc9\x59\xf9\x00\xb1\x28\x56\x60\x4d\x3a\x67\xfb\x3d\x94\xe8\xf2\x7f\x1b\xca\xc4\x16\x9e\x9f\x67\x5e\x88\x48\x67\xa2\x58\xcd\xf0\x3f\xb8\x8d\xa4\x46\x4e\xe6\x6e\x25\xb2\x7f\xac\xe7\xfb\x3d\x2c\x2b\x21\xf9\xef\x64\xea\x3b\xe8\x79\xd2\x96\x42\x29\x32\x0c\x50\xba\x39\xf3\xac\x8b\x76\x69\x82\xd1\xe7\x96\x8e\xe3\x4a\x28\x7f\xb1\xa7\xd2\xea\xbb\x6a\x29\x97\x4e\xc1\xd2\xa9\xa8\x34\xa2\x40\xb3\x03\xda\x52\x5a\x39\x5a\x2c\x9d\x62\xe0\x2f\x70\xce\x6c\xb5\x2c\x84\x63\xb0\x46\x59\x91\x0f\xa9\x9a\xa2\x0d\x97\x66\xf7\xe4\x14\x4b\x92\xd2\xee\x98\xb4\xb2\x4e\x17\x51\xb3\x38\x14\x45\x61\xbb\x95\x2c\x94\x25\xe3\x16\x05\x39\x23\xd2\x73\xf7\xa9\x4b\xe7\x9d\xda\xe8\xc5\x92\x08\x02\x0b\x04\x16\x40\x07\x69\x65\xac\x36\x10\xc5\xb3\x40\x7c\xea\xda\x70\xe6\xc9\xfa\xb2\x72\x4e\xab\xc6\x03\xe1\x0f\x76\xec\x33\x4e\x19\x56\xd2\x41\x40\x04\x5d\x3a\xef\x35\xe0\xc4\xab\xb2\xf6\xdf\xa9\xe7\x3b\x0c\x91\xbb\x32\x17\xa9\x56\x3e\xe2\x44\x12\x78\xa4\x48\xd1\xeb\x78\xa2\x62\x38\x7e\xe0\x1e\x83\x86\xb9\xe0\x9c\x54\xeb\xb9\x5a\x5c\x77\x61\xf7\xa7\x8a\xbc\xde\xba\x12\x8d\x13\x28\x17\x86\x6c\xa9\x95\xa5\x2b\x0d\x6d\xd8\xa1\x65\x7f\xbb\xad\xc7\x0a\x0d\x9a\x3d\x25\x65\xa6\x42\xf1\x45\x78\x31\x46\x1b\x40\xe9\x83\xb1\xfe\x8e\x38\xaa\x95\x4f\xed\x21\x80\xe9\x31\x6f\xd0\x28\xa1\x56\x07\xec\xcd\xda\x00\xff\x30\x40\x1c\xae\xfd\x39\x8a\x8e\x38\xbf\x7e\xfe\xf9\xf3\xdf\xe1\x93\x56\x6b\x7f\x96\xcb\x85\x05\xa7\xe1\x9f\x5a\x3b\xeb\x0c\x96\xa0\x70\xbd\x44\x73\x07\xf0\xd5\x6f\x19\xfa\xa3\x12\x86\x2c\xfc\x0b\xd7\x68\x53\x23\x4a\x77\x62\x89\xff\x18\xca\x0c\xd9\xfc\xee\x68\x33\x8a\x6e\xe4\x7e\x29\xac\x8b\x56\x46\xfb\x24\x30\x5a\xfa\x52\x84\xcb\x12\x15\xc9\x33\x8c\x00\x71\x25\x5b\x4e\x85\x6b\x6f\x73\xe4\x70\x69\x7b\xbc\x5e\xe0\x59\x56\x80\x58\x8a\x1e\x73\x24\x1c\x15\x2d\xa3\xc7\x35\x52\xae\x4e\x6a\x96\xc4\xd8\xa7\x93\x42\x3d\x31\xc8\x0d\x65\x73\xf6\x97\x3a\xc5\xda\x4a\x8b\x46\x60\x0b\x8e\x6d\xbb\xd1\xee\x75\x0a\x35\xa5\xd6\xe9\xd5\xaa\x5d\x49\x7e\xf1\x94\xf1\x0c\x93\x78\x26\xc5\xdb\x94\x6d\x89\x30\x75\x62\x4d\xa3\xba\xa7\x5a\x59\x2d\x69\x40\xfb\xa3\xdd\x51\xfd\x3f\x05\xda\x31\x0b\xe2\x59\x25\xcf\xae\xf7\x2e\xdf\xe1\xb2\x56\x80\xd4\xe0\x95\x79\xea\xa3\xb8\xe8\x73\xfb\x95\x06\xf6\xbc\x20\x14\x8a\x0c\x18\xf2\x95\x9d\xbd\xb4\x87\x8d\x4d\xe7\x8f\x38\x8a\x5b\x49\x68\x32\xb1\x1d\x24\x0e\xb9\x09\x0f\x5b\x67\x30\x75\xc4\x7d\x12\x66\xda\xa4\x5e\x0d\x5d\x95\xc4\xa1\x06\x40\x7b\x77\x92\x43\x43\x47\x96\x46\x17\xe4\x72\xaa\x6c\xe8\xcd\x16\xb5\x20\x30\x1e\x86\xc2\x0a\x94\x95\x94\x91\xa4\x6c\xc8\x4d\x8d\xd0\x80\xc8\x23\x14\x70\x5c\x39\xa4\xef\xa5\x7a\xe2\x47\x79\x0f\x6a\xd0\x28\x65\x5b\xdb\xd2\x45\x6d\xc5\x05\xb1\xc2\xf9\xfb\xfd\x35\x37\x42\x3d\x81\xcb\x09\x9c\x28\x28\xd8\x7f\x37\x6a\xf0\xd9\xf2\x05\xdd\xaf\xa8\x10\xaa\xb2\xa1\x9c\x8d\xb9\xad\xad\x64\x67\x40\xf7\xe5\x13\x45\x49\x28\x6d\x53\xdc\xdb\x39\x34\x04\xc3\xb8\xfd\x3e\x4c\x7b\x97\xdd\x04\xeb\x14\x97\x7d\xed\xfc\x04\x3a\x0b\x69\x30\xe5\x06\x7d\xc7\x3e\xe5\xfe\x7a\x4a\x8d\x93\x5b\xf1\x5f\x9a\xb3\xbf\x8d\x13\x35\xb5\x7e\xbf\xef\x89\xf5\x49\x79\xd1\xeb\x13\xa2\xfa\x6d\x71\x7d\x4d\x64\x43\xd7\xf1\x4e\x8a\xed\xee\xaa\x7e\x31\x7a\xf3\x4d\x63\xbb\x94\xd7\x84\xf6\x30\x10\x9d\x69\x3e\x0e\xf6\x6f\x03\x76\x7d\x80\x7b\x23\xc2\xdd\x3e\x16\x3c\xce\x91\xe2\x13\x23\xe1\x0b\x6d\x84\xe2\x75\x2c\x90\xff\x57\x14\x6f\x8c\x84\x25\xa6\x4f\x1b\x34\xfc\x4a\xa0\x1b\x22\x9c\x06\x74\x67\xa0\x8e\xa3\x6b\xeb\xd5\x04\xcc\x08\xb8\x47\x8a\x4f\xc1\xbb\xce\x79\x0f\x8d\xc7\x3a\xbc\x83\x0f\xbf\x7d\xfd\xf4\xf1\x12\xf7\xc1\xa0\xe5\x37\xe5\x84\xbc\xc4\x51\xf7\x3c\xbe\xd3\x45\x37\x67\xbb\xdd\x6e\x17\x3d\x3e\x46\xfc\xf2\x1d\x4f\x04\xd8\x36\x74\x48\xf1\x29\x00\xdb\x42\xec\xfd\x8f\x97\xe8\x3a\x94\x25\xc5\x3b\x74\x7d\x9f\xf0\x3a\x3d\xa5\x7e\xe2\x6b\x54\x29\x7d\xc3\x9c\xca\xb4\xb9\x2e\xa5\x5e\x0f\xb0\xd7\x81\xe3\x98\x41\xfd\x61\x51\x33\xe5\xeb\x30\xc7\x6a\x59\xd5\x23\x18\xa1\xc0\x52\xaa\x15\xb7\x47\xf3\xc7\x2f\x64\xef\xe0\x83\xfd\xc8\xfa\x41\xdc\x4e\x8e\x1c\x95\xed\xe0\xd0\xa7\xed\xcb\xdf\xed\xe3\x42\x17\x77\x2f\x5b\x7e\x39\x84\xed\x8f\x63\xdd\xf4\xcd\xfc\x33\x65\x92\x12\x02\xdb\x3a\x4c\x9f\x88\x9f\x1d\x98\x1c\x3b\x7d\x68\x7c\xd2\xc8\x78\x53\xec\x8c\x4e\x53\x9a\x03\x0e\x5c\x5f\xaf\x8c\x3c\xe9\xdc\xd0\xdd\xdd\x58\x31\x68\x5b\xe0\x76\x61\x75\x65\x52\x5a\x98\x2e\x18\x17\x87\x51\xfa\xb3\xde\x28\x8b\x45\x29\x85\x5a\x41\x18\x02\x86\x70\x1b\x61\xbe\xf0\x68\xd7\x29\x73\x3c\x80\xfc\xa9\x72\x1a\x78\xef\xc0\xa1\xb1\xe3\x90\xa4\x60\x1d\xf9\x64\x68\x7e\x75\x37\xf1\x9d\x65\xc9\x67\x25\x77\x60\x70\x53\x17\x92\x6b\x65\x37\x72\x7e\x28\x58\xf2\x88\x5b\xf8\xa1\x78\x93\xa6\xed\x90\x2d\x0f\xd2\xee\xf3\xeb\xa5\x0d\xcd\x5e\x7b\x04\x67\x67\x65\x07\x9b\x13\x9e\xbe\xc3\x93\x3c\x1a\xc2\xc1\xf1\xdb\x29\x93\xa4\x95\x2f\x15\x63\x0c\x63\x5b\x53\x26\x0c\x61\xb8\x02\xcd\x88\xe4\x60\xc0\x70\x38\x36\x79\x5f\x23\x86\x77\xdf\x75\x17\xba\x20\x75\x59\xf6\x49\xe3\x1d\xf8\x6e\xdd\x76\x8f\xfa\xf8\x66\x2d\x77\xb0\xfd\xaa\xae\xfb\xb1\x66\xf1\x3d\x77\x9b\x01\xd7\x77\xdd\x8f\x93\xae\xea\x46\x6d\x77\xf0\xc1\xff\xa9\xf3\x0e\xc2\x6b\x30\x78\x77\x49\xe5\xfb\xee\xab\x92\xaa\xdf\x7a\x7f\x8b\xac\xfa\xe6\x8d\xf7\x6b\x36\x1d\x2e\x25\xf5\x8a\x80\x24\xa8\xbf\x23\x5b\x34\x3f\x72\xbd\x26\xd3\x66\xc3\xa2\x5e\x1b\x83\x73\x97\x13\xf2\x51\x73\x5c\x9e\x3c\x48\xf2\xfe\x8b\x67\x2e\xbf\x44\xfa\xbb\x8f\xb5\x71\x42\xbf\x3b\x7a\x68\xec\x96\x9a\xef\xc6\x4f\x32\x49\xec\x38\xa4\x5a\xda\x12\xd5\x9c\x7d\xcf\x92\x58\x24\x4a\x37\x4d\x8d\x48\xe2\x99\xe3\xfe\xcb\x8c\xea\x31\x76\x4e\x3c\xab\x9d\x77\x65\xd9\x1e\x7a\x35\x77\xdd\x1b\xb7\x83\xa5\x57\xbf\xe0\x02\x0f\x3b\xed\x7f\x4a\x38\x6f\x06\xb6\x03\x55\x2a\xf4\x9a\xba\xf7\x31\x2c\xf9\x52\x2f\x40\xf7\x3a\xe8\x15\xaa\xc7\x33\xaf\xee\xcb\x4a\x43\xf0\xbf\x00\x00\x00\xff\xff\x03\xe0\x9f\x12\x28\x23\x00\x00")
+var _pkgUiStaticJsGraph_templateHandlebar = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xd5\x5a\xdd\x6f\xdb\x36\x10\x7f\xef\x5f\xc1\x69\x2f\x1d\x06\xc5\x4d\x87\xf6\x61\xb0\x3d\x74\x69\x50\x60\x40\xd0\xa1\x5f\xaf\x06\x2d\xd1\x16\x57\x8a\x54\x49\xca\x8e\x67\xe4\x7f\xdf\x1d\x29\x29\xb2\x2c\xc9\x52\x9d\x65\x8b\x81\xba\x36\x79\x77\xbc\x8f\xdf\x1d\xcf\xa7\x10\xe2\x5f\xd3\x98\x6f\x08\x8f\x67\xc1\x5a\xd3\x2c\x59\x6c\xe1\x3d\x63\x7a\xbf\xe7\xf1\xdd\x5d\x40\x22\x41\x8d\x69\xec\x05\xf3\x67\xa4\x7a\x4d\x57\x4a\xa7\x25\xd9\xb7\x9c\xe9\xdd\x02\x57\x0e\x68\x8a\x43\x0a\x22\xdc\x0e\xb5\xda\x36\x48\x0e\x89\x22\x25\x42\xb1\x0e\x2f\x5f\x1c\x51\x01\x9d\x65\xb7\x96\x6a\x46\x09\x48\x01\xda\xcb\x80\x64\x82\x46\x2c\x51\x22\x66\x7a\x16\x5c\xdf\x66\x9a\x19\xc3\x95\x24\xcf\xdd\x27\xf2\x31\xe1\x2b\xfb\xf3\xb5\xb4\x4c\x13\x38\x9e\x48\xb6\x15\x5c\x32\xf3\x53\x40\x24\x4d\xd9\x2c\x60\xc0\x12\x38\x2f\xe0\xa7\x86\xf1\x4e\xe1\x48\x49\xab\x95\x20\xac\x12\xbe\xe0\x32\xcb\x6d\x40\x62\x6a\x69\x98\x69\xb5\xe1\x31\x48\xb2\xbb\x8c\xd1\x84\xd1\x38\x20\x34\xb7\x2a\x52\x69\x26\x98\x85\x0d\xb5\x5a\x05\xc4\x64\x4c\x88\x28\x61\xd1\x57\x10\x4b\x85\x61\xc1\x7c\xbf\x47\x91\x77\x77\xd3\x49\x69\xd6\x91\x5f\x26\xe0\x98\x01\xce\x7a\xd9\xe6\xab\x1a\x19\xdb\x50\xb1\x30\x96\x5a\x43\x56\x42\x51\x1b\x6a\xbe\x4e\x6c\x30\x6f\x95\x0f\xac\x3c\x5d\x13\xa3\xa3\x59\xb0\xdf\x93\x8c\xda\xe4\x4f\xcd\x56\xfc\x96\xdc\xdd\x4d\x50\x08\x8f\x26\x40\x30\xa1\x7f\xd1\xdb\x10\xa4\x81\xe7\x2f\xd6\x7c\xf5\xdb\x66\x06\xd4\xcb\x9c\x8b\xf8\x0b\xd3\x2e\x06\x35\x4f\x9a\x8c\x4b\x09\x00\x22\x54\xd8\x59\x80\xac\x8b\x72\x69\x80\xd1\x6d\x4b\x4d\x5c\x71\x89\x81\x3d\x96\xe6\x62\x55\x52\x2e\xad\x24\xf0\x0f\x82\xc6\x53\xaa\x77\x10\x53\x16\xe5\x96\x2d\x60\x2d\x20\x18\x40\xd0\x34\x5f\xa6\x1c\x82\x0b\x2e\xcb\x19\x42\xca\x51\x94\x70\x29\x76\x8f\x4e\x31\x4c\xb0\xa8\x3a\x26\xca\x8d\x55\x69\x58\x2c\x76\xa1\xc8\x6f\x97\x92\xb9\x34\x4c\xdb\x45\xca\xac\xe6\x51\x5b\x3c\x55\x66\xd1\xa9\x85\x5e\xc1\x3c\x24\x9e\x85\x78\x16\x42\xe1\xf8\x5c\x1b\x00\x79\x38\x9d\x78\xe2\x63\xd7\xfa\x33\x8f\xd6\x97\xb9\xb5\x20\xdb\x7b\xc0\x7f\x09\x9a\x3e\x8b\xd9\x8a\xe6\xc2\x12\x5f\x11\xe0\x00\xf4\x1a\x89\x59\x9c\x67\xce\x7f\xc7\x9e\xaf\x6a\x88\xd8\x65\x09\x07\x0f\x20\xe2\xf8\xdc\xf3\x08\x1e\x51\xd4\xf1\x48\x45\x7f\x7c\x47\x1c\xbd\x86\x09\x8f\x63\x26\x4b\xcf\x39\x71\x55\xc0\xf6\x7b\xf7\x1d\xb0\xf7\x80\x56\x66\x54\x5b\x0e\x39\x04\xa1\xcb\x14\x78\x7d\xa4\xc1\x05\x3b\x29\xd9\xcf\xb7\xb9\xa9\x50\xcd\xfc\xe6\xd6\xc3\x7a\xc2\x24\x50\xa8\x8a\x7c\xe1\x56\x00\xfb\x95\xca\x76\x84\x12\xc8\xbe\xaf\xc4\x2a\x62\x13\x6e\x88\xbb\x0e\xc8\x96\xdb\x84\x70\x28\x3a\x1e\x8d\x86\x50\x19\xc3\x3e\x43\x9c\x6a\x26\xc1\x32\x9e\x32\xa2\xa9\x5c\x33\xe2\x8a\x41\x3c\xc4\xa7\xa4\xfa\x14\xe2\x99\x85\x8b\xa7\x26\xa3\xb2\xaa\x36\x4e\x49\x41\x97\x4c\x04\xf3\x08\xf5\x43\x4a\x40\x3f\xd0\xcc\x07\x39\x7f\x48\xc5\x19\x7a\x93\x9d\xac\xce\x5a\x43\xd2\x52\x81\xb9\xec\xde\xc3\x18\x5d\xa2\xbb\xeb\x73\x8d\x79\x4b\xb5\xe4\x72\x7d\xc0\x5e\xac\x75\xf0\x77\xd7\xd7\xc3\xb5\x1f\xc2\xb0\xc1\xf9\xe9\xfd\xdb\xf7\xbf\x92\x2b\x25\x37\x78\x96\x0b\x34\x04\xfc\x77\xa5\xac\xb1\x00\x12\x80\xe6\x66\x49\xf5\x05\x10\xe2\x96\x66\xdf\x72\x0e\x20\x24\x7f\xd0\x0d\x35\x91\xe6\x99\x3d\xb2\x04\x5f\x70\xb7\x00\x55\x72\xd1\xd8\x0c\xc3\x47\x72\xbf\xe0\xc6\x86\x6b\xad\xb0\x86\x40\x85\xc6\x9b\x9c\x2e\x01\x28\x88\x9d\x16\x85\xa7\xb9\x28\x39\xc1\x5e\xb4\x39\x04\x7a\x53\xe3\x45\x81\xad\xac\xc0\x2c\x78\x8d\x39\xe4\x96\xa5\x25\x23\x5e\x0b\x90\x13\xae\x26\x42\xe4\x68\x9d\xce\xe1\x9c\x24\xe0\xa9\x59\xf0\xa3\xcb\xc7\xb2\x51\xa1\x9a\xd3\xf2\x6e\x29\xbb\xb5\x72\xaf\x52\xa8\xe8\x54\xac\x5a\xaf\xcb\x95\xf9\x3b\xa4\x9c\x4e\x28\x60\x44\xf0\xf3\x94\x2d\x89\x68\x64\xf9\x86\xf5\xea\x0e\x9a\x1a\x10\xd0\xa1\x7d\x63\xb7\x57\xff\x2b\x4f\xdb\x67\xc1\x74\x92\x8b\xd6\xf5\x5a\xf0\x41\x96\x53\x00\x8c\xe9\x0a\x19\x52\x37\x70\x51\xe7\xc6\x95\xa2\x46\xa2\x20\x0a\x4d\x88\x06\x4c\x63\x63\x14\xdc\x77\xd7\x85\x4d\xed\x47\x34\x70\x2b\x18\xd5\xd0\x6e\x75\x12\xfb\xdc\x24\xd7\xb7\x90\x74\x91\x65\x31\x26\x21\x64\x44\x84\x6a\x00\x8a\x61\xc1\xdd\x1b\xe6\xe2\x28\x87\xba\x8e\x84\x1e\x16\x9a\x88\x84\xe5\xc6\xb7\xb6\x0b\x27\xc8\x57\x66\xbf\x42\xb2\x5c\x40\x3a\xb1\x95\xed\x51\xab\xba\x58\x7a\x28\x48\xf3\x9a\x11\xd8\x8a\xd6\xc4\xf7\xf2\x1e\x5c\x58\xbd\x94\x65\x6b\x10\x2d\x9c\x15\x27\xc4\xfa\x9b\xec\x63\xa2\xdd\x25\x06\x37\xd4\xfd\xcd\x74\xd1\x6b\xf0\xa9\x1b\x2a\xe5\x32\x37\xfe\x8a\xea\x73\x5b\x79\x07\xb5\x14\xdd\x83\x8a\xe8\x3b\x82\x21\xee\xad\x1c\xea\xc1\xd0\x6f\x3f\xc2\xb4\x16\xec\x02\xac\x43\x5c\xf6\xe9\xfe\x06\x57\x2b\x9f\x06\x43\x22\x88\x3f\x78\x86\xc4\xaf\xa6\x54\x3f\xb9\xe1\x7f\x03\xf9\x2f\xfd\x44\x55\x8b\x54\x13\x8b\x49\x79\xd2\xeb\x03\x50\x7d\x1e\xae\xc7\x20\x9b\x54\x3f\x18\x06\x61\xbb\x0a\xd5\x3b\xb8\x30\x1f\x14\xdb\x99\x18\x03\xed\xee\x42\xd4\xd2\x7c\xfc\x07\xc5\xae\x5e\xe0\xce\xac\x70\x8f\x8f\x05\xac\x73\x4c\xc6\x03\x91\xf0\x81\x6d\x79\xd1\x89\x33\xfc\x1f\xf0\x70\x1e\x12\x96\x34\xfa\x0a\x4d\x67\x3c\xb2\xd0\x3d\x3b\xab\xd0\xb5\x94\x3a\x68\x13\xca\xfb\x6a\x40\xcd\xf0\x75\x0f\x3c\x30\xa4\xde\x55\xce\xbb\x2e\x3c\x56\xd5\x3b\xf2\xfc\xf3\xa7\xab\x9f\x4e\x71\x1f\xcc\xa9\x3e\x4b\xcb\xc5\x29\x0e\xd7\xf3\x60\xa7\x4b\xed\x2c\xd8\xc1\x2b\xbc\xb9\x09\xe3\x78\x18\x78\x4e\x17\xd8\x12\x3a\x60\xff\x62\x90\xb3\x7c\x89\xbd\x7c\x7d\x8a\xae\xaa\xb2\x20\xb9\xaa\xae\x4f\xb3\xbc\x0e\x4f\xa9\x37\xf1\x86\x4a\x28\x4a\x0f\x97\x53\x10\xf9\x71\x29\xf5\xfd\x05\x76\x5c\x71\xec\x33\xa8\x3e\x6b\x2b\x86\xa4\x55\xcd\x81\x76\x3d\x77\x13\x2c\x2e\x89\x61\x60\x62\x6c\x1a\xe3\x5b\xa0\xb9\x20\xcf\x71\x36\x5b\x03\x71\x39\x78\xb3\x2c\x2b\xe7\xae\x98\xb6\xf7\xdf\xcb\x9f\x0b\x15\xee\xee\xb7\x70\xd9\xc3\xf6\x75\xf0\x7f\xf0\xcf\x90\xb1\x8b\x07\xb6\xb1\x50\x52\x59\xdc\x3a\x67\x1a\x3a\x75\x2a\x64\x9c\x85\x9d\xde\x21\x54\x71\xc0\x81\xeb\xdd\x4a\xcf\x2f\x9d\x47\x74\x77\x35\x95\xf5\xda\xa6\x38\x73\x56\x39\xb4\x0e\x38\x1a\x2b\xc0\xb8\x38\x44\xe9\x5b\xb5\x95\x86\xa6\x99\xc0\x91\x8a\x9f\x5a\x79\xb8\xf5\x30\x9f\xf8\x69\xd7\x39\xbf\x7d\x93\x43\x2b\x13\xd7\x0e\xec\x9a\xda\x76\x49\xf2\xd6\x31\x4c\x86\xe2\x53\x15\x89\x17\xd0\x98\xbd\x97\x62\x07\x6d\xde\xd6\x5d\x24\x63\x65\x17\x72\x5e\xa5\xc1\xfc\x86\xde\x92\x57\xe9\x59\x9a\x16\xd2\x2e\x13\x2f\xed\x32\x19\x2f\xad\x6b\x74\xdd\xff\xac\x64\x18\xe6\xea\x88\xf3\xbf\xe4\xf1\x61\x4c\xe7\xf8\xad\x65\x84\xc4\xd6\x78\x55\xf4\x31\xf4\x6d\x0d\x99\x30\xf8\xe1\x0a\x29\x46\x24\x07\x03\x86\xc3\xb1\xc9\xd3\x1a\x31\x3c\xf9\xae\x3b\x05\xb3\xa4\x1d\xdf\x78\x7b\xbe\xc7\x6e\xbb\x9f\x9d\x2c\xf4\x8f\xd1\x72\x7b\xdb\x47\x75\xdd\x37\x8e\x05\x7b\xee\x32\x03\xc6\x77\xdd\x37\x83\x42\xf5\x48\x6d\xb7\xf7\xc1\xbf\xd4\x79\x7b\xe1\xae\x18\x3c\xb9\xa4\xc2\xbe\x7b\x54\x52\xd5\x5b\xef\x87\xc8\xaa\x07\x6f\xbc\xbf\x67\x13\x1f\x23\xb0\xda\x25\x00\x5f\xdc\x7b\x68\xd2\xe2\x43\xa2\x36\x4c\x97\xd9\xb0\x70\x6b\x7d\xe5\xdc\xe2\x5f\x26\xf4\x9a\x63\x93\xf9\xb5\x60\xe8\xbf\xe9\x04\x3e\x9f\x20\xfd\x82\x58\xeb\x27\xc4\xdd\xde\x43\xa7\x76\xa9\xe2\x5d\xff\x49\x7a\x3e\xb5\x31\x98\x29\xf0\xd9\xdd\x2c\x78\x09\x61\xe1\x73\xa9\x8a\xa6\x86\x43\x90\x6c\x8c\x6f\xba\x57\x8f\xbe\x73\x60\x1b\x9d\x37\xf2\xda\xee\x7a\x34\x37\xee\x89\xdb\xc3\x3c\xe0\x22\x58\x76\xca\xbf\xe9\x68\x37\x83\x96\x03\x55\x96\x02\x6e\xaa\xe7\x31\xc1\xfc\x83\x5b\x20\xd5\xe3\xa0\xef\x50\x7d\x3a\x41\x75\xef\x57\x0a\x82\x7f\x00\x69\x93\x58\x55\x67\x24\x00\x00")
 
 func pkgUiStaticJsGraph_templateHandlebarBytes() ([]byte, error) {
 	return bindataRead(
@@ -540,7 +540,7 @@ func pkgUiStaticJsGraph_templateHandlebar() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/static/js/graph_template.handlebar", size: 9000, mode: os.FileMode(420), modTime: time.Unix(1565577418, 0)}
+	info := bindataFileInfo{name: "pkg/ui/static/js/graph_template.handlebar", size: 9319, mode: os.FileMode(420), modTime: time.Unix(1791980573, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -1,22 +1,31 @@
 package ui
 
 import (
+	"encoding/json"
 	"html/template"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/common/route"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 )
 
-// Bucket is a web UI representing state of buckets as a timeline.
+// Bucket is a web UI representing state of buckets as a timeline. It is served by the bucket web command for all
+// blocks of the bucket, and by the compactor and the store gateway for the blocks they know of, so their views of the
+// bucket can be compared.
 type Bucket struct {
 	*BaseUI
+
+	mtx sync.RWMutex
 	// Unique Prometheus label that identifies each shard, used as the title. If
 	// not present, all labels are displayed externally as a legend.
-	Label       string
-	Blocks      template.JS
+	Label  string
+	Blocks template.JS
+	// States are states of highlighted blocks by block ID, as JSON.
+	States      template.JS
 	RefreshedAt time.Time
 	Err         error
 }
@@ -25,6 +34,7 @@ func NewBucketUI(logger log.Logger, label string) *Bucket {
 	return &Bucket{
 		BaseUI: NewBaseUI(logger, "bucket_menu.html", queryTmplFuncs()),
 		Blocks: "[]",
+		States: "{}",
 		Label:  label,
 	}
 }
@@ -41,11 +51,54 @@ func (b *Bucket) Register(r *route.Router, ins extpromhttp.InstrumentationMiddle
 
 // Handle / of bucket UIs.
 func (b *Bucket) root(w http.ResponseWriter, r *http.Request) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
 	b.executeTemplate(w, "bucket.html", "", b)
 }
 
+// Set sets the blocks shown by the UI, given as JSON list of metas, and the error of getting them.
 func (b *Bucket) Set(data string, err error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
 	b.RefreshedAt = time.Now()
 	b.Blocks = template.JS(string(data))
 	b.Err = err
 }
+
+// SetBlocks sets the blocks shown by the UI.
+func (b *Bucket) SetBlocks(blocks []metadata.Meta, err error) {
+	if blocks == nil {
+		blocks = []metadata.Meta{}
+	}
+	data, mErr := json.Marshal(blocks)
+	if mErr != nil {
+		b.Set("[]", mErr)
+		return
+	}
+	b.Set(string(data), err)
+}
+
+// BlockState is the state of a block highlighted by the UI.
+type BlockState struct {
+	// State is either "halted" for blocks of halted groups or "marked" for blocks marked for no compaction.
+	State  string `json:"state"`
+	Reason string `json:"reason"`
+}
+
+// SetBlockStates sets the states of blocks highlighted by the UI by block ID.
+func (b *Bucket) SetBlockStates(states map[string]BlockState) {
+	if states == nil {
+		states = map[string]BlockState{}
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		data = []byte("{}")
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.States = template.JS(string(data))
+}
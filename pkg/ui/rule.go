@@ -29,9 +29,9 @@ type Rule struct {
 	reg          prometheus.Registerer
 }
 
-func NewRuleUI(logger log.Logger, reg prometheus.Registerer, ruleManagers map[storepb.PartialResponseStrategy]*rules.Manager, queryURL string, flagsMap map[string]string) *Rule {
+func NewRuleUI(logger log.Logger, reg prometheus.Registerer, ruleManagers map[storepb.PartialResponseStrategy]*rules.Manager, evalWarnings *thanosrule.EvalWarnings, queryURL string, flagsMap map[string]string) *Rule {
 	return &Rule{
-		BaseUI:       NewBaseUI(logger, "rule_menu.html", ruleTmplFuncs(queryURL)),
+		BaseUI:       NewBaseUI(logger, "rule_menu.html", ruleTmplFuncs(queryURL, evalWarnings)),
 		flagsMap:     flagsMap,
 		ruleManagers: ruleManagers,
 		queryURL:     queryURL,
@@ -39,7 +39,7 @@ func NewRuleUI(logger log.Logger, reg prometheus.Registerer, ruleManagers map[st
 	}
 }
 
-func ruleTmplFuncs(queryURL string) template.FuncMap {
+func ruleTmplFuncs(queryURL string, evalWarnings *thanosrule.EvalWarnings) template.FuncMap {
 	return template.FuncMap{
 		"since": func(t time.Time) time.Duration {
 			return time.Since(t) / time.Millisecond * time.Millisecond
@@ -56,9 +56,17 @@ func ruleTmplFuncs(queryURL string) template.FuncMap {
 				panic("unknown alert state")
 			}
 		},
+		"ruleHealth": func(s storepb.PartialResponseStrategy, r rules.Rule) rules.RuleHealth {
+			h, _ := evalWarnings.Health(s, r)
+			return h
+		},
+		"ruleLastError": func(s storepb.PartialResponseStrategy, r rules.Rule) error {
+			_, err := evalWarnings.Health(s, r)
+			return err
+		},
 		"ruleHealthToClass": func(rh rules.RuleHealth) string {
 			switch rh {
-			case rules.HealthUnknown:
+			case rules.HealthUnknown, thanosrule.HealthWarn:
 				return "warning"
 			case rules.HealthGood:
 				return "success"
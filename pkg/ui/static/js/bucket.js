@@ -24,6 +24,8 @@ function draw() {
 
         dataTable.addColumn({type: 'string', id: 'Replica'});
         dataTable.addColumn({type: 'string', id: 'Label'});
+        dataTable.addColumn({type: 'string', role: 'tooltip'});
+        dataTable.addColumn({type: 'string', id: 'style', role: 'style'});
         dataTable.addColumn({type: 'date', id: 'Start'});
         dataTable.addColumn({type: 'date', id: 'End'});
 
@@ -52,8 +54,25 @@ function draw() {
                     }
                 }();
 
-                label = `l: ${d.compaction.level}, res: ${d.thanos.downsample.resolution}`;
-                return [title, label, new Date(d.minTime), new Date(d.maxTime)];
+                // Blocks of different resolutions are shown in rows of their own, so they do not hide each other.
+                var res = d.thanos.downsample.resolution;
+                if (res > 0) {
+                    title = `${title} (res: ${res})`;
+                }
+
+                var sources = d.compaction.sources ? d.compaction.sources.length : 0;
+                label = `l: ${d.compaction.level}, sources: ${sources}`;
+
+                var tooltip = `${d.ulid}\nlevel: ${d.compaction.level}, sources: ${sources}, resolution: ${res}\n` +
+                    `${new Date(d.minTime).toISOString()} - ${new Date(d.maxTime).toISOString()}`;
+                // Blocks of halted groups and blocks marked for no compaction are highlighted.
+                var style = null;
+                var state = thanos.states[d.ulid];
+                if (state != undefined) {
+                    tooltip += `\n${state.state}: ${state.reason}`;
+                    style = state.state == "halted" ? "#dc3545" : "#ffc107";
+                }
+                return [title, label, tooltip, style, new Date(d.minTime), new Date(d.maxTime)];
             }));
 
         chart.draw(dataTable);
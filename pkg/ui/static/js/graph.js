@@ -41,6 +41,11 @@ Prometheus.Graph.prototype.initialize = function() {
   // Set default options.
   self.options.id = self.id;
   self.options.range_input = self.options.range_input || "1h";
+  self.options.dedup = self.options.dedup || "1";
+  self.options.partial_response = self.options.partial_response || "1";
+  if (self.options.max_source_resolution === undefined) {
+    self.options.max_source_resolution = "0s";
+  }
   if (self.options.tab === undefined) {
     self.options.tab = 1;
   }
@@ -92,6 +97,11 @@ Prometheus.Graph.prototype.initialize = function() {
   self.stackedBtn = self.queryForm.find(".stacked_btn");
   self.stacked = self.queryForm.find("input[name=stacked]");
   self.insertMetric = self.queryForm.find("select[name=insert_metric]");
+  self.maxSourceResolution = self.queryForm.find("select[name=max_source_resolution_input]");
+  // Auto downsampling is encoded as "auto", as empty options are dropped from URLs.
+  self.maxSourceResolution.val(self.options.max_source_resolution === "auto" ? "" : self.options.max_source_resolution);
+  self.maxSourceResolution.change(self.handleChange);
+  self.shareBtn = self.queryForm.find(".share_btn");
   self.refreshInterval = self.queryForm.find("select[name=refresh]");
 
   self.consoleTab = graphWrapper.find(".console");
@@ -208,6 +218,7 @@ Prometheus.Graph.prototype.initialize = function() {
   self.dedupBtn.click(function() {
     self.enableDedup.val(self.isDedupEnabled() ? '0' : '1');
     styleDedupBtn();
+    self.handleChange();
   });
 
   // Partial response.
@@ -229,6 +240,24 @@ Prometheus.Graph.prototype.initialize = function() {
   self.partialResponseBtn.click(function() {
     self.partialResponse.val(self.isPartialResponseEnabled() ? '0' : '1');
     stylePartialResponseBtn();
+    self.handleChange();
+  });
+
+  // Sharable links reproduce the query with the same options and time range for others, so a relative time range is
+  // pinned to the current end time.
+  self.shareBtn.click(function() {
+    var url = self.getShareURL();
+    if (navigator.clipboard && navigator.clipboard.writeText) {
+      navigator.clipboard.writeText(url).then(function() {
+        var label = self.shareBtn.find(".share_label");
+        label.text("copied");
+        setTimeout(function() { label.text("copy link"); }, 2000);
+      }, function() {
+        window.prompt("Copy link to this query", url);
+      });
+    } else {
+      window.prompt("Copy link to this query", url);
+    }
   });
 
   self.queryForm.submit(function() {
@@ -379,7 +408,9 @@ Prometheus.Graph.prototype.getOptions = function() {
     "step_input",
     "downsample_input",
     "stacked",
-    "moment_input"
+    "moment_input",
+    "dedup",
+    "partial_response"
   ];
 
   self.queryForm.find("input").each(function(index, element) {
@@ -392,9 +423,25 @@ Prometheus.Graph.prototype.getOptions = function() {
   });
   options.expr = self.expr.val();
   options.tab = self.options.tab;
+  options.max_source_resolution = self.maxSourceResolution.val() || "auto";
   return options;
 };
 
+// getShareURL returns an absolute link to the graph page showing only this query, with its end time or evaluation
+// time pinned if not set.
+Prometheus.Graph.prototype.getShareURL = function() {
+  var self = this;
+  var options = self.getOptions();
+  if (options.tab === 0 && !options.end_input) {
+    options.end_input = moment.utc(self.getEndDate()).format('YYYY-MM-DD HH:mm');
+  }
+  if (options.tab === 1 && !options.moment_input) {
+    options.moment_input = moment.utc(self.getMoment()).format('YYYY-MM-DD HH:mm:ss');
+  }
+  var queryObject = new Prometheus.Page.QueryParamHelper().generateQueryObject(options, 0);
+  return window.location.origin + PATH_PREFIX + "/graph?" + $.param(queryObject);
+};
+
 Prometheus.Graph.prototype.parseDuration = function(rangeText) {
   var rangeRE = new RegExp("^([0-9]+)([ywdhms]+)$");
   var matches = rangeText.match(rangeRE);
@@ -521,7 +568,7 @@ Prometheus.Graph.prototype.submitQuery = function() {
   var startTime = new Date().getTime();
   var rangeSeconds = self.parseDuration(self.rangeInput.val());
   var resolution = parseInt(self.queryForm.find("input[name=step_input]").val()) || Math.max(Math.floor(rangeSeconds / 250), 1);
-  var maxSourceResolution = self.queryForm.find("select[name=max_source_resolution_input]").val();
+  var maxSourceResolution = self.maxSourceResolution.val();
   var endDate = self.getEndDate() / 1000;
   var moment = self.getMoment() / 1000;
 
@@ -561,7 +608,9 @@ Prometheus.Graph.prototype.submitQuery = function() {
           self.showError(json.error);
           return;
         }
-        if (json.warnings) {
+        if (json.annotations) {
+          self.showAnnotations(json.annotations);
+        } else if (json.warnings) {
           self.showWarning(json.warnings);
         }
 
@@ -616,6 +665,33 @@ Prometheus.Graph.prototype.showWarning = function(msg) {
   self.warning.show();
 };
 
+Prometheus.Graph.prototype.showAnnotations = function(annotations) {
+  var self = this;
+  var titles = {
+    "partial_response": "Result is partial, store(s) failed or did not match the query:",
+    "store_limit": "Result is partial, store(s) hit their limits:",
+    "query": "Query evaluation warnings:"
+  };
+  var byType = {};
+  annotations.forEach(function(a) {
+    if (!byType[a.type]) {
+      byType[a.type] = [];
+    }
+    byType[a.type].push(a);
+  });
+  var message = $("<div>");
+  Object.keys(byType).sort().forEach(function(type) {
+    var list = $("<ul>");
+    byType[type].forEach(function(a) {
+      list.append($("<li>").text(a.message));
+    });
+    message
+      .append($("<div>").append("<strong>Warning!</strong> ").append(document.createTextNode(titles[type] || type + ":")))
+      .append(list);
+  });
+  self.showWarning(message);
+};
+
 Prometheus.Graph.prototype.clearWarning = function() {
   var self = this;
   self.warning.html('');
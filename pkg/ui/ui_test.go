@@ -1,6 +1,15 @@
 package ui
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
 
 func TestSanitizePrefix(t *testing.T) {
 	type args struct {
@@ -66,3 +75,40 @@ func TestSanitizePrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestBucket_SetBlocks(t *testing.T) {
+	b := NewBucketUI(log.NewNopLogger(), "")
+
+	b.SetBlocks(nil, nil)
+	if b.Blocks != "[]" || b.Err != nil {
+		t.Fatalf("unexpected blocks %q, error %v", b.Blocks, b.Err)
+	}
+
+	id := ulid.MustNew(1, nil)
+	err := errors.New("sync failed")
+	b.SetBlocks([]metadata.Meta{{BlockMeta: tsdb.BlockMeta{ULID: id}}}, err)
+	if !strings.Contains(string(b.Blocks), id.String()) {
+		t.Fatalf("block %s not in %q", id, b.Blocks)
+	}
+	if b.Err != err {
+		t.Fatalf("unexpected error %v", b.Err)
+	}
+}
+
+func TestBucket_SetBlockStates(t *testing.T) {
+	b := NewBucketUI(log.NewNopLogger(), "")
+	if b.States != "{}" {
+		t.Fatalf("unexpected states %q", b.States)
+	}
+
+	id := ulid.MustNew(1, nil)
+	b.SetBlockStates(map[string]BlockState{id.String(): {State: "halted", Reason: "overlap"}})
+	if exp := `{"` + id.String() + `":{"state":"halted","reason":"overlap"}}`; string(b.States) != exp {
+		t.Fatalf("unexpected states %q, expected %q", b.States, exp)
+	}
+
+	b.SetBlockStates(nil)
+	if b.States != "{}" {
+		t.Fatalf("unexpected states %q", b.States)
+	}
+}
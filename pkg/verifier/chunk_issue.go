@@ -0,0 +1,320 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const ChunkIssueID = "chunk_issue"
+
+// ChunkIssue verifies chunks of blocks by iterating all their samples. It detects chunk encodings not matching
+// the resolution of the block, chunks which cannot be decoded, samples out of order or outside of the chunk time range,
+// downsampled chunks of counter series without a valid counter aggregate and stats in meta.json not matching the actual
+// number of series, chunks and samples.
+// Such blocks otherwise surface only as unexpected query results.
+// No repair is available for this issue.
+// NOTE: Each verified block is downloaded entirely.
+func ChunkIssue(ctx context.Context, logger log.Logger, bkt objstore.Bucket, _ objstore.Bucket, repair bool, idMatcher func(ulid.ULID) bool) error {
+	level.Info(logger).Log("msg", "started verifying issue", "with-repair", repair, "issue", ChunkIssueID)
+
+	err := bkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if !ok {
+			return nil
+		}
+
+		if idMatcher != nil && !idMatcher(id) {
+			return nil
+		}
+
+		tmpdir, err := ioutil.TempDir("", fmt.Sprintf("chunk-issue-block-%s-", id))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpdir); err != nil {
+				level.Warn(logger).Log("msg", "failed to delete dir", "tmpdir", tmpdir, "err", err)
+			}
+		}()
+
+		bdir := filepath.Join(tmpdir, id.String())
+		if err := block.Download(ctx, logger, bkt, id, bdir); err != nil {
+			return errors.Wrapf(err, "download block %s", id)
+		}
+
+		meta, err := metadata.Read(bdir)
+		if err != nil {
+			return errors.Wrapf(err, "read meta file %s", id)
+		}
+
+		stats, err := GatherChunkIssueStats(logger, bdir, meta)
+		if err != nil {
+			return errors.Wrapf(err, "gather chunk issues %s", id)
+		}
+
+		if err := stats.AnyErr(); err != nil {
+			level.Warn(logger).Log("msg", "detected issue", "id", id, "err", err, "issue", ChunkIssueID)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "verify iter, issue %s", ChunkIssueID)
+	}
+
+	if repair {
+		level.Warn(logger).Log("msg", "repair is not implemented for this issue", "issue", ChunkIssueID)
+	}
+
+	level.Info(logger).Log("msg", "verified issue", "with-repair", repair, "issue", ChunkIssueID)
+	return nil
+}
+
+// ChunkStats holds statistics of a block gathered by iterating all samples of its chunks.
+type ChunkStats struct {
+	// MetaStats are the stats of the block from its meta.json.
+	MetaStats tsdb.BlockStats
+
+	TotalSeries  uint64
+	TotalChunks  uint64
+	TotalSamples uint64
+
+	// InvalidEncodingChunks is the number of chunks which encoding does not match the resolution of the block,
+	// i.e. chunks of raw blocks which are not XOR chunks and chunks of downsampled blocks which are not aggregate chunks.
+	InvalidEncodingChunks int
+	// CorruptedChunks is the number of chunks which could not be read or decoded.
+	CorruptedChunks int
+	// OutOfOrderSamplesChunks is the number of chunks with samples going back in time.
+	OutOfOrderSamplesChunks int
+	// OutsideSamplesChunks is the number of chunks with samples outside of the chunk time range from the index.
+	OutsideSamplesChunks int
+	// InvalidCounterChunks is the number of downsampled chunks of counter series, as told by downsample.IsCounterSeries,
+	// which were downsampled with gauge semantics, i.e. without counter aggregate or with a decreasing counter aggregate.
+	InvalidCounterChunks int
+}
+
+// StatsMismatchErr returns error if stats from meta.json do not match the actual number of series, chunks and samples.
+func (s ChunkStats) StatsMismatchErr() error {
+	var errMsg []string
+
+	if s.MetaStats.NumSeries != s.TotalSeries {
+		errMsg = append(errMsg, fmt.Sprintf("meta.json reports %d series, block has %d", s.MetaStats.NumSeries, s.TotalSeries))
+	}
+	if s.MetaStats.NumChunks != s.TotalChunks {
+		errMsg = append(errMsg, fmt.Sprintf("meta.json reports %d chunks, block has %d", s.MetaStats.NumChunks, s.TotalChunks))
+	}
+	if s.MetaStats.NumSamples != s.TotalSamples {
+		errMsg = append(errMsg, fmt.Sprintf("meta.json reports %d samples, block has %d", s.MetaStats.NumSamples, s.TotalSamples))
+	}
+
+	if len(errMsg) > 0 {
+		return errors.New(strings.Join(errMsg, ", "))
+	}
+	return nil
+}
+
+// AnyErr returns error if stats indicates any chunk issue.
+func (s ChunkStats) AnyErr() error {
+	var errMsg []string
+
+	if s.InvalidEncodingChunks > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d chunks with encoding not matching the block resolution", s.InvalidEncodingChunks))
+	}
+	if s.CorruptedChunks > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d corrupted chunks", s.CorruptedChunks))
+	}
+	if s.OutOfOrderSamplesChunks > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d chunks with out of order samples", s.OutOfOrderSamplesChunks))
+	}
+	if s.OutsideSamplesChunks > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d chunks with samples outside of the chunk time range", s.OutsideSamplesChunks))
+	}
+	if s.InvalidCounterChunks > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d chunks of counter series downsampled with gauge semantics", s.InvalidCounterChunks))
+	}
+	if err := s.StatsMismatchErr(); err != nil {
+		errMsg = append(errMsg, err.Error())
+	}
+
+	if len(errMsg) > 0 {
+		return errors.New(strings.Join(errMsg, ", "))
+	}
+	return nil
+}
+
+// GatherChunkIssueStats iterates all samples of all chunks of the block in the given directory and returns stats
+// helping to assess health of the chunks.
+func GatherChunkIssueStats(logger log.Logger, bdir string, meta *metadata.Meta) (stats ChunkStats, err error) {
+	stats.MetaStats = meta.Stats
+
+	b, err := tsdb.OpenBlock(logger, bdir, downsample.NewPool())
+	if err != nil {
+		return stats, errors.Wrap(err, "open block")
+	}
+	defer runutil.CloseWithErrCapture(&err, b, "gather chunk issue block reader")
+
+	indexr, err := b.Index()
+	if err != nil {
+		return stats, errors.Wrap(err, "open index reader")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "gather chunk issue index reader")
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return stats, errors.Wrap(err, "open chunk reader")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkr, "gather chunk issue chunk reader")
+
+	p, err := indexr.Postings(index.AllPostingsKey())
+	if err != nil {
+		return stats, errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		lset labels.Labels
+		chks []chunks.Meta
+	)
+	for p.Next() {
+		if err := indexr.Series(p.At(), &lset, &chks); err != nil {
+			return stats, errors.Wrapf(err, "read series %d", p.At())
+		}
+		stats.TotalSeries++
+
+		for _, c := range chks {
+			stats.TotalChunks++
+
+			chk, err := chunkr.Chunk(c.Ref)
+			if err != nil {
+				stats.CorruptedChunks++
+				continue
+			}
+			gatherChunkStats(&stats, meta.Thanos.Downsample.Resolution, lset, c, chk)
+		}
+	}
+	if p.Err() != nil {
+		return stats, errors.Wrap(p.Err(), "walk postings")
+	}
+	return stats, nil
+}
+
+func gatherChunkStats(stats *ChunkStats, resolution int64, lset labels.Labels, c chunks.Meta, chk chunkenc.Chunk) {
+	if resolution == 0 {
+		if chk.Encoding() != chunkenc.EncXOR {
+			stats.InvalidEncodingChunks++
+			return
+		}
+		n, outOfOrder, outside, err := iterateChunk(chk.Iterator(nil), c.MinTime, c.MaxTime, false)
+		stats.TotalSamples += uint64(n)
+		countChunkIssues(stats, outOfOrder, outside, err)
+		return
+	}
+
+	achk, ok := chk.(*downsample.AggrChunk)
+	if !ok || chk.Encoding() != downsample.ChunkEncAggr {
+		stats.InvalidEncodingChunks++
+		return
+	}
+
+	var anyOutOfOrder, anyOutside bool
+	for _, at := range []downsample.AggrType{downsample.AggrCount, downsample.AggrSum, downsample.AggrMin, downsample.AggrMax, downsample.AggrCounter} {
+		sub, err := achk.Get(at)
+		if err == downsample.ErrAggrNotExist {
+			continue
+		}
+		if err != nil {
+			stats.CorruptedChunks++
+			return
+		}
+		// The counter aggregate repeats the timestamp of its last sample to hold the true last value.
+		n, outOfOrder, outside, err := iterateChunk(sub.Iterator(nil), c.MinTime, c.MaxTime, at == downsample.AggrCounter)
+		if err != nil {
+			stats.CorruptedChunks++
+			return
+		}
+		if at == downsample.AggrCount {
+			// Stats of downsampled blocks count samples of the count aggregate.
+			stats.TotalSamples += uint64(n)
+		}
+		anyOutOfOrder = anyOutOfOrder || outOfOrder
+		anyOutside = anyOutside || outside
+	}
+	countChunkIssues(stats, anyOutOfOrder, anyOutside, nil)
+
+	if downsample.IsCounterSeries(lset) && !validCounterAggr(achk) {
+		stats.InvalidCounterChunks++
+	}
+}
+
+// validCounterAggr returns true if the chunk has a counter aggregate which does not decrease. Only its last sample,
+// which repeats the timestamp of the previous one to hold the true last value of the raw series, may be lower.
+func validCounterAggr(achk *downsample.AggrChunk) bool {
+	c, err := achk.Get(downsample.AggrCounter)
+	if err != nil {
+		return false
+	}
+	var (
+		n     int
+		lastT int64
+		lastV float64
+		it    = c.Iterator(nil)
+	)
+	for it.Next() {
+		t, v := it.At()
+		if n > 0 && t != lastT && v < lastV {
+			return false
+		}
+		lastT, lastV = t, v
+		n++
+	}
+	return it.Err() == nil
+}
+
+func countChunkIssues(stats *ChunkStats, outOfOrder, outside bool, err error) {
+	if err != nil {
+		stats.CorruptedChunks++
+		return
+	}
+	if outOfOrder {
+		stats.OutOfOrderSamplesChunks++
+	}
+	if outside {
+		stats.OutsideSamplesChunks++
+	}
+}
+
+// iterateChunk iterates all samples and returns their number and whether any of them went back in time or was outside
+// of the given time range. If allowSameTimestamp is true, samples with the same timestamp as the previous one are not
+// considered out of order.
+func iterateChunk(it chunkenc.Iterator, mint, maxt int64, allowSameTimestamp bool) (n int, outOfOrder bool, outside bool, err error) {
+	var lastT int64
+	for it.Next() {
+		t, _ := it.At()
+		if n > 0 && (t < lastT || (t == lastT && !allowSameTimestamp)) {
+			outOfOrder = true
+		}
+		if t < mint || t > maxt {
+			outside = true
+		}
+		lastT = t
+		n++
+	}
+	return n, outOfOrder, outside, it.Err()
+}
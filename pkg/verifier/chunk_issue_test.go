@@ -0,0 +1,114 @@
+package verifier
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestGatherChunkIssueStats(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test-chunk-issue")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	series := []labels.Labels{
+		{{Name: "a", Value: "1"}},
+		{{Name: "a", Value: "2"}},
+		{{Name: "b", Value: "1"}},
+	}
+	extLset := labels.Labels{{Name: "ext1", Value: "val1"}}
+
+	id, err := testutil.CreateBlock(ctx, dir, series, 100, 0, 1000, extLset, 0)
+	testutil.Ok(t, err)
+	bdir := filepath.Join(dir, id.String())
+
+	meta, err := metadata.Read(bdir)
+	testutil.Ok(t, err)
+
+	stats, err := GatherChunkIssueStats(log.NewNopLogger(), bdir, meta)
+	testutil.Ok(t, err)
+	testutil.Ok(t, stats.AnyErr())
+	testutil.Equals(t, uint64(3), stats.TotalSeries)
+	testutil.Equals(t, meta.Stats.NumChunks, stats.TotalChunks)
+	testutil.Equals(t, meta.Stats.NumSamples, stats.TotalSamples)
+
+	// Stats of meta.json which do not match the block are detected.
+	meta.Stats.NumSamples++
+	stats, err = GatherChunkIssueStats(log.NewNopLogger(), bdir, meta)
+	testutil.Ok(t, err)
+	testutil.NotOk(t, stats.AnyErr())
+	testutil.NotOk(t, stats.StatsMismatchErr())
+
+	// Raw chunks in a block with downsampled resolution are detected.
+	id, err = testutil.CreateBlock(ctx, dir, series, 100, 0, 1000, extLset, 300000)
+	testutil.Ok(t, err)
+	bdir = filepath.Join(dir, id.String())
+
+	meta, err = metadata.Read(bdir)
+	testutil.Ok(t, err)
+
+	stats, err = GatherChunkIssueStats(log.NewNopLogger(), bdir, meta)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int(meta.Stats.NumChunks), stats.InvalidEncodingChunks)
+	testutil.NotOk(t, stats.AnyErr())
+}
+
+func TestGatherChunkStats_CounterSeries(t *testing.T) {
+	encode := func(aggrs map[downsample.AggrType][]float64) chunkenc.Chunk {
+		var chks [5]chunkenc.Chunk
+		for at, vs := range aggrs {
+			chks[at] = chunkenc.NewXORChunk()
+			app, _ := chks[at].Appender()
+			for i, v := range vs {
+				app.Append(int64(i), v)
+			}
+		}
+		return downsample.EncodeAggrChunk(chks)
+	}
+	var (
+		counter = labels.FromStrings("__name__", "a_bucket", "le", "1")
+		gauge   = labels.FromStrings("__name__", "a")
+		meta    = chunks.Meta{MinTime: 0, MaxTime: 2}
+	)
+
+	for _, tcase := range []struct {
+		lset    labels.Labels
+		aggrs   map[downsample.AggrType][]float64
+		invalid int
+	}{
+		{
+			lset:  counter,
+			aggrs: map[downsample.AggrType][]float64{downsample.AggrCount: {1, 1}, downsample.AggrCounter: {1, 3}},
+		},
+		{
+			lset:    counter,
+			aggrs:   map[downsample.AggrType][]float64{downsample.AggrCount: {1, 1}},
+			invalid: 1,
+		},
+		{
+			lset:    counter,
+			aggrs:   map[downsample.AggrType][]float64{downsample.AggrCount: {1, 1}, downsample.AggrCounter: {3, 1}},
+			invalid: 1,
+		},
+		{
+			lset:  gauge,
+			aggrs: map[downsample.AggrType][]float64{downsample.AggrCount: {1, 1}},
+		},
+	} {
+		var stats ChunkStats
+		gatherChunkStats(&stats, 300000, tcase.lset, meta, encode(tcase.aggrs))
+		testutil.Equals(t, tcase.invalid, stats.InvalidCounterChunks)
+	}
+}
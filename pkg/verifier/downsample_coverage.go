@@ -0,0 +1,116 @@
+package verifier
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+const DownsampleCoverageIssueID = "downsample_coverage"
+
+// DownsampleCoverageIssue checks bucket for raw blocks without 5m downsampled blocks, and 5m blocks without 1h
+// downsampled blocks, once they are past the downsampling threshold of their resolution.
+// Such gaps make long range queries of the downsampled resolutions slow or incomplete.
+// No repair is available for this issue, blocks are downsampled by the compactor.
+func DownsampleCoverageIssue(ctx context.Context, logger log.Logger, bkt objstore.Bucket, _ objstore.Bucket, repair bool, idMatcher func(ulid.ULID) bool) error {
+	level.Info(logger).Log("msg", "started verifying issue", "with-repair", repair, "issue", DownsampleCoverageIssueID)
+
+	var metas []*metadata.Meta
+	err := bkt.Iter(ctx, "", func(name string) error {
+		id, ok := block.IsBlockDir(name)
+		if !ok {
+			return nil
+		}
+
+		m, err := block.DownloadMeta(ctx, logger, bkt, id)
+		if err != nil {
+			return err
+		}
+		metas = append(metas, &m)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, DownsampleCoverageIssueID)
+	}
+
+	for _, d := range MissingDownsamples(metas) {
+		if idMatcher != nil && !idMatcher(d.Meta.ULID) {
+			continue
+		}
+		level.Warn(logger).Log("msg", "found block without downsampled block", "id", d.Meta.ULID, "group", compact.GroupKey(d.Meta.Thanos),
+			"mint", d.Meta.MinTime, "maxt", d.Meta.MaxTime, "missing_resolution", d.Resolution, "issue", DownsampleCoverageIssueID)
+	}
+
+	if repair {
+		level.Warn(logger).Log("msg", "repair is not implemented for this issue", "issue", DownsampleCoverageIssueID)
+	}
+	return nil
+}
+
+// MissingDownsample is a block without a downsampled block of the next resolution covering it.
+type MissingDownsample struct {
+	Meta *metadata.Meta
+	// Resolution is the missing resolution.
+	Resolution int64
+}
+
+// MissingDownsamples returns blocks past the downsampling threshold of their resolution, which are not covered by
+// blocks of the next resolution. Like in the downsampler, a block is covered once all of its sources are sources of
+// the blocks of the next resolution. Blocks with downsampling disabled by hints are skipped.
+func MissingDownsamples(metas []*metadata.Meta) (res []MissingDownsample) {
+	sources5m := map[ulid.ULID]struct{}{}
+	sources1h := map[ulid.ULID]struct{}{}
+	for _, m := range metas {
+		switch m.Thanos.Downsample.Resolution {
+		case downsample.ResLevel1:
+			for _, id := range m.Compaction.Sources {
+				sources5m[id] = struct{}{}
+			}
+		case downsample.ResLevel2:
+			for _, id := range m.Compaction.Sources {
+				sources1h[id] = struct{}{}
+			}
+		}
+	}
+
+	for _, m := range metas {
+		if m.Thanos.Hints != nil && m.Thanos.Hints.DisableDownsampling {
+			continue
+		}
+
+		var (
+			sources    map[ulid.ULID]struct{}
+			resolution int64
+		)
+		switch m.Thanos.Downsample.Resolution {
+		case downsample.ResLevel0:
+			if m.MaxTime-m.MinTime < downsample.DownsampleRange0 {
+				continue
+			}
+			sources, resolution = sources5m, downsample.ResLevel1
+		case downsample.ResLevel1:
+			if m.MaxTime-m.MinTime < downsample.DownsampleRange1 {
+				continue
+			}
+			sources, resolution = sources1h, downsample.ResLevel2
+		default:
+			continue
+		}
+
+		for _, id := range m.Compaction.Sources {
+			if _, ok := sources[id]; !ok {
+				res = append(res, MissingDownsample{Meta: m, Resolution: resolution})
+				break
+			}
+		}
+	}
+	return res
+}
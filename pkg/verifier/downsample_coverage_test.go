@@ -0,0 +1,51 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func testMeta(id uint64, resolution, mint, maxt int64, sources ...uint64) *metadata.Meta {
+	m := &metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(id, nil), MinTime: mint, MaxTime: maxt},
+		Thanos:    metadata.Thanos{Downsample: metadata.ThanosDownsample{Resolution: resolution}},
+	}
+	for _, s := range sources {
+		m.Compaction.Sources = append(m.Compaction.Sources, ulid.MustNew(s, nil))
+	}
+	return m
+}
+
+func TestMissingDownsamples(t *testing.T) {
+	var (
+		// Covered raw block.
+		raw1 = testMeta(1, downsample.ResLevel0, 0, downsample.DownsampleRange0, 1)
+		// Raw block not covered by the 5m block.
+		raw2 = testMeta(2, downsample.ResLevel0, downsample.DownsampleRange0, 2*downsample.DownsampleRange0, 2, 3)
+		// Raw block too short to be downsampled.
+		raw3 = testMeta(4, downsample.ResLevel0, 2*downsample.DownsampleRange0, 2*downsample.DownsampleRange0+1, 4)
+		// Raw block with downsampling disabled.
+		raw4 = testMeta(5, downsample.ResLevel0, 3*downsample.DownsampleRange0, 4*downsample.DownsampleRange0, 5)
+		// 5m block not covered by any 1h block.
+		res5m = testMeta(6, downsample.ResLevel1, 0, downsample.DownsampleRange1, 1, 2)
+		// 1h blocks are never downsampled further.
+		res1h = testMeta(7, downsample.ResLevel2, 0, 2*downsample.DownsampleRange1, 8)
+	)
+	raw4.Thanos.Hints = &metadata.ThanosHints{DisableDownsampling: true}
+
+	testutil.Equals(t, []MissingDownsample{
+		{Meta: raw2, Resolution: downsample.ResLevel1},
+		{Meta: res5m, Resolution: downsample.ResLevel2},
+	}, MissingDownsamples([]*metadata.Meta{raw1, raw2, raw3, raw4, res5m, res1h}))
+
+	// Covered once the 1h block has all sources of the 5m block.
+	res1h.Compaction.Sources = res5m.Compaction.Sources
+	testutil.Equals(t, []MissingDownsample{
+		{Meta: raw2, Resolution: downsample.ResLevel1},
+	}, MissingDownsamples([]*metadata.Meta{raw1, raw2, raw3, raw4, res5m, res1h}))
+}
@@ -33,13 +33,13 @@ fi
 
 CHECK=${1:-}
 
-commands=("compact" "query" "rule" "sidecar" "store" "bucket" "check")
+commands=("compact" "query" "rule" "sidecar" "store" "bucket" "check" "tools")
 
 for x in "${commands[@]}"; do
     ./thanos "${x}" --help &> "docs/components/flags/${x}.txt"
 done
 
-bucketCommands=("verify" "ls" "inspect" "web")
+bucketCommands=("verify" "ls" "inspect" "web" "replicate" "mark")
 for x in "${bucketCommands[@]}"; do
     ./thanos bucket "${x}" --help &> "docs/components/flags/bucket_${x}.txt"
 done
@@ -49,6 +49,11 @@ for x in "${checkCommands[@]}"; do
     ./thanos check "${x}" --help &> "docs/components/flags/check_${x}.txt"
 done
 
+toolsCommands=("generate-rules" "prom-migrate" "receive-rebalance")
+for x in "${toolsCommands[@]}"; do
+    ./thanos tools "${x}" --help &> "docs/components/flags/tools_${x}.txt"
+done
+
 # remove white noise
 sed -i 's/[ \t]*$//' docs/components/flags/*.txt
 
@@ -39,3 +39,15 @@ for dir in ${DIRS}; do
 		${GOIMPORTS_BIN} -w *.pb.go
 	popd
 done
+
+# Exemplars API imports StoreAPI types, so it is generated from the pkg directory.
+pushd pkg
+	${PROTOC_BIN} --gogofast_out=Mstore/storepb/types.proto=github.com/thanos-io/thanos/pkg/store/storepb,plugins=grpc:. -I=. \
+		-I="${GOGOPROTO_PATH}" \
+		exemplars/exemplarspb/*.proto
+
+	sed -i.bak -E 's/import _ \"gogoproto\"//g' exemplars/exemplarspb/*.pb.go
+	sed -i.bak -E 's/import _ \"google\/protobuf\"//g' exemplars/exemplarspb/*.pb.go
+	rm -f exemplars/exemplarspb/*.bak
+	${GOIMPORTS_BIN} -w exemplars/exemplarspb/*.pb.go
+popd